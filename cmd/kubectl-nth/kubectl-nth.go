@@ -0,0 +1,74 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-node-termination-handler/pkg/kubectlplugin"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func main() {
+	flags := flag.NewFlagSet("kubectl-nth", flag.ExitOnError)
+	namespace := flags.String("namespace", kubectlplugin.DefaultNamespace, "The namespace the handler is installed in.")
+	selector := flags.String("selector", kubectlplugin.DefaultSelector, "The label selector of the handler pods.")
+	port := flags.Int("port", kubectlplugin.DefaultPort, "The port of the handler status API.")
+	tokenSecret := flags.String("token-secret", "", "The secret in the handler namespace holding the bearer token of the status API under the token key, i.e. the Helm chart's metricsBearerTokenSecretName.")
+	token := flags.String("token", os.Getenv("NTH_TOKEN"), "The bearer token of the status API, if it isn't read from --token-secret. Defaults to the NTH_TOKEN env var.")
+	https := flags.Bool("https", false, "Reach the handler status API over HTTPS, if it is served with a TLS certificate.")
+	kubeconfig := flags.String("kubeconfig", "", "Path to the kubeconfig file. Defaults to the kubectl kubeconfig.")
+	kubeContext := flags.String("context", "", "The kubeconfig context to use.")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), kubectlplugin.Usage+"\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = *kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: *kubeContext}
+	clientConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to load the kubeconfig: %v\n", err)
+		os.Exit(1)
+	}
+	clientset, err := kubernetes.NewForConfig(clientConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create the Kubernetes client: %v\n", err)
+		os.Exit(1)
+	}
+	if *tokenSecret != "" {
+		*token, err = kubectlplugin.SecretToken(context.Background(), clientset, *namespace, *tokenSecret)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	plugin := kubectlplugin.Plugin{
+		Clientset: clientset,
+		Proxy:     kubectlplugin.APIServerProxy{Clientset: clientset, Port: *port, HTTPS: *https, Token: *token},
+		Namespace: *namespace,
+		Selector:  *selector,
+		Out:       os.Stdout,
+	}
+	if err := plugin.Run(context.Background(), flags.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
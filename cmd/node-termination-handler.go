@@ -14,31 +14,61 @@
 package main
 
 import (
+	"context"
+	goerrors "errors"
+	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
+	"regexp"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/asgreplacement"
+	"github.com/aws/aws-node-termination-handler/pkg/audit"
+	"github.com/aws/aws-node-termination-handler/pkg/awsretry"
+	"github.com/aws/aws-node-termination-handler/pkg/bottlerocket"
 	"github.com/aws/aws-node-termination-handler/pkg/config"
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
+	"github.com/aws/aws-node-termination-handler/pkg/hooks"
+	"github.com/aws/aws-node-termination-handler/pkg/httpserver"
 	"github.com/aws/aws-node-termination-handler/pkg/interruptioneventstore"
+	"github.com/aws/aws-node-termination-handler/pkg/journal"
+	"github.com/aws/aws-node-termination-handler/pkg/logging"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/pluginevent"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/rebalancerecommendation"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/scheduledevent"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/spotitn"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-node-termination-handler/pkg/nodegc"
 	"github.com/aws/aws-node-termination-handler/pkg/observability"
+	"github.com/aws/aws-node-termination-handler/pkg/parameterstore"
+	"github.com/aws/aws-node-termination-handler/pkg/pushreceiver"
+	"github.com/aws/aws-node-termination-handler/pkg/replay"
+	"github.com/aws/aws-node-termination-handler/pkg/secrets"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	"github.com/aws/aws-node-termination-handler/pkg/stepfunctions"
+	"github.com/aws/aws-node-termination-handler/pkg/streamevent"
+	"github.com/aws/aws-node-termination-handler/pkg/terminationevent"
 	"github.com/aws/aws-node-termination-handler/pkg/webhook"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/endpoints"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -46,14 +76,23 @@ import (
 )
 
 const (
-	scheduledMaintenance    = "Scheduled Maintenance"
-	spotITN                 = "Spot ITN"
-	rebalanceRecommendation = "Rebalance Recommendation"
-	sqsEvents               = "SQS Event"
-	timeFormat              = "2006/01/02 15:04:05"
-	duplicateErrThreshold   = 3
+	scheduledMaintenance        = "Scheduled Maintenance"
+	spotITN                     = "Spot ITN"
+	rebalanceRecommendation     = "Rebalance Recommendation"
+	sqsEvents                   = "SQS Event"
+	monitorPlugin               = "Monitor Plugin"
+	replayEvents                = "Replay"
+	timeFormat                  = "2006/01/02 15:04:05"
+	duplicateErrThreshold       = 3
+	capacityPollInterval        = 15 * time.Second
+	cloudWatchLogsFlushInterval = 5 * time.Second
+	eventBridgeFlushInterval    = 1 * time.Second
+	workerAutoscalingInterval   = 10 * time.Second
 )
 
+// regionPattern matches a region like us-east-1 or us-gov-west-1 in a queue URL host
+var regionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+
 func main() {
 	// Zerolog uses json formatting by default, so change that to a human-readable format instead
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: timeFormat, NoColor: true})
@@ -62,6 +101,22 @@ func main() {
 	signal.Notify(signalChan, syscall.SIGTERM)
 	defer signal.Stop(signalChan)
 
+	// the replay subcommand handles recorded events instead of monitoring for new ones
+	replaying := len(os.Args) > 1 && os.Args[1] == replay.Command
+	var replayOptions replay.Options
+	if replaying {
+		var handlerArgs []string
+		var err error
+		replayOptions, handlerArgs, err = replay.ParseArgs(os.Args[2:])
+		if goerrors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to parse replay args,")
+		}
+		os.Args = append([]string{os.Args[0]}, handlerArgs...)
+	}
+
 	nthConfig, err := config.ParseCliArgs()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to parse cli args,")
@@ -78,6 +133,67 @@ func main() {
 	case "error":
 		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
 	}
+	logging.SetSampleInterval(time.Duration(nthConfig.LogSampleInterval) * time.Second)
+
+	imds := ec2metadata.New(nthConfig.MetadataURL, nthConfig.MetadataTries)
+	nodeMetadata := imds.GetNodeMetadata()
+	// Populate the aws region if available from node metadata and not already explicitly configured
+	if nthConfig.AWSRegion == "" && nodeMetadata.Region != "" {
+		nthConfig.AWSRegion = nodeMetadata.Region
+	} else if nthConfig.AWSRegion == "" && nthConfig.QueueURL != "" {
+		nthConfig.AWSRegion = getRegionFromQueueURL(nthConfig.QueueURL)
+		log.Debug().Str("Retrieved AWS region from queue-url: \"%s\"", nthConfig.AWSRegion)
+	}
+	awsRetryer := awsretry.New(nthConfig.AWSMaxAttempts, time.Duration(nthConfig.AWSMaxBackoff)*time.Second)
+	awsConfig := newAWSConfig(nthConfig, awsRetryer)
+
+	parameterChan := make(chan map[string]string)
+	if nthConfig.SSMParameterPath != "" {
+		if nthConfig.AWSRegion == "" {
+			nthConfig.Print()
+			log.Fatal().Msg("Unable to find the AWS region to load SSM parameters.")
+		}
+		parameterStore := parameterstore.New(ssm.NewFromConfig(awsConfig), nthConfig.SSMParameterPath)
+		parameters, err := parameterStore.Load()
+		if err != nil {
+			nthConfig.Print()
+			log.Fatal().Err(err).Msg("Unable to load configuration from SSM Parameter Store,")
+		}
+		applyParameters(nthConfig.ApplyParameters, parameters)
+		if nthConfig.SSMParameterRefreshInterval > 0 && !replaying {
+			go parameterStore.Watch(time.Duration(nthConfig.SSMParameterRefreshInterval)*time.Second, parameterChan)
+		}
+	}
+
+	var replayRecords []status.Record
+	if replaying {
+		replayOptions.Configure(&nthConfig)
+		replayRecords, err = replayOptions.Load()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Unable to load the events to replay,")
+		}
+		if len(replayRecords) == 0 {
+			log.Fatal().Msg("No recorded events match the replay filters.")
+		}
+		log.Info().Bool("live", replayOptions.Live).Msgf("Replaying %d recorded event(s)", len(replayRecords))
+	}
+
+	secretProviders := []secrets.Provider{secrets.SecretsManagerProvider{SecretsManager: secretsmanager.NewFromConfig(awsConfig)}}
+	if nthConfig.VaultAddress != "" {
+		vaultProvider, err := secrets.NewVaultProvider(secrets.VaultConfig{
+			Address:       nthConfig.VaultAddress,
+			Role:          nthConfig.VaultRole,
+			AuthMountPath: nthConfig.VaultAuthPath,
+			Namespace:     nthConfig.VaultNamespace,
+			CACertFile:    nthConfig.VaultCACert,
+		})
+		if err != nil {
+			nthConfig.Print()
+			log.Fatal().Err(err).Msg("Unable to configure the Vault secret provider,")
+		}
+		secretProviders = append(secretProviders, vaultProvider)
+	}
+	secretResolver := secrets.NewResolver(time.Duration(nthConfig.SecretsRefreshInterval)*time.Second, secretProviders...)
 
 	err = webhook.ValidateWebhookConfig(nthConfig)
 	if err != nil {
@@ -90,11 +206,21 @@ func main() {
 		log.Fatal().Err(err).Msg("Unable to instantiate a node for various kubernetes node functions,")
 	}
 
-	metrics, err := observability.InitMetrics(nthConfig.EnablePrometheus, nthConfig.PrometheusPort)
+	metrics, err := observability.InitMetrics(nthConfig.EnablePrometheus, nthConfig.PrometheusPort, httpserver.NewOptions(nthConfig))
 	if err != nil {
 		nthConfig.Print()
 		log.Fatal().Err(err).Msg("Unable to instantiate observability metrics,")
 	}
+	if nthConfig.EnableCloudWatchMetrics && nthConfig.AWSRegion == "" {
+		nthConfig.Print()
+		log.Fatal().Msg("Unable to find the AWS region to publish CloudWatch metrics.")
+	}
+	metrics, err = observability.InitCloudWatchMetrics(metrics, nthConfig.EnableCloudWatchMetrics, cloudwatch.NewFromConfig(awsConfig), nthConfig.CloudWatchMetricsNamespace, nthConfig.CloudWatchMetricsDimensions, time.Duration(nthConfig.CloudWatchMetricsInterval)*time.Second)
+	if err != nil {
+		nthConfig.Print()
+		log.Fatal().Err(err).Msg("Unable to instantiate CloudWatch metrics,")
+	}
+	awsRetryer.OnThrottle(metrics.AWSThrottlesInc)
 
 	err = observability.InitProbes(nthConfig.EnableProbes, nthConfig.ProbesPort, nthConfig.ProbesEndpoint)
 	if err != nil {
@@ -102,31 +228,53 @@ func main() {
 		log.Fatal().Err(err).Msg("Unable to instantiate probes service,")
 	}
 
-	imds := ec2metadata.New(nthConfig.MetadataURL, nthConfig.MetadataTries)
-
 	interruptionEventStore := interruptioneventstore.New(nthConfig)
-	nodeMetadata := imds.GetNodeMetadata()
-	// Populate the aws region if available from node metadata and not already explicitly configured
-	if nthConfig.AWSRegion == "" && nodeMetadata.Region != "" {
-		nthConfig.AWSRegion = nodeMetadata.Region
-	} else if nthConfig.AWSRegion == "" && nthConfig.QueueURL != "" {
-		nthConfig.AWSRegion = getRegionFromQueueURL(nthConfig.QueueURL)
-		log.Debug().Str("Retrieved AWS region from queue-url: \"%s\"", nthConfig.AWSRegion)
-	}
 	if nthConfig.AWSRegion == "" && nthConfig.EnableSQSTerminationDraining {
 		nthConfig.Print()
 		log.Fatal().Msgf("Unable to find the AWS region to process queue events.")
 	}
 
-	recorder, err := observability.InitK8sEventRecorder(nthConfig.EmitKubernetesEvents, nthConfig.NodeName, nthConfig.EnableSQSTerminationDraining, nodeMetadata, nthConfig.KubernetesEventsExtraAnnotations)
+	recorder, err := observability.InitK8sEventRecorder(nthConfig.EmitKubernetesEvents, nthConfig.NodeName, nthConfig.EnableSQSTerminationDraining, nodeMetadata, nthConfig.KubernetesEventsExtraAnnotations, nthConfig.KubernetesClientConfig)
 	if err != nil {
 		nthConfig.Print()
 		log.Fatal().Err(err).Msg("Unable to create Kubernetes event recorder,")
 	}
 
+	if nthConfig.RBACSelfCheck {
+		checkPermissions(*node, nthConfig.NodeName, recorder, terminationevent.RequiredPermissions(nthConfig.EnableTerminationEventResources))
+	}
+
+	var asgReplacer *asgreplacement.Replacer
+	if nthConfig.DetachFromASG || nthConfig.WaitForRebalanceReplacement || nthConfig.RequireCapacityRebalance {
+		replacer := asgreplacement.New(autoscaling.NewFromConfig(awsConfig), *node)
+		asgReplacer = &replacer
+	}
+
+	phaseHooks := newHooks(nthConfig, awsConfig)
+
+	terminationEvents, err := terminationevent.InitRecorder(nthConfig.EnableTerminationEventResources, nthConfig.KubernetesClientConfig)
+	if err != nil {
+		nthConfig.Print()
+		log.Fatal().Err(err).Msg("Unable to create the TerminationEvent recorder,")
+	}
+	clusters := newClusters(nthConfig, nodeMetadata)
+	// the replay reports the outcome of every replayed event from the history
+	history := status.NewHistory(len(replayRecords))
+	if nthConfig.CloudWatchLogsGroup != "" || nthConfig.S3ExportBucket != "" || nthConfig.EnableEventBridgeEvents {
+		addHistorySinks(history, nthConfig, awsConfig)
+	}
+	if nthConfig.EnableStatusAPI {
+		status.Serve(nthConfig.StatusAPIPort, interruptionEventStore, history, nthConfig)
+	}
+
+	var taskCallback *stepfunctions.TaskCallback
+	if nthConfig.EnableSQSTerminationDraining {
+		taskCallback = &stepfunctions.TaskCallback{SFN: sfn.NewFromConfig(awsConfig), HeartbeatInterval: time.Duration(nthConfig.StepFunctionsHeartbeatInterval) * time.Second}
+	}
+
 	nthConfig.Print()
 
-	if nthConfig.EnableScheduledEventDraining {
+	if nthConfig.EnableScheduledEventDraining && !replaying {
 		stopCh := make(chan struct{})
 		go func() {
 			time.Sleep(8 * time.Second)
@@ -134,7 +282,7 @@ func main() {
 		}()
 		//will retry 4 times with an interval of 2 seconds.
 		err = wait.PollImmediateUntil(2*time.Second, func() (done bool, err error) {
-			err = handleRebootUncordon(nthConfig.NodeName, interruptionEventStore, *node)
+			err = handleRebootUncordon(nthConfig.NodeName, nodeMetadata.InstanceID, interruptionEventStore, *node, phaseHooks[hooks.PostUncordonPhase], metrics, recorder)
 			if err != nil {
 				log.Warn().Err(err).Msgf("Unable to complete the uncordon after reboot workflow on startup, retrying")
 			}
@@ -145,6 +293,20 @@ func main() {
 		}
 	}
 
+	if nthConfig.EventJournalFile != "" {
+		eventJournal, unfinishedEvents, err := journal.Open(nthConfig.EventJournalFile)
+		if err != nil {
+			log.Err(err).Msg("Unable to restore the unfinished events, starting with an empty event journal")
+			eventJournal = journal.New(nthConfig.EventJournalFile)
+		}
+		interruptionEventStore.SetJournal(eventJournal)
+		interruptionEventStore.Restore(unfinishedEvents)
+	}
+
+	if nthConfig.CachePrewarmTimeout > 0 {
+		prewarmCaches(nthConfig, node, clusters)
+	}
+
 	interruptionChan := make(chan monitor.InterruptionEvent)
 	defer close(interruptionChan)
 	cancelChan := make(chan monitor.InterruptionEvent)
@@ -153,27 +315,29 @@ func main() {
 	monitoringFns := map[string]monitor.Monitor{}
 	if nthConfig.EnableSpotInterruptionDraining {
 		imdsSpotMonitor := spotitn.NewSpotInterruptionMonitor(imds, interruptionChan, cancelChan, nthConfig.NodeName)
+		imdsSpotMonitor.DrainLeadTime = time.Duration(nthConfig.DrainLeadTime) * time.Second
 		monitoringFns[spotITN] = imdsSpotMonitor
 	}
 	if nthConfig.EnableScheduledEventDraining {
 		imdsScheduledEventMonitor := scheduledevent.NewScheduledEventMonitor(imds, interruptionChan, cancelChan, nthConfig.NodeName)
+		imdsScheduledEventMonitor.NotifyOnlyCodes = nthConfig.NotifyOnlyEventCodes
+		imdsScheduledEventMonitor.DrainLeadTime = time.Duration(nthConfig.ScheduledEventDrainLeadTime) * time.Second
+		if nthConfig.BottlerocketReboot {
+			imdsScheduledEventMonitor.Reboot = bottlerocketReboot(bottlerocket.New(nthConfig.BottlerocketAPISocket), nthConfig.DryRun)
+		}
 		monitoringFns[scheduledMaintenance] = imdsScheduledEventMonitor
 	}
 	if nthConfig.EnableRebalanceMonitoring || nthConfig.EnableRebalanceDraining {
 		imdsRebalanceMonitor := rebalancerecommendation.NewRebalanceRecommendationMonitor(imds, interruptionChan, nthConfig.NodeName)
+		imdsRebalanceMonitor.DrainLeadTime = time.Duration(nthConfig.DrainLeadTime) * time.Second
 		monitoringFns[rebalanceRecommendation] = imdsRebalanceMonitor
 	}
 	if nthConfig.EnableSQSTerminationDraining {
-		cfg := aws.NewConfig().WithRegion(nthConfig.AWSRegion).WithEndpoint(nthConfig.AWSEndpoint).WithSTSRegionalEndpoint(endpoints.RegionalSTSEndpoint)
-		sess := session.Must(session.NewSessionWithOptions(session.Options{
-			Config:            *cfg,
-			SharedConfigState: session.SharedConfigEnable,
-		}))
-		creds, err := sess.Config.Credentials.Get()
+		creds, err := awsConfig.Credentials.Retrieve(context.TODO())
 		if err != nil {
 			log.Fatal().Err(err).Msg("Unable to get AWS credentials")
 		}
-		log.Debug().Msgf("AWS Credentials retrieved from provider: %s", creds.ProviderName)
+		log.Debug().Msgf("AWS Credentials retrieved from provider: %s", creds.Source)
 
 		sqsMonitor := sqsevent.SQSMonitor{
 			CheckIfManaged:   nthConfig.CheckASGTagBeforeDraining,
@@ -181,11 +345,81 @@ func main() {
 			QueueURL:         nthConfig.QueueURL,
 			InterruptionChan: interruptionChan,
 			CancelChan:       cancelChan,
-			SQS:              sqs.New(sess),
-			ASG:              autoscaling.New(sess),
-			EC2:              ec2.New(sess),
+			SQS:              sqs.NewFromConfig(awsConfig),
+			ASG:              autoscaling.NewFromConfig(awsConfig),
+			EC2:              ec2.NewFromConfig(awsConfig),
+			Node:             node,
+			DrainLeadTime:    time.Duration(nthConfig.DrainLeadTime) * time.Second,
+		}
+		if nthConfig.ClusterTagKey != "" {
+			sqsMonitor.ClusterTagKey = nthConfig.ClusterTagKey
+			sqsMonitor.Clusters = clusterNodes(clusters)
 		}
-		monitoringFns[sqsEvents] = sqsMonitor
+		// pushed and streamed events are alternatives to polling the queue
+		if nthConfig.QueueURL != "" || (!nthConfig.EnablePushReceiver && nthConfig.KafkaBrokers == "" && nthConfig.NATSURL == "") {
+			monitoringFns[sqsEvents] = sqsMonitor
+		}
+		if nthConfig.EnablePushReceiver {
+			receiver := pushreceiver.Receiver{Processor: sqsMonitor, InterruptionChan: interruptionChan, Secret: nthConfig.PushReceiverSecret}
+			if nthConfig.SNSTopicARNs != "" {
+				receiver.SNSTopicARNs = strings.Split(nthConfig.SNSTopicARNs, ",")
+			}
+			receiver.Serve(nthConfig.PushReceiverPort, nthConfig.PushReceiverTLSCertFile, nthConfig.PushReceiverTLSKeyFile)
+		}
+		if nthConfig.KafkaBrokers != "" {
+			kafkaConsumer := streamevent.Consumer{
+				Name: "kafka",
+				Source: streamevent.KafkaSource{Options: streamevent.KafkaOptions{
+					Brokers:       nthConfig.KafkaBrokers,
+					Topic:         nthConfig.KafkaTopic,
+					GroupID:       nthConfig.KafkaGroupID,
+					TLS:           nthConfig.KafkaTLS,
+					TLSCAFile:     nthConfig.KafkaTLSCAFile,
+					SASLMechanism: nthConfig.KafkaSASLMechanism,
+					SASLUsername:  nthConfig.KafkaSASLUsername,
+					SASLPassword:  nthConfig.KafkaSASLPassword,
+				}},
+				Processor:        sqsMonitor,
+				InterruptionChan: interruptionChan,
+			}
+			go kafkaConsumer.Run()
+		}
+		if nthConfig.NATSURL != "" {
+			natsConsumer := streamevent.Consumer{
+				Name: "nats",
+				Source: streamevent.NATSSource{Options: streamevent.NATSOptions{
+					Servers:      nthConfig.NATSURL,
+					Subject:      nthConfig.NATSSubject,
+					Stream:       nthConfig.NATSStream,
+					ConsumerName: nthConfig.NATSConsumerName,
+					TLSCAFile:    nthConfig.NATSTLSCAFile,
+					Token:        nthConfig.NATSToken,
+					Username:     nthConfig.NATSUsername,
+					Password:     nthConfig.NATSPassword,
+				}},
+				Processor:        sqsMonitor,
+				InterruptionChan: interruptionChan,
+			}
+			go natsConsumer.Run()
+		}
+		if nthConfig.EnableWorkerAutoscaling {
+			// pushed and streamed events have no backlog to poll, so the workers follow the events received
+			backlog := func() (int, error) { return 0, nil }
+			if nthConfig.QueueURL != "" {
+				backlog = sqsMonitor.VisibleMessages
+			}
+			go interruptioneventstore.NewWorkerAutoscaler(interruptionEventStore, backlog).Run(workerAutoscalingInterval)
+		}
+	}
+	if nthConfig.MonitorPluginCommand != "" {
+		pluginMonitor := pluginevent.NewExecPluginMonitor(nthConfig.MonitorPluginCommand, time.Duration(nthConfig.MonitorPluginTimeout)*time.Second, interruptionChan, cancelChan, nthConfig.NodeName)
+		monitoringFns[monitorPlugin] = pluginMonitor
+	}
+
+	var replayMonitor *replay.Monitor
+	if replaying {
+		replayMonitor = replay.NewMonitor(replayRecords, interruptionChan, replayOptions.Speed)
+		monitoringFns = map[string]monitor.Monitor{replayEvents: replayMonitor}
 	}
 
 	for _, fn := range monitoringFns {
@@ -193,10 +427,19 @@ func main() {
 			log.Info().Str("event_type", monitor.Kind()).Msg("Started monitoring for events")
 			var previousErr error
 			var duplicateErrCount int
+			// a failing monitor reports the same error on every poll, so only changes of the error are always logged
+			var errLog logging.Sampler
+			var failing bool
 			for range time.Tick(time.Second * 2) {
 				err := monitor.Monitor()
+				if err == nil && failing {
+					log.Info().Str("event_type", monitor.Kind()).Msg("Recovered from the problem monitoring for events")
+				}
 				if err != nil {
-					log.Warn().Str("event_type", monitor.Kind()).Err(err).Msg("There was a problem monitoring for events")
+					if !failing || previousErr == nil || err.Error() != previousErr.Error() {
+						errLog.Reset()
+					}
+					errLog.Warn().Str("event_type", monitor.Kind()).Err(err).Msg("There was a problem monitoring for events")
 					metrics.ErrorEventsInc(monitor.Kind())
 					recorder.Emit(nthConfig.NodeName, observability.Warning, observability.MonitorErrReason, observability.MonitorErrMsgFmt, monitor.Kind())
 					if previousErr != nil && err.Error() == previousErr.Error() {
@@ -210,45 +453,212 @@ func main() {
 						panic(fmt.Sprintf("%v", err))
 					}
 				}
+				failing = err != nil
 			}
 		}(fn)
 	}
 
-	go watchForInterruptionEvents(interruptionChan, interruptionEventStore)
+	go watchForInterruptionEvents(interruptionChan, interruptionEventStore, node, metrics)
 	log.Info().Msg("Started watching for interruption events")
 	log.Info().Msg("Kubernetes AWS Node Termination Handler has started successfully!")
 
-	go watchForCancellationEvents(cancelChan, interruptionEventStore, node, metrics, recorder)
+	go watchForCancellationEvents(cancelChan, interruptionEventStore, node, phaseHooks[hooks.PostUncordonPhase], metrics, recorder)
 	log.Info().Msg("Started watching for event cancellations")
 
+	if nthConfig.TerminationCountdownInterval > 0 {
+		go refreshTerminationCountdowns(interruptionEventStore, node, clusterNodes(clusters), time.Duration(nthConfig.TerminationCountdownInterval)*time.Second)
+	}
+	if nthConfig.OrphanedNodeGCInterval > 0 && !replaying {
+		go nodegc.New(ec2.NewFromConfig(awsConfig), awsConfig.Region, *node, metrics, recorder).Run(time.Duration(nthConfig.OrphanedNodeGCInterval) * time.Second)
+	}
+
 	var wg sync.WaitGroup
+	// the config is shared with the goroutines started above, so refreshed webhook parameters are applied to a copy,
+	// which the events handled afterwards get. Other parameters configure the monitors and clients and need a restart.
+	configHolder := config.NewHolder(nthConfig)
 
+eventLoop:
 	for range time.NewTicker(1 * time.Second).C {
 		select {
 		case <-signalChan:
 			// Exit interruption loop if a SIGTERM is received or the channel is closed
-			break
+			break eventLoop
+		case parameters := <-parameterChan:
+			applyParameters(configHolder.RefreshParameters, parameters)
 		default:
 			for event, ok := interruptionEventStore.GetActiveEvent(); ok && !event.InProgress; event, ok = interruptionEventStore.GetActiveEvent() {
-				select {
-				case interruptionEventStore.Workers <- 1:
-					event.InProgress = true
+				if interruptionEventStore.AcquireWorker() {
+					interruptionEventStore.MarkInProgress(event)
 					wg.Add(1)
-					recorder.Emit(event.NodeName, observability.Normal, observability.GetReasonForKind(event.Kind), event.Description)
-					go drainOrCordonIfNecessary(interruptionEventStore, event, *node, nthConfig, nodeMetadata, metrics, recorder, &wg)
-				default:
+					// multi-cluster queue processors handle the event with the clients of the node's cluster
+					eventClients := clusterClients{node: node, recorder: recorder, terminationEvents: terminationEvents}
+					if clients, ok := clusters[event.Cluster]; ok {
+						eventClients = clients
+					}
+					eventClients.recorder.Emit(event.NodeName, observability.Normal, observability.GetReasonForKind(event.Kind), event.Description)
+					go drainOrCordonIfNecessary(interruptionEventStore, event, *eventClients.node, configHolder.Get(), nodeMetadata, metrics, eventClients.recorder, secretResolver, asgReplacer, phaseHooks, taskCallback, eventClients.terminationEvents, history, &wg)
+				} else {
 					log.Warn().Msg("all workers busy, waiting")
 					break
 				}
 			}
+			if replayMonitor != nil && interruptionEventStore.BusyWorkers() == 0 && replayMonitor.Finished(history.Records()) {
+				wg.Wait()
+				if err := replayMonitor.Report(os.Stdout, history.Records()); err != nil {
+					log.Err(err).Msg("Unable to write the replay report")
+				}
+				return
+			}
 		}
 	}
 	log.Info().Msg("AWS Node Termination Handler is shutting down")
 	wg.Wait()
 	log.Debug().Msg("all event processors finished")
+	// the sinks ship their records periodically, so the records of the last events are shipped before exiting
+	if err := history.Flush(); err != nil {
+		log.Warn().Err(err).Msg("Unable to ship the records of the last handled events")
+	}
+}
+
+// clusterClients are the clients of the cluster of a node
+type clusterClients struct {
+	node              *node.Node
+	recorder          observability.K8sEventRecorder
+	terminationEvents terminationevent.Recorder
 }
 
-func handleRebootUncordon(nodeName string, interruptionEventStore *interruptioneventstore.Store, node node.Node) error {
+// newClusters creates the clients of the clusters a multi-cluster queue processor serves, with their kubeconfig contexts
+func newClusters(nthConfig config.Config, nodeMetadata ec2metadata.NodeMetadata) map[string]clusterClients {
+	clusters := map[string]clusterClients{}
+	for name, kubeContext := range nthConfig.ClusterContexts {
+		clusterConfig := nthConfig
+		clusterConfig.KubeContext = kubeContext
+		clusterNode, err := node.New(clusterConfig)
+		if err != nil {
+			nthConfig.Print()
+			log.Fatal().Err(err).Msgf("Unable to instantiate a node for cluster %s,", name)
+		}
+		recorder, err := observability.InitK8sEventRecorder(clusterConfig.EmitKubernetesEvents, clusterConfig.NodeName, true, nodeMetadata, clusterConfig.KubernetesEventsExtraAnnotations, clusterConfig.KubernetesClientConfig)
+		if err != nil {
+			nthConfig.Print()
+			log.Fatal().Err(err).Msgf("Unable to create Kubernetes event recorder for cluster %s,", name)
+		}
+		terminationEvents, err := terminationevent.InitRecorder(clusterConfig.EnableTerminationEventResources, clusterConfig.KubernetesClientConfig)
+		if err != nil {
+			nthConfig.Print()
+			log.Fatal().Err(err).Msgf("Unable to create the TerminationEvent recorder for cluster %s,", name)
+		}
+		log.Info().Str("cluster", name).Str("kube_context", kubeContext).Msg("Serving cluster")
+		clusters[name] = clusterClients{node: clusterNode, recorder: recorder, terminationEvents: terminationEvents}
+	}
+	return clusters
+}
+
+func clusterNodes(clusters map[string]clusterClients) map[string]*node.Node {
+	nodes := map[string]*node.Node{}
+	for name, clients := range clusters {
+		nodes[name] = clients.node
+	}
+	return nodes
+}
+
+// prewarmCaches waits for the pods to be cached and resolves the nodes of the instances before events are consumed, so
+// the first event after a restart doesn't race its deadline while the caches fill
+func prewarmCaches(nthConfig config.Config, handlerNode *node.Node, clusters map[string]clusterClients) {
+	timeout := time.Duration(nthConfig.CachePrewarmTimeout) * time.Second
+	start := time.Now()
+	nodes := []*node.Node{handlerNode}
+	for _, clients := range clusters {
+		nodes = append(nodes, clients.node)
+	}
+	for _, n := range nodes {
+		if !n.WaitForCacheSync(timeout) {
+			log.Warn().Msgf("The pod cache did not sync within %s, pods are listed until it does", timeout)
+		}
+		// queue processors resolve the node of each event's instance
+		if !nthConfig.EnableSQSTerminationDraining {
+			continue
+		}
+		instances, err := n.ResolveInstances()
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to prewarm the nodes of the instances, they are resolved with the first event")
+			continue
+		}
+		log.Debug().Msgf("Resolved the nodes of %d instance(s)", instances)
+	}
+	log.Info().Msgf("Prewarmed the caches in %s", time.Since(start).Round(time.Millisecond))
+}
+
+// addHistorySinks ships the records of handled events to CloudWatch Logs, S3 and EventBridge, as configured
+func addHistorySinks(history *status.History, nthConfig config.Config, awsConfig aws.Config) {
+	if nthConfig.AWSRegion == "" {
+		nthConfig.Print()
+		log.Fatal().Msg("Unable to find the AWS region to export the event history.")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		nthConfig.Print()
+		log.Fatal().Err(err).Msg("Unable to determine the hostname to export the event history as,")
+	}
+	if nthConfig.CloudWatchLogsGroup != "" {
+		stream := nthConfig.CloudWatchLogsStream
+		if stream == "" {
+			stream = hostname
+		}
+		sink := audit.NewCloudWatchLogsSink(cloudwatchlogs.NewFromConfig(awsConfig), nthConfig.CloudWatchLogsGroup, stream)
+		sink.Start(cloudWatchLogsFlushInterval)
+		history.AddSink(sink)
+	}
+	if nthConfig.S3ExportBucket != "" {
+		sink := audit.NewS3Sink(s3.NewFromConfig(awsConfig), nthConfig.S3ExportBucket, nthConfig.S3ExportPrefix, nthConfig.S3ExportClusterName, hostname)
+		sink.Start(time.Duration(nthConfig.S3ExportInterval) * time.Second)
+		history.AddSink(sink)
+	}
+	if nthConfig.EnableEventBridgeEvents {
+		sink := audit.NewEventBridgeSink(eventbridge.NewFromConfig(awsConfig), nthConfig.EventBridgeBusName, nthConfig.EventBridgeSource)
+		sink.Start(eventBridgeFlushInterval)
+		history.AddSink(sink)
+	}
+}
+
+// newAWSConfig loads the shared AWS config with the configured region, using the configured endpoint for every service if set.
+// Every client retries with the retryer, which backs off the clients of a service together when it throttles.
+func newAWSConfig(nthConfig config.Config, retryer aws.Retryer) aws.Config {
+	options := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(nthConfig.AWSRegion),
+		awsconfig.WithRetryer(func() aws.Retryer { return retryer }),
+	}
+	if nthConfig.AWSUseFIPSEndpoint {
+		options = append(options, awsconfig.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	if nthConfig.AWSUseDualStackEndpoint {
+		options = append(options, awsconfig.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled))
+	}
+	// the endpoint override takes precedence over the FIPS and dual-stack endpoints
+	if nthConfig.AWSEndpoint != "" {
+		resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{URL: nthConfig.AWSEndpoint, SigningRegion: region}, nil
+		})
+		options = append(options, awsconfig.WithEndpointResolverWithOptions(resolver))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.TODO(), options...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Unable to load the AWS config")
+	}
+	return awsConfig
+}
+
+func applyParameters(apply func(parameters map[string]string) ([]string, error), parameters map[string]string) {
+	applied, err := apply(parameters)
+	if err != nil {
+		log.Warn().Err(err).Msg("There was a problem applying configuration from SSM Parameter Store")
+	}
+	if len(applied) > 0 {
+		log.Info().Strs("parameters", applied).Msg("Applied configuration from SSM Parameter Store")
+	}
+}
+
+func handleRebootUncordon(nodeName string, instanceID string, interruptionEventStore *interruptioneventstore.Store, node node.Node, postUncordonHook hooks.Hook, metrics observability.Metrics, recorder observability.K8sEventRecorder) error {
 	isLabeled, err := node.IsLabeledWithAction(nodeName)
 	if err != nil {
 		return err
@@ -260,34 +670,84 @@ func handleRebootUncordon(nodeName string, interruptionEventStore *interruptione
 	if err != nil {
 		return err
 	}
-	err = node.UncordonIfRebooted(nodeName)
+	wasUnschedulable, err := node.IsUnschedulable(nodeName)
+	if err != nil {
+		return err
+	}
+	err = node.UncordonIfRestarted(nodeName, instanceID)
 	if err != nil {
 		return fmt.Errorf("Unable to complete node label actions: %w", err)
 	}
+	if unschedulable, err := node.IsUnschedulable(nodeName); err == nil && wasUnschedulable && !unschedulable {
+		uncordonEvent := monitor.InterruptionEvent{EventID: eventID, Kind: scheduledevent.ScheduledEventKind, NodeName: nodeName, InstanceID: instanceID}
+		runHook(interruptionEventStore, postUncordonHook, hooks.PostUncordonPhase, &uncordonEvent, metrics, recorder)
+	}
 	interruptionEventStore.IgnoreEvent(eventID)
 	return nil
 }
 
-func watchForInterruptionEvents(interruptionChan <-chan monitor.InterruptionEvent, interruptionEventStore *interruptioneventstore.Store) {
+func checkPermissions(node node.Node, nodeName string, recorder observability.K8sEventRecorder, extraPermissions []node.Permission) {
+	missing, err := node.CheckPermissions(extraPermissions...)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to verify kubernetes RBAC permissions")
+		return
+	}
+	if len(missing) == 0 {
+		log.Info().Msg("All required kubernetes RBAC permissions are granted")
+		return
+	}
+	missingPermissions := make([]string, len(missing))
+	for i, permission := range missing {
+		missingPermissions[i] = permission.String()
+		log.Error().Str("permission", missingPermissions[i]).Msg("Missing required kubernetes RBAC permission")
+	}
+	recorder.Emit(nodeName, observability.Warning, observability.MissingPermissionsReason, observability.MissingPermissionsMsgFmt, strings.Join(missingPermissions, ", "))
+}
+
+func watchForInterruptionEvents(interruptionChan <-chan monitor.InterruptionEvent, interruptionEventStore *interruptioneventstore.Store, node *node.Node, metrics observability.Metrics) {
 	for {
 		interruptionEvent := <-interruptionChan
+		// monitors report events again until they expire, so only the first report is counted
+		if !interruptionEventStore.HasEvent(interruptionEvent.EventID) {
+			metrics.InterruptionEventsInc(interruptionEvent.Kind)
+		}
+		if interruptionEvent.Kind == scheduledevent.ScheduledEventKind && !interruptionEvent.DrainTime.IsZero() && !interruptionEventStore.HasEvent(interruptionEvent.EventID) {
+			drainTime, err := node.ScheduleDrain(interruptionEvent.NodeName, interruptionEvent.EventID, interruptionEvent.DrainTime)
+			if err != nil {
+				log.Warn().Err(err).Msg("Unable to persist the scheduled drain time on the node")
+			}
+			log.Info().Str("event_id", interruptionEvent.EventID).Msgf("Drain scheduled for %s", drainTime)
+			interruptionEvent.DrainTime = drainTime
+		}
+		if interruptionEventStore.NthConfig.MaxDrainsPerAZ > 0 && interruptionEvent.AvailabilityZone == "" && !interruptionEventStore.HasEvent(interruptionEvent.EventID) {
+			zone, err := node.GetNodeZone(interruptionEvent.NodeName)
+			if err != nil {
+				log.Warn().Err(err).Str("node_name", interruptionEvent.NodeName).Msg("Unable to get the availability zone of the node, its drain is not paced")
+			}
+			interruptionEvent.AvailabilityZone = zone
+		}
 		interruptionEventStore.AddInterruptionEvent(&interruptionEvent)
 	}
 }
 
-func watchForCancellationEvents(cancelChan <-chan monitor.InterruptionEvent, interruptionEventStore *interruptioneventstore.Store, node *node.Node, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
+func watchForCancellationEvents(cancelChan <-chan monitor.InterruptionEvent, interruptionEventStore *interruptioneventstore.Store, node *node.Node, postUncordonHook hooks.Hook, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
 	for {
 		interruptionEvent := <-cancelChan
 		nodeName := interruptionEvent.NodeName
 		interruptionEventStore.CancelInterruptionEvent(interruptionEvent.EventID)
-		if interruptionEventStore.ShouldUncordonNode(nodeName) {
+		if interruptionEventStore.ShouldUncordonNode(interruptionEvent.NodeKey()) {
 			log.Info().Msg("Uncordoning the node due to a cancellation event")
+			// canceled events are reported until they expire, so hooks only run when the node was actually cordoned
+			wasUnschedulable, _ := node.IsUnschedulable(nodeName)
 			err := node.Uncordon(nodeName)
 			if err != nil {
 				log.Err(err).Msg("Uncordoning the node failed")
 				recorder.Emit(nodeName, observability.Warning, observability.UncordonErrReason, observability.UncordonErrMsgFmt, err.Error())
 			} else {
 				recorder.Emit(nodeName, observability.Normal, observability.UncordonReason, observability.UncordonMsg)
+				if wasUnschedulable {
+					runHook(interruptionEventStore, postUncordonHook, hooks.PostUncordonPhase, &interruptionEvent, metrics, recorder)
+				}
 			}
 			metrics.NodeActionsInc("uncordon", nodeName, err)
 
@@ -306,48 +766,600 @@ func watchForCancellationEvents(cancelChan <-chan monitor.InterruptionEvent, int
 	}
 }
 
-func drainOrCordonIfNecessary(interruptionEventStore *interruptioneventstore.Store, drainEvent *monitor.InterruptionEvent, node node.Node, nthConfig config.Config, nodeMetadata ec2metadata.NodeMetadata, metrics observability.Metrics, recorder observability.K8sEventRecorder, wg *sync.WaitGroup) {
+// countdownNode is a node with a termination countdown, in the cluster of its events
+type countdownNode struct {
+	cluster string
+	name    string
+}
+
+// refreshTerminationCountdowns keeps the termination countdown annotations of the nodes with interruption events up to
+// date until their deadline passed, and removes them once the events are gone. The nodes of multi-cluster queue
+// processors are annotated in their cluster.
+func refreshTerminationCountdowns(interruptionEventStore *interruptioneventstore.Store, defaultNode *node.Node, clusters map[string]*node.Node, interval time.Duration) {
+	nodeOf := func(countdown countdownNode) *node.Node {
+		if clusterNode, ok := clusters[countdown.cluster]; ok {
+			return clusterNode
+		}
+		return defaultNode
+	}
+	annotated := map[countdownNode]bool{}
+	for range time.NewTicker(interval).C {
+		now := time.Now()
+		deadlines := terminationDeadlines(interruptionEventStore.Snapshot())
+		for countdown, deadline := range deadlines {
+			// the countdown is refreshed once more after the deadline to show it ran out
+			if deadline.Before(now.Add(-interval)) {
+				continue
+			}
+			err := nodeOf(countdown).UpdateTerminationCountdown(countdown.name, deadline, now)
+			if err != nil {
+				log.Warn().Err(err).Str("node_name", countdown.name).Str("cluster", countdown.cluster).Msg("Unable to update the termination countdown of the node")
+				continue
+			}
+			annotated[countdown] = true
+		}
+		for countdown := range annotated {
+			if _, ok := deadlines[countdown]; ok {
+				continue
+			}
+			err := nodeOf(countdown).RemoveTerminationCountdown(countdown.name)
+			if err != nil {
+				log.Warn().Err(err).Str("node_name", countdown.name).Str("cluster", countdown.cluster).Msg("Unable to remove the termination countdown of the node")
+				continue
+			}
+			delete(annotated, countdown)
+		}
+	}
+}
+
+// terminationDeadlines returns the earliest deadline of the interruption events of each node. Rebalance recommendations
+// don't terminate the instance and have no deadline.
+func terminationDeadlines(events []monitor.InterruptionEvent) map[countdownNode]time.Time {
+	deadlines := map[countdownNode]time.Time{}
+	for i := range events {
+		event := &events[i]
+		if event.NodeName == "" || event.StartTime.IsZero() || event.IsRebalanceRecommendation() {
+			continue
+		}
+		countdown := countdownNode{cluster: event.Cluster, name: event.NodeName}
+		if deadline, ok := deadlines[countdown]; !ok || event.StartTime.Before(deadline) {
+			deadlines[countdown] = event.StartTime
+		}
+	}
+	return deadlines
+}
+
+// withMappedEvictionRetryPolicy returns a copy of the node which retries blocked evictions as set in the action mapping
+func withMappedEvictionRetryPolicy(n node.Node, mapping config.ActionMapping) node.Node {
+	return n.WithEvictionRetryPolicy(node.EvictionRetryPolicy{
+		Attempts:   mapping.EvictionRetryAttempts,
+		Interval:   time.Duration(mapping.EvictionRetryInterval) * time.Second,
+		Escalation: mapping.EvictionRetryEscalation,
+	})
+}
+
+func drainOrCordonIfNecessary(interruptionEventStore *interruptioneventstore.Store, drainEvent *monitor.InterruptionEvent, node node.Node, nthConfig config.Config, nodeMetadata ec2metadata.NodeMetadata, metrics observability.Metrics, recorder observability.K8sEventRecorder, secretResolver *secrets.Resolver, asgReplacer *asgreplacement.Replacer, phaseHooks map[string]hooks.Hook, taskCallback *stepfunctions.TaskCallback, terminationEvents terminationevent.Recorder, history *status.History, wg *sync.WaitGroup) {
 	defer wg.Done()
 	nodeName := drainEvent.NodeName
+	defer interruptionEventStore.ReleaseNode(drainEvent.NodeKey())
+	instanceID := drainEvent.InstanceID
+	if instanceID == "" && (!nthConfig.EnableSQSTerminationDraining || nthConfig.EnableCombinedMode && nodeName == nthConfig.NodeName) {
+		instanceID = nodeMetadata.InstanceID
+		// hooks act on the instance, which IMDS monitors don't set on their events
+		interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.InstanceID = instanceID })
+	}
+	// abortErr is set when a hook aborted handling the event, drainErr when the drain failed
+	var abortErr, drainErr error
+	// action is the action decided for the node, or why none was taken
+	var action string
+	// the summary of a failed attempt is replaced by the one of the retry
+	interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) {
+		e.HookOutcomes = nil
+		e.DrainDuration = 0
+	})
+	handlingStartedAt := time.Now()
+	startedAt := terminationEvents.Start(*drainEvent)
+	history.Start(*drainEvent, terminationevent.PhaseDraining)
+	defer func() {
+		phase := handlingPhase(abortErr, drainErr)
+		terminationEvents.Finish(*drainEvent, phase, action, startedAt, firstError(abortErr, drainErr))
+		history.Finish(*drainEvent, phase, action, firstError(abortErr, drainErr))
+		logHandlingSummary(*drainEvent, phase, action, time.Since(handlingStartedAt), firstError(abortErr, drainErr))
+	}()
+	if taskCallback != nil && drainEvent.TaskToken != "" {
+		stopHeartbeat := taskCallback.StartHeartbeat(*drainEvent)
+		defer func() {
+			stopHeartbeat()
+			// the store hands failed drains out again after a backoff, so the task of the event is only completed once
+			// the event was processed, and failed when handling it was aborted or its last attempt failed
+			if drainEvent.NodeProcessed {
+				completeTask(*taskCallback, *drainEvent, firstError(abortErr, drainErr))
+			}
+		}()
+	}
 	nodeLabels, err := node.GetNodeLabels(nodeName)
 	if err != nil {
 		log.Err(err).Msgf("Unable to fetch node labels for node '%s' ", nodeName)
 	}
-	drainEvent.NodeLabels = nodeLabels
-	if drainEvent.PreDrainTask != nil {
-		runPreDrainTask(node, nodeName, drainEvent, metrics, recorder)
+	interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.NodeLabels = nodeLabels })
+	if !node.InCanary(nodeName, nodeLabels) {
+		log.Info().Str("node_name", nodeName).Str("event_id", drainEvent.EventID).Str("kind", drainEvent.Kind).Msg("Node is outside of the canary, only logging the event")
+		action = "canary-log-only"
+		acknowledgeEvents(node, interruptionEventStore.MarkAllAsProcessed(drainEvent.NodeKey()), metrics, recorder)
+		<-interruptionEventStore.Workers
+		return
+	}
+	isKarpenterNode := false
+	if nthConfig.KarpenterNodeHandling != config.KarpenterNodeHandlingDrain {
+		isKarpenterNode, err = node.IsKarpenterManaged(nodeName)
+		if err != nil {
+			log.Err(err).Msgf("Unable to determine if node '%s' is managed by karpenter", nodeName)
+		}
+	}
+	if isKarpenterNode && nthConfig.KarpenterNodeHandling == config.KarpenterNodeHandlingSkip {
+		log.Info().Str("node_name", nodeName).Msg("Node is managed by karpenter's interruption handling, skipping")
+		action = "skip-karpenter"
+		acknowledgeEvents(node, interruptionEventStore.MarkAllAsProcessed(drainEvent.NodeKey()), metrics, recorder)
+		<-interruptionEventStore.Workers
+		return
+	}
+	drainOnly := false
+	if nthConfig.CordonedNodeHandling != config.CordonedNodeHandlingLayer {
+		cordonedBy, err := node.CordonedByOtherController(nodeName)
+		if err != nil {
+			log.Err(err).Msgf("Unable to determine if node '%s' is cordoned by another controller", nodeName)
+		} else if cordonedBy != "" {
+			switch nthConfig.CordonedNodeHandling {
+			case config.CordonedNodeHandlingSkip:
+				log.Info().Str("node_name", nodeName).Str("cordoned_by", cordonedBy).Msg("Node is already cordoned by another controller, skipping")
+				action = "skip-already-cordoned"
+				acknowledgeEvents(node, interruptionEventStore.MarkAllAsProcessed(drainEvent.NodeKey()), metrics, recorder)
+				<-interruptionEventStore.Workers
+				return
+			case config.CordonedNodeHandlingDrainOnly:
+				log.Info().Str("node_name", nodeName).Str("cordoned_by", cordonedBy).Msg("Node is already cordoned by another controller, only draining it")
+				drainOnly = true
+				node = node.WithDrainOnly()
+			case config.CordonedNodeHandlingAdopt:
+				log.Info().Str("node_name", nodeName).Str("cordoned_by", cordonedBy).Msg("Node is already cordoned by another controller, adopting it")
+			}
+			if err := node.AdoptCordonedNode(nodeName, cordonedBy); err != nil {
+				log.Warn().Err(err).Msg("Unable to record that the node is cordoned by another controller, it may be uncordoned after the event")
+			}
+		}
+	}
+	mapping, hasMapping := nthConfig.ActionMappingFor(drainEvent.Kind, drainEvent.Code)
+	if hasMapping {
+		log.Info().Str("node_name", nodeName).Str("event_id", drainEvent.EventID).Msgf("Event is mapped to the %s action", mapping.Action)
+		interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.NotifyOnly = mapping.Action == config.ActionNotify })
+		action = strings.ToLower(mapping.Action)
+		switch mapping.Action {
+		case config.ActionNoOp:
+			interruptionEventStore.MarkAsProcessed(drainEvent)
+			acknowledgeEvent(node, drainEvent, metrics, recorder)
+			<-interruptionEventStore.Workers
+			return
+		case config.ActionTaint:
+			if drainEvent.PreDrainTask != nil {
+				runPreDrainTask(node.WithTaintNode(), nodeName, drainEvent, metrics, recorder)
+			}
+			sendWebhook(nthConfig, nodeMetadata, drainEvent, secretResolver)
+			interruptionEventStore.MarkAsProcessed(drainEvent)
+			acknowledgeEvent(node, drainEvent, metrics, recorder)
+			<-interruptionEventStore.Workers
+			return
+		}
+		if mapping.Timeout > 0 {
+			node = node.WithDrainTimeout(time.Duration(mapping.Timeout) * time.Second)
+		}
+		if mapping.EvictionRetryAttempts > 0 {
+			node = withMappedEvictionRetryPolicy(node, mapping)
+		}
+	}
+	if drainEvent.NotifyOnly {
+		log.Info().Str("node_name", nodeName).Str("event_id", drainEvent.EventID).Msg("Event is configured to only send notifications, not cordoning or draining the node")
+		action = "notify"
+		sendWebhook(nthConfig, nodeMetadata, drainEvent, secretResolver)
+		interruptionEventStore.MarkAsProcessed(drainEvent)
+		acknowledgeEvent(node, drainEvent, metrics, recorder)
+		<-interruptionEventStore.Workers
+		return
+	}
+	// drainMarked is set once the node is annotated as drained, which replaces the claim of the drain
+	drainMarked := false
+	if nthConfig.EnableCombinedMode {
+		claimed, err := node.ClaimDrain(nodeName, time.Duration(nthConfig.DuplicateEventWindow)*time.Second, drainEvent.IsRebalanceRecommendation())
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to claim draining the node, handling the event")
+		} else if claimed {
+			defer func() {
+				if drainMarked {
+					return
+				}
+				if err := node.ReleaseDrainClaim(nodeName); err != nil {
+					log.Warn().Err(err).Msg("Unable to release the claim of draining the node, other reports of the interruption wait for it to expire")
+				}
+			}()
+		} else {
+			// the instance metadata and the queue report the same interruption, so only the lifecycle action is completed
+			log.Info().Str("node_name", nodeName).Str("event_id", drainEvent.EventID).Msg("Node was already drained for another report of the interruption, not draining it again")
+			action = "duplicate"
+			interruptionEventStore.MarkAsProcessed(drainEvent)
+			if drainEvent.PostDrainTask != nil {
+				runPostDrainTask(node, nodeName, drainEvent, metrics, recorder)
+			}
+			<-interruptionEventStore.Workers
+			return
+		}
+	}
+	if nthConfig.RequireCapacityRebalance && drainEvent.IsRebalanceRecommendation() && asgReplacer != nil && instanceID != "" {
+		enabled, asgName, err := asgReplacer.IsCapacityRebalanceEnabled(instanceID)
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to determine if Capacity Rebalancing is enabled, handling the rebalance recommendation")
+		} else if !enabled {
+			log.Info().Str("node_name", nodeName).Str("asg_name", asgName).Msg("Capacity Rebalancing is not enabled on the Auto Scaling Group, so no replacement is coming. Ignoring the rebalance recommendation")
+			action = "skip-no-capacity-rebalance"
+			interruptionEventStore.MarkAsProcessed(drainEvent)
+			acknowledgeEvent(node, drainEvent, metrics, recorder)
+			<-interruptionEventStore.Workers
+			return
+		}
 	}
-
 	podNameList, err := node.FetchPodNameList(nodeName)
 	if err != nil {
 		log.Err(err).Msgf("Unable to fetch running pods for node '%s' ", nodeName)
 	}
-	drainEvent.Pods = podNameList
+	interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.Pods = podNameList })
 	err = node.LogPods(podNameList, nodeName)
 	if err != nil {
 		log.Err(err).Msg("There was a problem while trying to log all pod names on the node")
 	}
+	// the hook runs before the pre-drain task, so an aborted event leaves the node untainted
+	err = runHook(interruptionEventStore, phaseHooks[hooks.PreDrainPhase], hooks.PreDrainPhase, drainEvent, metrics, recorder)
+	if goerrors.Is(err, hooks.ErrAbort) {
+		log.Info().Str("event_id", drainEvent.EventID).Msgf("Not draining node %s, the pre-drain hook aborted handling the event", nodeName)
+		action = "abort"
+		interruptionEventStore.MarkAsProcessed(drainEvent)
+		acknowledgeEvent(node, drainEvent, metrics, recorder)
+		abortErr = err
+		<-interruptionEventStore.Workers
+		return
+	}
+	err = node.SetInterruptionCondition(nodeName, observability.GetNodeConditionTypeForEvent(drainEvent), drainEvent.Description)
+	if err != nil {
+		log.Err(err).Msgf("Unable to publish interruption condition on node '%s'", nodeName)
+	}
+	if drainEvent.PreDrainTask != nil {
+		runPreDrainTask(node, nodeName, drainEvent, metrics, recorder)
+	}
+	drainedWorkloads := drainedWorkloadsToVerify(node, nodeName, nthConfig)
 
-	if nthConfig.CordonOnly || (!nthConfig.EnableSQSTerminationDraining && drainEvent.IsRebalanceRecommendation() && !nthConfig.EnableRebalanceDraining) {
+	if asgReplacer != nil {
+		replacementWaitTimeout := time.Duration(nthConfig.ReplacementWaitTimeout) * time.Second
+		if nthConfig.DetachFromASG && isTerminatingEvent(drainEvent) {
+			detachAndWaitForReplacement(*asgReplacer, nodeName, instanceID, replacementWaitTimeout, metrics)
+		} else if nthConfig.WaitForRebalanceReplacement && drainEvent.IsRebalanceRecommendation() {
+			err = asgReplacer.WaitForRebalanceReplacement(instanceID, nodeName, drainEvent.StartTime, replacementWaitTimeout)
+			if err != nil {
+				log.Warn().Err(err).Msg("Draining without replacement capacity")
+			}
+		}
+	}
+
+	if nthConfig.CapacityAwareRebalanceDrain && drainEvent.IsRebalanceRecommendation() && !hasMapping && !nthConfig.CordonOnly &&
+		(nthConfig.EnableRebalanceDraining || nthConfig.EnableSQSTerminationDraining) {
+		deferDrainUntilCapacity(node, nodeName, time.Duration(nthConfig.DrainDeferralTimeout)*time.Second, metrics, recorder)
+	}
+
+	if nthConfig.EnableTerminationNotices && !nthConfig.CordonOnly {
+		sendTerminationNotices(node, nodeName, drainEvent, time.Duration(nthConfig.TerminationNoticeDelay)*time.Second)
+	}
+	if nthConfig.EnableContainerCheckpoints && !nthConfig.CordonOnly {
+		checkpoints := node.CheckpointContainers(nodeName, drainEvent.StartTime)
+		interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.Checkpoints = checkpoints })
+	}
+
+	var evictionFailuresMutex sync.Mutex
+	evictionFailures := map[string]int{}
+	node = node.WithEvictionFailureHandler(func(reason string) {
+		metrics.EvictionFailuresInc(reason, nodeName)
+		evictionFailuresMutex.Lock()
+		evictionFailures[reason]++
+		evictionFailuresMutex.Unlock()
+	})
+
+	drainStartedAt := time.Now()
+	if hasMapping {
+		err = runMappedAction(mapping.Action, node, nodeName, drainEvent, metrics, recorder, nthConfig.EnableSQSTerminationDraining)
+	} else if isKarpenterNode && !drainEvent.IsStopOrHibernate() {
+		// stopped and hibernated instances come back, so their node objects are kept
+		action = "karpenter-delete"
+		err = deleteKarpenterNode(node, nodeName, metrics, recorder)
+	} else if nthConfig.SpotStopHibernateAction == config.SpotStopHibernateActionCordon && drainEvent.IsStopOrHibernate() && drainEvent.Kind != scheduledevent.ScheduledEventKind {
+		action = "cordon"
+		err = cordonNode(node, nodeName, drainEvent, metrics, recorder)
+	} else if nthConfig.AcceleratorEventAction == config.AcceleratorEventActionEvictAcceleratorPods && drainEvent.IsAcceleratorEvent(nthConfig.AcceleratorEventKeywords) {
+		action = "cordon-and-evict-accelerator-pods"
+		err = cordonAndEvictAcceleratorPods(node, nodeName, metrics, recorder)
+	} else if nthConfig.CordonOnly || (!nthConfig.EnableSQSTerminationDraining && drainEvent.IsRebalanceRecommendation() && !nthConfig.EnableRebalanceDraining) {
+		action = "cordon"
 		err = cordonNode(node, nodeName, drainEvent, metrics, recorder)
 	} else {
+		action = "cordon-and-drain"
 		err = cordonAndDrainNode(node, nodeName, metrics, recorder, nthConfig.EnableSQSTerminationDraining)
 	}
-
-	if nthConfig.WebhookURL != "" {
-		webhook.Post(nodeMetadata, drainEvent, nthConfig)
+	drainDuration := time.Since(drainStartedAt)
+	interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.DrainDuration = drainDuration })
+	if drainOnly && action == "cordon-and-drain" {
+		action = "drain"
+	}
+	if len(evictionFailures) > 0 {
+		// evictions are done, so the map is no longer changed once it is shared with the store and the journal
+		interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.EvictionFailures = evictionFailures })
+		log.Warn().Str("node_name", nodeName).Interface("eviction_failures", evictionFailures).Msg("Evicting pods failed")
+	}
+	if err != nil && !nthConfig.CordonOnly {
+		blockingPDBs := reportBlockingPDBs(node, nodeName, metrics, recorder)
+		interruptionEventStore.UpdateEvent(drainEvent, func(e *monitor.InterruptionEvent) { e.BlockingPDBs = blockingPDBs })
 	}
 
+	sendWebhook(nthConfig, nodeMetadata, drainEvent, secretResolver)
+
 	if err != nil {
+		drainErr = err
+		// a node which is gone can't be drained by retrying
+		if !interruptionEventStore.RecordFailedAttempt(drainEvent, errors.IsNotFound(err)) {
+			log.Warn().Str("node_name", nodeName).Str("event_id", drainEvent.EventID).Msg("Giving up on the event after its drain failed")
+		}
 		<-interruptionEventStore.Workers
 	} else {
-		interruptionEventStore.MarkAllAsProcessed(nodeName)
+		if nthConfig.EnableCombinedMode && (action == "cordon-and-drain" || action == "drain") {
+			if err := node.MarkDrained(nodeName, drainEvent.IsRebalanceRecommendation()); err != nil {
+				log.Warn().Err(err).Msg("Unable to mark the node as drained, other reports of the interruption drain it again")
+			} else {
+				drainMarked = true
+			}
+		}
+		mergedEvents := interruptionEventStore.MergeableEvents(drainEvent)
+		interruptionEventStore.MarkAsProcessed(append(mergedEvents, drainEvent)...)
 		if drainEvent.PostDrainTask != nil {
 			runPostDrainTask(node, nodeName, drainEvent, metrics, recorder)
 		}
+		runHook(interruptionEventStore, phaseHooks[hooks.PostDrainPhase], hooks.PostDrainPhase, drainEvent, metrics, recorder)
+		completeMergedEvents(interruptionEventStore, mergedEvents, drainEvent, node, nthConfig, nodeMetadata, metrics, recorder, secretResolver, phaseHooks[hooks.PostDrainPhase], taskCallback, terminationEvents, history, startedAt)
 		<-interruptionEventStore.Workers
+		if drainedWorkloads != nil && (action == "cordon-and-drain" || action == "drain" || action == "drainanddeletenode") {
+			// the worker and the node are released first, so waiting for the workloads holds back neither the drains
+			// of other nodes nor the node's other events. The outcome is recorded when handling the event finishes.
+			interruptionEventStore.ReleaseNode(drainEvent.NodeKey())
+			verifyRescheduling(interruptionEventStore, node, nodeName, drainEvent, drainedWorkloads, time.Duration(nthConfig.RescheduleVerificationTimeout)*time.Second, metrics, recorder)
+		}
+	}
+
+}
+
+// completeMergedEvents sends the webhooks and runs the post-drain tasks of the node's other due events, which were
+// satisfied by handling drainEvent, so their lifecycle hooks and queue messages are completed as well
+func completeMergedEvents(interruptionEventStore *interruptioneventstore.Store, mergedEvents []*monitor.InterruptionEvent, drainEvent *monitor.InterruptionEvent, node node.Node, nthConfig config.Config, nodeMetadata ec2metadata.NodeMetadata, metrics observability.Metrics, recorder observability.K8sEventRecorder, secretResolver *secrets.Resolver, postDrainHook hooks.Hook, taskCallback *stepfunctions.TaskCallback, terminationEvents terminationevent.Recorder, history *status.History, startedAt time.Time) {
+	for _, mergedEvent := range mergedEvents {
+		log.Info().Str("node_name", mergedEvent.NodeName).Str("event_id", mergedEvent.EventID).Msgf("Event was handled together with event %s", drainEvent.EventID)
+		interruptionEventStore.UpdateEvent(mergedEvent, func(e *monitor.InterruptionEvent) {
+			e.NodeLabels = drainEvent.NodeLabels
+			e.Pods = drainEvent.Pods
+		})
+		sendWebhook(nthConfig, nodeMetadata, mergedEvent, secretResolver)
+		if mergedEvent.PostDrainTask != nil {
+			runPostDrainTask(node, mergedEvent.NodeName, mergedEvent, metrics, recorder)
+		}
+		runHook(interruptionEventStore, postDrainHook, hooks.PostDrainPhase, mergedEvent, metrics, recorder)
+		if taskCallback != nil {
+			completeTask(*taskCallback, *mergedEvent, nil)
+		}
+		terminationEvents.Start(*mergedEvent)
+		terminationEvents.Finish(*mergedEvent, terminationevent.PhaseSucceeded, "merged", startedAt, nil)
+		history.Start(*mergedEvent, terminationevent.PhaseDraining)
+		history.Finish(*mergedEvent, terminationevent.PhaseSucceeded, "merged", nil)
+	}
+}
+
+func handlingPhase(abortErr error, drainErr error) string {
+	switch {
+	case abortErr != nil:
+		return terminationevent.PhaseAborted
+	case drainErr != nil:
+		return terminationevent.PhaseFailed
+	default:
+		return terminationevent.PhaseSucceeded
+	}
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func completeTask(taskCallback stepfunctions.TaskCallback, event monitor.InterruptionEvent, taskErr error) {
+	err := taskCallback.Complete(event, taskErr)
+	if err != nil {
+		log.Err(err).Str("event_id", event.EventID).Msg("Unable to report the Step Functions task")
+	}
+}
+
+// reportBlockingPDBs looks up the pod disruption budgets which blocked evicting the pods still running on the node after a
+// failed drain, so their owners learn which budget kept the node from being drained
+func reportBlockingPDBs(node node.Node, nodeName string, metrics observability.Metrics, recorder observability.K8sEventRecorder) []string {
+	pdbs, err := node.BlockingPDBs(nodeName)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to determine the pod disruption budgets blocking the drain")
+		return nil
+	}
+	if len(pdbs) == 0 {
+		return nil
+	}
+	log.Warn().Str("node_name", nodeName).Strs("pod_disruption_budgets", pdbs).Msg("Evictions were blocked by pod disruption budgets")
+	for _, pdb := range pdbs {
+		metrics.BlockedEvictionsInc(pdb, nodeName)
+	}
+	recorder.Emit(nodeName, observability.Warning, observability.EvictionBlockedReason, observability.EvictionBlockedMsgFmt, strings.Join(pdbs, ", "))
+	return pdbs
+}
+
+// drainedWorkloadsToVerify returns the workloads of the pods on the node, whose ready replicas are verified after the
+// drain, or nil if the verification is disabled
+func drainedWorkloadsToVerify(n node.Node, nodeName string, nthConfig config.Config) []node.DrainedWorkload {
+	if nthConfig.RescheduleVerificationTimeout <= 0 || nthConfig.CordonOnly {
+		return nil
+	}
+	workloads, err := n.DrainedWorkloads(nodeName)
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to determine the workloads of the node's pods, not verifying they are rescheduled")
+		return nil
+	}
+	return workloads
+}
+
+// verifyRescheduling waits for the workloads of the drained pods to have their desired ready replicas on other nodes and
+// reports the outcome in the event, which is recorded once handling it finished
+func verifyRescheduling(interruptionEventStore *interruptioneventstore.Store, n node.Node, nodeName string, drainEvent *monitor.InterruptionEvent, workloads []node.DrainedWorkload, timeout time.Duration, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
+	unrecovered := n.VerifyRescheduled(workloads, timeout)
+	if len(unrecovered) > 0 {
+		log.Warn().Str("node_name", nodeName).Strs("workloads", unrecovered).Msgf("Workloads of the drained pods did not have their desired ready replicas within %s", timeout)
+		interruptionEventStore.SetRescheduling(drainEvent, node.ReschedulingUnrecovered, unrecovered)
+		metrics.NodeActionsInc("verify-reschedule", nodeName, fmt.Errorf("%d workloads did not recover", len(unrecovered)))
+		recorder.Emit(nodeName, observability.Warning, observability.RescheduleErrReason, observability.RescheduleErrMsgFmt, timeout, strings.Join(unrecovered, ", "))
+		return
 	}
+	log.Info().Str("node_name", nodeName).Int("workloads", len(workloads)).Msg("Workloads of the drained pods have their desired ready replicas again")
+	interruptionEventStore.SetRescheduling(drainEvent, node.ReschedulingRecovered, nil)
+	metrics.NodeActionsInc("verify-reschedule", nodeName, nil)
+	recorder.Emit(nodeName, observability.Normal, observability.RescheduleReason, observability.RescheduleMsg)
+}
+
+// sendTerminationNotices posts the termination notice of the event to the pods of the node which asked for one, then
+// gives the pods which accepted it the delay to react before they're evicted
+func sendTerminationNotices(n node.Node, nodeName string, drainEvent *monitor.InterruptionEvent, delay time.Duration) {
+	notice := node.TerminationNotice{EventID: drainEvent.EventID, Kind: drainEvent.Kind}
+	if !drainEvent.StartTime.IsZero() {
+		notice.Deadline = drainEvent.StartTime.UTC().Format(time.RFC3339)
+	}
+	notified := n.SendTerminationNotices(nodeName, notice)
+	if notified == 0 || delay <= 0 {
+		return
+	}
+	log.Info().Str("node_name", nodeName).Msgf("Waiting %s for %d pods to react to the termination notice before draining", delay, notified)
+	time.Sleep(delay)
+}
+
+// deferDrainUntilCapacity cordons the node and waits until other nodes can absorb its pods or the timeout passes
+func deferDrainUntilCapacity(node node.Node, nodeName string, timeout time.Duration, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
+	hasCapacity, err := node.HasCapacityForPods(nodeName)
+	if err != nil {
+		log.Err(err).Msg("Unable to determine if there is capacity for the node's pods, draining without deferral")
+		metrics.DrainDeferralsInc("error", nodeName)
+		return
+	}
+	if hasCapacity {
+		metrics.DrainDeferralsInc("not-deferred", nodeName)
+		return
+	}
+	log.Info().Str("node_name", nodeName).Msgf("Deferring drain for up to %s until there is capacity for the node's pods", timeout)
+	metrics.DrainDeferralsInc("deferred", nodeName)
+	err = cordonNode(node, nodeName, &monitor.InterruptionEvent{}, metrics, recorder)
+	if err != nil {
+		return
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(capacityPollInterval)
+		hasCapacity, err = node.HasCapacityForPods(nodeName)
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to determine if there is capacity for the node's pods")
+			continue
+		}
+		if hasCapacity {
+			log.Info().Str("node_name", nodeName).Msg("Capacity is available for the node's pods, draining")
+			metrics.DrainDeferralsInc("capacity-available", nodeName)
+			return
+		}
+	}
+	log.Warn().Str("node_name", nodeName).Msg("Drain deferral timed out without capacity for the node's pods, draining anyway")
+	metrics.DrainDeferralsInc("deadline-exceeded", nodeName)
+}
+
+func runMappedAction(action string, node node.Node, nodeName string, drainEvent *monitor.InterruptionEvent, metrics observability.Metrics, recorder observability.K8sEventRecorder, sqsTerminationDraining bool) error {
+	switch action {
+	case config.ActionCordon:
+		return cordonNode(node, nodeName, drainEvent, metrics, recorder)
+	case config.ActionDrainAndDeleteNode:
+		err := cordonAndDrainNode(node, nodeName, metrics, recorder, sqsTerminationDraining)
+		if err != nil {
+			return err
+		}
+		err = node.DeleteNode(nodeName)
+		if err != nil {
+			log.Err(err).Msg("There was a problem while trying to delete the node")
+		} else {
+			log.Info().Str("node_name", nodeName).Msg("Node successfully deleted")
+		}
+		metrics.NodeActionsInc("delete-node", nodeName, err)
+		return err
+	default:
+		return cordonAndDrainNode(node, nodeName, metrics, recorder, sqsTerminationDraining)
+	}
+}
+
+func sendWebhook(nthConfig config.Config, nodeMetadata ec2metadata.NodeMetadata, drainEvent *monitor.InterruptionEvent, secretResolver *secrets.Resolver) {
+	if nthConfig.WebhookURL == "" {
+		return
+	}
+	webhookConfig, err := webhook.ResolveSecrets(nthConfig, secretResolver)
+	if err != nil {
+		log.Err(err).Msg("Webhook Error: Unable to resolve webhook secrets")
+		return
+	}
+	webhook.Post(nodeMetadata, drainEvent, webhookConfig)
+}
 
+// isTerminatingEvent returns true if the instance of the event is going away for good, so detaching it from its Auto
+// Scaling Group to launch the replacement early doesn't leave a healthy instance outside of the group
+func isTerminatingEvent(drainEvent *monitor.InterruptionEvent) bool {
+	switch drainEvent.Kind {
+	case scheduledevent.ScheduledEventKind:
+		return scheduledevent.IsRetirement(drainEvent.Code)
+	case spotitn.SpotITNKind:
+		return !drainEvent.IsStopOrHibernate()
+	}
+	return false
+}
+
+func detachAndWaitForReplacement(asgReplacer asgreplacement.Replacer, nodeName string, instanceID string, timeout time.Duration, metrics observability.Metrics) {
+	asgName, err := asgReplacer.DetachInstance(instanceID)
+	metrics.NodeActionsInc("asg-detach", nodeName, err)
+	if err != nil {
+		log.Err(err).Msg("There was a problem detaching the instance from its Auto Scaling Group")
+		return
+	}
+	if asgName == "" {
+		return
+	}
+	err = asgReplacer.WaitForReplacement(asgName, timeout)
+	if err != nil {
+		log.Warn().Err(err).Msg("Draining without replacement capacity")
+	}
+}
+
+func bottlerocketReboot(client bottlerocket.Client, dryRun bool) func() error {
+	return func() error {
+		if dryRun {
+			log.Info().Msg("Node would have been rebooted through the Bottlerocket API, but dry-run flag was set")
+			return nil
+		}
+		return client.Reboot()
+	}
 }
 
 func runPreDrainTask(node node.Node, nodeName string, drainEvent *monitor.InterruptionEvent, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
@@ -402,6 +1414,40 @@ func cordonAndDrainNode(node node.Node, nodeName string, metrics observability.M
 	return nil
 }
 
+func cordonAndEvictAcceleratorPods(node node.Node, nodeName string, metrics observability.Metrics, recorder observability.K8sEventRecorder) error {
+	err := node.CordonAndEvictAcceleratorPods(nodeName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Err(err).Msgf("node '%s' not found in the cluster", nodeName)
+		} else {
+			log.Err(err).Msg("There was a problem while trying to cordon the node and evict accelerator pods")
+			recorder.Emit(nodeName, observability.Warning, observability.AcceleratorEvictErrReason, observability.AcceleratorEvictErrMsgFmt, err.Error())
+		}
+	} else {
+		log.Info().Str("node_name", nodeName).Msg("Node successfully cordoned and accelerator pods evicted")
+		recorder.Emit(nodeName, observability.Normal, observability.AcceleratorEvictReason, observability.AcceleratorEvictMsg)
+	}
+	metrics.NodeActionsInc("cordon-and-evict-accelerator-pods", nodeName, err)
+	return err
+}
+
+func deleteKarpenterNode(node node.Node, nodeName string, metrics observability.Metrics, recorder observability.K8sEventRecorder) error {
+	err := node.DeleteKarpenterNode(nodeName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.Err(err).Msgf("node '%s' not found in the cluster", nodeName)
+		} else {
+			log.Err(err).Msg("There was a problem while trying to delete the karpenter node")
+			recorder.Emit(nodeName, observability.Warning, observability.KarpenterDeleteErrReason, observability.KarpenterDeleteErrMsgFmt, err.Error())
+		}
+	} else {
+		log.Info().Str("node_name", nodeName).Msg("Karpenter node successfully deleted")
+		recorder.Emit(nodeName, observability.Normal, observability.KarpenterDeleteReason, observability.KarpenterDeleteMsg)
+	}
+	metrics.NodeActionsInc("karpenter-delete", nodeName, err)
+	return err
+}
+
 func runPostDrainTask(node node.Node, nodeName string, drainEvent *monitor.InterruptionEvent, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
 	err := drainEvent.PostDrainTask(*drainEvent, node)
 	if err != nil {
@@ -413,13 +1459,133 @@ func runPostDrainTask(node node.Node, nodeName string, drainEvent *monitor.Inter
 	metrics.NodeActionsInc("post-drain", nodeName, err)
 }
 
-func getRegionFromQueueURL(queueURL string) string {
-	for _, partition := range endpoints.DefaultPartitions() {
-		for regionID := range partition.Regions() {
-			if strings.Contains(queueURL, regionID) {
-				return regionID
+// acknowledgeEvent runs the post-drain task of an event which is done without draining the node, so its queue message
+// is deleted and its lifecycle action completed rather than redelivered. The post-drain task of scheduled events reboots
+// the instance, so it only runs after a drain.
+func acknowledgeEvent(node node.Node, drainEvent *monitor.InterruptionEvent, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
+	if drainEvent.PostDrainTask == nil || drainEvent.Kind == scheduledevent.ScheduledEventKind {
+		return
+	}
+	runPostDrainTask(node, drainEvent.NodeName, drainEvent, metrics, recorder)
+}
+
+// acknowledgeEvents acknowledges each of the events, e.g. the events of a node which is skipped
+func acknowledgeEvents(node node.Node, events []*monitor.InterruptionEvent, metrics observability.Metrics, recorder observability.K8sEventRecorder) {
+	for _, event := range events {
+		acknowledgeEvent(node, event, metrics, recorder)
+	}
+}
+
+// newHooks returns the hooks configured for each phase, phases without hooks are left out
+func newHooks(nthConfig config.Config, awsConfig aws.Config) map[string]hooks.Hook {
+	timeout := time.Duration(nthConfig.HookTimeout) * time.Second
+	chains := map[string]hooks.Chain{}
+	for phase, command := range map[string]string{
+		hooks.PreDrainPhase:     nthConfig.PreDrainHook,
+		hooks.PostDrainPhase:    nthConfig.PostDrainHook,
+		hooks.PostUncordonPhase: nthConfig.PostUncordonHook,
+	} {
+		if command != "" {
+			chains[phase] = append(chains[phase], hooks.ExecHook{Command: command, Timeout: timeout})
+		}
+	}
+	if nthConfig.PreDrainLambdaHook != "" || nthConfig.PostDrainLambdaHook != "" {
+		lambdaAPI := lambda.NewFromConfig(awsConfig)
+		for phase, functionName := range map[string]string{
+			hooks.PreDrainPhase:  nthConfig.PreDrainLambdaHook,
+			hooks.PostDrainPhase: nthConfig.PostDrainLambdaHook,
+		} {
+			if functionName != "" {
+				chains[phase] = append(chains[phase], hooks.LambdaHook{Lambda: lambdaAPI, FunctionName: functionName, FailureAction: nthConfig.LambdaHookFailureAction})
 			}
 		}
 	}
+	if nthConfig.PreDrainSSMDocument != "" {
+		chains[hooks.PreDrainPhase] = append(chains[hooks.PreDrainPhase], hooks.SSMHook{
+			SSM:           ssm.NewFromConfig(awsConfig),
+			DocumentName:  nthConfig.PreDrainSSMDocument,
+			Parameters:    nthConfig.PreDrainSSMParameterValues,
+			Timeout:       time.Duration(nthConfig.SSMHookTimeout) * time.Second,
+			FailureAction: nthConfig.SSMHookFailureAction,
+		})
+	}
+	phaseHooks := map[string]hooks.Hook{}
+	for phase, chain := range chains {
+		phaseHooks[phase] = chain
+	}
+	return phaseHooks
+}
+
+// runHook runs the hook of the phase, if one is configured, and returns its error so callers can honor hooks.ErrAbort
+func runHook(interruptionEventStore *interruptioneventstore.Store, hook hooks.Hook, phase string, event *monitor.InterruptionEvent, metrics observability.Metrics, recorder observability.K8sEventRecorder) error {
+	if hook == nil {
+		return nil
+	}
+	err := hook.Run(phase, *event)
+	if err != nil {
+		log.Err(err).Msgf("There was a problem executing the %s hook", phase)
+		recorder.Emit(event.NodeName, observability.Warning, observability.HookErrReason, observability.HookErrMsgFmt, phase, err.Error())
+	} else {
+		recorder.Emit(event.NodeName, observability.Normal, observability.HookReason, observability.HookMsgFmt, phase)
+	}
+	metrics.NodeActionsInc(phase+"-hook", event.NodeName, err)
+	interruptionEventStore.UpdateEvent(event, func(e *monitor.InterruptionEvent) {
+		// snapshots of the event share its map, so the outcomes are copied
+		hookOutcomes := make(map[string]string, len(e.HookOutcomes)+1)
+		for p, outcome := range e.HookOutcomes {
+			hookOutcomes[p] = outcome
+		}
+		hookOutcomes[phase] = hookOutcome(err)
+		e.HookOutcomes = hookOutcomes
+	})
+	return err
+}
+
+// hookOutcome returns how a hook run ended for the summary of the event
+func hookOutcome(err error) string {
+	switch {
+	case goerrors.Is(err, hooks.ErrAbort):
+		return "aborted"
+	case err != nil:
+		return "failed"
+	default:
+		return "succeeded"
+	}
+}
+
+// logHandlingSummary logs the outcome of handling the event as a single line, so it doesn't need to be pieced together
+// from the log lines of concurrent drains
+func logHandlingSummary(event monitor.InterruptionEvent, phase string, action string, duration time.Duration, handlingErr error) {
+	summary := log.Info()
+	if handlingErr != nil {
+		summary = log.Warn().Err(handlingErr)
+	}
+	summary.Str("event_id", event.EventID).
+		Str("node_name", event.NodeName).
+		Str("kind", event.Kind).
+		Str("phase", phase).
+		Str("action", action).
+		Dur("duration", duration).
+		Dur("drain_duration", event.DrainDuration).
+		Int("pods", len(event.Pods)).
+		Interface("eviction_failures", event.EvictionFailures).
+		Strs("blocking_pdbs", event.BlockingPDBs).
+		Interface("hooks", event.HookOutcomes).
+		Str("rescheduling", event.Rescheduling).
+		Strs("unrecovered_workloads", event.UnrecoveredWorkloads).
+		Msg("Finished handling the interruption event")
+}
+
+// getRegionFromQueueURL returns the region in the host of the queue URL, like sqs.us-east-1.amazonaws.com or us-east-1.queue.amazonaws.com
+func getRegionFromQueueURL(queueURL string) string {
+	parsed, err := url.Parse(queueURL)
+	if err != nil {
+		return ""
+	}
+	for _, label := range strings.Split(parsed.Hostname(), ".") {
+		if regionPattern.MatchString(label) {
+			return label
+		}
+	}
 	return ""
 }
@@ -0,0 +1,180 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package asgreplacement
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/rs/zerolog/log"
+)
+
+const defaultPollInterval = 10 * time.Second
+
+// ASGAPI is the part of the Auto Scaling API the Replacer uses
+type ASGAPI interface {
+	DescribeAutoScalingGroups(ctx context.Context, params *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
+	DescribeAutoScalingInstances(ctx context.Context, params *autoscaling.DescribeAutoScalingInstancesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingInstancesOutput, error)
+	DetachInstances(ctx context.Context, params *autoscaling.DetachInstancesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DetachInstancesOutput, error)
+}
+
+// Replacer brings up replacement capacity in an instance's Auto Scaling Group before the instance is drained
+type Replacer struct {
+	ASG          ASGAPI
+	Node         node.Node
+	PollInterval time.Duration
+}
+
+// New constructs a Replacer
+func New(asg ASGAPI, node node.Node) Replacer {
+	return Replacer{
+		ASG:          asg,
+		Node:         node,
+		PollInterval: defaultPollInterval,
+	}
+}
+
+// DetachInstance detaches the instance from its Auto Scaling Group without decrementing the desired capacity, so the
+// group launches a replacement while the instance keeps serving. The name of the group is returned, or an empty string
+// if the instance does not belong to one.
+func (r Replacer) DetachInstance(instanceID string) (string, error) {
+	asgName, err := r.autoScalingGroupName(instanceID)
+	if err != nil || asgName == "" {
+		return "", err
+	}
+	_, err = r.ASG.DetachInstances(context.TODO(), &autoscaling.DetachInstancesInput{
+		AutoScalingGroupName:           aws.String(asgName),
+		InstanceIds:                    []string{instanceID},
+		ShouldDecrementDesiredCapacity: aws.Bool(false),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to detach instance %s from Auto Scaling Group %s: %w", instanceID, asgName, err)
+	}
+	log.Info().Str("instance_id", instanceID).Str("asg_name", asgName).Msg("Detached instance from its Auto Scaling Group")
+	return asgName, nil
+}
+
+// WaitForReplacement blocks until the Auto Scaling Group's desired capacity is fulfilled by InService instances whose
+// kubernetes nodes are Ready, or the timeout elapses
+func (r Replacer) WaitForReplacement(asgName string, timeout time.Duration) error {
+	return r.waitFor(fmt.Sprintf("Auto Scaling Group %s", asgName), timeout, func() (bool, error) {
+		return r.isCapacityFulfilled(asgName, "")
+	})
+}
+
+// WaitForRebalanceReplacement blocks until capacity replacing an instance which received a rebalance recommendation is
+// Ready, or the timeout elapses. If the instance belongs to an Auto Scaling Group, the group's desired capacity must be
+// fulfilled without the instance. Otherwise a Ready node in the same zone and node group launched after the recommendation is required.
+func (r Replacer) WaitForRebalanceReplacement(instanceID string, nodeName string, since time.Time, timeout time.Duration) error {
+	asgName := ""
+	if instanceID != "" {
+		var err error
+		asgName, err = r.autoScalingGroupName(instanceID)
+		if err != nil {
+			return err
+		}
+	}
+	if asgName != "" {
+		return r.waitFor(fmt.Sprintf("Auto Scaling Group %s", asgName), timeout, func() (bool, error) {
+			return r.isCapacityFulfilled(asgName, instanceID)
+		})
+	}
+	return r.waitFor(fmt.Sprintf("node %s", nodeName), timeout, func() (bool, error) {
+		return r.Node.HasReadyReplacement(nodeName, since)
+	})
+}
+
+// IsCapacityRebalanceEnabled returns whether Capacity Rebalancing is enabled on the instance's Auto Scaling Group, so
+// the group launches a replacement when the instance receives a rebalance recommendation. Instances which do not
+// belong to an Auto Scaling Group return true along with an empty group name.
+func (r Replacer) IsCapacityRebalanceEnabled(instanceID string) (bool, string, error) {
+	asgName, err := r.autoScalingGroupName(instanceID)
+	if err != nil || asgName == "" {
+		return true, "", err
+	}
+	result, err := r.ASG.DescribeAutoScalingGroups(context.TODO(), &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{asgName},
+	})
+	if err != nil {
+		return true, asgName, fmt.Errorf("Unable to describe Auto Scaling Group %s: %w", asgName, err)
+	}
+	if len(result.AutoScalingGroups) == 0 {
+		return true, asgName, fmt.Errorf("Auto Scaling Group %s was not found", asgName)
+	}
+	return aws.ToBool(result.AutoScalingGroups[0].CapacityRebalance), asgName, nil
+}
+
+func (r Replacer) waitFor(target string, timeout time.Duration, isReplaced func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		replaced, err := isReplaced()
+		if err != nil {
+			log.Warn().Err(err).Msgf("Unable to check replacement capacity for %s", target)
+		} else if replaced {
+			log.Info().Msgf("Replacement capacity for %s is ready", target)
+			return nil
+		}
+		if time.Now().Add(r.PollInterval).After(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for replacement capacity for %s", timeout, target)
+		}
+		time.Sleep(r.PollInterval)
+	}
+}
+
+// isCapacityFulfilled checks whether the group's desired capacity is InService with Ready nodes, not counting the excluded instance
+func (r Replacer) isCapacityFulfilled(asgName string, excludedInstanceID string) (bool, error) {
+	result, err := r.ASG.DescribeAutoScalingGroups(context.TODO(), &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{asgName},
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(result.AutoScalingGroups) == 0 {
+		return false, fmt.Errorf("Auto Scaling Group %s was not found", asgName)
+	}
+	group := result.AutoScalingGroups[0]
+	var inServiceInstanceIDs []string
+	for _, instance := range group.Instances {
+		if aws.ToString(instance.InstanceId) == excludedInstanceID {
+			continue
+		}
+		if instance.LifecycleState == types.LifecycleStateInService {
+			inServiceInstanceIDs = append(inServiceInstanceIDs, aws.ToString(instance.InstanceId))
+		}
+	}
+	if int32(len(inServiceInstanceIDs)) < aws.ToInt32(group.DesiredCapacity) {
+		log.Debug().Str("asg_name", asgName).Msgf("%d of %d desired instances are InService", len(inServiceInstanceIDs), aws.ToInt32(group.DesiredCapacity))
+		return false, nil
+	}
+	return r.Node.AreInstancesReady(inServiceInstanceIDs)
+}
+
+func (r Replacer) autoScalingGroupName(instanceID string) (string, error) {
+	result, err := r.ASG.DescribeAutoScalingInstances(context.TODO(), &autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to find the Auto Scaling Group of instance %s: %w", instanceID, err)
+	}
+	if len(result.AutoScalingInstances) == 0 {
+		log.Info().Str("instance_id", instanceID).Msg("Instance does not belong to an Auto Scaling Group")
+		return "", nil
+	}
+	return aws.ToString(result.AutoScalingInstances[0].AutoScalingGroupName), nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package asgreplacement_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/asgreplacement"
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+const (
+	asgName    = "nth-test-asg"
+	instanceID = "i-0123456789"
+)
+
+func getNode(t *testing.T, nodes ...*v1.Node) node.Node {
+	client := fake.NewSimpleClientset()
+	for _, n := range nodes {
+		client.Tracker().Add(n)
+	}
+	tNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client}, uptime.Uptime)
+	h.Ok(t, err)
+	return *tNode
+}
+
+func readyNode(name string, instanceID string, status v1.ConditionStatus) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1a/" + instanceID},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}},
+	}
+}
+
+func describeASGResp(desired int32, instanceIDs ...string) autoscaling.DescribeAutoScalingGroupsOutput {
+	group := types.AutoScalingGroup{AutoScalingGroupName: aws.String(asgName), DesiredCapacity: aws.Int32(desired)}
+	for _, id := range instanceIDs {
+		group.Instances = append(group.Instances, types.Instance{InstanceId: aws.String(id), LifecycleState: types.LifecycleStateInService})
+	}
+	return autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: []types.AutoScalingGroup{group}}
+}
+
+func TestDetachInstance(t *testing.T) {
+	asgMock := h.MockedASG{
+		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
+			AutoScalingInstances: []types.AutoScalingInstanceDetails{{AutoScalingGroupName: aws.String(asgName)}},
+		},
+	}
+	name, err := asgreplacement.New(asgMock, getNode(t)).DetachInstance(instanceID)
+	h.Ok(t, err)
+	h.Equals(t, asgName, name)
+}
+
+func TestDetachInstanceNotInASG(t *testing.T) {
+	name, err := asgreplacement.New(h.MockedASG{}, getNode(t)).DetachInstance(instanceID)
+	h.Ok(t, err)
+	h.Equals(t, "", name)
+}
+
+func TestDetachInstanceFailure(t *testing.T) {
+	asgMock := h.MockedASG{
+		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
+			AutoScalingInstances: []types.AutoScalingInstanceDetails{{AutoScalingGroupName: aws.String(asgName)}},
+		},
+		DetachInstancesErr: fmt.Errorf("detach failed"),
+	}
+	_, err := asgreplacement.New(asgMock, getNode(t)).DetachInstance(instanceID)
+	h.Nok(t, err)
+}
+
+func TestWaitForReplacementReady(t *testing.T) {
+	asgMock := h.MockedASG{DescribeAutoScalingGroupsResp: describeASGResp(1, "i-replacement")}
+	replacement := asgreplacement.New(asgMock, getNode(t, readyNode("replacement", "i-replacement", v1.ConditionTrue)))
+	replacement.PollInterval = time.Millisecond
+	h.Ok(t, replacement.WaitForReplacement(asgName, time.Second))
+}
+
+func TestWaitForReplacementTimeout(t *testing.T) {
+	asgMock := h.MockedASG{DescribeAutoScalingGroupsResp: describeASGResp(1, "i-replacement")}
+	replacement := asgreplacement.New(asgMock, getNode(t, readyNode("replacement", "i-replacement", v1.ConditionFalse)))
+	replacement.PollInterval = time.Millisecond
+	h.Nok(t, replacement.WaitForReplacement(asgName, 10*time.Millisecond))
+}
+
+func TestWaitForReplacementDesiredNotFulfilled(t *testing.T) {
+	asgMock := h.MockedASG{DescribeAutoScalingGroupsResp: describeASGResp(2, "i-replacement")}
+	replacement := asgreplacement.New(asgMock, getNode(t, readyNode("replacement", "i-replacement", v1.ConditionTrue)))
+	replacement.PollInterval = time.Millisecond
+	h.Nok(t, replacement.WaitForReplacement(asgName, 10*time.Millisecond))
+}
+
+func TestWaitForRebalanceReplacementASG(t *testing.T) {
+	asgMock := h.MockedASG{
+		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
+			AutoScalingInstances: []types.AutoScalingInstanceDetails{{AutoScalingGroupName: aws.String(asgName)}},
+		},
+		DescribeAutoScalingGroupsResp: describeASGResp(1, instanceID, "i-replacement"),
+	}
+	replacement := asgreplacement.New(asgMock, getNode(t,
+		readyNode("interrupted", instanceID, v1.ConditionTrue),
+		readyNode("replacement", "i-replacement", v1.ConditionTrue),
+	))
+	replacement.PollInterval = time.Millisecond
+	h.Ok(t, replacement.WaitForRebalanceReplacement(instanceID, "interrupted", time.Now(), time.Second))
+}
+
+func TestWaitForRebalanceReplacementASGOnlyInterruptedInstance(t *testing.T) {
+	asgMock := h.MockedASG{
+		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
+			AutoScalingInstances: []types.AutoScalingInstanceDetails{{AutoScalingGroupName: aws.String(asgName)}},
+		},
+		DescribeAutoScalingGroupsResp: describeASGResp(1, instanceID),
+	}
+	replacement := asgreplacement.New(asgMock, getNode(t, readyNode("interrupted", instanceID, v1.ConditionTrue)))
+	replacement.PollInterval = time.Millisecond
+	h.Nok(t, replacement.WaitForRebalanceReplacement(instanceID, "interrupted", time.Now(), 10*time.Millisecond))
+}
+
+func TestWaitForRebalanceReplacementNodeGroup(t *testing.T) {
+	since := time.Now().Add(-time.Minute)
+	labels := map[string]string{v1.LabelTopologyZone: "us-east-1a", "eks.amazonaws.com/nodegroup": "workers"}
+	interrupted := readyNode("interrupted", instanceID, v1.ConditionTrue)
+	interrupted.Labels = labels
+	interrupted.CreationTimestamp = metav1.NewTime(since.Add(-time.Hour))
+	otherZone := readyNode("other-zone", "i-other", v1.ConditionTrue)
+	otherZone.Labels = map[string]string{v1.LabelTopologyZone: "us-east-1b", "eks.amazonaws.com/nodegroup": "workers"}
+	otherZone.CreationTimestamp = metav1.NewTime(since.Add(time.Second))
+
+	replacement := asgreplacement.New(h.MockedASG{}, getNode(t, interrupted, otherZone))
+	replacement.PollInterval = time.Millisecond
+	h.Nok(t, replacement.WaitForRebalanceReplacement(instanceID, "interrupted", since, 10*time.Millisecond))
+
+	sameZone := readyNode("same-zone", "i-same", v1.ConditionTrue)
+	sameZone.Labels = labels
+	sameZone.CreationTimestamp = metav1.NewTime(since.Add(time.Second))
+	replacement = asgreplacement.New(h.MockedASG{}, getNode(t, interrupted, otherZone, sameZone))
+	replacement.PollInterval = time.Millisecond
+	h.Ok(t, replacement.WaitForRebalanceReplacement(instanceID, "interrupted", since, time.Second))
+}
+
+func TestIsCapacityRebalanceEnabled(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		groups := describeASGResp(1, instanceID)
+		groups.AutoScalingGroups[0].CapacityRebalance = aws.Bool(enabled)
+		asgMock := h.MockedASG{
+			DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
+				AutoScalingInstances: []types.AutoScalingInstanceDetails{{AutoScalingGroupName: aws.String(asgName)}},
+			},
+			DescribeAutoScalingGroupsResp: groups,
+		}
+		result, name, err := asgreplacement.New(asgMock, getNode(t)).IsCapacityRebalanceEnabled(instanceID)
+		h.Ok(t, err)
+		h.Equals(t, asgName, name)
+		h.Equals(t, enabled, result)
+	}
+}
+
+func TestIsCapacityRebalanceEnabledNotInASG(t *testing.T) {
+	result, name, err := asgreplacement.New(h.MockedASG{}, getNode(t)).IsCapacityRebalanceEnabled(instanceID)
+	h.Ok(t, err)
+	h.Equals(t, "", name)
+	h.Assert(t, result, "Expected instances outside of an Auto Scaling Group to be handled")
+}
@@ -0,0 +1,190 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxEventsPerRequest stays well below the 10,000 events and 1 MB a PutLogEvents request is limited to
+	maxEventsPerRequest = 500
+	// maxPendingEvents bounds the memory held while CloudWatch Logs is unavailable, the oldest records are dropped first
+	maxPendingEvents = 10000
+)
+
+// CloudWatchLogsAPI is the part of the CloudWatch Logs API the sink uses
+type CloudWatchLogsAPI interface {
+	CreateLogGroup(ctx context.Context, params *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error)
+	CreateLogStream(ctx context.Context, params *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error)
+	PutLogEvents(ctx context.Context, params *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error)
+}
+
+// CloudWatchLogsSink ships the record of each handled event as a JSON log event to a CloudWatch Logs stream, which
+// keeps the audit trail after the handler pods and the nodes are gone
+type CloudWatchLogsSink struct {
+	logs          CloudWatchLogsAPI
+	group         string
+	stream        string
+	mutex         sync.Mutex
+	pending       []types.InputLogEvent
+	streamReady   bool
+	sequenceToken *string
+}
+
+// NewCloudWatchLogsSink creates a sink writing to the stream of the log group, which are created if they don't exist
+func NewCloudWatchLogsSink(logs CloudWatchLogsAPI, group string, stream string) *CloudWatchLogsSink {
+	return &CloudWatchLogsSink{logs: logs, group: group, stream: stream}
+}
+
+// Start flushes the records to CloudWatch Logs periodically
+func (s *CloudWatchLogsSink) Start(interval time.Duration) {
+	log.Info().Str("log_group", s.group).Str("log_stream", s.stream).Msg("Starting to ship the audit trail to CloudWatch Logs")
+	go func() {
+		for range time.Tick(interval) {
+			if err := s.Flush(); err != nil {
+				log.Warn().Err(err).Msg("Unable to ship the audit trail to CloudWatch Logs, retrying with the next flush")
+			}
+		}
+	}()
+}
+
+// Write queues the record until the next flush
+func (s *CloudWatchLogsSink) Write(record status.Record) {
+	message, err := json.Marshal(record)
+	if err != nil {
+		log.Err(err).Str("event_id", record.EventID).Msg("Unable to marshal the audit record")
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending = append(s.pending, types.InputLogEvent{
+		Message:   aws.String(string(message)),
+		Timestamp: aws.Int64(record.CompletedAt.UnixNano() / int64(time.Millisecond)),
+	})
+	if len(s.pending) > maxPendingEvents {
+		log.Warn().Msgf("Dropping %d audit records which could not be shipped to CloudWatch Logs", len(s.pending)-maxPendingEvents)
+		s.pending = s.pending[len(s.pending)-maxPendingEvents:]
+	}
+}
+
+// Flush sends the queued records to CloudWatch Logs, records which fail to be sent are kept for the next flush
+func (s *CloudWatchLogsSink) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(s.pending) == 0 {
+		return nil
+	}
+	if !s.streamReady {
+		if err := s.createStream(); err != nil {
+			return err
+		}
+		s.streamReady = true
+	}
+	for len(s.pending) > 0 {
+		batch := s.pending
+		if len(batch) > maxEventsPerRequest {
+			batch = batch[:maxEventsPerRequest]
+		}
+		if err := s.put(batch); err != nil {
+			return err
+		}
+		s.pending = s.pending[len(batch):]
+	}
+	return nil
+}
+
+// put sends the events, retrying once with the sequence token CloudWatch Logs expects if the cached one is outdated,
+// e.g. when the stream already existed
+func (s *CloudWatchLogsSink) put(events []types.InputLogEvent) error {
+	for attempt := 0; ; attempt++ {
+		output, err := s.logs.PutLogEvents(context.TODO(), &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(s.group),
+			LogStreamName: aws.String(s.stream),
+			LogEvents:     events,
+			SequenceToken: s.sequenceToken,
+		})
+		if err == nil {
+			s.sequenceToken = output.NextSequenceToken
+			return nil
+		}
+		var invalidSequenceToken *types.InvalidSequenceTokenException
+		var dataAlreadyAccepted *types.DataAlreadyAcceptedException
+		switch {
+		case errors.As(err, &invalidSequenceToken):
+			s.sequenceToken = invalidSequenceToken.ExpectedSequenceToken
+		case errors.As(err, &dataAlreadyAccepted):
+			s.sequenceToken = dataAlreadyAccepted.ExpectedSequenceToken
+			return nil
+		default:
+			if notFound(err) {
+				// the stream or group was deleted, so it is created again with the next flush
+				s.streamReady = false
+				s.sequenceToken = nil
+			}
+			return err
+		}
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// createStream creates the log stream, and the log group if it does not exist either
+func (s *CloudWatchLogsSink) createStream() error {
+	err := s.createStreamOnce()
+	if notFound(err) {
+		_, err = s.logs.CreateLogGroup(context.TODO(), &cloudwatchlogs.CreateLogGroupInput{LogGroupName: aws.String(s.group)})
+		if err != nil && !alreadyExists(err) {
+			return fmt.Errorf("Unable to create the log group %s: %w", s.group, err)
+		}
+		err = s.createStreamOnce()
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to create the log stream %s: %w", s.stream, err)
+	}
+	return nil
+}
+
+func (s *CloudWatchLogsSink) createStreamOnce() error {
+	_, err := s.logs.CreateLogStream(context.TODO(), &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  aws.String(s.group),
+		LogStreamName: aws.String(s.stream),
+	})
+	if err != nil && !alreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func notFound(err error) bool {
+	var notFoundErr *types.ResourceNotFoundException
+	return errors.As(err, &notFoundErr)
+}
+
+func alreadyExists(err error) bool {
+	var alreadyExistsErr *types.ResourceAlreadyExistsException
+	return errors.As(err, &alreadyExistsErr)
+}
@@ -0,0 +1,107 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/audit"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+func record(eventID string) status.Record {
+	return status.Record{
+		EventID:     eventID,
+		Kind:        "SQS_TERMINATE",
+		NodeName:    "node-1",
+		Phase:       "Succeeded",
+		Action:      "cordon-and-drain",
+		CompletedAt: time.Unix(1600000000, 0).UTC(),
+	}
+}
+
+func TestCloudWatchLogsFlush(t *testing.T) {
+	var calls []string
+	var inputs []*cloudwatchlogs.PutLogEventsInput
+	sink := audit.NewCloudWatchLogsSink(h.MockedCloudWatchLogs{Calls: &calls, PutLogEventsInputs: &inputs}, "nth", "pod-1")
+
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 0, len(calls))
+
+	sink.Write(record("event-1"))
+	sink.Write(record("event-2"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, []string{"CreateLogStream", "PutLogEvents"}, calls)
+	h.Equals(t, "nth", *inputs[0].LogGroupName)
+	h.Equals(t, "pod-1", *inputs[0].LogStreamName)
+	h.Assert(t, inputs[0].SequenceToken == nil, "Expected no sequence token for a new stream")
+	h.Equals(t, 2, len(inputs[0].LogEvents))
+	h.Equals(t, int64(1600000000000), *inputs[0].LogEvents[0].Timestamp)
+	var shipped status.Record
+	h.Ok(t, json.Unmarshal([]byte(*inputs[0].LogEvents[1].Message), &shipped))
+	h.Equals(t, record("event-2"), shipped)
+
+	sink.Write(record("event-3"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, []string{"CreateLogStream", "PutLogEvents", "PutLogEvents"}, calls)
+	h.Equals(t, "1", *inputs[1].SequenceToken)
+}
+
+func TestCloudWatchLogsCreatesLogGroup(t *testing.T) {
+	var calls []string
+	createLogStreamErrs := []error{&types.ResourceNotFoundException{Message: aws.String("group not found")}}
+	logs := h.MockedCloudWatchLogs{Calls: &calls, CreateLogStreamErrs: &createLogStreamErrs}
+	sink := audit.NewCloudWatchLogsSink(logs, "nth", "pod-1")
+
+	sink.Write(record("event-1"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, []string{"CreateLogStream", "CreateLogGroup", "CreateLogStream", "PutLogEvents"}, calls)
+}
+
+func TestCloudWatchLogsExistingStream(t *testing.T) {
+	var inputs []*cloudwatchlogs.PutLogEventsInput
+	createLogStreamErrs := []error{&types.ResourceAlreadyExistsException{Message: aws.String("stream exists")}}
+	putLogEventsErrs := []error{&types.InvalidSequenceTokenException{ExpectedSequenceToken: aws.String("42")}}
+	logs := h.MockedCloudWatchLogs{CreateLogStreamErrs: &createLogStreamErrs, PutLogEventsErrs: &putLogEventsErrs, PutLogEventsInputs: &inputs}
+	sink := audit.NewCloudWatchLogsSink(logs, "nth", "pod-1")
+
+	sink.Write(record("event-1"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+	h.Equals(t, "42", *inputs[1].SequenceToken)
+}
+
+func TestCloudWatchLogsKeepsRecordsOnFailure(t *testing.T) {
+	var inputs []*cloudwatchlogs.PutLogEventsInput
+	putLogEventsErrs := []error{errors.New("throttled")}
+	logs := h.MockedCloudWatchLogs{PutLogEventsErrs: &putLogEventsErrs, PutLogEventsInputs: &inputs}
+	sink := audit.NewCloudWatchLogsSink(logs, "nth", "pod-1")
+
+	sink.Write(record("event-1"))
+	h.Nok(t, sink.Flush())
+	sink.Write(record("event-2"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+	h.Equals(t, 2, len(inputs[1].LogEvents))
+
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+}
@@ -0,0 +1,173 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	"github.com/aws/aws-node-termination-handler/pkg/terminationevent"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxEntriesPerPutEvents is the number of entries a PutEvents request is limited to
+	maxEntriesPerPutEvents = 10
+
+	// DrainCompleted is the detail type of the events for nodes which were drained successfully
+	DrainCompleted = "nth.drain.completed"
+	// DrainFailed is the detail type of the events for nodes which failed to be handled
+	DrainFailed = "nth.drain.failed"
+	// DrainAborted is the detail type of the events whose handling was aborted by a hook
+	DrainAborted = "nth.drain.aborted"
+	// DrainSkipped is the detail type of the events no action was taken for, e.g. for Karpenter nodes
+	DrainSkipped = "nth.drain.skipped"
+	// NodeHandled is the detail type of the events for nodes which were handled successfully without draining them,
+	// e.g. only cordoned or tainted
+	NodeHandled = "nth.node.handled"
+)
+
+// drainActions are the actions which drain the node
+var drainActions = map[string]bool{
+	"cordon-and-drain":   true,
+	"drain":              true,
+	"drainanddeletenode": true,
+	"karpenter-delete":   true,
+}
+
+// EventBridgeAPI is the part of the EventBridge API the sink uses
+type EventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgeSink publishes the record of each handled event as a custom event to an EventBridge bus, so downstream
+// automation like reprovisioning or ticketing can be triggered by rules
+type EventBridgeSink struct {
+	eventBridge EventBridgeAPI
+	bus         string
+	source      string
+	mutex       sync.Mutex
+	flushMutex  sync.Mutex
+	pending     []types.PutEventsRequestEntry
+}
+
+// NewEventBridgeSink creates a sink publishing to the bus with the source, the default bus is used if bus is empty
+func NewEventBridgeSink(eventBridge EventBridgeAPI, bus string, source string) *EventBridgeSink {
+	if bus == "" {
+		bus = "default"
+	}
+	return &EventBridgeSink{eventBridge: eventBridge, bus: bus, source: source}
+}
+
+// DetailType returns the detail type of the event published for the record
+func DetailType(record status.Record) string {
+	switch {
+	case record.Phase == terminationevent.PhaseFailed:
+		return DrainFailed
+	case record.Phase == terminationevent.PhaseAborted:
+		return DrainAborted
+	case strings.HasPrefix(record.Action, "skip-"):
+		return DrainSkipped
+	case drainActions[record.Action]:
+		return DrainCompleted
+	default:
+		return NodeHandled
+	}
+}
+
+// Start publishes the records periodically
+func (s *EventBridgeSink) Start(interval time.Duration) {
+	log.Info().Str("event_bus", s.bus).Msg("Starting to publish handled events to EventBridge")
+	go func() {
+		for range time.Tick(interval) {
+			if err := s.Flush(); err != nil {
+				log.Warn().Err(err).Msg("Unable to publish handled events to EventBridge, retrying with the next flush")
+			}
+		}
+	}()
+}
+
+// Write queues the record until the next flush
+func (s *EventBridgeSink) Write(record status.Record) {
+	detail, err := json.Marshal(record)
+	if err != nil {
+		log.Err(err).Str("event_id", record.EventID).Msg("Unable to marshal the EventBridge event detail")
+		return
+	}
+	entry := types.PutEventsRequestEntry{
+		EventBusName: aws.String(s.bus),
+		Source:       aws.String(s.source),
+		DetailType:   aws.String(DetailType(record)),
+		Detail:       aws.String(string(detail)),
+		Time:         aws.Time(record.CompletedAt),
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending = append(s.pending, entry)
+	if len(s.pending) > maxPendingEvents {
+		log.Warn().Msgf("Dropping %d handled events which could not be published to EventBridge", len(s.pending)-maxPendingEvents)
+		s.pending = s.pending[len(s.pending)-maxPendingEvents:]
+	}
+}
+
+// Flush publishes the queued events, events which fail to be published are kept for the next flush
+func (s *EventBridgeSink) Flush() error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+	s.mutex.Lock()
+	entries := s.pending
+	s.pending = nil
+	s.mutex.Unlock()
+
+	var failed []types.PutEventsRequestEntry
+	var firstErr error
+	for start := 0; start < len(entries); start += maxEntriesPerPutEvents {
+		end := start + maxEntriesPerPutEvents
+		if end > len(entries) {
+			end = len(entries)
+		}
+		batch := entries[start:end]
+		output, err := s.eventBridge.PutEvents(context.TODO(), &eventbridge.PutEventsInput{Entries: batch})
+		if err != nil {
+			failed = append(failed, batch...)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		// entries fail individually, e.g. when throttled, the results are in the order of the entries
+		for i, result := range output.Entries {
+			if result.ErrorCode != nil && i < len(batch) {
+				failed = append(failed, batch[i])
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %s", *result.ErrorCode, aws.ToString(result.ErrorMessage))
+				}
+			}
+		}
+	}
+	if len(failed) > 0 {
+		s.mutex.Lock()
+		s.pending = append(failed, s.pending...)
+		s.mutex.Unlock()
+	}
+	return firstErr
+}
@@ -0,0 +1,78 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/audit"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+)
+
+func TestDetailType(t *testing.T) {
+	h.Equals(t, audit.DrainCompleted, audit.DetailType(status.Record{Phase: "Succeeded", Action: "cordon-and-drain"}))
+	h.Equals(t, audit.DrainCompleted, audit.DetailType(status.Record{Phase: "Succeeded", Action: "drainanddeletenode"}))
+	h.Equals(t, audit.NodeHandled, audit.DetailType(status.Record{Phase: "Succeeded", Action: "cordon"}))
+	h.Equals(t, audit.NodeHandled, audit.DetailType(status.Record{Phase: "Succeeded", Action: "notify"}))
+	h.Equals(t, audit.DrainSkipped, audit.DetailType(status.Record{Phase: "Succeeded", Action: "skip-karpenter"}))
+	h.Equals(t, audit.DrainFailed, audit.DetailType(status.Record{Phase: "Failed", Action: "cordon-and-drain"}))
+	h.Equals(t, audit.DrainAborted, audit.DetailType(status.Record{Phase: "Aborted", Action: "abort"}))
+}
+
+func TestEventBridgeFlush(t *testing.T) {
+	var inputs []*eventbridge.PutEventsInput
+	sink := audit.NewEventBridgeSink(h.MockedEventBridge{PutEventsInputs: &inputs}, "", "aws-node-termination-handler")
+
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 0, len(inputs))
+
+	for i := 0; i < 12; i++ {
+		sink.Write(record("event-1"))
+	}
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+	h.Equals(t, 10, len(inputs[0].Entries))
+	h.Equals(t, 2, len(inputs[1].Entries))
+	entry := inputs[0].Entries[0]
+	h.Equals(t, "default", *entry.EventBusName)
+	h.Equals(t, "aws-node-termination-handler", *entry.Source)
+	h.Equals(t, audit.DrainCompleted, *entry.DetailType)
+	h.Equals(t, record("event-1").CompletedAt, *entry.Time)
+	var detail status.Record
+	h.Ok(t, json.Unmarshal([]byte(*entry.Detail), &detail))
+	h.Equals(t, record("event-1"), detail)
+}
+
+func TestEventBridgeRetriesFailedEntries(t *testing.T) {
+	var inputs []*eventbridge.PutEventsInput
+	eventBridge := h.MockedEventBridge{PutEventsInputs: &inputs, PutEventsFailedEntries: []int{1}}
+	sink := audit.NewEventBridgeSink(eventBridge, "nth", "aws-node-termination-handler")
+
+	sink.Write(record("event-1"))
+	sink.Write(record("event-2"))
+	h.Nok(t, sink.Flush())
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+	h.Equals(t, 1, len(inputs[1].Entries))
+	h.Equals(t, inputs[0].Entries[1], inputs[1].Entries[0])
+
+	sink = audit.NewEventBridgeSink(h.MockedEventBridge{PutEventsErr: errors.New("access denied")}, "nth", "aws-node-termination-handler")
+	sink.Write(record("event-1"))
+	h.Nok(t, sink.Flush())
+	h.Nok(t, sink.Flush())
+}
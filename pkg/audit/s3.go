@@ -0,0 +1,170 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog/log"
+)
+
+const s3DateFormat = "2006-01-02"
+
+// S3API is the part of the S3 API the sink uses
+type S3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3Sink exports the records of handled events as newline delimited JSON objects to S3, partitioned Hive style by
+// completion date and cluster, e.g. prefix/date=2021-06-01/cluster=prod/, so Athena can query them
+type S3Sink struct {
+	s3      S3API
+	bucket  string
+	prefix  string
+	cluster string
+	writer  string
+	// flushOnWrite exports every record once it is written, instead of periodically
+	flushOnWrite bool
+	mutex        sync.Mutex
+	flushMutex   sync.Mutex
+	pending      []status.Record
+	now          func() time.Time
+}
+
+// NewS3Sink creates a sink exporting to the prefix of the bucket, writer tells apart the objects of the replicas
+func NewS3Sink(s3API S3API, bucket string, prefix string, cluster string, writer string) *S3Sink {
+	return &S3Sink{
+		s3:      s3API,
+		bucket:  bucket,
+		prefix:  strings.Trim(prefix, "/"),
+		cluster: cluster,
+		writer:  writer,
+		now:     time.Now,
+	}
+}
+
+// Start exports the records periodically, or once they are written if the interval is 0
+func (s *S3Sink) Start(interval time.Duration) {
+	log.Info().Str("bucket", s.bucket).Str("prefix", s.prefix).Msg("Starting to export the event history to S3")
+	if interval <= 0 {
+		s.mutex.Lock()
+		s.flushOnWrite = true
+		s.mutex.Unlock()
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			s.logFlush()
+		}
+	}()
+}
+
+// Write queues the record until the next export
+func (s *S3Sink) Write(record status.Record) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pending = append(s.pending, record)
+	if len(s.pending) > maxPendingEvents {
+		log.Warn().Msgf("Dropping %d event records which could not be exported to S3", len(s.pending)-maxPendingEvents)
+		s.pending = s.pending[len(s.pending)-maxPendingEvents:]
+	}
+	if s.flushOnWrite {
+		go s.logFlush()
+	}
+}
+
+func (s *S3Sink) logFlush() {
+	if err := s.Flush(); err != nil {
+		log.Warn().Err(err).Msg("Unable to export the event history to S3, retrying with the next export")
+	}
+}
+
+// Flush writes an object with the queued records for each completion date, records which fail to be written are
+// kept for the next flush
+func (s *S3Sink) Flush() error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+	s.mutex.Lock()
+	records := s.pending
+	s.pending = nil
+	s.mutex.Unlock()
+
+	byDate := map[string][]status.Record{}
+	for _, record := range records {
+		date := record.CompletedAt.UTC().Format(s3DateFormat)
+		byDate[date] = append(byDate[date], record)
+	}
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	var failed []status.Record
+	var firstErr error
+	for _, date := range dates {
+		if err := s.put(date, byDate[date]); err != nil {
+			failed = append(failed, byDate[date]...)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if len(failed) > 0 {
+		s.mutex.Lock()
+		s.pending = append(failed, s.pending...)
+		s.mutex.Unlock()
+	}
+	return firstErr
+}
+
+func (s *S3Sink) put(date string, records []status.Record) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	key := s.key(date)
+	_, err := s.s3.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body.Bytes()),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	if err != nil {
+		return fmt.Errorf("Unable to write s3://%s/%s: %w", s.bucket, key, err)
+	}
+	log.Debug().Msgf("Exported %d event records to s3://%s/%s", len(records), s.bucket, key)
+	return nil
+}
+
+func (s *S3Sink) key(date string) string {
+	key := fmt.Sprintf("date=%s/cluster=%s/%s-%d.json", date, s.cluster, s.writer, s.now().UnixNano())
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
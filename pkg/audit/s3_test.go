@@ -0,0 +1,79 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package audit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/audit"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func readRecords(t *testing.T, input *s3.PutObjectInput) []status.Record {
+	body, err := ioutil.ReadAll(input.Body)
+	h.Ok(t, err)
+	var records []status.Record
+	for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+		var record status.Record
+		h.Ok(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestS3FlushPartitionsByDate(t *testing.T) {
+	var inputs []*s3.PutObjectInput
+	sink := audit.NewS3Sink(h.MockedS3{PutObjectInputs: &inputs}, "bucket", "/nth/events/", "prod", "pod-1")
+
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 0, len(inputs))
+
+	nextDay := record("event-3")
+	nextDay.CompletedAt = nextDay.CompletedAt.Add(24 * time.Hour)
+	sink.Write(nextDay)
+	sink.Write(record("event-1"))
+	sink.Write(record("event-2"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+	h.Equals(t, "bucket", *inputs[0].Bucket)
+	h.Assert(t, strings.HasPrefix(*inputs[0].Key, "nth/events/date=2020-09-13/cluster=prod/pod-1-"), "Unexpected key %s", *inputs[0].Key)
+	h.Assert(t, strings.HasSuffix(*inputs[0].Key, ".json"), "Unexpected key %s", *inputs[0].Key)
+	h.Equals(t, []status.Record{record("event-1"), record("event-2")}, readRecords(t, inputs[0]))
+	h.Assert(t, strings.HasPrefix(*inputs[1].Key, "nth/events/date=2020-09-14/cluster=prod/"), "Unexpected key %s", *inputs[1].Key)
+	h.Equals(t, []status.Record{nextDay}, readRecords(t, inputs[1]))
+
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+}
+
+func TestS3KeepsRecordsOnFailure(t *testing.T) {
+	var inputs []*s3.PutObjectInput
+	putObjectErrs := []error{errors.New("access denied")}
+	sink := audit.NewS3Sink(h.MockedS3{PutObjectErrs: &putObjectErrs, PutObjectInputs: &inputs}, "bucket", "", "prod", "pod-1")
+
+	sink.Write(record("event-1"))
+	h.Nok(t, sink.Flush())
+	sink.Write(record("event-2"))
+	h.Ok(t, sink.Flush())
+	h.Equals(t, 2, len(inputs))
+	h.Assert(t, strings.HasPrefix(*inputs[1].Key, "date=2020-09-13/cluster=prod/"), "Unexpected key %s", *inputs[1].Key)
+	h.Equals(t, []status.Record{record("event-1"), record("event-2")}, readRecords(t, inputs[1]))
+}
@@ -0,0 +1,132 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsretry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// Retryer retries the calls of every AWS client with adaptive, jittered backoff. The clients of a service share the
+// token buckets of the service, so when a service throttles, e.g. EC2 with RequestLimitExceeded, every client of the
+// service slows down together while the calls to the other services are not delayed.
+type Retryer struct {
+	mutex    sync.Mutex
+	services map[string]*retry.AdaptiveMode
+	// defaults decides which attempts are retried and their delay, which doesn't depend on the service
+	defaults    *retry.AdaptiveMode
+	maxAttempts int
+	maxBackoff  time.Duration
+	throttles   retry.IsErrorThrottles
+	onThrottle  func(service string)
+}
+
+// New creates a retryer making up to maxAttempts attempts of each call, waiting up to maxBackoff between them
+func New(maxAttempts int, maxBackoff time.Duration) *Retryer {
+	r := &Retryer{
+		services:    map[string]*retry.AdaptiveMode{},
+		maxAttempts: maxAttempts,
+		maxBackoff:  maxBackoff,
+		throttles:   retry.IsErrorThrottles(retry.DefaultThrottles),
+	}
+	r.defaults = r.newAdaptiveMode()
+	return r
+}
+
+// OnThrottle calls the function with the service of every throttled call
+func (r *Retryer) OnThrottle(onThrottle func(service string)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.onThrottle = onThrottle
+}
+
+// forService returns the retryer of the service of the call
+func (r *Retryer) forService(ctx context.Context) (string, *retry.AdaptiveMode) {
+	service := awsmiddleware.GetServiceID(ctx)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	retryer, ok := r.services[service]
+	if !ok {
+		retryer = r.newAdaptiveMode()
+		r.services[service] = retryer
+	}
+	return service, retryer
+}
+
+func (r *Retryer) newAdaptiveMode() *retry.AdaptiveMode {
+	return retry.NewAdaptiveMode(func(options *retry.AdaptiveModeOptions) {
+		options.StandardOptions = append(options.StandardOptions, func(options *retry.StandardOptions) {
+			options.MaxAttempts = r.maxAttempts
+			options.MaxBackoff = r.maxBackoff
+		})
+	})
+}
+
+// IsErrorRetryable returns whether the failed attempt is retried
+func (r *Retryer) IsErrorRetryable(err error) bool {
+	return r.defaults.IsErrorRetryable(err)
+}
+
+// MaxAttempts returns the most attempts made for a call
+func (r *Retryer) MaxAttempts() int {
+	return r.maxAttempts
+}
+
+// RetryDelay returns the jittered, exponential delay before retrying the attempt
+func (r *Retryer) RetryDelay(attempt int, err error) (time.Duration, error) {
+	return r.defaults.RetryDelay(attempt, err)
+}
+
+// GetRetryToken takes the cost of retrying the failed attempt from the retry token bucket of the service
+func (r *Retryer) GetRetryToken(ctx context.Context, err error) (func(error) error, error) {
+	_, retryer := r.forService(ctx)
+	return retryer.GetRetryToken(ctx, err)
+}
+
+// GetInitialToken is only present to implement aws.Retryer, GetAttemptToken is used instead
+func (r *Retryer) GetInitialToken() func(error) error {
+	return func(error) error { return nil }
+}
+
+// GetAttemptToken waits until the rate of attempts of the service allows the attempt. The rate is lowered when the
+// service throttles attempts, and raised again once it doesn't.
+func (r *Retryer) GetAttemptToken(ctx context.Context) (func(error) error, error) {
+	service, retryer := r.forService(ctx)
+	release, err := retryer.GetAttemptToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return func(err error) error {
+		if err != nil && r.throttles.IsErrorThrottle(err).Bool() {
+			r.throttled(service)
+		}
+		return release(err)
+	}, nil
+}
+
+func (r *Retryer) throttled(service string) {
+	r.mutex.Lock()
+	onThrottle := r.onThrottle
+	r.mutex.Unlock()
+	if onThrottle != nil {
+		onThrottle(service)
+	}
+}
+
+var _ aws.RetryerV2 = &Retryer{}
@@ -0,0 +1,114 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsretry_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/smithy-go"
+
+	"github.com/aws/aws-node-termination-handler/pkg/awsretry"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+type throttleCounts struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func (c *throttleCounts) inc(service string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.counts[service]++
+}
+
+func (c *throttleCounts) get(service string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.counts[service]
+}
+
+func TestThrottlesAreCountedPerService(t *testing.T) {
+	counts := &throttleCounts{counts: map[string]int{}}
+	retryer := awsretry.New(3, time.Second)
+	retryer.OnThrottle(counts.inc)
+
+	ec2Ctx := awsmiddleware.SetServiceID(context.Background(), "EC2")
+	release, err := retryer.GetAttemptToken(ec2Ctx)
+	h.Ok(t, err)
+	h.Ok(t, release(&smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"}))
+
+	release, err = retryer.GetAttemptToken(ec2Ctx)
+	h.Ok(t, err)
+	h.Ok(t, release(&smithy.GenericAPIError{Code: "RequestLimitExceeded"}))
+
+	release, err = retryer.GetAttemptToken(awsmiddleware.SetServiceID(context.Background(), "SQS"))
+	h.Ok(t, err)
+	h.Ok(t, release(nil))
+
+	h.Equals(t, 1, counts.get("EC2"))
+	h.Equals(t, 0, counts.get("SQS"))
+}
+
+func TestThrottledServiceDoesNotDelayOtherServices(t *testing.T) {
+	retryer := awsretry.New(3, time.Second)
+	ec2Ctx := awsmiddleware.SetServiceID(context.Background(), "EC2")
+	release, err := retryer.GetAttemptToken(ec2Ctx)
+	h.Ok(t, err)
+	h.Ok(t, release(&smithy.GenericAPIError{Code: "RequestLimitExceeded"}))
+
+	sqsCtx, cancel := context.WithTimeout(awsmiddleware.SetServiceID(context.Background(), "SQS"), 10*time.Millisecond)
+	defer cancel()
+	_, err = retryer.GetAttemptToken(sqsCtx)
+	h.Ok(t, err)
+
+	ec2Ctx, cancel = context.WithTimeout(ec2Ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = retryer.GetAttemptToken(ec2Ctx)
+	h.Nok(t, err)
+}
+
+func TestClientRetriesThrottles(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		attempts++
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(res, `<ErrorResponse><Error><Type>Sender</Type><Code>RequestThrottled</Code><Message>Rate exceeded</Message></Error></ErrorResponse>`)
+	}))
+	defer server.Close()
+
+	counts := &throttleCounts{counts: map[string]int{}}
+	retryer := awsretry.New(2, 10*time.Millisecond)
+	retryer.OnThrottle(counts.inc)
+	client := sqs.New(sqs.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: sqs.EndpointResolverFromURL(server.URL),
+		Retryer:          retryer,
+	})
+
+	_, err := client.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{QueueUrl: aws.String(server.URL + "/queue")})
+	h.Nok(t, err)
+	h.Equals(t, 2, attempts)
+	h.Equals(t, 2, counts.get("SQS"))
+}
@@ -0,0 +1,74 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package bottlerocket
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultAPISocketPath is where the Bottlerocket API server listens on the host
+	DefaultAPISocketPath = "/run/api.sock"
+	rebootPath           = "/actions/reboot"
+)
+
+// Client talks to the Bottlerocket API server over its unix domain socket
+type Client struct {
+	SocketPath string
+	httpClient http.Client
+}
+
+// New constructs a Bottlerocket API client for the given socket path
+func New(socketPath string) Client {
+	if socketPath == "" {
+		socketPath = DefaultAPISocketPath
+	}
+	return Client{
+		SocketPath: socketPath,
+		httpClient: http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Reboot asks the Bottlerocket API server to reboot the host
+func (c Client) Reboot() error {
+	// The host name is ignored since requests are always sent to the API socket
+	req, err := http.NewRequest(http.MethodPost, "http://localhost"+rebootPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Unable to reach the Bottlerocket API at %s: %w", c.SocketPath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Bottlerocket API responded to reboot request with http status code %d", resp.StatusCode)
+	}
+	log.Info().Msg("Requested a reboot from the Bottlerocket API")
+	return nil
+}
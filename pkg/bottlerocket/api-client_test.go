@@ -0,0 +1,67 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package bottlerocket_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/bottlerocket"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func serveOnSocket(t *testing.T, handler http.HandlerFunc) string {
+	dir, err := ioutil.TempDir("", "bottlerocket")
+	h.Ok(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", socketPath)
+	h.Ok(t, err)
+	server := &http.Server{Handler: handler}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return socketPath
+}
+
+func TestReboot(t *testing.T) {
+	requested := false
+	socketPath := serveOnSocket(t, func(rw http.ResponseWriter, req *http.Request) {
+		h.Equals(t, http.MethodPost, req.Method)
+		h.Equals(t, "/actions/reboot", req.URL.Path)
+		requested = true
+		rw.WriteHeader(http.StatusNoContent)
+	})
+
+	err := bottlerocket.New(socketPath).Reboot()
+	h.Ok(t, err)
+	h.Assert(t, requested, "Expected a reboot request to be sent to the API socket")
+}
+
+func TestRebootErrorStatus(t *testing.T) {
+	socketPath := serveOnSocket(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusLocked)
+	})
+
+	err := bottlerocket.New(socketPath).Reboot()
+	h.Assert(t, err != nil, "Expected an error when the API rejects the reboot")
+}
+
+func TestRebootMissingSocket(t *testing.T) {
+	err := bottlerocket.New("/does/not/exist/api.sock").Reboot()
+	h.Assert(t, err != nil, "Expected an error when the API socket does not exist")
+}
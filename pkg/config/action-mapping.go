@@ -0,0 +1,112 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Actions which can be mapped to interruption events
+const (
+	ActionNoOp               = "NoOp"
+	ActionNotify             = "Notify"
+	ActionCordon             = "Cordon"
+	ActionTaint              = "Taint"
+	ActionDrain              = "Drain"
+	ActionDrainAndDeleteNode = "DrainAndDeleteNode"
+	// ActionMappingWildcard matches any event kind or code
+	ActionMappingWildcard = "*"
+)
+
+// ActionMapping maps interruption events of a kind, and optionally a code, to the action taken for them
+type ActionMapping struct {
+	Kind string `json:"kind"`
+	// Code is the scheduled event code, spot instance action or AWS Health event type code. Empty matches any code.
+	Code   string `json:"code,omitempty"`
+	Action string `json:"action"`
+	// Timeout is the drain timeout in seconds. Zero uses node-termination-grace-period.
+	Timeout int `json:"timeout,omitempty"`
+	// EvictionRetryAttempts overrides eviction-retry-attempts. Zero uses eviction-retry-attempts.
+	EvictionRetryAttempts int `json:"evictionRetryAttempts,omitempty"`
+	// EvictionRetryInterval overrides eviction-retry-interval, in seconds. Zero uses eviction-retry-interval.
+	EvictionRetryInterval int `json:"evictionRetryInterval,omitempty"`
+	// EvictionRetryEscalation overrides eviction-retry-escalation. Empty uses eviction-retry-escalation.
+	EvictionRetryEscalation string `json:"evictionRetryEscalation,omitempty"`
+}
+
+// ActionMappingFile is the format of the file passed with action-mapping-file
+type ActionMappingFile struct {
+	ActionMappings []ActionMapping `json:"actionMappings"`
+}
+
+// LoadActionMappings reads and validates the action mappings in a YAML or JSON file
+func LoadActionMappings(path string) ([]ActionMapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open action mapping file: %w", err)
+	}
+	defer file.Close()
+	mappingFile := ActionMappingFile{}
+	err = yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&mappingFile)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse action mapping file %s: %w", path, err)
+	}
+	for i, mapping := range mappingFile.ActionMappings {
+		if mapping.Kind == "" {
+			return nil, fmt.Errorf("Action mapping %d must specify a kind", i)
+		}
+		switch mapping.Action {
+		case ActionNoOp, ActionNotify, ActionCordon, ActionTaint, ActionDrain, ActionDrainAndDeleteNode:
+		default:
+			return nil, fmt.Errorf("Invalid action %q in action mapping %d  Should be one of: %s, %s, %s, %s, %s, %s", mapping.Action, i, ActionNoOp, ActionNotify, ActionCordon, ActionTaint, ActionDrain, ActionDrainAndDeleteNode)
+		}
+		if mapping.Timeout < 0 {
+			return nil, fmt.Errorf("Action mapping %d has a negative timeout", i)
+		}
+		if mapping.EvictionRetryAttempts < 0 || mapping.EvictionRetryInterval < 0 {
+			return nil, fmt.Errorf("Action mapping %d has a negative eviction retry attempts or interval", i)
+		}
+		if mapping.EvictionRetryEscalation != "" && !isEvictionRetryEscalation(mapping.EvictionRetryEscalation) {
+			return nil, fmt.Errorf("Invalid eviction retry escalation %q in action mapping %d  Should be one of: retry, delete, give-up", mapping.EvictionRetryEscalation, i)
+		}
+	}
+	return mappingFile.ActionMappings, nil
+}
+
+// ActionMappingFor returns the first action mapping matching the event kind and code
+func (c Config) ActionMappingFor(kind string, code string) (ActionMapping, bool) {
+	for _, mapping := range c.ActionMappings {
+		if mapping.Kind != ActionMappingWildcard && mapping.Kind != kind {
+			continue
+		}
+		if mapping.Code != "" && mapping.Code != ActionMappingWildcard && mapping.Code != code {
+			continue
+		}
+		return mapping, true
+	}
+	return ActionMapping{}, false
+}
+
+// HasAction returns true if any action mapping uses the action
+func (c Config) HasAction(action string) bool {
+	for _, mapping := range c.ActionMappings {
+		if mapping.Action == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+const testActionMappings = `actionMappings:
+- kind: SCHEDULED_EVENT
+  code: system-maintenance
+  action: Notify
+- kind: SPOT_ITN
+  code: stop
+  action: Cordon
+- kind: "*"
+  action: Drain
+  timeout: 90
+`
+
+func writeActionMappingFile(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "action-mappings")
+	h.Ok(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "action-mappings.yaml")
+	h.Ok(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestLoadActionMappings(t *testing.T) {
+	mappings, err := config.LoadActionMappings(writeActionMappingFile(t, testActionMappings))
+	h.Ok(t, err)
+	h.Equals(t, 3, len(mappings))
+	nthConfig := config.Config{ActionMappings: mappings}
+
+	mapping, ok := nthConfig.ActionMappingFor("SCHEDULED_EVENT", "system-maintenance")
+	h.Assert(t, ok, "Expected a mapping for system-maintenance")
+	h.Equals(t, config.ActionNotify, mapping.Action)
+
+	mapping, ok = nthConfig.ActionMappingFor("SPOT_ITN", "stop")
+	h.Assert(t, ok, "Expected a mapping for spot stop")
+	h.Equals(t, config.ActionCordon, mapping.Action)
+
+	mapping, ok = nthConfig.ActionMappingFor("SPOT_ITN", "terminate")
+	h.Assert(t, ok, "Expected the wildcard mapping for spot terminate")
+	h.Equals(t, config.ActionDrain, mapping.Action)
+	h.Equals(t, 90, mapping.Timeout)
+
+	h.Assert(t, !nthConfig.HasAction(config.ActionDrainAndDeleteNode), "Expected no DrainAndDeleteNode mapping")
+	_, ok = config.Config{}.ActionMappingFor("SPOT_ITN", "terminate")
+	h.Assert(t, !ok, "Expected no mapping without action mappings")
+}
+
+func TestLoadActionMappingsJSON(t *testing.T) {
+	mappings, err := config.LoadActionMappings(writeActionMappingFile(t, `{"actionMappings": [{"kind": "SQS_TERMINATE", "action": "DrainAndDeleteNode"}]}`))
+	h.Ok(t, err)
+	h.Equals(t, 1, len(mappings))
+	h.Assert(t, config.Config{ActionMappings: mappings}.HasAction(config.ActionDrainAndDeleteNode), "Expected a DrainAndDeleteNode mapping")
+}
+
+func TestLoadActionMappingsEvictionRetries(t *testing.T) {
+	mappings, err := config.LoadActionMappings(writeActionMappingFile(t, "actionMappings:\n- kind: SPOT_ITN\n  action: Drain\n  evictionRetryAttempts: 6\n  evictionRetryInterval: 10\n  evictionRetryEscalation: delete\n"))
+	h.Ok(t, err)
+	h.Equals(t, 6, mappings[0].EvictionRetryAttempts)
+	h.Equals(t, 10, mappings[0].EvictionRetryInterval)
+	h.Equals(t, config.EvictionRetryEscalationDelete, mappings[0].EvictionRetryEscalation)
+}
+
+func TestLoadActionMappingsInvalid(t *testing.T) {
+	_, err := config.LoadActionMappings(writeActionMappingFile(t, "actionMappings:\n- kind: SPOT_ITN\n  action: Explode\n"))
+	h.Assert(t, err != nil, "Expected an error for an invalid action")
+
+	_, err = config.LoadActionMappings(writeActionMappingFile(t, "actionMappings:\n- action: Drain\n"))
+	h.Assert(t, err != nil, "Expected an error for a mapping without a kind")
+
+	_, err = config.LoadActionMappings(writeActionMappingFile(t, "actionMappings:\n- kind: SPOT_ITN\n  action: Drain\n  evictionRetryEscalation: panic\n"))
+	h.Assert(t, err != nil, "Expected an error for an invalid eviction retry escalation")
+
+	_, err = config.LoadActionMappings("/does/not/exist.yaml")
+	h.Assert(t, err != nil, "Expected an error for a missing file")
+}
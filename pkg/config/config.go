@@ -14,6 +14,7 @@
 package config
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -91,7 +92,231 @@ const (
 	kubernetesEventsExtraAnnotationsConfigKey = "KUBERNETES_EVENTS_EXTRA_ANNOTATIONS"
 	awsRegionConfigKey                        = "AWS_REGION"
 	awsEndpointConfigKey                      = "AWS_ENDPOINT"
+	awsUseFIPSEndpointConfigKey               = "AWS_USE_FIPS_ENDPOINT"
+	awsUseDualStackEndpointConfigKey          = "AWS_USE_DUALSTACK_ENDPOINT"
+	awsMaxAttemptsConfigKey                   = "AWS_MAX_ATTEMPTS"
+	awsMaxAttemptsDefault                     = 3
+	awsMaxBackoffConfigKey                    = "AWS_MAX_BACKOFF"
+	awsMaxBackoffDefault                      = 20
 	queueURLConfigKey                         = "QUEUE_URL"
+	ssmParameterPathConfigKey                 = "SSM_PARAMETER_PATH"
+	ssmParameterRefreshIntervalConfigKey      = "SSM_PARAMETER_REFRESH_INTERVAL"
+	ssmParameterRefreshIntervalDefault        = 0
+	secretsRefreshIntervalConfigKey           = "SECRETS_REFRESH_INTERVAL"
+	secretsRefreshIntervalDefault             = 300
+	vaultAddressConfigKey                     = "VAULT_ADDR"
+	vaultRoleConfigKey                        = "VAULT_ROLE"
+	vaultAuthPathConfigKey                    = "VAULT_AUTH_PATH"
+	vaultAuthPathDefault                      = "kubernetes"
+	vaultNamespaceConfigKey                   = "VAULT_NAMESPACE"
+	vaultCACertConfigKey                      = "VAULT_CACERT"
+	rbacSelfCheckConfigKey                    = "RBAC_SELF_CHECK"
+	rbacSelfCheckDefault                      = true
+	kubeconfigConfigKey                       = "KUBECONFIG"
+	kubeContextConfigKey                      = "KUBE_CONTEXT"
+	clusterAutoscalerCoordinationConfigKey    = "CLUSTER_AUTOSCALER_COORDINATION"
+	karpenterNodeHandlingConfigKey            = "KARPENTER_NODE_HANDLING"
+	detachFromASGConfigKey                    = "DETACH_FROM_ASG"
+	replacementWaitTimeoutConfigKey           = "REPLACEMENT_WAIT_TIMEOUT"
+	replacementWaitTimeoutDefault             = 300
+	waitForRebalanceReplacementConfigKey      = "WAIT_FOR_REBALANCE_REPLACEMENT"
+	publishNodeConditionsConfigKey            = "PUBLISH_NODE_CONDITIONS"
+	kubeletShutdownCoordinationConfigKey      = "KUBELET_SHUTDOWN_COORDINATION"
+	bottlerocketRebootConfigKey               = "BOTTLEROCKET_REBOOT"
+	bottlerocketAPISocketConfigKey            = "BOTTLEROCKET_API_SOCKET"
+	bottlerocketAPISocketDefault              = "/run/api.sock"
+	acceleratorEventActionConfigKey           = "ACCELERATOR_EVENT_ACTION"
+	acceleratorEventKeywordsConfigKey         = "ACCELERATOR_EVENT_KEYWORDS"
+	acceleratorEventKeywordsDefault           = "GPU,ACCELERATOR,NEURON"
+	acceleratorResourceNamesConfigKey         = "ACCELERATOR_RESOURCE_NAMES"
+	acceleratorResourceNamesDefault           = "nvidia.com/gpu,amd.com/gpu,aws.amazon.com/neuron,aws.amazon.com/neuroncore,aws.amazon.com/neurondevice"
+	spotStopHibernateActionConfigKey          = "SPOT_STOP_HIBERNATE_ACTION"
+	notifyOnlyEventCodesConfigKey             = "NOTIFY_ONLY_EVENT_CODES"
+	actionMappingFileConfigKey                = "ACTION_MAPPING_FILE"
+	capacityAwareRebalanceDrainConfigKey      = "CAPACITY_AWARE_REBALANCE_DRAIN"
+	drainDeferralTimeoutConfigKey             = "DRAIN_DEFERRAL_TIMEOUT"
+	drainMaxAttemptsConfigKey                 = "DRAIN_MAX_ATTEMPTS"
+	requireCapacityRebalanceConfigKey         = "REQUIRE_CAPACITY_REBALANCE"
+	scheduledEventDrainLeadTimeConfigKey      = "SCHEDULED_EVENT_DRAIN_LEAD_TIME"
+	drainLeadTimeConfigKey                    = "DRAIN_LEAD_TIME"
+	monitorPluginCommandConfigKey             = "MONITOR_PLUGIN_COMMAND"
+	monitorPluginTimeoutConfigKey             = "MONITOR_PLUGIN_TIMEOUT"
+	preDrainHookConfigKey                     = "PRE_DRAIN_HOOK"
+	postDrainHookConfigKey                    = "POST_DRAIN_HOOK"
+	postUncordonHookConfigKey                 = "POST_UNCORDON_HOOK"
+	hookTimeoutConfigKey                      = "HOOK_TIMEOUT"
+	preDrainLambdaHookConfigKey               = "PRE_DRAIN_LAMBDA_HOOK"
+	postDrainLambdaHookConfigKey              = "POST_DRAIN_LAMBDA_HOOK"
+	lambdaHookFailureActionConfigKey          = "LAMBDA_HOOK_FAILURE_ACTION"
+	preDrainSSMDocumentConfigKey              = "PRE_DRAIN_SSM_DOCUMENT"
+	preDrainSSMParametersConfigKey            = "PRE_DRAIN_SSM_PARAMETERS"
+	sSMHookTimeoutConfigKey                   = "SSM_HOOK_TIMEOUT"
+	sSMHookFailureActionConfigKey             = "SSM_HOOK_FAILURE_ACTION"
+	stepFunctionsHeartbeatIntervalConfigKey   = "STEP_FUNCTIONS_HEARTBEAT_INTERVAL"
+	drainStrategyConfigKey                    = "DRAIN_STRATEGY"
+	enableDrainPoliciesConfigKey              = "ENABLE_DRAIN_POLICIES"
+	enableTerminationEventResourcesConfigKey  = "ENABLE_TERMINATION_EVENT_RESOURCES"
+	enableStatusAPIConfigKey                  = "ENABLE_STATUS_API"
+	statusAPIPortConfigKey                    = "STATUS_API_PORT"
+	enableControlAPIConfigKey                 = "ENABLE_CONTROL_API"
+	enableSimulateAPIConfigKey                = "ENABLE_SIMULATE_API"
+	enablePushReceiverConfigKey               = "ENABLE_PUSH_RECEIVER"
+	pushReceiverPortConfigKey                 = "PUSH_RECEIVER_PORT"
+	pushReceiverSecretConfigKey               = "PUSH_RECEIVER_SECRET"
+	pushReceiverTLSCertFileConfigKey          = "PUSH_RECEIVER_TLS_CERT_FILE"
+	pushReceiverTLSKeyFileConfigKey           = "PUSH_RECEIVER_TLS_KEY_FILE"
+	snsTopicARNsConfigKey                     = "SNS_TOPIC_ARNS"
+	kafkaBrokersConfigKey                     = "KAFKA_BROKERS"
+	kafkaTopicConfigKey                       = "KAFKA_TOPIC"
+	kafkaGroupIDConfigKey                     = "KAFKA_GROUP_ID"
+	kafkaTLSConfigKey                         = "KAFKA_TLS"
+	kafkaTLSCAFileConfigKey                   = "KAFKA_TLS_CA_FILE"
+	kafkaSASLMechanismConfigKey               = "KAFKA_SASL_MECHANISM"
+	kafkaSASLUsernameConfigKey                = "KAFKA_SASL_USERNAME"
+	kafkaSASLPasswordConfigKey                = "KAFKA_SASL_PASSWORD"
+	natsURLConfigKey                          = "NATS_URL"
+	natsSubjectConfigKey                      = "NATS_SUBJECT"
+	natsStreamConfigKey                       = "NATS_STREAM"
+	natsConsumerNameConfigKey                 = "NATS_CONSUMER_NAME"
+	natsTLSCAFileConfigKey                    = "NATS_TLS_CA_FILE"
+	natsTokenConfigKey                        = "NATS_TOKEN"
+	natsUsernameConfigKey                     = "NATS_USERNAME"
+	natsPasswordConfigKey                     = "NATS_PASSWORD"
+	enableCloudWatchMetricsConfigKey          = "ENABLE_CLOUDWATCH_METRICS"
+	cloudWatchMetricsNamespaceConfigKey       = "CLOUDWATCH_METRICS_NAMESPACE"
+	cloudWatchMetricsDimensionsConfigKey      = "CLOUDWATCH_METRICS_DIMENSIONS"
+	cloudWatchMetricsIntervalConfigKey        = "CLOUDWATCH_METRICS_INTERVAL"
+	cloudWatchLogsGroupConfigKey              = "CLOUDWATCH_LOGS_GROUP"
+	cloudWatchLogsStreamConfigKey             = "CLOUDWATCH_LOGS_STREAM"
+	s3ExportBucketConfigKey                   = "S3_EXPORT_BUCKET"
+	s3ExportPrefixConfigKey                   = "S3_EXPORT_PREFIX"
+	s3ExportClusterNameConfigKey              = "S3_EXPORT_CLUSTER_NAME"
+	s3ExportIntervalConfigKey                 = "S3_EXPORT_INTERVAL"
+	enableEventBridgeEventsConfigKey          = "ENABLE_EVENTBRIDGE_EVENTS"
+	eventBridgeBusNameConfigKey               = "EVENTBRIDGE_BUS_NAME"
+	eventBridgeSourceConfigKey                = "EVENTBRIDGE_SOURCE"
+	enableCombinedModeConfigKey               = "ENABLE_COMBINED_MODE"
+	duplicateEventWindowConfigKey             = "DUPLICATE_EVENT_WINDOW"
+	clusterTagKeyConfigKey                    = "CLUSTER_TAG_KEY"
+	clusterKubeContextsConfigKey              = "CLUSTER_KUBE_CONTEXTS"
+	kubernetesWriteQPSConfigKey               = "KUBERNETES_WRITE_QPS"
+	kubernetesWriteBurstConfigKey             = "KUBERNETES_WRITE_BURST"
+	enableWorkerAutoscalingConfigKey          = "ENABLE_WORKER_AUTOSCALING"
+	minWorkersConfigKey                       = "MIN_WORKERS"
+	cachePrewarmTimeoutConfigKey              = "CACHE_PREWARM_TIMEOUT"
+	metricsBindAddressConfigKey               = "METRICS_BIND_ADDRESS"
+	metricsTLSCertFileConfigKey               = "METRICS_TLS_CERT_FILE"
+	metricsTLSKeyFileConfigKey                = "METRICS_TLS_KEY_FILE"
+	metricsBearerTokenConfigKey               = "METRICS_BEARER_TOKEN"
+	logSampleIntervalConfigKey                = "LOG_SAMPLE_INTERVAL"
+	eventJournalFileConfigKey                 = "EVENT_JOURNAL_FILE"
+	cordonedNodeHandlingConfigKey             = "CORDONED_NODE_HANDLING"
+	safeToEvictHandlingConfigKey              = "SAFE_TO_EVICT_HANDLING"
+	evictionTiersConfigKey                    = "EVICTION_TIERS"
+	jobCompletionWaitConfigKey                = "JOB_COMPLETION_WAIT"
+	enableContainerCheckpointsConfigKey       = "ENABLE_CONTAINER_CHECKPOINTS"
+	containerCheckpointTimeoutConfigKey       = "CONTAINER_CHECKPOINT_TIMEOUT"
+	defaultContainerCheckpointTimeout         = 30
+	enableTerminationNoticesConfigKey         = "ENABLE_TERMINATION_NOTICES"
+	terminationNoticeDelayConfigKey           = "TERMINATION_NOTICE_DELAY"
+	terminationCountdownIntervalConfigKey     = "TERMINATION_COUNTDOWN_INTERVAL"
+	canaryNodeSelectorConfigKey               = "CANARY_NODE_SELECTOR"
+	canaryPercentageConfigKey                 = "CANARY_PERCENTAGE"
+	defaultCanaryPercentage                   = 100
+	evictionRetryAttemptsConfigKey            = "EVICTION_RETRY_ATTEMPTS"
+	evictionRetryIntervalConfigKey            = "EVICTION_RETRY_INTERVAL"
+	defaultEvictionRetryInterval              = 5
+	evictionRetryEscalationConfigKey          = "EVICTION_RETRY_ESCALATION"
+	orphanedNodeGCIntervalConfigKey           = "ORPHANED_NODE_GC_INTERVAL"
+	enableNamespaceDrainPoliciesConfigKey     = "ENABLE_NAMESPACE_DRAIN_POLICIES"
+	maxDrainsPerAZConfigKey                   = "MAX_DRAINS_PER_AZ"
+	rescheduleVerificationTimeoutConfigKey    = "RESCHEDULE_VERIFICATION_TIMEOUT"
+	defaultDrainDeferralTimeout               = 600
+	defaultDrainMaxAttempts                   = 5
+	defaultMonitorPluginTimeout               = 10
+	defaultHookTimeout                        = 30
+	defaultLambdaHookFailureAction            = HookFailureActionContinue
+	defaultSSMHookTimeout                     = 300
+	defaultSSMHookFailureAction               = HookFailureActionContinue
+	defaultStepFunctionsHeartbeatInterval     = 60
+	defaultDrainStrategy                      = "drain"
+	defaultStatusAPIPort                      = 8090
+	defaultPushReceiverPort                   = 8443
+	defaultKafkaGroupID                       = "aws-node-termination-handler"
+	defaultNATSConsumerName                   = "aws-node-termination-handler"
+	defaultCloudWatchMetricsNamespace         = "AWSNodeTerminationHandler"
+	defaultCloudWatchMetricsInterval          = 60
+	defaultS3ExportInterval                   = 300
+	defaultEventBridgeBusName                 = "default"
+	defaultEventBridgeSource                  = "aws-node-termination-handler"
+	defaultDuplicateEventWindow               = 600
+	defaultKubernetesWriteBurst               = 10
+	defaultMinWorkers                         = 1
+	defaultCachePrewarmTimeout                = 30
+	defaultLogSampleInterval                  = 60
+)
+
+// Karpenter node handling modes
+const (
+	// KarpenterNodeHandlingDrain cordons and drains karpenter nodes like any other node
+	KarpenterNodeHandlingDrain = "drain"
+	// KarpenterNodeHandlingDelete deletes karpenter nodes so karpenter replaces them immediately
+	KarpenterNodeHandlingDelete = "delete"
+	// KarpenterNodeHandlingSkip leaves karpenter nodes to karpenter's own interruption handling
+	KarpenterNodeHandlingSkip = "skip"
+)
+
+// Handling modes of nodes already cordoned or tainted for termination by another controller
+const (
+	// CordonedNodeHandlingLayer handles the nodes like any other node
+	CordonedNodeHandlingLayer = "layer"
+	// CordonedNodeHandlingSkip leaves the nodes to the other controller
+	CordonedNodeHandlingSkip = "skip"
+	// CordonedNodeHandlingDrainOnly evicts the pods of the nodes without cordoning or tainting them
+	CordonedNodeHandlingDrainOnly = "drain-only"
+	// CordonedNodeHandlingAdopt handles the nodes like any other node, but leaves them unschedulable when uncordoning
+	CordonedNodeHandlingAdopt = "adopt"
+)
+
+// Handling modes of pods annotated with cluster-autoscaler.kubernetes.io/safe-to-evict=false
+const (
+	// SafeToEvictHandlingIgnore evicts the pods like any other pod
+	SafeToEvictHandlingIgnore = "ignore"
+	// SafeToEvictHandlingLast evicts the pods once the other pods of the node are gone
+	SafeToEvictHandlingLast = "last"
+	// SafeToEvictHandlingSkip leaves the pods running
+	SafeToEvictHandlingSkip = "skip"
+)
+
+// Escalations of evictions still blocked once the eviction retry attempts are used up
+const (
+	// EvictionRetryEscalationRetry keeps retrying the eviction until the drain times out
+	EvictionRetryEscalationRetry = "retry"
+	// EvictionRetryEscalationDelete deletes the pod, bypassing pod disruption budgets
+	EvictionRetryEscalationDelete = "delete"
+	// EvictionRetryEscalationGiveUp fails the drain
+	EvictionRetryEscalationGiveUp = "give-up"
+)
+
+const (
+	// AcceleratorEventActionDrain drains the node for accelerator events like any other interruption
+	AcceleratorEventActionDrain = "drain"
+	// AcceleratorEventActionEvictAcceleratorPods cordons the node and only evicts pods requesting accelerator resources
+	AcceleratorEventActionEvictAcceleratorPods = "evict-accelerator-pods"
+)
+
+const (
+	// SpotStopHibernateActionDrain drains nodes of spot instances which will be stopped or hibernated
+	SpotStopHibernateActionDrain = "drain"
+	// SpotStopHibernateActionCordon only cordons nodes of spot instances which will be stopped or hibernated
+	SpotStopHibernateActionCordon = "cordon"
+)
+
+const (
+	// HookFailureActionContinue goes on handling the event when a hook can't be run or fails
+	HookFailureActionContinue = "continue"
+	// HookFailureActionAbort stops handling the event when a hook can't be run or fails
+	HookFailureActionAbort = "abort"
 )
 
 //Config arguments set via CLI, environment variables, or defaults
@@ -132,8 +357,153 @@ type Config struct {
 	KubernetesEventsExtraAnnotations string
 	AWSRegion                        string
 	AWSEndpoint                      string
+	AWSUseFIPSEndpoint               bool
+	AWSUseDualStackEndpoint          bool
+	AWSMaxAttempts                   int
+	AWSMaxBackoff                    int
 	QueueURL                         string
 	Workers                          int
+	SSMParameterPath                 string
+	SSMParameterRefreshInterval      int
+	SecretsRefreshInterval           int
+	VaultAddress                     string
+	VaultRole                        string
+	VaultAuthPath                    string
+	VaultNamespace                   string
+	VaultCACert                      string
+	RBACSelfCheck                    bool
+	Kubeconfig                       string
+	KubeContext                      string
+	ClusterAutoscalerCoordination    bool
+	KarpenterNodeHandling            string
+	DetachFromASG                    bool
+	ReplacementWaitTimeout           int
+	WaitForRebalanceReplacement      bool
+	PublishNodeConditions            bool
+	KubeletShutdownCoordination      bool
+	BottlerocketReboot               bool
+	BottlerocketAPISocket            string
+	AcceleratorEventAction           string
+	AcceleratorEventKeywords         string
+	AcceleratorResourceNames         string
+	SpotStopHibernateAction          string
+	NotifyOnlyEventCodes             string
+	ActionMappingFile                string
+	ActionMappings                   []ActionMapping
+	PreDrainSSMParameterValues       map[string]string
+	ClusterContexts                  map[string]string
+	CapacityAwareRebalanceDrain      bool
+	DrainDeferralTimeout             int
+	DrainMaxAttempts                 int
+	RequireCapacityRebalance         bool
+	ScheduledEventDrainLeadTime      int
+	DrainLeadTime                    int
+	MonitorPluginCommand             string
+	MonitorPluginTimeout             int
+	PreDrainHook                     string
+	PostDrainHook                    string
+	PostUncordonHook                 string
+	HookTimeout                      int
+	PreDrainLambdaHook               string
+	PostDrainLambdaHook              string
+	LambdaHookFailureAction          string
+	PreDrainSSMDocument              string
+	PreDrainSSMParameters            string
+	SSMHookTimeout                   int
+	SSMHookFailureAction             string
+	StepFunctionsHeartbeatInterval   int
+	DrainStrategy                    string
+	EnableDrainPolicies              bool
+	EnableTerminationEventResources  bool
+	EnableStatusAPI                  bool
+	StatusAPIPort                    int
+	EnableControlAPI                 bool
+	EnableSimulateAPI                bool
+	EnablePushReceiver               bool
+	PushReceiverPort                 int
+	PushReceiverSecret               string
+	PushReceiverTLSCertFile          string
+	PushReceiverTLSKeyFile           string
+	SNSTopicARNs                     string
+	KafkaBrokers                     string
+	KafkaTopic                       string
+	KafkaGroupID                     string
+	KafkaTLS                         bool
+	KafkaTLSCAFile                   string
+	KafkaSASLMechanism               string
+	KafkaSASLUsername                string
+	KafkaSASLPassword                string
+	NATSURL                          string
+	NATSSubject                      string
+	NATSStream                       string
+	NATSConsumerName                 string
+	NATSTLSCAFile                    string
+	NATSToken                        string
+	NATSUsername                     string
+	NATSPassword                     string
+	EnableCloudWatchMetrics          bool
+	CloudWatchMetricsNamespace       string
+	CloudWatchMetricsDimensions      string
+	CloudWatchMetricsInterval        int
+	CloudWatchLogsGroup              string
+	CloudWatchLogsStream             string
+	S3ExportBucket                   string
+	S3ExportPrefix                   string
+	S3ExportClusterName              string
+	S3ExportInterval                 int
+	EnableEventBridgeEvents          bool
+	EventBridgeBusName               string
+	EventBridgeSource                string
+	EnableCombinedMode               bool
+	DuplicateEventWindow             int
+	ClusterTagKey                    string
+	ClusterKubeContexts              string
+	KubernetesWriteQPS               int
+	KubernetesWriteBurst             int
+	EnableWorkerAutoscaling          bool
+	MinWorkers                       int
+	CachePrewarmTimeout              int
+	MetricsBindAddress               string
+	MetricsTLSCertFile               string
+	MetricsTLSKeyFile                string
+	MetricsBearerToken               string
+	LogSampleInterval                int
+	EventJournalFile                 string
+	CordonedNodeHandling             string
+	SafeToEvictHandling              string
+	EvictionTiers                    string
+	JobCompletionWait                int
+	EnableContainerCheckpoints       bool
+	ContainerCheckpointTimeout       int
+	EnableTerminationNotices         bool
+	TerminationNoticeDelay           int
+	TerminationCountdownInterval     int
+	CanaryNodeSelector               string
+	CanaryPercentage                 int
+	EvictionRetryAttempts            int
+	EvictionRetryInterval            int
+	EvictionRetryEscalation          string
+	OrphanedNodeGCInterval           int
+	EnableNamespaceDrainPolicies     bool
+	MaxDrainsPerAZ                   int
+	RescheduleVerificationTimeout    int
+}
+
+// parseClusterContexts parses comma separated cluster=context pairs, a cluster without context maps to the context named after it
+func parseClusterContexts(clusterContexts string) (map[string]string, error) {
+	contexts := map[string]string{}
+	for _, pair := range strings.Split(clusterContexts, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		cluster, context := parts[0], parts[0]
+		if len(parts) == 2 {
+			context = parts[1]
+		}
+		if cluster == "" || context == "" {
+			return nil, fmt.Errorf("Unable to parse cluster-kube-contexts entry %q, must be cluster=context or cluster", pair)
+		}
+		contexts[cluster] = context
+	}
+	return contexts, nil
 }
 
 //ParseCliArgs parses cli arguments and uses environment variables as fallback values
@@ -184,11 +554,386 @@ func ParseCliArgs() (config Config, err error) {
 	flag.StringVar(&config.KubernetesEventsExtraAnnotations, "kubernetes-events-extra-annotations", getEnv(kubernetesEventsExtraAnnotationsConfigKey, ""), "A comma-separated list of key=value extra annotations to attach to all emitted Kubernetes events. Example: --kubernetes-events-extra-annotations first=annotation,sample.annotation/number=two")
 	flag.StringVar(&config.AWSRegion, "aws-region", getEnv(awsRegionConfigKey, ""), "If specified, use the AWS region for AWS API calls")
 	flag.StringVar(&config.AWSEndpoint, "aws-endpoint", getEnv(awsEndpointConfigKey, ""), "[testing] If specified, use the AWS endpoint to make API calls")
+	flag.BoolVar(&config.AWSUseFIPSEndpoint, "aws-use-fips-endpoint", getBoolEnv(awsUseFIPSEndpointConfigKey, false), "If true, make AWS API calls to the FIPS endpoints of the services.")
+	flag.BoolVar(&config.AWSUseDualStackEndpoint, "aws-use-dualstack-endpoint", getBoolEnv(awsUseDualStackEndpointConfigKey, false), "If true, make AWS API calls to the dual-stack (IPv4 and IPv6) endpoints of the services.")
+	flag.IntVar(&config.AWSMaxAttempts, "aws-max-attempts", getIntEnv(awsMaxAttemptsConfigKey, awsMaxAttemptsDefault), "The maximum number of attempts of an AWS API call which fails with a retryable error, e.g. throttling.")
+	flag.IntVar(&config.AWSMaxBackoff, "aws-max-backoff", getIntEnv(awsMaxBackoffConfigKey, awsMaxBackoffDefault), "The maximum period of time in seconds to back off between the attempts of an AWS API call.")
 	flag.StringVar(&config.QueueURL, "queue-url", getEnv(queueURLConfigKey, ""), "Listens for messages on the specified SQS queue URL")
 	flag.IntVar(&config.Workers, "workers", getIntEnv(workersConfigKey, workersDefault), "The amount of parallel event processors.")
+	flag.StringVar(&config.SSMParameterPath, "ssm-parameter-path", getEnv(ssmParameterPathConfigKey, ""), "If specified, load configuration values from the SSM Parameter Store parameters under this path. Parameter names are the environment variable names (e.g. /nth/prod/WEBHOOK_URL).")
+	flag.IntVar(&config.SSMParameterRefreshInterval, "ssm-parameter-refresh-interval", getIntEnv(ssmParameterRefreshIntervalConfigKey, ssmParameterRefreshIntervalDefault), "Period of time in seconds between reloads of the SSM Parameter Store configuration. If zero, parameters are only loaded at startup.")
+
+	flag.IntVar(&config.SecretsRefreshInterval, "secrets-refresh-interval", getIntEnv(secretsRefreshIntervalConfigKey, secretsRefreshIntervalDefault), "Period of time in seconds a value resolved from a secret reference (e.g. a Secrets Manager ARN passed as webhook-url) is cached before it is fetched again to pick up rotations.")
+	flag.StringVar(&config.VaultAddress, "vault-address", getEnv(vaultAddressConfigKey, ""), "If specified, secret references of the form vault:<path>#<key> are read from the HashiCorp Vault server at this address.")
+	flag.StringVar(&config.VaultRole, "vault-role", getEnv(vaultRoleConfigKey, ""), "The Vault role to log in as using the Kubernetes auth method.")
+	flag.StringVar(&config.VaultAuthPath, "vault-auth-path", getEnv(vaultAuthPathConfigKey, vaultAuthPathDefault), "The mount path of the Vault Kubernetes auth method.")
+	flag.StringVar(&config.VaultNamespace, "vault-namespace", getEnv(vaultNamespaceConfigKey, ""), "The Vault Enterprise namespace to use.")
+	flag.StringVar(&config.VaultCACert, "vault-cacert", getEnv(vaultCACertConfigKey, ""), "Path to a PEM encoded CA certificate used to verify the Vault server's TLS certificate.")
+	flag.BoolVar(&config.RBACSelfCheck, "rbac-self-check", getBoolEnv(rbacSelfCheckConfigKey, rbacSelfCheckDefault), "If true, check at startup that all kubernetes RBAC permissions required by the configuration are granted and report the missing ones.")
+	flag.StringVar(&config.Kubeconfig, "kubeconfig", getEnv(kubeconfigConfigKey, ""), "If specified, connect to the kubernetes api server using this kubeconfig file instead of the in-cluster config. Allows running outside of the cluster.")
+	flag.StringVar(&config.KubeContext, "kube-context", getEnv(kubeContextConfigKey, ""), "The kubeconfig context to use. Defaults to the current context of the kubeconfig file.")
+	flag.BoolVar(&config.ClusterAutoscalerCoordination, "cluster-autoscaler-coordination", getBoolEnv(clusterAutoscalerCoordinationConfigKey, false), "If true, nodes are annotated with cluster-autoscaler's scale-down-disabled annotation and tainted with its ToBeDeletedByClusterAutoscaler taint when cordoned, so cluster-autoscaler neither scales them down nor counts them as capacity.")
+	flag.StringVar(&config.KarpenterNodeHandling, "karpenter-node-handling", getEnv(karpenterNodeHandlingConfigKey, KarpenterNodeHandlingDrain), "How interruptions of nodes launched by karpenter are handled: drain (cordon and drain like any other node), delete (delete the node so karpenter drains it and launches a replacement immediately) or skip (leave the node to karpenter's own interruption handling).")
+	flag.BoolVar(&config.DetachFromASG, "detach-from-asg", getBoolEnv(detachFromASGConfigKey, false), "If true, on instance retirement events and spot interruptions terminating the instance, the instance is detached from its Auto Scaling Group without decrementing the desired capacity, and draining waits until the replacement instance's node is Ready.")
+	flag.IntVar(&config.ReplacementWaitTimeout, "replacement-wait-timeout", getIntEnv(replacementWaitTimeoutConfigKey, replacementWaitTimeoutDefault), "Maximum period of time in seconds to wait for replacement capacity to become Ready before draining anyway.")
+	flag.BoolVar(&config.WaitForRebalanceReplacement, "wait-for-rebalance-replacement", getBoolEnv(waitForRebalanceReplacementConfigKey, false), "If true, draining on a rebalance recommendation waits until replacement capacity is Ready: the Auto Scaling Group's desired capacity is fulfilled without the instance, or a new node is Ready in the same zone and node group.")
+	flag.BoolVar(&config.PublishNodeConditions, "publish-node-conditions", getBoolEnv(publishNodeConditionsConfigKey, false), "If true, interruptions are published as node-problem-detector style node conditions (e.g. SpotInterruption=True) which are removed when the node is uncordoned.")
+	flag.BoolVar(&config.KubeletShutdownCoordination, "kubelet-shutdown-coordination", getBoolEnv(kubeletShutdownCoordinationConfigKey, false), "If true, pods are not evicted when kubelet's graceful node shutdown is already terminating them, and nodes being drained are annotated with aws-node-termination-handler/drain-started.")
+	flag.BoolVar(&config.BottlerocketReboot, "bottlerocket-reboot", getBoolEnv(bottlerocketRebootConfigKey, false), "If true, Bottlerocket nodes are rebooted through the Bottlerocket API once they are drained for a reboot scheduled event, instead of waiting for the maintenance window.")
+	flag.StringVar(&config.BottlerocketAPISocket, "bottlerocket-api-socket", getEnv(bottlerocketAPISocketConfigKey, bottlerocketAPISocketDefault), "The path of the Bottlerocket API server's unix domain socket.")
+	flag.StringVar(&config.AcceleratorEventAction, "accelerator-event-action", getEnv(acceleratorEventActionConfigKey, AcceleratorEventActionDrain), "The action taken for events which only impact accelerators: drain or evict-accelerator-pods.")
+	flag.StringVar(&config.AcceleratorEventKeywords, "accelerator-event-keywords", getEnv(acceleratorEventKeywordsConfigKey, acceleratorEventKeywordsDefault), "Comma separated keywords which identify accelerator events when found in a scheduled event or AWS Health event description.")
+	flag.StringVar(&config.AcceleratorResourceNames, "accelerator-resource-names", getEnv(acceleratorResourceNamesConfigKey, acceleratorResourceNamesDefault), "Comma separated extended resource names of accelerators. Pods requesting any of them are evicted for accelerator events.")
+	flag.StringVar(&config.SpotStopHibernateAction, "spot-stop-hibernate-action", getEnv(spotStopHibernateActionConfigKey, SpotStopHibernateActionDrain), "The action taken when a spot instance will be stopped or hibernated rather than terminated: drain or cordon. The node object is kept in both cases.")
+	flag.StringVar(&config.NotifyOnlyEventCodes, "notify-only-event-codes", getEnv(notifyOnlyEventCodesConfigKey, ""), "Comma separated scheduled event codes (e.g. network-maintenance,power-maintenance) which only send notifications, without cordoning or draining the node.")
+	flag.StringVar(&config.ActionMappingFile, "action-mapping-file", getEnv(actionMappingFileConfigKey, ""), "If specified, the path of a YAML or JSON file mapping event kinds and codes to actions. Mapped events ignore the individual action flags.")
+	flag.BoolVar(&config.CapacityAwareRebalanceDrain, "capacity-aware-rebalance-drain", getBoolEnv(capacityAwareRebalanceDrainConfigKey, false), "If true, nodes are only cordoned on a rebalance recommendation until the other nodes in the same zone and node group can absorb their pods' requests, or drain-deferral-timeout passes.")
+	flag.IntVar(&config.DrainDeferralTimeout, "drain-deferral-timeout", getIntEnv(drainDeferralTimeoutConfigKey, defaultDrainDeferralTimeout), "Maximum period of time in seconds to defer draining while waiting for capacity.")
+	flag.IntVar(&config.DrainMaxAttempts, "drain-max-attempts", getIntEnv(drainMaxAttemptsConfigKey, defaultDrainMaxAttempts), "The maximum number of attempts to handle an event whose drain fails, retried with an exponential backoff.")
+	flag.BoolVar(&config.RequireCapacityRebalance, "require-capacity-rebalance", getBoolEnv(requireCapacityRebalanceConfigKey, false), "If true, rebalance recommendations are ignored for instances in Auto Scaling Groups which do not have Capacity Rebalancing enabled, since no replacement is launched for them.")
+	flag.IntVar(&config.ScheduledEventDrainLeadTime, "scheduled-event-drain-lead-time", getIntEnv(scheduledEventDrainLeadTimeConfigKey, 0), "If greater than 0, the period of time in seconds before a scheduled event's NotBefore time to start draining, instead of node-termination-grace-period. The drain time is persisted on the node so it survives restarts.")
+	flag.IntVar(&config.DrainLeadTime, "drain-lead-time", getIntEnv(drainLeadTimeConfigKey, 0), "If greater than 0, spot interruption notices and rebalance recommendations are drained only this many seconds before the end of the 2 minute spot interruption window, instead of immediately.")
+	flag.StringVar(&config.MonitorPluginCommand, "monitor-plugin-command", getEnv(monitorPluginCommandConfigKey, ""), "If set, an executable (with optional arguments) run every 2 seconds which writes a JSON array of interruption events to stdout.")
+	flag.IntVar(&config.MonitorPluginTimeout, "monitor-plugin-timeout", getIntEnv(monitorPluginTimeoutConfigKey, defaultMonitorPluginTimeout), "Period of time in seconds after which the monitor plugin is killed.")
+	flag.StringVar(&config.PreDrainHook, "pre-drain-hook", getEnv(preDrainHookConfigKey, ""), "A command run before the node is cordoned and drained, with the event details in NTH_ prefixed environment variables.")
+	flag.StringVar(&config.PostDrainHook, "post-drain-hook", getEnv(postDrainHookConfigKey, ""), "A command run after the node is cordoned and drained, with the event details in NTH_ prefixed environment variables.")
+	flag.StringVar(&config.PostUncordonHook, "post-uncordon-hook", getEnv(postUncordonHookConfigKey, ""), "A command run after the node is uncordoned, with the event details in NTH_ prefixed environment variables.")
+	flag.IntVar(&config.HookTimeout, "hook-timeout", getIntEnv(hookTimeoutConfigKey, defaultHookTimeout), "Period of time in seconds after which a hook command is killed.")
+	flag.StringVar(&config.PreDrainLambdaHook, "pre-drain-lambda-hook", getEnv(preDrainLambdaHookConfigKey, ""), "If specified, name or ARN of a Lambda function synchronously invoked with the event before draining a node.")
+	flag.StringVar(&config.PostDrainLambdaHook, "post-drain-lambda-hook", getEnv(postDrainLambdaHookConfigKey, ""), "If specified, name or ARN of a Lambda function synchronously invoked with the event after draining a node.")
+	flag.StringVar(&config.LambdaHookFailureAction, "lambda-hook-failure-action", getEnv(lambdaHookFailureActionConfigKey, defaultLambdaHookFailureAction), "Action taken when a hook can't be run or fails. Options are continue and abort; abort stops handling the event.")
+	flag.StringVar(&config.PreDrainSSMDocument, "pre-drain-ssm-document", getEnv(preDrainSSMDocumentConfigKey, ""), "If specified, name or ARN of an SSM document run with Run Command on the instance before draining its node.")
+	flag.StringVar(&config.PreDrainSSMParameters, "pre-drain-ssm-parameters", getEnv(preDrainSSMParametersConfigKey, ""), "JSON object of parameter names and values passed to the pre-drain SSM document.")
+	flag.IntVar(&config.SSMHookTimeout, "ssm-hook-timeout", getIntEnv(sSMHookTimeoutConfigKey, defaultSSMHookTimeout), "Period of time in seconds after which the pre-drain SSM command is canceled.")
+	flag.StringVar(&config.SSMHookFailureAction, "ssm-hook-failure-action", getEnv(sSMHookFailureActionConfigKey, defaultSSMHookFailureAction), "Action taken when the pre-drain SSM command can't be sent, fails or times out. Options are continue and abort; abort stops handling the event.")
+	flag.IntVar(&config.StepFunctionsHeartbeatInterval, "step-functions-heartbeat-interval", getIntEnv(stepFunctionsHeartbeatIntervalConfigKey, defaultStepFunctionsHeartbeatInterval), "Period of time in seconds between heartbeats sent to Step Functions executions waiting for an event with a task token. 0 disables heartbeats.")
+	flag.StringVar(&config.DrainStrategy, "drain-strategy", getEnv(drainStrategyConfigKey, defaultDrainStrategy), "Strategy used to remove pods from nodes. Built-in options are drain, taint-and-wait, priority-tiered, label-tiered and delete-only.")
+	flag.BoolVar(&config.EnableDrainPolicies, "enable-drain-policies", getBoolEnv(enableDrainPoliciesConfigKey, false), "If true, consult the DrainPolicy custom resources of pods when draining nodes.")
+	flag.BoolVar(&config.EnableTerminationEventResources, "enable-termination-event-resources", getBoolEnv(enableTerminationEventResourcesConfigKey, false), "If true, record every handled event as a cluster-scoped TerminationEvent custom resource.")
+	flag.BoolVar(&config.EnableStatusAPI, "enable-status-api", getBoolEnv(enableStatusAPIConfigKey, false), "If true, serve a status API and web dashboard with live and recent events, drain progress and the configuration.")
+	flag.IntVar(&config.StatusAPIPort, "status-api-port", getIntEnv(statusAPIPortConfigKey, defaultStatusAPIPort), "The port to serve the status API and dashboard on.")
+	flag.BoolVar(&config.EnableControlAPI, "enable-control-api", getBoolEnv(enableControlAPIConfigKey, false), "If true, serve endpoints on the status API to pause and resume handling events and approve draining nodes while paused. Requires enable-status-api and metrics-bearer-token.")
+	flag.BoolVar(&config.EnableSimulateAPI, "enable-simulate-api", getBoolEnv(enableSimulateAPIConfigKey, false), "If true, serve an endpoint on the control API to simulate interruption events, which cordon and drain the node. Requires enable-control-api.")
+	flag.BoolVar(&config.EnablePushReceiver, "enable-push-receiver", getBoolEnv(enablePushReceiverConfigKey, false), "If true, serve an endpoint accepting Amazon EventBridge events pushed by external systems, as an alternative or in addition to polling the SQS queue. Requires enable-sqs-termination-draining.")
+	flag.IntVar(&config.PushReceiverPort, "push-receiver-port", getIntEnv(pushReceiverPortConfigKey, defaultPushReceiverPort), "The port to accept pushed events on.")
+	flag.StringVar(&config.PushReceiverSecret, "push-receiver-secret", getEnv(pushReceiverSecretConfigKey, ""), "The shared secret pushed events are authenticated with, sent as a bearer token or used to sign an HS256 JWT bearer token.")
+	flag.StringVar(&config.PushReceiverTLSCertFile, "push-receiver-tls-cert-file", getEnv(pushReceiverTLSCertFileConfigKey, ""), "Path to the TLS certificate to serve pushed events over HTTPS with.")
+	flag.StringVar(&config.PushReceiverTLSKeyFile, "push-receiver-tls-key-file", getEnv(pushReceiverTLSKeyFileConfigKey, ""), "Path to the TLS private key to serve pushed events over HTTPS with.")
+	flag.StringVar(&config.SNSTopicARNs, "sns-topic-arns", getEnv(snsTopicARNsConfigKey, ""), "Comma separated ARNs of Amazon SNS topics to accept messages from on the SNS HTTPS subscription endpoint of the push receiver. Subscriptions are confirmed automatically. Requires enable-push-receiver.")
+	flag.StringVar(&config.KafkaBrokers, "kafka-brokers", getEnv(kafkaBrokersConfigKey, ""), "Comma separated Kafka bootstrap brokers to consume Amazon EventBridge events from, as an alternative or in addition to polling the SQS queue. Requires enable-sqs-termination-draining.")
+	flag.StringVar(&config.KafkaTopic, "kafka-topic", getEnv(kafkaTopicConfigKey, ""), "The Kafka topic carrying the Amazon EventBridge events.")
+	flag.StringVar(&config.KafkaGroupID, "kafka-group-id", getEnv(kafkaGroupIDConfigKey, defaultKafkaGroupID), "The Kafka consumer group the handler replicas join, each event is consumed by one replica.")
+	flag.BoolVar(&config.KafkaTLS, "kafka-tls", getBoolEnv(kafkaTLSConfigKey, false), "If true, connect to the Kafka brokers over TLS.")
+	flag.StringVar(&config.KafkaTLSCAFile, "kafka-tls-ca-file", getEnv(kafkaTLSCAFileConfigKey, ""), "Path to the CA certificate the Kafka broker certificates are verified with, the system CAs are used if empty.")
+	flag.StringVar(&config.KafkaSASLMechanism, "kafka-sasl-mechanism", getEnv(kafkaSASLMechanismConfigKey, ""), "The SASL mechanism to authenticate to Kafka with, one of PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512. SASL is not used if empty.")
+	flag.StringVar(&config.KafkaSASLUsername, "kafka-sasl-username", getEnv(kafkaSASLUsernameConfigKey, ""), "The SASL username to authenticate to Kafka with.")
+	flag.StringVar(&config.KafkaSASLPassword, "kafka-sasl-password", getEnv(kafkaSASLPasswordConfigKey, ""), "The SASL password to authenticate to Kafka with.")
+	flag.StringVar(&config.NATSURL, "nats-url", getEnv(natsURLConfigKey, ""), "Comma separated NATS server URLs to consume Amazon EventBridge events from, as an alternative or in addition to polling the SQS queue. tls:// URLs connect over TLS. Requires enable-sqs-termination-draining.")
+	flag.StringVar(&config.NATSSubject, "nats-subject", getEnv(natsSubjectConfigKey, ""), "The NATS subject carrying the Amazon EventBridge events, it filters the messages of nats-stream if both are set.")
+	flag.StringVar(&config.NATSStream, "nats-stream", getEnv(natsStreamConfigKey, ""), "The JetStream stream to consume with a durable consumer, which acknowledges events once they are handled. The subject is subscribed to with core NATS if empty.")
+	flag.StringVar(&config.NATSConsumerName, "nats-consumer-name", getEnv(natsConsumerNameConfigKey, defaultNATSConsumerName), "The durable JetStream consumer, or the core NATS queue group, the handler replicas share, each event is handled by one replica.")
+	flag.StringVar(&config.NATSTLSCAFile, "nats-tls-ca-file", getEnv(natsTLSCAFileConfigKey, ""), "Path to the CA certificate the NATS server certificates are verified with, the system CAs are used if empty.")
+	flag.StringVar(&config.NATSToken, "nats-token", getEnv(natsTokenConfigKey, ""), "The token to authenticate to NATS with.")
+	flag.StringVar(&config.NATSUsername, "nats-username", getEnv(natsUsernameConfigKey, ""), "The username to authenticate to NATS with.")
+	flag.StringVar(&config.NATSPassword, "nats-password", getEnv(natsPasswordConfigKey, ""), "The password to authenticate to NATS with.")
+	flag.BoolVar(&config.EnableCloudWatchMetrics, "enable-cloudwatch-metrics", getBoolEnv(enableCloudWatchMetricsConfigKey, false), "If true, publish the handler counters, e.g. received interruption events and drain results, as Amazon CloudWatch custom metrics.")
+	flag.StringVar(&config.CloudWatchMetricsNamespace, "cloudwatch-metrics-namespace", getEnv(cloudWatchMetricsNamespaceConfigKey, defaultCloudWatchMetricsNamespace), "The CloudWatch namespace to publish the metrics under.")
+	flag.StringVar(&config.CloudWatchMetricsDimensions, "cloudwatch-metrics-dimensions", getEnv(cloudWatchMetricsDimensionsConfigKey, ""), "Comma separated Name=Value dimensions added to every CloudWatch metric, e.g. ClusterName=prod.")
+	flag.IntVar(&config.CloudWatchMetricsInterval, "cloudwatch-metrics-interval", getIntEnv(cloudWatchMetricsIntervalConfigKey, defaultCloudWatchMetricsInterval), "The interval in seconds to publish the CloudWatch metrics at.")
+	flag.StringVar(&config.CloudWatchLogsGroup, "cloudwatch-logs-group", getEnv(cloudWatchLogsGroupConfigKey, ""), "If specified, ship the audit record of each handled event to this CloudWatch Logs group. The group and stream are created if they don't exist.")
+	flag.StringVar(&config.CloudWatchLogsStream, "cloudwatch-logs-stream", getEnv(cloudWatchLogsStreamConfigKey, ""), "The CloudWatch Logs stream to ship the audit records to. Defaults to the hostname, i.e. the pod name.")
+	flag.StringVar(&config.S3ExportBucket, "s3-export-bucket", getEnv(s3ExportBucketConfigKey, ""), "If specified, export the records of handled events as JSON to this S3 bucket, partitioned by date and cluster for Athena.")
+	flag.StringVar(&config.S3ExportPrefix, "s3-export-prefix", getEnv(s3ExportPrefixConfigKey, ""), "The key prefix to export the event records under.")
+	flag.StringVar(&config.S3ExportClusterName, "s3-export-cluster-name", getEnv(s3ExportClusterNameConfigKey, ""), "The cluster name the exported event records are partitioned by.")
+	flag.IntVar(&config.S3ExportInterval, "s3-export-interval", getIntEnv(s3ExportIntervalConfigKey, defaultS3ExportInterval), "The interval in seconds to export the event records at, 0 exports each record once its event is handled.")
+	flag.BoolVar(&config.EnableEventBridgeEvents, "enable-eventbridge-events", getBoolEnv(enableEventBridgeEventsConfigKey, false), "If true, publish an event to EventBridge for each handled event, e.g. nth.drain.completed or nth.drain.failed.")
+	flag.StringVar(&config.EventBridgeBusName, "eventbridge-bus-name", getEnv(eventBridgeBusNameConfigKey, defaultEventBridgeBusName), "The name or ARN of the EventBridge bus to publish the events to.")
+	flag.StringVar(&config.EventBridgeSource, "eventbridge-source", getEnv(eventBridgeSourceConfigKey, defaultEventBridgeSource), "The source of the events published to EventBridge.")
+	flag.BoolVar(&config.EnableCombinedMode, "enable-combined-mode", getBoolEnv(enableCombinedModeConfigKey, false), "If true, monitor the instance metadata and the queue together, handling an interruption reported by both once. Requires enable-sqs-termination-draining.")
+	flag.IntVar(&config.DuplicateEventWindow, "duplicate-event-window", getIntEnv(duplicateEventWindowConfigKey, defaultDuplicateEventWindow), "In combined mode, the time in seconds after a node was drained during which events for the node are handled as duplicates, completing their lifecycle actions without draining again.")
+	flag.StringVar(&config.ClusterTagKey, "cluster-tag-key", getEnv(clusterTagKeyConfigKey, ""), "If specified, serve several clusters from one queue processor: the EC2 tag names the cluster of an instance, e.g. eks:cluster-name. Requires cluster-kube-contexts.")
+	flag.StringVar(&config.ClusterKubeContexts, "cluster-kube-contexts", getEnv(clusterKubeContextsConfigKey, ""), "Comma separated cluster=context pairs mapping the served clusters to contexts of the kubeconfig. A cluster without context uses the context named after it.")
+	flag.IntVar(&config.KubernetesWriteQPS, "kubernetes-write-qps", getIntEnv(kubernetesWriteQPSConfigKey, 0), "If greater than 0, the maximum number of writes per second, e.g. evictions and patches, to the kubernetes api server, so mass drains don't starve other controllers.")
+	flag.IntVar(&config.KubernetesWriteBurst, "kubernetes-write-burst", getIntEnv(kubernetesWriteBurstConfigKey, defaultKubernetesWriteBurst), "The number of writes to the kubernetes api server allowed in a burst above kubernetes-write-qps.")
+	flag.BoolVar(&config.EnableWorkerAutoscaling, "enable-worker-autoscaling", getBoolEnv(enableWorkerAutoscalingConfigKey, false), "If true, the number of parallel event processors grows with the backlog of the queue and the drains in progress, and shrinks when idle, between min-workers and workers.")
+	flag.IntVar(&config.MinWorkers, "min-workers", getIntEnv(minWorkersConfigKey, defaultMinWorkers), "The least amount of parallel event processors when enable-worker-autoscaling is set.")
+	flag.IntVar(&config.CachePrewarmTimeout, "cache-prewarm-timeout", getIntEnv(cachePrewarmTimeoutConfigKey, defaultCachePrewarmTimeout), "The maximum period of time in seconds to wait on startup for the pod cache to sync and the nodes of the instances to be resolved, before events are consumed. With 0, the caches are filled by the first event.")
+	flag.StringVar(&config.MetricsBindAddress, "metrics-bind-address", getEnv(metricsBindAddressConfigKey, ""), "The address the prometheus server and the status API listen on, e.g. 127.0.0.1 or the node IP. All addresses if empty.")
+	flag.StringVar(&config.MetricsTLSCertFile, "metrics-tls-cert-file", getEnv(metricsTLSCertFileConfigKey, ""), "Path to the TLS certificate to serve the prometheus metrics and the status API over HTTPS with.")
+	flag.StringVar(&config.MetricsTLSKeyFile, "metrics-tls-key-file", getEnv(metricsTLSKeyFileConfigKey, ""), "Path to the TLS private key to serve the prometheus metrics and the status API over HTTPS with.")
+	flag.StringVar(&config.MetricsBearerToken, "metrics-bearer-token", getEnv(metricsBearerTokenConfigKey, ""), "If specified, requests to the prometheus server and the status API must send the token as bearer token.")
+	flag.IntVar(&config.LogSampleInterval, "log-sample-interval", getIntEnv(logSampleIntervalConfigKey, defaultLogSampleInterval), "The period of time in seconds repetitive log messages, like the ones logged on every poll, are logged at most once in. State changes are always logged. With 0, every message is logged.")
+	flag.StringVar(&config.EventJournalFile, "event-journal-file", getEnv(eventJournalFileConfigKey, ""), "If specified, the received events which are not handled yet are recorded in the file, e.g. on a hostPath or emptyDir volume, and handled again after a restart.")
+	flag.StringVar(&config.CordonedNodeHandling, "cordoned-node-handling", getEnv(cordonedNodeHandlingConfigKey, CordonedNodeHandlingLayer), "How nodes which are already unschedulable or carry another controller's termination taint are handled: layer (like any other node), skip (leave them to the other controller), drain-only (evict their pods without cordoning or tainting them) or adopt (handle them, but leave them unschedulable when the event is canceled).")
+	flag.StringVar(&config.SafeToEvictHandling, "safe-to-evict-handling", getEnv(safeToEvictHandlingConfigKey, SafeToEvictHandlingIgnore), "How pods annotated with cluster-autoscaler.kubernetes.io/safe-to-evict=false are handled when draining: ignore (evict them like any other pod), last (evict them once the other pods are gone) or skip (leave them running).")
+	flag.StringVar(&config.EvictionTiers, "eviction-tiers", getEnv(evictionTiersConfigKey, ""), "Semicolon separated label selectors of the tiers the label-tiered drain strategy evicts pods in, e.g. \"tier=batch;app=web;tier in (stateful,database)\". Pods matching none of the selectors are evicted before the first tier.")
+	flag.IntVar(&config.JobCompletionWait, "job-completion-wait", getIntEnv(jobCompletionWaitConfigKey, 0), "The period of time in seconds pods of Jobs annotated with an aws-node-termination-handler/expected-completion time within it are given to complete before they're evicted, after the other pods of the node were drained. The wait is capped at half of the drain timeout. With 0, Job pods are evicted like any other pod.")
+	flag.BoolVar(&config.EnableContainerCheckpoints, "enable-container-checkpoints", getBoolEnv(enableContainerCheckpointsConfigKey, false), "If true, the containers of pods annotated with aws-node-termination-handler/checkpoint=true are checkpointed through the kubelet checkpoint API before the node is drained.")
+	flag.IntVar(&config.ContainerCheckpointTimeout, "container-checkpoint-timeout", getIntEnv(containerCheckpointTimeoutConfigKey, defaultContainerCheckpointTimeout), "Maximum period of time in seconds to checkpoint containers, measured from when checkpointing starts. It is cut to half of the time left until the interruption, so the other half is left to drain the node.")
+	flag.BoolVar(&config.EnableTerminationNotices, "enable-termination-notices", getBoolEnv(enableTerminationNoticesConfigKey, false), "If true, a termination notice with the deadline is posted to pods annotated with aws-node-termination-handler/termination-notice-port before the node is drained.")
+	flag.IntVar(&config.TerminationNoticeDelay, "termination-notice-delay", getIntEnv(terminationNoticeDelayConfigKey, 0), "The period of time in seconds to wait after pods accepted termination notices before the node is drained, giving them time to start draining connections and handing off state.")
+	flag.IntVar(&config.TerminationCountdownInterval, "termination-countdown-interval", getIntEnv(terminationCountdownIntervalConfigKey, 0), "The period of time in seconds the termination deadline and countdown annotations of nodes with a pending interruption are refreshed in. With 0, nodes are not annotated.")
+	flag.StringVar(&config.CanaryNodeSelector, "canary-node-selector", getEnv(canaryNodeSelectorConfigKey, ""), "If specified, only events of nodes matching the label selector are acted on. The events of other nodes are only logged.")
+	flag.IntVar(&config.CanaryPercentage, "canary-percentage", getIntEnv(canaryPercentageConfigKey, defaultCanaryPercentage), "The percentage of nodes, bucketed by a hash of their name, whose events are acted on. The events of other nodes are only logged.")
+	flag.IntVar(&config.EvictionRetryAttempts, "eviction-retry-attempts", getIntEnv(evictionRetryAttemptsConfigKey, 0), "The number of attempts to evict a pod blocked by the API server, e.g. by a pod disruption budget, before the eviction-retry-escalation. 0 retries every 5 seconds until the drain times out.")
+	flag.IntVar(&config.EvictionRetryInterval, "eviction-retry-interval", getIntEnv(evictionRetryIntervalConfigKey, defaultEvictionRetryInterval), "The number of seconds between attempts to evict a blocked pod.")
+	flag.StringVar(&config.EvictionRetryEscalation, "eviction-retry-escalation", getEnv(evictionRetryEscalationConfigKey, EvictionRetryEscalationRetry), "What happens to pods still blocked after eviction-retry-attempts: retry (keep retrying until the drain times out), delete (delete the pod, bypassing pod disruption budgets) or give-up (fail the drain).")
+	flag.IntVar(&config.OrphanedNodeGCInterval, "orphaned-node-gc-interval", getIntEnv(orphanedNodeGCIntervalConfigKey, 0), "If greater than 0, the interval in seconds the nodes whose instances no longer exist are looked for and deleted in. Only used with enable-sqs-termination-draining.")
+	flag.BoolVar(&config.EnableNamespaceDrainPolicies, "enable-namespace-drain-policies", getBoolEnv(enableNamespaceDrainPoliciesConfigKey, false), "If true, the drain-policy and grace-period-multiplier annotations of namespaces apply to their pods when draining nodes.")
+	flag.IntVar(&config.MaxDrainsPerAZ, "max-drains-per-az", getIntEnv(maxDrainsPerAZConfigKey, 0), "If greater than 0, the most nodes of an availability zone which are drained at the same time. Events for other nodes of the zone wait until a drain finishes.")
+	flag.IntVar(&config.RescheduleVerificationTimeout, "reschedule-verification-timeout", getIntEnv(rescheduleVerificationTimeoutConfigKey, 0), "If greater than 0, the seconds to wait after a drain for the deployments, stateful sets and replica sets of the drained pods to have their desired ready replicas again.")
 
 	flag.Parse()
 
+	if config.KubeContext != "" && config.Kubeconfig == "" {
+		return config, fmt.Errorf("kubeconfig must be provided when kube-context is set")
+	}
+
+	switch config.KarpenterNodeHandling {
+	case KarpenterNodeHandlingDrain, KarpenterNodeHandlingDelete, KarpenterNodeHandlingSkip:
+	default:
+		return config, fmt.Errorf("Invalid karpenter-node-handling passed: %s  Should be one of: drain, delete, skip", config.KarpenterNodeHandling)
+	}
+
+	switch config.CordonedNodeHandling {
+	case CordonedNodeHandlingLayer, CordonedNodeHandlingSkip, CordonedNodeHandlingDrainOnly, CordonedNodeHandlingAdopt:
+	default:
+		return config, fmt.Errorf("Invalid cordoned-node-handling passed: %s  Should be one of: layer, skip, drain-only, adopt", config.CordonedNodeHandling)
+	}
+
+	switch config.SafeToEvictHandling {
+	case SafeToEvictHandlingIgnore, SafeToEvictHandlingLast, SafeToEvictHandlingSkip:
+	default:
+		return config, fmt.Errorf("Invalid safe-to-evict-handling passed: %s  Should be one of: ignore, last, skip", config.SafeToEvictHandling)
+	}
+
+	switch config.AcceleratorEventAction {
+	case AcceleratorEventActionDrain, AcceleratorEventActionEvictAcceleratorPods:
+	default:
+		return config, fmt.Errorf("Invalid accelerator-event-action passed: %s  Should be one of: drain, evict-accelerator-pods", config.AcceleratorEventAction)
+	}
+
+	switch config.SpotStopHibernateAction {
+	case SpotStopHibernateActionDrain, SpotStopHibernateActionCordon:
+	default:
+		return config, fmt.Errorf("Invalid spot-stop-hibernate-action passed: %s  Should be one of: drain, cordon", config.SpotStopHibernateAction)
+	}
+
+	switch config.LambdaHookFailureAction {
+	case HookFailureActionContinue, HookFailureActionAbort:
+	default:
+		return config, fmt.Errorf("Invalid lambda-hook-failure-action passed: %s  Should be one of: continue, abort", config.LambdaHookFailureAction)
+	}
+
+	switch config.SSMHookFailureAction {
+	case HookFailureActionContinue, HookFailureActionAbort:
+	default:
+		return config, fmt.Errorf("Invalid ssm-hook-failure-action passed: %s  Should be one of: continue, abort", config.SSMHookFailureAction)
+	}
+
+	if config.PreDrainSSMDocument != "" && config.SSMHookTimeout <= 0 {
+		return config, fmt.Errorf("ssm-hook-timeout must be greater than 0 when pre-drain-ssm-document is set")
+	}
+
+	if config.StepFunctionsHeartbeatInterval < 0 {
+		return config, fmt.Errorf("step-functions-heartbeat-interval must not be negative")
+	}
+
+	if config.PreDrainSSMParameters != "" {
+		err = json.Unmarshal([]byte(config.PreDrainSSMParameters), &config.PreDrainSSMParameterValues)
+		if err != nil {
+			return config, fmt.Errorf("Unable to parse pre-drain-ssm-parameters as a JSON object of strings: %w", err)
+		}
+	}
+
+	if config.ActionMappingFile != "" {
+		config.ActionMappings, err = LoadActionMappings(config.ActionMappingFile)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	// the taint manager evicts every pod not tolerating the taint at once, so drain policies can't order the evictions
+	if config.EnableDrainPolicies && config.DrainStrategy == "taint-and-wait" {
+		return config, fmt.Errorf("enable-drain-policies can not be used with drain-strategy taint-and-wait")
+	}
+
+	if config.BottlerocketReboot && (config.EnableSQSTerminationDraining || config.CordonOnly) {
+		return config, fmt.Errorf("bottlerocket-reboot can not be used with enable-sqs-termination-draining or cordon-only")
+	}
+
+	if config.ContainerCheckpointTimeout < 1 {
+		return config, fmt.Errorf("container-checkpoint-timeout must be at least 1")
+	}
+
+	if config.DrainMaxAttempts < 1 {
+		return config, fmt.Errorf("drain-max-attempts must be at least 1")
+	}
+
+	if config.AWSMaxAttempts < 1 {
+		return config, fmt.Errorf("aws-max-attempts must be at least 1")
+	}
+	if config.AWSMaxBackoff <= 0 {
+		return config, fmt.Errorf("aws-max-backoff must be greater than 0")
+	}
+
+	if config.ScheduledEventDrainLeadTime < 0 {
+		return config, fmt.Errorf("scheduled-event-drain-lead-time must not be negative")
+	}
+
+	if config.DrainLeadTime < 0 {
+		return config, fmt.Errorf("drain-lead-time must not be negative")
+	}
+
+	if config.MonitorPluginCommand != "" && config.MonitorPluginTimeout <= 0 {
+		return config, fmt.Errorf("monitor-plugin-timeout must be greater than 0 when monitor-plugin-command is set")
+	}
+
+	if (config.PreDrainHook != "" || config.PostDrainHook != "" || config.PostUncordonHook != "") && config.HookTimeout <= 0 {
+		return config, fmt.Errorf("hook-timeout must be greater than 0 when a hook is set")
+	}
+
+	if config.VaultAddress != "" && config.VaultRole == "" {
+		return config, fmt.Errorf("vault-role must be provided when vault-address is set")
+	}
+
+	if config.EnablePushReceiver && !config.EnableSQSTerminationDraining {
+		return config, fmt.Errorf("enable-sqs-termination-draining must be true when enable-push-receiver is set")
+	}
+	if config.EnablePushReceiver && config.PushReceiverSecret == "" {
+		return config, fmt.Errorf("push-receiver-secret must be provided when enable-push-receiver is set")
+	}
+	if config.SNSTopicARNs != "" && !config.EnablePushReceiver {
+		return config, fmt.Errorf("enable-push-receiver must be true when sns-topic-arns is set")
+	}
+	if (config.PushReceiverTLSCertFile == "") != (config.PushReceiverTLSKeyFile == "") {
+		return config, fmt.Errorf("push-receiver-tls-cert-file and push-receiver-tls-key-file must be provided together")
+	}
+
+	if config.KafkaBrokers != "" && !config.EnableSQSTerminationDraining {
+		return config, fmt.Errorf("enable-sqs-termination-draining must be true when kafka-brokers is set")
+	}
+	if config.KafkaBrokers != "" && config.KafkaTopic == "" {
+		return config, fmt.Errorf("kafka-topic must be provided when kafka-brokers is set")
+	}
+	switch config.KafkaSASLMechanism {
+	case "":
+	case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+		if config.KafkaSASLUsername == "" || config.KafkaSASLPassword == "" {
+			return config, fmt.Errorf("kafka-sasl-username and kafka-sasl-password must be provided when kafka-sasl-mechanism is set")
+		}
+	default:
+		return config, fmt.Errorf("invalid kafka-sasl-mechanism %s, must be one of PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512", config.KafkaSASLMechanism)
+	}
+
+	if config.NATSURL != "" && !config.EnableSQSTerminationDraining {
+		return config, fmt.Errorf("enable-sqs-termination-draining must be true when nats-url is set")
+	}
+	if config.NATSURL != "" && config.NATSSubject == "" && config.NATSStream == "" {
+		return config, fmt.Errorf("nats-subject or nats-stream must be provided when nats-url is set")
+	}
+	if config.NATSStream != "" && config.NATSConsumerName == "" {
+		return config, fmt.Errorf("nats-consumer-name must be provided when nats-stream is set")
+	}
+	if (config.NATSUsername == "") != (config.NATSPassword == "") {
+		return config, fmt.Errorf("nats-username and nats-password must be provided together")
+	}
+
+	if config.EnableCloudWatchMetrics && config.CloudWatchMetricsInterval <= 0 {
+		return config, fmt.Errorf("cloudwatch-metrics-interval must be greater than 0 when enable-cloudwatch-metrics is set")
+	}
+	if config.S3ExportBucket != "" && config.S3ExportClusterName == "" {
+		return config, fmt.Errorf("s3-export-cluster-name is required when s3-export-bucket is set")
+	}
+	if config.S3ExportInterval < 0 {
+		return config, fmt.Errorf("s3-export-interval must not be negative")
+	}
+	if config.EnableEventBridgeEvents && strings.HasPrefix(config.EventBridgeSource, "aws.") {
+		return config, fmt.Errorf("eventbridge-source must not start with \"aws.\", which is reserved for AWS services")
+	}
+	if config.ClusterTagKey != "" || config.ClusterKubeContexts != "" {
+		if config.ClusterTagKey == "" || config.ClusterKubeContexts == "" {
+			return config, fmt.Errorf("cluster-tag-key and cluster-kube-contexts must be set together")
+		}
+		if !config.EnableSQSTerminationDraining || config.EnableCombinedMode {
+			return config, fmt.Errorf("cluster-tag-key requires enable-sqs-termination-draining without enable-combined-mode")
+		}
+		if config.Kubeconfig == "" {
+			return config, fmt.Errorf("kubeconfig must be provided when cluster-kube-contexts is set")
+		}
+		config.ClusterContexts, err = parseClusterContexts(config.ClusterKubeContexts)
+		if err != nil {
+			return config, err
+		}
+	}
+	if config.EnableCombinedMode && !config.EnableSQSTerminationDraining {
+		return config, fmt.Errorf("enable-combined-mode requires enable-sqs-termination-draining")
+	}
+	if config.EnableCombinedMode && config.DuplicateEventWindow <= 0 {
+		return config, fmt.Errorf("duplicate-event-window must be greater than 0 when enable-combined-mode is set")
+	}
+	if config.KubernetesWriteQPS > 0 && config.KubernetesWriteBurst <= 0 {
+		return config, fmt.Errorf("kubernetes-write-burst must be greater than 0 when kubernetes-write-qps is set")
+	}
+	if config.EnableWorkerAutoscaling && !config.EnableSQSTerminationDraining {
+		return config, fmt.Errorf("enable-worker-autoscaling requires enable-sqs-termination-draining")
+	}
+	if config.EnableWorkerAutoscaling && (config.MinWorkers < 1 || config.MinWorkers > config.Workers) {
+		return config, fmt.Errorf("min-workers must be between 1 and workers when enable-worker-autoscaling is set")
+	}
+	if config.CachePrewarmTimeout < 0 {
+		return config, fmt.Errorf("cache-prewarm-timeout must not be negative")
+	}
+	if (config.MetricsTLSCertFile == "") != (config.MetricsTLSKeyFile == "") {
+		return config, fmt.Errorf("metrics-tls-cert-file and metrics-tls-key-file must be provided together")
+	}
+	if config.LogSampleInterval < 0 {
+		return config, fmt.Errorf("log-sample-interval must not be negative")
+	}
+	if config.JobCompletionWait < 0 {
+		return config, fmt.Errorf("job-completion-wait must not be negative")
+	}
+	if config.TerminationNoticeDelay < 0 {
+		return config, fmt.Errorf("termination-notice-delay must not be negative")
+	}
+	if config.TerminationCountdownInterval < 0 {
+		return config, fmt.Errorf("termination-countdown-interval must not be negative")
+	}
+	if config.CanaryPercentage < 0 || config.CanaryPercentage > 100 {
+		return config, fmt.Errorf("canary-percentage must be between 0 and 100")
+	}
+	if config.EvictionRetryAttempts < 0 {
+		return config, fmt.Errorf("eviction-retry-attempts must not be negative")
+	}
+	if config.EvictionRetryInterval <= 0 {
+		return config, fmt.Errorf("eviction-retry-interval must be positive")
+	}
+	if !isEvictionRetryEscalation(config.EvictionRetryEscalation) {
+		return config, fmt.Errorf("Invalid eviction-retry-escalation passed: %s  Should be one of: retry, delete, give-up", config.EvictionRetryEscalation)
+	}
+	if config.OrphanedNodeGCInterval < 0 {
+		return config, fmt.Errorf("orphaned-node-gc-interval must not be negative")
+	}
+	if config.OrphanedNodeGCInterval > 0 && !config.EnableSQSTerminationDraining {
+		return config, fmt.Errorf("orphaned-node-gc-interval requires enable-sqs-termination-draining")
+	}
+	if config.MaxDrainsPerAZ < 0 {
+		return config, fmt.Errorf("max-drains-per-az must not be negative")
+	}
+	if config.RescheduleVerificationTimeout < 0 {
+		return config, fmt.Errorf("reschedule-verification-timeout must not be negative")
+	}
+
+	if config.EnableControlAPI && !config.EnableStatusAPI {
+		return config, fmt.Errorf("enable-status-api must be true when enable-control-api is set")
+	}
+	if config.EnableControlAPI && config.MetricsBearerToken == "" {
+		return config, fmt.Errorf("metrics-bearer-token must be set when enable-control-api is set, so only authenticated requests can control the handler")
+	}
+	if config.EnableSimulateAPI && !config.EnableControlAPI {
+		return config, fmt.Errorf("enable-control-api must be true when enable-simulate-api is set")
+	}
+
 	if isConfigProvided("pod-termination-grace-period", podTerminationGracePeriodConfigKey) && isConfigProvided("grace-period", gracePeriodConfigKey) {
 		log.Warn().Msg("Deprecated argument \"grace-period\" and the replacement argument \"pod-termination-grace-period\" was provided. Using the newer argument \"pod-termination-grace-period\"")
 	} else if isConfigProvided("grace-period", gracePeriodConfigKey) {
@@ -256,9 +1001,129 @@ func (c Config) PrintJsonConfigArgs() {
 		Str("kubernetes_events_extra_annotations", c.KubernetesEventsExtraAnnotations).
 		Str("aws_region", c.AWSRegion).
 		Str("aws_endpoint", c.AWSEndpoint).
+		Bool("aws_use_fips_endpoint", c.AWSUseFIPSEndpoint).
+		Bool("aws_use_dualstack_endpoint", c.AWSUseDualStackEndpoint).
+		Int("aws_max_attempts", c.AWSMaxAttempts).
+		Int("aws_max_backoff", c.AWSMaxBackoff).
 		Str("queue_url", c.QueueURL).
 		Bool("check_asg_tag_before_draining", c.CheckASGTagBeforeDraining).
 		Str("ManagedAsgTag", c.ManagedAsgTag).
+		Str("ssm_parameter_path", c.SSMParameterPath).
+		Int("ssm_parameter_refresh_interval", c.SSMParameterRefreshInterval).
+		Int("secrets_refresh_interval", c.SecretsRefreshInterval).
+		Str("vault_address", c.VaultAddress).
+		Str("vault_role", c.VaultRole).
+		Str("vault_auth_path", c.VaultAuthPath).
+		Str("vault_namespace", c.VaultNamespace).
+		Str("vault_cacert", c.VaultCACert).
+		Bool("rbac_self_check", c.RBACSelfCheck).
+		Str("kubeconfig", c.Kubeconfig).
+		Str("kube_context", c.KubeContext).
+		Bool("cluster_autoscaler_coordination", c.ClusterAutoscalerCoordination).
+		Str("karpenter_node_handling", c.KarpenterNodeHandling).
+		Bool("detach_from_asg", c.DetachFromASG).
+		Int("replacement_wait_timeout", c.ReplacementWaitTimeout).
+		Bool("wait_for_rebalance_replacement", c.WaitForRebalanceReplacement).
+		Bool("publish_node_conditions", c.PublishNodeConditions).
+		Bool("kubelet_shutdown_coordination", c.KubeletShutdownCoordination).
+		Bool("bottlerocket_reboot", c.BottlerocketReboot).
+		Str("bottlerocket_api_socket", c.BottlerocketAPISocket).
+		Str("accelerator_event_action", c.AcceleratorEventAction).
+		Str("accelerator_event_keywords", c.AcceleratorEventKeywords).
+		Str("accelerator_resource_names", c.AcceleratorResourceNames).
+		Str("spot_stop_hibernate_action", c.SpotStopHibernateAction).
+		Str("notify_only_event_codes", c.NotifyOnlyEventCodes).
+		Str("action_mapping_file", c.ActionMappingFile).
+		Bool("capacity_aware_rebalance_drain", c.CapacityAwareRebalanceDrain).
+		Int("drain_deferral_timeout", c.DrainDeferralTimeout).
+		Int("drain_max_attempts", c.DrainMaxAttempts).
+		Bool("require_capacity_rebalance", c.RequireCapacityRebalance).
+		Int("scheduled_event_drain_lead_time", c.ScheduledEventDrainLeadTime).
+		Int("drain_lead_time", c.DrainLeadTime).
+		Str("monitor_plugin_command", c.MonitorPluginCommand).
+		Int("monitor_plugin_timeout", c.MonitorPluginTimeout).
+		Str("pre_drain_hook", c.PreDrainHook).
+		Str("post_drain_hook", c.PostDrainHook).
+		Str("post_uncordon_hook", c.PostUncordonHook).
+		Int("hook_timeout", c.HookTimeout).
+		Str("pre_drain_lambda_hook", c.PreDrainLambdaHook).
+		Str("post_drain_lambda_hook", c.PostDrainLambdaHook).
+		Str("lambda_hook_failure_action", c.LambdaHookFailureAction).
+		Str("pre_drain_ssm_document", c.PreDrainSSMDocument).
+		Str("pre_drain_ssm_parameters", c.PreDrainSSMParameters).
+		Int("ssm_hook_timeout", c.SSMHookTimeout).
+		Str("ssm_hook_failure_action", c.SSMHookFailureAction).
+		Int("step_functions_heartbeat_interval", c.StepFunctionsHeartbeatInterval).
+		Str("drain_strategy", c.DrainStrategy).
+		Bool("enable_drain_policies", c.EnableDrainPolicies).
+		Bool("enable_termination_event_resources", c.EnableTerminationEventResources).
+		Bool("enable_status_api", c.EnableStatusAPI).
+		Int("status_api_port", c.StatusAPIPort).
+		Bool("enable_control_api", c.EnableControlAPI).
+		Bool("enable_simulate_api", c.EnableSimulateAPI).
+		Bool("enable_push_receiver", c.EnablePushReceiver).
+		Int("push_receiver_port", c.PushReceiverPort).
+		Str("push_receiver_tls_cert_file", c.PushReceiverTLSCertFile).
+		Str("push_receiver_tls_key_file", c.PushReceiverTLSKeyFile).
+		Str("sns_topic_arns", c.SNSTopicARNs).
+		Str("kafka_brokers", c.KafkaBrokers).
+		Str("kafka_topic", c.KafkaTopic).
+		Str("kafka_group_id", c.KafkaGroupID).
+		Bool("kafka_tls", c.KafkaTLS).
+		Str("kafka_tls_ca_file", c.KafkaTLSCAFile).
+		Str("kafka_sasl_mechanism", c.KafkaSASLMechanism).
+		Str("kafka_sasl_username", c.KafkaSASLUsername).
+		Str("nats_url", c.NATSURL).
+		Str("nats_subject", c.NATSSubject).
+		Str("nats_stream", c.NATSStream).
+		Str("nats_consumer_name", c.NATSConsumerName).
+		Str("nats_tls_ca_file", c.NATSTLSCAFile).
+		Str("nats_username", c.NATSUsername).
+		Bool("enable_cloudwatch_metrics", c.EnableCloudWatchMetrics).
+		Str("cloudwatch_metrics_namespace", c.CloudWatchMetricsNamespace).
+		Str("cloudwatch_metrics_dimensions", c.CloudWatchMetricsDimensions).
+		Int("cloudwatch_metrics_interval", c.CloudWatchMetricsInterval).
+		Str("cloudwatch_logs_group", c.CloudWatchLogsGroup).
+		Str("cloudwatch_logs_stream", c.CloudWatchLogsStream).
+		Str("s3_export_bucket", c.S3ExportBucket).
+		Str("s3_export_prefix", c.S3ExportPrefix).
+		Str("s3_export_cluster_name", c.S3ExportClusterName).
+		Int("s3_export_interval", c.S3ExportInterval).
+		Bool("enable_eventbridge_events", c.EnableEventBridgeEvents).
+		Str("eventbridge_bus_name", c.EventBridgeBusName).
+		Str("eventbridge_source", c.EventBridgeSource).
+		Bool("enable_combined_mode", c.EnableCombinedMode).
+		Int("duplicate_event_window", c.DuplicateEventWindow).
+		Str("cluster_tag_key", c.ClusterTagKey).
+		Str("cluster_kube_contexts", c.ClusterKubeContexts).
+		Int("kubernetes_write_qps", c.KubernetesWriteQPS).
+		Int("kubernetes_write_burst", c.KubernetesWriteBurst).
+		Bool("enable_worker_autoscaling", c.EnableWorkerAutoscaling).
+		Int("min_workers", c.MinWorkers).
+		Int("cache_prewarm_timeout", c.CachePrewarmTimeout).
+		Str("metrics_bind_address", c.MetricsBindAddress).
+		Str("metrics_tls_cert_file", c.MetricsTLSCertFile).
+		Str("metrics_tls_key_file", c.MetricsTLSKeyFile).
+		Int("log_sample_interval", c.LogSampleInterval).
+		Str("event_journal_file", c.EventJournalFile).
+		Str("cordoned_node_handling", c.CordonedNodeHandling).
+		Str("safe_to_evict_handling", c.SafeToEvictHandling).
+		Str("eviction_tiers", c.EvictionTiers).
+		Int("job_completion_wait", c.JobCompletionWait).
+		Bool("enable_container_checkpoints", c.EnableContainerCheckpoints).
+		Int("container_checkpoint_timeout", c.ContainerCheckpointTimeout).
+		Bool("enable_termination_notices", c.EnableTerminationNotices).
+		Int("termination_notice_delay", c.TerminationNoticeDelay).
+		Int("termination_countdown_interval", c.TerminationCountdownInterval).
+		Str("canary_node_selector", c.CanaryNodeSelector).
+		Int("canary_percentage", c.CanaryPercentage).
+		Int("eviction_retry_attempts", c.EvictionRetryAttempts).
+		Int("eviction_retry_interval", c.EvictionRetryInterval).
+		Str("eviction_retry_escalation", c.EvictionRetryEscalation).
+		Int("orphaned_node_gc_interval", c.OrphanedNodeGCInterval).
+		Bool("enable_namespace_drain_policies", c.EnableNamespaceDrainPolicies).
+		Int("max_drains_per_az", c.MaxDrainsPerAZ).
+		Int("reschedule_verification_timeout", c.RescheduleVerificationTimeout).
 		Msg("aws-node-termination-handler arguments")
 }
 
@@ -303,7 +1168,127 @@ func (c Config) PrintHumanConfigArgs() {
 			"\tqueue-url: %s,\n"+
 			"\tcheck-asg-tag-before-draining: %t,\n"+
 			"\tmanaged-asg-tag: %s,\n"+
-			"\taws-endpoint: %s,\n",
+			"\taws-endpoint: %s,\n"+
+			"\taws-use-fips-endpoint: %t,\n"+
+			"\taws-use-dualstack-endpoint: %t,\n"+
+			"\taws-max-attempts: %d,\n"+
+			"\taws-max-backoff: %d,\n"+
+			"\tssm-parameter-path: %s,\n"+
+			"\tssm-parameter-refresh-interval: %d,\n"+
+			"\tsecrets-refresh-interval: %d,\n"+
+			"\tvault-address: %s,\n"+
+			"\tvault-role: %s,\n"+
+			"\tvault-auth-path: %s,\n"+
+			"\tvault-namespace: %s,\n"+
+			"\tvault-cacert: %s,\n"+
+			"\trbac-self-check: %t,\n"+
+			"\tkubeconfig: %s,\n"+
+			"\tkube-context: %s,\n"+
+			"\tcluster-autoscaler-coordination: %t,\n"+
+			"\tkarpenter-node-handling: %s,\n"+
+			"\tdetach-from-asg: %t,\n"+
+			"\treplacement-wait-timeout: %d,\n"+
+			"\twait-for-rebalance-replacement: %t,\n"+
+			"\tpublish-node-conditions: %t,\n"+
+			"\tkubelet-shutdown-coordination: %t,\n"+
+			"\tbottlerocket-reboot: %t,\n"+
+			"\tbottlerocket-api-socket: %s,\n"+
+			"\taccelerator-event-action: %s,\n"+
+			"\taccelerator-event-keywords: %s,\n"+
+			"\taccelerator-resource-names: %s,\n"+
+			"\tspot-stop-hibernate-action: %s,\n"+
+			"\tnotify-only-event-codes: %s,\n"+
+			"\taction-mapping-file: %s,\n"+
+			"\tcapacity-aware-rebalance-drain: %t,\n"+
+			"\tdrain-deferral-timeout: %d,\n"+
+			"\tdrain-max-attempts: %d,\n"+
+			"\trequire-capacity-rebalance: %t,\n"+
+			"\tscheduled-event-drain-lead-time: %d,\n"+
+			"\tdrain-lead-time: %d,\n"+
+			"\tmonitor-plugin-command: %s,\n"+
+			"\tmonitor-plugin-timeout: %d,\n"+
+			"\tpre-drain-hook: %s,\n"+
+			"\tpost-drain-hook: %s,\n"+
+			"\tpost-uncordon-hook: %s,\n"+
+			"\thook-timeout: %d,\n"+
+			"\tpre-drain-lambda-hook: %s,\n"+
+			"\tpost-drain-lambda-hook: %s,\n"+
+			"\tlambda-hook-failure-action: %s,\n"+
+			"\tpre-drain-ssm-document: %s,\n"+
+			"\tpre-drain-ssm-parameters: %s,\n"+
+			"\tssm-hook-timeout: %d,\n"+
+			"\tssm-hook-failure-action: %s,\n"+
+			"\tstep-functions-heartbeat-interval: %d,\n"+
+			"\tdrain-strategy: %s,\n"+
+			"\tenable-drain-policies: %t,\n"+
+			"\tenable-termination-event-resources: %t,\n"+
+			"\tenable-status-api: %t,\n"+
+			"\tstatus-api-port: %d,\n"+
+			"\tenable-control-api: %t,\n"+
+			"\tenable-simulate-api: %t,\n"+
+			"\tenable-push-receiver: %t,\n"+
+			"\tpush-receiver-port: %d,\n"+
+			"\tpush-receiver-tls-cert-file: %s,\n"+
+			"\tpush-receiver-tls-key-file: %s,\n"+
+			"\tsns-topic-arns: %s,\n"+
+			"\tkafka-brokers: %s,\n"+
+			"\tkafka-topic: %s,\n"+
+			"\tkafka-group-id: %s,\n"+
+			"\tkafka-tls: %t,\n"+
+			"\tkafka-tls-ca-file: %s,\n"+
+			"\tkafka-sasl-mechanism: %s,\n"+
+			"\tkafka-sasl-username: %s,\n"+
+			"\tnats-url: %s,\n"+
+			"\tnats-subject: %s,\n"+
+			"\tnats-stream: %s,\n"+
+			"\tnats-consumer-name: %s,\n"+
+			"\tnats-tls-ca-file: %s,\n"+
+			"\tnats-username: %s,\n"+
+			"\tenable-cloudwatch-metrics: %t,\n"+
+			"\tcloudwatch-metrics-namespace: %s,\n"+
+			"\tcloudwatch-metrics-dimensions: %s,\n"+
+			"\tcloudwatch-metrics-interval: %d,\n"+
+			"\tcloudwatch-logs-group: %s,\n"+
+			"\tcloudwatch-logs-stream: %s,\n"+
+			"\ts3-export-bucket: %s,\n"+
+			"\ts3-export-prefix: %s,\n"+
+			"\ts3-export-cluster-name: %s,\n"+
+			"\ts3-export-interval: %d,\n"+
+			"\tenable-eventbridge-events: %t,\n"+
+			"\teventbridge-bus-name: %s,\n"+
+			"\teventbridge-source: %s,\n"+
+			"\tenable-combined-mode: %t,\n"+
+			"\tduplicate-event-window: %d,\n"+
+			"\tcluster-tag-key: %s,\n"+
+			"\tcluster-kube-contexts: %s,\n"+
+			"\tkubernetes-write-qps: %d,\n"+
+			"\tkubernetes-write-burst: %d,\n"+
+			"\tenable-worker-autoscaling: %t,\n"+
+			"\tmin-workers: %d,\n"+
+			"\tcache-prewarm-timeout: %d,\n"+
+			"\tmetrics-bind-address: %s,\n"+
+			"\tmetrics-tls-cert-file: %s,\n"+
+			"\tmetrics-tls-key-file: %s,\n"+
+			"\tlog-sample-interval: %d,\n"+
+			"\tevent-journal-file: %s,\n"+
+			"\tcordoned-node-handling: %s,\n"+
+			"\tsafe-to-evict-handling: %s,\n"+
+			"\teviction-tiers: %s,\n"+
+			"\tjob-completion-wait: %d,\n"+
+			"\tenable-container-checkpoints: %t,\n"+
+			"\tcontainer-checkpoint-timeout: %d,\n"+
+			"\tenable-termination-notices: %t,\n"+
+			"\ttermination-notice-delay: %d,\n"+
+			"\ttermination-countdown-interval: %d,\n"+
+			"\tcanary-node-selector: %s,\n"+
+			"\tcanary-percentage: %d,\n"+
+			"\teviction-retry-attempts: %d,\n"+
+			"\teviction-retry-interval: %d,\n"+
+			"\teviction-retry-escalation: %s,\n"+
+			"\torphaned-node-gc-interval: %d,\n"+
+			"\tenable-namespace-drain-policies: %t,\n"+
+			"\tmax-drains-per-az: %d,\n"+
+			"\treschedule-verification-timeout: %d,\n",
 		c.DryRun,
 		c.NodeName,
 		c.MetadataURL,
@@ -337,10 +1322,138 @@ func (c Config) PrintHumanConfigArgs() {
 		c.CheckASGTagBeforeDraining,
 		c.ManagedAsgTag,
 		c.AWSEndpoint,
+		c.AWSUseFIPSEndpoint,
+		c.AWSUseDualStackEndpoint,
+		c.AWSMaxAttempts,
+		c.AWSMaxBackoff,
+		c.SSMParameterPath,
+		c.SSMParameterRefreshInterval,
+		c.SecretsRefreshInterval,
+		c.VaultAddress,
+		c.VaultRole,
+		c.VaultAuthPath,
+		c.VaultNamespace,
+		c.VaultCACert,
+		c.RBACSelfCheck,
+		c.Kubeconfig,
+		c.KubeContext,
+		c.ClusterAutoscalerCoordination,
+		c.KarpenterNodeHandling,
+		c.DetachFromASG,
+		c.ReplacementWaitTimeout,
+		c.WaitForRebalanceReplacement,
+		c.PublishNodeConditions,
+		c.KubeletShutdownCoordination,
+		c.BottlerocketReboot,
+		c.BottlerocketAPISocket,
+		c.AcceleratorEventAction,
+		c.AcceleratorEventKeywords,
+		c.AcceleratorResourceNames,
+		c.SpotStopHibernateAction,
+		c.NotifyOnlyEventCodes,
+		c.ActionMappingFile,
+		c.CapacityAwareRebalanceDrain,
+		c.DrainDeferralTimeout,
+		c.DrainMaxAttempts,
+		c.RequireCapacityRebalance,
+		c.ScheduledEventDrainLeadTime,
+		c.DrainLeadTime,
+		c.MonitorPluginCommand,
+		c.MonitorPluginTimeout,
+		c.PreDrainHook,
+		c.PostDrainHook,
+		c.PostUncordonHook,
+		c.HookTimeout,
+		c.PreDrainLambdaHook,
+		c.PostDrainLambdaHook,
+		c.LambdaHookFailureAction,
+		c.PreDrainSSMDocument,
+		c.PreDrainSSMParameters,
+		c.SSMHookTimeout,
+		c.SSMHookFailureAction,
+		c.StepFunctionsHeartbeatInterval,
+		c.DrainStrategy,
+		c.EnableDrainPolicies,
+		c.EnableTerminationEventResources,
+		c.EnableStatusAPI,
+		c.StatusAPIPort,
+		c.EnableControlAPI,
+		c.EnableSimulateAPI,
+		c.EnablePushReceiver,
+		c.PushReceiverPort,
+		c.PushReceiverTLSCertFile,
+		c.PushReceiverTLSKeyFile,
+		c.SNSTopicARNs,
+		c.KafkaBrokers,
+		c.KafkaTopic,
+		c.KafkaGroupID,
+		c.KafkaTLS,
+		c.KafkaTLSCAFile,
+		c.KafkaSASLMechanism,
+		c.KafkaSASLUsername,
+		c.NATSURL,
+		c.NATSSubject,
+		c.NATSStream,
+		c.NATSConsumerName,
+		c.NATSTLSCAFile,
+		c.NATSUsername,
+		c.EnableCloudWatchMetrics,
+		c.CloudWatchMetricsNamespace,
+		c.CloudWatchMetricsDimensions,
+		c.CloudWatchMetricsInterval,
+		c.CloudWatchLogsGroup,
+		c.CloudWatchLogsStream,
+		c.S3ExportBucket,
+		c.S3ExportPrefix,
+		c.S3ExportClusterName,
+		c.S3ExportInterval,
+		c.EnableEventBridgeEvents,
+		c.EventBridgeBusName,
+		c.EventBridgeSource,
+		c.EnableCombinedMode,
+		c.DuplicateEventWindow,
+		c.ClusterTagKey,
+		c.ClusterKubeContexts,
+		c.KubernetesWriteQPS,
+		c.KubernetesWriteBurst,
+		c.EnableWorkerAutoscaling,
+		c.MinWorkers,
+		c.CachePrewarmTimeout,
+		c.MetricsBindAddress,
+		c.MetricsTLSCertFile,
+		c.MetricsTLSKeyFile,
+		c.LogSampleInterval,
+		c.EventJournalFile,
+		c.CordonedNodeHandling,
+		c.SafeToEvictHandling,
+		c.EvictionTiers,
+		c.JobCompletionWait,
+		c.EnableContainerCheckpoints,
+		c.ContainerCheckpointTimeout,
+		c.EnableTerminationNotices,
+		c.TerminationNoticeDelay,
+		c.TerminationCountdownInterval,
+		c.CanaryNodeSelector,
+		c.CanaryPercentage,
+		c.EvictionRetryAttempts,
+		c.EvictionRetryInterval,
+		c.EvictionRetryEscalation,
+		c.OrphanedNodeGCInterval,
+		c.EnableNamespaceDrainPolicies,
+		c.MaxDrainsPerAZ,
+		c.RescheduleVerificationTimeout,
 	)
 }
 
 // Get env var or default
+func isEvictionRetryEscalation(escalation string) bool {
+	switch escalation {
+	case EvictionRetryEscalationRetry, EvictionRetryEscalationDelete, EvictionRetryEscalationGiveUp:
+		return true
+	}
+	return false
+}
+
 func getEnv(key string, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		if value != "" {
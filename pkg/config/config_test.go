@@ -264,6 +264,128 @@ func TestParseCliArgsAWSSession(t *testing.T) {
 	h.Assert(t, nthConfig.AWSRegion == "us-weast-1", "Should find region as us-weast-1")
 }
 
+func TestParseCliArgsPushReceiverRequiresSecret(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_SQS_TERMINATION_DRAINING", "true")
+	setEnvForTest("ENABLE_PUSH_RECEIVER", "true")
+	setEnvForTest("AWS_REGION", "us-weast-1")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when push-receiver-secret not provided")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("PUSH_RECEIVER_SECRET", "s3cr3t")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 8443, nthConfig.PushReceiverPort)
+}
+
+func TestParseCliArgsKafkaSASLMechanism(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_SQS_TERMINATION_DRAINING", "true")
+	setEnvForTest("KAFKA_BROKERS", "b-1:9096")
+	setEnvForTest("KAFKA_TOPIC", "events")
+	setEnvForTest("KAFKA_SASL_MECHANISM", "GSSAPI")
+	setEnvForTest("AWS_REGION", "us-weast-1")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error for an unsupported kafka-sasl-mechanism")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("KAFKA_SASL_MECHANISM", "SCRAM-SHA-512")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when kafka-sasl-password not provided")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("KAFKA_SASL_USERNAME", "nth")
+	setEnvForTest("KAFKA_SASL_PASSWORD", "s3cr3t")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, "aws-node-termination-handler", nthConfig.KafkaGroupID)
+}
+
+func TestParseCliArgsNATSRequiresSubjectOrStream(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_SQS_TERMINATION_DRAINING", "true")
+	setEnvForTest("NATS_URL", "nats://nats:4222")
+	setEnvForTest("AWS_REGION", "us-weast-1")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when neither nats-subject nor nats-stream provided")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("NATS_STREAM", "AWS_EVENTS")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, "aws-node-termination-handler", nthConfig.NATSConsumerName)
+}
+
+func TestParseCliArgsS3ExportRequiresClusterName(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("S3_EXPORT_BUCKET", "nth-events")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when s3-export-cluster-name not provided")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("S3_EXPORT_CLUSTER_NAME", "prod")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 300, nthConfig.S3ExportInterval)
+}
+
+func TestParseCliArgsEventBridgeSource(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_EVENTBRIDGE_EVENTS", "true")
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, "default", nthConfig.EventBridgeBusName)
+	h.Equals(t, "aws-node-termination-handler", nthConfig.EventBridgeSource)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("EVENTBRIDGE_SOURCE", "aws.nth")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when eventbridge-source is reserved for AWS services")
+}
+
+func TestParseCliArgsCombinedModeRequiresSQS(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_COMBINED_MODE", "true")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when enable-combined-mode is set without enable-sqs-termination-draining")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("ENABLE_SQS_TERMINATION_DRAINING", "true")
+	setEnvForTest("AWS_REGION", "us-weast-1")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 600, nthConfig.DuplicateEventWindow)
+}
+
+func TestParseCliArgsClusterKubeContexts(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_SQS_TERMINATION_DRAINING", "true")
+	setEnvForTest("AWS_REGION", "us-weast-1")
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("CLUSTER_TAG_KEY", "eks:cluster-name")
+	setEnvForTest("CLUSTER_KUBE_CONTEXTS", "prod=arn:aws:eks:us-east-1:123456789012:cluster/prod, dev")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when cluster-kube-contexts is set without kubeconfig")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("KUBECONFIG", "/etc/nth/kubeconfig")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, map[string]string{"prod": "arn:aws:eks:us-east-1:123456789012:cluster/prod", "dev": "dev"}, nthConfig.ClusterContexts)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("CLUSTER_KUBE_CONTEXTS", "prod=")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when a cluster-kube-contexts entry has an empty context")
+}
+
 func TestPrint_Human(t *testing.T) {
 	resetFlagsForTest()
 	setEnvForTest("NODE_NAME", "node")
@@ -293,3 +415,310 @@ func TestPrint_JSON(t *testing.T) {
 	nthConfig.PrintJsonConfigArgs()
 	h.Assert(t, jsonBuf.String() == printBuf.String(), "Should have printed JSON formatted config values")
 }
+
+func TestApplyParameters(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("CORDON_ONLY", "true")
+	// the Helm chart sets the environment variables of unchanged values to their defaults
+	setEnvForTest("TAINT_NODE", "false")
+	setEnvForTest("WEBHOOK_URL", "")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+
+	applied, err := nthConfig.ApplyParameters(map[string]string{
+		"WEBHOOK_URL":                   "https://example.com/hook",
+		"ENABLE_REBALANCE_DRAINING":     "true",
+		"NODE_TERMINATION_GRACE_PERIOD": "60",
+		"CORDON_ONLY":                   "false",
+		"TAINT_NODE":                    "true",
+		"UNSUPPORTED_KEY":               "value",
+	})
+	h.Ok(t, err)
+	h.Equals(t, 4, len(applied))
+	h.Equals(t, "https://example.com/hook", nthConfig.WebhookURL)
+	h.Equals(t, true, nthConfig.EnableRebalanceDraining)
+	h.Equals(t, 60, nthConfig.NodeTerminationGracePeriod)
+	h.Equals(t, true, nthConfig.TaintNode)
+	// explicitly provided values take precedence over parameters
+	h.Equals(t, true, nthConfig.CordonOnly)
+}
+
+func TestConfigHolder(t *testing.T) {
+	resetFlagsForTest()
+	holder := config.NewHolder(config.Config{NodeName: "node"})
+	applied, err := holder.RefreshParameters(map[string]string{
+		"WEBHOOK_URL":                     "https://example.com/hook",
+		"TAINT_NODE":                      "true",
+		"ENABLE_SQS_TERMINATION_DRAINING": "true",
+	})
+	h.Ok(t, err)
+	h.Equals(t, []string{"WEBHOOK_URL"}, applied)
+	h.Equals(t, "https://example.com/hook", holder.Get().WebhookURL)
+	h.Equals(t, "node", holder.Get().NodeName)
+	// parameters configuring the monitors and clients at startup need a restart
+	h.Equals(t, false, holder.Get().TaintNode)
+	h.Equals(t, false, holder.Get().EnableSQSTerminationDraining)
+}
+
+func TestApplyParametersInvalidValue(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+
+	applied, err := nthConfig.ApplyParameters(map[string]string{
+		"WEBHOOK_URL":            "https://example.com/hook",
+		"CORDON_ONLY":            "true",
+		"TAINT_NODE":             "maybe",
+		"EMIT_KUBERNETES_EVENTS": "yes",
+	})
+	h.Nok(t, err)
+	h.Equals(t, 0, len(applied))
+	// no value is applied if any is invalid
+	h.Equals(t, "", nthConfig.WebhookURL)
+	h.Equals(t, false, nthConfig.CordonOnly)
+}
+
+func TestParseCliArgsKubernetesWriteBurst(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("KUBERNETES_WRITE_QPS", "5")
+	setEnvForTest("KUBERNETES_WRITE_BURST", "0")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when kubernetes-write-qps is set without kubernetes-write-burst")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("KUBERNETES_WRITE_BURST", "20")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 5, nthConfig.KubernetesWriteQPS)
+	h.Equals(t, 20, nthConfig.KubernetesWriteBurst)
+}
+
+func TestParseCliArgsMinWorkers(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("ENABLE_SQS_TERMINATION_DRAINING", "true")
+	setEnvForTest("ENABLE_WORKER_AUTOSCALING", "true")
+	setEnvForTest("WORKERS", "10")
+	setEnvForTest("MIN_WORKERS", "11")
+	setEnvForTest("AWS_REGION", "us-weast-1")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when min-workers is greater than workers")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("MIN_WORKERS", "2")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Assert(t, nthConfig.EnableWorkerAutoscaling, "Expected worker autoscaling to be enabled")
+	h.Equals(t, 2, nthConfig.MinWorkers)
+}
+
+func TestParseCliArgsCachePrewarmTimeout(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 30, nthConfig.CachePrewarmTimeout)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("CACHE_PREWARM_TIMEOUT", "-1")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when cache-prewarm-timeout is negative")
+}
+
+func TestParseCliArgsMetricsTLS(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("METRICS_TLS_CERT_FILE", "/metrics-tls/tls.crt")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when metrics-tls-cert-file is set without metrics-tls-key-file")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("METRICS_TLS_KEY_FILE", "/metrics-tls/tls.key")
+	setEnvForTest("METRICS_BIND_ADDRESS", "10.0.0.1")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, "10.0.0.1", nthConfig.MetricsBindAddress)
+	h.Equals(t, "/metrics-tls/tls.crt", nthConfig.MetricsTLSCertFile)
+	h.Equals(t, "/metrics-tls/tls.key", nthConfig.MetricsTLSKeyFile)
+}
+
+func TestParseCliArgsLogSampleInterval(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 60, nthConfig.LogSampleInterval)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("LOG_SAMPLE_INTERVAL", "-1")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when log-sample-interval is negative")
+}
+
+func TestParseCliArgsCordonedNodeHandling(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, config.CordonedNodeHandlingLayer, nthConfig.CordonedNodeHandling)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("CORDONED_NODE_HANDLING", "drain-only")
+	nthConfig, err = config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, config.CordonedNodeHandlingDrainOnly, nthConfig.CordonedNodeHandling)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("CORDONED_NODE_HANDLING", "restore")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when cordoned-node-handling is invalid")
+}
+
+func TestParseCliArgsSafeToEvictHandling(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, config.SafeToEvictHandlingIgnore, nthConfig.SafeToEvictHandling)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("SAFE_TO_EVICT_HANDLING", "never")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when safe-to-evict-handling is invalid")
+}
+
+func TestParseCliArgsNegativeJobCompletionWait(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("JOB_COMPLETION_WAIT", "-1")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when job-completion-wait is negative")
+}
+
+func TestParseCliArgsCanaryPercentage(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 100, nthConfig.CanaryPercentage)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("CANARY_PERCENTAGE", "101")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when canary-percentage is above 100")
+}
+
+func TestParseCliArgsEvictionRetries(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 0, nthConfig.EvictionRetryAttempts)
+	h.Equals(t, 5, nthConfig.EvictionRetryInterval)
+	h.Equals(t, config.EvictionRetryEscalationRetry, nthConfig.EvictionRetryEscalation)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("EVICTION_RETRY_ESCALATION", "panic")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when eviction-retry-escalation is invalid")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("EVICTION_RETRY_ESCALATION", "give-up")
+	setEnvForTest("EVICTION_RETRY_INTERVAL", "0")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when eviction-retry-interval is 0")
+}
+
+func TestParseCliArgsOrphanedNodeGCRequiresQueueProcessor(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("ORPHANED_NODE_GC_INTERVAL", "300")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when orphaned-node-gc-interval is set without enable-sqs-termination-draining")
+}
+
+func TestParseCliArgsMaxDrainsPerAZ(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("MAX_DRAINS_PER_AZ", "2")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 2, nthConfig.MaxDrainsPerAZ)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("MAX_DRAINS_PER_AZ", "-1")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when max-drains-per-az is negative")
+}
+
+func TestParseCliArgsRescheduleVerificationTimeout(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("RESCHEDULE_VERIFICATION_TIMEOUT", "-1")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when reschedule-verification-timeout is negative")
+}
+
+func TestParseCliArgsSimulateAPI(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, false, nthConfig.EnableSimulateAPI)
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("ENABLE_SIMULATE_API", "true")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when enable-simulate-api is set without enable-control-api")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("ENABLE_STATUS_API", "true")
+	setEnvForTest("ENABLE_CONTROL_API", "true")
+	_, err = config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when enable-control-api is set without metrics-bearer-token")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("METRICS_BEARER_TOKEN", "secret")
+	nthConfig, err = config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, true, nthConfig.EnableSimulateAPI)
+}
+
+func TestParseCliArgsAWSMaxAttempts(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("AWS_MAX_ATTEMPTS", "0")
+	setEnvForTest("NODE_NAME", "node")
+	_, err := config.ParseCliArgs()
+	h.Assert(t, err != nil, "Failed to return error when aws-max-attempts is 0")
+
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	setEnvForTest("AWS_MAX_ATTEMPTS", "5")
+	setEnvForTest("AWS_MAX_BACKOFF", "2")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, 5, nthConfig.AWSMaxAttempts)
+	h.Equals(t, 2, nthConfig.AWSMaxBackoff)
+}
+
+func TestParseCliArgsDrainPoliciesWithTaintAndWait(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("ENABLE_DRAIN_POLICIES", "true")
+	setEnvForTest("DRAIN_STRATEGY", "taint-and-wait")
+	_, err := config.ParseCliArgs()
+	h.Nok(t, err)
+}
+
+func TestParseCliArgsAWSEndpointVariants(t *testing.T) {
+	resetFlagsForTest()
+	setEnvForTest("NODE_NAME", "node")
+	setEnvForTest("AWS_USE_FIPS_ENDPOINT", "true")
+	setEnvForTest("AWS_USE_DUALSTACK_ENDPOINT", "true")
+	nthConfig, err := config.ParseCliArgs()
+	h.Ok(t, err)
+	h.Equals(t, true, nthConfig.AWSUseFIPSEndpoint)
+	h.Equals(t, true, nthConfig.AWSUseDualStackEndpoint)
+}
@@ -0,0 +1,59 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Holder shares the config with the goroutines handling events while parameters refreshed from an external source are
+// applied to it
+type Holder struct {
+	sync.RWMutex
+	config Config
+	// restartParameters holds the refreshed values of the parameters which only take effect after a restart, so the
+	// restart is only asked for once for each value
+	restartParameters map[string]string
+}
+
+// NewHolder returns a holder of the config
+func NewHolder(config Config) *Holder {
+	return &Holder{config: config, restartParameters: map[string]string{}}
+}
+
+// Get returns a copy of the current config
+func (h *Holder) Get() Config {
+	h.RLock()
+	defer h.RUnlock()
+	return h.config
+}
+
+// RefreshParameters applies the refreshed parameters which are read for each event, the webhook settings, to the held
+// config like Config.ApplyParameters. The other parameters configure the monitors and clients at startup, so a warning
+// that a restart is needed is logged when their value changed.
+func (h *Holder) RefreshParameters(parameters map[string]string) ([]string, error) {
+	h.Lock()
+	defer h.Unlock()
+	refreshable, changed := h.config.refreshableParameters(parameters)
+	for _, key := range changed {
+		if value, warned := h.restartParameters[key]; warned && value == parameters[key] {
+			continue
+		}
+		h.restartParameters[key] = parameters[key]
+		log.Warn().Str("parameter", key).Msg("Configuration parameter changed, restart node termination handler to apply it")
+	}
+	return h.config.ApplyParameters(refreshable)
+}
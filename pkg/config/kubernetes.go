@@ -0,0 +1,84 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"net/http"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// writeLimiters are shared by the clients of a cluster, so evictions, patches and events written by all of them draw from
+// one budget
+var (
+	writeLimitersMutex sync.Mutex
+	writeLimiters      = map[string]flowcontrol.RateLimiter{}
+)
+
+// KubernetesClientConfig returns the config used to connect to the kubernetes api server.
+// The in-cluster config is used unless a kubeconfig file is provided.
+// Writes are rate limited if kubernetes-write-qps is set.
+func (c Config) KubernetesClientConfig() (*rest.Config, error) {
+	clientConfig, err := c.kubernetesClientConfig()
+	if err != nil || c.KubernetesWriteQPS <= 0 {
+		return clientConfig, err
+	}
+	limiter := c.writeLimiter()
+	clientConfig.Wrap(func(next http.RoundTripper) http.RoundTripper {
+		return writeLimitedRoundTripper{limiter: limiter, next: next}
+	})
+	return clientConfig, nil
+}
+
+func (c Config) kubernetesClientConfig() (*rest.Config, error) {
+	if c.Kubeconfig == "" {
+		return rest.InClusterConfig()
+	}
+	loadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: c.Kubeconfig}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: c.KubeContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+}
+
+// writeLimiter returns the write rate limiter of the cluster the config connects to
+func (c Config) writeLimiter() flowcontrol.RateLimiter {
+	key := c.Kubeconfig + "/" + c.KubeContext
+	writeLimitersMutex.Lock()
+	defer writeLimitersMutex.Unlock()
+	limiter, ok := writeLimiters[key]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(float32(c.KubernetesWriteQPS), c.KubernetesWriteBurst)
+		writeLimiters[key] = limiter
+	}
+	return limiter
+}
+
+// writeLimitedRoundTripper waits for the rate limiter before every request that isn't a read
+type writeLimitedRoundTripper struct {
+	limiter flowcontrol.RateLimiter
+	next    http.RoundTripper
+}
+
+func (w writeLimitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		if err := w.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return w.next.RoundTrip(req)
+}
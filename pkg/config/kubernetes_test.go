@@ -0,0 +1,107 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: admin
+  user:
+    token: abc
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: admin
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+current-context: dev
+`
+
+func writeKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	h.Ok(t, ioutil.WriteFile(path, []byte(testKubeconfig), os.ModePerm))
+	return path
+}
+
+func TestKubernetesClientConfigCurrentContext(t *testing.T) {
+	clientConfig, err := config.Config{Kubeconfig: writeKubeconfig(t)}.KubernetesClientConfig()
+	h.Ok(t, err)
+	h.Equals(t, "https://dev.example.com", clientConfig.Host)
+}
+
+func TestKubernetesClientConfigContext(t *testing.T) {
+	clientConfig, err := config.Config{Kubeconfig: writeKubeconfig(t), KubeContext: "prod"}.KubernetesClientConfig()
+	h.Ok(t, err)
+	h.Equals(t, "https://prod.example.com", clientConfig.Host)
+}
+
+func TestKubernetesClientConfigMissingContext(t *testing.T) {
+	_, err := config.Config{Kubeconfig: writeKubeconfig(t), KubeContext: "missing"}.KubernetesClientConfig()
+	h.Nok(t, err)
+}
+
+func TestKubernetesClientConfigWriteLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	kubeconfig := fmt.Sprintf("apiVersion: v1\nkind: Config\nclusters:\n- name: test\n  cluster:\n    server: %s\ncontexts:\n- name: test\n  context:\n    cluster: test\ncurrent-context: test\n", server.URL)
+	h.Ok(t, ioutil.WriteFile(path, []byte(kubeconfig), os.ModePerm))
+
+	clientConfig, err := config.Config{Kubeconfig: path, KubernetesWriteQPS: 10, KubernetesWriteBurst: 1}.KubernetesClientConfig()
+	h.Ok(t, err)
+	transport, err := rest.TransportFor(clientConfig)
+	h.Ok(t, err)
+	client := http.Client{Transport: transport}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		h.Ok(t, err)
+		resp.Body.Close()
+	}
+	h.Assert(t, time.Since(start) < 150*time.Millisecond, "Reads should not be rate limited")
+
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Post(server.URL, "application/json", nil)
+		h.Ok(t, err)
+		resp.Body.Close()
+	}
+	h.Assert(t, time.Since(start) >= 150*time.Millisecond, "Writes should be rate limited to 10 per second")
+}
@@ -0,0 +1,165 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+type parameterSetter func(c *Config, value string) error
+
+// parameter sets a config field from an external parameter source. The default of the field tells environment variables
+// which were set explicitly apart from the ones the Helm chart renders for all of its values. Refreshable parameters
+// are read for each event, so refreshed values take effect while running. The others configure the monitors and
+// clients at startup and only take effect after a restart.
+type parameter struct {
+	set          parameterSetter
+	defaultValue interface{}
+	refreshable  bool
+}
+
+// configParameters maps the config keys which may be loaded from an external parameter source to their config fields
+var configParameters = map[string]parameter{
+	webhookURLConfigKey:                     {stringParameter(func(c *Config) *string { return &c.WebhookURL }), webhookURLDefault, true},
+	webhookHeadersConfigKey:                 {stringParameter(func(c *Config) *string { return &c.WebhookHeaders }), webhookHeadersDefault, true},
+	webhookTemplateConfigKey:                {stringParameter(func(c *Config) *string { return &c.WebhookTemplate }), webhookTemplateDefault, true},
+	webhookProxyConfigKey:                   {stringParameter(func(c *Config) *string { return &c.WebhookProxy }), webhookProxyDefault, true},
+	queueURLConfigKey:                       {stringParameter(func(c *Config) *string { return &c.QueueURL }), "", false},
+	managedAsgTagConfigKey:                  {stringParameter(func(c *Config) *string { return &c.ManagedAsgTag }), managedAsgTagDefault, false},
+	enableScheduledEventDrainingConfigKey:   {boolParameter(func(c *Config) *bool { return &c.EnableScheduledEventDraining }), enableScheduledEventDrainingDefault, false},
+	enableSpotInterruptionDrainingConfigKey: {boolParameter(func(c *Config) *bool { return &c.EnableSpotInterruptionDraining }), enableSpotInterruptionDrainingDefault, false},
+	enableSQSTerminationDrainingConfigKey:   {boolParameter(func(c *Config) *bool { return &c.EnableSQSTerminationDraining }), enableSQSTerminationDrainingDefault, false},
+	enableRebalanceMonitoringConfigKey:      {boolParameter(func(c *Config) *bool { return &c.EnableRebalanceMonitoring }), enableRebalanceMonitoringDefault, false},
+	enableRebalanceDrainingConfigKey:        {boolParameter(func(c *Config) *bool { return &c.EnableRebalanceDraining }), enableRebalanceDrainingDefault, false},
+	checkASGTagBeforeDrainingConfigKey:      {boolParameter(func(c *Config) *bool { return &c.CheckASGTagBeforeDraining }), checkASGTagBeforeDrainingDefault, false},
+	cordonOnly:                              {boolParameter(func(c *Config) *bool { return &c.CordonOnly }), false, false},
+	taintNode:                               {boolParameter(func(c *Config) *bool { return &c.TaintNode }), false, false},
+	clusterAutoscalerCoordinationConfigKey:  {boolParameter(func(c *Config) *bool { return &c.ClusterAutoscalerCoordination }), false, false},
+	emitKubernetesEventsConfigKey:           {boolParameter(func(c *Config) *bool { return &c.EmitKubernetesEvents }), emitKubernetesEventsDefault, false},
+	nodeTerminationGracePeriodConfigKey:     {intParameter(func(c *Config) *int { return &c.NodeTerminationGracePeriod }), nodeTerminationGracePeriodDefault, false},
+	podTerminationGracePeriodConfigKey:      {intParameter(func(c *Config) *int { return &c.PodTerminationGracePeriod }), podTerminationGracePeriodDefault, false},
+}
+
+// ApplyParameters overrides config values with parameters loaded from an external source such as SSM Parameter Store.
+// Parameters are keyed by their environment variable name. Values explicitly provided via CLI args or environment
+// variables take precedence and are left untouched. Environment variables holding the default value, which the Helm
+// chart sets for values that were not changed, don't count as explicit. All values are validated before any is applied,
+// so an invalid value leaves the config unchanged. The keys of the applied parameters are returned.
+func (c *Config) ApplyParameters(parameters map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	updated := *c
+	var applied, invalid []string
+	for _, key := range keys {
+		param, ok := configParameters[key]
+		if !ok {
+			log.Warn().Str("parameter", key).Msg("Ignoring unsupported configuration parameter")
+			continue
+		}
+		if isParameterProvided(key, param.defaultValue) {
+			log.Debug().Str("parameter", key).Msg("Configuration parameter was explicitly provided, not overriding")
+			continue
+		}
+		if err := param.set(&updated, parameters[key]); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		applied = append(applied, key)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("Unable to apply configuration parameters, none were applied: %s", strings.Join(invalid, "; "))
+	}
+	*c = updated
+	return applied, nil
+}
+
+// refreshableParameters returns the parameters which take effect while running. For the others, the keys whose value
+// differs from the config are returned, they only take effect after a restart.
+func (c Config) refreshableParameters(parameters map[string]string) (map[string]string, []string) {
+	refreshable := map[string]string{}
+	var changed []string
+	for key, value := range parameters {
+		param, ok := configParameters[key]
+		if !ok || param.refreshable {
+			refreshable[key] = value
+			continue
+		}
+		if isParameterProvided(key, param.defaultValue) {
+			continue
+		}
+		updated := c
+		if err := param.set(&updated, value); err != nil || !reflect.DeepEqual(updated, c) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return refreshable, changed
+}
+
+func stringParameter(field func(c *Config) *string) parameterSetter {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func boolParameter(field func(c *Config) *bool) parameterSetter {
+	return func(c *Config, value string) error {
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = boolValue
+		return nil
+	}
+}
+
+func intParameter(field func(c *Config) *int) parameterSetter {
+	return func(c *Config, value string) error {
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = intValue
+		return nil
+	}
+}
+
+// isParameterProvided returns true if the config key was provided as CLI arg, or as environment variable with another
+// value than the default
+func isParameterProvided(key string, defaultValue interface{}) bool {
+	provided := false
+	name := flagNameForConfigKey(key)
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			provided = true
+		}
+	})
+	value := getEnv(key, "")
+	return provided || value != "" && !strings.EqualFold(value, fmt.Sprint(defaultValue))
+}
+
+func flagNameForConfigKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
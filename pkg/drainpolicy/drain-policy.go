@@ -0,0 +1,117 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package drainpolicy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// Group is the API group of the DrainPolicy custom resource
+	Group = "nodeterminationhandler.aws.amazon.com"
+	// Version is the API version of the DrainPolicy custom resource
+	Version = "v1alpha1"
+	// Resource is the plural resource name of the DrainPolicy custom resource
+	Resource = "drainpolicies"
+)
+
+// GroupVersionResource identifies the DrainPolicy custom resource
+var GroupVersionResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: Resource}
+
+// DrainPolicy declares how the pods selected in its namespace are drained
+type DrainPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              DrainPolicySpec `json:"spec"`
+}
+
+// DrainPolicySpec holds the drain rules of a DrainPolicy
+type DrainPolicySpec struct {
+	// Selector selects the pods of the policy in its namespace. All pods of the namespace are selected if it is not set.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Order sorts the eviction of selected pods in ascending order. Pods with a negative order are evicted before pods
+	// without a policy, others after them.
+	Order int `json:"order,omitempty"`
+	// GracePeriodSeconds overrides the pod termination grace period of selected pods
+	GracePeriodSeconds *int `json:"gracePeriodSeconds,omitempty"`
+	// PreStopURL is sent a POST request for each selected pod before it is evicted
+	PreStopURL string `json:"preStopURL,omitempty"`
+	// OptOut leaves selected pods running on the node
+	OptOut bool `json:"optOut,omitempty"`
+}
+
+// Lister lists the drain policies of the cluster
+type Lister interface {
+	List() ([]DrainPolicy, error)
+}
+
+// Client lists drain policies from the kubernetes api server
+type Client struct {
+	Dynamic dynamic.Interface
+}
+
+// List returns the drain policies of all namespaces
+func (c Client) List() ([]DrainPolicy, error) {
+	list, err := c.Dynamic.Resource(GroupVersionResource).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list drain policies: %w", err)
+	}
+	policies := make([]DrainPolicy, 0, len(list.Items))
+	for _, item := range list.Items {
+		policy := DrainPolicy{}
+		err = runtime.DefaultUnstructuredConverter.FromUnstructured(item.UnstructuredContent(), &policy)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse drain policy %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// Match returns the policy selecting the pod. If several policies select it, the one first by name is used.
+func Match(policies []DrainPolicy, pod corev1.Pod) (DrainPolicy, bool) {
+	matches := []DrainPolicy{}
+	for _, policy := range policies {
+		if policy.Namespace != pod.Namespace {
+			continue
+		}
+		selector := labels.Everything()
+		if policy.Spec.Selector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(policy.Spec.Selector)
+			if err != nil {
+				log.Warn().Err(err).Str("drain_policy", policy.Namespace+"/"+policy.Name).Msg("Ignoring drain policy with an invalid selector")
+				continue
+			}
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			matches = append(matches, policy)
+		}
+	}
+	if len(matches) == 0 {
+		return DrainPolicy{}, false
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Name < matches[j].Name })
+	return matches[0], true
+}
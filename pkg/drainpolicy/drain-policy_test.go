@@ -0,0 +1,95 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package drainpolicy_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/drainpolicy"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func policy(namespace string, name string, matchLabels map[string]string) drainpolicy.DrainPolicy {
+	p := drainpolicy.DrainPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	if matchLabels != nil {
+		p.Spec.Selector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	}
+	return p
+}
+
+func pod(namespace string, podLabels map[string]string) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "pod", Labels: podLabels}}
+}
+
+func TestMatch(t *testing.T) {
+	policies := []drainpolicy.DrainPolicy{
+		policy("team-a", "web", map[string]string{"app": "web"}),
+		policy("team-b", "everything", nil),
+	}
+
+	match, ok := drainpolicy.Match(policies, pod("team-a", map[string]string{"app": "web"}))
+	h.Assert(t, ok, "Expected the pod to be selected")
+	h.Equals(t, "web", match.Name)
+
+	_, ok = drainpolicy.Match(policies, pod("team-a", map[string]string{"app": "db"}))
+	h.Assert(t, !ok, "Expected the pod not to be selected")
+
+	_, ok = drainpolicy.Match(policies, pod("team-c", map[string]string{"app": "web"}))
+	h.Assert(t, !ok, "Expected policies of other namespaces to be ignored")
+
+	match, ok = drainpolicy.Match(policies, pod("team-b", nil))
+	h.Assert(t, ok, "Expected a policy without selector to select all pods of its namespace")
+	h.Equals(t, "everything", match.Name)
+}
+
+func TestMatchFirstByName(t *testing.T) {
+	policies := []drainpolicy.DrainPolicy{
+		policy("team-a", "z-policy", nil),
+		policy("team-a", "a-policy", map[string]string{"app": "web"}),
+	}
+	match, ok := drainpolicy.Match(policies, pod("team-a", map[string]string{"app": "web"}))
+	h.Assert(t, ok, "Expected the pod to be selected")
+	h.Equals(t, "a-policy", match.Name)
+}
+
+func TestClientList(t *testing.T) {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": drainpolicy.Group + "/" + drainpolicy.Version,
+		"kind":       "DrainPolicy",
+		"metadata":   map[string]interface{}{"namespace": "team-a", "name": "web"},
+		"spec": map[string]interface{}{
+			"selector":           map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"order":              int64(-1),
+			"gracePeriodSeconds": int64(5),
+			"preStopURL":         "http://web.team-a/pre-stop",
+		},
+	}}
+	dynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		drainpolicy.GroupVersionResource: "DrainPolicyList",
+	}, object)
+
+	policies, err := drainpolicy.Client{Dynamic: dynamicClient}.List()
+	h.Ok(t, err)
+	h.Equals(t, 1, len(policies))
+	h.Equals(t, "web", policies[0].Name)
+	h.Equals(t, -1, policies[0].Spec.Order)
+	h.Equals(t, 5, *policies[0].Spec.GracePeriodSeconds)
+	h.Equals(t, "http://web.team-a/pre-stop", policies[0].Spec.PreStopURL)
+}
@@ -16,14 +16,18 @@ package ec2metadata
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
+	"github.com/aws/aws-node-termination-handler/pkg/logging"
 	"github.com/rs/zerolog/log"
 )
 
@@ -60,6 +64,10 @@ const (
 	tokenTTL                = 3600 // 1 hour
 	secondsBeforeTTLRefresh = 15
 	tokenRetryAttempts      = 2
+	// maxDrainedBodyBytes is the most read from an unread response body to reuse its connection
+	maxDrainedBodyBytes = 64 * 1024
+	// maxEventBodyBytes is the most read from an event response, longer bodies fail to parse as truncated
+	maxEventBodyBytes = 64 * 1024
 )
 
 // Service is used to query the EC2 instance metadata service v1 and v2
@@ -69,40 +77,26 @@ type Service struct {
 	metadataURL string
 	v2Token     string
 	tokenTTL    int
+	// v1Fallback is set while no IMDSv2 token can be retrieved
+	v1Fallback bool
 	sync.RWMutex
 }
 
-// [
-//   {
-//     "NotBefore" : "21 Jan 2019 09:00:43 GMT",
-//     "Code" : "system-reboot",
-//     "Description" : "scheduled reboot",
-//     "EventId" : "instance-event-0d59937288b749b32",
-//     "NotAfter" : "21 Jan 2019 09:17:23 GMT",
-//     "State" : "active"
-//   }
-// ]
+// the token messages are logged on every request while IMDSv2 is unavailable
+var (
+	tokenRequestLog  logging.Sampler
+	tokenReceivedLog logging.Sampler
+	v1FallbackLog    logging.Sampler
+)
 
 // ScheduledEventDetail metadata structure for json parsing
-type ScheduledEventDetail struct {
-	NotBefore   string `json:"NotBefore"`
-	Code        string `json:"Code"`
-	Description string `json:"Description"`
-	EventID     string `json:"EventId"`
-	NotAfter    string `json:"NotAfter"`
-	State       string `json:"State"`
-}
+type ScheduledEventDetail = eventparser.ScheduledEventDetail
 
 // InstanceAction metadata structure for json parsing
-type InstanceAction struct {
-	Action string `json:"action"`
-	Time   string `json:"time"`
-}
+type InstanceAction = eventparser.InstanceAction
 
 // RebalanceRecommendation metadata structure for json parsing
-type RebalanceRecommendation struct {
-	NoticeTime string `json:"noticeTime"`
-}
+type RebalanceRecommendation = eventparser.RebalanceRecommendation
 
 // NodeMetadata contains information that applies to every drain event
 type NodeMetadata struct {
@@ -123,28 +117,51 @@ func New(metadataURL string, tries int) *Service {
 	return &Service{
 		metadataURL: metadataURL,
 		tries:       tries,
-		httpClient: http.Client{
-			Timeout: 2 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:    10,
-				IdleConnTimeout: 30 * time.Second,
-			},
+		httpClient:  newHTTPClient(),
+	}
+}
+
+// newHTTPClient returns a client which keeps its connections to IMDS alive between the polls of the monitors instead
+// of connecting for every request
+func newHTTPClient() http.Client {
+	return http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			// IMDS is link-local, so requests never go through a proxy
+			Proxy: nil,
+			DialContext: (&net.Dialer{
+				Timeout:   1 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:          10,
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 2 * time.Second,
 		},
 	}
 }
 
+// closeBody reads the rest of the response body and closes it, so the connection is reused for the next request
+func closeBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxDrainedBodyBytes))
+	resp.Body.Close()
+}
+
 // GetScheduledMaintenanceEvents retrieves EC2 scheduled maintenance events from imds
 func (e *Service) GetScheduledMaintenanceEvents() ([]ScheduledEventDetail, error) {
 	resp, err := e.Request(ScheduledEventPath)
-	if resp != nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse metadata response: %w", err)
+	}
+	defer closeBody(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("Metadata request received http status code: %d", resp.StatusCode)
 	}
+	body, err := readEventBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to parse metadata response: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-	var scheduledEvents []ScheduledEventDetail
-	err = json.NewDecoder(resp.Body).Decode(&scheduledEvents)
+	scheduledEvents, err := eventparser.ParseScheduledEvents(body)
 	if err != nil {
 		return nil, fmt.Errorf("Could not decode json retrieved from imds: %w", err)
 	}
@@ -154,18 +171,22 @@ func (e *Service) GetScheduledMaintenanceEvents() ([]ScheduledEventDetail, error
 // GetSpotITNEvent retrieves EC2 spot interruption events from imds
 func (e *Service) GetSpotITNEvent() (instanceAction *InstanceAction, err error) {
 	resp, err := e.Request(SpotInstanceActionPath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse metadata response: %w", err)
+	}
+	defer closeBody(resp)
 	// 404s are normal when querying for the 'latest/meta-data/spot' path
-	if resp != nil && resp.StatusCode == 404 {
+	if resp.StatusCode == 404 {
 		return nil, nil
-	} else if resp != nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("Metadata request received http status code: %d", resp.StatusCode)
 	}
+
+	body, err := readEventBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to parse metadata response: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	err = json.NewDecoder(resp.Body).Decode(&instanceAction)
+	instanceAction, err = eventparser.ParseInstanceAction(body)
 	if err != nil {
 		return nil, fmt.Errorf("Could not decode instance action response: %w", err)
 	}
@@ -175,34 +196,47 @@ func (e *Service) GetSpotITNEvent() (instanceAction *InstanceAction, err error)
 // GetRebalanceRecommendationEvent retrieves rebalance recommendation events from imds
 func (e *Service) GetRebalanceRecommendationEvent() (rebalanceRec *RebalanceRecommendation, err error) {
 	resp, err := e.Request(RebalanceRecommendationPath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse metadata response: %w", err)
+	}
+	defer closeBody(resp)
 	// 404s are normal when querying for the 'events/recommendations/rebalance' path
-	if resp != nil && resp.StatusCode == 404 {
+	if resp.StatusCode == 404 {
 		return nil, nil
-	} else if resp != nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("Metadata request received http status code: %d", resp.StatusCode)
 	}
+
+	body, err := readEventBody(resp)
 	if err != nil {
-		return nil, fmt.Errorf("Unable to parse metadata response: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	err = json.NewDecoder(resp.Body).Decode(&rebalanceRec)
+	rebalanceRec, err = eventparser.ParseRebalanceRecommendation(body)
 	if err != nil {
 		return nil, fmt.Errorf("Could not decode rebalance recommendation response: %w", err)
 	}
 	return rebalanceRec, nil
 }
 
+// readEventBody reads up to maxEventBodyBytes of the response body
+func readEventBody(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxEventBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the metadata response: %w", err)
+	}
+	return body, nil
+}
+
 // GetMetadataInfo generic function for retrieving ec2 metadata
 func (e *Service) GetMetadataInfo(path string) (info string, err error) {
 	resp, err := e.Request(path)
-	if resp != nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
-		return "", fmt.Errorf("Metadata request received http status code: %d", resp.StatusCode)
-	}
 	if err != nil {
 		return "", fmt.Errorf("Unable to parse metadata response: %w", err)
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("Metadata request received http status code: %d", resp.StatusCode)
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("Unable to parse http response: %w", err)
@@ -226,15 +260,25 @@ func (e *Service) Request(contextPath string) (*http.Response, error) {
 			if err != nil {
 				e.v2Token = ""
 				e.tokenTTL = -1
-				log.Debug().Msgf("Unable to retrieve an IMDSv2 token, continuing with IMDSv1, %v", err)
+				if !e.v1Fallback {
+					v1FallbackLog.Reset()
+				}
+				e.v1Fallback = true
+				v1FallbackLog.Debug().Msgf("Unable to retrieve an IMDSv2 token, continuing with IMDSv1, %v", err)
 			} else {
+				if e.v1Fallback {
+					log.Debug().Msg("Retrieved an IMDSv2 token again, no longer using IMDSv1")
+				}
+				e.v1Fallback = false
 				e.v2Token = token
 				e.tokenTTL = ttl
 			}
 			e.Unlock()
 		}
 		if e.v2Token != "" {
-			req.Header.Add(tokenRequestHeader, e.v2Token)
+			req.Header.Set(tokenRequestHeader, e.v2Token)
+		} else {
+			req.Header.Del(tokenRequestHeader)
 		}
 		httpReq := func() (*http.Response, error) {
 			return e.httpClient.Do(req)
@@ -248,6 +292,10 @@ func (e *Service) Request(contextPath string) (*http.Response, error) {
 			e.v2Token = ""
 			e.tokenTTL = 0
 			e.Unlock()
+			if i < tokenRetryAttempts-1 {
+				// the request is sent again with a new token
+				closeBody(resp)
+			}
 		} else {
 			break
 		}
@@ -270,12 +318,12 @@ func (e *Service) getV2Token() (string, int, error) {
 	httpReq := func() (*http.Response, error) {
 		return e.httpClient.Do(req)
 	}
-	log.Debug().Msg("Trying to get token from IMDSv2")
+	tokenRequestLog.Debug().Msg("Trying to get token from IMDSv2")
 	resp, err := retry(1, 2*time.Second, httpReq)
 	if err != nil {
 		return "", -1, err
 	}
-	defer resp.Body.Close()
+	defer closeBody(resp)
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return "", -1, fmt.Errorf("Received an http status code %d", resp.StatusCode)
 	}
@@ -287,7 +335,7 @@ func (e *Service) getV2Token() (string, int, error) {
 	if err != nil {
 		return "", -1, fmt.Errorf("IMDS v2 Token TTL header not sent in response: %w", err)
 	}
-	log.Debug().Msg("Got token from IMDSv2")
+	tokenReceivedLog.Debug().Msg("Got token from IMDSv2")
 	return string(token), ttl, nil
 }
 
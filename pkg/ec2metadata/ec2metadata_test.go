@@ -16,6 +16,7 @@ package ec2metadata_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -219,6 +220,34 @@ func TestGetSpotITNEvent404Success(t *testing.T) {
 	h.Assert(t, spotITN == nil, "SpotITN Event should be nil")
 }
 
+func TestGetSpotITNEvent404ReusesConnection(t *testing.T) {
+	connections := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Add("X-aws-ec2-metadata-token-ttl-seconds", "100")
+		if req.URL.String() == "/latest/api/token" {
+			_, err := rw.Write([]byte(`token`))
+			h.Ok(t, err)
+			return
+		}
+		http.NotFound(rw, req)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections++
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	imds := ec2metadata.New(server.URL, 1)
+	for i := 0; i < 5; i++ {
+		spotITN, err := imds.GetSpotITNEvent()
+		h.Ok(t, err)
+		h.Assert(t, spotITN == nil, "SpotITN Event should be nil")
+	}
+	h.Equals(t, 1, connections)
+}
+
 func TestGetSpotITNEventBadJSON(t *testing.T) {
 	var requestPath string = "/latest/meta-data/spot/instance-action"
 
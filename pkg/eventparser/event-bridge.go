@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventparser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// EventBridgeEvent is a structure to hold generic event details from Amazon EventBridge
+type EventBridgeEvent struct {
+	Version    string          `json:"version"`
+	ID         string          `json:"id"`
+	DetailType string          `json:"detail-type"`
+	Source     string          `json:"source"`
+	Account    string          `json:"account"`
+	Time       string          `json:"time"`
+	Region     string          `json:"region"`
+	Resources  []string        `json:"resources"`
+	Detail     json.RawMessage `json:"detail"`
+	// TaskToken is set by Step Functions executions waiting for the event to be handled
+	TaskToken string `json:"taskToken,omitempty"`
+}
+
+// ParseEventBridgeEvent parses an Amazon EventBridge event. The detail is left to ParseDetail, since its structure
+// depends on the source and detail type.
+func ParseEventBridgeEvent(body []byte) (EventBridgeEvent, error) {
+	var event EventBridgeEvent
+	if err := decode(body, &event); err != nil {
+		return EventBridgeEvent{}, err
+	}
+	return event, nil
+}
+
+// ParseDetail parses the detail of an Amazon EventBridge event into v. Details forwarded as a JSON encoded string,
+// e.g. by a transformation of an EventBridge rule, are decoded from the string.
+func ParseDetail(detail json.RawMessage, v interface{}) error {
+	if isNull(detail) {
+		return fmt.Errorf("%w: the event has no detail", ErrMalformed)
+	}
+	var encoded string
+	if err := json.Unmarshal(detail, &encoded); err == nil {
+		detail = json.RawMessage(encoded)
+		if isNull(detail) {
+			return fmt.Errorf("%w: the event has no detail", ErrMalformed)
+		}
+	}
+	return decode(detail, v)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package eventparser parses the interruption events of IMDS and Amazon EventBridge. The payloads come from outside
+// the cluster, so parsing never panics: missing fields are left empty, unknown fields are ignored, timestamps are
+// accepted in the formats seen in the wild, and truncated or malformed bodies fail with ErrMalformed.
+package eventparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMalformed is wrapped by the errors of payloads which cannot be parsed
+var ErrMalformed = errors.New("malformed event")
+
+// utf8BOM is prepended to JSON by some tools, which encoding/json rejects
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// timeLayouts are tried in order by ParseTime. EventBridge and IMDS use RFC 3339 and the scheduled events their own
+// format, the others are written by tools forwarding or replaying events.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2 Jan 2006 15:04:05 GMT",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// ParseTime parses a timestamp of an event. Timestamps without a time zone are in UTC, and numbers are seconds or,
+// if too large for seconds, milliseconds since the epoch.
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%w: the time is empty", ErrMalformed)
+	}
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if epoch > 1e12 || epoch < -1e12 {
+			return time.Unix(0, epoch*int64(time.Millisecond)).UTC(), nil
+		}
+		return time.Unix(epoch, 0).UTC(), nil
+	}
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: unable to parse the time %q", ErrMalformed, value)
+}
+
+// decode decodes the JSON body into v, failing with ErrMalformed on empty, truncated or malformed bodies
+func decode(body []byte, v interface{}) error {
+	body = trim(body)
+	if len(body) == 0 {
+		return fmt.Errorf("%w: the body is empty", ErrMalformed)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.Is(err, io.ErrUnexpectedEOF) || (errors.As(err, &syntaxErr) && syntaxErr.Offset >= int64(len(body))) {
+			return fmt.Errorf("%w: the body is truncated after %d bytes", ErrMalformed, len(body))
+		}
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return nil
+}
+
+// isNull returns whether the body is empty or the JSON null
+func isNull(body []byte) bool {
+	body = trim(body)
+	return len(body) == 0 || bytes.Equal(body, []byte("null"))
+}
+
+// trim removes the byte order mark and the white space around the body
+func trim(body []byte) []byte {
+	return bytes.TrimSpace(bytes.TrimPrefix(bytes.TrimSpace(body), utf8BOM))
+}
@@ -0,0 +1,74 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+// The fuzz targets need Go 1.18, run one with e.g.:
+// $ go test -run '^$' -fuzz FuzzParseEventBridgeEvent ./pkg/eventparser
+
+package eventparser_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
+)
+
+func FuzzParseEventBridgeEvent(f *testing.F) {
+	f.Add([]byte(spotITNEvent))
+	f.Add([]byte(`{"source": "aws.autoscaling", "detail": "{\"EC2InstanceId\": \"i-1\"}"}`))
+	f.Add([]byte(`{"detail": null}`))
+	f.Add([]byte("\xEF\xBB\xBF{}"))
+	f.Fuzz(func(t *testing.T, body []byte) {
+		event, err := eventparser.ParseEventBridgeEvent(body)
+		if err != nil {
+			return
+		}
+		eventparser.ParseTime(event.Time)
+		detail := spotInterruptionDetail{}
+		eventparser.ParseDetail(event.Detail, &detail)
+		generic := map[string]interface{}{}
+		eventparser.ParseDetail(event.Detail, &generic)
+	})
+}
+
+func FuzzParseTime(f *testing.F) {
+	for _, seed := range []string{"2021-03-04T05:06:07Z", "21 Jan 2019 09:00:43 GMT", "2021-03-04 05:06:07", "1614834367000", "-9223372036854775808"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		eventparser.ParseTime(value)
+	})
+}
+
+func FuzzParseIMDSEvents(f *testing.F) {
+	f.Add([]byte(`{"action": "terminate", "time": "2021-03-04T05:06:07Z"}`))
+	f.Add([]byte(`{"noticeTime": "2021-03-04T05:06:07Z"}`))
+	f.Add([]byte(`[{"NotBefore": "21 Jan 2019 09:00:43 GMT", "Code": "system-reboot", "State": "active"}]`))
+	f.Add([]byte("null"))
+	f.Fuzz(func(t *testing.T, body []byte) {
+		if instanceAction, err := eventparser.ParseInstanceAction(body); err == nil && instanceAction != nil {
+			eventparser.ParseTime(instanceAction.Time)
+		}
+		if rebalanceRecommendation, err := eventparser.ParseRebalanceRecommendation(body); err == nil && rebalanceRecommendation != nil {
+			eventparser.ParseTime(rebalanceRecommendation.NoticeTime)
+		}
+		if scheduledEvents, err := eventparser.ParseScheduledEvents(body); err == nil {
+			for _, scheduledEvent := range scheduledEvents {
+				eventparser.ParseTime(scheduledEvent.NotBefore)
+				eventparser.ParseTime(scheduledEvent.NotAfter)
+			}
+		}
+	})
+}
@@ -0,0 +1,162 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventparser_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+const spotITNEvent = `{
+	"version": "0",
+	"id": "1e5527d7-bb36-4607-3370-4164db56a40e",
+	"detail-type": "EC2 Spot Instance Interruption Warning",
+	"source": "aws.ec2",
+	"account": "123456789012",
+	"time": "1970-01-01T00:00:00Z",
+	"region": "us-east-1",
+	"resources": ["arn:aws:ec2:us-east-1b:instance/i-0b662ef9931388ba0"],
+	"detail": {"instance-id": "i-0b662ef9931388ba0", "instance-action": "terminate"}
+}`
+
+type spotInterruptionDetail struct {
+	InstanceID     string `json:"instance-id"`
+	InstanceAction string `json:"instance-action"`
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	for _, value := range []string{
+		"2021-03-04T05:06:07Z",
+		" 2021-03-04T05:06:07Z\n",
+		"2021-03-04T05:06:07.000Z",
+		"2021-03-04T07:06:07+02:00",
+		"2021-03-04T05:06:07",
+		"2021-03-04 05:06:07",
+		"4 Mar 2021 05:06:07 GMT",
+		"04 Mar 2021 05:06:07 GMT",
+		"Thu, 04 Mar 2021 05:06:07 UTC",
+		"1614834367",
+		"1614834367000",
+	} {
+		parsed, err := eventparser.ParseTime(value)
+		h.Ok(t, err)
+		h.Assert(t, parsed.Equal(expected), "Expected %q to be parsed as %s, got %s", value, expected, parsed)
+	}
+
+	for _, value := range []string{"", "  ", "yesterday", "2021-13-04T05:06:07Z", "2021-03-04T05:06"} {
+		_, err := eventparser.ParseTime(value)
+		h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected %q to be malformed, got %v", value, err)
+	}
+}
+
+func TestParseEventBridgeEvent(t *testing.T) {
+	event, err := eventparser.ParseEventBridgeEvent([]byte(spotITNEvent))
+	h.Ok(t, err)
+	h.Equals(t, "aws.ec2", event.Source)
+	h.Equals(t, "EC2 Spot Instance Interruption Warning", event.DetailType)
+	h.Equals(t, "1970-01-01T00:00:00Z", event.Time)
+
+	// byte order marks and unknown fields are ignored, missing fields are left empty
+	event, err = eventparser.ParseEventBridgeEvent([]byte("\xEF\xBB\xBF" + `{"source": "aws.ec2", "unknown": {"nested": [1, 2]}}`))
+	h.Ok(t, err)
+	h.Equals(t, "aws.ec2", event.Source)
+	h.Equals(t, "", event.ID)
+	h.Equals(t, 0, len(event.Detail))
+}
+
+func TestParseEventBridgeEventMalformed(t *testing.T) {
+	for _, body := range []string{"", "  ", "not json", `{"source": 1}`, `[]`} {
+		_, err := eventparser.ParseEventBridgeEvent([]byte(body))
+		h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected %q to be malformed, got %v", body, err)
+	}
+
+	_, err := eventparser.ParseEventBridgeEvent([]byte(spotITNEvent[:len(spotITNEvent)/2]))
+	h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the truncated event to be malformed, got %v", err)
+	h.Assert(t, strings.Contains(err.Error(), "truncated"), "Expected the error to report the truncation, got %v", err)
+}
+
+func TestParseDetail(t *testing.T) {
+	event, err := eventparser.ParseEventBridgeEvent([]byte(spotITNEvent))
+	h.Ok(t, err)
+	detail := spotInterruptionDetail{}
+	h.Ok(t, eventparser.ParseDetail(event.Detail, &detail))
+	h.Equals(t, spotInterruptionDetail{InstanceID: "i-0b662ef9931388ba0", InstanceAction: "terminate"}, detail)
+
+	encoded, err := json.Marshal(string(event.Detail))
+	h.Ok(t, err)
+	detail = spotInterruptionDetail{}
+	h.Ok(t, eventparser.ParseDetail(encoded, &detail))
+	h.Equals(t, "i-0b662ef9931388ba0", detail.InstanceID)
+
+	for _, body := range []string{"", "null", `""`, `"null"`, `"{"`, `[1]`, `{"instance-id": 1}`} {
+		err := eventparser.ParseDetail(json.RawMessage(body), &spotInterruptionDetail{})
+		h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the detail %q to be malformed, got %v", body, err)
+	}
+}
+
+func TestParseInstanceAction(t *testing.T) {
+	instanceAction, err := eventparser.ParseInstanceAction([]byte(`{"action": "stop", "time": "2021-03-04T05:06:07Z", "extra": true}`))
+	h.Ok(t, err)
+	h.Equals(t, &eventparser.InstanceAction{Action: "stop", Time: "2021-03-04T05:06:07Z"}, instanceAction)
+
+	instanceAction, err = eventparser.ParseInstanceAction([]byte("null"))
+	h.Ok(t, err)
+	h.Assert(t, instanceAction == nil, "Expected no instance action for null")
+
+	_, err = eventparser.ParseInstanceAction([]byte(`{"action": "stop", "ti`))
+	h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the truncated instance action to be malformed, got %v", err)
+	_, err = eventparser.ParseInstanceAction(nil)
+	h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the empty instance action to be malformed, got %v", err)
+}
+
+func TestParseRebalanceRecommendation(t *testing.T) {
+	rebalanceRecommendation, err := eventparser.ParseRebalanceRecommendation([]byte(`{"noticeTime": "2021-03-04T05:06:07Z"}`))
+	h.Ok(t, err)
+	h.Equals(t, "2021-03-04T05:06:07Z", rebalanceRecommendation.NoticeTime)
+
+	_, err = eventparser.ParseRebalanceRecommendation([]byte(`{"noticeTime": }`))
+	h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the rebalance recommendation to be malformed, got %v", err)
+}
+
+func TestParseScheduledEvents(t *testing.T) {
+	scheduledEvent := `{"NotBefore": "21 Jan 2019 09:00:43 GMT", "Code": "system-reboot", "EventId": "instance-event-0d59937288b749b32", "State": "active"}`
+	expected := []eventparser.ScheduledEventDetail{{
+		NotBefore: "21 Jan 2019 09:00:43 GMT",
+		Code:      "system-reboot",
+		EventID:   "instance-event-0d59937288b749b32",
+		State:     "active",
+	}}
+
+	scheduledEvents, err := eventparser.ParseScheduledEvents([]byte("[" + scheduledEvent + "]"))
+	h.Ok(t, err)
+	h.Equals(t, expected, scheduledEvents)
+
+	scheduledEvents, err = eventparser.ParseScheduledEvents([]byte(scheduledEvent))
+	h.Ok(t, err)
+	h.Equals(t, expected, scheduledEvents)
+
+	scheduledEvents, err = eventparser.ParseScheduledEvents([]byte("[]"))
+	h.Ok(t, err)
+	h.Equals(t, 0, len(scheduledEvents))
+
+	_, err = eventparser.ParseScheduledEvents([]byte("[" + scheduledEvent))
+	h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the truncated events to be malformed, got %v", err)
+}
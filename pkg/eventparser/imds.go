@@ -0,0 +1,83 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventparser
+
+import "bytes"
+
+// [
+//   {
+//     "NotBefore" : "21 Jan 2019 09:00:43 GMT",
+//     "Code" : "system-reboot",
+//     "Description" : "scheduled reboot",
+//     "EventId" : "instance-event-0d59937288b749b32",
+//     "NotAfter" : "21 Jan 2019 09:17:23 GMT",
+//     "State" : "active"
+//   }
+// ]
+
+// ScheduledEventDetail metadata structure for json parsing
+type ScheduledEventDetail struct {
+	NotBefore   string `json:"NotBefore"`
+	Code        string `json:"Code"`
+	Description string `json:"Description"`
+	EventID     string `json:"EventId"`
+	NotAfter    string `json:"NotAfter"`
+	State       string `json:"State"`
+}
+
+// InstanceAction metadata structure for json parsing
+type InstanceAction struct {
+	Action string `json:"action"`
+	Time   string `json:"time"`
+}
+
+// RebalanceRecommendation metadata structure for json parsing
+type RebalanceRecommendation struct {
+	NoticeTime string `json:"noticeTime"`
+}
+
+// ParseScheduledEvents parses the scheduled maintenance events of IMDS. A single event which is not in a list is
+// accepted as well.
+func ParseScheduledEvents(body []byte) ([]ScheduledEventDetail, error) {
+	if bytes.HasPrefix(trim(body), []byte("{")) {
+		var scheduledEvent ScheduledEventDetail
+		if err := decode(body, &scheduledEvent); err != nil {
+			return nil, err
+		}
+		return []ScheduledEventDetail{scheduledEvent}, nil
+	}
+	var scheduledEvents []ScheduledEventDetail
+	if err := decode(body, &scheduledEvents); err != nil {
+		return nil, err
+	}
+	return scheduledEvents, nil
+}
+
+// ParseInstanceAction parses the spot interruption notice of IMDS, nil is returned for the JSON null
+func ParseInstanceAction(body []byte) (*InstanceAction, error) {
+	var instanceAction *InstanceAction
+	if err := decode(body, &instanceAction); err != nil {
+		return nil, err
+	}
+	return instanceAction, nil
+}
+
+// ParseRebalanceRecommendation parses the rebalance recommendation of IMDS, nil is returned for the JSON null
+func ParseRebalanceRecommendation(body []byte) (*RebalanceRecommendation, error) {
+	var rebalanceRecommendation *RebalanceRecommendation
+	if err := decode(body, &rebalanceRecommendation); err != nil {
+		return nil, err
+	}
+	return rebalanceRecommendation, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PreDrainPhase hooks run after the event's pre-drain task, before the node is cordoned and drained
+	PreDrainPhase = "pre-drain"
+	// PostDrainPhase hooks run after the node was successfully cordoned and drained
+	PostDrainPhase = "post-drain"
+	// PostUncordonPhase hooks run after the node was uncordoned because its events were canceled or it restarted
+	PostUncordonPhase = "post-uncordon"
+)
+
+// ExecHook is a command run at a phase of handling an interruption event. It is not run through a shell.
+type ExecHook struct {
+	Command string
+	Timeout time.Duration
+}
+
+// Run runs the hook command with the event details in NTH_ prefixed environment variables
+func (h ExecHook) Run(phase string, event monitor.InterruptionEvent) error {
+	args := strings.Fields(h.Command)
+	if len(args) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), EventEnv(phase, event)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s hook %s failed: %w: %s", phase, args[0], err, strings.TrimSpace(string(output)))
+	}
+	log.Debug().Str("phase", phase).Str("event_id", event.EventID).Msgf("Hook output: %s", output)
+	return nil
+}
+
+// EventEnv returns the environment variables describing the event passed to hooks
+func EventEnv(phase string, event monitor.InterruptionEvent) []string {
+	env := map[string]string{
+		"NTH_HOOK_PHASE":        phase,
+		"NTH_EVENT_ID":          event.EventID,
+		"NTH_EVENT_KIND":        event.Kind,
+		"NTH_EVENT_CODE":        event.Code,
+		"NTH_EVENT_STATE":       event.State,
+		"NTH_EVENT_DESCRIPTION": strings.TrimSpace(event.Description),
+		"NTH_NODE":              event.NodeName,
+		"NTH_INSTANCE_ID":       event.InstanceID,
+		"NTH_INSTANCE_ACTION":   event.InstanceAction,
+		"NTH_AUTOSCALING_GROUP": event.AutoScalingGroupName,
+		"NTH_PODS":              strings.Join(event.Pods, ","),
+		"NTH_START_TIME":        formatTime(event.StartTime),
+		"NTH_DEADLINE":          formatTime(event.StartTime),
+		"NTH_END_TIME":          formatTime(event.EndTime),
+	}
+	vars := make([]string, 0, len(env))
+	for key, value := range env {
+		vars = append(vars, fmt.Sprintf("%s=%s", key, value))
+	}
+	return vars
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
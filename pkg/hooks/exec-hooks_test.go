@@ -0,0 +1,86 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/hooks"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+var event = monitor.InterruptionEvent{
+	EventID:    "spot-itn-1",
+	Kind:       "SPOT_ITN",
+	NodeName:   "test-node",
+	InstanceID: "i-0123456789",
+	Pods:       []string{"pod-1", "pod-2"},
+	StartTime:  time.Date(2021, time.June, 5, 8, 0, 0, 0, time.UTC),
+}
+
+func TestEventEnv(t *testing.T) {
+	env := hooks.EventEnv(hooks.PreDrainPhase, event)
+	for _, expected := range []string{
+		"NTH_HOOK_PHASE=pre-drain",
+		"NTH_EVENT_ID=spot-itn-1",
+		"NTH_EVENT_KIND=SPOT_ITN",
+		"NTH_NODE=test-node",
+		"NTH_INSTANCE_ID=i-0123456789",
+		"NTH_PODS=pod-1,pod-2",
+		"NTH_DEADLINE=2021-06-05T08:00:00Z",
+		"NTH_END_TIME=",
+	} {
+		h.Assert(t, contains(env, expected), "Expected hook environment to contain "+expected)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nth-hook")
+	h.Ok(t, err)
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "output")
+	script := filepath.Join(dir, "hook.sh")
+	err = ioutil.WriteFile(script, []byte("#!/bin/sh\necho \"$NTH_HOOK_PHASE $NTH_NODE $1\" > "+output+"\n"), 0755)
+	h.Ok(t, err)
+
+	err = hooks.ExecHook{Command: script + " arg", Timeout: 5 * time.Second}.Run(hooks.PostDrainPhase, event)
+	h.Ok(t, err)
+	written, err := ioutil.ReadFile(output)
+	h.Ok(t, err)
+	h.Equals(t, "post-drain test-node arg", strings.TrimSpace(string(written)))
+}
+
+func TestRunFailure(t *testing.T) {
+	err := hooks.ExecHook{Command: "/bin/sh -c exit", Timeout: 5 * time.Second}.Run(hooks.PostUncordonPhase, event)
+	h.Ok(t, err)
+	err = hooks.ExecHook{Command: "/bin/false", Timeout: 5 * time.Second}.Run(hooks.PostUncordonPhase, event)
+	h.Assert(t, err != nil, "Expected an error when the hook fails")
+	err = hooks.ExecHook{Command: "/bin/sleep 5", Timeout: 100 * time.Millisecond}.Run(hooks.PostUncordonPhase, event)
+	h.Assert(t, err != nil, "Expected an error when the hook times out")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
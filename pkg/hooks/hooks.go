@@ -0,0 +1,66 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+)
+
+const (
+	// FailureActionContinue goes on handling the event when a hook can't be run or fails
+	FailureActionContinue = "continue"
+	// FailureActionAbort stops handling the event when a hook can't be run or fails
+	FailureActionAbort = "abort"
+)
+
+// ErrAbort is returned by hooks which decided the event should not be handled any further
+var ErrAbort = errors.New("hook aborted handling the event")
+
+// Hook is run at a phase of handling an interruption event
+type Hook interface {
+	Run(phase string, event monitor.InterruptionEvent) error
+}
+
+// Chain runs hooks in order. It stops at the first hook which aborts handling the event, other errors are collected.
+type Chain []Hook
+
+// Run runs the hooks of the chain
+func (c Chain) Run(phase string, event monitor.InterruptionEvent) error {
+	var errs []string
+	for _, hook := range c {
+		err := hook.Run(phase, event)
+		if errors.Is(err, ErrAbort) {
+			return err
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// failure wraps err in ErrAbort when the failure action of the hook is abort
+func failure(failureAction string, err error) error {
+	if failureAction == FailureActionAbort {
+		return fmt.Errorf("%w: %v", ErrAbort, err)
+	}
+	return err
+}
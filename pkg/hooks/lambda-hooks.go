@@ -0,0 +1,94 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	// LambdaHookActionContinue is the response action to go on handling the event
+	LambdaHookActionContinue = "continue"
+	// LambdaHookActionAbort is the response action to stop handling the event
+	LambdaHookActionAbort = "abort"
+)
+
+// LambdaHookRequest is the payload a Lambda hook is invoked with
+type LambdaHookRequest struct {
+	Phase string                    `json:"phase"`
+	Event monitor.InterruptionEvent `json:"event"`
+}
+
+// LambdaHookResponse is the payload returned by a Lambda hook. An empty response continues handling the event.
+type LambdaHookResponse struct {
+	Action  string `json:"action"`
+	Message string `json:"message"`
+}
+
+// LambdaAPI is the part of the Lambda API the hook uses
+type LambdaAPI interface {
+	Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+// LambdaHook synchronously invokes a Lambda function at a phase of handling an interruption event
+type LambdaHook struct {
+	Lambda       LambdaAPI
+	FunctionName string
+	// FailureAction is continue or abort, and decides if the event is handled when the function can't be invoked or fails
+	FailureAction string
+}
+
+// Run invokes the function with the phase and event, and returns ErrAbort if the function responds with the abort action
+func (h LambdaHook) Run(phase string, event monitor.InterruptionEvent) error {
+	if h.FunctionName == "" {
+		return nil
+	}
+	payload, err := json.Marshal(LambdaHookRequest{Phase: phase, Event: event})
+	if err != nil {
+		return failure(h.FailureAction, fmt.Errorf("Unable to marshal the %s Lambda hook payload: %w", phase, err))
+	}
+	output, err := h.Lambda.Invoke(context.TODO(), &lambda.InvokeInput{
+		FunctionName:   aws.String(h.FunctionName),
+		InvocationType: types.InvocationTypeRequestResponse,
+		Payload:        payload,
+	})
+	if err != nil {
+		return failure(h.FailureAction, fmt.Errorf("Unable to invoke %s Lambda hook %s: %w", phase, h.FunctionName, err))
+	}
+	if output.FunctionError != nil {
+		return failure(h.FailureAction, fmt.Errorf("%s Lambda hook %s failed with %s: %s", phase, h.FunctionName, aws.ToString(output.FunctionError), output.Payload))
+	}
+	response := LambdaHookResponse{}
+	if len(output.Payload) > 0 && string(output.Payload) != "null" {
+		err = json.Unmarshal(output.Payload, &response)
+		if err != nil {
+			return failure(h.FailureAction, fmt.Errorf("Unable to parse the response of %s Lambda hook %s: %w", phase, h.FunctionName, err))
+		}
+	}
+	switch response.Action {
+	case "", LambdaHookActionContinue:
+		return nil
+	case LambdaHookActionAbort:
+		return fmt.Errorf("%w: %s Lambda hook %s: %s", ErrAbort, phase, h.FunctionName, response.Message)
+	default:
+		return failure(h.FailureAction, fmt.Errorf("%s Lambda hook %s responded with an unknown action %s", phase, h.FunctionName, response.Action))
+	}
+}
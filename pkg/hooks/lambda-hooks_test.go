@@ -0,0 +1,89 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/hooks"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+func lambdaHook(invokeResp lambda.InvokeOutput, invokeErr error, failureAction string) hooks.LambdaHook {
+	return hooks.LambdaHook{
+		Lambda:        h.MockedLambda{InvokeResp: invokeResp, InvokeErr: invokeErr},
+		FunctionName:  "drain-hook",
+		FailureAction: failureAction,
+	}
+}
+
+func TestLambdaHookNoFunction(t *testing.T) {
+	err := hooks.LambdaHook{}.Run(hooks.PreDrainPhase, event)
+	h.Ok(t, err)
+}
+
+func TestLambdaHookContinue(t *testing.T) {
+	for _, payload := range []string{"", "null", `{}`, `{"action":"continue"}`} {
+		err := lambdaHook(lambda.InvokeOutput{Payload: []byte(payload)}, nil, hooks.FailureActionAbort).Run(hooks.PreDrainPhase, event)
+		h.Ok(t, err)
+	}
+}
+
+func TestLambdaHookAbort(t *testing.T) {
+	err := lambdaHook(lambda.InvokeOutput{Payload: []byte(`{"action":"abort","message":"keep it"}`)}, nil, hooks.FailureActionContinue).Run(hooks.PreDrainPhase, event)
+	h.Assert(t, errors.Is(err, hooks.ErrAbort), "Expected the hook to abort, got: %v", err)
+}
+
+func TestLambdaHookFailureContinue(t *testing.T) {
+	for _, hook := range []hooks.LambdaHook{
+		lambdaHook(lambda.InvokeOutput{}, errors.New("throttled"), hooks.FailureActionContinue),
+		lambdaHook(lambda.InvokeOutput{FunctionError: aws.String("Unhandled"), Payload: []byte(`{"errorMessage":"boom"}`)}, nil, hooks.FailureActionContinue),
+		lambdaHook(lambda.InvokeOutput{Payload: []byte(`not json`)}, nil, hooks.FailureActionContinue),
+		lambdaHook(lambda.InvokeOutput{Payload: []byte(`{"action":"retry"}`)}, nil, hooks.FailureActionContinue),
+	} {
+		err := hook.Run(hooks.PostDrainPhase, event)
+		h.Assert(t, err != nil, "Expected the hook to fail")
+		h.Assert(t, !errors.Is(err, hooks.ErrAbort), "Expected the hook not to abort, got: %v", err)
+	}
+}
+
+func TestLambdaHookFailureAbort(t *testing.T) {
+	err := lambdaHook(lambda.InvokeOutput{}, errors.New("throttled"), hooks.FailureActionAbort).Run(hooks.PreDrainPhase, event)
+	h.Assert(t, errors.Is(err, hooks.ErrAbort), "Expected the hook to abort, got: %v", err)
+}
+
+func TestChainCollectsErrors(t *testing.T) {
+	chain := hooks.Chain{
+		lambdaHook(lambda.InvokeOutput{}, errors.New("first"), hooks.FailureActionContinue),
+		lambdaHook(lambda.InvokeOutput{}, errors.New("second"), hooks.FailureActionContinue),
+	}
+	err := chain.Run(hooks.PreDrainPhase, event)
+	h.Assert(t, err != nil, "Expected the chain to fail")
+	h.Assert(t, !errors.Is(err, hooks.ErrAbort), "Expected the chain not to abort, got: %v", err)
+	h.Assert(t, strings.Contains(err.Error(), "first") && strings.Contains(err.Error(), "second"), "Expected both errors, got: %v", err)
+}
+
+func TestChainStopsAtAbort(t *testing.T) {
+	chain := hooks.Chain{
+		lambdaHook(lambda.InvokeOutput{Payload: []byte(`{"action":"abort"}`)}, nil, hooks.FailureActionContinue),
+		lambdaHook(lambda.InvokeOutput{}, errors.New("not invoked"), hooks.FailureActionContinue),
+	}
+	err := chain.Run(hooks.PreDrainPhase, event)
+	h.Assert(t, errors.Is(err, hooks.ErrAbort), "Expected the chain to abort, got: %v", err)
+	h.Assert(t, !strings.Contains(err.Error(), "not invoked"), "Expected the chain to stop at the abort, got: %v", err)
+}
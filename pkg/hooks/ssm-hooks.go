@@ -0,0 +1,104 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+const defaultSSMPollInterval = 5 * time.Second
+
+// SSMAPI is the part of the SSM API the hook uses
+type SSMAPI interface {
+	SendCommand(ctx context.Context, params *ssm.SendCommandInput, optFns ...func(*ssm.Options)) (*ssm.SendCommandOutput, error)
+	GetCommandInvocation(ctx context.Context, params *ssm.GetCommandInvocationInput, optFns ...func(*ssm.Options)) (*ssm.GetCommandInvocationOutput, error)
+	CancelCommand(ctx context.Context, params *ssm.CancelCommandInput, optFns ...func(*ssm.Options)) (*ssm.CancelCommandOutput, error)
+}
+
+// SSMHook runs an SSM document on the instance of an interruption event with Run Command and waits for its result
+type SSMHook struct {
+	SSM          SSMAPI
+	DocumentName string
+	Parameters   map[string]string
+	// Timeout is the period of time the command may take before it is canceled
+	Timeout      time.Duration
+	PollInterval time.Duration
+	// FailureAction is continue or abort, and decides if the event is handled when the command can't be sent or fails
+	FailureAction string
+}
+
+// Run sends the command to the instance of the event and waits until it succeeded, failed or timed out
+func (h SSMHook) Run(phase string, event monitor.InterruptionEvent) error {
+	if h.DocumentName == "" {
+		return nil
+	}
+	if event.InstanceID == "" {
+		return failure(h.FailureAction, fmt.Errorf("Unable to run %s SSM document %s, the instance of event %s is unknown", phase, h.DocumentName, event.EventID))
+	}
+	parameters := map[string][]string{}
+	for name, value := range h.Parameters {
+		parameters[name] = []string{value}
+	}
+	output, err := h.SSM.SendCommand(context.TODO(), &ssm.SendCommandInput{
+		DocumentName: aws.String(h.DocumentName),
+		InstanceIds:  []string{event.InstanceID},
+		Parameters:   parameters,
+	})
+	if err != nil {
+		return failure(h.FailureAction, fmt.Errorf("Unable to send %s SSM document %s to instance %s: %w", phase, h.DocumentName, event.InstanceID, err))
+	}
+	commandID := output.Command.CommandId
+	pollInterval := h.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultSSMPollInterval
+	}
+	deadline := time.Now().Add(h.Timeout)
+	for {
+		invocation, err := h.SSM.GetCommandInvocation(context.TODO(), &ssm.GetCommandInvocationInput{
+			CommandId:  commandID,
+			InstanceId: aws.String(event.InstanceID),
+		})
+		if err != nil {
+			// the invocation is only visible a moment after the command was sent
+			var notExists *types.InvocationDoesNotExist
+			if !errors.As(err, &notExists) {
+				return failure(h.FailureAction, fmt.Errorf("Unable to get the result of %s SSM command %s: %w", phase, aws.ToString(commandID), err))
+			}
+		} else {
+			switch invocation.Status {
+			case types.CommandInvocationStatusSuccess:
+				return nil
+			case types.CommandInvocationStatusFailed, types.CommandInvocationStatusCancelled, types.CommandInvocationStatusTimedOut:
+				return failure(h.FailureAction, fmt.Errorf("%s SSM command %s ended with status %s: %s", phase, aws.ToString(commandID), aws.ToString(invocation.StatusDetails), aws.ToString(invocation.StandardErrorContent)))
+			}
+		}
+		if time.Now().Add(pollInterval).After(deadline) {
+			break
+		}
+		time.Sleep(pollInterval)
+	}
+	_, err = h.SSM.CancelCommand(context.TODO(), &ssm.CancelCommandInput{CommandId: commandID, InstanceIds: []string{event.InstanceID}})
+	if err != nil {
+		return failure(h.FailureAction, fmt.Errorf("%s SSM command %s timed out after %s and could not be canceled: %w", phase, aws.ToString(commandID), h.Timeout, err))
+	}
+	return failure(h.FailureAction, fmt.Errorf("%s SSM command %s timed out after %s", phase, aws.ToString(commandID), h.Timeout))
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package hooks_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/hooks"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func ssmHook(mock h.MockedSSM, failureAction string) hooks.SSMHook {
+	mock.SendCommandResp = ssm.SendCommandOutput{Command: &types.Command{CommandId: aws.String("command-1")}}
+	return hooks.SSMHook{
+		SSM:           mock,
+		DocumentName:  "flush-caches",
+		Parameters:    map[string]string{"path": "/var/cache"},
+		Timeout:       50 * time.Millisecond,
+		PollInterval:  10 * time.Millisecond,
+		FailureAction: failureAction,
+	}
+}
+
+func invocationStatus(status types.CommandInvocationStatus) h.MockedSSM {
+	return h.MockedSSM{GetCommandInvocationResp: ssm.GetCommandInvocationOutput{Status: status, StatusDetails: aws.String(string(status))}}
+}
+
+func TestSSMHookNoDocument(t *testing.T) {
+	err := hooks.SSMHook{}.Run(hooks.PreDrainPhase, event)
+	h.Ok(t, err)
+}
+
+func TestSSMHookSuccess(t *testing.T) {
+	err := ssmHook(invocationStatus(types.CommandInvocationStatusSuccess), hooks.FailureActionAbort).Run(hooks.PreDrainPhase, event)
+	h.Ok(t, err)
+}
+
+func TestSSMHookFailed(t *testing.T) {
+	for _, status := range []types.CommandInvocationStatus{types.CommandInvocationStatusFailed, types.CommandInvocationStatusCancelled, types.CommandInvocationStatusTimedOut} {
+		err := ssmHook(invocationStatus(status), hooks.FailureActionContinue).Run(hooks.PreDrainPhase, event)
+		h.Assert(t, err != nil, "Expected the hook to fail for status %s", status)
+		h.Assert(t, !errors.Is(err, hooks.ErrAbort), "Expected the hook not to abort, got: %v", err)
+	}
+}
+
+func TestSSMHookFailedAbort(t *testing.T) {
+	err := ssmHook(invocationStatus(types.CommandInvocationStatusFailed), hooks.FailureActionAbort).Run(hooks.PreDrainPhase, event)
+	h.Assert(t, errors.Is(err, hooks.ErrAbort), "Expected the hook to abort, got: %v", err)
+}
+
+func TestSSMHookTimeout(t *testing.T) {
+	start := time.Now()
+	err := ssmHook(invocationStatus(types.CommandInvocationStatusInProgress), hooks.FailureActionContinue).Run(hooks.PreDrainPhase, event)
+	h.Assert(t, err != nil, "Expected the hook to time out")
+	h.Assert(t, time.Since(start) < time.Second, "Expected the hook to give up after its timeout")
+}
+
+func TestSSMHookSendCommandError(t *testing.T) {
+	err := ssmHook(h.MockedSSM{SendCommandErr: errors.New("no agent")}, hooks.FailureActionAbort).Run(hooks.PreDrainPhase, event)
+	h.Assert(t, errors.Is(err, hooks.ErrAbort), "Expected the hook to abort, got: %v", err)
+}
+
+func TestSSMHookUnknownInstance(t *testing.T) {
+	imdsEvent := event
+	imdsEvent.InstanceID = ""
+	err := ssmHook(invocationStatus(types.CommandInvocationStatusSuccess), hooks.FailureActionContinue).Run(hooks.PreDrainPhase, imdsEvent)
+	h.Assert(t, err != nil, "Expected the hook to fail without an instance")
+}
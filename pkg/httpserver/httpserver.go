@@ -0,0 +1,93 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package httpserver
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/rs/zerolog/log"
+)
+
+// TokenHeader carries the bearer token of requests sent through the pod proxy of the Kubernetes API server, which
+// consumes the Authorization header of the requests it proxies
+const TokenHeader = "X-NTH-Token"
+
+// Options configure how the prometheus server and the status API listen, for clusters whose security policy forbids
+// plaintext, unauthenticated endpoints on the host network
+type Options struct {
+	// BindAddress is the address to listen on, all addresses if empty
+	BindAddress string
+	// TLSCertFile and TLSKeyFile serve over HTTPS if set
+	TLSCertFile string
+	TLSKeyFile  string
+	// BearerToken must be sent in the Authorization header or the TokenHeader of the requests if set
+	BearerToken string
+}
+
+// NewOptions returns the listener options of the configuration
+func NewOptions(nthConfig config.Config) Options {
+	return Options{
+		BindAddress: nthConfig.MetricsBindAddress,
+		TLSCertFile: nthConfig.MetricsTLSCertFile,
+		TLSKeyFile:  nthConfig.MetricsTLSKeyFile,
+		BearerToken: nthConfig.MetricsBearerToken,
+	}
+}
+
+// Addr returns the address to listen on for the port
+func (o Options) Addr(port int) string {
+	return net.JoinHostPort(o.BindAddress, strconv.Itoa(port))
+}
+
+// Scheme returns the scheme the server is reached with
+func (o Options) Scheme() string {
+	if o.TLSCertFile != "" {
+		return "https"
+	}
+	return "http"
+}
+
+// Handler returns the handler, which rejects requests without the bearer token if one is configured
+func (o Options) Handler(handler http.Handler) http.Handler {
+	if o.BearerToken == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		authorization := req.Header.Get("Authorization")
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if proxiedToken := req.Header.Get(TokenHeader); proxiedToken != "" {
+			authorization, token = "", proxiedToken
+		}
+		if token == authorization || subtle.ConstantTimeCompare([]byte(token), []byte(o.BearerToken)) != 1 {
+			log.Debug().Str("remote_addr", req.RemoteAddr).Str("path", req.URL.Path).Msg("Rejecting request without a valid bearer token")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="aws-node-termination-handler"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// ListenAndServe serves the server over HTTPS if a certificate is configured, and over plain HTTP otherwise
+func (o Options) ListenAndServe(server *http.Server) error {
+	if o.TLSCertFile != "" {
+		return server.ListenAndServeTLS(o.TLSCertFile, o.TLSKeyFile)
+	}
+	return server.ListenAndServe()
+}
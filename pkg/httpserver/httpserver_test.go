@@ -0,0 +1,83 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/httpserver"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestNewOptions(t *testing.T) {
+	options := httpserver.NewOptions(config.Config{
+		MetricsBindAddress: "10.0.0.1",
+		MetricsTLSCertFile: "/metrics-tls/tls.crt",
+		MetricsTLSKeyFile:  "/metrics-tls/tls.key",
+		MetricsBearerToken: "secret",
+	})
+	h.Equals(t, "10.0.0.1:9092", options.Addr(9092))
+	h.Equals(t, "https", options.Scheme())
+	h.Equals(t, "secret", options.BearerToken)
+}
+
+func TestAddr(t *testing.T) {
+	h.Equals(t, ":9092", httpserver.Options{}.Addr(9092))
+	h.Equals(t, "127.0.0.1:8080", httpserver.Options{BindAddress: "127.0.0.1"}.Addr(8080))
+	h.Equals(t, "[::1]:8080", httpserver.Options{BindAddress: "::1"}.Addr(8080))
+}
+
+func TestScheme(t *testing.T) {
+	h.Equals(t, "http", httpserver.Options{}.Scheme())
+	h.Equals(t, "https", httpserver.Options{TLSCertFile: "tls.crt", TLSKeyFile: "tls.key"}.Scheme())
+}
+
+func TestHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(options httpserver.Options, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		options.Handler(ok).ServeHTTP(rec, req)
+		return rec
+	}
+
+	h.Equals(t, http.StatusOK, serve(httpserver.Options{}, "").Code)
+
+	options := httpserver.Options{BearerToken: "secret"}
+	rec := serve(options, "")
+	h.Equals(t, http.StatusUnauthorized, rec.Code)
+	h.Assert(t, rec.Header().Get("WWW-Authenticate") != "", "Expected a WWW-Authenticate challenge")
+	h.Equals(t, http.StatusUnauthorized, serve(options, "secret").Code)
+	h.Equals(t, http.StatusUnauthorized, serve(options, "Bearer wrong").Code)
+	h.Equals(t, http.StatusUnauthorized, serve(options, "Basic c2VjcmV0").Code)
+	h.Equals(t, http.StatusOK, serve(options, "Bearer secret").Code)
+
+	proxied := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set(httpserver.TokenHeader, token)
+		rec := httptest.NewRecorder()
+		options.Handler(ok).ServeHTTP(rec, req)
+		return rec.Code
+	}
+	h.Equals(t, http.StatusUnauthorized, proxied("wrong"))
+	h.Equals(t, http.StatusOK, proxied("secret"))
+}
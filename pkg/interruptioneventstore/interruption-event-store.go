@@ -14,6 +14,7 @@
 package interruptioneventstore
 
 import (
+	"sort"
 	"sync"
 	"time"
 
@@ -23,61 +24,373 @@ import (
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 )
 
+// restoredEventGracePeriod is how long events restored from the journal wait for their monitor to report them again,
+// which attaches the drain tasks the journal cannot persist
+const restoredEventGracePeriod = 10 * time.Second
+
+// retryBaseDelay is how long an event whose drain failed waits before it is handed out again. The delay doubles with
+// every failed attempt, up to retryMaxDelay.
+var retryBaseDelay = 10 * time.Second
+
+const retryMaxDelay = 5 * time.Minute
+
+// Journal persists the events of the store which are not handled yet, so they are handled after a restart
+type Journal interface {
+	Add(interruptionEvent monitor.InterruptionEvent) error
+	Remove(eventIDs ...string) error
+}
+
+// journalEntry is a change of the journal recorded while holding the lock of the store, and written to the journal
+// once the lock is released so reading the store never waits on the disk
+type journalEntry struct {
+	add    *monitor.InterruptionEvent
+	remove []string
+}
+
 // Store is the drain event store data structure
 type Store struct {
 	sync.RWMutex
 	NthConfig              config.Config
 	interruptionEventStore map[string]*monitor.InterruptionEvent
 	ignoredEvents          map[string]struct{}
-	atLeastOneEvent        bool
-	Workers                chan int
+	nodesInProgress        map[string]struct{}
+	approvedNodes          map[string]struct{}
+	nodeZones              map[string]string
+	zonesInProgress        map[string]int
+	restoredEvents         map[string]time.Time
+	failedAttempts         map[string]int
+	retryAt                map[string]time.Time
+	journal                Journal
+	journalEntries         []journalEntry
+	// journalMutex keeps the journal entries written in the order they were recorded in
+	journalMutex    sync.Mutex
+	paused          bool
+	atLeastOneEvent bool
+	// Workers holds a token for each event being handled, at most workerLimit of them
+	Workers     chan int
+	workerLimit int
 }
 
 // New Creates a new interruption event store
 func New(nthConfig config.Config) *Store {
+	workerLimit := nthConfig.Workers
+	if nthConfig.EnableWorkerAutoscaling {
+		workerLimit = nthConfig.MinWorkers
+	}
 	return &Store{
 		NthConfig:              nthConfig,
 		interruptionEventStore: make(map[string]*monitor.InterruptionEvent),
 		ignoredEvents:          make(map[string]struct{}),
+		nodesInProgress:        make(map[string]struct{}),
+		approvedNodes:          make(map[string]struct{}),
+		nodeZones:              make(map[string]string),
+		zonesInProgress:        make(map[string]int),
+		restoredEvents:         make(map[string]time.Time),
+		failedAttempts:         make(map[string]int),
+		retryAt:                make(map[string]time.Time),
 		Workers:                make(chan int, nthConfig.Workers),
+		workerLimit:            workerLimit,
+	}
+}
+
+// SetJournal records the events added to the store in the journal until they are handled
+func (s *Store) SetJournal(journal Journal) {
+	s.Lock()
+	defer s.Unlock()
+	s.journal = journal
+}
+
+// Restore adds the events which were not handled before a restart. They are held back until their monitor reports them
+// again or restoredEventGracePeriod passes, so they are handled with their drain tasks if the monitor still knows them.
+func (s *Store) Restore(interruptionEvents []monitor.InterruptionEvent) {
+	defer s.writeJournal()
+	s.Lock()
+	defer s.Unlock()
+	for i := range interruptionEvents {
+		interruptionEvent := interruptionEvents[i]
+		if _, ok := s.interruptionEventStore[interruptionEvent.EventID]; ok {
+			continue
+		}
+		if _, ignored := s.ignoredEvents[interruptionEvent.EventID]; ignored {
+			s.removeFromJournal(interruptionEvent.EventID)
+			continue
+		}
+		log.Info().Interface("event", interruptionEvent).Msg("Restoring unfinished event from the event journal")
+		s.interruptionEventStore[interruptionEvent.EventID] = &interruptionEvent
+		s.restoredEvents[interruptionEvent.EventID] = time.Now().Add(restoredEventGracePeriod)
+		s.atLeastOneEvent = true
 	}
 }
 
 // CancelInterruptionEvent removes an interruption event from the internal store
 func (s *Store) CancelInterruptionEvent(eventID string) {
+	defer s.writeJournal()
 	s.Lock()
 	defer s.Unlock()
 	delete(s.interruptionEventStore, eventID)
+	delete(s.restoredEvents, eventID)
+	delete(s.failedAttempts, eventID)
+	delete(s.retryAt, eventID)
+	s.removeFromJournal(eventID)
 }
 
 // AddInterruptionEvent adds an interruption event to the internal store
 func (s *Store) AddInterruptionEvent(interruptionEvent *monitor.InterruptionEvent) {
 	s.RLock()
 	_, ok := s.interruptionEventStore[interruptionEvent.EventID]
+	_, restored := s.restoredEvents[interruptionEvent.EventID]
 	s.RUnlock()
-	if ok {
+	if ok && !restored {
 		return
 	}
 
+	defer s.writeJournal()
 	s.Lock()
 	defer s.Unlock()
+	if storedEvent, ok := s.interruptionEventStore[interruptionEvent.EventID]; ok {
+		if _, restored := s.restoredEvents[interruptionEvent.EventID]; restored && !storedEvent.InProgress {
+			storedEvent.PreDrainTask = interruptionEvent.PreDrainTask
+			storedEvent.PostDrainTask = interruptionEvent.PostDrainTask
+			delete(s.restoredEvents, interruptionEvent.EventID)
+		}
+		return
+	}
 	log.Info().Interface("event", interruptionEvent).Msg("Adding new event to the event store")
 	s.interruptionEventStore[interruptionEvent.EventID] = interruptionEvent
 	if _, ignored := s.ignoredEvents[interruptionEvent.EventID]; !ignored {
 		s.atLeastOneEvent = true
+		if s.journal != nil {
+			journaled := *interruptionEvent
+			s.journalEntries = append(s.journalEntries, journalEntry{add: &journaled})
+		}
+	}
+}
+
+// HasEvent returns true if an interruption event with the event ID is in the internal store
+func (s *Store) HasEvent(eventID string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	_, ok := s.interruptionEventStore[eventID]
+	return ok
+}
+
+// Snapshot returns copies of the stored interruption events, ordered by start time
+func (s *Store) Snapshot() []monitor.InterruptionEvent {
+	s.RLock()
+	defer s.RUnlock()
+	events := make([]monitor.InterruptionEvent, 0, len(s.interruptionEventStore))
+	for _, interruptionEvent := range s.interruptionEventStore {
+		events = append(events, *interruptionEvent)
 	}
+	sort.Slice(events, func(i, j int) bool { return events[i].StartTime.Before(events[j].StartTime) })
+	return events
 }
 
-// GetActiveEvent returns true if there are interruption events in the internal store
+// GetActiveEvent returns true if there are interruption events in the internal store. When a node has several drainable
+// events, the most urgent one is returned, and no event is returned for a node which already has an event in progress.
+// Events are held back while max-drains-per-az nodes of their availability zone are in progress.
 func (s *Store) GetActiveEvent() (*monitor.InterruptionEvent, bool) {
 	s.RLock()
 	defer s.RUnlock()
+	var activeEvent *monitor.InterruptionEvent
 	for _, interruptionEvent := range s.interruptionEventStore {
-		if s.shouldEventDrain(interruptionEvent) {
-			return interruptionEvent, true
+		if _, inProgress := s.nodesInProgress[interruptionEvent.NodeKey()]; inProgress || interruptionEvent.InProgress {
+			continue
+		}
+		if _, approved := s.approvedNodes[interruptionEvent.NodeName]; s.paused && !approved {
+			continue
+		}
+		if heldUntil, restored := s.restoredEvents[interruptionEvent.EventID]; restored && time.Now().Before(heldUntil) {
+			continue
 		}
+		if retryAt, failed := s.retryAt[interruptionEvent.EventID]; failed && time.Now().Before(retryAt) {
+			continue
+		}
+		if s.zoneIsBusy(interruptionEvent.AvailabilityZone) {
+			continue
+		}
+		if s.shouldEventDrain(interruptionEvent) && (activeEvent == nil || interruptionEvent.IsMoreUrgentThan(activeEvent)) {
+			activeEvent = interruptionEvent
+		}
+	}
+	if activeEvent == nil {
+		return &monitor.InterruptionEvent{}, false
+	}
+	return activeEvent, true
+}
+
+// MarkInProgress marks the event and its node as in progress, so other events for the node wait until ReleaseNode is called
+func (s *Store) MarkInProgress(interruptionEvent *monitor.InterruptionEvent) {
+	s.Lock()
+	defer s.Unlock()
+	interruptionEvent.InProgress = true
+	s.nodesInProgress[interruptionEvent.NodeKey()] = struct{}{}
+	delete(s.approvedNodes, interruptionEvent.NodeName)
+	if interruptionEvent.AvailabilityZone != "" {
+		s.nodeZones[interruptionEvent.NodeKey()] = interruptionEvent.AvailabilityZone
+		s.zonesInProgress[interruptionEvent.AvailabilityZone]++
+	}
+}
+
+// zoneIsBusy returns true if max-drains-per-az nodes of the availability zone are in progress, the caller must hold the lock
+func (s *Store) zoneIsBusy(zone string) bool {
+	if zone == "" || s.NthConfig.MaxDrainsPerAZ <= 0 {
+		return false
+	}
+	return s.zonesInProgress[zone] >= s.NthConfig.MaxDrainsPerAZ
+}
+
+// Pause holds back handling events which are not in progress yet, until Resume is called or their node is approved
+func (s *Store) Pause() {
+	s.Lock()
+	defer s.Unlock()
+	log.Info().Msg("Pausing handling interruption events")
+	s.paused = true
+}
+
+// Resume handles events again after Pause, approvals which were not used are dropped
+func (s *Store) Resume() {
+	s.Lock()
+	defer s.Unlock()
+	log.Info().Msg("Resuming handling interruption events")
+	s.paused = false
+	s.approvedNodes = make(map[string]struct{})
+}
+
+// Paused returns true if handling events is paused
+func (s *Store) Paused() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.paused
+}
+
+// ApproveNode allows the next event for the node to be handled while handling events is paused
+func (s *Store) ApproveNode(nodeName string) {
+	s.Lock()
+	defer s.Unlock()
+	log.Info().Str("node_name", nodeName).Msg("Approving handling the next interruption event of the node")
+	s.approvedNodes[nodeName] = struct{}{}
+}
+
+// ApprovedNodes returns the names of the nodes which are approved while handling events is paused
+func (s *Store) ApprovedNodes() []string {
+	s.RLock()
+	defer s.RUnlock()
+	nodeNames := make([]string, 0, len(s.approvedNodes))
+	for nodeName := range s.approvedNodes {
+		nodeNames = append(nodeNames, nodeName)
 	}
-	return &monitor.InterruptionEvent{}, false
+	sort.Strings(nodeNames)
+	return nodeNames
+}
+
+// AcquireWorker takes a worker to handle an event, returning false if all workers are busy. The worker is released by
+// receiving from Workers.
+func (s *Store) AcquireWorker() bool {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.Workers) >= s.workerLimit {
+		return false
+	}
+	select {
+	case s.Workers <- 1:
+		return true
+	default:
+		return false
+	}
+}
+
+// BusyWorkers returns the number of events being handled
+func (s *Store) BusyWorkers() int {
+	return len(s.Workers)
+}
+
+// WorkerLimit returns the number of events which may be handled in parallel
+func (s *Store) WorkerLimit() int {
+	s.RLock()
+	defer s.RUnlock()
+	return s.workerLimit
+}
+
+// SetWorkerLimit changes the number of events which may be handled in parallel, up to the configured workers. Events
+// already being handled are not affected when the limit is lowered.
+func (s *Store) SetWorkerLimit(limit int) {
+	s.Lock()
+	defer s.Unlock()
+	if limit > cap(s.Workers) {
+		limit = cap(s.Workers)
+	}
+	s.workerLimit = limit
+}
+
+// PendingEvents returns the number of drainable events which are not being handled yet
+func (s *Store) PendingEvents() int {
+	s.RLock()
+	defer s.RUnlock()
+	pending := 0
+	for _, interruptionEvent := range s.interruptionEventStore {
+		if !interruptionEvent.InProgress && s.shouldEventDrain(interruptionEvent) {
+			pending++
+		}
+	}
+	return pending
+}
+
+// RecordFailedAttempt records that handling the event failed. The event is handed out again once its node is released
+// and the backoff of its failed attempts passed. After drain-max-attempts failed attempts, or a final failure like the
+// node being gone, the event is marked as processed instead. It returns whether the event is retried.
+func (s *Store) RecordFailedAttempt(interruptionEvent *monitor.InterruptionEvent, final bool) bool {
+	defer s.writeJournal()
+	s.Lock()
+	defer s.Unlock()
+	s.failedAttempts[interruptionEvent.EventID]++
+	attempts := s.failedAttempts[interruptionEvent.EventID]
+	if final || attempts >= s.NthConfig.DrainMaxAttempts {
+		interruptionEvent.NodeProcessed = true
+		delete(s.failedAttempts, interruptionEvent.EventID)
+		delete(s.retryAt, interruptionEvent.EventID)
+		s.removeFromJournal(interruptionEvent.EventID)
+		return false
+	}
+	delay := retryMaxDelay
+	if attempts < 32 && retryBaseDelay<<(attempts-1) < retryMaxDelay {
+		delay = retryBaseDelay << (attempts - 1)
+	}
+	s.retryAt[interruptionEvent.EventID] = time.Now().Add(delay)
+	return true
+}
+
+// ReleaseNode allows events for the node, identified by the node key of its events, to be processed again once the
+// event in progress is done. Events of the node which were not processed, e.g. after a failed drain, are handled again.
+func (s *Store) ReleaseNode(nodeKey string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.nodesInProgress, nodeKey)
+	for _, interruptionEvent := range s.interruptionEventStore {
+		if interruptionEvent.NodeKey() == nodeKey && !interruptionEvent.NodeProcessed {
+			interruptionEvent.InProgress = false
+		}
+	}
+	if zone, ok := s.nodeZones[nodeKey]; ok {
+		delete(s.nodeZones, nodeKey)
+		s.zonesInProgress[zone]--
+		if s.zonesInProgress[zone] <= 0 {
+			delete(s.zonesInProgress, zone)
+		}
+	}
+}
+
+// MergeableEvents returns the other drainable events for the interruption event's node, which are satisfied by handling it
+func (s *Store) MergeableEvents(interruptionEvent *monitor.InterruptionEvent) []*monitor.InterruptionEvent {
+	s.RLock()
+	defer s.RUnlock()
+	var events []*monitor.InterruptionEvent
+	for _, event := range s.interruptionEventStore {
+		if event != interruptionEvent && event.NodeKey() == interruptionEvent.NodeKey() && !event.InProgress && s.shouldEventDrain(event) {
+			events = append(events, event)
+		}
+	}
+	return events
 }
 
 // ShouldDrainNode returns true if there are drainable events in the internal store
@@ -102,20 +415,65 @@ func (s *Store) shouldEventDrain(interruptionEvent *monitor.InterruptionEvent) b
 
 // TimeUntilDrain returns the duration until a node drain should occur (can return a negative duration)
 func (s *Store) TimeUntilDrain(interruptionEvent *monitor.InterruptionEvent) time.Duration {
+	if !interruptionEvent.DrainTime.IsZero() {
+		return time.Until(interruptionEvent.DrainTime)
+	}
 	nodeTerminationGracePeriod := time.Duration(s.NthConfig.NodeTerminationGracePeriod) * time.Second
 	drainTime := interruptionEvent.StartTime.Add(-1 * nodeTerminationGracePeriod)
 	return time.Until(drainTime)
 }
 
-// MarkAllAsProcessed should be called after the node has been drained to prevent further unnecessary drain calls to the k8s api
-func (s *Store) MarkAllAsProcessed(nodeName string) {
+// MarkAllAsProcessed should be called after the node, identified by the node key of its events, has been drained to
+// prevent further unnecessary drain calls to the k8s api. The events which were not processed before are returned, so
+// they can be acknowledged.
+func (s *Store) MarkAllAsProcessed(nodeKey string) []*monitor.InterruptionEvent {
+	defer s.writeJournal()
 	s.Lock()
 	defer s.Unlock()
+	var eventIDs []string
+	var marked []*monitor.InterruptionEvent
 	for _, interruptionEvent := range s.interruptionEventStore {
-		if interruptionEvent.NodeName == nodeName {
+		if interruptionEvent.NodeKey() == nodeKey {
+			if !interruptionEvent.NodeProcessed {
+				marked = append(marked, interruptionEvent)
+			}
 			interruptionEvent.NodeProcessed = true
+			eventIDs = append(eventIDs, interruptionEvent.EventID)
 		}
 	}
+	s.removeFromJournal(eventIDs...)
+	return marked
+}
+
+// MarkAsProcessed should be called after handling the passed in events, leaving other events for the node to be handled
+// when they are due
+func (s *Store) MarkAsProcessed(interruptionEvents ...*monitor.InterruptionEvent) {
+	defer s.writeJournal()
+	s.Lock()
+	defer s.Unlock()
+	eventIDs := make([]string, 0, len(interruptionEvents))
+	for _, interruptionEvent := range interruptionEvents {
+		interruptionEvent.NodeProcessed = true
+		eventIDs = append(eventIDs, interruptionEvent.EventID)
+	}
+	s.removeFromJournal(eventIDs...)
+}
+
+// SetRescheduling records the outcome of verifying that the workloads drained for the event were rescheduled
+func (s *Store) SetRescheduling(interruptionEvent *monitor.InterruptionEvent, rescheduling string, unrecoveredWorkloads []string) {
+	s.Lock()
+	defer s.Unlock()
+	interruptionEvent.Rescheduling = rescheduling
+	interruptionEvent.UnrecoveredWorkloads = unrecoveredWorkloads
+}
+
+// UpdateEvent applies update to the stored event while holding the lock, so the event can be changed while it is read
+// through Snapshot, e.g. by the status API. Maps of the event are shared with earlier snapshots, so update must replace
+// them rather than change them in place.
+func (s *Store) UpdateEvent(interruptionEvent *monitor.InterruptionEvent, update func(*monitor.InterruptionEvent)) {
+	s.Lock()
+	defer s.Unlock()
+	update(interruptionEvent)
 }
 
 // IgnoreEvent will store an event ID so that monitor loops cannot write to the store with the same event ID
@@ -124,13 +482,46 @@ func (s *Store) IgnoreEvent(eventID string) {
 	if eventID == "" {
 		return
 	}
+	defer s.writeJournal()
 	s.Lock()
 	defer s.Unlock()
 	s.ignoredEvents[eventID] = struct{}{}
+	s.removeFromJournal(eventID)
+}
+
+// removeFromJournal records the removal of the handled events from the journal, the caller must hold the lock and
+// call writeJournal once it released it
+func (s *Store) removeFromJournal(eventIDs ...string) {
+	if s.journal == nil || len(eventIDs) == 0 {
+		return
+	}
+	s.journalEntries = append(s.journalEntries, journalEntry{remove: eventIDs})
+}
+
+// writeJournal writes the recorded journal entries to the journal, the caller must not hold the lock
+func (s *Store) writeJournal() {
+	s.journalMutex.Lock()
+	defer s.journalMutex.Unlock()
+	s.Lock()
+	journal, entries := s.journal, s.journalEntries
+	s.journalEntries = nil
+	s.Unlock()
+	for _, entry := range entries {
+		if entry.add != nil {
+			if err := journal.Add(*entry.add); err != nil {
+				log.Warn().Err(err).Str("event_id", entry.add.EventID).Msg("Unable to record the event in the event journal")
+			}
+			continue
+		}
+		if err := journal.Remove(entry.remove...); err != nil {
+			log.Warn().Err(err).Strs("event_ids", entry.remove).Msg("Unable to remove the events from the event journal")
+		}
+	}
 }
 
-// ShouldUncordonNode returns true if there was a interruption event but it was canceled and the store is now empty or only consists of ignored events
-func (s *Store) ShouldUncordonNode(nodeName string) bool {
+// ShouldUncordonNode returns true if there was a interruption event but it was canceled and the store is now empty or
+// only consists of ignored events for the node, identified by the node key of its events
+func (s *Store) ShouldUncordonNode(nodeKey string) bool {
 	s.RLock()
 	defer s.RUnlock()
 	if !s.atLeastOneEvent {
@@ -141,7 +532,7 @@ func (s *Store) ShouldUncordonNode(nodeName string) bool {
 	}
 
 	for _, interruptionEvent := range s.interruptionEventStore {
-		if _, ignored := s.ignoredEvents[interruptionEvent.EventID]; !ignored && interruptionEvent.NodeName == nodeName {
+		if _, ignored := s.ignoredEvents[interruptionEvent.EventID]; !ignored && interruptionEvent.NodeKey() == nodeKey {
 			return false
 		}
 	}
@@ -0,0 +1,41 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License
+
+package interruptioneventstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestRetryBackoffDoubles(t *testing.T) {
+	store := New(config.Config{DrainMaxAttempts: 10})
+	event := &monitor.InterruptionEvent{EventID: "123", NodeName: "node", StartTime: time.Now().Add(-time.Minute)}
+	store.AddInterruptionEvent(event)
+
+	for _, delay := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second, retryMaxDelay, retryMaxDelay} {
+		before := time.Now()
+		h.Equals(t, true, store.RecordFailedAttempt(event, false))
+		retryIn := store.retryAt[event.EventID].Sub(before)
+		h.Assert(t, retryIn >= delay && retryIn < delay+time.Second, "Expected a backoff of %s, got %s", delay, retryIn)
+	}
+
+	store.retryAt[event.EventID] = time.Now().Add(-time.Second)
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "123", activeEvent.EventID)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/aws/aws-node-termination-handler/pkg/config"
 	"github.com/aws/aws-node-termination-handler/pkg/interruptioneventstore"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
 	h "github.com/aws/aws-node-termination-handler/pkg/test"
 )
 
@@ -99,6 +100,22 @@ func TestShouldDrainNode(t *testing.T) {
 	h.Equals(t, true, store.ShouldDrainNode())
 }
 
+func TestShouldDrainNodeDrainTime(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	event := &monitor.InterruptionEvent{
+		EventID:   "scheduled",
+		StartTime: time.Now().Add(time.Hour * 24 * 7),
+		DrainTime: time.Now().Add(time.Second * 20),
+		NodeName:  node1,
+	}
+	store.AddInterruptionEvent(event)
+	h.Equals(t, true, store.HasEvent(event.EventID))
+	h.Equals(t, false, store.ShouldDrainNode())
+
+	event.DrainTime = time.Now()
+	h.Equals(t, true, store.ShouldDrainNode())
+}
+
 func TestMarkAllAsProcessed(t *testing.T) {
 	store := interruptioneventstore.New(config.Config{})
 	event1 := &monitor.InterruptionEvent{
@@ -124,6 +141,228 @@ func TestMarkAllAsProcessed(t *testing.T) {
 	h.Equals(t, false, isActive)
 }
 
+func TestGetActiveEventMostUrgent(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	rebalance := &monitor.InterruptionEvent{
+		EventID:   "rebalance-recommendation-1",
+		StartTime: time.Now().Add(-time.Minute),
+		NodeName:  node1,
+	}
+	spotITN := &monitor.InterruptionEvent{
+		EventID:   "spot-itn-1",
+		StartTime: time.Now(),
+		NodeName:  node1,
+	}
+	store.AddInterruptionEvent(rebalance)
+	store.AddInterruptionEvent(spotITN)
+
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, spotITN.EventID, activeEvent.EventID)
+
+	// the rebalance recommendation must not race the spot ITN on the same node
+	store.MarkInProgress(activeEvent)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+
+	mergedEvents := store.MergeableEvents(activeEvent)
+	h.Equals(t, 1, len(mergedEvents))
+	h.Equals(t, rebalance.EventID, mergedEvents[0].EventID)
+
+	store.MarkAsProcessed(append(mergedEvents, activeEvent)...)
+	store.ReleaseNode(node1)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+}
+
+func TestMarkAsProcessedLeavesFutureEvents(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	currentEvent := &monitor.InterruptionEvent{
+		EventID:   "current",
+		StartTime: time.Now(),
+		NodeName:  node1,
+	}
+	futureEvent := &monitor.InterruptionEvent{
+		EventID:   "future",
+		StartTime: time.Now().Add(time.Second * 20),
+		NodeName:  node1,
+	}
+	store.AddInterruptionEvent(currentEvent)
+	store.AddInterruptionEvent(futureEvent)
+
+	h.Equals(t, 0, len(store.MergeableEvents(currentEvent)))
+	store.MarkAsProcessed(currentEvent)
+	h.Equals(t, false, currentEvent.InProgress)
+	h.Equals(t, true, currentEvent.NodeProcessed)
+	h.Equals(t, false, futureEvent.NodeProcessed)
+}
+
+func TestPauseAndApproveNode(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	event := &monitor.InterruptionEvent{
+		EventID:   "spot-itn-1",
+		StartTime: time.Now(),
+		NodeName:  node1,
+	}
+	store.AddInterruptionEvent(event)
+
+	store.Pause()
+	h.Equals(t, true, store.Paused())
+	_, isActive := store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+
+	store.ApproveNode(node1)
+	h.Equals(t, []string{node1}, store.ApprovedNodes())
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, event.EventID, activeEvent.EventID)
+
+	// an approval is used up by handling the event
+	store.MarkInProgress(activeEvent)
+	h.Equals(t, 0, len(store.ApprovedNodes()))
+
+	store.Resume()
+	h.Equals(t, false, store.Paused())
+}
+
+func TestMarkAllAsProcessedReturnsNewlyProcessedEvents(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	processed := &monitor.InterruptionEvent{EventID: "processed", NodeName: node1, StartTime: time.Now()}
+	store.AddInterruptionEvent(processed)
+	store.MarkAsProcessed(processed)
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "pending", NodeName: node1, StartTime: time.Now()})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "other", NodeName: "other-node", StartTime: time.Now()})
+
+	marked := store.MarkAllAsProcessed(node1)
+	h.Equals(t, 1, len(marked))
+	h.Equals(t, "pending", marked[0].EventID)
+	h.Equals(t, 0, len(store.MarkAllAsProcessed(node1)))
+}
+
+func TestFailedEventIsRetried(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "123", NodeName: node1, StartTime: time.Now().Add(-time.Minute)})
+
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	store.MarkInProgress(activeEvent)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+
+	// the drain failed, so the event is not marked as processed
+	store.ReleaseNode(node1)
+	activeEvent, isActive = store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "123", activeEvent.EventID)
+	h.Equals(t, false, activeEvent.InProgress)
+
+	store.MarkInProgress(activeEvent)
+	store.MarkAsProcessed(activeEvent)
+	store.ReleaseNode(node1)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+}
+
+func TestFailedEventIsRetriedAfterBackoff(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{DrainMaxAttempts: 2})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "123", NodeName: node1, StartTime: time.Now().Add(-time.Minute)})
+
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	store.MarkInProgress(activeEvent)
+	h.Equals(t, true, store.RecordFailedAttempt(activeEvent, false))
+	store.ReleaseNode(node1)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+
+	h.Equals(t, false, store.RecordFailedAttempt(activeEvent, false))
+	h.Equals(t, true, activeEvent.NodeProcessed)
+}
+
+func TestFinalFailureIsNotRetried(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{DrainMaxAttempts: 5})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "123", NodeName: node1, StartTime: time.Now().Add(-time.Minute)})
+
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	store.MarkInProgress(activeEvent)
+	h.Equals(t, false, store.RecordFailedAttempt(activeEvent, true))
+	store.ReleaseNode(node1)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+}
+
+func TestDrainsArePacedPerZone(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{MaxDrainsPerAZ: 1})
+	now := time.Now().Add(-time.Minute)
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "a1", NodeName: "node-a1", AvailabilityZone: "us-east-1a", StartTime: now})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "a2", NodeName: "node-a2", AvailabilityZone: "us-east-1a", StartTime: now.Add(time.Second)})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "b1", NodeName: "node-b1", AvailabilityZone: "us-east-1b", StartTime: now.Add(2 * time.Second)})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "unknown", NodeName: "node-unknown", StartTime: now.Add(3 * time.Second)})
+
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "a1", activeEvent.EventID)
+	store.MarkInProgress(activeEvent)
+
+	// the other node of us-east-1a waits, while other zones and nodes without a zone are not held back
+	activeEvent, isActive = store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "b1", activeEvent.EventID)
+	store.MarkInProgress(activeEvent)
+	activeEvent, isActive = store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "unknown", activeEvent.EventID)
+	store.MarkInProgress(activeEvent)
+	_, isActive = store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+
+	store.MarkAllAsProcessed("node-a1")
+	store.ReleaseNode("node-a1")
+	activeEvent, isActive = store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "a2", activeEvent.EventID)
+}
+
+func TestNodesOfClustersAreKeptApart(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	now := time.Now().Add(-time.Minute)
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "prod", NodeName: node1, Cluster: "prod", StartTime: now})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "dev", NodeName: node1, Cluster: "dev", StartTime: now.Add(time.Second)})
+
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "prod", activeEvent.EventID)
+	store.MarkInProgress(activeEvent)
+	h.Equals(t, 0, len(store.MergeableEvents(activeEvent)))
+
+	// the node of the same name in the other cluster is drained independently
+	activeEvent, isActive = store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "dev", activeEvent.EventID)
+	store.MarkInProgress(activeEvent)
+
+	marked := store.MarkAllAsProcessed("prod/" + node1)
+	h.Equals(t, 1, len(marked))
+	h.Equals(t, "prod", marked[0].EventID)
+	store.ReleaseNode("prod/" + node1)
+	h.Equals(t, false, store.ShouldUncordonNode("prod/"+node1))
+	h.Equals(t, 1, len(store.MarkAllAsProcessed("dev/"+node1)))
+}
+
+func TestDrainsAreNotPacedByDefault(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	now := time.Now().Add(-time.Minute)
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "a1", NodeName: "node-a1", AvailabilityZone: "us-east-1a", StartTime: now})
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "a2", NodeName: "node-a2", AvailabilityZone: "us-east-1a", StartTime: now.Add(time.Second)})
+
+	activeEvent, _ := store.GetActiveEvent()
+	store.MarkInProgress(activeEvent)
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "a2", activeEvent.EventID)
+}
+
 func TestShouldUncordonNode(t *testing.T) {
 	eventID := "123"
 	store := interruptioneventstore.New(config.Config{})
@@ -160,6 +399,127 @@ func TestIgnoreEvent(t *testing.T) {
 	h.Equals(t, false, store.ShouldDrainNode())
 }
 
+type fakeJournal struct {
+	events map[string]monitor.InterruptionEvent
+}
+
+func (j *fakeJournal) Add(interruptionEvent monitor.InterruptionEvent) error {
+	j.events[interruptionEvent.EventID] = interruptionEvent
+	return nil
+}
+
+func (j *fakeJournal) Remove(eventIDs ...string) error {
+	for _, eventID := range eventIDs {
+		delete(j.events, eventID)
+	}
+	return nil
+}
+
+func TestJournalRecordsUnfinishedEvents(t *testing.T) {
+	journal := &fakeJournal{events: map[string]monitor.InterruptionEvent{}}
+	store := interruptioneventstore.New(config.Config{})
+	store.SetJournal(journal)
+
+	processed := &monitor.InterruptionEvent{EventID: "processed", StartTime: time.Now(), NodeName: node1}
+	canceled := &monitor.InterruptionEvent{EventID: "canceled", StartTime: time.Now(), NodeName: "test-node-2"}
+	ignored := &monitor.InterruptionEvent{EventID: "ignored", StartTime: time.Now(), NodeName: "test-node-3"}
+	pending := &monitor.InterruptionEvent{EventID: "pending", StartTime: time.Now().Add(time.Hour), NodeName: "test-node-4"}
+	for _, event := range []*monitor.InterruptionEvent{processed, canceled, ignored, pending} {
+		store.AddInterruptionEvent(event)
+	}
+	h.Equals(t, 4, len(journal.events))
+
+	store.MarkAsProcessed(processed)
+	store.CancelInterruptionEvent(canceled.EventID)
+	store.IgnoreEvent(ignored.EventID)
+	h.Equals(t, 1, len(journal.events))
+	_, ok := journal.events[pending.EventID]
+	h.Assert(t, ok, "Expected the pending event to stay in the journal")
+
+	store.MarkAllAsProcessed(pending.NodeName)
+	h.Equals(t, 0, len(journal.events))
+}
+
+// blockingJournal blocks adding events until it is released
+type blockingJournal struct {
+	fakeJournal
+	adding  chan struct{}
+	release chan struct{}
+}
+
+func (j *blockingJournal) Add(interruptionEvent monitor.InterruptionEvent) error {
+	j.adding <- struct{}{}
+	<-j.release
+	return j.fakeJournal.Add(interruptionEvent)
+}
+
+func TestJournalWrittenOutsideTheLock(t *testing.T) {
+	journal := &blockingJournal{fakeJournal: fakeJournal{events: map[string]monitor.InterruptionEvent{}}, adding: make(chan struct{}), release: make(chan struct{})}
+	store := interruptioneventstore.New(config.Config{})
+	store.SetJournal(journal)
+
+	added := make(chan struct{})
+	go func() {
+		store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "spot-itn-123", StartTime: time.Now(), NodeName: node1})
+		close(added)
+	}()
+	<-journal.adding
+	h.Equals(t, 1, len(store.Snapshot()))
+	h.Equals(t, true, store.HasEvent("spot-itn-123"))
+	close(journal.release)
+	<-added
+	h.Equals(t, 1, len(journal.events))
+}
+
+func TestRestoredEventWaitsForItsMonitor(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	store.Restore([]monitor.InterruptionEvent{{EventID: "spot-itn-123", StartTime: time.Now(), NodeName: node1}})
+	h.Equals(t, true, store.HasEvent("spot-itn-123"))
+	_, isActive := store.GetActiveEvent()
+	h.Equals(t, false, isActive)
+
+	preDrainTask := func(monitor.InterruptionEvent, node.Node) error { return nil }
+	store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: "spot-itn-123", StartTime: time.Now(), NodeName: node1, PreDrainTask: preDrainTask})
+	storedEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, "spot-itn-123", storedEvent.EventID)
+	h.Assert(t, storedEvent.PreDrainTask != nil, "Expected the drain task of the reported event to be attached to the restored event")
+}
+
+func TestRestoreSkipsIgnoredEvents(t *testing.T) {
+	journal := &fakeJournal{events: map[string]monitor.InterruptionEvent{"rebooted": {EventID: "rebooted"}}}
+	store := interruptioneventstore.New(config.Config{})
+	store.SetJournal(journal)
+	store.IgnoreEvent("rebooted")
+	store.Restore([]monitor.InterruptionEvent{{EventID: "rebooted", StartTime: time.Now(), NodeName: node1}})
+	h.Equals(t, false, store.HasEvent("rebooted"))
+	h.Equals(t, 0, len(journal.events))
+}
+
+func TestUpdateEventWhileTakingSnapshots(t *testing.T) {
+	store := interruptioneventstore.New(config.Config{})
+	interruptionEvent := &monitor.InterruptionEvent{EventID: "123", NodeName: node1, StartTime: time.Now()}
+	store.AddInterruptionEvent(interruptionEvent)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			store.UpdateEvent(interruptionEvent, func(e *monitor.InterruptionEvent) {
+				e.Pods = []string{strconv.Itoa(i)}
+				e.HookOutcomes = map[string]string{"pre-drain": strconv.Itoa(i)}
+			})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		for _, snapshot := range store.Snapshot() {
+			_ = len(snapshot.Pods) + len(snapshot.HookOutcomes["pre-drain"])
+		}
+	}
+	<-done
+	h.Equals(t, []string{"99"}, store.Snapshot()[0].Pods)
+}
+
 // BenchmarkDrainEventStore tests concurrent read/write patterns. We don't really care about the timings as long as deadlock doesn't occur
 func BenchmarkDrainEventStore(b *testing.B) {
 	// too many logs can break the Travis build, so we'll disable logging for this test
@@ -181,3 +541,58 @@ func BenchmarkDrainEventStore(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkAddInterruptionEvent measures adding events to stores of growing size, both for events which are already in
+// the store, which is the dedup path taken when monitors report the same event on every poll, and for new events
+func BenchmarkAddInterruptionEvent(b *testing.B) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	for _, size := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("Duplicate/%d-events", size), func(b *testing.B) {
+			store := newBenchmarkStore(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: strconv.Itoa(i % size)})
+			}
+		})
+		b.Run(fmt.Sprintf("New/%d-events", size), func(b *testing.B) {
+			store := newBenchmarkStore(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: strconv.Itoa(size + i), StartTime: time.Now()})
+			}
+		})
+	}
+}
+
+// BenchmarkGetActiveEvent measures picking the most urgent event from stores of growing size
+func BenchmarkGetActiveEvent(b *testing.B) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	for _, size := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("%d-events", size), func(b *testing.B) {
+			store := newBenchmarkStore(size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, ok := store.GetActiveEvent()
+				h.Assert(b, ok, "Expected an active event")
+			}
+		})
+	}
+}
+
+// newBenchmarkStore returns a store with drainable events for distinct nodes
+func newBenchmarkStore(size int) *interruptioneventstore.Store {
+	store := interruptioneventstore.New(config.Config{})
+	startTime := time.Now().Add(-time.Minute)
+	for i := 0; i < size; i++ {
+		store.AddInterruptionEvent(&monitor.InterruptionEvent{
+			EventID:   strconv.Itoa(i),
+			NodeName:  fmt.Sprintf("node-%d", i),
+			StartTime: startTime.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	return store
+}
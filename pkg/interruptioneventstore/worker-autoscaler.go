@@ -0,0 +1,78 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package interruptioneventstore
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WorkerAutoscaler grows the workers of the store with the backlog of events and the drains in progress, and shrinks
+// them gradually once the backlog is handled, between the configured min-workers and workers
+type WorkerAutoscaler struct {
+	store *Store
+	// backlog returns the number of events waiting to be received, e.g. the visible messages of the queue
+	backlog    func() (int, error)
+	minWorkers int
+	maxWorkers int
+}
+
+// NewWorkerAutoscaler creates an autoscaler of the store's workers
+func NewWorkerAutoscaler(store *Store, backlog func() (int, error)) WorkerAutoscaler {
+	return WorkerAutoscaler{
+		store:      store,
+		backlog:    backlog,
+		minWorkers: store.NthConfig.MinWorkers,
+		maxWorkers: store.NthConfig.Workers,
+	}
+}
+
+// Run scales the workers at every interval
+func (a WorkerAutoscaler) Run(interval time.Duration) {
+	for range time.NewTicker(interval).C {
+		a.Scale()
+	}
+}
+
+// Scale sets the worker limit to the workers needed for the drains in progress, the events waiting for a worker and the
+// backlog. The limit grows to the needed workers at once, so interruption storms are handled in parallel, and shrinks by
+// half of the difference, so a backlog received in bursts doesn't make it flap.
+func (a WorkerAutoscaler) Scale() {
+	current := a.store.WorkerLimit()
+	needed := a.store.BusyWorkers() + a.store.PendingEvents()
+	backlog, err := a.backlog()
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to get the backlog of events, not shrinking the workers")
+	}
+	needed += backlog
+	if needed < a.minWorkers {
+		needed = a.minWorkers
+	}
+	if needed > a.maxWorkers {
+		needed = a.maxWorkers
+	}
+	target := needed
+	if needed < current {
+		if err != nil {
+			return
+		}
+		target = needed + (current-needed)/2
+	}
+	if target == current {
+		return
+	}
+	log.Info().Int("workers", target).Int("previous_workers", current).Int("backlog", backlog).Msg("Scaling the event processors")
+	a.store.SetWorkerLimit(target)
+}
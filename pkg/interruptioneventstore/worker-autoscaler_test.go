@@ -0,0 +1,83 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package interruptioneventstore_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/interruptioneventstore"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func autoscalingStore() *interruptioneventstore.Store {
+	return interruptioneventstore.New(config.Config{EnableWorkerAutoscaling: true, MinWorkers: 2, Workers: 20})
+}
+
+func backlogOf(messages int, err error) func() (int, error) {
+	return func() (int, error) { return messages, err }
+}
+
+func TestAcquireWorkerRespectsLimit(t *testing.T) {
+	store := autoscalingStore()
+	h.Equals(t, 2, store.WorkerLimit())
+	h.Assert(t, store.AcquireWorker(), "Expected the first worker to be acquired")
+	h.Assert(t, store.AcquireWorker(), "Expected the second worker to be acquired")
+	h.Assert(t, !store.AcquireWorker(), "Expected no worker above the limit")
+
+	<-store.Workers
+	h.Assert(t, store.AcquireWorker(), "Expected a released worker to be acquired")
+
+	store.SetWorkerLimit(100)
+	h.Equals(t, 20, store.WorkerLimit())
+}
+
+func TestWorkerAutoscalerGrowsWithBacklog(t *testing.T) {
+	store := autoscalingStore()
+	for i := 0; i < 3; i++ {
+		store.AddInterruptionEvent(&monitor.InterruptionEvent{EventID: strconv.Itoa(i), NodeName: fmt.Sprintf("node-%d", i), StartTime: time.Now()})
+	}
+	h.Assert(t, store.AcquireWorker(), "Expected a worker to be acquired")
+
+	interruptioneventstore.NewWorkerAutoscaler(store, backlogOf(5, nil)).Scale()
+	h.Equals(t, 9, store.WorkerLimit())
+
+	interruptioneventstore.NewWorkerAutoscaler(store, backlogOf(500, nil)).Scale()
+	h.Equals(t, 20, store.WorkerLimit())
+}
+
+func TestWorkerAutoscalerShrinksGradually(t *testing.T) {
+	store := autoscalingStore()
+	store.SetWorkerLimit(20)
+	autoscaler := interruptioneventstore.NewWorkerAutoscaler(store, backlogOf(0, nil))
+
+	autoscaler.Scale()
+	h.Equals(t, 11, store.WorkerLimit())
+	for i := 0; i < 10; i++ {
+		autoscaler.Scale()
+	}
+	h.Equals(t, 2, store.WorkerLimit())
+}
+
+func TestWorkerAutoscalerKeepsWorkersWithoutBacklog(t *testing.T) {
+	store := autoscalingStore()
+	store.SetWorkerLimit(20)
+
+	interruptioneventstore.NewWorkerAutoscaler(store, backlogOf(0, fmt.Errorf("throttled"))).Scale()
+	h.Equals(t, 20, store.WorkerLimit())
+}
@@ -0,0 +1,135 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+)
+
+// Journal persists the received interruption events which are not handled yet to a small file, e.g. on a hostPath or
+// emptyDir volume, so a handler which crashes after an event was received but before the node was drained handles the
+// event again when it restarts. The file is replaced on every change, so it is never left partially written.
+type Journal struct {
+	mutex  sync.Mutex
+	path   string
+	events map[string]monitor.InterruptionEvent
+}
+
+// New creates an empty journal at the path, replacing the events recorded in it
+func New(path string) *Journal {
+	return &Journal{path: path, events: map[string]monitor.InterruptionEvent{}}
+}
+
+// Open opens the journal at the path and returns the events which were not handled before the handler stopped
+func Open(path string) (*Journal, []monitor.InterruptionEvent, error) {
+	j := New(path)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to read the event journal %s: %w", path, err)
+	}
+	var events []monitor.InterruptionEvent
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, nil, fmt.Errorf("Unable to parse the event journal %s: %w", path, err)
+		}
+	}
+	unfinished := make([]monitor.InterruptionEvent, 0, len(events))
+	for _, event := range events {
+		if event.EventID == "" {
+			continue
+		}
+		// the handling of the event starts over
+		event.InProgress = false
+		event.NodeProcessed = false
+		j.events[event.EventID] = event
+		unfinished = append(unfinished, event)
+	}
+	return j, unfinished, nil
+}
+
+// Add records the event, until it is removed once handled
+func (j *Journal) Add(event monitor.InterruptionEvent) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.events[event.EventID] = event
+	return j.write()
+}
+
+// Remove removes the events, which were handled or canceled
+func (j *Journal) Remove(eventIDs ...string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	removed := false
+	for _, eventID := range eventIDs {
+		if _, ok := j.events[eventID]; ok {
+			delete(j.events, eventID)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return j.write()
+}
+
+// Len returns the number of events in the journal
+func (j *Journal) Len() int {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return len(j.events)
+}
+
+// write replaces the journal file with the events, ordered by start time
+func (j *Journal) write() error {
+	events := make([]monitor.InterruptionEvent, 0, len(j.events))
+	for _, event := range j.events {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, k int) bool { return events[i].StartTime.Before(events[k].StartTime) })
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("Unable to encode the event journal: %w", err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(j.path), filepath.Base(j.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Unable to write the event journal: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Unable to write the event journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Unable to write the event journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Unable to write the event journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("Unable to replace the event journal %s: %w", j.path, err)
+	}
+	return nil
+}
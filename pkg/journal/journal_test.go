@@ -0,0 +1,72 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package journal_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/journal"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestOpenMissingJournal(t *testing.T) {
+	j, events, err := journal.Open(filepath.Join(t.TempDir(), "events.json"))
+	h.Ok(t, err)
+	h.Equals(t, 0, len(events))
+	h.Equals(t, 0, j.Len())
+}
+
+func TestJournalRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.json")
+	j, _, err := journal.Open(path)
+	h.Ok(t, err)
+
+	start := time.Now().Truncate(time.Second).UTC()
+	h.Ok(t, j.Add(monitor.InterruptionEvent{EventID: "spot-itn-1", Kind: "SPOT_ITN", NodeName: "node-1", StartTime: start, InProgress: true}))
+	h.Ok(t, j.Add(monitor.InterruptionEvent{EventID: "scheduled-1", Kind: "SCHEDULED_EVENT", NodeName: "node-1", StartTime: start.Add(time.Hour), DrainTime: start.Add(30 * time.Minute)}))
+	h.Ok(t, j.Add(monitor.InterruptionEvent{EventID: "rebalance-1", Kind: "REBALANCE_RECOMMENDATION", NodeName: "node-1", StartTime: start}))
+	h.Ok(t, j.Remove("rebalance-1", "unknown"))
+	h.Equals(t, 2, j.Len())
+
+	_, events, err := journal.Open(path)
+	h.Ok(t, err)
+	h.Equals(t, 2, len(events))
+	h.Equals(t, "spot-itn-1", events[0].EventID)
+	h.Equals(t, "SPOT_ITN", events[0].Kind)
+	h.Equals(t, false, events[0].InProgress)
+	h.Equals(t, "scheduled-1", events[1].EventID)
+	h.Equals(t, start.Add(30*time.Minute), events[1].DrainTime.UTC())
+
+	files, err := ioutil.ReadDir(dir)
+	h.Ok(t, err)
+	h.Equals(t, 1, len(files))
+}
+
+func TestOpenCorruptJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.json")
+	h.Ok(t, ioutil.WriteFile(path, []byte("{not json"), 0600))
+	_, _, err := journal.Open(path)
+	h.Nok(t, err)
+
+	j := journal.New(path)
+	h.Ok(t, j.Add(monitor.InterruptionEvent{EventID: "spot-itn-1", NodeName: "node-1"}))
+	_, events, err := journal.Open(path)
+	h.Ok(t, err)
+	h.Equals(t, 1, len(events))
+}
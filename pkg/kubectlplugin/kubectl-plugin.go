@@ -0,0 +1,291 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package kubectlplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/httpserver"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultNamespace is the namespace the handler is installed in by default
+	DefaultNamespace = "kube-system"
+	// DefaultSelector selects the pods of the handler installed by the Helm chart
+	DefaultSelector = "app.kubernetes.io/name=aws-node-termination-handler"
+	// DefaultPort is the default port of the status API
+	DefaultPort = 8090
+	// TokenSecretKey is the key of the bearer token in the secret of the Helm chart's metricsBearerTokenSecretName
+	TokenSecretKey = "token"
+	// Usage describes the commands of the plugin
+	Usage = `Usage: kubectl nth [flags] <command>
+
+Commands:
+  status            Show whether the handlers are paused and how many events they hold
+  events            List the live events and the history of handled events
+  pause             Hold back handling events which are not in progress yet
+  resume            Handle events again
+  approve <node>    Handle the next event of the node while paused
+  simulate <node>   Create a simulated interruption event for the node
+`
+)
+
+// Proxy sends requests to the status API of a handler pod
+type Proxy interface {
+	Do(ctx context.Context, pod corev1.Pod, method string, path string) ([]byte, error)
+}
+
+// APIServerProxy sends requests to handler pods through the pod proxy of the Kubernetes API server
+type APIServerProxy struct {
+	Clientset kubernetes.Interface
+	Port      int
+	// HTTPS reaches status APIs served over HTTPS
+	HTTPS bool
+	// Token is sent as bearer token of the status API if set
+	Token string
+}
+
+// Do sends the request to the pod
+func (p APIServerProxy) Do(ctx context.Context, pod corev1.Pod, method string, path string) ([]byte, error) {
+	request := p.Clientset.CoreV1().RESTClient().Verb(method).
+		Namespace(pod.Namespace).
+		Resource("pods").
+		SubResource("proxy").
+		Name(p.target(pod)).
+		Suffix(path)
+	if p.Token != "" {
+		// the API server doesn't pass the Authorization header on to the pod
+		request = request.SetHeader(httpserver.TokenHeader, p.Token)
+	}
+	return request.DoRaw(ctx)
+}
+
+// SecretToken returns the bearer token of the status API held in the secret
+func SecretToken(ctx context.Context, clientset kubernetes.Interface, namespace string, name string) (string, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("Unable to get the bearer token secret %s: %w", name, err)
+	}
+	token, ok := secret.Data[TokenSecretKey]
+	if !ok {
+		return "", fmt.Errorf("the bearer token secret %s has no %s key", name, TokenSecretKey)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// target is the pod proxy name of the status API of the pod, e.g. https:pod:8090
+func (p APIServerProxy) target(pod corev1.Pod) string {
+	target := pod.Name + ":" + strconv.Itoa(p.Port)
+	if p.HTTPS {
+		target = "https:" + target
+	}
+	return target
+}
+
+// Plugin runs the commands of the kubectl nth plugin against the handler pods
+type Plugin struct {
+	Clientset kubernetes.Interface
+	Proxy     Proxy
+	Namespace string
+	Selector  string
+	Out       io.Writer
+}
+
+// Run runs the command with its arguments
+func (p Plugin) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a command is required\n\n%s", Usage)
+	}
+	command, args := args[0], args[1:]
+	expectedArgs := 0
+	if command == "approve" || command == "simulate" {
+		expectedArgs = 1
+	}
+	if len(args) != expectedArgs {
+		return fmt.Errorf("%s expects %d argument(s)\n\n%s", command, expectedArgs, Usage)
+	}
+	switch command {
+	case "status":
+		return p.status(ctx)
+	case "events":
+		return p.events(ctx)
+	case "pause", "resume":
+		return p.control(ctx, "", command)
+	case "approve":
+		return p.control(ctx, args[0], command)
+	case "simulate":
+		return p.simulate(ctx, args[0])
+	default:
+		return fmt.Errorf("unknown command %s\n\n%s", command, Usage)
+	}
+}
+
+func (p Plugin) pods(ctx context.Context) ([]corev1.Pod, error) {
+	podList, err := p.Clientset.CoreV1().Pods(p.Namespace).List(ctx, metav1.ListOptions{LabelSelector: p.Selector})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list the handler pods: %w", err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no running handler pods found in namespace %s with selector %s", p.Namespace, p.Selector)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// podsFor returns the handler pods which handle the node: the pod running on it in IMDS mode, otherwise all pods
+func (p Plugin) podsFor(ctx context.Context, nodeName string) ([]corev1.Pod, error) {
+	pods, err := p.pods(ctx)
+	if err != nil || nodeName == "" {
+		return pods, err
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == nodeName {
+			return []corev1.Pod{pod}, nil
+		}
+	}
+	return pods, nil
+}
+
+func (p Plugin) get(ctx context.Context, pod corev1.Pod, path string, response interface{}) error {
+	body, err := p.Proxy.Do(ctx, pod, "GET", path)
+	if err != nil {
+		return fmt.Errorf("pod %s: %w", pod.Name, err)
+	}
+	return json.Unmarshal(body, response)
+}
+
+func (p Plugin) status(ctx context.Context) error {
+	pods, err := p.pods(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(p.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tMODE\tNODE\tPAUSED\tAPPROVED\tPENDING\tDRAINING\tPROCESSED")
+	var errs []string
+	for _, pod := range pods {
+		current := status.Status{}
+		if err := p.get(ctx, pod, "/api/status", &current); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%d\t%d\t%d\n", pod.Name, current.Mode, orNone(current.NodeName), current.Paused,
+			orNone(strings.Join(current.ApprovedNodes, ",")), current.Pending, current.Draining, current.Processed)
+	}
+	return flush(w, errs)
+}
+
+func (p Plugin) events(ctx context.Context) error {
+	pods, err := p.pods(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(p.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "POD\tEVENT\tKIND\tNODE\tSTATE\tSTART\tERROR")
+	var errs []string
+	for _, pod := range pods {
+		events := status.Events{}
+		if err := p.get(ctx, pod, "/api/events", &events); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		for _, event := range events.Active {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pod.Name, event.EventID, event.Kind, event.NodeName, event.State,
+				event.StartTime.Format(time.RFC3339), orNone(""))
+		}
+		for _, record := range events.History {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", pod.Name, record.EventID, record.Kind, record.NodeName, record.Phase,
+				record.StartedAt.Format(time.RFC3339), orNone(record.Error))
+		}
+	}
+	return flush(w, errs)
+}
+
+// control sends the control command to the handlers, approvals only to the handlers of the node
+func (p Plugin) control(ctx context.Context, nodeName string, command string) error {
+	pods, err := p.podsFor(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	path := "/api/" + command
+	if nodeName != "" {
+		path = "/api/nodes/" + nodeName + "/" + command
+	}
+	var errs []string
+	for _, pod := range pods {
+		if _, err := p.Proxy.Do(ctx, pod, "POST", path); err != nil {
+			errs = append(errs, fmt.Sprintf("pod %s: %v", pod.Name, err))
+			continue
+		}
+		fmt.Fprintf(p.Out, "%s: %s\n", pod.Name, command)
+	}
+	return joinErrors(errs)
+}
+
+func (p Plugin) simulate(ctx context.Context, nodeName string) error {
+	pods, err := p.podsFor(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	// a single handler must hold the event, otherwise every replica would handle the node
+	pod := pods[0]
+	body, err := p.Proxy.Do(ctx, pod, "POST", "/api/nodes/"+nodeName+"/simulate")
+	if err != nil {
+		return fmt.Errorf("pod %s: %w", pod.Name, err)
+	}
+	event := status.ActiveEvent{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return err
+	}
+	fmt.Fprintf(p.Out, "%s: created event %s for node %s\n", pod.Name, event.EventID, event.NodeName)
+	return nil
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+func flush(w *tabwriter.Writer, errs []string) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return joinErrors(errs)
+}
+
+func joinErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errs, "\n"))
+}
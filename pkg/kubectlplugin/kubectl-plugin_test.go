@@ -0,0 +1,136 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package kubectlplugin_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/kubectlplugin"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type request struct {
+	pod    string
+	method string
+	path   string
+}
+
+type fakeProxy struct {
+	requests  []request
+	responses map[string]interface{}
+}
+
+func (p *fakeProxy) Do(ctx context.Context, pod corev1.Pod, method string, path string) ([]byte, error) {
+	p.requests = append(p.requests, request{pod: pod.Name, method: method, path: path})
+	response, ok := p.responses[path]
+	if !ok {
+		return nil, fmt.Errorf("not found")
+	}
+	return json.Marshal(response)
+}
+
+func handlerPod(name string, nodeName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: kubectlplugin.DefaultNamespace,
+			Labels:    map[string]string{"app.kubernetes.io/name": "aws-node-termination-handler"},
+		},
+		Spec:   corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func newPlugin(proxy *fakeProxy, out *bytes.Buffer) kubectlplugin.Plugin {
+	return kubectlplugin.Plugin{
+		Clientset: fake.NewSimpleClientset(handlerPod("nth-a", "node-a"), handlerPod("nth-b", "node-b")),
+		Proxy:     proxy,
+		Namespace: kubectlplugin.DefaultNamespace,
+		Selector:  kubectlplugin.DefaultSelector,
+		Out:       out,
+	}
+}
+
+func TestStatus(t *testing.T) {
+	proxy := &fakeProxy{responses: map[string]interface{}{
+		"/api/status": status.Status{Mode: status.ModeIMDS, NodeName: "node-a", Paused: true, Pending: 1},
+	}}
+	out := &bytes.Buffer{}
+	h.Ok(t, newPlugin(proxy, out).Run(context.Background(), []string{"status"}))
+	h.Equals(t, 2, len(proxy.requests))
+	h.Assert(t, strings.Contains(out.String(), "nth-a"), "Expected the status of nth-a to be printed")
+	h.Assert(t, strings.Contains(out.String(), "true"), "Expected the paused status to be printed")
+}
+
+func TestPauseAllPods(t *testing.T) {
+	proxy := &fakeProxy{responses: map[string]interface{}{"/api/pause": status.Status{Paused: true}}}
+	out := &bytes.Buffer{}
+	h.Ok(t, newPlugin(proxy, out).Run(context.Background(), []string{"pause"}))
+	h.Equals(t, []request{{"nth-a", "POST", "/api/pause"}, {"nth-b", "POST", "/api/pause"}}, proxy.requests)
+}
+
+func TestApproveTargetsPodOnNode(t *testing.T) {
+	proxy := &fakeProxy{responses: map[string]interface{}{"/api/nodes/node-b/approve": status.Status{}}}
+	out := &bytes.Buffer{}
+	h.Ok(t, newPlugin(proxy, out).Run(context.Background(), []string{"approve", "node-b"}))
+	h.Equals(t, []request{{"nth-b", "POST", "/api/nodes/node-b/approve"}}, proxy.requests)
+}
+
+func TestSimulateQueueMode(t *testing.T) {
+	proxy := &fakeProxy{responses: map[string]interface{}{
+		"/api/nodes/node-c/simulate": status.ActiveEvent{EventID: "simulated-node-c-1", NodeName: "node-c"},
+	}}
+	out := &bytes.Buffer{}
+	h.Ok(t, newPlugin(proxy, out).Run(context.Background(), []string{"simulate", "node-c"}))
+	// no handler runs on the node, so only one of the queue processors gets the event
+	h.Equals(t, []request{{"nth-a", "POST", "/api/nodes/node-c/simulate"}}, proxy.requests)
+	h.Assert(t, strings.Contains(out.String(), "simulated-node-c-1"), "Expected the simulated event ID to be printed")
+}
+
+func TestInvalidCommands(t *testing.T) {
+	plugin := newPlugin(&fakeProxy{}, &bytes.Buffer{})
+	h.Assert(t, plugin.Run(context.Background(), nil) != nil, "Expected an error without a command")
+	h.Assert(t, plugin.Run(context.Background(), []string{"approve"}) != nil, "Expected an error without a node")
+	h.Assert(t, plugin.Run(context.Background(), []string{"unknown"}) != nil, "Expected an error for an unknown command")
+}
+
+func TestStatusUnreachable(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := newPlugin(&fakeProxy{}, out).Run(context.Background(), []string{"status"})
+	h.Assert(t, err != nil, "Expected an error when the status API is unreachable")
+	h.Assert(t, strings.Contains(err.Error(), "nth-b"), "Expected the unreachable pods to be reported")
+}
+
+func TestSecretToken(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "nth-token", Namespace: kubectlplugin.DefaultNamespace},
+		Data:       map[string][]byte{kubectlplugin.TokenSecretKey: []byte("secret\n")},
+	}
+	clientset := fake.NewSimpleClientset(secret)
+	token, err := kubectlplugin.SecretToken(context.Background(), clientset, kubectlplugin.DefaultNamespace, "nth-token")
+	h.Ok(t, err)
+	h.Equals(t, "secret", token)
+
+	_, err = kubectlplugin.SecretToken(context.Background(), clientset, kubectlplugin.DefaultNamespace, "missing")
+	h.Assert(t, err != nil, "Expected an error when the secret is missing")
+}
@@ -0,0 +1,87 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package logging
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// sampleInterval is the period of time in nanoseconds every sampler lets through one message in, 0 lets through all
+var sampleInterval int64
+
+// SetSampleInterval sets the period of time every sampler lets through one message in, 0 lets through every message
+func SetSampleInterval(interval time.Duration) {
+	atomic.StoreInt64(&sampleInterval, int64(interval))
+}
+
+// Sampler limits a high-frequency, low-value log message, like the ones logged on every poll, to one every sample
+// interval, so debug logging on large fleets doesn't overwhelm the logging pipeline. The messages it drops are counted
+// in the sampled_out field of the next message it lets through. Messages of state changes are not sampled.
+type Sampler struct {
+	mutex   sync.Mutex
+	last    time.Time
+	dropped int
+	now     func() time.Time
+}
+
+// Debug starts a debug message which is dropped if the sampler let through one within the sample interval
+func (s *Sampler) Debug() *zerolog.Event {
+	return s.sample(log.Debug())
+}
+
+// Info starts an info message which is dropped if the sampler let through one within the sample interval
+func (s *Sampler) Info() *zerolog.Event {
+	return s.sample(log.Info())
+}
+
+// Warn starts a warning message which is dropped if the sampler let through one within the sample interval
+func (s *Sampler) Warn() *zerolog.Event {
+	return s.sample(log.Warn())
+}
+
+// Reset lets the next message through, e.g. after the state the message reports changed
+func (s *Sampler) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.last = time.Time{}
+}
+
+func (s *Sampler) sample(event *zerolog.Event) *zerolog.Event {
+	// messages below the log level are not counted as dropped
+	if event == nil {
+		return nil
+	}
+	interval := time.Duration(atomic.LoadInt64(&sampleInterval))
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	if interval > 0 && !s.last.IsZero() && now.Sub(s.last) < interval {
+		s.dropped++
+		return event.Discard()
+	}
+	s.last = now
+	if s.dropped > 0 {
+		event = event.Int("sampled_out", s.dropped)
+		s.dropped = 0
+	}
+	return event
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func captureLogs(t *testing.T) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	logger := log.Logger
+	level := zerolog.GlobalLevel()
+	log.Logger = zerolog.New(out)
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	t.Cleanup(func() {
+		log.Logger = logger
+		zerolog.SetGlobalLevel(level)
+		SetSampleInterval(0)
+	})
+	return out
+}
+
+func lines(out *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func TestSamplerDropsWithinInterval(t *testing.T) {
+	out := captureLogs(t)
+	SetSampleInterval(time.Minute)
+	now := time.Now()
+	sampler := Sampler{now: func() time.Time { return now }}
+
+	for i := 0; i < 5; i++ {
+		sampler.Debug().Msg("Checking for queue messages")
+	}
+	h.Equals(t, 1, len(lines(out)))
+
+	now = now.Add(time.Minute)
+	sampler.Debug().Msg("Checking for queue messages")
+	logged := lines(out)
+	h.Equals(t, 2, len(logged))
+	h.Assert(t, strings.Contains(logged[1], `"sampled_out":4`), "Expected the dropped messages to be counted: %s", logged[1])
+
+	now = now.Add(time.Minute)
+	sampler.Debug().Msg("Checking for queue messages")
+	logged = lines(out)
+	h.Equals(t, 3, len(logged))
+	h.Assert(t, !strings.Contains(logged[2], "sampled_out"), "Expected no dropped messages: %s", logged[2])
+}
+
+func TestSamplerReset(t *testing.T) {
+	out := captureLogs(t)
+	SetSampleInterval(time.Minute)
+	sampler := Sampler{}
+
+	sampler.Warn().Msg("first error")
+	sampler.Warn().Msg("first error")
+	sampler.Reset()
+	sampler.Warn().Msg("second error")
+	logged := lines(out)
+	h.Equals(t, 2, len(logged))
+	h.Assert(t, strings.Contains(logged[1], "second error"), "Expected the message after the reset: %s", logged[1])
+	h.Assert(t, strings.Contains(logged[1], `"sampled_out":1`), "Expected the dropped message to be counted: %s", logged[1])
+}
+
+func TestSamplerDisabled(t *testing.T) {
+	out := captureLogs(t)
+	sampler := Sampler{}
+
+	for i := 0; i < 3; i++ {
+		sampler.Info().Msg("Got token from IMDSv2")
+	}
+	h.Equals(t, 3, len(lines(out)))
+}
+
+func TestSamplerIgnoresDisabledLevels(t *testing.T) {
+	out := captureLogs(t)
+	SetSampleInterval(time.Minute)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	sampler := Sampler{}
+
+	sampler.Debug().Msg("below the log level")
+	sampler.Info().Msg("logged")
+	logged := lines(out)
+	h.Equals(t, 1, len(logged))
+	h.Assert(t, !strings.Contains(logged[0], "sampled_out"), "Expected messages below the log level not to be counted: %s", logged[0])
+}
@@ -0,0 +1,161 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pluginevent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+)
+
+const (
+	// PluginEventKind is a const to define a monitor plugin kind of interruption event
+	PluginEventKind = "PLUGIN_EVENT"
+	// PluginEventStateActive is the state of a plugin event which should be handled
+	PluginEventStateActive = "active"
+	// PluginEventStateCanceled is the state of a plugin event which no longer applies
+	PluginEventStateCanceled = "canceled"
+	eventIDPrefix            = "plugin-"
+	nodeNameEnv              = "NODE_NAME"
+)
+
+// PluginEvent is the JSON schema of an interruption event written to stdout by a monitor plugin
+type PluginEvent struct {
+	// EventID uniquely identifies the event. Reporting the same ID again does not handle the event twice.
+	EventID string `json:"eventId"`
+	// Code is matched by action mappings, e.g. a custom health check name
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+	// State is either active (the default) or canceled
+	State string `json:"state,omitempty"`
+	// NodeName defaults to the node the handler runs on
+	NodeName   string `json:"nodeName,omitempty"`
+	InstanceID string `json:"instanceId,omitempty"`
+	// StartTime is the RFC3339 time the node is interrupted, the node is drained before it. Defaults to now.
+	StartTime string `json:"startTime,omitempty"`
+}
+
+// ExecPluginMonitor is a struct definition which runs an executable to check for custom interruption events
+type ExecPluginMonitor struct {
+	Command          string
+	Timeout          time.Duration
+	InterruptionChan chan<- monitor.InterruptionEvent
+	CancelChan       chan<- monitor.InterruptionEvent
+	NodeName         string
+}
+
+// NewExecPluginMonitor creates an instance of an exec plugin monitor
+func NewExecPluginMonitor(command string, timeout time.Duration, interruptionChan chan<- monitor.InterruptionEvent, cancelChan chan<- monitor.InterruptionEvent, nodeName string) ExecPluginMonitor {
+	return ExecPluginMonitor{
+		Command:          command,
+		Timeout:          timeout,
+		InterruptionChan: interruptionChan,
+		CancelChan:       cancelChan,
+		NodeName:         nodeName,
+	}
+}
+
+// Monitor runs the plugin and sends the interruption events it reports to the passed in channels
+func (m ExecPluginMonitor) Monitor() error {
+	pluginEvents, err := m.runPlugin()
+	if err != nil {
+		return err
+	}
+	for _, pluginEvent := range pluginEvents {
+		interruptionEvent, err := m.toInterruptionEvent(pluginEvent)
+		if err != nil {
+			return err
+		}
+		if pluginEvent.State == PluginEventStateCanceled {
+			m.CancelChan <- interruptionEvent
+		} else {
+			m.InterruptionChan <- interruptionEvent
+		}
+	}
+	return nil
+}
+
+// Kind denotes the kind of event that is processed
+func (m ExecPluginMonitor) Kind() string {
+	return PluginEventKind
+}
+
+// runPlugin runs the plugin command and parses the JSON array of events it writes to stdout. No output means no events.
+func (m ExecPluginMonitor) runPlugin() ([]PluginEvent, error) {
+	args := strings.Fields(m.Command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("monitor plugin command is empty")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", nodeNameEnv, m.NodeName))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("monitor plugin %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+	var pluginEvents []PluginEvent
+	err = json.Unmarshal(output, &pluginEvents)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse monitor plugin output: %w", err)
+	}
+	return pluginEvents, nil
+}
+
+func (m ExecPluginMonitor) toInterruptionEvent(pluginEvent PluginEvent) (monitor.InterruptionEvent, error) {
+	if pluginEvent.EventID == "" {
+		return monitor.InterruptionEvent{}, fmt.Errorf("monitor plugin reported an event without an eventId")
+	}
+	if pluginEvent.State != "" && pluginEvent.State != PluginEventStateActive && pluginEvent.State != PluginEventStateCanceled {
+		return monitor.InterruptionEvent{}, fmt.Errorf("monitor plugin event %s has an invalid state %s", pluginEvent.EventID, pluginEvent.State)
+	}
+	startTime := time.Now()
+	if pluginEvent.StartTime != "" {
+		var err error
+		startTime, err = time.Parse(time.RFC3339, pluginEvent.StartTime)
+		if err != nil {
+			return monitor.InterruptionEvent{}, fmt.Errorf("Unable to parse the start time of monitor plugin event %s: %w", pluginEvent.EventID, err)
+		}
+	}
+	nodeName := pluginEvent.NodeName
+	if nodeName == "" {
+		nodeName = m.NodeName
+	}
+	description := pluginEvent.Description
+	if description == "" {
+		description = fmt.Sprintf("Monitor plugin event %s received for node %s \n", pluginEvent.Code, nodeName)
+	}
+	return monitor.InterruptionEvent{
+		EventID:     eventIDPrefix + pluginEvent.EventID,
+		Kind:        PluginEventKind,
+		Code:        pluginEvent.Code,
+		Description: description,
+		State:       pluginEvent.State,
+		NodeName:    nodeName,
+		InstanceID:  pluginEvent.InstanceID,
+		StartTime:   startTime,
+	}, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pluginevent_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/pluginevent"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+const nodeName = "test-node"
+
+func writePlugin(t *testing.T, script string) string {
+	dir, err := ioutil.TempDir("", "nth-plugin")
+	h.Ok(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "plugin.sh")
+	err = ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755)
+	h.Ok(t, err)
+	return path
+}
+
+func TestMonitor_Success(t *testing.T) {
+	plugin := writePlugin(t, `echo '[
+		{"eventId": "1", "code": "disk-failing", "startTime": "2021-06-05T08:00:00Z"},
+		{"eventId": "2", "state": "canceled", "nodeName": "other-node"}
+	]'`)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	cancelChan := make(chan monitor.InterruptionEvent, 1)
+	pluginMonitor := pluginevent.NewExecPluginMonitor(plugin, time.Second*5, drainChan, cancelChan, nodeName)
+	h.Equals(t, pluginevent.PluginEventKind, pluginMonitor.Kind())
+
+	err := pluginMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Equals(t, "plugin-1", result.EventID)
+	h.Equals(t, pluginevent.PluginEventKind, result.Kind)
+	h.Equals(t, "disk-failing", result.Code)
+	h.Equals(t, nodeName, result.NodeName)
+	h.Equals(t, "2021-06-05 08:00:00 +0000 UTC", result.StartTime.String())
+
+	canceled := <-cancelChan
+	h.Equals(t, "plugin-2", canceled.EventID)
+	h.Equals(t, "other-node", canceled.NodeName)
+}
+
+func TestMonitor_NodeNameEnv(t *testing.T) {
+	plugin := writePlugin(t, `echo "[{\"eventId\": \"$NODE_NAME\"}]"`)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	pluginMonitor := pluginevent.NewExecPluginMonitor(plugin, time.Second*5, drainChan, nil, nodeName)
+	err := pluginMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Equals(t, "plugin-"+nodeName, result.EventID)
+}
+
+func TestMonitor_NoEvents(t *testing.T) {
+	pluginMonitor := pluginevent.NewExecPluginMonitor(writePlugin(t, "exit 0"), time.Second*5, nil, nil, nodeName)
+	err := pluginMonitor.Monitor()
+	h.Ok(t, err)
+}
+
+func TestMonitor_Failures(t *testing.T) {
+	for _, script := range []string{
+		"echo 'check failed' >&2; exit 1",
+		"echo 'not json'",
+		`echo '[{"code": "no-event-id"}]'`,
+		`echo '[{"eventId": "1", "state": "pending"}]'`,
+		`echo '[{"eventId": "1", "startTime": "tomorrow"}]'`,
+		"exec sleep 5",
+	} {
+		pluginMonitor := pluginevent.NewExecPluginMonitor(writePlugin(t, script), time.Millisecond*500, nil, nil, nodeName)
+		err := pluginMonitor.Monitor()
+		h.Assert(t, err != nil, "Expected an error for plugin script: "+script)
+	}
+}
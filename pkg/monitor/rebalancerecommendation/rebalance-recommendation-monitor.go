@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
 )
@@ -33,6 +34,9 @@ type RebalanceRecommendationMonitor struct {
 	IMDS             *ec2metadata.Service
 	InterruptionChan chan<- monitor.InterruptionEvent
 	NodeName         string
+	// DrainLeadTime, if set, delays the drain until this long before the earliest time the instance could be interrupted,
+	// a Spot interruption window after the recommendation
+	DrainLeadTime time.Duration
 }
 
 // NewRebalanceRecommendationMonitor creates an instance of a rebalance recoomendation IMDS monitor
@@ -72,7 +76,7 @@ func (m RebalanceRecommendationMonitor) checkForRebalanceRecommendation() (*moni
 		return nil, nil
 	}
 	nodeName := m.NodeName
-	noticeTime, err := time.Parse(time.RFC3339, rebalanceRecommendation.NoticeTime)
+	noticeTime, err := eventparser.ParseTime(rebalanceRecommendation.NoticeTime)
 	if err != nil {
 		return nil, fmt.Errorf("Could not parse time from rebalance recommendation metadata json: %w", err)
 	}
@@ -88,6 +92,7 @@ func (m RebalanceRecommendationMonitor) checkForRebalanceRecommendation() (*moni
 		EventID:      fmt.Sprintf("rebalance-recommendation-%x", hash.Sum(nil)),
 		Kind:         RebalanceRecommendationKind,
 		StartTime:    noticeTime,
+		DrainTime:    monitor.DrainTimeBefore(noticeTime.Add(monitor.SpotInterruptionWindow), m.DrainLeadTime),
 		NodeName:     nodeName,
 		Description:  fmt.Sprintf("Rebalance recommendation received. Instance will be cordoned at %s \n", rebalanceRecommendation.NoticeTime),
 		PreDrainTask: setInterruptionTaint,
@@ -15,9 +15,11 @@ package scheduledevent
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
 	"github.com/rs/zerolog/log"
@@ -28,11 +30,13 @@ const (
 	ScheduledEventKind           = "SCHEDULED_EVENT"
 	scheduledEventStateCompleted = "completed"
 	scheduledEventStateCanceled  = "canceled"
-	scheduledEventDateFormat     = "2 Jan 2006 15:04:05 GMT"
 	instanceStopCode             = "instance-stop"
 	systemRebootCode             = "system-reboot"
 	instanceRebootCode           = "instance-reboot"
 	instanceRetirementCode       = "instance-retirement"
+	systemMaintenanceCode        = "system-maintenance"
+	networkMaintenanceCode       = "network-maintenance"
+	powerMaintenanceCode         = "power-maintenance"
 )
 
 // ScheduledEventMonitor is a struct definition that knows how to process scheduled events from IMDS
@@ -41,6 +45,15 @@ type ScheduledEventMonitor struct {
 	InterruptionChan chan<- monitor.InterruptionEvent
 	CancelChan       chan<- monitor.InterruptionEvent
 	NodeName         string
+	// Reboot, if set, is called after draining the node for a reboot event so the instance reboots immediately instead of
+	// during the maintenance window
+	Reboot func() error
+	// NotifyOnlyCodes are comma separated event codes which are reported without cordoning or draining the node.
+	// System maintenance events also match network-maintenance and power-maintenance based on their description.
+	NotifyOnlyCodes string
+	// DrainLeadTime, if set, schedules the drain this long before the event's NotBefore time instead of using the node
+	// termination grace period
+	DrainLeadTime time.Duration
 }
 
 // NewScheduledEventMonitor creates an instance of a scheduled event monitor
@@ -83,36 +96,62 @@ func (m ScheduledEventMonitor) checkForScheduledEvents() ([]monitor.Interruption
 
 	events := make([]monitor.InterruptionEvent, 0)
 	for _, scheduledEvent := range scheduledEvents {
-		var preDrainFunc monitor.DrainTask
+		var preDrainFunc, postDrainFunc monitor.DrainTask
+		var instanceAction string
 		if isRestartEvent(scheduledEvent.Code) && !isStateCanceledOrCompleted(scheduledEvent.State) {
 			preDrainFunc = uncordonAfterRebootPreDrain
 		}
-		notBefore, err := time.Parse(scheduledEventDateFormat, scheduledEvent.NotBefore)
+		if scheduledEvent.Code == instanceStopCode && !isStateCanceledOrCompleted(scheduledEvent.State) {
+			// the instance is started again with its disk, so the node is kept and uncordoned if the same instance returns
+			instanceAction = monitor.InstanceActionStop
+			preDrainFunc = m.instanceStopPreDrain
+		}
+		if m.Reboot != nil && isRebootEvent(scheduledEvent.Code) && !isStateCanceledOrCompleted(scheduledEvent.State) {
+			postDrainFunc = rebootPostDrain(m.Reboot)
+		}
+		notBefore, err := eventparser.ParseTime(scheduledEvent.NotBefore)
 		if err != nil {
 			return nil, fmt.Errorf("Unable to parse scheduled event start time: %w", err)
 		}
 		notAfter := notBefore
 		if len(scheduledEvent.NotAfter) > 0 {
-			notAfter, err = time.Parse(scheduledEventDateFormat, scheduledEvent.NotAfter)
+			notAfter, err = eventparser.ParseTime(scheduledEvent.NotAfter)
 			if err != nil {
 				notAfter = notBefore
 				log.Err(err).Msg("Unable to parse scheduled event end time, continuing")
 			}
 		}
 		events = append(events, monitor.InterruptionEvent{
-			EventID:      scheduledEvent.EventID,
-			Kind:         ScheduledEventKind,
-			Description:  fmt.Sprintf("%s will occur between %s and %s because %s\n", scheduledEvent.Code, scheduledEvent.NotBefore, scheduledEvent.NotAfter, scheduledEvent.Description),
-			State:        scheduledEvent.State,
-			NodeName:     m.NodeName,
-			StartTime:    notBefore,
-			EndTime:      notAfter,
-			PreDrainTask: preDrainFunc,
+			EventID:        scheduledEvent.EventID,
+			Kind:           ScheduledEventKind,
+			Code:           scheduledEvent.Code,
+			InstanceAction: instanceAction,
+			Description:    fmt.Sprintf("%s will occur between %s and %s because %s\n", scheduledEvent.Code, scheduledEvent.NotBefore, scheduledEvent.NotAfter, scheduledEvent.Description),
+			State:          scheduledEvent.State,
+			NodeName:       m.NodeName,
+			StartTime:      notBefore,
+			EndTime:        notAfter,
+			DrainTime:      monitor.DrainTimeBefore(notBefore, m.DrainLeadTime),
+			PreDrainTask:   preDrainFunc,
+			PostDrainTask:  postDrainFunc,
+			NotifyOnly:     m.isNotifyOnly(scheduledEvent.Code, scheduledEvent.Description),
 		})
 	}
 	return events, nil
 }
 
+func (m ScheduledEventMonitor) instanceStopPreDrain(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+	instanceID, err := m.IMDS.GetMetadataInfo(ec2metadata.InstanceIDPath)
+	if err != nil {
+		return fmt.Errorf("Unable to get the instance ID before the instance is stopped: %w", err)
+	}
+	err = n.MarkInstanceStopping(interruptionEvent.NodeName, instanceID)
+	if err != nil {
+		return err
+	}
+	return uncordonAfterRebootPreDrain(interruptionEvent, n)
+}
+
 func uncordonAfterRebootPreDrain(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
 	nodeName := interruptionEvent.NodeName
 	err := n.MarkWithEventID(nodeName, interruptionEvent.EventID)
@@ -141,11 +180,65 @@ func uncordonAfterRebootPreDrain(interruptionEvent monitor.InterruptionEvent, n
 	return nil
 }
 
+// isNotifyOnly returns true if the event code is configured to only be reported
+func (m ScheduledEventMonitor) isNotifyOnly(maintenanceCode string, description string) bool {
+	codes := []string{maintenanceCode}
+	if maintenanceCode == systemMaintenanceCode {
+		lowerDescription := strings.ToLower(description)
+		if strings.Contains(lowerDescription, "network") {
+			codes = append(codes, networkMaintenanceCode)
+		}
+		if strings.Contains(lowerDescription, "power") {
+			codes = append(codes, powerMaintenanceCode)
+		}
+	}
+	for _, notifyOnlyCode := range strings.Split(m.NotifyOnlyCodes, ",") {
+		notifyOnlyCode = strings.TrimSpace(notifyOnlyCode)
+		for _, code := range codes {
+			if notifyOnlyCode != "" && notifyOnlyCode == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func rebootPostDrain(reboot func() error) monitor.DrainTask {
+	return func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+		nodeName := interruptionEvent.NodeName
+		// the scheduled event remains until the maintenance window, so only reboot once per event
+		rebooted, err := n.WasRebootedForEvent(nodeName, interruptionEvent.EventID)
+		if err != nil {
+			return fmt.Errorf("Unable to determine if the node was already rebooted for event %s: %w", interruptionEvent.EventID, err)
+		}
+		if rebooted {
+			log.Info().Str("event_id", interruptionEvent.EventID).Msg("Node was already rebooted for this event, not rebooting again")
+			return nil
+		}
+		err = n.MarkRebootedForEvent(nodeName, interruptionEvent.EventID)
+		if err != nil {
+			return err
+		}
+		return reboot()
+	}
+}
+
 func isStateCanceledOrCompleted(state string) bool {
 	return state == scheduledEventStateCanceled ||
 		state == scheduledEventStateCompleted
 }
 
+func isRebootEvent(maintenanceCode string) bool {
+	return maintenanceCode == instanceRebootCode ||
+		maintenanceCode == systemRebootCode
+}
+
+// IsRetirement returns true if the scheduled event retires the instance, which is the only scheduled event taking it out
+// of service for good. Stopped and rebooted instances come back with their node.
+func IsRetirement(maintenanceCode string) bool {
+	return maintenanceCode == instanceRetirementCode
+}
+
 func isRestartEvent(maintenanceCode string) bool {
 	return maintenanceCode == instanceStopCode ||
 		maintenanceCode == instanceRetirementCode ||
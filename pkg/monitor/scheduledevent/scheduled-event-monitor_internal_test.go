@@ -66,7 +66,7 @@ func TestUncordonAfterRebootPreDrainSuccess(t *testing.T) {
 		NodeName: nodeName,
 	}
 
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, metav1.CreateOptions{})
 	h.Ok(t, err)
 
@@ -79,7 +79,7 @@ func TestUncordonAfterRebootPreDrainSuccess(t *testing.T) {
 }
 
 func TestUncordonAfterRebootPreDrainMarkWithEventIDFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := uncordonAfterRebootPreDrain(monitor.InterruptionEvent{}, *tNode)
 	h.Assert(t, err != nil, "Failed to return error on MarkWithEventID failing to fetch node")
 }
@@ -90,7 +90,7 @@ func TestUncordonAfterRebootPreDrainNodeAlreadyMarkedSuccess(t *testing.T) {
 		NodeName: nodeName,
 	}
 
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -14,15 +14,23 @@
 package scheduledevent_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/config"
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/scheduledevent"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
 	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
 )
 
 const (
@@ -95,6 +103,147 @@ func TestMonitor_Success(t *testing.T) {
 	h.Ok(t, err)
 }
 
+func TestMonitor_RebootPostDrain(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if imdsV2TokenPath == req.URL.String() {
+			rw.WriteHeader(403)
+			return
+		}
+		_, err := rw.Write(scheduledEventResponse)
+		h.Ok(t, err)
+	}))
+	defer server.Close()
+
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	cancelChan := make(chan monitor.InterruptionEvent, 1)
+	imds := ec2metadata.New(server.URL, 1)
+	reboots := 0
+
+	scheduledEventMonitor := scheduledevent.NewScheduledEventMonitor(imds, drainChan, cancelChan, nodeName)
+	scheduledEventMonitor.Reboot = func() error {
+		reboots++
+		return nil
+	}
+	err := scheduledEventMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Assert(t, result.PostDrainTask != nil, "Expected a post-drain reboot task for a reboot event")
+
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client, Ctx: context.TODO()}, uptime.Uptime)
+	h.Ok(t, err)
+
+	err = result.PostDrainTask(result, *tNode)
+	h.Ok(t, err)
+	h.Equals(t, 1, reboots)
+
+	// the event is still reported after the reboot, but the node must not be rebooted again
+	err = result.PostDrainTask(result, *tNode)
+	h.Ok(t, err)
+	h.Equals(t, 1, reboots)
+}
+
+func TestMonitor_NotifyOnlyCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if imdsV2TokenPath == req.URL.String() {
+			rw.WriteHeader(403)
+			return
+		}
+		_, err := rw.Write([]byte(`[{
+			"NotBefore": "` + scheduledEventStartTime + `",
+			"Code": "system-maintenance",
+			"Description": "scheduled network maintenance",
+			"EventId": "` + scheduledEventId + `",
+			"NotAfter": "` + scheduledEventEndTime + `",
+			"State": "` + scheduledEventState + `"
+		}]`))
+		h.Ok(t, err)
+	}))
+	defer server.Close()
+
+	imds := ec2metadata.New(server.URL, 1)
+	for notifyOnlyCodes, expected := range map[string]bool{
+		"":                                       false,
+		"power-maintenance":                      false,
+		"power-maintenance, network-maintenance": true,
+		"system-maintenance":                     true,
+	} {
+		drainChan := make(chan monitor.InterruptionEvent, 1)
+		cancelChan := make(chan monitor.InterruptionEvent, 1)
+		scheduledEventMonitor := scheduledevent.NewScheduledEventMonitor(imds, drainChan, cancelChan, nodeName)
+		scheduledEventMonitor.NotifyOnlyCodes = notifyOnlyCodes
+		err := scheduledEventMonitor.Monitor()
+		h.Ok(t, err)
+		result := <-drainChan
+		h.Equals(t, expected, result.NotifyOnly)
+	}
+}
+
+func TestMonitor_DrainLeadTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if imdsV2TokenPath == req.URL.String() {
+			rw.WriteHeader(403)
+			return
+		}
+		_, err := rw.Write(scheduledEventResponse)
+		h.Ok(t, err)
+	}))
+	defer server.Close()
+
+	imds := ec2metadata.New(server.URL, 1)
+	for leadTime, expected := range map[time.Duration]string{
+		0:                "0001-01-01 00:00:00 +0000 UTC",
+		time.Hour * 2:    "2019-01-21 07:00:43 +0000 UTC",
+		time.Minute * 30: "2019-01-21 08:30:43 +0000 UTC",
+	} {
+		drainChan := make(chan monitor.InterruptionEvent, 1)
+		cancelChan := make(chan monitor.InterruptionEvent, 1)
+		scheduledEventMonitor := scheduledevent.NewScheduledEventMonitor(imds, drainChan, cancelChan, nodeName)
+		scheduledEventMonitor.DrainLeadTime = leadTime
+		err := scheduledEventMonitor.Monitor()
+		h.Ok(t, err)
+		result := <-drainChan
+		h.Equals(t, expected, result.DrainTime.String())
+	}
+}
+
+func TestMonitor_InstanceStop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if imdsV2TokenPath == req.URL.String() {
+			rw.WriteHeader(403)
+			return
+		}
+		if req.URL.String() == ec2metadata.InstanceIDPath {
+			_, err := rw.Write([]byte("i-0123456789"))
+			h.Ok(t, err)
+			return
+		}
+		_, err := rw.Write([]byte(strings.Replace(string(scheduledEventResponse), scheduledEventCode, "instance-stop", 1)))
+		h.Ok(t, err)
+	}))
+	defer server.Close()
+
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	cancelChan := make(chan monitor.InterruptionEvent, 1)
+	imds := ec2metadata.New(server.URL, 1)
+
+	scheduledEventMonitor := scheduledevent.NewScheduledEventMonitor(imds, drainChan, cancelChan, nodeName)
+	err := scheduledEventMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Assert(t, result.IsStopOrHibernate(), "Expected an instance-stop event to keep the node")
+
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client, Ctx: context.TODO()}, uptime.Uptime)
+	h.Ok(t, err)
+	err = result.PreDrainTask(result, *tNode)
+	h.Ok(t, err)
+
+	n, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "i-0123456789", n.Annotations[node.StoppedInstanceAnnotation])
+}
+
 func TestMonitor_CanceledEvent(t *testing.T) {
 	var requestPath string = ec2metadata.ScheduledEventPath
 	var state = "canceled"
@@ -273,3 +422,10 @@ func TestMonitor_EndTimeParseFail(t *testing.T) {
 	err := scheduledEventMonitor.Monitor()
 	h.Ok(t, err)
 }
+
+func TestIsRetirement(t *testing.T) {
+	h.Equals(t, true, scheduledevent.IsRetirement("instance-retirement"))
+	for _, code := range []string{"instance-stop", "instance-reboot", "system-reboot", "system-maintenance"} {
+		h.Equals(t, false, scheduledevent.IsRetirement(code))
+	}
+}
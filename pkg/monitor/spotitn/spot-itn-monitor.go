@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
 )
@@ -34,6 +35,8 @@ type SpotInterruptionMonitor struct {
 	InterruptionChan chan<- monitor.InterruptionEvent
 	CancelChan       chan<- monitor.InterruptionEvent
 	NodeName         string
+	// DrainLeadTime, if set, delays the drain until this long before the interruption time
+	DrainLeadTime time.Duration
 }
 
 // NewSpotInterruptionMonitor creates an instance of a spot ITN IMDS monitor
@@ -74,7 +77,7 @@ func (m SpotInterruptionMonitor) checkForSpotInterruptionNotice() (*monitor.Inte
 		return nil, fmt.Errorf("There was a problem checking for spot ITNs: %w", err)
 	}
 	nodeName := m.NodeName
-	interruptionTime, err := time.Parse(time.RFC3339, instanceAction.Time)
+	interruptionTime, err := eventparser.ParseTime(instanceAction.Time)
 	if err != nil {
 		return nil, fmt.Errorf("Could not parse time from spot interruption notice metadata json: %w", err)
 	}
@@ -86,14 +89,22 @@ func (m SpotInterruptionMonitor) checkForSpotInterruptionNotice() (*monitor.Inte
 		return nil, fmt.Errorf("There was a problem creating an event ID from the event: %w", err)
 	}
 
-	return &monitor.InterruptionEvent{
-		EventID:      fmt.Sprintf("spot-itn-%x", hash.Sum(nil)),
-		Kind:         SpotITNKind,
-		StartTime:    interruptionTime,
-		NodeName:     nodeName,
-		Description:  fmt.Sprintf("Spot ITN received. Instance will be interrupted at %s \n", instanceAction.Time),
-		PreDrainTask: setInterruptionTaint,
-	}, nil
+	interruptionEvent := &monitor.InterruptionEvent{
+		EventID:        fmt.Sprintf("spot-itn-%x", hash.Sum(nil)),
+		Kind:           SpotITNKind,
+		StartTime:      interruptionTime,
+		DrainTime:      monitor.DrainTimeBefore(interruptionTime, m.DrainLeadTime),
+		NodeName:       nodeName,
+		InstanceAction: instanceAction.Action,
+		Code:           instanceAction.Action,
+		Description:    fmt.Sprintf("Spot ITN received. Instance will be interrupted at %s \n", instanceAction.Time),
+		PreDrainTask:   setInterruptionTaint,
+	}
+	if interruptionEvent.IsStopOrHibernate() {
+		interruptionEvent.Description = fmt.Sprintf("Spot ITN received. Instance will be interrupted with action %s at %s \n", instanceAction.Action, instanceAction.Time)
+		interruptionEvent.PreDrainTask = setInterruptionTaintAndUncordonAfterRestart
+	}
+	return interruptionEvent, nil
 }
 
 func setInterruptionTaint(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
@@ -104,3 +115,29 @@ func setInterruptionTaint(interruptionEvent monitor.InterruptionEvent, n node.No
 
 	return nil
 }
+
+// setInterruptionTaintAndUncordonAfterRestart taints the node and marks it to be uncordoned once a stopped or
+// hibernated instance is started again
+func setInterruptionTaintAndUncordonAfterRestart(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+	err := setInterruptionTaint(interruptionEvent, n)
+	if err != nil {
+		return err
+	}
+	// if the node is already marked as unschedulable, then it should stay cordoned when the instance comes back
+	unschedulable, err := n.IsUnschedulable(interruptionEvent.NodeName)
+	if err != nil {
+		return fmt.Errorf("Encountered an error while checking if the node is unschedulable. Not setting an uncordon label: %w", err)
+	}
+	if unschedulable {
+		return nil
+	}
+	err = n.MarkWithEventID(interruptionEvent.NodeName, interruptionEvent.EventID)
+	if err != nil {
+		return fmt.Errorf("Unable to mark node with event ID: %w", err)
+	}
+	err = n.MarkForUncordonAfterReboot(interruptionEvent.NodeName)
+	if err != nil {
+		return fmt.Errorf("Unable to mark the node for uncordon: %w", err)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
@@ -68,6 +69,54 @@ func TestMonitor_Success(t *testing.T) {
 	h.Ok(t, err)
 }
 
+func TestMonitor_StopAction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if imdsV2TokenPath == req.URL.String() {
+			rw.WriteHeader(403)
+			return
+		}
+		_, err := rw.Write([]byte(`{"action": "stop", "time": "` + startTime + `"}`))
+		h.Ok(t, err)
+	}))
+	defer server.Close()
+
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	cancelChan := make(chan monitor.InterruptionEvent)
+	imds := ec2metadata.New(server.URL, 1)
+
+	spotITNMonitor := spotitn.NewSpotInterruptionMonitor(imds, drainChan, cancelChan, nodeName)
+	err := spotITNMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Equals(t, monitor.InstanceActionStop, result.InstanceAction)
+	h.Assert(t, result.IsStopOrHibernate(), "Expected a stop action to be recognized")
+	h.Assert(t, strings.Contains(result.Description, "stop"),
+		"Expected description to contain the action but is actually: "+result.Description)
+}
+
+func TestMonitor_DrainLeadTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if imdsV2TokenPath == req.URL.String() {
+			rw.WriteHeader(403)
+			return
+		}
+		_, err := rw.Write(instanceActionResponse)
+		h.Ok(t, err)
+	}))
+	defer server.Close()
+
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	cancelChan := make(chan monitor.InterruptionEvent)
+	imds := ec2metadata.New(server.URL, 1)
+
+	spotITNMonitor := spotitn.NewSpotInterruptionMonitor(imds, drainChan, cancelChan, nodeName)
+	spotITNMonitor.DrainLeadTime = 90 * time.Second
+	err := spotITNMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Equals(t, "2017-09-18 08:20:30 +0000 UTC", result.DrainTime.String())
+}
+
 func TestMonitor_MetadataParseFailure(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		if imdsV2TokenPath == req.URL.String() {
@@ -14,15 +14,17 @@
 package sqsevent
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/rs/zerolog/log"
 )
 
@@ -55,51 +57,57 @@ type LifecycleDetail struct {
 	LifecycleHookName    string `json:"LifecycleHookName"`
 	EC2InstanceID        string `json:"EC2InstanceId"`
 	LifecycleTransition  string `json:"LifecycleTransition"`
+	Origin               string `json:"Origin"`
+	Destination          string `json:"Destination"`
 }
 
-func (m SQSMonitor) asgTerminationToInterruptionEvent(event EventBridgeEvent, message *sqs.Message) (monitor.InterruptionEvent, error) {
+const (
+	// lifecycleTransitionTerminating is the transition of instances leaving an ASG or its warm pool
+	lifecycleTransitionTerminating = "autoscaling:EC2_INSTANCE_TERMINATING"
+	// warmPoolLocation is the Origin or Destination of a lifecycle transition into or out of an ASG's warm pool
+	warmPoolLocation = "WarmPool"
+)
+
+func (m SQSMonitor) asgTerminationToInterruptionEvent(event EventBridgeEvent, message *types.Message) (monitor.InterruptionEvent, error) {
 	lifecycleDetail := &LifecycleDetail{}
-	err := json.Unmarshal(event.Detail, lifecycleDetail)
+	err := eventparser.ParseDetail(event.Detail, lifecycleDetail)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
 
-	nodeName, err := m.retrieveNodeName(lifecycleDetail.EC2InstanceID)
+	if lifecycleDetail.LifecycleTransition != "" && lifecycleDetail.LifecycleTransition != lifecycleTransitionTerminating {
+		// launch transitions, including instances launched into a warm pool (Warmed:Pending), never require a drain
+		log.Info().Msgf("Ignoring ASG Lifecycle %s event for instance %s", lifecycleDetail.LifecycleTransition, lifecycleDetail.EC2InstanceID)
+		return monitor.InterruptionEvent{}, m.deleteMessage(message)
+	}
+	if lifecycleDetail.Origin == warmPoolLocation {
+		// instances terminating from a warm pool (Warmed:Terminating) were stopped or hibernated and are not serving as cluster nodes
+		log.Info().Msgf("Instance %s is terminating from the warm pool of ASG %s, completing the lifecycle hook without draining", lifecycleDetail.EC2InstanceID, lifecycleDetail.AutoScalingGroupName)
+		return monitor.InterruptionEvent{}, m.completeLifecycleAction(lifecycleDetail, message)
+	}
+
+	nodeName, cluster, err := m.retrieveNodeName(lifecycleDetail.EC2InstanceID)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
 
+	description := fmt.Sprintf("ASG Lifecycle Termination event received. Instance will be interrupted at %s \n", event.getTime())
+	if lifecycleDetail.Destination == warmPoolLocation {
+		description = fmt.Sprintf("ASG Lifecycle Termination event received. Instance will be returned to the warm pool at %s \n", event.getTime())
+	}
 	interruptionEvent := monitor.InterruptionEvent{
 		EventID:              fmt.Sprintf("asg-lifecycle-term-%x", event.ID),
 		Kind:                 SQSTerminateKind,
 		AutoScalingGroupName: lifecycleDetail.AutoScalingGroupName,
 		StartTime:            event.getTime(),
 		NodeName:             nodeName,
+		Cluster:              cluster,
 		InstanceID:           lifecycleDetail.EC2InstanceID,
-		Description:          fmt.Sprintf("ASG Lifecycle Termination event received. Instance will be interrupted at %s \n", event.getTime()),
+		Description:          description,
 	}
 
 	interruptionEvent.PostDrainTask = func(interruptionEvent monitor.InterruptionEvent, _ node.Node) error {
-		_, err := m.ASG.CompleteLifecycleAction(&autoscaling.CompleteLifecycleActionInput{
-			AutoScalingGroupName:  &lifecycleDetail.AutoScalingGroupName,
-			LifecycleActionResult: aws.String("CONTINUE"),
-			LifecycleHookName:     &lifecycleDetail.LifecycleHookName,
-			LifecycleActionToken:  &lifecycleDetail.LifecycleActionToken,
-			InstanceId:            &lifecycleDetail.EC2InstanceID,
-		})
-		if err != nil {
-			if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() != 400 {
-				return err
-			}
-		}
-		log.Info().Msgf("Completed ASG Lifecycle Hook (%s) for instance %s",
-			lifecycleDetail.LifecycleHookName,
-			lifecycleDetail.EC2InstanceID)
-		errs := m.deleteMessages([]*sqs.Message{message})
-		if errs != nil {
-			return errs[0]
-		}
-		return nil
+		return m.completeLifecycleAction(lifecycleDetail, message)
 	}
 
 	interruptionEvent.PreDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
@@ -112,3 +120,24 @@ func (m SQSMonitor) asgTerminationToInterruptionEvent(event EventBridgeEvent, me
 
 	return interruptionEvent, nil
 }
+
+// completeLifecycleAction continues the lifecycle hook and deletes the lifecycle message from the queue
+func (m SQSMonitor) completeLifecycleAction(lifecycleDetail *LifecycleDetail, message *types.Message) error {
+	_, err := m.ASG.CompleteLifecycleAction(context.TODO(), &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  &lifecycleDetail.AutoScalingGroupName,
+		LifecycleActionResult: aws.String("CONTINUE"),
+		LifecycleHookName:     &lifecycleDetail.LifecycleHookName,
+		LifecycleActionToken:  &lifecycleDetail.LifecycleActionToken,
+		InstanceId:            &lifecycleDetail.EC2InstanceID,
+	})
+	if err != nil {
+		var responseErr *awshttp.ResponseError
+		if errors.As(err, &responseErr) && responseErr.HTTPStatusCode() != 400 {
+			return err
+		}
+	}
+	log.Info().Msgf("Completed ASG Lifecycle Hook (%s) for instance %s",
+		lifecycleDetail.LifecycleHookName,
+		lifecycleDetail.EC2InstanceID)
+	return m.deleteMessage(message)
+}
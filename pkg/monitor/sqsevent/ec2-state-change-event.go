@@ -14,13 +14,15 @@
 package sqsevent
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/rs/zerolog/log"
 )
 
 /* Example EC2 State Change Event:
@@ -50,18 +52,24 @@ type EC2StateChangeDetail struct {
 
 const instanceStatesToDrain = "stopping,stopped,shutting-down,terminated"
 
-func (m SQSMonitor) ec2StateChangeToInterruptionEvent(event EventBridgeEvent, message *sqs.Message) (monitor.InterruptionEvent, error) {
+// instanceStateRunning is the state of a stopped or hibernated instance which was started again
+const instanceStateRunning = "running"
+
+func (m SQSMonitor) ec2StateChangeToInterruptionEvent(event EventBridgeEvent, message *types.Message) (monitor.InterruptionEvent, error) {
 	ec2StateChangeDetail := &EC2StateChangeDetail{}
-	err := json.Unmarshal(event.Detail, ec2StateChangeDetail)
+	err := eventparser.ParseDetail(event.Detail, ec2StateChangeDetail)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
 
+	if strings.ToLower(ec2StateChangeDetail.State) == instanceStateRunning {
+		return monitor.InterruptionEvent{}, m.uncordonStartedInstance(ec2StateChangeDetail.InstanceID, event.getTime(), message)
+	}
 	if !strings.Contains(instanceStatesToDrain, strings.ToLower(ec2StateChangeDetail.State)) {
 		return monitor.InterruptionEvent{}, nil
 	}
 
-	nodeName, err := m.retrieveNodeName(ec2StateChangeDetail.InstanceID)
+	nodeName, cluster, err := m.retrieveNodeName(ec2StateChangeDetail.InstanceID)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
@@ -71,13 +79,14 @@ func (m SQSMonitor) ec2StateChangeToInterruptionEvent(event EventBridgeEvent, me
 		Kind:                 SQSTerminateKind,
 		StartTime:            event.getTime(),
 		NodeName:             nodeName,
+		Cluster:              cluster,
 		AutoScalingGroupName: asgName,
 		InstanceID:           ec2StateChangeDetail.InstanceID,
 		Description:          fmt.Sprintf("EC2 State Change event received. Instance %s went into %s at %s \n", ec2StateChangeDetail.InstanceID, ec2StateChangeDetail.State, event.getTime()),
 	}
 
 	interruptionEvent.PostDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
-		errs := m.deleteMessages([]*sqs.Message{message})
+		errs := m.deleteMessages([]*types.Message{message})
 		if errs != nil {
 			return errs[0]
 		}
@@ -85,3 +94,27 @@ func (m SQSMonitor) ec2StateChangeToInterruptionEvent(event EventBridgeEvent, me
 	}
 	return interruptionEvent, nil
 }
+
+// uncordonStartedInstance uncordons the node of a stopped or hibernated spot instance which is running again, if it was
+// marked to be uncordoned when the instance was interrupted. The message is deleted once the node was handled.
+func (m SQSMonitor) uncordonStartedInstance(instanceID string, startedAt time.Time, message *types.Message) error {
+	nodeName, cluster, err := m.retrieveNodeName(instanceID)
+	if err != nil {
+		return err
+	}
+	n := m.Node
+	if m.ClusterTagKey != "" {
+		n = m.Clusters[cluster]
+	}
+	if n == nil {
+		return m.deleteMessage(message)
+	}
+	uncordoned, err := n.UncordonIfStarted(nodeName, instanceID, startedAt)
+	if err != nil {
+		return fmt.Errorf("Unable to uncordon node %s of started instance %s: %w", nodeName, instanceID, err)
+	}
+	if uncordoned {
+		log.Info().Str("node_name", nodeName).Msgf("Uncordoned the node since instance %s is running again", instanceID)
+	}
+	return m.deleteMessage(message)
+}
@@ -14,29 +14,19 @@
 package sqsevent
 
 import (
-	"encoding/json"
 	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/rs/zerolog/log"
 )
 
 // EventBridgeEvent is a structure to hold generic event details from Amazon EventBridge
-type EventBridgeEvent struct {
-	Version    string          `json:"version"`
-	ID         string          `json:"id"`
-	DetailType string          `json:"detail-type"`
-	Source     string          `json:"source"`
-	Account    string          `json:"account"`
-	Time       string          `json:"time"`
-	Region     string          `json:"region"`
-	Resources  []string        `json:"resources"`
-	Detail     json.RawMessage `json:"detail"`
-}
+type EventBridgeEvent eventparser.EventBridgeEvent
 
 func (e EventBridgeEvent) getTime() time.Time {
-	terminationTime, err := time.Parse(time.RFC3339, e.Time)
+	terminationTime, err := eventparser.ParseTime(e.Time)
 	if err != nil {
-		log.Warn().Msgf("Unable to parse time as RFC3339 from event %s (%s), using current time instead.", e.DetailType, e.ID)
+		log.Warn().Msgf("Unable to parse time from event %s (%s), using current time instead.", e.DetailType, e.ID)
 		return time.Now()
 	}
 	return terminationTime
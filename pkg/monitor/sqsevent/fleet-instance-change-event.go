@@ -0,0 +1,101 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqsevent
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/rs/zerolog/log"
+)
+
+/* Example EC2 Fleet Instance Change Event:
+{
+	"version": "0",
+	"id": "2ba3c3b4-7c11-6ab9-42a4-bd5b1ab0a4f3",
+	"detail-type": "EC2 Fleet Instance Change",
+	"source": "aws.ec2fleet",
+	"account": "123456789012",
+	"time": "2020-11-09T09:25:02Z",
+	"region": "us-east-1",
+	"resources": [
+	  "arn:aws:ec2:us-east-1:123456789012:fleet/fleet-58b5b8e5-4c14-4d6a-a1e4-1b3a3fc8b7a2"
+	],
+	"detail": {
+	  "instance-id": "i-0c594155dd5ff1829",
+	  "description": "{\"instanceType\":\"c5.large\",\"image\":\"ami-6057e21a\",\"productDescription\":\"Linux/UNIX\",\"availabilityZone\":\"us-east-1d\"}",
+	  "sub-type": "termination_notified"
+	}
+}
+
+Spot Fleet sends the same detail with the source "aws.ec2spotfleet" and the detail-type "EC2 Spot Fleet Instance Change".
+*/
+
+const (
+	fleetInstanceChangeDetailType     = "EC2 Fleet Instance Change"
+	spotFleetInstanceChangeDetailType = "EC2 Spot Fleet Instance Change"
+	fleetSubTypeTerminationNotified   = "termination_notified"
+	fleetSubTypeTerminated            = "terminated"
+)
+
+// FleetInstanceChangeDetail holds the event details for EC2 Fleet and Spot Fleet instance change events from Amazon EventBridge
+type FleetInstanceChangeDetail struct {
+	InstanceID  string `json:"instance-id"`
+	Description string `json:"description"`
+	SubType     string `json:"sub-type"`
+}
+
+func (m SQSMonitor) fleetInstanceChangeToInterruptionEvent(event EventBridgeEvent, message *types.Message) (monitor.InterruptionEvent, error) {
+	if event.DetailType != fleetInstanceChangeDetailType && event.DetailType != spotFleetInstanceChangeDetailType {
+		log.Debug().Msgf("Ignoring fleet event with detail-type %s", event.DetailType)
+		return monitor.InterruptionEvent{}, m.deleteMessage(message)
+	}
+	fleetDetail := &FleetInstanceChangeDetail{}
+	err := eventparser.ParseDetail(event.Detail, fleetDetail)
+	if err != nil {
+		return monitor.InterruptionEvent{}, err
+	}
+	if fleetDetail.SubType != fleetSubTypeTerminationNotified && fleetDetail.SubType != fleetSubTypeTerminated {
+		log.Debug().Msgf("Ignoring %s event with sub-type %s for instance %s", event.DetailType, fleetDetail.SubType, fleetDetail.InstanceID)
+		return monitor.InterruptionEvent{}, m.deleteMessage(message)
+	}
+
+	nodeName, cluster, err := m.retrieveNodeName(fleetDetail.InstanceID)
+	if err != nil {
+		return monitor.InterruptionEvent{}, err
+	}
+	interruptionEvent := monitor.InterruptionEvent{
+		EventID:     fmt.Sprintf("fleet-instance-change-%x", event.ID),
+		Kind:        SQSTerminateKind,
+		StartTime:   event.getTime(),
+		NodeName:    nodeName,
+		Cluster:     cluster,
+		InstanceID:  fleetDetail.InstanceID,
+		Description: fmt.Sprintf("%s event received. Instance %s was %s at %s \n", event.DetailType, fleetDetail.InstanceID, fleetDetail.SubType, event.getTime()),
+	}
+	interruptionEvent.PostDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+		return m.deleteMessage(message)
+	}
+	interruptionEvent.PreDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+		err := n.TaintSpotItn(interruptionEvent.NodeName, interruptionEvent.EventID)
+		if err != nil {
+			log.Err(err).Msgf("Unable to taint node with taint %s:%s", node.SpotInterruptionTaint, interruptionEvent.EventID)
+		}
+		return nil
+	}
+	return interruptionEvent, nil
+}
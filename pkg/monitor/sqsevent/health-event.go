@@ -0,0 +1,168 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sqsevent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/rs/zerolog/log"
+)
+
+/* Example AWS Health Event:
+{
+	"version": "0",
+	"id": "7bf73129-1428-4cd3-a780-95db273d1602",
+	"detail-type": "AWS Health Event",
+	"source": "aws.health",
+	"account": "123456789012",
+	"time": "2021-06-05T00:00:00Z",
+	"region": "us-east-1",
+	"resources": [
+	  "i-0c594155dd5ff1829"
+	],
+	"detail": {
+	  "eventArn": "arn:aws:health:us-east-1::event/EC2/AWS_EC2_INSTANCE_STORE_DRIVE_PERFORMANCE_DEGRADED/AWS_EC2_INSTANCE_STORE_DRIVE_PERFORMANCE_DEGRADED_4d7a1c3b",
+	  "service": "EC2",
+	  "eventTypeCode": "AWS_EC2_INSTANCE_STORE_DRIVE_PERFORMANCE_DEGRADED",
+	  "eventTypeCategory": "issue",
+	  "startTime": "Sat, 05 Jun 2021 00:00:00 GMT",
+	  "eventDescription": [{
+		"language": "en_US",
+		"latestDescription": "A description of the event"
+	  }],
+	  "affectedEntities": [{
+		"entityValue": "i-0c594155dd5ff1829"
+	  }]
+	}
+}
+*/
+
+const (
+	healthEventDetailType = "AWS Health Event"
+	healthEventEC2Service = "EC2"
+	instanceIDPrefix      = "i-"
+)
+
+// HealthEventDetail holds the event details for AWS Health events from Amazon EventBridge
+type HealthEventDetail struct {
+	EventArn          string                   `json:"eventArn"`
+	Service           string                   `json:"service"`
+	EventTypeCode     string                   `json:"eventTypeCode"`
+	EventTypeCategory string                   `json:"eventTypeCategory"`
+	EventDescription  []HealthEventDescription `json:"eventDescription"`
+	AffectedEntities  []HealthAffectedEntity   `json:"affectedEntities"`
+}
+
+// HealthEventDescription is the description of an AWS Health event in a given language
+type HealthEventDescription struct {
+	Language          string `json:"language"`
+	LatestDescription string `json:"latestDescription"`
+}
+
+// HealthAffectedEntity is a resource affected by an AWS Health event
+type HealthAffectedEntity struct {
+	EntityValue string `json:"entityValue"`
+}
+
+// healthEventToInterruptionEvents returns an interruption event for each instance affected by the AWS Health event.
+// Instances which are no longer running are left out, unless none of the instances is running.
+func (m SQSMonitor) healthEventToInterruptionEvents(event EventBridgeEvent, message *types.Message) ([]monitor.InterruptionEvent, error) {
+	healthDetail := &HealthEventDetail{}
+	err := eventparser.ParseDetail(event.Detail, healthDetail)
+	if err != nil {
+		return nil, err
+	}
+	instanceIDs := healthDetail.affectedInstanceIDs()
+	if event.DetailType != healthEventDetailType || healthDetail.Service != healthEventEC2Service || len(instanceIDs) == 0 {
+		log.Debug().Msgf("Ignoring AWS Health event %s which does not affect an EC2 instance", healthDetail.EventTypeCode)
+		return nil, m.deleteMessage(message)
+	}
+
+	var interruptionEvents []monitor.InterruptionEvent
+	for _, instanceID := range instanceIDs {
+		eventID := fmt.Sprintf("health-event-%x", event.ID)
+		if len(instanceIDs) > 1 {
+			eventID = fmt.Sprintf("%s-%s", eventID, instanceID)
+		}
+		interruptionEvent, err := m.healthEventToInterruptionEvent(event, healthDetail, eventID, instanceID, message)
+		if errors.Is(err, ErrNodeStateNotRunning) && len(instanceIDs) > 1 {
+			log.Warn().Err(err).Msgf("Not handling AWS Health event %s for an already terminated instance", healthDetail.EventTypeCode)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		interruptionEvents = append(interruptionEvents, interruptionEvent)
+	}
+	if len(interruptionEvents) == 0 {
+		return nil, fmt.Errorf("AWS Health event %s affects no running instance: %w", healthDetail.EventTypeCode, ErrNodeStateNotRunning)
+	}
+	return interruptionEvents, nil
+}
+
+func (m SQSMonitor) healthEventToInterruptionEvent(event EventBridgeEvent, healthDetail *HealthEventDetail, eventID string, instanceID string, message *types.Message) (monitor.InterruptionEvent, error) {
+	nodeName, cluster, err := m.retrieveNodeName(instanceID)
+	if err != nil {
+		return monitor.InterruptionEvent{}, err
+	}
+	interruptionEvent := monitor.InterruptionEvent{
+		EventID:     eventID,
+		Kind:        SQSTerminateKind,
+		StartTime:   event.getTime(),
+		NodeName:    nodeName,
+		Cluster:     cluster,
+		InstanceID:  instanceID,
+		Code:        healthDetail.EventTypeCode,
+		Description: fmt.Sprintf("AWS Health event %s received for instance %s at %s: %s \n", healthDetail.EventTypeCode, instanceID, event.getTime(), healthDetail.latestDescription()),
+	}
+	interruptionEvent.PostDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+		return m.deleteMessage(message)
+	}
+	interruptionEvent.PreDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+		err := n.TaintScheduledMaintenance(interruptionEvent.NodeName, interruptionEvent.EventID)
+		if err != nil {
+			log.Err(err).Msgf("Unable to taint node with taint %s:%s", node.ScheduledMaintenanceTaint, interruptionEvent.EventID)
+		}
+		return nil
+	}
+	return interruptionEvent, nil
+}
+
+func (d HealthEventDetail) affectedInstanceIDs() []string {
+	var instanceIDs []string
+	for _, entity := range d.AffectedEntities {
+		if strings.HasPrefix(entity.EntityValue, instanceIDPrefix) {
+			instanceIDs = append(instanceIDs, entity.EntityValue)
+		}
+	}
+	return instanceIDs
+}
+
+func (d HealthEventDetail) latestDescription() string {
+	for _, description := range d.EventDescription {
+		if description.Language == "en_US" {
+			return description.LatestDescription
+		}
+	}
+	if len(d.EventDescription) > 0 {
+		return d.EventDescription[0].LatestDescription
+	}
+	return ""
+}
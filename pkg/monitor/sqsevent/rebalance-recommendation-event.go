@@ -14,12 +14,12 @@
 package sqsevent
 
 import (
-	"encoding/json"
 	"fmt"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/rs/zerolog/log"
 )
 
@@ -46,14 +46,14 @@ type RebalanceRecommendationDetail struct {
 	InstanceID string `json:"instance-id"`
 }
 
-func (m SQSMonitor) rebalanceRecommendationToInterruptionEvent(event EventBridgeEvent, message *sqs.Message) (monitor.InterruptionEvent, error) {
+func (m SQSMonitor) rebalanceRecommendationToInterruptionEvent(event EventBridgeEvent, message *types.Message) (monitor.InterruptionEvent, error) {
 	rebalanceRecDetail := &RebalanceRecommendationDetail{}
-	err := json.Unmarshal(event.Detail, rebalanceRecDetail)
+	err := eventparser.ParseDetail(event.Detail, rebalanceRecDetail)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
 
-	nodeName, err := m.retrieveNodeName(rebalanceRecDetail.InstanceID)
+	nodeName, cluster, err := m.retrieveNodeName(rebalanceRecDetail.InstanceID)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
@@ -63,12 +63,14 @@ func (m SQSMonitor) rebalanceRecommendationToInterruptionEvent(event EventBridge
 		Kind:                 SQSTerminateKind,
 		AutoScalingGroupName: asgName,
 		StartTime:            event.getTime(),
+		DrainTime:            monitor.DrainTimeBefore(event.getTime().Add(monitor.SpotInterruptionWindow), m.DrainLeadTime),
 		NodeName:             nodeName,
+		Cluster:              cluster,
 		InstanceID:           rebalanceRecDetail.InstanceID,
 		Description:          fmt.Sprintf("Rebalance recommendation event received. Instance %s will be cordoned at %s \n", rebalanceRecDetail.InstanceID, event.getTime()),
 	}
 	interruptionEvent.PostDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
-		errs := m.deleteMessages([]*sqs.Message{message})
+		errs := m.deleteMessages([]*types.Message{message})
 		if errs != nil {
 			return errs[0]
 		}
@@ -14,12 +14,12 @@
 package sqsevent
 
 import (
-	"encoding/json"
 	"fmt"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	"github.com/rs/zerolog/log"
 )
 
@@ -48,14 +48,14 @@ type SpotInterruptionDetail struct {
 	InstanceAction string `json:"instance-action"`
 }
 
-func (m SQSMonitor) spotITNTerminationToInterruptionEvent(event EventBridgeEvent, message *sqs.Message) (monitor.InterruptionEvent, error) {
+func (m SQSMonitor) spotITNTerminationToInterruptionEvent(event EventBridgeEvent, message *types.Message) (monitor.InterruptionEvent, error) {
 	spotInterruptionDetail := &SpotInterruptionDetail{}
-	err := json.Unmarshal(event.Detail, spotInterruptionDetail)
+	err := eventparser.ParseDetail(event.Detail, spotInterruptionDetail)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
 
-	nodeName, err := m.retrieveNodeName(spotInterruptionDetail.InstanceID)
+	nodeName, cluster, err := m.retrieveNodeName(spotInterruptionDetail.InstanceID)
 	if err != nil {
 		return monitor.InterruptionEvent{}, err
 	}
@@ -65,12 +65,19 @@ func (m SQSMonitor) spotITNTerminationToInterruptionEvent(event EventBridgeEvent
 		Kind:                 SQSTerminateKind,
 		AutoScalingGroupName: asgName,
 		StartTime:            event.getTime(),
+		DrainTime:            monitor.DrainTimeBefore(event.getTime().Add(monitor.SpotInterruptionWindow), m.DrainLeadTime),
 		NodeName:             nodeName,
+		Cluster:              cluster,
 		InstanceID:           spotInterruptionDetail.InstanceID,
+		InstanceAction:       spotInterruptionDetail.InstanceAction,
+		Code:                 spotInterruptionDetail.InstanceAction,
 		Description:          fmt.Sprintf("Spot Interruption event received. Instance %s will be interrupted at %s \n", spotInterruptionDetail.InstanceID, event.getTime()),
 	}
+	if interruptionEvent.IsStopOrHibernate() {
+		interruptionEvent.Description = fmt.Sprintf("Spot Interruption event received. Instance %s will be interrupted with action %s at %s \n", spotInterruptionDetail.InstanceID, spotInterruptionDetail.InstanceAction, event.getTime())
+	}
 	interruptionEvent.PostDrainTask = func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
-		errs := m.deleteMessages([]*sqs.Message{message})
+		errs := m.deleteMessages([]*types.Message{message})
 		if errs != nil {
 			return errs[0]
 		}
@@ -81,7 +88,32 @@ func (m SQSMonitor) spotITNTerminationToInterruptionEvent(event EventBridgeEvent
 		if err != nil {
 			log.Err(err).Msgf("Unable to taint node with taint %s:%s", node.SpotInterruptionTaint, interruptionEvent.EventID)
 		}
+		if interruptionEvent.IsStopOrHibernate() {
+			return markForUncordonAfterStart(interruptionEvent, n)
+		}
 		return nil
 	}
 	return interruptionEvent, nil
 }
+
+// markForUncordonAfterStart marks the node of a stopped or hibernated instance to be uncordoned once the EC2 state change
+// event of the instance running again is received
+func markForUncordonAfterStart(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+	// if the node is already marked as unschedulable, then it should stay cordoned when the instance comes back
+	unschedulable, err := n.IsUnschedulable(interruptionEvent.NodeName)
+	if err != nil {
+		return fmt.Errorf("Encountered an error while checking if the node is unschedulable. Not setting an uncordon label: %w", err)
+	}
+	if unschedulable {
+		return nil
+	}
+	err = n.MarkWithEventID(interruptionEvent.NodeName, interruptionEvent.EventID)
+	if err != nil {
+		return fmt.Errorf("Unable to mark node with event ID: %w", err)
+	}
+	err = n.MarkForUncordonAfterReboot(interruptionEvent.NodeName)
+	if err != nil {
+		return fmt.Errorf("Unable to mark the node for uncordon: %w", err)
+	}
+	return n.MarkInstanceStopping(interruptionEvent.NodeName, interruptionEvent.InstanceID)
+}
@@ -14,19 +14,25 @@
 package sqsevent
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
+	"github.com/aws/aws-node-termination-handler/pkg/logging"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
-	"github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asgtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
 	"github.com/rs/zerolog/log"
 )
 
@@ -38,16 +44,53 @@ const (
 // ErrNodeStateNotRunning forwards condition that the instance is terminated thus metadata missing
 var ErrNodeStateNotRunning = errors.New("node metadata unavailable")
 
+// ErrClusterNotServed is returned for events of instances of clusters the multi-cluster queue processor does not serve
+var ErrClusterNotServed = errors.New("cluster not served")
+
+// ErrUnsupportedEvent is returned for events which are not valid Amazon EventBridge events from a supported source
+var ErrUnsupportedEvent = errors.New("unsupported event")
+
+// pollLog samples the message logged on every poll of the queue
+var pollLog logging.Sampler
+
+// SQSAPI is the part of the SQS API the monitor uses
+type SQSAPI interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+}
+
+// ASGAPI is the part of the Auto Scaling API the monitor uses
+type ASGAPI interface {
+	CompleteLifecycleAction(ctx context.Context, params *autoscaling.CompleteLifecycleActionInput, optFns ...func(*autoscaling.Options)) (*autoscaling.CompleteLifecycleActionOutput, error)
+	DescribeAutoScalingInstances(ctx context.Context, params *autoscaling.DescribeAutoScalingInstancesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingInstancesOutput, error)
+	DescribeTags(ctx context.Context, params *autoscaling.DescribeTagsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeTagsOutput, error)
+}
+
+// EC2API is the part of the EC2 API the monitor uses
+type EC2API interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+}
+
 // SQSMonitor is a struct definition that knows how to process events from Amazon EventBridge
 type SQSMonitor struct {
 	InterruptionChan chan<- monitor.InterruptionEvent
 	CancelChan       chan<- monitor.InterruptionEvent
 	QueueURL         string
-	SQS              sqsiface.SQSAPI
-	ASG              autoscalingiface.AutoScalingAPI
-	EC2              ec2iface.EC2API
+	SQS              SQSAPI
+	ASG              ASGAPI
+	EC2              EC2API
 	CheckIfManaged   bool
 	ManagedAsgTag    string
+	// Node is used to match instances to kubernetes nodes. If nil, the instance's private DNS name is used as the node name.
+	Node *node.Node
+	// ClusterTagKey, if set, is the EC2 tag naming the cluster of an instance. Instances are matched to the nodes of their
+	// cluster in Clusters, and the events of instances of other clusters are ignored.
+	ClusterTagKey string
+	Clusters      map[string]*node.Node
+	// DrainLeadTime, if set, delays the drain of spot interruptions and rebalance recommendations until this long before
+	// the end of the Spot interruption window following the event
+	DrainLeadTime time.Duration
 }
 
 // Kind denotes the kind of event that is processed
@@ -57,34 +100,49 @@ func (m SQSMonitor) Kind() string {
 
 // Monitor continuously monitors SQS for events and sends interruption events to the passed in channel
 func (m SQSMonitor) Monitor() error {
-	log.Debug().Msg("Checking for queue messages")
+	pollLog.Debug().Msg("Checking for queue messages")
 	messages, err := m.receiveQueueMessages(m.QueueURL)
 	if err != nil {
 		return err
 	}
 
 	failedEvents := 0
-	for _, message := range messages {
-		interruptionEvent, err := m.processSQSMessage(message)
+	for i := range messages {
+		message := &messages[i]
+		interruptionEvents, err := m.processSQSMessage(message)
 		switch {
 		case errors.Is(err, ErrNodeStateNotRunning):
 			// If the node is no longer running, just log and delete the message.  If message deletion fails, count it as an error.
 			log.Warn().Err(err).Msg("dropping event for an already terminated node")
-			errs := m.deleteMessages([]*sqs.Message{message})
+			errs := m.deleteMessages([]*types.Message{message})
 			if len(errs) > 0 {
 				log.Err(errs[0]).Msg("error deleting event for already terminated node")
 				failedEvents++
 			}
 
+		case errors.Is(err, ErrClusterNotServed):
+			// Nothing serves the event if it stays in the queue, so it is deleted like the events of terminated nodes
+			log.Debug().Err(err).Msg("dropping event for an instance of a cluster which is not served")
+			errs := m.deleteMessages([]*types.Message{message})
+			if len(errs) > 0 {
+				log.Err(errs[0]).Msg("error deleting event for an instance of a cluster which is not served")
+				failedEvents++
+			}
+
 		case err != nil:
 			// Log errors and record as failed events
 			log.Err(err).Msg("ignoring event due to error")
 			failedEvents++
 
-		case err == nil && interruptionEvent != nil && interruptionEvent.Kind == SQSTerminateKind:
-			// Successfully processed SQS message into a SQSTerminateKind interruption event
-			log.Debug().Msgf("Sending %s interruption event to the interruption channel", SQSTerminateKind)
-			m.InterruptionChan <- *interruptionEvent
+		default:
+			for _, interruptionEvent := range interruptionEvents {
+				if interruptionEvent.Kind != SQSTerminateKind {
+					continue
+				}
+				// Successfully processed SQS message into a SQSTerminateKind interruption event
+				log.Debug().Msgf("Sending %s interruption event to the interruption channel", SQSTerminateKind)
+				m.InterruptionChan <- interruptionEvent
+			}
 		}
 	}
 
@@ -96,14 +154,28 @@ func (m SQSMonitor) Monitor() error {
 }
 
 // processSQSMessage checks sqs for new messages and returns interruption events
-func (m SQSMonitor) processSQSMessage(message *sqs.Message) (*monitor.InterruptionEvent, error) {
-	event := EventBridgeEvent{}
-	err := json.Unmarshal([]byte(*message.Body), &event)
+func (m SQSMonitor) processSQSMessage(message *types.Message) ([]monitor.InterruptionEvent, error) {
+	return m.processEvent([]byte(*message.Body), message)
+}
+
+// ProcessEvents returns the interruption events for an Amazon EventBridge event which was not received from the queue,
+// e.g. pushed to the handler. No events are returned if the event does not need to be handled. Events affecting several
+// instances, such as AWS Health events, have an interruption event for each instance.
+func (m SQSMonitor) ProcessEvents(body []byte) ([]monitor.InterruptionEvent, error) {
+	return m.processEvent(body, nil)
+}
+
+// processEvent returns the interruption events for an Amazon EventBridge event, message is nil if it was not received
+// from the queue. The interruption events of a message delete it together, once all of them were handled.
+func (m SQSMonitor) processEvent(body []byte, message *types.Message) ([]monitor.InterruptionEvent, error) {
+	parsed, err := eventparser.ParseEventBridgeEvent(body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedEvent, err)
 	}
+	event := EventBridgeEvent(parsed)
 
 	interruptionEvent := monitor.InterruptionEvent{}
+	var interruptionEvents []monitor.InterruptionEvent
 
 	switch event.Source {
 	case "aws.autoscaling":
@@ -122,41 +194,71 @@ func (m SQSMonitor) processSQSMessage(message *sqs.Message) (*monitor.Interrupti
 		if err != nil {
 			return nil, err
 		}
+	case "aws.health":
+		interruptionEvents, err = m.healthEventToInterruptionEvents(event, message)
+		if err != nil {
+			return nil, err
+		}
+	case "aws.ec2fleet", "aws.ec2spotfleet":
+		interruptionEvent, err = m.fleetInstanceChangeToInterruptionEvent(event, message)
+		if err != nil {
+			return nil, err
+		}
 	default:
-		return nil, fmt.Errorf("Event source (%s) is not supported", event.Source)
+		return nil, fmt.Errorf("%w: Event source (%s) is not supported", ErrUnsupportedEvent, event.Source)
 	}
 
-	// Bail if empty event is returned after parsing
-	if interruptionEvent.EventID == "" {
-		return nil, nil
+	if event.Source != "aws.health" {
+		interruptionEvents = []monitor.InterruptionEvent{interruptionEvent}
 	}
 
-	if m.CheckIfManaged {
-		isManaged, err := m.isInstanceManaged(interruptionEvent.InstanceID)
-		if err != nil {
-			return &interruptionEvent, err
+	var handledEvents []monitor.InterruptionEvent
+	var skipErr error
+	for _, interruptionEvent := range interruptionEvents {
+		// Skip empty events returned after parsing
+		if interruptionEvent.EventID == "" {
+			continue
 		}
-		if !isManaged {
-			return nil, nil
+		interruptionEvent.TaskToken = event.TaskToken
+
+		if _, ok := m.Clusters[interruptionEvent.Cluster]; m.ClusterTagKey != "" && !ok {
+			skipErr = fmt.Errorf("instance %s of cluster %q: %w", interruptionEvent.InstanceID, interruptionEvent.Cluster, ErrClusterNotServed)
+			continue
 		}
-	}
 
-	return &interruptionEvent, err
+		if m.CheckIfManaged {
+			isManaged, err := m.isInstanceManaged(interruptionEvent.InstanceID)
+			if err != nil {
+				return nil, err
+			}
+			if !isManaged {
+				continue
+			}
+		}
+		handledEvents = append(handledEvents, interruptionEvent)
+	}
+	if len(handledEvents) == 0 {
+		return nil, skipErr
+	}
+	if message != nil && len(handledEvents) > 1 {
+		monitor.CompleteTogether(handledEvents)
+	}
+	return handledEvents, nil
 }
 
 // receiveQueueMessages checks the configured SQS queue for new messages
-func (m SQSMonitor) receiveQueueMessages(qURL string) ([]*sqs.Message, error) {
-	result, err := m.SQS.ReceiveMessage(&sqs.ReceiveMessageInput{
-		AttributeNames: []*string{
-			aws.String(sqs.MessageSystemAttributeNameSentTimestamp),
+func (m SQSMonitor) receiveQueueMessages(qURL string) ([]types.Message, error) {
+	result, err := m.SQS.ReceiveMessage(context.TODO(), &sqs.ReceiveMessageInput{
+		AttributeNames: []types.QueueAttributeName{
+			types.QueueAttributeName(types.MessageSystemAttributeNameSentTimestamp),
 		},
-		MessageAttributeNames: []*string{
-			aws.String(sqs.QueueAttributeNameAll),
+		MessageAttributeNames: []string{
+			string(types.QueueAttributeNameAll),
 		},
 		QueueUrl:            &qURL,
-		MaxNumberOfMessages: aws.Int64(5),
-		VisibilityTimeout:   aws.Int64(20), // 20 seconds
-		WaitTimeSeconds:     aws.Int64(20), // Max long polling
+		MaxNumberOfMessages: 5,
+		VisibilityTimeout:   20, // 20 seconds
+		WaitTimeSeconds:     20, // Max long polling
 	})
 
 	if err != nil {
@@ -166,60 +268,108 @@ func (m SQSMonitor) receiveQueueMessages(qURL string) ([]*sqs.Message, error) {
 	return result.Messages, nil
 }
 
+// VisibleMessages returns the approximate number of messages waiting to be received from the queue
+func (m SQSMonitor) VisibleMessages() (int, error) {
+	result, err := m.SQS.GetQueueAttributes(context.TODO(), &sqs.GetQueueAttributesInput{
+		QueueUrl:       &m.QueueURL,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameApproximateNumberOfMessages},
+	})
+	if err != nil {
+		return 0, err
+	}
+	visible, err := strconv.Atoi(result.Attributes[string(types.QueueAttributeNameApproximateNumberOfMessages)])
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse the approximate number of messages of the queue: %w", err)
+	}
+	return visible, nil
+}
+
 // deleteMessages deletes messages from the configured SQS queue
-func (m SQSMonitor) deleteMessages(messages []*sqs.Message) []error {
+func (m SQSMonitor) deleteMessages(messages []*types.Message) []error {
 	var errs []error
 	for _, message := range messages {
-		_, err := m.SQS.DeleteMessage(&sqs.DeleteMessageInput{
+		// events which were not received from the queue have no message to delete
+		if message == nil {
+			continue
+		}
+		_, err := m.SQS.DeleteMessage(context.TODO(), &sqs.DeleteMessageInput{
 			ReceiptHandle: message.ReceiptHandle,
 			QueueUrl:      &m.QueueURL,
 		})
 		if err != nil {
 			errs = append(errs, err)
 		}
-		log.Debug().Msgf("SQS Deleted Message: %s", message)
+		log.Debug().Msgf("SQS Deleted Message: %s", aws.ToString(message.MessageId))
 	}
 	return errs
 }
 
-// retrieveNodeName queries the EC2 API to determine the private DNS name for the instanceID specified
-func (m SQSMonitor) retrieveNodeName(instanceID string) (string, error) {
-	result, err := m.EC2.DescribeInstances(&ec2.DescribeInstancesInput{
-		InstanceIds: []*string{
-			aws.String(instanceID),
-		},
+// deleteMessage deletes a single message from the configured SQS queue
+func (m SQSMonitor) deleteMessage(message *types.Message) error {
+	errs := m.deleteMessages([]*types.Message{message})
+	if errs != nil {
+		return errs[0]
+	}
+	return nil
+}
+
+// retrieveNodeName queries the EC2 API to determine the kubernetes node name and, if ClusterTagKey is set, the cluster
+// for the instanceID specified
+func (m SQSMonitor) retrieveNodeName(instanceID string) (string, string, error) {
+	result, err := m.EC2.DescribeInstances(context.TODO(), &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
 	})
 	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidInstanceID.NotFound" {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidInstanceID.NotFound" {
 			log.Warn().Msgf("No instance found with instance-id %s", instanceID)
-			return "", ErrNodeStateNotRunning
+			return "", "", ErrNodeStateNotRunning
 		}
-		return "", err
+		return "", "", err
 	}
 	if len(result.Reservations) == 0 || len(result.Reservations[0].Instances) == 0 {
 		log.Warn().Msgf("No instance found with instance-id %s", instanceID)
-		return "", ErrNodeStateNotRunning
+		return "", "", ErrNodeStateNotRunning
 	}
 
 	instance := result.Reservations[0].Instances[0]
-	nodeName := *instance.PrivateDnsName
+	nodeName := aws.ToString(instance.PrivateDnsName)
 	log.Debug().Msgf("Got nodename from private ip %s", nodeName)
-	instanceJSON, _ := json.MarshalIndent(*instance, " ", "    ")
+	instanceJSON, _ := json.MarshalIndent(instance, " ", "    ")
 	log.Debug().Msgf("Got instance data from ec2 describe call: %s", instanceJSON)
 
 	if nodeName == "" {
 		state := "unknown"
 		// safe access instance.State potentially being nil
 		if instance.State != nil {
-			state = *instance.State.Name
+			state = string(instance.State.Name)
 		}
 		// anything except running might not contain PrivateDnsName
-		if state != ec2.InstanceStateNameRunning {
-			return "", fmt.Errorf("node: '%s' in state '%s': %w", instanceID, state, ErrNodeStateNotRunning)
+		if state != string(ec2types.InstanceStateNameRunning) {
+			return "", "", fmt.Errorf("node: '%s' in state '%s': %w", instanceID, state, ErrNodeStateNotRunning)
+		}
+		return "", "", fmt.Errorf("unable to retrieve PrivateDnsName name for '%s' in state '%s'", instanceID, state)
+	}
+	nodeResolver := m.Node
+	cluster := ""
+	if m.ClusterTagKey != "" {
+		cluster = tagValue(instance.Tags, m.ClusterTagKey)
+		// the events of unknown clusters are ignored, so their node names are not resolved
+		nodeResolver = m.Clusters[cluster]
+	}
+	if nodeResolver != nil {
+		nodeName, err = nodeResolver.FetchNodeNameByInstance(instanceID, nodeName, aws.ToString(instance.PrivateIpAddress))
+	}
+	return nodeName, cluster, err
+}
+
+func tagValue(tags []ec2types.Tag, key string) string {
+	for _, tag := range tags {
+		if aws.ToString(tag.Key) == key {
+			return aws.ToString(tag.Value)
 		}
-		return "", fmt.Errorf("unable to retrieve PrivateDnsName name for '%s' in state '%s'", instanceID, state)
 	}
-	return nodeName, nil
+	return ""
 }
 
 // isInstanceManaged returns whether the instance specified should be managed by node termination handler
@@ -231,22 +381,25 @@ func (m SQSMonitor) isInstanceManaged(instanceID string) (bool, error) {
 	if asgName == "" {
 		return false, err
 	}
-	asgFilter := autoscaling.Filter{Name: aws.String("auto-scaling-group"), Values: []*string{aws.String(asgName)}}
+	asgFilter := asgtypes.Filter{Name: aws.String("auto-scaling-group"), Values: []string{asgName}}
 	asgDescribeTagsInput := autoscaling.DescribeTagsInput{
-		Filters: []*autoscaling.Filter{&asgFilter},
+		Filters: []asgtypes.Filter{asgFilter},
 	}
 	isManaged := false
-	err = m.ASG.DescribeTagsPages(&asgDescribeTagsInput, func(resp *autoscaling.DescribeTagsOutput, next bool) bool {
+	paginator := autoscaling.NewDescribeTagsPaginator(m.ASG, &asgDescribeTagsInput)
+	for !isManaged && paginator.HasMorePages() {
+		var resp *autoscaling.DescribeTagsOutput
+		resp, err = paginator.NextPage(context.TODO())
+		if err != nil {
+			break
+		}
 		for _, tag := range resp.Tags {
-			if *tag.Key == m.ManagedAsgTag {
+			if aws.ToString(tag.Key) == m.ManagedAsgTag {
 				isManaged = true
-				// breaks paging loop
-				return false
+				break
 			}
 		}
-		// continue paging loop
-		return true
-	})
+	}
 
 	if !isManaged {
 		log.Debug().
@@ -259,10 +412,10 @@ func (m SQSMonitor) isInstanceManaged(instanceID string) (bool, error) {
 // retrieveAutoScalingGroupName returns the autoscaling group name for a given instanceID
 func (m SQSMonitor) retrieveAutoScalingGroupName(instanceID string) (string, error) {
 	asgDescribeInstanceInput := autoscaling.DescribeAutoScalingInstancesInput{
-		InstanceIds: []*string{&instanceID},
-		MaxRecords:  aws.Int64(50),
+		InstanceIds: []string{instanceID},
+		MaxRecords:  aws.Int32(50),
 	}
-	asgs, err := m.ASG.DescribeAutoScalingInstances(&asgDescribeInstanceInput)
+	asgs, err := m.ASG.DescribeAutoScalingInstances(context.TODO(), &asgDescribeInstanceInput)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,52 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+// The fuzz target needs Go 1.18, run it with:
+// $ go test -run '^$' -fuzz FuzzProcessEvent ./pkg/monitor/sqsevent
+
+package sqsevent_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func FuzzProcessEvent(f *testing.F) {
+	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent, rebalanceRecommendationEvent} {
+		body, err := json.Marshal(event)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(body)
+	}
+	f.Add([]byte(`{"source": "aws.health", "detail-type": "AWS Health Event", "detail": {"service": "EC2", "affectedEntities": [{}]}}`))
+	f.Add([]byte(`{"source": "aws.ec2fleet", "detail-type": "EC2 Fleet Instance Change", "detail": {"sub-type": "terminated"}}`))
+	sqsMonitor := sqsevent.SQSMonitor{
+		EC2: h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG: mockIsManagedTrue(nil),
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		results, err := sqsMonitor.ProcessEvents(body)
+		for _, result := range results {
+			if err == nil && result.EventID == "" {
+				t.Errorf("ProcessEvents returned an event without an ID for %q", body)
+			}
+		}
+	})
+}
@@ -19,8 +19,9 @@ import (
 	"time"
 
 	h "github.com/aws/aws-node-termination-handler/pkg/test"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asgtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
 )
 
 func TestGetTime_Success(t *testing.T) {
@@ -42,12 +43,12 @@ func TestIsInstanceManaged(t *testing.T) {
 	asgName := "test-asg"
 	asgMock := h.MockedASG{
 		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
-			AutoScalingInstances: []*autoscaling.InstanceDetails{
+			AutoScalingInstances: []asgtypes.AutoScalingInstanceDetails{
 				{AutoScalingGroupName: &asgName},
 			},
 		},
-		DescribeTagsPagesResp: autoscaling.DescribeTagsOutput{
-			Tags: []*autoscaling.TagDescription{
+		DescribeTagsResp: autoscaling.DescribeTagsOutput{
+			Tags: []asgtypes.TagDescription{
 				{Key: aws.String("aws-node-termination-handler/managed")},
 			},
 		},
@@ -65,7 +66,7 @@ func TestIsInstanceManaged(t *testing.T) {
 func TestIsInstanceManaged_NotInASG(t *testing.T) {
 	asgMock := h.MockedASG{
 		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
-			AutoScalingInstances: []*autoscaling.InstanceDetails{},
+			AutoScalingInstances: []asgtypes.AutoScalingInstanceDetails{},
 		},
 	}
 	monitor := SQSMonitor{ASG: asgMock}
@@ -78,12 +79,12 @@ func TestIsInstanceManaged_ASGNotManaged(t *testing.T) {
 	asgName := "test-asg"
 	asgMock := h.MockedASG{
 		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
-			AutoScalingInstances: []*autoscaling.InstanceDetails{
+			AutoScalingInstances: []asgtypes.AutoScalingInstanceDetails{
 				{AutoScalingGroupName: &asgName},
 			},
 		},
-		DescribeTagsPagesResp: autoscaling.DescribeTagsOutput{
-			Tags: []*autoscaling.TagDescription{},
+		DescribeTagsResp: autoscaling.DescribeTagsOutput{
+			Tags: []asgtypes.TagDescription{},
 		},
 	}
 	monitor := SQSMonitor{ASG: asgMock}
@@ -105,11 +106,11 @@ func TestIsInstanceManaged_TagErr(t *testing.T) {
 	asgName := "test-asg"
 	asgMock := h.MockedASG{
 		DescribeAutoScalingInstancesResp: autoscaling.DescribeAutoScalingInstancesOutput{
-			AutoScalingInstances: []*autoscaling.InstanceDetails{
+			AutoScalingInstances: []asgtypes.AutoScalingInstanceDetails{
 				{AutoScalingGroupName: &asgName},
 			},
 		},
-		DescribeTagsPagesErr: fmt.Errorf("error"),
+		DescribeTagsErr: fmt.Errorf("error"),
 	}
 	monitor := SQSMonitor{ASG: asgMock}
 	_, err := monitor.isInstanceManaged("i-0123456789")
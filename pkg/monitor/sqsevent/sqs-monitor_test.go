@@ -15,19 +15,35 @@ package sqsevent_test
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/eventparser"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
 	"github.com/aws/aws-node-termination-handler/pkg/node"
 	h "github.com/aws/aws-node-termination-handler/pkg/test"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asgtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubectl/pkg/drain"
 )
 
 var spotItnEvent = sqsevent.EventBridgeEvent{
@@ -87,14 +103,28 @@ func TestKind(t *testing.T) {
 	h.Assert(t, sqsevent.SQSMonitor{}.Kind() == sqsevent.SQSTerminateKind, "SQSMonitor kind should return the kind constant for the event")
 }
 
+func TestVisibleMessages(t *testing.T) {
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:      h.MockedSQS{GetQueueAttributesResp: sqs.GetQueueAttributesOutput{Attributes: map[string]string{"ApproximateNumberOfMessages": "42"}}},
+		QueueURL: "https://test-queue",
+	}
+	visible, err := sqsMonitor.VisibleMessages()
+	h.Ok(t, err)
+	h.Equals(t, 42, visible)
+
+	sqsMonitor.SQS = h.MockedSQS{GetQueueAttributesErr: fmt.Errorf("throttled")}
+	_, err = sqsMonitor.VisibleMessages()
+	h.Nok(t, err)
+}
+
 func TestMonitor_Success(t *testing.T) {
 	spotItnEventNoTime := spotItnEvent
 	spotItnEventNoTime.Time = ""
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent, spotItnEventNoTime, rebalanceRecommendationEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -134,13 +164,335 @@ func TestMonitor_Success(t *testing.T) {
 	}
 }
 
+func TestMonitor_DrainLeadTime(t *testing.T) {
+	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, rebalanceRecommendationEvent} {
+		msg, err := getSQSMessageFromEvent(event)
+		h.Ok(t, err)
+		drainChan := make(chan monitor.InterruptionEvent, 1)
+		sqsMonitor := sqsevent.SQSMonitor{
+			SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+			EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+			ASG:              mockIsManagedTrue(nil),
+			QueueURL:         "https://test-queue",
+			InterruptionChan: drainChan,
+			DrainLeadTime:    90 * time.Second,
+		}
+		err = sqsMonitor.Monitor()
+		h.Ok(t, err)
+		result := <-drainChan
+		h.Equals(t, result.StartTime.Add(30*time.Second), result.DrainTime)
+	}
+}
+
+func TestMonitor_TaskToken(t *testing.T) {
+	taskEvent := spotItnEvent
+	taskEvent.TaskToken = "AQB8AAAAKgAAAAMAAAAAAAAAAT"
+	msg, err := getSQSMessageFromEvent(taskEvent)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+		EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG:              mockIsManagedTrue(nil),
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+	}
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Equals(t, taskEvent.TaskToken, result.TaskToken)
+}
+
+func TestProcessEvent(t *testing.T) {
+	body, err := json.Marshal(spotItnEvent)
+	h.Ok(t, err)
+	sqsMonitor := sqsevent.SQSMonitor{
+		EC2: h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG: mockIsManagedTrue(nil),
+	}
+	results, err := sqsMonitor.ProcessEvents(body)
+	h.Ok(t, err)
+	h.Equals(t, 1, len(results))
+	h.Equals(t, sqsevent.SQSTerminateKind, results[0].Kind)
+	h.Equals(t, "ip-10-0-0-157.us-east-2.compute.internal", results[0].NodeName)
+	// there is no queue message to delete once the event was handled
+	h.Ok(t, results[0].PostDrainTask(results[0], node.Node{}))
+
+	_, err = sqsMonitor.ProcessEvents([]byte(`{"source": "aws.unknown"}`))
+	h.Assert(t, errors.Is(err, sqsevent.ErrUnsupportedEvent), "Expected unsupported event sources to be rejected")
+	_, err = sqsMonitor.ProcessEvents([]byte(`not json`))
+	h.Assert(t, errors.Is(err, sqsevent.ErrUnsupportedEvent), "Expected invalid events to be rejected")
+}
+
+func TestProcessEvent_MalformedDetail(t *testing.T) {
+	sqsMonitor := sqsevent.SQSMonitor{
+		EC2: h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG: mockIsManagedTrue(nil),
+	}
+	// a detail encoded as a string, e.g. by an input transformer, is decoded
+	event := spotItnEvent
+	event.Detail = json.RawMessage(strconv.Quote(string(spotItnEvent.Detail)))
+	body, err := json.Marshal(event)
+	h.Ok(t, err)
+	results, err := sqsMonitor.ProcessEvents(body)
+	h.Ok(t, err)
+	h.Equals(t, "i-0b662ef9931388ba0", results[0].InstanceID)
+
+	for _, detail := range []string{"null", `"truncated {"`, `[]`} {
+		event.Detail = json.RawMessage(detail)
+		body, err := json.Marshal(event)
+		h.Ok(t, err)
+		_, err = sqsMonitor.ProcessEvents(body)
+		h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected the detail %s to be rejected as malformed, got %v", detail, err)
+	}
+	_, err = sqsMonitor.ProcessEvents([]byte(`{"source": "aws.ec2", "detail-type": "EC2 Spot Instance Interruption Warning"}`))
+	h.Assert(t, errors.Is(err, eventparser.ErrMalformed), "Expected a missing detail to be rejected as malformed, got %v", err)
+}
+
+func TestMonitor_NodeResolvedByProviderID(t *testing.T) {
+	msg, err := getSQSMessageFromEvent(spotItnEvent)
+	h.Ok(t, err)
+	sqsMock := h.MockedSQS{
+		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}},
+	}
+	ec2Mock := h.MockedEC2{
+		DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal"),
+	}
+	client := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom-node-name"},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1b/i-0b662ef9931388ba0"},
+	})
+	tNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client}, uptime.Uptime)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              sqsMock,
+		EC2:              ec2Mock,
+		ASG:              mockIsManagedTrue(nil),
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+		Node:             tNode,
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+
+	select {
+	case result := <-drainChan:
+		h.Equals(t, "custom-node-name", result.NodeName)
+	default:
+		h.Ok(t, fmt.Errorf("Expected an event to be generated"))
+	}
+}
+
+func TestMonitor_StoppedSpotInstanceUncordonedOnceRunning(t *testing.T) {
+	stopEvent := spotItnEvent
+	stopEvent.Detail = []byte(`{
+		"instance-id": "i-0b662ef9931388ba0",
+		"instance-action": "stop"
+	}`)
+	msg, err := getSQSMessageFromEvent(stopEvent)
+	h.Ok(t, err)
+	client := h.NewFakeClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "custom-node-name"},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1b/i-0b662ef9931388ba0"},
+	})
+	tNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client}, uptime.Uptime)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+		EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG:              mockIsManagedTrue(nil),
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+		Node:             tNode,
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Ok(t, result.PreDrainTask(result, *tNode))
+	h.Ok(t, tNode.Cordon("custom-node-name"))
+
+	runningEvent := sqsevent.EventBridgeEvent{
+		Version:    "0",
+		ID:         "7bf73129-1428-4cd3-a780-95db273d1602",
+		DetailType: "EC2 Instance State-change Notification",
+		Source:     "aws.ec2",
+		Time:       time.Now().Add(time.Minute).UTC().Format(time.RFC3339),
+		Detail: []byte(`{
+			"instance-id": "i-0b662ef9931388ba0",
+			"state": "running"
+		}`),
+	}
+	msg, err = getSQSMessageFromEvent(runningEvent)
+	h.Ok(t, err)
+	sqsMonitor.SQS = h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}}
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(drainChan))
+
+	unschedulable, err := tNode.IsUnschedulable("custom-node-name")
+	h.Ok(t, err)
+	h.Equals(t, false, unschedulable)
+	isLabeled, err := tNode.IsLabeledWithAction("custom-node-name")
+	h.Ok(t, err)
+	h.Equals(t, false, isLabeled)
+}
+
+func TestProcessEvent_ClusterTag(t *testing.T) {
+	body, err := json.Marshal(spotItnEvent)
+	h.Ok(t, err)
+	describeInstancesResp := getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")
+	describeInstancesResp.Reservations[0].Instances[0].Tags = []ec2types.Tag{{Key: aws.String("eks:cluster-name"), Value: aws.String("prod")}}
+	client := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "prod-node-name"},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1b/i-0b662ef9931388ba0"},
+	})
+	prodNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client}, uptime.Uptime)
+	h.Ok(t, err)
+	sqsMonitor := sqsevent.SQSMonitor{
+		EC2:           h.MockedEC2{DescribeInstancesResp: describeInstancesResp},
+		ASG:           mockIsManagedTrue(nil),
+		ClusterTagKey: "eks:cluster-name",
+		Clusters:      map[string]*node.Node{"prod": prodNode},
+	}
+	results, err := sqsMonitor.ProcessEvents(body)
+	h.Ok(t, err)
+	h.Equals(t, "prod", results[0].Cluster)
+	h.Equals(t, "prod-node-name", results[0].NodeName)
+
+	// the events of instances of other clusters are ignored
+	sqsMonitor.Clusters = map[string]*node.Node{"dev": prodNode}
+	results, err = sqsMonitor.ProcessEvents(body)
+	h.Assert(t, errors.Is(err, sqsevent.ErrClusterNotServed), "Expected the event of an instance of another cluster to be ignored")
+	h.Equals(t, 0, len(results))
+}
+
+func TestMonitor_ClusterNotServedDeleted(t *testing.T) {
+	msg, err := getSQSMessageFromEvent(spotItnEvent)
+	h.Ok(t, err)
+	describeInstancesResp := getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")
+	describeInstancesResp.Reservations[0].Instances[0].Tags = []ec2types.Tag{{Key: aws.String("eks:cluster-name"), Value: aws.String("prod")}}
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+		EC2:              h.MockedEC2{DescribeInstancesResp: describeInstancesResp},
+		ASG:              mockIsManagedTrue(nil),
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+		ClusterTagKey:    "eks:cluster-name",
+		Clusters:         map[string]*node.Node{"dev": nil},
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(drainChan))
+
+	// the message is deleted, so a failed deletion fails the event
+	sqsMonitor.SQS = h.MockedSQS{
+		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}},
+		DeleteMessageErr:   fmt.Errorf("error"),
+	}
+	err = sqsMonitor.Monitor()
+	h.Nok(t, err)
+	h.Equals(t, 0, len(drainChan))
+}
+
+func TestMonitor_WarmPoolTerminationSkipsDrain(t *testing.T) {
+	warmPoolEvent := asgLifecycleEvent
+	warmPoolEvent.Detail = []byte(`{
+		"LifecycleActionToken": "0befcbdb-6ecd-498a-9ff7-ae9b54447cd6",
+		"AutoScalingGroupName": "nth-test1",
+		"LifecycleHookName": "node-termination-handler",
+		"EC2InstanceId": "i-0633ac2b0d9769723",
+		"LifecycleTransition": "autoscaling:EC2_INSTANCE_TERMINATING",
+		"Origin": "WarmPool",
+		"Destination": "EC2"
+	}`)
+	msg, err := getSQSMessageFromEvent(warmPoolEvent)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+		EC2:              h.MockedEC2{DescribeInstancesErr: fmt.Errorf("instance should not be looked up")},
+		ASG:              h.MockedASG{},
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(drainChan))
+
+	sqsMonitor.ASG = h.MockedASG{CompleteLifecycleActionErr: responseError(500)}
+	err = sqsMonitor.Monitor()
+	h.Nok(t, err)
+}
+
+func TestMonitor_LaunchLifecycleIgnored(t *testing.T) {
+	launchEvent := asgLifecycleEvent
+	launchEvent.DetailType = "EC2 Instance-launch Lifecycle Action"
+	launchEvent.Detail = []byte(`{
+		"AutoScalingGroupName": "nth-test1",
+		"EC2InstanceId": "i-0633ac2b0d9769723",
+		"LifecycleTransition": "autoscaling:EC2_INSTANCE_LAUNCHING",
+		"Origin": "EC2",
+		"Destination": "WarmPool"
+	}`)
+	msg, err := getSQSMessageFromEvent(launchEvent)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+		EC2:              h.MockedEC2{DescribeInstancesErr: fmt.Errorf("instance should not be looked up")},
+		ASG:              h.MockedASG{},
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(drainChan))
+}
+
+func TestMonitor_ReturnToWarmPoolDrains(t *testing.T) {
+	warmPoolEvent := asgLifecycleEvent
+	warmPoolEvent.Detail = []byte(`{
+		"LifecycleActionToken": "0befcbdb-6ecd-498a-9ff7-ae9b54447cd6",
+		"AutoScalingGroupName": "nth-test1",
+		"LifecycleHookName": "node-termination-handler",
+		"EC2InstanceId": "i-0633ac2b0d9769723",
+		"LifecycleTransition": "autoscaling:EC2_INSTANCE_TERMINATING",
+		"Origin": "AutoScalingGroup",
+		"Destination": "WarmPool"
+	}`)
+	msg, err := getSQSMessageFromEvent(warmPoolEvent)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 1)
+	sqsMonitor := sqsevent.SQSMonitor{
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+		EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG:              h.MockedASG{},
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	result := <-drainChan
+	h.Assert(t, strings.Contains(result.Description, "warm pool"), "Expected the description to mention the warm pool")
+}
+
 func TestMonitor_DrainTasks(t *testing.T) {
 	testEvents := []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent, rebalanceRecommendationEvent}
-	messages := make([]*sqs.Message, 0, len(testEvents))
+	messages := make([]types.Message, 0, len(testEvents))
 	for _, event := range testEvents {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages = append(messages, &msg)
+		messages = append(messages, msg)
 	}
 
 	sqsMock := h.MockedSQS{
@@ -185,11 +537,11 @@ func TestMonitor_DrainTasks(t *testing.T) {
 
 func TestMonitor_DrainTasks_Errors(t *testing.T) {
 	testEvents := []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent, {}, rebalanceRecommendationEvent}
-	messages := make([]*sqs.Message, 0, len(testEvents))
+	messages := make([]types.Message, 0, len(testEvents))
 	for _, event := range testEvents {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages = append(messages, &msg)
+		messages = append(messages, msg)
 	}
 
 	sqsMock := h.MockedSQS{
@@ -241,8 +593,8 @@ func TestMonitor_DrainTasks_Errors(t *testing.T) {
 func TestMonitor_DrainTasksASGFailure(t *testing.T) {
 	msg, err := getSQSMessageFromEvent(asgLifecycleEvent)
 	h.Ok(t, err)
-	messages := []*sqs.Message{
-		&msg,
+	messages := []types.Message{
+		msg,
 	}
 	sqsMock := h.MockedSQS{
 		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -255,7 +607,7 @@ func TestMonitor_DrainTasksASGFailure(t *testing.T) {
 	}
 	asgMock := h.MockedASG{
 		CompleteLifecycleActionResp: autoscaling.CompleteLifecycleActionOutput{},
-		CompleteLifecycleActionErr:  awserr.NewRequestFailure(aws.ErrMissingEndpoint, 500, "bad-request"),
+		CompleteLifecycleActionErr:  responseError(500),
 	}
 	drainChan := make(chan monitor.InterruptionEvent, 1)
 
@@ -291,8 +643,8 @@ func TestMonitor_Failure(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{emptyEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -322,8 +674,8 @@ func TestMonitor_SQSFailure(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -351,7 +703,7 @@ func TestMonitor_SQSFailure(t *testing.T) {
 }
 
 func TestMonitor_SQSNoMessages(t *testing.T) {
-	messages := []*sqs.Message{}
+	messages := []types.Message{}
 	sqsMock := h.MockedSQS{
 		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
 		ReceiveMessageErr:  nil,
@@ -379,7 +731,7 @@ func TestMonitor_SQSNoMessages(t *testing.T) {
 // Test processing invalid sqs message
 func TestMonitor_SQSJsonErr(t *testing.T) {
 	replaceStr := `{"test":"test-string-to-replace"}`
-	badJson := []*sqs.Message{{Body: aws.String(`?`)}}
+	badJson := []types.Message{{Body: aws.String(`?`)}}
 	spotEventBadDetail := spotItnEvent
 	spotEventBadDetail.Detail = []byte(replaceStr)
 	badDetailsMessageSpot, err := getSQSMessageFromEvent(spotEventBadDetail)
@@ -390,7 +742,7 @@ func TestMonitor_SQSJsonErr(t *testing.T) {
 	h.Ok(t, err)
 	badDetailsMessageSpot.Body = aws.String(strings.Replace(*badDetailsMessageSpot.Body, replaceStr, "?", 1))
 	badDetailsMessageASG.Body = aws.String(strings.Replace(*badDetailsMessageASG.Body, replaceStr, "?", 1))
-	for _, badMessages := range [][]*sqs.Message{badJson, {&badDetailsMessageSpot}, {&badDetailsMessageASG}} {
+	for _, badMessages := range [][]types.Message{badJson, {badDetailsMessageSpot}, {badDetailsMessageASG}} {
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: badMessages},
 			ReceiveMessageErr:  nil,
@@ -418,8 +770,8 @@ func TestMonitor_EC2Failure(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -454,8 +806,8 @@ func TestMonitor_EC2NoInstances(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -489,8 +841,8 @@ func TestMonitor_DescribeInstancesError(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -498,7 +850,7 @@ func TestMonitor_DescribeInstancesError(t *testing.T) {
 		}
 		ec2Mock := h.MockedEC2{
 			DescribeInstancesResp: ec2.DescribeInstancesOutput{},
-			DescribeInstancesErr:  awserr.New("InvalidInstanceID.NotFound", "The instance ID 'i-0d6bd3ce2bf8a6751' does not exist\n\tstatus code: 400, request id: 6a5c30e2-922d-464c-946c-a1ec76e5920b", fmt.Errorf("original error")),
+			DescribeInstancesErr:  &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound", Message: "The instance ID 'i-0d6bd3ce2bf8a6751' does not exist\n\tstatus code: 400, request id: 6a5c30e2-922d-464c-946c-a1ec76e5920b"},
 		}
 		drainChan := make(chan monitor.InterruptionEvent, 1)
 
@@ -524,8 +876,8 @@ func TestMonitor_DescribeInstancesError(t *testing.T) {
 func TestMonitor_EC2NoDNSName(t *testing.T) {
 	msg, err := getSQSMessageFromEvent(asgLifecycleEvent)
 	h.Ok(t, err)
-	messages := []*sqs.Message{
-		&msg,
+	messages := []types.Message{
+		msg,
 	}
 	sqsMock := h.MockedSQS{
 		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -561,8 +913,8 @@ func TestMonitor_EC2NoDNSName(t *testing.T) {
 func TestMonitor_EC2NoDNSNameOnTerminatedInstance(t *testing.T) {
 	msg, err := getSQSMessageFromEvent(asgLifecycleEvent)
 	h.Ok(t, err)
-	messages := []*sqs.Message{
-		&msg,
+	messages := []types.Message{
+		msg,
 	}
 	sqsMock := h.MockedSQS{
 		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -572,8 +924,8 @@ func TestMonitor_EC2NoDNSNameOnTerminatedInstance(t *testing.T) {
 	ec2Mock := h.MockedEC2{
 		DescribeInstancesResp: getDescribeInstancesResp(""),
 	}
-	ec2Mock.DescribeInstancesResp.Reservations[0].Instances[0].State = &ec2.InstanceState{
-		Name: aws.String("running"),
+	ec2Mock.DescribeInstancesResp.Reservations[0].Instances[0].State = &ec2types.InstanceState{
+		Name: ec2types.InstanceStateNameRunning,
 	}
 	drainChan := make(chan monitor.InterruptionEvent, 1)
 
@@ -601,8 +953,8 @@ func TestMonitor_EC2NoDNSNameOnTerminatedInstance(t *testing.T) {
 func TestMonitor_SQSDeleteFailure(t *testing.T) {
 	msg, err := getSQSMessageFromEvent(asgLifecycleEvent)
 	h.Ok(t, err)
-	messages := []*sqs.Message{
-		&msg,
+	messages := []types.Message{
+		msg,
 	}
 	sqsMock := h.MockedSQS{
 		ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -640,8 +992,8 @@ func TestMonitor_InstanceNotManaged(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -679,8 +1031,8 @@ func TestMonitor_InstanceManagedErr(t *testing.T) {
 	for _, event := range []sqsevent.EventBridgeEvent{spotItnEvent, asgLifecycleEvent} {
 		msg, err := getSQSMessageFromEvent(event)
 		h.Ok(t, err)
-		messages := []*sqs.Message{
-			&msg,
+		messages := []types.Message{
+			msg,
 		}
 		sqsMock := h.MockedSQS{
 			ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: messages},
@@ -718,9 +1070,9 @@ func TestMonitor_InstanceManagedErr(t *testing.T) {
 
 func getDescribeInstancesResp(privateDNSName string) ec2.DescribeInstancesOutput {
 	return ec2.DescribeInstancesOutput{
-		Reservations: []*ec2.Reservation{
+		Reservations: []ec2types.Reservation{
 			{
-				Instances: []*ec2.Instance{
+				Instances: []ec2types.Instance{
 					{
 						InstanceId:     aws.String("i-0123456789"),
 						PrivateDnsName: &privateDNSName,
@@ -731,13 +1083,13 @@ func getDescribeInstancesResp(privateDNSName string) ec2.DescribeInstancesOutput
 	}
 }
 
-func getSQSMessageFromEvent(event sqsevent.EventBridgeEvent) (sqs.Message, error) {
+func getSQSMessageFromEvent(event sqsevent.EventBridgeEvent) (types.Message, error) {
 	eventBytes, err := json.Marshal(&event)
 	if err != nil {
-		return sqs.Message{}, err
+		return types.Message{}, err
 	}
 	eventStr := string(eventBytes)
-	return sqs.Message{Body: &eventStr}, nil
+	return types.Message{Body: &eventStr}, nil
 }
 
 func mockIsManagedTrue(asg *h.MockedASG) h.MockedASG {
@@ -745,12 +1097,12 @@ func mockIsManagedTrue(asg *h.MockedASG) h.MockedASG {
 		asg = &h.MockedASG{}
 	}
 	asg.DescribeAutoScalingInstancesResp = autoscaling.DescribeAutoScalingInstancesOutput{
-		AutoScalingInstances: []*autoscaling.InstanceDetails{
+		AutoScalingInstances: []asgtypes.AutoScalingInstanceDetails{
 			{AutoScalingGroupName: aws.String("test-asg")},
 		},
 	}
-	asg.DescribeTagsPagesResp = autoscaling.DescribeTagsOutput{
-		Tags: []*autoscaling.TagDescription{
+	asg.DescribeTagsResp = autoscaling.DescribeTagsOutput{
+		Tags: []asgtypes.TagDescription{
 			{Key: aws.String("aws-node-termination-handler/managed")},
 		},
 	}
@@ -762,7 +1114,7 @@ func mockIsManagedFalse(asg *h.MockedASG) h.MockedASG {
 		asg = &h.MockedASG{}
 	}
 	asg.DescribeAutoScalingInstancesResp = autoscaling.DescribeAutoScalingInstancesOutput{
-		AutoScalingInstances: []*autoscaling.InstanceDetails{},
+		AutoScalingInstances: []asgtypes.AutoScalingInstanceDetails{},
 	}
 	return *asg
 }
@@ -774,3 +1126,138 @@ func mockIsManagedErr(asg *h.MockedASG) h.MockedASG {
 	asg.DescribeAutoScalingInstancesErr = fmt.Errorf("error")
 	return *asg
 }
+
+func TestMonitor_FleetInstanceChange(t *testing.T) {
+	fleetEvent := sqsevent.EventBridgeEvent{
+		Version:    "0",
+		ID:         "2ba3c3b4-7c11-6ab9-42a4-bd5b1ab0a4f3",
+		DetailType: "EC2 Spot Fleet Instance Change",
+		Source:     "aws.ec2spotfleet",
+		Account:    "123456789012",
+		Time:       "2020-11-09T09:25:02Z",
+		Region:     "us-east-1",
+		Resources: []string{
+			"arn:aws:ec2:us-east-1:123456789012:spot-fleet-request/sfr-4b6d274d-0cea-4b2c-b3be-9dc627ad1f55",
+		},
+	}
+	for subType, expectEvent := range map[string]bool{"termination_notified": true, "terminated": true, "launched": false} {
+		fleetEvent.Detail = []byte(fmt.Sprintf(`{"instance-id": "i-0c594155dd5ff1829", "sub-type": "%s"}`, subType))
+		msg, err := getSQSMessageFromEvent(fleetEvent)
+		h.Ok(t, err)
+		dnsNodeName := "ip-10-0-0-157.us-east-2.compute.internal"
+		drainChan := make(chan monitor.InterruptionEvent, 1)
+		sqsMonitor := sqsevent.SQSMonitor{
+			SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+			EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp(dnsNodeName)},
+			ASG:              h.MockedASG{},
+			QueueURL:         "https://test-queue",
+			InterruptionChan: drainChan,
+		}
+
+		err = sqsMonitor.Monitor()
+		h.Ok(t, err)
+		if !expectEvent {
+			h.Equals(t, 0, len(drainChan))
+			continue
+		}
+		result := <-drainChan
+		h.Equals(t, sqsevent.SQSTerminateKind, result.Kind)
+		h.Equals(t, dnsNodeName, result.NodeName)
+		h.Equals(t, "i-0c594155dd5ff1829", result.InstanceID)
+		h.Ok(t, result.PostDrainTask(result, node.Node{}))
+	}
+}
+
+func TestMonitor_HealthEvent(t *testing.T) {
+	healthEvent := sqsevent.EventBridgeEvent{
+		Version:    "0",
+		ID:         "7bf73129-1428-4cd3-a780-95db273d1602",
+		DetailType: "AWS Health Event",
+		Source:     "aws.health",
+		Account:    "123456789012",
+		Time:       "2021-06-05T00:00:00Z",
+		Region:     "us-east-1",
+		Resources:  []string{"i-0c594155dd5ff1829"},
+	}
+	for entity, expectEvent := range map[string]bool{"i-0c594155dd5ff1829": true, "vol-0c594155dd5ff1829": false} {
+		healthEvent.Detail = []byte(fmt.Sprintf(`{
+			"service": "EC2",
+			"eventTypeCode": "AWS_EC2_INSTANCE_STORE_DRIVE_PERFORMANCE_DEGRADED",
+			"eventTypeCategory": "issue",
+			"eventDescription": [{"language": "en_US", "latestDescription": "degraded"}],
+			"affectedEntities": [{"entityValue": "%s"}]
+		}`, entity))
+		msg, err := getSQSMessageFromEvent(healthEvent)
+		h.Ok(t, err)
+		dnsNodeName := "ip-10-0-0-157.us-east-2.compute.internal"
+		drainChan := make(chan monitor.InterruptionEvent, 1)
+		sqsMonitor := sqsevent.SQSMonitor{
+			SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}},
+			EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp(dnsNodeName)},
+			ASG:              h.MockedASG{},
+			QueueURL:         "https://test-queue",
+			InterruptionChan: drainChan,
+		}
+
+		err = sqsMonitor.Monitor()
+		h.Ok(t, err)
+		if !expectEvent {
+			h.Equals(t, 0, len(drainChan))
+			continue
+		}
+		result := <-drainChan
+		h.Equals(t, sqsevent.SQSTerminateKind, result.Kind)
+		h.Equals(t, dnsNodeName, result.NodeName)
+		h.Equals(t, entity, result.InstanceID)
+		h.Assert(t, strings.Contains(result.Description, "AWS_EC2_INSTANCE_STORE_DRIVE_PERFORMANCE_DEGRADED"), "Expected the event type code in the description")
+		h.Ok(t, result.PostDrainTask(result, node.Node{}))
+	}
+}
+
+func TestMonitor_HealthEventOfSeveralInstances(t *testing.T) {
+	healthEvent := sqsevent.EventBridgeEvent{
+		Version:    "0",
+		ID:         "7bf73129-1428-4cd3-a780-95db273d1602",
+		DetailType: "AWS Health Event",
+		Source:     "aws.health",
+		Time:       "2021-06-05T00:00:00Z",
+		Region:     "us-east-1",
+		Detail: []byte(`{
+			"service": "EC2",
+			"eventTypeCode": "AWS_EC2_INSTANCE_STORE_DRIVE_PERFORMANCE_DEGRADED",
+			"affectedEntities": [{"entityValue": "i-0c594155dd5ff1829"}, {"entityValue": "i-0b662ef9931388ba0"}]
+		}`),
+	}
+	msg, err := getSQSMessageFromEvent(healthEvent)
+	h.Ok(t, err)
+	drainChan := make(chan monitor.InterruptionEvent, 2)
+	sqsMonitor := sqsevent.SQSMonitor{
+		// a failing deletion tells when the message is deleted
+		SQS:              h.MockedSQS{ReceiveMessageResp: sqs.ReceiveMessageOutput{Messages: []types.Message{msg}}, DeleteMessageErr: fmt.Errorf("deleted")},
+		EC2:              h.MockedEC2{DescribeInstancesResp: getDescribeInstancesResp("ip-10-0-0-157.us-east-2.compute.internal")},
+		ASG:              h.MockedASG{},
+		QueueURL:         "https://test-queue",
+		InterruptionChan: drainChan,
+	}
+
+	err = sqsMonitor.Monitor()
+	h.Ok(t, err)
+	h.Equals(t, 2, len(drainChan))
+	first, second := <-drainChan, <-drainChan
+	h.Equals(t, "i-0c594155dd5ff1829", first.InstanceID)
+	h.Equals(t, "i-0b662ef9931388ba0", second.InstanceID)
+	h.Assert(t, first.EventID != second.EventID, "Expected an event ID for each instance")
+
+	// the message is deleted once the events of all instances were handled
+	h.Ok(t, first.PostDrainTask(first, node.Node{}))
+	h.Nok(t, second.PostDrainTask(second, node.Node{}))
+}
+
+func responseError(statusCode int) error {
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: statusCode}},
+			Err:      fmt.Errorf("request failed"),
+		},
+	}
+}
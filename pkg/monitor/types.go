@@ -15,11 +15,21 @@ package monitor
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-node-termination-handler/pkg/node"
 )
 
+const (
+	// InstanceActionStop is the spot instance action when the instance is stopped rather than terminated
+	InstanceActionStop = "stop"
+	// InstanceActionHibernate is the spot instance action when the instance is hibernated rather than terminated
+	InstanceActionHibernate = "hibernate"
+	// SpotInterruptionWindow is the time between a Spot Instance interruption notice and the interruption
+	SpotInterruptionWindow = 2 * time.Minute
+)
+
 // DrainTask defines a task to be run when draining a node
 type DrainTask func(InterruptionEvent, node.Node) error
 
@@ -31,17 +41,40 @@ type InterruptionEvent struct {
 	State                string
 	AutoScalingGroupName string
 	NodeName             string
+	Cluster              string
 	NodeLabels           map[string]string
+	AvailabilityZone     string
 	Pods                 []string
+	Checkpoints          map[string]string
+	BlockingPDBs         []string
+	EvictionFailures     map[string]int
+	Rescheduling         string
+	UnrecoveredWorkloads []string
+	HookOutcomes         map[string]string
+	DrainDuration        time.Duration
 	InstanceID           string
+	InstanceAction       string
+	Code                 string
 	StartTime            time.Time
 	EndTime              time.Time
+	DrainTime            time.Time
 	NodeProcessed        bool
 	InProgress           bool
+	NotifyOnly           bool
+	TaskToken            string    `json:"-"`
 	PreDrainTask         DrainTask `json:"-"`
 	PostDrainTask        DrainTask `json:"-"`
 }
 
+// DrainTimeBefore returns the time to start draining so leadTime remains before the deadline.
+// The zero time is returned if leadTime is not set, so the drain is scheduled based on the node termination grace period.
+func DrainTimeBefore(deadline time.Time, leadTime time.Duration) time.Time {
+	if leadTime <= 0 {
+		return time.Time{}
+	}
+	return deadline.Add(-1 * leadTime)
+}
+
 // TimeUntilEvent returns the duration until the event start time
 func (e *InterruptionEvent) TimeUntilEvent() time.Duration {
 	return time.Until(e.StartTime)
@@ -52,6 +85,67 @@ func (e *InterruptionEvent) IsRebalanceRecommendation() bool {
 	return strings.Contains(e.EventID, "rebalance-recommendation")
 }
 
+// IsMoreUrgentThan returns true if the event should be handled before the other event for the same node.
+// Events which interrupt the instance come before rebalance recommendations and notify-only events, otherwise the event
+// starting first is more urgent.
+func (e *InterruptionEvent) IsMoreUrgentThan(other *InterruptionEvent) bool {
+	if e.isAdvisory() != other.isAdvisory() {
+		return !e.isAdvisory()
+	}
+	return e.StartTime.Before(other.StartTime)
+}
+
+func (e *InterruptionEvent) isAdvisory() bool {
+	return e.IsRebalanceRecommendation() || e.NotifyOnly
+}
+
+// CompleteTogether makes the post-drain tasks of the events, which were created for a single message, complete the
+// message together: only the tasks of the last events handled run, so a failed task is run again when it is retried
+func CompleteTogether(events []InterruptionEvent) {
+	var mutex sync.Mutex
+	pending := len(events)
+	for i := range events {
+		task := events[i].PostDrainTask
+		events[i].PostDrainTask = func(interruptionEvent InterruptionEvent, n node.Node) error {
+			mutex.Lock()
+			pending--
+			last := pending <= 0
+			mutex.Unlock()
+			if !last || task == nil {
+				return nil
+			}
+			return task(interruptionEvent, n)
+		}
+	}
+}
+
+// NodeKey identifies the node of the event across the clusters a multi-cluster queue processor serves, whose node
+// names may overlap
+func (e *InterruptionEvent) NodeKey() string {
+	if e.Cluster == "" {
+		return e.NodeName
+	}
+	return e.Cluster + "/" + e.NodeName
+}
+
+// IsStopOrHibernate returns true if the instance will be stopped or hibernated, so it comes back with its disk intact
+func (e *InterruptionEvent) IsStopOrHibernate() bool {
+	return e.InstanceAction == InstanceActionStop || e.InstanceAction == InstanceActionHibernate
+}
+
+// IsAcceleratorEvent returns true if the interruption event description mentions any of the comma separated keywords,
+// ignoring case
+func (e *InterruptionEvent) IsAcceleratorEvent(keywords string) bool {
+	description := strings.ToUpper(e.Description)
+	for _, keyword := range strings.Split(keywords, ",") {
+		keyword = strings.ToUpper(strings.TrimSpace(keyword))
+		if keyword != "" && strings.Contains(description, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // Monitor is an interface which can be implemented for various sources of interruption events
 type Monitor interface {
 	Monitor() error
@@ -64,3 +64,39 @@ func TestIsRebalanceRecommendation_Empty_Failure(t *testing.T) {
 	event := &monitor.InterruptionEvent{}
 	h.Equals(t, false, event.IsRebalanceRecommendation())
 }
+
+func TestIsAcceleratorEvent(t *testing.T) {
+	event := &monitor.InterruptionEvent{
+		Description: "AWS Health event AWS_EC2_GPU_DEGRADED received for instance i-0123456789",
+	}
+
+	h.Equals(t, true, event.IsAcceleratorEvent("gpu, neuron"))
+	h.Equals(t, false, event.IsAcceleratorEvent("neuron"))
+	h.Equals(t, false, event.IsAcceleratorEvent(""))
+}
+
+func TestIsStopOrHibernate(t *testing.T) {
+	for action, expected := range map[string]bool{"stop": true, "hibernate": true, "terminate": false, "": false} {
+		event := &monitor.InterruptionEvent{InstanceAction: action}
+		h.Equals(t, expected, event.IsStopOrHibernate())
+	}
+}
+
+func TestDrainTimeBefore(t *testing.T) {
+	deadline := time.Date(2021, time.June, 5, 8, 0, 0, 0, time.UTC)
+	h.Equals(t, time.Time{}, monitor.DrainTimeBefore(deadline, 0))
+	h.Equals(t, deadline.Add(-90*time.Second), monitor.DrainTimeBefore(deadline, 90*time.Second))
+}
+
+func TestIsMoreUrgentThan(t *testing.T) {
+	now := time.Now()
+	rebalance := &monitor.InterruptionEvent{EventID: "rebalance-recommendation-1", StartTime: now}
+	spotITN := &monitor.InterruptionEvent{EventID: "spot-itn-1", StartTime: now.Add(2 * time.Minute)}
+	scheduled := &monitor.InterruptionEvent{EventID: "instance-event-1", StartTime: now.Add(time.Hour)}
+	notifyOnly := &monitor.InterruptionEvent{EventID: "instance-event-2", StartTime: now, NotifyOnly: true}
+
+	h.Equals(t, true, spotITN.IsMoreUrgentThan(rebalance))
+	h.Equals(t, false, rebalance.IsMoreUrgentThan(spotITN))
+	h.Equals(t, true, spotITN.IsMoreUrgentThan(scheduled))
+	h.Equals(t, true, scheduled.IsMoreUrgentThan(notifyOnly))
+}
@@ -0,0 +1,70 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// CordonAndEvictAcceleratorPods cordons the node and evicts only pods which request accelerator resources such as GPUs
+func (n Node) CordonAndEvictAcceleratorPods(nodeName string) error {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Node would have been cordoned and had its accelerator pods evicted, but dry-run flag was set")
+		return nil
+	}
+	err := n.Cordon(nodeName)
+	if err != nil {
+		return err
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return err
+	}
+	log.Info().Msg("Evicting pods requesting accelerator resources from the node")
+	acceleratorDrainHelper := *n.drainHelper
+	acceleratorDrainHelper.AdditionalFilters = append(append([]drain.PodFilter{}, n.drainHelper.AdditionalFilters...), n.acceleratorPodFilter)
+	return drain.RunNodeDrain(&acceleratorDrainHelper, node.Name)
+}
+
+// acceleratorPodFilter skips pods which do not request any of the configured accelerator resources
+func (n Node) acceleratorPodFilter(pod corev1.Pod) drain.PodDeleteStatus {
+	resourceNames := splitList(n.nthConfig.AcceleratorResourceNames)
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, resourceName := range resourceNames {
+			name := corev1.ResourceName(resourceName)
+			if _, ok := container.Resources.Requests[name]; ok {
+				return drain.MakePodDeleteStatusOkay()
+			}
+			if _, ok := container.Resources.Limits[name]; ok {
+				return drain.MakePodDeleteStatusOkay()
+			}
+		}
+	}
+	return drain.MakePodDeleteStatusSkip()
+}
+
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
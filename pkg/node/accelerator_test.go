@@ -0,0 +1,61 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getPod(name string, resources v1.ResourceRequirements) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PodSpec{
+			NodeName:   nodeName,
+			Containers: []v1.Container{{Name: name, Resources: resources}},
+		},
+	}
+}
+
+func TestCordonAndEvictAcceleratorPods(t *testing.T) {
+	gpuLimits := v1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+	client := h.NewFakeClientset(
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}},
+		getPod("gpu", v1.ResourceRequirements{Limits: gpuLimits}),
+		getPod("cpu", v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}),
+	)
+	drainHelper := getDrainHelper(client)
+	drainHelper.DisableEviction = true
+	tNode, err := node.NewWithValues(config.Config{AcceleratorResourceNames: "nvidia.com/gpu, aws.amazon.com/neuron"}, drainHelper, uptime.Uptime)
+	h.Ok(t, err)
+
+	err = tNode.CordonAndEvictAcceleratorPods(nodeName)
+	h.Ok(t, err)
+
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Assert(t, k8sNode.Spec.Unschedulable, "Expected node to be cordoned")
+	pods, err := client.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(pods.Items))
+	h.Equals(t, "cpu", pods.Items[0].Name)
+}
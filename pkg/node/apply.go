@@ -0,0 +1,119 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FieldManager is the field manager of the node fields node termination handler sets with server-side apply
+const FieldManager = "aws-node-termination-handler"
+
+// nodeApply is the apply configuration of a node. Only the fields set are applied, fields applied before and left out
+// are removed by the api server.
+type nodeApply struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   nodeApplyMetadata `json:"metadata"`
+	Status     *nodeApplyStatus  `json:"status,omitempty"`
+}
+
+type nodeApplyMetadata struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// taintsPatch is the merge patch of the taints of a node, which replaces the whole list
+type taintsPatch struct {
+	Metadata struct {
+		// ResourceVersion fails the patch with a conflict if the node changed since it was read
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Spec struct {
+		Taints []corev1.Taint `json:"taints"`
+	} `json:"spec"`
+}
+
+type nodeApplyStatus struct {
+	Conditions []corev1.NodeCondition `json:"conditions,omitempty"`
+}
+
+func newNodeApply(nodeName string) nodeApply {
+	return nodeApply{APIVersion: "v1", Kind: "Node", Metadata: nodeApplyMetadata{Name: nodeName}}
+}
+
+// applyNode applies the configuration to the node with the node termination handler field manager, taking over fields
+// other managers set to different values
+func applyNode(client kubernetes.Interface, apply nodeApply, subresources ...string) (*corev1.Node, error) {
+	payload, err := json.Marshal(apply)
+	if err != nil {
+		return nil, fmt.Errorf("An error occurred while marshalling the json to apply to the node: %w", err)
+	}
+	force := true
+	return client.CoreV1().Nodes().Patch(context.TODO(), apply.Metadata.Name, types.ApplyPatchType, payload, metav1.PatchOptions{FieldManager: FieldManager, Force: &force}, subresources...)
+}
+
+// appliedKeys returns the keys of the metadata map, e.g. f:labels, that node termination handler applied to the node
+func appliedKeys(node *corev1.Node, field string) map[string]bool {
+	keys := map[string]bool{}
+	for _, entry := range node.ManagedFields {
+		if entry.Manager != FieldManager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]map[string]map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		for key := range fields["f:metadata"][field] {
+			if strings.HasPrefix(key, "f:") {
+				keys[strings.TrimPrefix(key, "f:")] = true
+			}
+		}
+	}
+	return keys
+}
+
+// appliedValues returns the current values of the keys node termination handler applied to the node
+func appliedValues(values map[string]string, applied map[string]bool) map[string]string {
+	result := map[string]string{}
+	for key := range applied {
+		if value, ok := values[key]; ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// patchTaints patches the taints of the node. Taints are an atomic list, so applying them would take the ownership of the
+// taints other controllers applied. The patch fails with a conflict if the node changed since it was read, so taints
+// other controllers added meanwhile are never dropped.
+func patchTaints(client kubernetes.Interface, node *corev1.Node) (*corev1.Node, error) {
+	patch := taintsPatch{}
+	patch.Metadata.ResourceVersion = node.ResourceVersion
+	patch.Spec.Taints = append([]corev1.Taint{}, node.Spec.Taints...)
+	payload, err := json.Marshal(patch)
+	if err != nil {
+		return nil, fmt.Errorf("An error occurred while marshalling the json to patch the taints of the node: %w", err)
+	}
+	return client.CoreV1().Nodes().Patch(context.TODO(), node.Name, types.MergePatchType, payload, metav1.PatchOptions{FieldManager: FieldManager})
+}
@@ -0,0 +1,95 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func managedFields(manager string, operation metav1.ManagedFieldsOperationType, fields string) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{Manager: manager, Operation: operation, FieldsV1: &metav1.FieldsV1{Raw: []byte(fields)}}
+}
+
+func TestAppliedKeys(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{
+		managedFields(FieldManager, metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{"f:aws-node-termination-handler/action":{}},"f:annotations":{"f:cluster-autoscaler.kubernetes.io/scale-down-disabled":{}}}}`),
+		managedFields(FieldManager, metav1.ManagedFieldsOperationUpdate, `{"f:metadata":{"f:labels":{"f:updated":{}}}}`),
+		managedFields("kubelet", metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{".":{},"f:kubernetes.io/hostname":{}}}}`),
+	}}}
+	h.Equals(t, map[string]bool{"aws-node-termination-handler/action": true}, appliedKeys(node, "f:labels"))
+	h.Equals(t, map[string]bool{"cluster-autoscaler.kubernetes.io/scale-down-disabled": true}, appliedKeys(node, "f:annotations"))
+}
+
+func TestAddLabelAppliesLabels(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   nodeName,
+		Labels: map[string]string{"aws-node-termination-handler/event-id": "event", "team": "a"},
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			managedFields(FieldManager, metav1.ManagedFieldsOperationApply, `{"f:metadata":{"f:labels":{"f:aws-node-termination-handler/event-id":{}}}}`),
+		},
+	}}
+	client := h.NewFakeClientset(node)
+	var patches []k8stesting.PatchAction
+	client.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, action.(k8stesting.PatchAction))
+		return false, nil, nil
+	})
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName}, getTestDrainHelper(client), nil)
+	h.Ok(t, err)
+
+	h.Ok(t, tNode.addLabel(nodeName, ActionLabelKey, UncordonAfterRebootLabelVal))
+	h.Equals(t, 1, len(patches))
+	h.Equals(t, types.ApplyPatchType, patches[0].GetPatchType())
+	var apply nodeApply
+	h.Ok(t, json.Unmarshal(patches[0].GetPatch(), &apply))
+	h.Equals(t, map[string]string{"aws-node-termination-handler/event-id": "event", ActionLabelKey: UncordonAfterRebootLabelVal}, apply.Metadata.Labels)
+
+	updated, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "a", updated.Labels["team"])
+	h.Equals(t, UncordonAfterRebootLabelVal, updated.Labels[ActionLabelKey])
+}
+
+func TestPatchTaintsSendsResourceVersion(t *testing.T) {
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName, ResourceVersion: "42"},
+		Spec:       v1.NodeSpec{Taints: []v1.Taint{{Key: "other", Effect: v1.TaintEffectNoSchedule}}},
+	}
+	client := h.NewFakeClientset(node)
+	var patch k8stesting.PatchAction
+	client.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch = action.(k8stesting.PatchAction)
+		return false, nil, nil
+	})
+	node.Spec.Taints = append(node.Spec.Taints, v1.Taint{Key: SpotInterruptionTaint, Effect: v1.TaintEffectNoSchedule})
+	_, err := patchTaints(client, node)
+	h.Ok(t, err)
+
+	// taints are patched rather than applied, so the ownership of other controllers' taints is not taken over
+	h.Equals(t, types.MergePatchType, patch.GetPatchType())
+	var taints taintsPatch
+	h.Ok(t, json.Unmarshal(patch.GetPatch(), &taints))
+	h.Equals(t, "42", taints.Metadata.ResourceVersion)
+	h.Equals(t, 2, len(taints.Spec.Taints))
+}
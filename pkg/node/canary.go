@@ -0,0 +1,48 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// parseCanarySelector parses the canary-node-selector flag, an empty selector matches every node
+func parseCanarySelector(canaryNodeSelector string) (labels.Selector, error) {
+	selector, err := labels.Parse(canaryNodeSelector)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid canary-node-selector passed: %w", err)
+	}
+	return selector, nil
+}
+
+// InCanary returns true if node termination handler acts on the node. Nodes outside of the canary, which don't match the
+// canary node selector or fall outside of the canary percentage, only have their events logged, so new handler versions
+// and configurations can be rolled out progressively. The percentage buckets nodes by a hash of their name, so a node
+// stays in or out of the canary across restarts and versions.
+func (n Node) InCanary(nodeName string, nodeLabels map[string]string) bool {
+	if n.canarySelector != nil && !n.canarySelector.Matches(labels.Set(nodeLabels)) {
+		return false
+	}
+	return canaryBucket(nodeName) < n.nthConfig.CanaryPercentage
+}
+
+// canaryBucket returns the bucket from 0 to 99 of the node for the canary percentage
+func canaryBucket(nodeName string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(nodeName))
+	return int(hash.Sum32() % 100)
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+)
+
+func getCanaryNode(t *testing.T, canaryNodeSelector string, canaryPercentage int) *node.Node {
+	tNode, err := node.NewWithValues(config.Config{CanaryNodeSelector: canaryNodeSelector, CanaryPercentage: canaryPercentage}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Ok(t, err)
+	return tNode
+}
+
+func TestInCanarySelector(t *testing.T) {
+	tNode := getCanaryNode(t, "nth-canary=true", 100)
+	h.Assert(t, tNode.InCanary(nodeName, map[string]string{"nth-canary": "true"}), "Expected a matching node to be in the canary")
+	h.Assert(t, !tNode.InCanary(nodeName, map[string]string{"nth-canary": "false"}), "Expected a node not matching the selector to be outside of the canary")
+	h.Assert(t, !tNode.InCanary(nodeName, nil), "Expected a node without labels to be outside of the canary")
+
+	tNode = getCanaryNode(t, "", 100)
+	h.Assert(t, tNode.InCanary(nodeName, nil), "Expected every node to be in the canary without selector")
+}
+
+func TestInCanaryPercentage(t *testing.T) {
+	none := getCanaryNode(t, "", 0)
+	half := getCanaryNode(t, "", 50)
+	inHalf := 0
+	for i := 0; i < 1000; i++ {
+		name := fmt.Sprintf("ip-10-0-%d-%d.ec2.internal", i/256, i%256)
+		h.Assert(t, !none.InCanary(name, nil), "Expected no node to be in a 0% canary")
+		if half.InCanary(name, nil) {
+			inHalf++
+			h.Assert(t, half.InCanary(name, nil), "Expected the node to stay in the canary")
+		}
+	}
+	h.Assert(t, inHalf > 400 && inHalf < 600, "Expected about half of the nodes in a 50%% canary, got %d", inHalf)
+}
+
+func TestInvalidCanarySelector(t *testing.T) {
+	_, err := node.NewWithValues(config.Config{CanaryNodeSelector: "nth-canary in true"}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Assert(t, err != nil, "Expected an invalid canary node selector to be rejected")
+}
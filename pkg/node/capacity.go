@@ -0,0 +1,130 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// capacityResources are the resources compared when checking whether pods fit on other nodes
+var capacityResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// HasCapacityForPods returns true if the schedulable, Ready nodes in the same zone and node group have enough
+// unrequested allocatable cpu and memory, in aggregate, for the requests of the pods which would be evicted from the node
+func (n Node) HasCapacityForPods(nodeName string) (bool, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msg("Would have checked for capacity to absorb the node's pods, but dry-run flag was set")
+		return true, nil
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return false, err
+	}
+	nodes, err := n.drainHelper.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("Unable to list nodes to compute capacity: %w", err)
+	}
+	// pods are listed once and grouped by node rather than listed for every node
+	pods, err := n.drainHelper.Client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("Unable to list pods to compute capacity: %w", err)
+	}
+	podsByNode := map[string][]corev1.Pod{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+	}
+
+	required := corev1.ResourceList{}
+	for _, pod := range podsByNode[node.Name] {
+		if isDaemonSetPod(pod) {
+			continue
+		}
+		addResources(required, podRequests(pod))
+	}
+	available := corev1.ResourceList{}
+	for _, candidate := range nodes.Items {
+		if candidate.Name == node.Name || candidate.Spec.Unschedulable || !isNodeReady(candidate) {
+			continue
+		}
+		if !hasSameLabels(*node, candidate, append([]string{corev1.LabelTopologyZone}, nodeGroupLabelKeys...)...) {
+			continue
+		}
+		requested := corev1.ResourceList{}
+		for _, pod := range podsByNode[candidate.Name] {
+			addResources(requested, podRequests(pod))
+		}
+		for _, name := range capacityResources {
+			free := candidate.Status.Allocatable[name].DeepCopy()
+			free.Sub(requested[name])
+			if free.Sign() > 0 {
+				addResources(available, corev1.ResourceList{name: free})
+			}
+		}
+	}
+	for _, name := range capacityResources {
+		requiredQuantity := required[name]
+		availableQuantity := available[name]
+		if requiredQuantity.Cmp(availableQuantity) > 0 {
+			log.Info().Msgf("Not enough %s capacity for the pods on node %s: %s required, %s available", name, node.Name, requiredQuantity.String(), availableQuantity.String())
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// podRequests returns the resources requested by the pod, which is the larger of the sum of its containers' requests
+// and the largest init container request
+func podRequests(pod corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResources(requests, container.Resources.Requests)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
+
+func addResources(total corev1.ResourceList, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		current, ok := total[name]
+		if !ok {
+			current = resource.Quantity{}
+		}
+		current.Add(quantity)
+		total[name] = current
+	}
+}
+
+func isDaemonSetPod(pod corev1.Pod) bool {
+	for _, ownerRef := range pod.OwnerReferences {
+		if ownerRef.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getCapacityNode(name string, cpu string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"eks.amazonaws.com/nodegroup": "ng-1"}},
+		Status: v1.NodeStatus{
+			Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse("8Gi")},
+			Conditions:  []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+func getCapacityPod(name string, nodeName string, cpu string) *v1.Pod {
+	pod := getPod(name, v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse("1Gi")}})
+	pod.Spec.NodeName = nodeName
+	return pod
+}
+
+func TestHasCapacityForPods(t *testing.T) {
+	client := h.NewFakeClientset(
+		getCapacityNode(nodeName, "4"),
+		getCapacityNode("other", "4"),
+		getCapacityPod("evicted", nodeName, "2"),
+		getCapacityPod("resident", "other", "1"),
+	)
+	tNode := getNode(t, getDrainHelper(client))
+
+	hasCapacity, err := tNode.HasCapacityForPods(nodeName)
+	h.Ok(t, err)
+	h.Assert(t, hasCapacity, "Expected the other node to have capacity for the pods")
+}
+
+func TestHasCapacityForPodsInsufficient(t *testing.T) {
+	otherGroup := getCapacityNode("other-group", "16")
+	otherGroup.Labels["eks.amazonaws.com/nodegroup"] = "ng-2"
+	client := h.NewFakeClientset(
+		getCapacityNode(nodeName, "4"),
+		getCapacityNode("other", "4"),
+		otherGroup,
+		getCapacityPod("evicted", nodeName, "2"),
+		getCapacityPod("resident", "other", "3"),
+	)
+	tNode := getNode(t, getDrainHelper(client))
+
+	hasCapacity, err := tNode.HasCapacityForPods(nodeName)
+	h.Ok(t, err)
+	h.Assert(t, !hasCapacity, "Expected no capacity in the node group for the pods")
+}
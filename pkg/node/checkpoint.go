@@ -0,0 +1,152 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// CheckpointAnnotation opts the containers of a pod into being checkpointed before the pod is evicted, when set to "true"
+	CheckpointAnnotation = "aws-node-termination-handler/checkpoint"
+	// CheckpointsAnnotation holds the JSON object of the checkpoint locations on the node by container name
+	CheckpointsAnnotation = "aws-node-termination-handler/checkpoints"
+)
+
+var checkpointTimeout = 60 * time.Second
+
+// checkpointContainer asks the kubelet of the node to checkpoint the container and returns the location of the checkpoint
+// archive on the node. It is replaced in tests, since the fake clientset has no REST client.
+var checkpointContainer = kubeletCheckpoint
+
+// kubeletCheckpointResponse is the response of the kubelet checkpoint API
+type kubeletCheckpointResponse struct {
+	Items []string `json:"items"`
+}
+
+// CheckpointContainers checkpoints the containers of the pods annotated as checkpointable on the node through the
+// kubelet checkpoint API, so long-running processes can be inspected or migrated after the interruption. The locations
+// of the checkpoints are recorded on the pods and returned by namespace/pod/container. Failed checkpoints are logged
+// and don't keep the pods from being evicted. Checkpointing takes at most the container checkpoint timeout from now and
+// at most half of the time left until the deadline of the event, so the rest is left to drain the node. A zero deadline,
+// or one which passed already like the start time of a queued event, only bounds it by the timeout.
+func (n Node) CheckpointContainers(nodeName string, deadline time.Time) map[string]string {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Containers would have been checkpointed, but dry-run flag was set")
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), checkpointBudget(time.Duration(n.nthConfig.ContainerCheckpointTimeout)*time.Second, deadline))
+	defer cancel()
+	podList, errs := n.drainHelper.GetPodsForDeletion(nodeName)
+	if len(errs) > 0 {
+		log.Warn().Str("node_name", nodeName).Msgf("Unable to list pods to checkpoint: %v", errs)
+		return nil
+	}
+	checkpoints := map[string]string{}
+	for _, pod := range podList.Pods() {
+		if pod.Annotations[CheckpointAnnotation] != "true" {
+			continue
+		}
+		podCheckpoints := map[string]string{}
+		for _, container := range pod.Spec.Containers {
+			if ctx.Err() != nil {
+				log.Warn().Str("pod", pod.Namespace+"/"+pod.Name).Str("container", container.Name).Msg("No time left to checkpoint the container before draining the node")
+				continue
+			}
+			location, err := checkpointContainer(ctx, n.drainHelper.Client, nodeName, pod.Namespace, pod.Name, container.Name)
+			if err != nil {
+				log.Warn().Err(err).Str("pod", pod.Namespace+"/"+pod.Name).Str("container", container.Name).Msg("Unable to checkpoint the container")
+				continue
+			}
+			log.Info().Str("pod", pod.Namespace+"/"+pod.Name).Str("container", container.Name).Str("location", location).Msg("Checkpointed the container")
+			podCheckpoints[container.Name] = location
+			checkpoints[pod.Namespace+"/"+pod.Name+"/"+container.Name] = location
+		}
+		if len(podCheckpoints) == 0 {
+			continue
+		}
+		err := n.annotateCheckpoints(pod, podCheckpoints)
+		if err != nil {
+			log.Warn().Err(err).Str("pod", pod.Namespace+"/"+pod.Name).Msg("Unable to record the checkpoints on the pod")
+		}
+	}
+	return checkpoints
+}
+
+// checkpointBudget returns the time to checkpoint containers for, the timeout cut to half of the time left until the
+// deadline
+func checkpointBudget(timeout time.Duration, deadline time.Time) time.Duration {
+	if deadline.IsZero() {
+		return timeout
+	}
+	if left := time.Until(deadline) / 2; left > 0 && left < timeout {
+		return left
+	}
+	return timeout
+}
+
+func (n Node) annotateCheckpoints(pod corev1.Pod, checkpoints map[string]string) error {
+	value, err := json.Marshal(checkpoints)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{CheckpointsAnnotation: string(value)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = n.drainHelper.Client.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// kubeletCheckpoint calls the checkpoint API of the kubelet through the node proxy of the Kubernetes API server. The
+// kubelet must run with the ContainerCheckpoint feature gate.
+func kubeletCheckpoint(ctx context.Context, client kubernetes.Interface, nodeName string, namespace string, pod string, container string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, checkpointTimeout)
+	defer cancel()
+	body, err := client.CoreV1().RESTClient().Post().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("checkpoint", namespace, pod, container).
+		DoRaw(ctx)
+	if err != nil {
+		return "", err
+	}
+	return parseCheckpointResponse(body)
+}
+
+func parseCheckpointResponse(body []byte) (string, error) {
+	response := kubeletCheckpointResponse{}
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse the kubelet checkpoint response: %w", err)
+	}
+	if len(response.Items) == 0 {
+		return "", fmt.Errorf("The kubelet checkpoint response holds no checkpoint")
+	}
+	return response.Items[0], nil
+}
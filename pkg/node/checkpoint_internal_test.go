@@ -0,0 +1,138 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestCheckpointContainers(t *testing.T) {
+	defer func() { checkpointContainer = kubeletCheckpoint }()
+	checkpointContainer = func(ctx context.Context, client kubernetes.Interface, nodeName string, namespace string, pod string, container string) (string, error) {
+		if container == "sidecar" {
+			return "", errors.New("checkpointing is not supported")
+		}
+		return "/var/lib/kubelet/checkpoints/checkpoint-" + pod + "_" + namespace + "-" + container + ".tar", nil
+	}
+	client := h.NewFakeClientset()
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	pods := []v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Annotations: map[string]string{CheckpointAnnotation: "true"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}, {Name: "sidecar"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "web"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+		},
+	}
+	for i := range pods {
+		pods[i].Spec.NodeName = nodeName
+		_, err = client.CoreV1().Pods("default").Create(context.Background(), &pods[i], metav1.CreateOptions{})
+		h.Ok(t, err)
+	}
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName, EnableContainerCheckpoints: true, ContainerCheckpointTimeout: 30}, getTestDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	checkpoints := tNode.CheckpointContainers(nodeName, time.Time{})
+	h.Equals(t, map[string]string{"default/worker/app": "/var/lib/kubelet/checkpoints/checkpoint-worker_default-app.tar"}, checkpoints)
+	worker, err := client.CoreV1().Pods("default").Get(context.Background(), "worker", metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, `{"app":"/var/lib/kubelet/checkpoints/checkpoint-worker_default-app.tar"}`, worker.Annotations[CheckpointsAnnotation])
+	web, err := client.CoreV1().Pods("default").Get(context.Background(), "web", metav1.GetOptions{})
+	h.Ok(t, err)
+	_, ok := web.Annotations[CheckpointsAnnotation]
+	h.Assert(t, !ok, "Expected pods which are not checkpointable to be left alone")
+}
+
+func TestCheckpointContainersBeforeSpotInterruption(t *testing.T) {
+	defer func() { checkpointContainer = kubeletCheckpoint }()
+	var budget time.Duration
+	checkpointContainer = func(ctx context.Context, client kubernetes.Interface, nodeName string, namespace string, pod string, container string) (string, error) {
+		deadline, _ := ctx.Deadline()
+		budget = time.Until(deadline)
+		return "/var/lib/kubelet/checkpoints/checkpoint-" + pod + "_" + namespace + "-" + container + ".tar", nil
+	}
+	client := h.NewFakeClientset()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default", Annotations: map[string]string{CheckpointAnnotation: "true"}},
+		Spec:       v1.PodSpec{NodeName: nodeName, Containers: []v1.Container{{Name: "app"}}},
+	}
+	_, err := client.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+	h.Ok(t, err)
+	// the drain timeout is the default node termination grace period, as long as the spot interruption notice
+	drainHelper := getTestDrainHelper(client)
+	drainHelper.Timeout = 120 * time.Second
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName, EnableContainerCheckpoints: true, ContainerCheckpointTimeout: 30}, drainHelper, uptime.Uptime)
+	h.Ok(t, err)
+
+	// spot interruption notices start the interruption 2 minutes after the notice
+	checkpoints := tNode.CheckpointContainers(nodeName, time.Now().Add(2*time.Minute))
+	h.Equals(t, map[string]string{"default/worker/app": "/var/lib/kubelet/checkpoints/checkpoint-worker_default-app.tar"}, checkpoints)
+	h.Assert(t, budget > 29*time.Second && budget <= 30*time.Second, "Expected the checkpoint timeout as budget, got %s", budget)
+
+	// the start time of a queued event passed already
+	checkpoints = tNode.CheckpointContainers(nodeName, time.Now().Add(-time.Minute))
+	h.Equals(t, 1, len(checkpoints))
+	h.Assert(t, budget > 29*time.Second && budget <= 30*time.Second, "Expected the checkpoint timeout as budget, got %s", budget)
+
+	// half of the time left is kept to drain the node
+	checkpoints = tNode.CheckpointContainers(nodeName, time.Now().Add(40*time.Second))
+	h.Equals(t, 1, len(checkpoints))
+	h.Assert(t, budget > 19*time.Second && budget <= 20*time.Second, "Expected half of the time left as budget, got %s", budget)
+}
+
+func TestCheckpointContainersStopsAtDeadline(t *testing.T) {
+	defer func() { checkpointContainer = kubeletCheckpoint }()
+	checkpointed := []string{}
+	checkpointContainer = func(ctx context.Context, client kubernetes.Interface, nodeName string, namespace string, pod string, container string) (string, error) {
+		checkpointed = append(checkpointed, container)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	client := h.NewFakeClientset()
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "default", Annotations: map[string]string{CheckpointAnnotation: "true"}},
+		Spec:       v1.PodSpec{NodeName: nodeName, Containers: []v1.Container{{Name: "app"}, {Name: "sidecar"}}},
+	}
+	_, err := client.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+	h.Ok(t, err)
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName, EnableContainerCheckpoints: true, ContainerCheckpointTimeout: 30}, getTestDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	checkpoints := tNode.CheckpointContainers(nodeName, time.Now().Add(100*time.Millisecond))
+	h.Equals(t, 0, len(checkpoints))
+	h.Equals(t, []string{"app"}, checkpointed)
+}
+
+func TestParseCheckpointResponse(t *testing.T) {
+	location, err := parseCheckpointResponse([]byte(`{"items":["/var/lib/kubelet/checkpoints/checkpoint-worker_default-app.tar"]}`))
+	h.Ok(t, err)
+	h.Equals(t, "/var/lib/kubelet/checkpoints/checkpoint-worker_default-app.tar", location)
+	_, err = parseCheckpointResponse([]byte(`{"items":[]}`))
+	h.Assert(t, err != nil, "Expected a response without checkpoints to be rejected")
+	_, err = parseCheckpointResponse([]byte(`not json`))
+	h.Assert(t, err != nil, "Expected an invalid response to be rejected")
+}
@@ -0,0 +1,116 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// InterruptionConditionReason is the reason of every node condition published by node termination handler.
+// It distinguishes these conditions from those published by other components such as node-problem-detector.
+const InterruptionConditionReason = "InterruptionNoticeReceived"
+
+type conditionsPatch struct {
+	Status conditionsPatchStatus `json:"status"`
+}
+
+type conditionsPatchStatus struct {
+	Conditions []map[string]interface{} `json:"conditions"`
+}
+
+// SetInterruptionCondition publishes a node-problem-detector style permanent condition with the given type on the node.
+// The condition is applied along with the other interruption conditions on the node.
+func (n Node) SetInterruptionCondition(nodeName string, conditionType string, message string) error {
+	if !n.nthConfig.PublishNodeConditions {
+		return nil
+	}
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have set condition %s on node %s, but dry-run flag was set", conditionType, nodeName)
+		return nil
+	}
+	now := metav1.Now()
+	return retryOnConflict(func() error {
+		node, err := n.drainHelper.Client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		conditions := []corev1.NodeCondition{{
+			Type:               corev1.NodeConditionType(conditionType),
+			Status:             corev1.ConditionTrue,
+			Reason:             InterruptionConditionReason,
+			Message:            message,
+			LastHeartbeatTime:  now,
+			LastTransitionTime: now,
+		}}
+		for _, condition := range node.Status.Conditions {
+			if condition.Reason == InterruptionConditionReason && condition.Type != conditions[0].Type {
+				conditions = append(conditions, condition)
+			}
+		}
+		apply := newNodeApply(nodeName)
+		apply.Status = &nodeApplyStatus{Conditions: conditions}
+		_, err = applyNode(n.drainHelper.Client, apply, "status")
+		if err != nil {
+			return fmt.Errorf("%v node status apply failed when setting node condition %s: %w", nodeName, conditionType, err)
+		}
+		return nil
+	})
+}
+
+// RemoveInterruptionConditions removes all conditions published by node termination handler from the node with a
+// targeted patch
+func (n Node) RemoveInterruptionConditions(nodeName string) error {
+	if !n.nthConfig.PublishNodeConditions {
+		return nil
+	}
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have removed interruption conditions from node %s, but dry-run flag was set", nodeName)
+		return nil
+	}
+	return retryOnConflict(func() error {
+		node, err := n.fetchKubernetesNode(nodeName)
+		if err != nil {
+			return err
+		}
+		var deletions []map[string]interface{}
+		for _, condition := range node.Status.Conditions {
+			if condition.Reason == InterruptionConditionReason {
+				deletions = append(deletions, map[string]interface{}{"type": condition.Type, "$patch": "delete"})
+			}
+		}
+		if len(deletions) == 0 {
+			return nil
+		}
+		return n.patchConditions(node.Name, deletions)
+	})
+}
+
+func (n Node) patchConditions(nodeName string, conditions []map[string]interface{}) error {
+	payload, err := json.Marshal(conditionsPatch{Status: conditionsPatchStatus{Conditions: conditions}})
+	if err != nil {
+		return fmt.Errorf("An error occurred while marshalling the json to patch node conditions: %w", err)
+	}
+	_, err = n.drainHelper.Client.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.StrategicMergePatchType, payload, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return fmt.Errorf("%v node status Patch failed when updating node conditions: %w", nodeName, err)
+	}
+	return nil
+}
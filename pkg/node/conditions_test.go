@@ -0,0 +1,67 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetAndRemoveInterruptionCondition(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}},
+	})
+	tNode, err := node.NewWithValues(config.Config{PublishNodeConditions: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	err = tNode.SetInterruptionCondition(nodeName, "SpotInterruption", "Spot ITN received")
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, 2, len(k8sNode.Status.Conditions))
+	condition := getCondition(k8sNode, "SpotInterruption")
+	h.Assert(t, condition != nil, "Expected the SpotInterruption condition to be set")
+	h.Equals(t, v1.ConditionTrue, condition.Status)
+	h.Equals(t, node.InterruptionConditionReason, condition.Reason)
+
+	err = tNode.RemoveInterruptionConditions(nodeName)
+	h.Ok(t, err)
+	k8sNode, err = client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(k8sNode.Status.Conditions))
+	h.Assert(t, getCondition(k8sNode, v1.NodeReady) != nil, "Expected the Ready condition to be kept")
+}
+
+func TestSetInterruptionConditionDisabled(t *testing.T) {
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
+	err := tNode.SetInterruptionCondition(nodeName, "SpotInterruption", "Spot ITN received")
+	h.Ok(t, err)
+}
+
+func getCondition(k8sNode *v1.Node, conditionType v1.NodeConditionType) *v1.NodeCondition {
+	for i, condition := range k8sNode.Status.Conditions {
+		if condition.Type == conditionType {
+			return &k8sNode.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	// TerminationDeadlineAnnotation holds the RFC3339 time the instance of the node is interrupted at
+	TerminationDeadlineAnnotation = "aws-node-termination-handler/termination-deadline"
+	// TerminationCountdownAnnotation holds the seconds remaining until the instance of the node is interrupted, as of the
+	// last refresh
+	TerminationCountdownAnnotation = "aws-node-termination-handler/termination-countdown"
+)
+
+// UpdateTerminationCountdown annotates the node with the termination deadline and the seconds remaining until it, so
+// workloads watching the node can react to the countdown without polling the instance metadata service themselves
+func (n Node) UpdateTerminationCountdown(nodeName string, deadline time.Time, now time.Time) error {
+	remaining := int64(math.Ceil(deadline.Sub(now).Seconds()))
+	if remaining < 0 {
+		remaining = 0
+	}
+	return n.addAnnotations(nodeName, map[string]string{
+		TerminationDeadlineAnnotation:  deadline.UTC().Format(time.RFC3339),
+		TerminationCountdownAnnotation: strconv.FormatInt(remaining, 10),
+	})
+}
+
+// RemoveTerminationCountdown removes the termination countdown annotations from the node, e.g. once the interruption
+// was canceled
+func (n Node) RemoveTerminationCountdown(nodeName string) error {
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return err
+	}
+	for _, key := range []string{TerminationDeadlineAnnotation, TerminationCountdownAnnotation} {
+		if _, ok := node.Annotations[key]; !ok {
+			continue
+		}
+		err = n.removeAnnotation(nodeName, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateTerminationCountdown(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client)
+	tNode := getNode(t, getDrainHelper(client))
+	now := time.Date(2021, time.June, 5, 7, 58, 0, 0, time.UTC)
+	deadline := now.Add(90*time.Second + 500*time.Millisecond)
+
+	err := tNode.UpdateTerminationCountdown(nodeName, deadline, now)
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "2021-06-05T07:59:30Z", k8sNode.Annotations[node.TerminationDeadlineAnnotation])
+	h.Equals(t, "91", k8sNode.Annotations[node.TerminationCountdownAnnotation])
+
+	err = tNode.UpdateTerminationCountdown(nodeName, deadline, deadline.Add(time.Minute))
+	h.Ok(t, err)
+	k8sNode, err = client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "0", k8sNode.Annotations[node.TerminationCountdownAnnotation])
+}
+
+func TestRemoveTerminationCountdown(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client)
+	tNode := getNode(t, getDrainHelper(client))
+	err := tNode.RemoveTerminationCountdown(nodeName)
+	h.Ok(t, err)
+
+	err = tNode.UpdateTerminationCountdown(nodeName, time.Now().Add(time.Minute), time.Now())
+	h.Ok(t, err)
+	err = tNode.RemoveTerminationCountdown(nodeName)
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	_, deadlineFound := k8sNode.Annotations[node.TerminationDeadlineAnnotation]
+	_, countdownFound := k8sNode.Annotations[node.TerminationCountdownAnnotation]
+	h.Assert(t, !deadlineFound && !countdownFound, "Expected the termination countdown annotations to be removed")
+}
@@ -0,0 +1,153 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+// drainByDeletionCost drains the node like kubectl drain, except that replicas with a lower pod deletion cost are
+// evicted before their more expensive siblings
+func drainByDeletionCost(drainHelper *drain.Helper, nodeName string) error {
+	podList, errs := drainHelper.GetPodsForDeletion(nodeName)
+	if errs != nil {
+		return utilerrors.NewAggregate(errs)
+	}
+	if warnings := podList.Warnings(); warnings != "" {
+		fmt.Fprintf(drainHelper.ErrOut, "WARNING: %s\n", warnings)
+	}
+	return evictByDeletionCost(drainHelper, nodeName, podList.Pods())
+}
+
+// evictByDeletionCost evicts the pods of each controller in the rounds of their deletion costs, waiting for each round
+// to be gone. The rounds of different controllers don't wait for each other, and the pods of controllers with a single
+// round are evicted at once. All rounds share the drain timeout.
+func evictByDeletionCost(drainHelper *drain.Helper, nodeName string, pods []corev1.Pod) error {
+	var deadline time.Time
+	if drainHelper.Timeout > 0 {
+		deadline = time.Now().Add(drainHelper.Timeout)
+	}
+	groups := deletionCostGroups(pods)
+	errs := make([]error, len(groups))
+	var wg sync.WaitGroup
+	for i := range groups {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = evictRounds(drainHelper, nodeName, groups[i], deadline)
+		}(i)
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
+
+// evictRounds evicts the rounds in order, each within the time left until the deadline, if any
+func evictRounds(drainHelper *drain.Helper, nodeName string, rounds [][]corev1.Pod, deadline time.Time) error {
+	for i, round := range rounds {
+		roundHelper := *drainHelper
+		if !deadline.IsZero() {
+			roundHelper.Timeout = time.Until(deadline)
+			if roundHelper.Timeout <= 0 {
+				return fmt.Errorf("Evicting the pods of node %s in the order of their %s did not finish within %s", nodeName, corev1.PodDeletionCost, drainHelper.Timeout)
+			}
+		}
+		if len(rounds) > 1 {
+			log.Info().Str("node_name", nodeName).Str("controller", deletionCostOwner(round[0])).Int("round", i+1).Msgf("Evicting %d pods in the order of their %s", len(round), corev1.PodDeletionCost)
+		}
+		err := roundHelper.DeleteOrEvictPods(round)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletionCostGroups groups the pods into the eviction rounds of their controllers. Like the ReplicaSet controller when
+// scaling down, deletion costs are only compared between the pods of the same controller: a pod is evicted in the round
+// of the number of distinct lower costs among its siblings on the node. The controllers with several rounds have a group
+// each, the pods of the others are evicted together in the first group.
+func deletionCostGroups(pods []corev1.Pod) [][][]corev1.Pod {
+	if len(pods) == 0 {
+		return [][][]corev1.Pod{{pods}}
+	}
+	costs := map[string][]int32{}
+	owners := []string{}
+	for _, pod := range pods {
+		owner := deletionCostOwner(pod)
+		if _, ok := costs[owner]; !ok {
+			owners = append(owners, owner)
+		}
+		costs[owner] = append(costs[owner], podDeletionCost(pod))
+	}
+	for owner, ownerCosts := range costs {
+		sort.Slice(ownerCosts, func(i, j int) bool { return ownerCosts[i] < ownerCosts[j] })
+		distinct := []int32{}
+		for _, cost := range ownerCosts {
+			if len(distinct) == 0 || cost != distinct[len(distinct)-1] {
+				distinct = append(distinct, cost)
+			}
+		}
+		costs[owner] = distinct
+	}
+	groups := [][][]corev1.Pod{{{}}}
+	ownerGroups := map[string]int{}
+	for _, owner := range owners {
+		if len(costs[owner]) > 1 {
+			ownerGroups[owner] = len(groups)
+			groups = append(groups, make([][]corev1.Pod, len(costs[owner])))
+		}
+	}
+	for _, pod := range pods {
+		owner := deletionCostOwner(pod)
+		group, ok := ownerGroups[owner]
+		if !ok {
+			groups[0][0] = append(groups[0][0], pod)
+			continue
+		}
+		ownerCosts := costs[owner]
+		round := sort.Search(len(ownerCosts), func(i int) bool { return ownerCosts[i] >= podDeletionCost(pod) })
+		groups[group][round] = append(groups[group][round], pod)
+	}
+	if len(groups[0][0]) == 0 && len(groups) > 1 {
+		groups = groups[1:]
+	}
+	return groups
+}
+
+// deletionCostOwner returns the UID of the pod's controller, or the pod's own key if it has none
+func deletionCostOwner(pod corev1.Pod) string {
+	if controller := metav1.GetControllerOf(&pod); controller != nil {
+		return string(controller.UID)
+	}
+	return pod.Namespace + "/" + pod.Name
+}
+
+// podDeletionCost returns the deletion cost of the pod, 0 if it is not annotated or the annotation is invalid
+func podDeletionCost(pod corev1.Pod) int32 {
+	cost, err := strconv.ParseInt(pod.Annotations[corev1.PodDeletionCost], 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(cost)
+}
@@ -0,0 +1,77 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func podWithDeletionCost(name string, controllerUID types.UID, cost string) v1.Pod {
+	pod := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if controllerUID != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: string(controllerUID), UID: controllerUID, Controller: &controller}}
+	}
+	if cost != "" {
+		pod.Annotations = map[string]string{v1.PodDeletionCost: cost}
+	}
+	return pod
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestDrainByDeletionCost(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client,
+		podWithDeletionCost("web-expensive", "web", "100"),
+		podWithDeletionCost("web-default", "web", ""),
+		podWithDeletionCost("web-cheap", "web", "-10"),
+		podWithDeletionCost("web-also-cheap", "web", "-10"),
+		podWithDeletionCost("cache-0", "cache", "1000"),
+		podWithDeletionCost("standalone", "", "5"))
+	deleted := recordPodDeletions(client)
+
+	h.Ok(t, getNodeWithDrainStrategy(t, client, node.DrainStrategyDrain).CordonAndDrain(nodeName))
+	h.Equals(t, 6, len(*deleted))
+	// only the replicas of the same controller wait for each other
+	for _, firstRound := range []string{"web-cheap", "web-also-cheap"} {
+		h.Assert(t, indexOf(*deleted, firstRound) < indexOf(*deleted, "web-default"), "Expected %s to be evicted before web-default, evicted %v", firstRound, *deleted)
+	}
+	h.Assert(t, indexOf(*deleted, "web-default") < indexOf(*deleted, "web-expensive"), "Expected web-default to be evicted before web-expensive, evicted %v", *deleted)
+}
+
+func TestPriorityTieredDrainByDeletionCost(t *testing.T) {
+	client := h.NewFakeClientset()
+	expensive := podWithDeletionCost("web-expensive", "web", "100")
+	cheap := podWithDeletionCost("web-cheap", "web", "1")
+	batch := podWithPriority("batch", -10)
+	createNodeWithPods(t, client, expensive, batch, cheap)
+	deleted := recordPodDeletions(client)
+
+	h.Ok(t, getNodeWithDrainStrategy(t, client, node.DrainStrategyPriorityTiered).CordonAndDrain(nodeName))
+	h.Equals(t, []string{"batch", "web-cheap", "web-expensive"}, *deleted)
+}
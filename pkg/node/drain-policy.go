@@ -0,0 +1,170 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/drainpolicy"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+var preStopRequestTimeout = 10 * time.Second
+
+// PreStopRequest is the body of the POST request sent to the pre-stop URL of a drain policy before a pod is evicted
+type PreStopRequest struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Node      string `json:"node"`
+}
+
+// policyTier is a group of pods evicted together because their policies share an order, grace period and pre-stop URL
+type policyTier struct {
+	spec drainpolicy.DrainPolicySpec
+	pods []corev1.Pod
+}
+
+// WithDrainPolicies returns a copy of the node which consults the drain policies of the lister when draining
+func (n Node) WithDrainPolicies(lister drainpolicy.Lister) Node {
+	n.drainPolicies = lister
+	return n
+}
+
+// drainWithPolicies lets the drain strategy evict the pods selected by drain policies with a negative order, then the
+// pods without a policy, then the other selected pods. Opted out pods are left running.
+func (n Node) drainWithPolicies(node *corev1.Node) error {
+	policies, err := n.drainPolicies.List()
+	if err != nil {
+		log.Warn().Err(err).Msg("Unable to list drain policies, draining without them")
+		return n.drainWithStrategy(node)
+	}
+	podList, errs := n.drainHelper.GetPodsForDeletion(node.Name)
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to list pods for deletion on node %s: %v", node.Name, errs)
+	}
+	selected := map[string]bool{}
+	tiers := map[string]*policyTier{}
+	for _, pod := range podList.Pods() {
+		policy, ok := drainpolicy.Match(policies, pod)
+		if !ok {
+			continue
+		}
+		selected[pod.Namespace+"/"+pod.Name] = true
+		if policy.Spec.OptOut {
+			log.Info().Str("pod", pod.Namespace+"/"+pod.Name).Str("drain_policy", policy.Namespace+"/"+policy.Name).Msg("Pod is opted out of draining")
+			continue
+		}
+		key := tierKey(policy.Spec)
+		if tiers[key] == nil {
+			tiers[key] = &policyTier{spec: policy.Spec}
+		}
+		tiers[key].pods = append(tiers[key].pods, pod)
+	}
+	sortedTiers := make([]*policyTier, 0, len(tiers))
+	for _, tier := range tiers {
+		sortedTiers = append(sortedTiers, tier)
+	}
+	sort.SliceStable(sortedTiers, func(i, j int) bool { return sortedTiers[i].spec.Order < sortedTiers[j].spec.Order })
+
+	for _, tier := range sortedTiers {
+		if tier.spec.Order < 0 {
+			err = n.drainPolicyTier(node, tier)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	unselected := n.withPodFilter(func(pod corev1.Pod) bool { return !selected[pod.Namespace+"/"+pod.Name] })
+	err = unselected.drainWithStrategy(node)
+	if err != nil {
+		return err
+	}
+	for _, tier := range sortedTiers {
+		if tier.spec.Order >= 0 {
+			err = n.drainPolicyTier(node, tier)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// drainPolicyTier lets the drain strategy evict the pods of the tier, with the grace period of their policies
+func (n Node) drainPolicyTier(node *corev1.Node, tier *policyTier) error {
+	inTier := map[string]bool{}
+	for _, pod := range tier.pods {
+		inTier[pod.Namespace+"/"+pod.Name] = true
+		if tier.spec.PreStopURL == "" {
+			continue
+		}
+		err := sendPreStopRequest(tier.spec.PreStopURL, PreStopRequest{Namespace: pod.Namespace, Pod: pod.Name, Node: node.Name})
+		if err != nil {
+			log.Warn().Err(err).Str("pod", pod.Namespace+"/"+pod.Name).Msg("Pre-stop request of the drain policy failed, evicting the pod anyway")
+		}
+	}
+	tierNode := n.withPodFilter(func(pod corev1.Pod) bool { return inTier[pod.Namespace+"/"+pod.Name] })
+	if tier.spec.GracePeriodSeconds != nil {
+		tierNode.drainHelper.GracePeriodSeconds = *tier.spec.GracePeriodSeconds
+	}
+	log.Info().Str("node_name", node.Name).Int("order", tier.spec.Order).Msgf("Evicting %d pods selected by drain policies", len(tier.pods))
+	return tierNode.drainWithStrategy(node)
+}
+
+// withPodFilter returns a copy of the node whose drain helper skips the pods the filter doesn't keep, so drain
+// strategies listing the pods through the drain helper leave them running
+func (n Node) withPodFilter(keep func(pod corev1.Pod) bool) Node {
+	filtered := n
+	drainHelper := *n.drainHelper
+	drainHelper.AdditionalFilters = append(append([]drain.PodFilter{}, n.drainHelper.AdditionalFilters...), func(pod corev1.Pod) drain.PodDeleteStatus {
+		if !keep(pod) {
+			return drain.MakePodDeleteStatusSkip()
+		}
+		return drain.MakePodDeleteStatusOkay()
+	})
+	filtered.drainHelper = &drainHelper
+	return filtered
+}
+
+func tierKey(spec drainpolicy.DrainPolicySpec) string {
+	gracePeriod := "default"
+	if spec.GracePeriodSeconds != nil {
+		gracePeriod = fmt.Sprint(*spec.GracePeriodSeconds)
+	}
+	return fmt.Sprintf("%d/%s/%s", spec.Order, gracePeriod, spec.PreStopURL)
+}
+
+func sendPreStopRequest(url string, request PreStopRequest) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: preStopRequestTimeout}
+	response, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("pre-stop URL %s responded with status %d", url, response.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/drainpolicy"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type staticDrainPolicies []drainpolicy.DrainPolicy
+
+func (p staticDrainPolicies) List() ([]drainpolicy.DrainPolicy, error) {
+	return p, nil
+}
+
+func labeledPod(name string, app string) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"app": app}}}
+}
+
+func drainPolicy(app string, spec drainpolicy.DrainPolicySpec) drainpolicy.DrainPolicy {
+	spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}
+	return drainpolicy.DrainPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: app}, Spec: spec}
+}
+
+func TestDrainWithPolicies(t *testing.T) {
+	preStopRequests := make(chan node.PreStopRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := node.PreStopRequest{}
+		h.Ok(t, json.NewDecoder(r.Body).Decode(&request))
+		preStopRequests <- request
+	}))
+	defer server.Close()
+
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, labeledPod("db", "db"), labeledPod("web", "web"), labeledPod("cache", "cache"), labeledPod("agent", "agent"))
+	deleted := recordPodDeletions(client)
+	tNode := getNodeWithDrainStrategy(t, client, node.DrainStrategyDrain).WithDrainPolicies(staticDrainPolicies{
+		drainPolicy("cache", drainpolicy.DrainPolicySpec{Order: -1, PreStopURL: server.URL}),
+		drainPolicy("db", drainpolicy.DrainPolicySpec{Order: 1}),
+		drainPolicy("agent", drainpolicy.DrainPolicySpec{OptOut: true}),
+	})
+
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"cache", "web", "db"}, *deleted)
+	h.Equals(t, node.PreStopRequest{Namespace: "default", Pod: "cache", Node: nodeName}, <-preStopRequests)
+}
+
+func TestDrainWithPoliciesUsesDrainStrategy(t *testing.T) {
+	drained := [][]string{}
+	node.RegisterDrainStrategy("test-policy-tiers", node.DrainStrategyFunc(func(n node.Node, k8sNode *v1.Node) error {
+		podList, errs := n.DrainHelper().GetPodsForDeletion(k8sNode.Name)
+		h.Equals(t, 0, len(errs))
+		pods := []string{}
+		for _, pod := range podList.Pods() {
+			pods = append(pods, pod.Name)
+		}
+		drained = append(drained, pods)
+		return nil
+	}))
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, labeledPod("db", "db"), labeledPod("web", "web"), labeledPod("cache", "cache"))
+	tNode := getNodeWithDrainStrategy(t, client, "test-policy-tiers").WithDrainPolicies(staticDrainPolicies{
+		drainPolicy("cache", drainpolicy.DrainPolicySpec{Order: -1}),
+		drainPolicy("db", drainpolicy.DrainPolicySpec{Order: 1}),
+	})
+
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, [][]string{{"cache"}, {"web"}, {"db"}}, drained)
+}
+
+func TestDrainWithPoliciesSharesDrainTimeout(t *testing.T) {
+	timeouts := []time.Duration{}
+	node.RegisterDrainStrategy("test-policy-timeouts", node.DrainStrategyFunc(func(n node.Node, k8sNode *v1.Node) error {
+		timeouts = append(timeouts, n.DrainHelper().Timeout)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}))
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, labeledPod("db", "db"), labeledPod("web", "web"), labeledPod("cache", "cache"))
+	tNode := getNodeWithDrainStrategy(t, client, "test-policy-timeouts").WithDrainPolicies(staticDrainPolicies{
+		drainPolicy("cache", drainpolicy.DrainPolicySpec{Order: -1}),
+		drainPolicy("db", drainpolicy.DrainPolicySpec{Order: 1}),
+	}).WithDrainTimeout(time.Minute)
+
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, 3, len(timeouts))
+	h.Assert(t, timeouts[0] <= time.Minute, "Expected the first tier to get at most the drain timeout, got %s", timeouts[0])
+	for i := 1; i < len(timeouts); i++ {
+		h.Assert(t, timeouts[i] <= timeouts[i-1]-10*time.Millisecond, "Expected each tier to get the time left, got %v", timeouts)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+const (
+	// DrainStrategyDrain evicts pods like kubectl drain, the replicas with a lower pod deletion cost first
+	DrainStrategyDrain = "drain"
+	// DrainStrategyTaintAndWait taints the node with NoExecute and waits for the taint manager to delete pods
+	DrainStrategyTaintAndWait = "taint-and-wait"
+	// DrainStrategyPriorityTiered evicts pods in tiers of ascending priority, waiting for each tier to be gone
+	DrainStrategyPriorityTiered = "priority-tiered"
+	// DrainStrategyLabelTiered evicts pods in the tiers of the eviction-tiers label selectors, waiting for each tier to be gone
+	DrainStrategyLabelTiered = "label-tiered"
+	// DrainStrategyDeleteOnly deletes pods without the eviction API, so pod disruption budgets are not honored
+	DrainStrategyDeleteOnly = "delete-only"
+
+	// DrainingTaint is the NoExecute taint placed on nodes drained with the taint-and-wait strategy
+	DrainingTaint = "aws-node-termination-handler/draining"
+)
+
+var taintAndWaitPollInterval = 2 * time.Second
+
+// DrainStrategy removes the pods from a cordoned node
+type DrainStrategy interface {
+	Drain(n Node, node *corev1.Node) error
+}
+
+// DrainStrategyFunc adapts a function to the DrainStrategy interface
+type DrainStrategyFunc func(n Node, node *corev1.Node) error
+
+// Drain calls the function
+func (f DrainStrategyFunc) Drain(n Node, node *corev1.Node) error {
+	return f(n, node)
+}
+
+var (
+	drainStrategiesLock sync.RWMutex
+	drainStrategies     = map[string]DrainStrategy{
+		DrainStrategyDrain:          DrainStrategyFunc(kubectlDrain),
+		DrainStrategyTaintAndWait:   DrainStrategyFunc(taintAndWait),
+		DrainStrategyPriorityTiered: DrainStrategyFunc(priorityTieredDrain),
+		DrainStrategyLabelTiered:    DrainStrategyFunc(labelTieredDrain),
+		DrainStrategyDeleteOnly:     DrainStrategyFunc(deleteOnlyDrain),
+	}
+)
+
+// RegisterDrainStrategy makes a custom drain strategy selectable by name with the drain-strategy flag. It is meant to be
+// called from the init function of a package compiled into the binary.
+func RegisterDrainStrategy(name string, strategy DrainStrategy) {
+	drainStrategiesLock.Lock()
+	defer drainStrategiesLock.Unlock()
+	drainStrategies[name] = strategy
+}
+
+// DrainStrategyNames returns the sorted names of the registered drain strategies
+func DrainStrategyNames() []string {
+	drainStrategiesLock.RLock()
+	defer drainStrategiesLock.RUnlock()
+	names := make([]string, 0, len(drainStrategies))
+	for name := range drainStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getDrainStrategy(name string) (DrainStrategy, error) {
+	if name == "" {
+		name = DrainStrategyDrain
+	}
+	drainStrategiesLock.RLock()
+	strategy, ok := drainStrategies[name]
+	drainStrategiesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Invalid drain-strategy passed: %s  Should be one of: %s", name, strings.Join(DrainStrategyNames(), ", "))
+	}
+	return strategy, nil
+}
+
+// DrainHelper returns the kubectl drain helper of the node, for custom drain strategies
+func (n Node) DrainHelper() *drain.Helper {
+	return n.drainHelper
+}
+
+func kubectlDrain(n Node, node *corev1.Node) error {
+	return drainByDeletionCost(n.drainHelper, node.Name)
+}
+
+func deleteOnlyDrain(n Node, node *corev1.Node) error {
+	drainHelper := *n.drainHelper
+	drainHelper.DisableEviction = true
+	return drainByDeletionCost(&drainHelper, node.Name)
+}
+
+// priorityTieredDrain evicts the pods with the lowest priority first, so higher priority workloads keep serving until
+// the pods they may depend on are gone
+func priorityTieredDrain(n Node, node *corev1.Node) error {
+	podList, errs := n.drainHelper.GetPodsForDeletion(node.Name)
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to list pods for deletion on node %s: %v", node.Name, errs)
+	}
+	tiers := map[int32][]corev1.Pod{}
+	for _, pod := range podList.Pods() {
+		priority := podPriority(pod)
+		tiers[priority] = append(tiers[priority], pod)
+	}
+	priorities := make([]int32, 0, len(tiers))
+	for priority := range tiers {
+		priorities = append(priorities, priority)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+	for _, priority := range priorities {
+		log.Info().Str("node_name", node.Name).Int32("priority", priority).Msgf("Evicting %d pods", len(tiers[priority]))
+		err := evictByDeletionCost(n.drainHelper, node.Name, tiers[priority])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelTieredDrain evicts the pods matching none of the eviction tiers first, then the pods of each tier in order, so
+// the most restart-sensitive workloads listed last keep serving the longest. A pod belongs to the first tier it matches.
+func labelTieredDrain(n Node, node *corev1.Node) error {
+	podList, errs := n.drainHelper.GetPodsForDeletion(node.Name)
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to list pods for deletion on node %s: %v", node.Name, errs)
+	}
+	tiers := make([][]corev1.Pod, len(n.evictionTiers)+1)
+	for _, pod := range podList.Pods() {
+		tier := 0
+		for i, selector := range n.evictionTiers {
+			if selector.Matches(labels.Set(pod.Labels)) {
+				tier = i + 1
+				break
+			}
+		}
+		tiers[tier] = append(tiers[tier], pod)
+	}
+	for i, pods := range tiers {
+		if len(pods) == 0 {
+			continue
+		}
+		selector := "none"
+		if i > 0 {
+			selector = n.evictionTiers[i-1].String()
+		}
+		log.Info().Str("node_name", node.Name).Str("tier", selector).Msgf("Evicting %d pods", len(pods))
+		err := evictByDeletionCost(n.drainHelper, node.Name, pods)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseEvictionTiers parses the semicolon separated label selectors of the eviction-tiers flag
+func parseEvictionTiers(evictionTiers string) ([]labels.Selector, error) {
+	selectors := []labels.Selector{}
+	for _, tier := range strings.Split(evictionTiers, ";") {
+		tier = strings.TrimSpace(tier)
+		if tier == "" {
+			continue
+		}
+		selector, err := labels.Parse(tier)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid eviction tier %q passed: %w", tier, err)
+		}
+		selectors = append(selectors, selector)
+	}
+	return selectors, nil
+}
+
+func podPriority(pod corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// taintAndWait leaves deleting pods to the taint manager, which honors the tolerationSeconds of pods tolerating the taint
+// temporarily, and waits until the pods which don't tolerate it are gone or the drain timeout passed
+func taintAndWait(n Node, node *corev1.Node) error {
+	err := addTaint(node, n, DrainingTaint, "", corev1.TaintEffectNoExecute)
+	if err != nil {
+		return err
+	}
+	var deadline time.Time
+	if n.drainHelper.Timeout > 0 {
+		deadline = time.Now().Add(n.drainHelper.Timeout)
+	}
+	for {
+		remaining, err := n.podsNotToleratingDrain(node.Name)
+		if err != nil {
+			return err
+		}
+		if remaining == 0 {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("%d pods are still running on node %s after %s", remaining, node.Name, n.drainHelper.Timeout)
+		}
+		time.Sleep(taintAndWaitPollInterval)
+	}
+}
+
+func (n Node) podsNotToleratingDrain(nodeName string) (int, error) {
+	podList, errs := n.drainHelper.GetPodsForDeletion(nodeName)
+	if len(errs) > 0 {
+		return 0, fmt.Errorf("Unable to list pods for deletion on node %s: %v", nodeName, errs)
+	}
+	drainingTaint := corev1.Taint{Key: DrainingTaint, Effect: corev1.TaintEffectNoExecute}
+	remaining := 0
+	for _, pod := range podList.Pods() {
+		if !tolerationsTolerateTaintForever(pod.Spec.Tolerations, &drainingTaint) {
+			remaining++
+		}
+	}
+	return remaining, nil
+}
+
+func tolerationsTolerateTaintForever(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for _, toleration := range tolerations {
+		if toleration.ToleratesTaint(taint) && toleration.TolerationSeconds == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTaint(node *corev1.Node, taintKey string) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == taintKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func getNodeWithDrainStrategy(t *testing.T, client *fake.Clientset, drainStrategy string) *node.Node {
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, DrainStrategy: drainStrategy}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	return tNode
+}
+
+func createNodeWithPods(t *testing.T, client *fake.Clientset, pods ...v1.Pod) {
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	for i := range pods {
+		pods[i].Namespace = "default"
+		pods[i].Spec.NodeName = nodeName
+		_, err = client.CoreV1().Pods("default").Create(context.Background(), &pods[i], metav1.CreateOptions{})
+		h.Ok(t, err)
+	}
+}
+
+func podWithPriority(name string, priority int32) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: v1.PodSpec{Priority: &priority}}
+}
+
+func recordPodDeletions(client *fake.Clientset) *[]string {
+	deleted := []string{}
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleted = append(deleted, action.(k8stesting.DeleteAction).GetName())
+		return false, nil, nil
+	})
+	return &deleted
+}
+
+func TestDrainStrategyInvalid(t *testing.T) {
+	_, err := node.NewWithValues(config.Config{DrainStrategy: "bogus"}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Assert(t, err != nil, "Expected an unknown drain strategy to be rejected")
+}
+
+func TestRegisterDrainStrategy(t *testing.T) {
+	drained := ""
+	node.RegisterDrainStrategy("test-custom", node.DrainStrategyFunc(func(n node.Node, k8sNode *v1.Node) error {
+		drained = k8sNode.Name
+		return nil
+	}))
+	h.Assert(t, contains(node.DrainStrategyNames(), "test-custom"), "Expected the custom strategy to be registered")
+
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client)
+	err := getNodeWithDrainStrategy(t, client, "test-custom").CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, nodeName, drained)
+}
+
+func TestPriorityTieredDrain(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, podWithPriority("critical", 1000), podWithPriority("batch", -10), podWithPriority("web", 0))
+	deleted := recordPodDeletions(client)
+	err := getNodeWithDrainStrategy(t, client, node.DrainStrategyPriorityTiered).CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"batch", "web", "critical"}, *deleted)
+}
+
+func podWithLabels(name string, podLabels map[string]string) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: podLabels}}
+}
+
+func TestLabelTieredDrain(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client,
+		podWithLabels("database", map[string]string{"tier": "stateful"}),
+		podWithLabels("web", map[string]string{"app": "web"}),
+		podWithLabels("monitoring", nil),
+		podWithLabels("batch", map[string]string{"tier": "batch", "app": "web"}))
+	deleted := recordPodDeletions(client)
+	nthConfig := config.Config{NodeName: nodeName, DrainStrategy: node.DrainStrategyLabelTiered, EvictionTiers: "tier=batch; app=web ;tier in (stateful,database)"}
+	tNode, err := node.NewWithValues(nthConfig, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	err = tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"monitoring", "batch", "web", "database"}, *deleted)
+}
+
+func TestLabelTieredDrainRequiresTiers(t *testing.T) {
+	_, err := node.NewWithValues(config.Config{DrainStrategy: node.DrainStrategyLabelTiered}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Assert(t, err != nil, "Expected the label-tiered strategy without eviction tiers to be rejected")
+	_, err = node.NewWithValues(config.Config{DrainStrategy: node.DrainStrategyLabelTiered, EvictionTiers: "tier=batch;app in web"}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Assert(t, err != nil, "Expected an invalid eviction tier to be rejected")
+}
+
+func TestDeleteOnlyDrain(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, podWithPriority("web", 0))
+	deleted := recordPodDeletions(client)
+	err := getNodeWithDrainStrategy(t, client, node.DrainStrategyDeleteOnly).CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"web"}, *deleted)
+}
+
+func TestTaintAndWaitDrain(t *testing.T) {
+	client := h.NewFakeClientset()
+	tolerating := podWithPriority("agent", 0)
+	tolerating.Spec.Tolerations = []v1.Toleration{{Operator: v1.TolerationOpExists}}
+	createNodeWithPods(t, client, tolerating)
+	tNode := getNodeWithDrainStrategy(t, client, node.DrainStrategyTaintAndWait)
+
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Assert(t, hasTaintKey(k8sNode, node.DrainingTaint), "Expected the node to be tainted")
+
+	err = tNode.Uncordon(nodeName)
+	h.Ok(t, err)
+	k8sNode, err = client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Assert(t, !hasTaintKey(k8sNode, node.DrainingTaint), "Expected the taint to be removed when uncordoning")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTaintKey(k8sNode *v1.Node, key string) bool {
+	for _, taint := range k8sNode.Spec.Taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DrainedAnnotation is set once node termination handler drained the node, to "<unix time>/<drain kind>". Handlers
+	// monitoring the instance metadata and the queue check it, so an interruption both report drains the node once.
+	DrainedAnnotation = "aws-node-termination-handler/drained"
+
+	drainKindInterruption = "interruption"
+	drainKindRebalance    = "rebalance"
+	// drainClaimSuffix marks the annotation of a node which a handler claimed and is still draining
+	drainClaimSuffix = "/draining"
+)
+
+// drainClaimCheckInterval is how often a node which is drained by another handler is checked for the end of its drain
+var drainClaimCheckInterval = 5 * time.Second
+
+func drainKind(rebalance bool) string {
+	if rebalance {
+		return drainKindRebalance
+	}
+	return drainKindInterruption
+}
+
+// MarkDrained annotates the node as drained for an interruption, or a rebalance recommendation, replacing its claim
+func (n Node) MarkDrained(nodeName string, rebalance bool) error {
+	value := fmt.Sprintf("%d/%s", time.Now().Unix(), drainKind(rebalance))
+	return n.addAnnotation(nodeName, DrainedAnnotation, value)
+}
+
+// DrainedWithin returns true if the node was drained within the window for an event which covers the event to handle:
+// a drain for an interruption covers any event, a drain for a rebalance recommendation only other rebalance recommendations
+func (n Node) DrainedWithin(nodeName string, window time.Duration, rebalance bool) (bool, error) {
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return false, err
+	}
+	value, ok := node.Annotations[DrainedAnnotation]
+	if !ok {
+		return false, nil
+	}
+	drainedAt, kind, claimed, err := parseDrained(nodeName, value)
+	if err != nil {
+		return false, err
+	}
+	if claimed || time.Since(drainedAt) > window {
+		return false, nil
+	}
+	return kind == drainKindInterruption || rebalance, nil
+}
+
+// ClaimDrain annotates the node as being drained for the event before it is drained, so handlers monitoring the instance
+// metadata and the queue don't drain it for the same interruption at the same time. While another handler's claim within
+// the window is held, ClaimDrain waits for its drain to finish. False is returned if the node was drained within the
+// window for an event which covers the event to handle, see DrainedWithin.
+func (n Node) ClaimDrain(nodeName string, window time.Duration, rebalance bool) (bool, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have claimed draining node %s, but dry-run flag was set", nodeName)
+		return true, nil
+	}
+	for {
+		claimed, draining, err := n.tryClaimDrain(nodeName, window, rebalance)
+		if err != nil || !draining {
+			return claimed, err
+		}
+		log.Info().Str("node_name", nodeName).Msg("Node is being drained by another handler, waiting for its drain to finish")
+		time.Sleep(drainClaimCheckInterval)
+	}
+}
+
+// tryClaimDrain claims draining the node unless it was drained within the window for a covering event, or another
+// handler holds a claim within the window. The node is updated with its resource version, so only one handler claims it.
+func (n Node) tryClaimDrain(nodeName string, window time.Duration, rebalance bool) (claimed bool, draining bool, err error) {
+	err = retryOnConflict(func() error {
+		claimed, draining = false, false
+		node, err := n.drainHelper.Client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if value, ok := node.Annotations[DrainedAnnotation]; ok {
+			drainedAt, kind, claimedByOther, err := parseDrained(nodeName, value)
+			if err != nil {
+				return err
+			}
+			if time.Since(drainedAt) <= window {
+				if claimedByOther {
+					draining = true
+					return nil
+				}
+				if kind == drainKindInterruption || rebalance {
+					return nil
+				}
+			}
+		}
+		if node.Annotations == nil {
+			node.Annotations = map[string]string{}
+		}
+		node.Annotations[DrainedAnnotation] = fmt.Sprintf("%d/%s%s", time.Now().Unix(), drainKind(rebalance), drainClaimSuffix)
+		_, err = n.drainHelper.Client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		claimed = true
+		return nil
+	})
+	return claimed, draining, err
+}
+
+// ReleaseDrainClaim removes the claim of a node which was not drained after all, e.g. as the drain failed, so other
+// handlers drain it
+func (n Node) ReleaseDrainClaim(nodeName string) error {
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(node.Annotations[DrainedAnnotation], drainClaimSuffix) {
+		return nil
+	}
+	return n.removeAnnotation(nodeName, DrainedAnnotation)
+}
+
+// parseDrained parses the drained annotation, "<unix time>/<drain kind>" with the claim suffix while the node is drained
+func parseDrained(nodeName string, value string) (time.Time, string, bool, error) {
+	claimed := strings.HasSuffix(value, drainClaimSuffix)
+	parts := strings.SplitN(strings.TrimSuffix(value, drainClaimSuffix), "/", 2)
+	drainedAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || len(parts) != 2 {
+		return time.Time{}, "", false, fmt.Errorf("Unable to parse the %s annotation %q of node %s", DrainedAnnotation, value, nodeName)
+	}
+	return time.Unix(drainedAt, 0), parts[1], claimed, nil
+}
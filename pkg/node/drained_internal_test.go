@@ -0,0 +1,46 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestClaimDrainWaitsForTheDrainOfAnotherHandler(t *testing.T) {
+	defer func(interval time.Duration) { drainClaimCheckInterval = interval }(drainClaimCheckInterval)
+	drainClaimCheckInterval = 10 * time.Millisecond
+	client := h.NewFakeClientset()
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	tNode := getNode(t, getTestDrainHelper(client), nil)
+
+	claimed, err := tNode.ClaimDrain("node", time.Minute, false)
+	h.Ok(t, err)
+	h.Assert(t, claimed, "Expected the first handler to claim the node")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if err := tNode.MarkDrained("node", false); err != nil {
+			t.Error(err)
+		}
+	}()
+	claimed, err = tNode.ClaimDrain("node", time.Minute, false)
+	h.Ok(t, err)
+	h.Assert(t, !claimed, "Expected the second handler to wait for the drain and handle its event as a duplicate")
+}
@@ -0,0 +1,105 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDrainedWithin(t *testing.T) {
+	client := h.NewFakeClientset()
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	tNode := getNode(t, getDrainHelper(client))
+
+	drained, err := tNode.DrainedWithin(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, false, drained)
+
+	h.Ok(t, tNode.MarkDrained(nodeName, true))
+	drained, err = tNode.DrainedWithin(nodeName, time.Minute, true)
+	h.Ok(t, err)
+	h.Equals(t, true, drained)
+	drained, err = tNode.DrainedWithin(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, false, drained)
+
+	h.Ok(t, tNode.MarkDrained(nodeName, false))
+	drained, err = tNode.DrainedWithin(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, true, drained)
+
+	h.Ok(t, tNode.Uncordon(nodeName))
+	drained, err = tNode.DrainedWithin(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, false, drained)
+}
+
+func TestDrainedWithinExpired(t *testing.T) {
+	client := h.NewFakeClientset()
+	annotations := map[string]string{node.DrainedAnnotation: fmt.Sprintf("%d/interruption", time.Now().Add(-time.Hour).Unix())}
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Annotations: annotations}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	tNode := getNode(t, getDrainHelper(client))
+
+	drained, err := tNode.DrainedWithin(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, false, drained)
+}
+
+func TestClaimDrain(t *testing.T) {
+	client := h.NewFakeClientset()
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	tNode := getNode(t, getDrainHelper(client))
+
+	claimed, err := tNode.ClaimDrain(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, true, claimed)
+	drained, err := tNode.DrainedWithin(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, false, drained)
+
+	// a failed drain releases the claim, so the node is claimed again
+	h.Ok(t, tNode.ReleaseDrainClaim(nodeName))
+	claimed, err = tNode.ClaimDrain(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, true, claimed)
+
+	h.Ok(t, tNode.MarkDrained(nodeName, false))
+	h.Ok(t, tNode.ReleaseDrainClaim(nodeName))
+	claimed, err = tNode.ClaimDrain(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, false, claimed)
+}
+
+func TestClaimDrainIgnoresExpiredClaims(t *testing.T) {
+	client := h.NewFakeClientset()
+	annotations := map[string]string{node.DrainedAnnotation: fmt.Sprintf("%d/interruption/draining", time.Now().Add(-time.Hour).Unix())}
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Annotations: annotations}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	tNode := getNode(t, getDrainHelper(client))
+
+	claimed, err := tNode.ClaimDrain(nodeName, time.Minute, false)
+	h.Ok(t, err)
+	h.Equals(t, true, claimed)
+}
@@ -0,0 +1,150 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	policyv1beta1client "k8s.io/client-go/kubernetes/typed/policy/v1beta1"
+)
+
+// Reasons of failed evictions and pod deletions
+const (
+	// EvictionFailurePDB is an eviction rejected because it would violate a pod disruption budget
+	EvictionFailurePDB = "pdb"
+	// EvictionFailureTooManyRequests is a request throttled by the API server, other than by a pod disruption budget
+	EvictionFailureTooManyRequests = "too-many-requests"
+	// EvictionFailureTimeout is a request which timed out
+	EvictionFailureTimeout = "timeout"
+	// EvictionFailureNotFound is a pod which was gone already
+	EvictionFailureNotFound = "not-found"
+	// EvictionFailureForbidden is a request node termination handler isn't allowed to make
+	EvictionFailureForbidden = "forbidden"
+	// EvictionFailureOther is any other failure
+	EvictionFailureOther = "other"
+
+	// disruptionBudgetCause is the cause newer API servers add to evictions rejected by pod disruption budgets. Older ones
+	// only tell by the message.
+	disruptionBudgetCause = "DisruptionBudget"
+)
+
+// WithEvictionFailureHandler returns a copy of the node which calls the handler with the reason of every failed
+// eviction or pod deletion attempt while draining. Blocked evictions are retried, so every attempt is reported. The
+// handler is called concurrently.
+func (n Node) WithEvictionFailureHandler(handler func(reason string)) Node {
+	drainHelper := *n.drainHelper
+	drainHelper.Client = evictionFailureClient{Interface: n.drainHelper.Client, handler: handler}
+	n.drainHelper = &drainHelper
+	return n
+}
+
+// EvictionFailureReason classifies the error of a failed eviction or pod deletion
+func EvictionFailureReason(err error) string {
+	var netErr net.Error
+	switch {
+	case apierrors.IsTooManyRequests(err) && isDisruptionBudgetViolation(err):
+		return EvictionFailurePDB
+	case apierrors.IsTooManyRequests(err):
+		return EvictionFailureTooManyRequests
+	case apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		return EvictionFailureTimeout
+	case apierrors.IsNotFound(err):
+		return EvictionFailureNotFound
+	case apierrors.IsForbidden(err):
+		return EvictionFailureForbidden
+	}
+	return EvictionFailureOther
+}
+
+func isDisruptionBudgetViolation(err error) bool {
+	var statusErr apierrors.APIStatus
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	status := statusErr.Status()
+	if status.Details != nil {
+		for _, cause := range status.Details.Causes {
+			if cause.Type == disruptionBudgetCause {
+				return true
+			}
+		}
+	}
+	return strings.Contains(status.Message, "disruption budget")
+}
+
+// evictionFailureClient reports the failed evictions and pod deletions of the drain helper
+type evictionFailureClient struct {
+	kubernetes.Interface
+	handler func(reason string)
+}
+
+func (c evictionFailureClient) PolicyV1beta1() policyv1beta1client.PolicyV1beta1Interface {
+	return evictionFailurePolicyClient{PolicyV1beta1Interface: c.Interface.PolicyV1beta1(), handler: c.handler}
+}
+
+func (c evictionFailureClient) CoreV1() corev1client.CoreV1Interface {
+	return evictionFailureCoreClient{CoreV1Interface: c.Interface.CoreV1(), handler: c.handler}
+}
+
+type evictionFailurePolicyClient struct {
+	policyv1beta1client.PolicyV1beta1Interface
+	handler func(reason string)
+}
+
+func (c evictionFailurePolicyClient) Evictions(namespace string) policyv1beta1client.EvictionInterface {
+	return evictionFailureEvictions{EvictionInterface: c.PolicyV1beta1Interface.Evictions(namespace), handler: c.handler}
+}
+
+type evictionFailureEvictions struct {
+	policyv1beta1client.EvictionInterface
+	handler func(reason string)
+}
+
+func (e evictionFailureEvictions) Evict(ctx context.Context, eviction *policyv1beta1.Eviction) error {
+	err := e.EvictionInterface.Evict(ctx, eviction)
+	if err != nil {
+		e.handler(EvictionFailureReason(err))
+	}
+	return err
+}
+
+type evictionFailureCoreClient struct {
+	corev1client.CoreV1Interface
+	handler func(reason string)
+}
+
+func (c evictionFailureCoreClient) Pods(namespace string) corev1client.PodInterface {
+	return evictionFailurePods{PodInterface: c.CoreV1Interface.Pods(namespace), handler: c.handler}
+}
+
+type evictionFailurePods struct {
+	corev1client.PodInterface
+	handler func(reason string)
+}
+
+func (p evictionFailurePods) Delete(ctx context.Context, name string, options metav1.DeleteOptions) error {
+	err := p.PodInterface.Delete(ctx, name, options)
+	if err != nil {
+		p.handler(EvictionFailureReason(err))
+	}
+	return err
+}
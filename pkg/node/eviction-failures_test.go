@@ -0,0 +1,76 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEvictionFailureHandler(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "secret"}})
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, action.(k8stesting.DeleteAction).GetName(), errors.New("denied"))
+	})
+	reasons := []string{}
+	tNode := getNode(t, getDrainHelper(client)).WithEvictionFailureHandler(func(reason string) {
+		reasons = append(reasons, reason)
+	})
+
+	err := tNode.CordonAndDrain(nodeName)
+	h.Assert(t, err != nil, "Expected the drain to fail")
+	h.Equals(t, []string{node.EvictionFailureForbidden}, reasons)
+}
+
+func TestEvictionFailureHandlerWithoutFailures(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1"}})
+	reasons := []string{}
+	tNode := getNode(t, getDrainHelper(client)).WithEvictionFailureHandler(func(reason string) {
+		reasons = append(reasons, reason)
+	})
+
+	h.Ok(t, tNode.CordonAndDrain(nodeName))
+	h.Equals(t, []string{}, reasons)
+}
+
+func TestEvictionFailureReason(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	pdbErr := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 10)
+	for _, test := range []struct {
+		err    error
+		reason string
+	}{
+		{pdbErr, node.EvictionFailurePDB},
+		{apierrors.NewTooManyRequests("too many requests", 1), node.EvictionFailureTooManyRequests},
+		{apierrors.NewTimeoutError("timed out", 1), node.EvictionFailureTimeout},
+		{context.DeadlineExceeded, node.EvictionFailureTimeout},
+		{apierrors.NewNotFound(pods, "web-1"), node.EvictionFailureNotFound},
+		{apierrors.NewForbidden(pods, "web-1", errors.New("denied")), node.EvictionFailureForbidden},
+		{errors.New("connection refused"), node.EvictionFailureOther},
+	} {
+		h.Equals(t, test.reason, node.EvictionFailureReason(test.err))
+	}
+}
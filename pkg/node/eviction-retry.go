@@ -0,0 +1,140 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/rs/zerolog/log"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	policyv1beta1client "k8s.io/client-go/kubernetes/typed/policy/v1beta1"
+)
+
+// EvictionRetryPolicy decides how often a pod eviction blocked by the API server, e.g. by a pod disruption budget, is
+// attempted before escalating
+type EvictionRetryPolicy struct {
+	// Attempts is the number of eviction attempts before escalating. Zero leaves retrying to the drain helper, which
+	// attempts the eviction every 5 seconds until the drain times out.
+	Attempts int
+	// Interval is the time between attempts
+	Interval time.Duration
+	// Escalation is what happens once the attempts are used up: config.EvictionRetryEscalationRetry,
+	// config.EvictionRetryEscalationDelete or config.EvictionRetryEscalationGiveUp
+	Escalation string
+}
+
+func evictionRetryPolicyFor(nthConfig config.Config) EvictionRetryPolicy {
+	return EvictionRetryPolicy{
+		Attempts:   nthConfig.EvictionRetryAttempts,
+		Interval:   time.Duration(nthConfig.EvictionRetryInterval) * time.Second,
+		Escalation: nthConfig.EvictionRetryEscalation,
+	}
+}
+
+// WithEvictionRetryPolicy returns a copy of the node which retries blocked evictions with the policy. The interval and
+// escalation of the node's current policy are kept if the policy leaves them empty.
+func (n Node) WithEvictionRetryPolicy(policy EvictionRetryPolicy) Node {
+	if policy.Interval <= 0 {
+		policy.Interval = n.evictionRetryPolicy.Interval
+	}
+	if policy.Escalation == "" {
+		policy.Escalation = n.evictionRetryPolicy.Escalation
+	}
+	n.evictionRetryPolicy = policy
+	return n
+}
+
+// withEvictionRetries returns a copy of the node whose drain helper retries blocked evictions with the node's policy,
+// without waiting past the drain timeout
+func (n Node) withEvictionRetries() Node {
+	if n.evictionRetryPolicy.Attempts <= 0 {
+		return n
+	}
+	client := evictionRetryClient{Interface: n.drainHelper.Client, policy: n.evictionRetryPolicy}
+	if n.drainHelper.Timeout > 0 {
+		client.deadline = time.Now().Add(n.drainHelper.Timeout)
+	}
+	drainHelper := *n.drainHelper
+	drainHelper.Client = client
+	n.drainHelper = &drainHelper
+	return n
+}
+
+// evictionRetryClient retries the evictions of the drain helper and escalates once the attempts are used up
+type evictionRetryClient struct {
+	kubernetes.Interface
+	policy   EvictionRetryPolicy
+	deadline time.Time
+}
+
+func (c evictionRetryClient) PolicyV1beta1() policyv1beta1client.PolicyV1beta1Interface {
+	return evictionRetryPolicyClient{PolicyV1beta1Interface: c.Interface.PolicyV1beta1(), client: c}
+}
+
+type evictionRetryPolicyClient struct {
+	policyv1beta1client.PolicyV1beta1Interface
+	client evictionRetryClient
+}
+
+func (c evictionRetryPolicyClient) Evictions(namespace string) policyv1beta1client.EvictionInterface {
+	return evictionRetryEvictions{EvictionInterface: c.PolicyV1beta1Interface.Evictions(namespace), client: c.client}
+}
+
+type evictionRetryEvictions struct {
+	policyv1beta1client.EvictionInterface
+	client evictionRetryClient
+}
+
+func (e evictionRetryEvictions) Evict(ctx context.Context, eviction *policyv1beta1.Eviction) error {
+	policy := e.client.policy
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = e.EvictionInterface.Evict(ctx, eviction)
+		if err == nil || !apierrors.IsTooManyRequests(err) {
+			return err
+		}
+		if attempt >= policy.Attempts {
+			break
+		}
+		if !e.client.deadline.IsZero() && time.Now().Add(policy.Interval).After(e.client.deadline) {
+			// the drain times out before the next attempt, the drain helper reports the timeout
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(policy.Interval):
+		}
+	}
+
+	switch policy.Escalation {
+	case config.EvictionRetryEscalationDelete:
+		log.Warn().Err(err).Msgf("Unable to evict pod %s/%s after %d attempts, deleting it", eviction.Namespace, eviction.Name, policy.Attempts)
+		options := metav1.DeleteOptions{}
+		if eviction.DeleteOptions != nil {
+			options = *eviction.DeleteOptions
+		}
+		return e.client.Interface.CoreV1().Pods(eviction.Namespace).Delete(ctx, eviction.Name, options)
+	case config.EvictionRetryEscalationGiveUp:
+		// the error must not be too many requests, or the drain helper keeps retrying
+		return fmt.Errorf("giving up evicting pod %s/%s after %d attempts: %v", eviction.Namespace, eviction.Name, policy.Attempts, err)
+	}
+	return err
+}
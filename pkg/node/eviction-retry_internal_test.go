@@ -0,0 +1,114 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// blockedEvictions rejects the first evictions like a pod disruption budget does, and lets the later ones pass
+func blockedEvictions(client *fake.Clientset, blocked int) *int {
+	attempts := 0
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		attempts++
+		if blocked < 0 || attempts <= blocked {
+			return true, nil, errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		return true, nil, nil
+	})
+	return &attempts
+}
+
+func evictWithRetries(t *testing.T, client *fake.Clientset, timeout time.Duration, policy EvictionRetryPolicy) error {
+	drainHelper := getTestDrainHelper(client)
+	drainHelper.Timeout = timeout
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName, EvictionRetryInterval: 5, EvictionRetryEscalation: config.EvictionRetryEscalationRetry}, drainHelper, uptime.Uptime)
+	h.Ok(t, err)
+	retrying := tNode.WithEvictionRetryPolicy(policy).withEvictionRetries()
+	eviction := &policyv1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}}
+	return retrying.drainHelper.Client.PolicyV1beta1().Evictions("default").Evict(context.Background(), eviction)
+}
+
+func TestEvictionRetries(t *testing.T) {
+	client := h.NewFakeClientset()
+	attempts := blockedEvictions(client, 2)
+
+	err := evictWithRetries(t, client, time.Minute, EvictionRetryPolicy{Attempts: 3, Interval: time.Millisecond})
+	h.Ok(t, err)
+	h.Equals(t, 3, *attempts)
+}
+
+func TestEvictionRetriesKeepRetrying(t *testing.T) {
+	client := h.NewFakeClientset()
+	attempts := blockedEvictions(client, -1)
+
+	err := evictWithRetries(t, client, time.Minute, EvictionRetryPolicy{Attempts: 3, Interval: time.Millisecond})
+	h.Assert(t, errors.IsTooManyRequests(err), "Expected the drain helper to be left retrying the eviction, got %v", err)
+	h.Equals(t, 3, *attempts)
+}
+
+func TestEvictionRetriesGiveUp(t *testing.T) {
+	client := h.NewFakeClientset()
+	attempts := blockedEvictions(client, -1)
+
+	err := evictWithRetries(t, client, time.Minute, EvictionRetryPolicy{Attempts: 2, Interval: time.Millisecond, Escalation: config.EvictionRetryEscalationGiveUp})
+	h.Assert(t, err != nil && !errors.IsTooManyRequests(err), "Expected the eviction to fail the drain, got %v", err)
+	h.Equals(t, 2, *attempts)
+}
+
+func TestEvictionRetriesDelete(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}})
+	attempts := blockedEvictions(client, -1)
+
+	err := evictWithRetries(t, client, time.Minute, EvictionRetryPolicy{Attempts: 2, Interval: time.Millisecond, Escalation: config.EvictionRetryEscalationDelete})
+	h.Ok(t, err)
+	h.Equals(t, 2, *attempts)
+	_, err = client.CoreV1().Pods("default").Get(context.Background(), "web-1", metav1.GetOptions{})
+	h.Assert(t, errors.IsNotFound(err), "Expected the blocked pod to be deleted")
+}
+
+func TestEvictionRetriesStopAtDrainTimeout(t *testing.T) {
+	client := h.NewFakeClientset()
+	attempts := blockedEvictions(client, -1)
+
+	err := evictWithRetries(t, client, time.Second, EvictionRetryPolicy{Attempts: 5, Interval: time.Minute, Escalation: config.EvictionRetryEscalationDelete})
+	h.Assert(t, errors.IsTooManyRequests(err), "Expected the blocked eviction to be returned, got %v", err)
+	h.Equals(t, 1, *attempts)
+}
+
+func TestWithEvictionRetryPolicyDefaults(t *testing.T) {
+	client := h.NewFakeClientset()
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName, EvictionRetryInterval: 5, EvictionRetryEscalation: config.EvictionRetryEscalationGiveUp}, getTestDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	h.Assert(t, tNode.withEvictionRetries().drainHelper.Client == client, "Expected evictions not to be retried without attempts")
+
+	policy := tNode.WithEvictionRetryPolicy(EvictionRetryPolicy{Attempts: 4}).evictionRetryPolicy
+	h.Equals(t, EvictionRetryPolicy{Attempts: 4, Interval: 5 * time.Second, Escalation: config.EvictionRetryEscalationGiveUp}, policy)
+}
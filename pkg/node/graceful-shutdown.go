@@ -0,0 +1,53 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DrainStartedAnnotation is set to the unix time node termination handler began draining the node.
+	// Shutdown scripts and other tooling can check for it to avoid terminating pods a second time.
+	DrainStartedAnnotation = "aws-node-termination-handler/drain-started"
+	// kubeletShutdownMessage is the Ready condition message the kubelet's shutdown manager publishes
+	kubeletShutdownMessage = "node is shutting down"
+)
+
+// isKubeletShuttingDown returns true if kubelet's graceful node shutdown manager is already terminating pods on the node
+func isKubeletShuttingDown(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status != corev1.ConditionTrue && strings.Contains(condition.Message, kubeletShutdownMessage)
+		}
+	}
+	return false
+}
+
+// coordinateWithKubeletShutdown returns false if pods should not be evicted because kubelet is already shutting the node down.
+// Otherwise, the node is annotated to signal node termination handler is draining it.
+func (n Node) coordinateWithKubeletShutdown(node *corev1.Node) (bool, error) {
+	if isKubeletShuttingDown(node) {
+		return false, nil
+	}
+	err := n.addAnnotation(node.Name, DrainStartedAnnotation, strconv.FormatInt(time.Now().Unix(), 10))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
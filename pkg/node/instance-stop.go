@@ -0,0 +1,94 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StoppedInstanceAnnotation holds the ID of the instance which was stopped for an instance-stop scheduled event.
+// The node is only uncordoned once the same instance is started again.
+const StoppedInstanceAnnotation = "aws-node-termination-handler/stopped-instance-id"
+
+// MarkInstanceStopping records the ID of the instance backing the node before it is stopped
+func (n Node) MarkInstanceStopping(nodeName string, instanceID string) error {
+	err := n.addAnnotation(nodeName, StoppedInstanceAnnotation, instanceID)
+	if err != nil {
+		return fmt.Errorf("Unable to annotate node with instance ID %s=%s: %w", StoppedInstanceAnnotation, instanceID, err)
+	}
+	return nil
+}
+
+// UncordonIfRestarted uncordons the node like UncordonIfRebooted, unless the node was stopped and a different instance
+// came back with the same node name. In that case the node is left cordoned and the uncordon labels are removed.
+func (n Node) UncordonIfRestarted(nodeName string, instanceID string) error {
+	k8sNode, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return fmt.Errorf("Unable to fetch kubernetes node from API: %w", err)
+	}
+	stoppedInstanceID, ok := k8sNode.Annotations[StoppedInstanceAnnotation]
+	if !ok || instanceID == "" || stoppedInstanceID == instanceID {
+		return n.UncordonIfRebooted(nodeName)
+	}
+	log.Warn().Str("node_name", nodeName).Msgf("Instance %s was stopped, but instance %s came back, not uncordoning the node", stoppedInstanceID, instanceID)
+	err = n.RemoveNTHLabels(nodeName)
+	if err != nil {
+		return err
+	}
+	return n.removeAnnotation(nodeName, StoppedInstanceAnnotation)
+}
+
+// UncordonIfStarted uncordons the node marked for uncordon after a restart once its instance was started again at
+// startedAt. It is used in queue mode, where the uptime of the instance is not known. It returns true if the node was
+// uncordoned.
+func (n Node) UncordonIfStarted(nodeName string, instanceID string, startedAt time.Time) (bool, error) {
+	k8sNode, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return false, fmt.Errorf("Unable to fetch kubernetes node from API: %w", err)
+	}
+	if k8sNode.Labels[ActionLabelKey] != UncordonAfterRebootLabelVal {
+		log.Debug().Msgf("Node %s is not marked to be uncordoned after a restart", nodeName)
+		return false, nil
+	}
+	timeValNum, err := strconv.ParseInt(k8sNode.Labels[ActionLabelTimeKey], 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("Cannot convert unix time: %w", err)
+	}
+	if startedAt.Unix() < timeValNum {
+		log.Debug().Msgf("Instance %s was started before node %s was marked, not uncordoning the node", instanceID, nodeName)
+		return false, nil
+	}
+	if stoppedInstanceID, ok := k8sNode.Annotations[StoppedInstanceAnnotation]; ok && stoppedInstanceID != instanceID {
+		log.Debug().Msgf("Instance %s does not back node %s, not uncordoning the node", instanceID, nodeName)
+		return false, nil
+	}
+	err = n.Uncordon(nodeName)
+	if err != nil {
+		return false, fmt.Errorf("Unable to uncordon node: %w", err)
+	}
+	err = n.RemoveNTHLabels(nodeName)
+	if err != nil {
+		return true, err
+	}
+	err = n.RemoveNTHTaints(nodeName)
+	if err != nil {
+		return true, err
+	}
+	log.Info().Msgf("Successfully completed action %s.", UncordonAfterRebootLabelVal)
+	return true, nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JobExpectedCompletionAnnotation holds the RFC3339 time the pod of a Job expects to complete at
+const JobExpectedCompletionAnnotation = "aws-node-termination-handler/expected-completion"
+
+var jobCompletionPollInterval = 2 * time.Second
+
+// drainWaitingForJobs drains the node with the drain function, leaving out the pods of Jobs which expect to complete
+// within the job completion wait. Those pods are given until the end of the wait to complete before they're evicted,
+// since rescheduling a Job pod close to completion wastes more compute than briefly delaying its eviction.
+func (n Node) drainWaitingForJobs(node *corev1.Node, drainFn func(n Node, node *corev1.Node) error) error {
+	wait := n.jobCompletionWait()
+	if wait <= 0 {
+		return drainFn(n, node)
+	}
+	podList, errs := n.drainHelper.GetPodsForDeletion(node.Name)
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to list pods for deletion on node %s: %v", node.Name, errs)
+	}
+	deadline := time.Now().Add(wait)
+	var jobPods []corev1.Pod
+	completing := map[string]bool{}
+	for _, pod := range podList.Pods() {
+		if isJobCompletingBefore(pod, deadline) {
+			jobPods = append(jobPods, pod)
+			completing[pod.Namespace+"/"+pod.Name] = true
+		}
+	}
+	if len(jobPods) == 0 {
+		return drainFn(n, node)
+	}
+	others := n.withPodFilter(func(pod corev1.Pod) bool { return !completing[pod.Namespace+"/"+pod.Name] })
+	err := drainFn(others, node)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("node_name", node.Name).Msgf("Waiting up to %s for %d Job pods close to completion", time.Until(deadline).Round(time.Second), len(jobPods))
+	for {
+		jobPods, err = n.runningPods(jobPods)
+		if err != nil {
+			return err
+		}
+		remaining := time.Until(deadline)
+		if len(jobPods) == 0 || remaining <= 0 {
+			break
+		}
+		if remaining > jobCompletionPollInterval {
+			remaining = jobCompletionPollInterval
+		}
+		time.Sleep(remaining)
+	}
+	if len(jobPods) == 0 {
+		return nil
+	}
+	log.Info().Str("node_name", node.Name).Msgf("Evicting %d Job pods which didn't complete in time", len(jobPods))
+	return n.deleteOrEvictPods(node.Name, jobPods)
+}
+
+// jobCompletionWait returns the job completion wait, capped at half of the time left to drain to leave the other half
+// for evicting the Job pods which didn't complete
+func (n Node) jobCompletionWait() time.Duration {
+	wait := time.Duration(n.nthConfig.JobCompletionWait) * time.Second
+	timeout := n.drainHelper.Timeout
+	if !n.drainDeadline.IsZero() {
+		timeout = time.Until(n.drainDeadline)
+	}
+	if timeout > 0 && wait > timeout/2 {
+		wait = timeout / 2
+	}
+	return wait
+}
+
+// runningPods returns the pods which still exist and didn't complete yet
+func (n Node) runningPods(pods []corev1.Pod) ([]corev1.Pod, error) {
+	var running []corev1.Pod
+	for _, pod := range pods {
+		current, err := n.drainHelper.Client.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if current.UID != pod.UID || current.Status.Phase == corev1.PodSucceeded || current.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		running = append(running, pod)
+	}
+	return running, nil
+}
+
+func isJobCompletingBefore(pod corev1.Pod, deadline time.Time) bool {
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil || owner.Kind != "Job" {
+		return false
+	}
+	expected, ok := pod.Annotations[JobExpectedCompletionAnnotation]
+	if !ok {
+		return false
+	}
+	expectedCompletion, err := time.Parse(time.RFC3339, expected)
+	if err != nil {
+		log.Warn().Err(err).Str("pod", pod.Namespace+"/"+pod.Name).Msgf("Unable to parse the %s annotation", JobExpectedCompletionAnnotation)
+		return false
+	}
+	return !expectedCompletion.After(deadline)
+}
@@ -0,0 +1,110 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func jobPod(name string, expectedCompletion time.Time) v1.Pod {
+	controller := true
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       "default",
+		Annotations:     map[string]string{JobExpectedCompletionAnnotation: expectedCompletion.Format(time.RFC3339)},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: name, Controller: &controller}},
+	}}
+}
+
+func setupJobCompletionTest(t *testing.T, jobCompletionWait int, pods ...v1.Pod) (*Node, *fake.Clientset, *[]string) {
+	jobCompletionPollInterval = 10 * time.Millisecond
+	client := h.NewFakeClientset()
+	_, err := client.CoreV1().Nodes().Create(context.Background(), &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	for i := range pods {
+		pods[i].Namespace = "default"
+		pods[i].Spec.NodeName = nodeName
+		_, err = client.CoreV1().Pods("default").Create(context.Background(), &pods[i], metav1.CreateOptions{})
+		h.Ok(t, err)
+	}
+	deleted := []string{}
+	client.PrependReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleted = append(deleted, action.(k8stesting.DeleteAction).GetName())
+		return false, nil, nil
+	})
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName, JobCompletionWait: jobCompletionWait}, getTestDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	return tNode, client, &deleted
+}
+
+func TestJobCompletingInTimeIsNotEvicted(t *testing.T) {
+	tNode, client, deleted := setupJobCompletionTest(t, 60, jobPod("batch", time.Now().Add(time.Second)), v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		pod, err := client.CoreV1().Pods("default").Get(context.Background(), "batch", metav1.GetOptions{})
+		if err == nil {
+			pod.Status.Phase = v1.PodSucceeded
+			_, _ = client.CoreV1().Pods("default").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+		}
+	}()
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"web"}, *deleted)
+}
+
+func TestJobNotCompletingInTimeIsEvictedLast(t *testing.T) {
+	tNode, _, deleted := setupJobCompletionTest(t, 1, jobPod("batch", time.Now()), v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"web", "batch"}, *deleted)
+}
+
+func TestJobFarFromCompletionIsEvicted(t *testing.T) {
+	tNode, _, deleted := setupJobCompletionTest(t, 60, jobPod("batch", time.Now().Add(time.Hour)))
+	start := time.Now()
+	err := tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"batch"}, *deleted)
+	h.Assert(t, time.Since(start) < 30*time.Second, "Expected the drain not to wait for the Job pod")
+}
+
+func TestIsJobCompletingBefore(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	h.Assert(t, isJobCompletingBefore(jobPod("batch", time.Now()), deadline), "Expected the Job pod to complete before the deadline")
+	h.Assert(t, !isJobCompletingBefore(jobPod("batch", time.Now().Add(time.Hour)), deadline), "Expected the Job pod not to complete before the deadline")
+
+	notOwned := jobPod("batch", time.Now())
+	notOwned.OwnerReferences = nil
+	h.Assert(t, !isJobCompletingBefore(notOwned, deadline), "Expected pods not owned by a Job to be ignored")
+
+	invalid := jobPod("batch", time.Now())
+	invalid.Annotations[JobExpectedCompletionAnnotation] = "soon"
+	h.Assert(t, !isJobCompletingBefore(invalid, deadline), "Expected an invalid expected completion to be ignored")
+}
+
+func TestJobCompletionWaitIsCappedByDrainTimeout(t *testing.T) {
+	tNode, _, _ := setupJobCompletionTest(t, 600)
+	h.Equals(t, time.Minute, tNode.jobCompletionWait())
+}
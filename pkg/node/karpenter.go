@@ -0,0 +1,74 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// KarpenterNodePoolLabelKey is the label karpenter adds to nodes launched for a NodePool
+	KarpenterNodePoolLabelKey = "karpenter.sh/nodepool"
+	// KarpenterProvisionerLabelKey is the label older karpenter versions add to nodes launched for a Provisioner
+	KarpenterProvisionerLabelKey = "karpenter.sh/provisioner-name"
+
+	karpenterAPIGroup      = "karpenter.sh"
+	karpenterNodeClaimKind = "NodeClaim"
+)
+
+// IsKarpenterManaged returns true if the node was launched by karpenter
+func (n Node) IsKarpenterManaged(nodeName string) (bool, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Would have checked if the node is managed by karpenter, but dry-run flag was set")
+		return false, nil
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return false, err
+	}
+	return isKarpenterNode(node), nil
+}
+
+// DeleteKarpenterNode deletes the kubernetes node so karpenter's termination finalizer drains it, removes the owning
+// NodeClaim and launches replacement capacity right away
+func (n Node) DeleteKarpenterNode(nodeName string) error {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Node would have been deleted for karpenter, but dry-run flag was set")
+		return nil
+	}
+	err := n.deleteNode(nodeName)
+	if err != nil {
+		return fmt.Errorf("Unable to delete karpenter node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+func isKarpenterNode(node *corev1.Node) bool {
+	if _, ok := node.Labels[KarpenterNodePoolLabelKey]; ok {
+		return true
+	}
+	if _, ok := node.Labels[KarpenterProvisionerLabelKey]; ok {
+		return true
+	}
+	for _, owner := range node.OwnerReferences {
+		if owner.Kind == karpenterNodeClaimKind && strings.HasPrefix(owner.APIVersion, karpenterAPIGroup+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsKarpenterManaged(t *testing.T) {
+	for _, k8sNode := range []*v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{node.KarpenterNodePoolLabelKey: "default"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{node.KarpenterProvisionerLabelKey: "default"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: nodeName, OwnerReferences: []metav1.OwnerReference{{APIVersion: "karpenter.sh/v1", Kind: "NodeClaim", Name: "default-abcde"}}}},
+	} {
+		tNode := getNode(t, getDrainHelper(h.NewFakeClientset(k8sNode)))
+		managed, err := tNode.IsKarpenterManaged(nodeName)
+		h.Ok(t, err)
+		h.Assert(t, managed, "Expected node to be detected as karpenter managed")
+	}
+}
+
+func TestIsKarpenterManagedFalse(t *testing.T) {
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})))
+	managed, err := tNode.IsKarpenterManaged(nodeName)
+	h.Ok(t, err)
+	h.Assert(t, !managed, "Expected node to not be detected as karpenter managed")
+}
+
+func TestDeleteKarpenterNode(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode := getNode(t, getDrainHelper(client))
+	err := tNode.DeleteKarpenterNode(nodeName)
+	h.Ok(t, err)
+	_, err = client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Assert(t, errors.IsNotFound(err), "Expected node to be deleted")
+}
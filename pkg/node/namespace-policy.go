@@ -0,0 +1,250 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	policyv1beta1client "k8s.io/client-go/kubernetes/typed/policy/v1beta1"
+)
+
+const (
+	// NamespaceDrainPolicyAnnotation is set on namespaces to change how their pods are drained
+	NamespaceDrainPolicyAnnotation = "aws-node-termination-handler/drain-policy"
+	// NamespaceDrainPolicySkip leaves the pods of the namespace running
+	NamespaceDrainPolicySkip = "skip"
+	// NamespaceDrainPolicyNotifyOnly leaves the pods of the namespace running and annotates them with
+	// TerminationPendingAnnotation
+	NamespaceDrainPolicyNotifyOnly = "notify-only"
+	// NamespaceGracePeriodMultiplierAnnotation is set on namespaces to multiply the termination grace period of their
+	// pods when they are evicted, e.g. "2" or "0.5"
+	NamespaceGracePeriodMultiplierAnnotation = "aws-node-termination-handler/grace-period-multiplier"
+	// TerminationPendingAnnotation holds the time the drain of the node started at on the pods of notify-only
+	// namespaces
+	TerminationPendingAnnotation = "aws-node-termination-handler/termination-pending"
+
+	defaultPodTerminationGracePeriod = 30
+)
+
+// namespacePolicy is the drain behaviour set by the annotations of a namespace
+type namespacePolicy struct {
+	drainPolicy           string
+	gracePeriodMultiplier float64
+}
+
+// drainRespectingNamespacePolicies drains the node with the drain function, leaving the pods of skipped and notify-only
+// namespaces running and evicting the pods of namespaces with a grace period multiplier with their multiplied grace
+// period
+func (n Node) drainRespectingNamespacePolicies(node *corev1.Node, drainFn func(n Node, node *corev1.Node) error) error {
+	if !n.nthConfig.EnableNamespaceDrainPolicies {
+		return drainFn(n, node)
+	}
+	podList, errs := n.drainHelper.GetPodsForDeletion(node.Name)
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to list pods for deletion on node %s: %v", node.Name, errs)
+	}
+	policies := map[string]namespacePolicy{}
+	skipped := map[string]bool{}
+	gracePeriods := map[string]int64{}
+	pendingSince := time.Now().UTC().Format(time.RFC3339)
+	for _, pod := range podList.Pods() {
+		policy, ok := policies[pod.Namespace]
+		if !ok {
+			policy = n.namespacePolicy(pod.Namespace)
+			policies[pod.Namespace] = policy
+		}
+		key := pod.Namespace + "/" + pod.Name
+		switch policy.drainPolicy {
+		case NamespaceDrainPolicySkip:
+			log.Info().Str("pod", key).Msgf("Namespace is annotated with %s=%s, leaving the pod running", NamespaceDrainPolicyAnnotation, policy.drainPolicy)
+			skipped[key] = true
+			continue
+		case NamespaceDrainPolicyNotifyOnly:
+			log.Info().Str("pod", key).Msgf("Namespace is annotated with %s=%s, leaving the pod running", NamespaceDrainPolicyAnnotation, policy.drainPolicy)
+			skipped[key] = true
+			if err := n.annotateTerminationPending(pod, pendingSince); err != nil {
+				log.Warn().Err(err).Str("pod", key).Msg("Unable to annotate the pod with the pending termination")
+			}
+			continue
+		}
+		if policy.gracePeriodMultiplier > 0 {
+			gracePeriods[key] = multiplyGracePeriod(n.drainHelper.GracePeriodSeconds, pod, policy.gracePeriodMultiplier)
+			if timeout := n.drainHelper.Timeout; timeout > 0 && time.Duration(gracePeriods[key])*time.Second > timeout {
+				log.Warn().Str("pod", key).Msgf("The multiplied grace period of %ds exceeds the drain timeout of %s, the drain will time out before the pod terminates", gracePeriods[key], timeout)
+			}
+		}
+	}
+	if len(skipped) == 0 && len(gracePeriods) == 0 {
+		return drainFn(n, node)
+	}
+	respecting := n.withPodFilter(func(pod corev1.Pod) bool { return !skipped[pod.Namespace+"/"+pod.Name] })
+	if len(gracePeriods) > 0 {
+		respecting.drainHelper.Client = gracePeriodClient{Interface: n.drainHelper.Client, gracePeriods: gracePeriods}
+	}
+	return drainFn(respecting, node)
+}
+
+// namespacePolicy reads the drain policy of the namespace from its annotations. Pods of namespaces which can't be
+// read, or have invalid annotations, are drained like any other pod.
+func (n Node) namespacePolicy(name string) namespacePolicy {
+	namespace, err := n.drainHelper.Client.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		log.Warn().Err(err).Str("namespace", name).Msg("Unable to get the namespace, draining its pods without a namespace drain policy")
+		return namespacePolicy{}
+	}
+	policy := namespacePolicy{}
+	switch drainPolicy := namespace.Annotations[NamespaceDrainPolicyAnnotation]; drainPolicy {
+	case "", NamespaceDrainPolicySkip, NamespaceDrainPolicyNotifyOnly:
+		policy.drainPolicy = drainPolicy
+	default:
+		log.Warn().Str("namespace", name).Msgf("Ignoring invalid %s annotation %q, should be one of: %s, %s", NamespaceDrainPolicyAnnotation, drainPolicy, NamespaceDrainPolicySkip, NamespaceDrainPolicyNotifyOnly)
+	}
+	if value, ok := namespace.Annotations[NamespaceGracePeriodMultiplierAnnotation]; ok {
+		multiplier, err := strconv.ParseFloat(value, 64)
+		if err != nil || multiplier <= 0 || math.IsInf(multiplier, 0) {
+			log.Warn().Str("namespace", name).Msgf("Ignoring invalid %s annotation %q, should be a positive number", NamespaceGracePeriodMultiplierAnnotation, value)
+		} else {
+			policy.gracePeriodMultiplier = multiplier
+		}
+	}
+	return policy
+}
+
+// multiplyGracePeriod multiplies the grace period the pod would be evicted with: the configured pod termination grace
+// period, or the pod's own if none is configured
+func multiplyGracePeriod(configured int, pod corev1.Pod, multiplier float64) int64 {
+	gracePeriod := int64(defaultPodTerminationGracePeriod)
+	if configured >= 0 {
+		gracePeriod = int64(configured)
+	} else if pod.Spec.TerminationGracePeriodSeconds != nil {
+		gracePeriod = *pod.Spec.TerminationGracePeriodSeconds
+	}
+	return int64(math.Ceil(float64(gracePeriod) * multiplier))
+}
+
+func (n Node) annotateTerminationPending(pod corev1.Pod, since string) error {
+	return n.patchTerminationPending(pod, since)
+}
+
+// clearTerminationPending removes TerminationPendingAnnotation from the pods of the node once the termination was
+// cancelled, so their controllers stop moving the work away
+func (n Node) clearTerminationPending(nodeName string) {
+	pods, err := n.drainHelper.Client.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(fields.Set{"spec.nodeName": nodeName}).String(),
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("node_name", nodeName).Msg("Unable to list the pods of the node to clear their pending termination")
+		return
+	}
+	for _, pod := range pods.Items {
+		if _, ok := pod.Annotations[TerminationPendingAnnotation]; !ok {
+			continue
+		}
+		if err := n.patchTerminationPending(pod, nil); err != nil && !apierrors.IsNotFound(err) {
+			log.Warn().Err(err).Str("pod", pod.Namespace+"/"+pod.Name).Msg("Unable to clear the pending termination of the pod")
+		}
+	}
+}
+
+// patchTerminationPending sets TerminationPendingAnnotation on the pod, or removes it if the value is nil
+func (n Node) patchTerminationPending(pod corev1.Pod, value interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{TerminationPendingAnnotation: value},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = n.drainHelper.Client.CoreV1().Pods(pod.Namespace).Patch(context.TODO(), pod.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// gracePeriodClient overrides the grace period of the evictions and pod deletions of the drain helper per pod
+type gracePeriodClient struct {
+	kubernetes.Interface
+	gracePeriods map[string]int64
+}
+
+func (c gracePeriodClient) PolicyV1beta1() policyv1beta1client.PolicyV1beta1Interface {
+	return gracePeriodPolicyClient{PolicyV1beta1Interface: c.Interface.PolicyV1beta1(), gracePeriods: c.gracePeriods}
+}
+
+func (c gracePeriodClient) CoreV1() corev1client.CoreV1Interface {
+	return gracePeriodCoreClient{CoreV1Interface: c.Interface.CoreV1(), gracePeriods: c.gracePeriods}
+}
+
+type gracePeriodPolicyClient struct {
+	policyv1beta1client.PolicyV1beta1Interface
+	gracePeriods map[string]int64
+}
+
+func (c gracePeriodPolicyClient) Evictions(namespace string) policyv1beta1client.EvictionInterface {
+	return gracePeriodEvictions{EvictionInterface: c.PolicyV1beta1Interface.Evictions(namespace), gracePeriods: c.gracePeriods}
+}
+
+type gracePeriodEvictions struct {
+	policyv1beta1client.EvictionInterface
+	gracePeriods map[string]int64
+}
+
+func (e gracePeriodEvictions) Evict(ctx context.Context, eviction *policyv1beta1.Eviction) error {
+	if gracePeriod, ok := e.gracePeriods[eviction.Namespace+"/"+eviction.Name]; ok {
+		withGracePeriod := *eviction
+		options := metav1.DeleteOptions{}
+		if eviction.DeleteOptions != nil {
+			options = *eviction.DeleteOptions
+		}
+		options.GracePeriodSeconds = &gracePeriod
+		withGracePeriod.DeleteOptions = &options
+		eviction = &withGracePeriod
+	}
+	return e.EvictionInterface.Evict(ctx, eviction)
+}
+
+type gracePeriodCoreClient struct {
+	corev1client.CoreV1Interface
+	gracePeriods map[string]int64
+}
+
+func (c gracePeriodCoreClient) Pods(namespace string) corev1client.PodInterface {
+	return gracePeriodPods{PodInterface: c.CoreV1Interface.Pods(namespace), namespace: namespace, gracePeriods: c.gracePeriods}
+}
+
+type gracePeriodPods struct {
+	corev1client.PodInterface
+	namespace    string
+	gracePeriods map[string]int64
+}
+
+func (p gracePeriodPods) Delete(ctx context.Context, name string, options metav1.DeleteOptions) error {
+	if gracePeriod, ok := p.gracePeriods[p.namespace+"/"+name]; ok {
+		options.GracePeriodSeconds = &gracePeriod
+	}
+	return p.PodInterface.Delete(ctx, name, options)
+}
@@ -0,0 +1,76 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNamespacePolicy(t *testing.T) {
+	client := h.NewFakeClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "batch", Annotations: map[string]string{
+			NamespaceDrainPolicyAnnotation:           NamespaceDrainPolicySkip,
+			NamespaceGracePeriodMultiplierAnnotation: "0.5",
+		}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Annotations: map[string]string{
+			NamespaceDrainPolicyAnnotation:           "evict-harder",
+			NamespaceGracePeriodMultiplierAnnotation: "Inf",
+		}}},
+	)
+	tNode := getNode(t, getTestDrainHelper(client), nil)
+
+	h.Equals(t, namespacePolicy{drainPolicy: NamespaceDrainPolicySkip, gracePeriodMultiplier: 0.5}, tNode.namespacePolicy("batch"))
+	h.Equals(t, namespacePolicy{}, tNode.namespacePolicy("invalid"))
+	h.Equals(t, namespacePolicy{}, tNode.namespacePolicy("missing"))
+}
+
+func TestMultiplyGracePeriod(t *testing.T) {
+	gracePeriod := int64(45)
+	pod := v1.Pod{Spec: v1.PodSpec{TerminationGracePeriodSeconds: &gracePeriod}}
+
+	h.Equals(t, int64(90), multiplyGracePeriod(-1, pod, 2))
+	h.Equals(t, int64(15), multiplyGracePeriod(-1, v1.Pod{}, 0.5))
+	h.Equals(t, int64(4), multiplyGracePeriod(10, pod, 0.35))
+}
+
+func TestGracePeriodClient(t *testing.T) {
+	client := h.NewFakeClientset()
+	gracePeriods := map[string]*int64{}
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+		gracePeriods[eviction.Name] = eviction.DeleteOptions.GracePeriodSeconds
+		return true, nil, nil
+	})
+	overridden := gracePeriodClient{Interface: client, gracePeriods: map[string]int64{"stateful/db-0": 120}}
+	configured := int64(30)
+
+	for _, name := range []string{"db-0", "web-1"} {
+		eviction := &policyv1beta1.Eviction{
+			ObjectMeta:    metav1.ObjectMeta{Name: name, Namespace: "stateful"},
+			DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: &configured},
+		}
+		h.Ok(t, overridden.PolicyV1beta1().Evictions("stateful").Evict(context.Background(), eviction))
+		h.Equals(t, int64(30), *eviction.DeleteOptions.GracePeriodSeconds)
+	}
+	h.Equals(t, int64(120), *gracePeriods["db-0"])
+	h.Equals(t, int64(30), *gracePeriods["web-1"])
+}
@@ -0,0 +1,78 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func createNamespacedPod(t *testing.T, client *fake.Clientset, namespace string, annotations map[string]string, gracePeriod int64) {
+	_, err := client.CoreV1().Namespaces().Create(context.Background(), &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: annotations}}, metav1.CreateOptions{})
+	h.Ok(t, err)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace + "-1", Namespace: namespace},
+		Spec:       v1.PodSpec{NodeName: nodeName, TerminationGracePeriodSeconds: &gracePeriod},
+	}
+	_, err = client.CoreV1().Pods(namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+	h.Ok(t, err)
+}
+
+func TestNamespaceDrainPolicies(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client)
+	createNamespacedPod(t, client, "batch", map[string]string{node.NamespaceDrainPolicyAnnotation: node.NamespaceDrainPolicySkip}, 30)
+	createNamespacedPod(t, client, "queue", map[string]string{node.NamespaceDrainPolicyAnnotation: node.NamespaceDrainPolicyNotifyOnly}, 30)
+	createNamespacedPod(t, client, "stateful", map[string]string{node.NamespaceGracePeriodMultiplierAnnotation: "2.5"}, 30)
+	createNamespacedPod(t, client, "web", map[string]string{node.NamespaceGracePeriodMultiplierAnnotation: "-1"}, 30)
+	deleted := recordPodDeletions(client)
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, EnableNamespaceDrainPolicies: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	h.Ok(t, tNode.CordonAndDrain(nodeName))
+	h.Assert(t, !contains(*deleted, "batch-1") && !contains(*deleted, "queue-1"), "Expected the pods of skipped and notify-only namespaces to be left running, deleted %v", *deleted)
+	h.Assert(t, contains(*deleted, "stateful-1") && contains(*deleted, "web-1"), "Expected the pods of the other namespaces to be deleted, deleted %v", *deleted)
+
+	queuePod, err := client.CoreV1().Pods("queue").Get(context.Background(), "queue-1", metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Assert(t, queuePod.Annotations[node.TerminationPendingAnnotation] != "", "Expected the notify-only pod to be annotated with the pending termination")
+	batchPod, err := client.CoreV1().Pods("batch").Get(context.Background(), "batch-1", metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "", batchPod.Annotations[node.TerminationPendingAnnotation])
+
+	h.Ok(t, tNode.Uncordon(nodeName))
+	queuePod, err = client.CoreV1().Pods("queue").Get(context.Background(), "queue-1", metav1.GetOptions{})
+	h.Ok(t, err)
+	_, pending := queuePod.Annotations[node.TerminationPendingAnnotation]
+	h.Assert(t, !pending, "Expected the pending termination of the notify-only pod to be cleared on uncordon")
+}
+
+func TestNamespaceDrainPoliciesDisabled(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client)
+	createNamespacedPod(t, client, "batch", map[string]string{node.NamespaceDrainPolicyAnnotation: node.NamespaceDrainPolicySkip}, 30)
+	deleted := recordPodDeletions(client)
+	tNode := getNode(t, getDrainHelper(client))
+
+	h.Ok(t, tNode.CordonAndDrain(nodeName))
+	h.Equals(t, []string{"batch-1"}, *deleted)
+}
@@ -22,16 +22,18 @@ import (
 	"time"
 
 	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/drainpolicy"
 	"github.com/aws/aws-node-termination-handler/pkg/uptime"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubectl/pkg/drain"
 )
 
@@ -59,16 +61,42 @@ const (
 	maxTaintValueLength = 63
 )
 
-var (
-	maxRetryDeadline      time.Duration = 5 * time.Second
-	conflictRetryInterval time.Duration = 750 * time.Millisecond
+const (
+	// ClusterAutoscalerToBeDeletedTaint is the taint cluster-autoscaler places on nodes it is deleting. Nodes with this taint are
+	// not counted as schedulable capacity by cluster-autoscaler.
+	ClusterAutoscalerToBeDeletedTaint = "ToBeDeletedByClusterAutoscaler"
+	// ClusterAutoscalerScaleDownDisabledAnnotation prevents cluster-autoscaler from selecting the node for scale down
+	ClusterAutoscalerScaleDownDisabledAnnotation = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
+	// ScaleDownDisabledAnnotation is set on the nodes node termination handler added
+	// ClusterAutoscalerScaleDownDisabledAnnotation to, so an annotation set by the cluster operator is never removed
+	ScaleDownDisabledAnnotation = "aws-node-termination-handler/scale-down-disabled"
 )
 
+// RebootedForEventAnnotation holds the ID of the scheduled event node termination handler last rebooted the node for
+const RebootedForEventAnnotation = "aws-node-termination-handler/rebooted-for-event"
+
+// nodeGroupLabelKeys are labels which identify the node group a node was launched in
+var nodeGroupLabelKeys = []string{"eks.amazonaws.com/nodegroup", "alpha.eksctl.io/nodegroup-name", "karpenter.sh/nodepool"}
+
 // Node represents a kubernetes node with functions to manipulate its state via the kubernetes api server
 type Node struct {
-	nthConfig   config.Config
-	drainHelper *drain.Helper
-	uptime      uptime.UptimeFuncType
+	nthConfig     config.Config
+	drainHelper   *drain.Helper
+	drainStrategy DrainStrategy
+	drainPolicies drainpolicy.Lister
+	evictionTiers []labels.Selector
+	pods          cache.SharedIndexInformer
+	instanceNodes *instanceNodes
+	uptime        uptime.UptimeFuncType
+	// drainOnly leaves nodes another controller made unschedulable as they are when draining them
+	drainOnly bool
+	// canarySelector selects the nodes node termination handler acts on, nil selects every node
+	canarySelector labels.Selector
+	// evictionRetryPolicy decides how blocked evictions are retried when draining
+	evictionRetryPolicy EvictionRetryPolicy
+	// drainDeadline is the end of the drain timeout shared by the stages of a drain, zero when not draining or the
+	// drain timeout is zero
+	drainDeadline time.Time
 }
 
 // New will construct a node struct to perform various node function through the kubernetes api server
@@ -77,15 +105,57 @@ func New(nthConfig config.Config) (*Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewWithValues(nthConfig, drainHelper, getUptimeFunc(nthConfig.UptimeFromFile))
+	n, err := NewWithValues(nthConfig, drainHelper, getUptimeFunc(nthConfig.UptimeFromFile))
+	if err != nil || nthConfig.DryRun {
+		return n, err
+	}
+	if usesPodInformer(nthConfig) {
+		pods := newPodInformer(drainHelper.Client, nthConfig.NodeName)
+		go pods.Run(wait.NeverStop)
+		withPods := n.WithPodInformer(pods)
+		n = &withPods
+	}
+	if !nthConfig.EnableDrainPolicies {
+		return n, nil
+	}
+	clusterConfig, err := nthConfig.KubernetesClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(clusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	withDrainPolicies := n.WithDrainPolicies(drainpolicy.Client{Dynamic: dynamicClient})
+	return &withDrainPolicies, nil
 }
 
 // NewWithValues will construct a node struct with a drain helper and an uptime function
 func NewWithValues(nthConfig config.Config, drainHelper *drain.Helper, uptime uptime.UptimeFuncType) (*Node, error) {
+	drainStrategy, err := getDrainStrategy(nthConfig.DrainStrategy)
+	if err != nil {
+		return nil, err
+	}
+	evictionTiers, err := parseEvictionTiers(nthConfig.EvictionTiers)
+	if err != nil {
+		return nil, err
+	}
+	if nthConfig.DrainStrategy == DrainStrategyLabelTiered && len(evictionTiers) == 0 {
+		return nil, fmt.Errorf("eviction-tiers must be provided when drain-strategy is %s", DrainStrategyLabelTiered)
+	}
+	canarySelector, err := parseCanarySelector(nthConfig.CanaryNodeSelector)
+	if err != nil {
+		return nil, err
+	}
 	return &Node{
-		nthConfig:   nthConfig,
-		drainHelper: drainHelper,
-		uptime:      uptime,
+		nthConfig:           nthConfig,
+		drainHelper:         drainHelper,
+		drainStrategy:       drainStrategy,
+		evictionTiers:       evictionTiers,
+		canarySelector:      canarySelector,
+		evictionRetryPolicy: evictionRetryPolicyFor(nthConfig),
+		instanceNodes:       &instanceNodes{},
+		uptime:              uptime,
 	}, nil
 }
 
@@ -106,27 +176,137 @@ func (n Node) CordonAndDrain(nodeName string) error {
 	if err != nil {
 		return err
 	}
-	err = drain.RunNodeDrain(n.drainHelper, node.Name)
+	if n.nthConfig.KubeletShutdownCoordination {
+		shouldDrain, err := n.coordinateWithKubeletShutdown(node)
+		if err != nil {
+			return err
+		}
+		if !shouldDrain {
+			log.Info().Str("node_name", nodeName).Msg("Kubelet is already gracefully shutting down the node, skipping pod eviction")
+			return nil
+		}
+	}
+	n = n.withEvictionRetries()
+	if n.drainHelper.Timeout > 0 {
+		n.drainDeadline = time.Now().Add(n.drainHelper.Timeout)
+	}
+	err = n.drainRespectingNamespacePolicies(node, func(n Node, node *corev1.Node) error {
+		return n.drainWaitingForJobs(node, func(n Node, node *corev1.Node) error {
+			return n.drainRespectingSafeToEvict(node, func(n Node, node *corev1.Node) error {
+				if n.drainPolicies != nil {
+					return n.drainWithPolicies(node)
+				}
+				return n.drainWithStrategy(node)
+			})
+		})
+	})
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// Cordon will add a NoSchedule on the node
-func (n Node) Cordon(nodeName string) error {
+// drainWithStrategy lets the drain strategy evict the pods within the time left until the drain deadline
+func (n Node) drainWithStrategy(node *corev1.Node) error {
+	left, err := n.drainTimeLeft(node.Name)
+	if err != nil {
+		return err
+	}
+	return n.drainStrategy.Drain(left, node)
+}
+
+// deleteOrEvictPods evicts the pods within the time left until the drain deadline
+func (n Node) deleteOrEvictPods(nodeName string, pods []corev1.Pod) error {
+	left, err := n.drainTimeLeft(nodeName)
+	if err != nil {
+		return err
+	}
+	return left.drainHelper.DeleteOrEvictPods(pods)
+}
+
+// drainTimeLeft returns a copy of the node whose drain helper waits only for the time left until the drain deadline,
+// so the stages of a drain share the drain timeout instead of each waiting for all of it
+func (n Node) drainTimeLeft(nodeName string) (Node, error) {
+	if n.drainDeadline.IsZero() {
+		return n, nil
+	}
+	left := time.Until(n.drainDeadline)
+	if left <= 0 {
+		return n, fmt.Errorf("Draining node %s did not finish within the drain timeout", nodeName)
+	}
+	return n.WithDrainTimeout(left), nil
+}
+
+// WithDrainTimeout returns a copy of the node which waits up to the timeout for pods to be evicted when draining
+func (n Node) WithDrainTimeout(timeout time.Duration) Node {
+	drainHelper := *n.drainHelper
+	drainHelper.Timeout = timeout
+	n.drainHelper = &drainHelper
+	return n
+}
+
+// WithTaintNode returns a copy of the node which taints nodes for interruptions regardless of the taint-node flag
+func (n Node) WithTaintNode() Node {
+	n.nthConfig.TaintNode = true
+	return n
+}
+
+// DeleteNode deletes the node object from the cluster
+func (n Node) DeleteNode(nodeName string) error {
 	if n.nthConfig.DryRun {
-		log.Info().Str("node_name", nodeName).Msg("Node would have been cordoned, but dry-run flag was set")
+		log.Info().Str("node_name", nodeName).Msg("Node would have been deleted, but dry-run flag was set")
 		return nil
 	}
+	err := n.deleteNode(nodeName)
+	if err != nil {
+		return fmt.Errorf("Unable to delete node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+func (n Node) deleteNode(nodeName string) error {
 	node, err := n.fetchKubernetesNode(nodeName)
 	if err != nil {
 		return err
 	}
-	err = drain.RunCordonOrUncordon(n.drainHelper, node, true)
+	return n.drainHelper.Client.CoreV1().Nodes().Delete(context.TODO(), node.Name, metav1.DeleteOptions{})
+}
+
+// Cordon will add a NoSchedule on the node
+func (n Node) Cordon(nodeName string) error {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Node would have been cordoned, but dry-run flag was set")
+		return nil
+	}
+	if n.drainOnly {
+		log.Info().Str("node_name", nodeName).Msg("Node is already unschedulable because of another controller, not cordoning it")
+		return nil
+	}
+	if n.tracksCordonedNodes() {
+		err := n.addAnnotation(nodeName, CordonedAnnotation, strconv.FormatInt(time.Now().Unix(), 10))
+		if err != nil {
+			return err
+		}
+	}
+	var node *corev1.Node
+	err := retryOnConflict(func() error {
+		var err error
+		node, err = n.fetchKubernetesNode(nodeName)
+		if err != nil {
+			return err
+		}
+		return drain.RunCordonOrUncordon(n.drainHelper, node, true)
+	})
 	if err != nil {
 		return err
 	}
+	_, preCordoned := node.Annotations[PreCordonedAnnotation]
+	if n.nthConfig.ClusterAutoscalerCoordination && !preCordoned {
+		err = n.markForClusterAutoscaler(node)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -136,13 +316,122 @@ func (n Node) Uncordon(nodeName string) error {
 		log.Info().Str("node_name", nodeName).Msg("Node would have been uncordoned, but dry-run flag was set")
 		return nil
 	}
-	node, err := n.fetchKubernetesNode(nodeName)
+	var node *corev1.Node
+	var preCordoned bool
+	err := retryOnConflict(func() error {
+		var err error
+		node, err = n.fetchKubernetesNode(nodeName)
+		if err != nil {
+			return fmt.Errorf("There was an error fetching the node in preparation for uncordoning: %w", err)
+		}
+		// a node another controller made unschedulable is left as node termination handler found it
+		if _, preCordoned = node.Annotations[PreCordonedAnnotation]; preCordoned {
+			return nil
+		}
+		return drain.RunCordonOrUncordon(n.drainHelper, node, false)
+	})
 	if err != nil {
-		return fmt.Errorf("There was an error fetching the node in preparation for uncordoning: %w", err)
+		return err
+	}
+	if preCordoned {
+		log.Info().Str("node_name", nodeName).Msgf("Node was cordoned by another controller (%s), leaving it unschedulable", node.Annotations[PreCordonedAnnotation])
+		err = n.removeAnnotation(nodeName, PreCordonedAnnotation)
+		if err != nil {
+			return err
+		}
+	} else if n.nthConfig.ClusterAutoscalerCoordination {
+		err = n.unmarkForClusterAutoscaler(node)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := node.Annotations[CordonedAnnotation]; ok {
+		err = n.removeAnnotation(nodeName, CordonedAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+	if hasTaint(node, DrainingTaint) {
+		_, err = removeTaint(node, n.drainHelper.Client, DrainingTaint)
+		if err != nil {
+			return fmt.Errorf("Unable to clean taint %s from node %s: %w", DrainingTaint, nodeName, err)
+		}
 	}
-	err = drain.RunCordonOrUncordon(n.drainHelper, node, false)
+	if _, ok := node.Annotations[DrainStartedAnnotation]; ok {
+		err = n.removeAnnotation(nodeName, DrainStartedAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := node.Annotations[ScheduledDrainAnnotation]; ok {
+		err = n.removeAnnotation(nodeName, ScheduledDrainAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := node.Annotations[StoppedInstanceAnnotation]; ok {
+		err = n.removeAnnotation(nodeName, StoppedInstanceAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := node.Annotations[DrainedAnnotation]; ok {
+		err = n.removeAnnotation(nodeName, DrainedAnnotation)
+		if err != nil {
+			return err
+		}
+	}
+	if n.nthConfig.EnableNamespaceDrainPolicies {
+		n.clearTerminationPending(nodeName)
+	}
+	err = n.RemoveInterruptionConditions(nodeName)
 	if err != nil {
-		return err
+		return fmt.Errorf("Unable to remove interruption conditions from node: %w", err)
+	}
+	return nil
+}
+
+// markForClusterAutoscaler signals cluster-autoscaler that the node is going away so it neither selects the node for
+// scale down nor counts it as capacity pods can be scheduled on
+func (n Node) markForClusterAutoscaler(node *corev1.Node) error {
+	err := addTaint(node, n, ClusterAutoscalerToBeDeletedTaint, strconv.FormatInt(time.Now().Unix(), 10), corev1.TaintEffectNoSchedule)
+	if err != nil {
+		return fmt.Errorf("Unable to taint node for cluster-autoscaler: %w", err)
+	}
+	_, disabled := node.Annotations[ClusterAutoscalerScaleDownDisabledAnnotation]
+	if _, ours := node.Annotations[ScaleDownDisabledAnnotation]; disabled && !ours {
+		return nil
+	}
+	// the marker is set first, so a failure in between never leaves an annotation behind which isn't removed again
+	err = n.addAnnotation(node.Name, ScaleDownDisabledAnnotation, "true")
+	if err != nil {
+		return fmt.Errorf("Unable to annotate node for cluster-autoscaler: %w", err)
+	}
+	err = n.addAnnotation(node.Name, ClusterAutoscalerScaleDownDisabledAnnotation, "true")
+	if err != nil {
+		return fmt.Errorf("Unable to annotate node for cluster-autoscaler: %w", err)
+	}
+	return nil
+}
+
+// unmarkForClusterAutoscaler removes the cluster-autoscaler signals added by markForClusterAutoscaler
+func (n Node) unmarkForClusterAutoscaler(node *corev1.Node) error {
+	_, err := removeTaint(node, n.drainHelper.Client, ClusterAutoscalerToBeDeletedTaint)
+	if err != nil {
+		return fmt.Errorf("Unable to remove cluster-autoscaler taint from node: %w", err)
+	}
+	if _, ok := node.Annotations[ScaleDownDisabledAnnotation]; !ok {
+		return nil
+	}
+	if _, ok := node.Annotations[ClusterAutoscalerScaleDownDisabledAnnotation]; ok {
+		err = n.removeAnnotation(node.Name, ClusterAutoscalerScaleDownDisabledAnnotation)
+		if err != nil {
+			return fmt.Errorf("Unable to remove cluster-autoscaler annotation from node: %w", err)
+		}
+	}
+	err = n.removeAnnotation(node.Name, ScaleDownDisabledAnnotation)
+	if err != nil {
+		return fmt.Errorf("Unable to remove cluster-autoscaler annotation from node: %w", err)
 	}
 	return nil
 }
@@ -169,6 +458,28 @@ func (n Node) MarkWithEventID(nodeName string, eventID string) error {
 	return nil
 }
 
+// MarkRebootedForEvent records that the node was rebooted to complete the scheduled event
+func (n Node) MarkRebootedForEvent(nodeName string, eventID string) error {
+	err := n.addAnnotation(nodeName, RebootedForEventAnnotation, eventID)
+	if err != nil {
+		return fmt.Errorf("Unable to annotate node with event ID %s=%s: %w", RebootedForEventAnnotation, eventID, err)
+	}
+	return nil
+}
+
+// WasRebootedForEvent returns true if the node was already rebooted to complete the scheduled event
+func (n Node) WasRebootedForEvent(nodeName string, eventID string) (bool, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msg("WasRebootedForEvent returning false since dry-run is set")
+		return false, nil
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return false, err
+	}
+	return node.Annotations[RebootedForEventAnnotation] == eventID, nil
+}
+
 // RemoveNTHLabels will remove all the custom NTH labels added to the node
 func (n Node) RemoveNTHLabels(nodeName string) error {
 	for _, label := range []string{EventIDLabelKey, ActionLabelKey, ActionLabelTimeKey} {
@@ -218,41 +529,31 @@ func (n Node) MarkForUncordonAfterReboot(nodeName string) error {
 	return nil
 }
 
-// addLabel will add a label to the node given a label key and value
+// addLabel will add a label to the node given a label key and value, applying it along with the other labels
+// node termination handler applied
 func (n Node) addLabel(nodeName string, key string, value string) error {
-	type metadata struct {
-		Labels map[string]string `json:"labels"`
-	}
-	type patch struct {
-		Metadata metadata `json:"metadata"`
-	}
-	labels := make(map[string]string)
-	labels[key] = value
-	payload := patch{
-		Metadata: metadata{
-			Labels: labels,
-		},
-	}
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("An error occurred while marshalling the json to add a label to the node: %w", err)
-	}
-	node, err := n.fetchKubernetesNode(nodeName)
-	if err != nil {
-		return err
-	}
 	if n.nthConfig.DryRun {
 		log.Info().Msgf("Would have added label (%s=%s) to node %s, but dry-run flag was set", key, value, nodeName)
 		return nil
 	}
-	_, err = n.drainHelper.Client.CoreV1().Nodes().Patch(context.TODO(), node.Name, types.StrategicMergePatchType, payloadBytes, metav1.PatchOptions{})
-	if err != nil {
-		return fmt.Errorf("%v node Patch failed when adding a label to the node: %w", node.Name, err)
-	}
-	return nil
+	return retryOnConflict(func() error {
+		node, err := n.fetchKubernetesNode(nodeName)
+		if err != nil {
+			return err
+		}
+		apply := newNodeApply(node.Name)
+		apply.Metadata.Labels = appliedValues(node.Labels, appliedKeys(node, "f:labels"))
+		apply.Metadata.Labels[key] = value
+		_, err = applyNode(n.drainHelper.Client, apply)
+		if err != nil {
+			return fmt.Errorf("%v node Patch failed when adding a label to the node: %w", node.Name, err)
+		}
+		return nil
+	})
 }
 
-// removeLabel will remove a node label given a label key
+// removeLabel will remove a node label given a label key. The label is removed with a targeted patch, which also
+// removes labels added before node termination handler used server-side apply.
 func (n Node) removeLabel(nodeName string, key string) error {
 	type patchRequest struct {
 		Op   string `json:"op"`
@@ -268,19 +569,82 @@ func (n Node) removeLabel(nodeName string, key string) error {
 	if err != nil {
 		return fmt.Errorf("An error occurred while marshalling the json to remove a label from the node: %w", err)
 	}
-	node, err := n.fetchKubernetesNode(nodeName)
-	if err != nil {
-		return err
-	}
 	if n.nthConfig.DryRun {
 		log.Info().Msgf("Would have removed label with key %s from node %s, but dry-run flag was set", key, nodeName)
 		return nil
 	}
-	_, err = n.drainHelper.Client.CoreV1().Nodes().Patch(context.TODO(), node.Name, types.JSONPatchType, payload, metav1.PatchOptions{})
+	return retryOnConflict(func() error {
+		node, err := n.fetchKubernetesNode(nodeName)
+		if err != nil {
+			return err
+		}
+		_, err = n.drainHelper.Client.CoreV1().Nodes().Patch(context.TODO(), node.Name, types.JSONPatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("%v node Patch failed when removing a label from the node: %w", node.Name, err)
+		}
+		return nil
+	})
+}
+
+// addAnnotation will add an annotation to the node given an annotation key and value, applying it along with the
+// other annotations node termination handler applied
+func (n Node) addAnnotation(nodeName string, key string, value string) error {
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have added annotation (%s=%s) to node %s, but dry-run flag was set", key, value, nodeName)
+		return nil
+	}
+	return n.addAnnotations(nodeName, map[string]string{key: value})
+}
+
+// addAnnotations adds or updates several node annotations with a single apply
+func (n Node) addAnnotations(nodeName string, annotations map[string]string) error {
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have added annotations %v to node %s, but dry-run flag was set", annotations, nodeName)
+		return nil
+	}
+	return retryOnConflict(func() error {
+		node, err := n.drainHelper.Client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		apply := newNodeApply(nodeName)
+		apply.Metadata.Annotations = appliedValues(node.Annotations, appliedKeys(node, "f:annotations"))
+		for key, value := range annotations {
+			apply.Metadata.Annotations[key] = value
+		}
+		_, err = applyNode(n.drainHelper.Client, apply)
+		if err != nil {
+			return fmt.Errorf("%v node Patch failed when adding an annotation to the node: %w", nodeName, err)
+		}
+		return nil
+	})
+}
+
+// removeAnnotation will remove a node annotation given an annotation key. Like labels, annotations are removed with a
+// targeted patch.
+func (n Node) removeAnnotation(nodeName string, key string) error {
+	type patchRequest struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+	}
+	payload, err := json.Marshal([]patchRequest{{
+		Op:   "remove",
+		Path: fmt.Sprintf("/metadata/annotations/%s", jsonPatchEscape(key)),
+	}})
 	if err != nil {
-		return fmt.Errorf("%v node Patch failed when removing a label from the node: %w", node.Name, err)
+		return fmt.Errorf("An error occurred while marshalling the json to remove an annotation from the node: %w", err)
 	}
-	return nil
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have removed annotation with key %s from node %s, but dry-run flag was set", key, nodeName)
+		return nil
+	}
+	return retryOnConflict(func() error {
+		_, err := n.drainHelper.Client.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.JSONPatchType, payload, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("%v node Patch failed when removing an annotation from the node: %w", nodeName, err)
+		}
+		return nil
+	})
 }
 
 // GetNodeLabels will fetch node labels for a given nodeName
@@ -296,6 +660,18 @@ func (n Node) GetNodeLabels(nodeName string) (map[string]string, error) {
 	return node.Labels, nil
 }
 
+// GetNodeZone returns the availability zone of a node from its zone label, or "" if the node has no zone label
+func (n Node) GetNodeZone(nodeName string) (string, error) {
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return "", err
+	}
+	if zone, ok := node.Labels[corev1.LabelTopologyZone]; ok {
+		return zone, nil
+	}
+	return node.Labels[corev1.LabelFailureDomainBetaZone], nil
+}
+
 // TaintSpotItn adds the spot termination notice taint onto a node
 func (n Node) TaintSpotItn(nodeName string, eventID string) error {
 	if !n.nthConfig.TaintNode {
@@ -495,11 +871,115 @@ func (n Node) fetchKubernetesNode(nodeName string) (*corev1.Node, error) {
 	return &matchingNodes.Items[0], nil
 }
 
+// FetchNodeNameByInstance returns the name of the kubernetes node backing an EC2 instance. Nodes are matched on
+// spec.providerID first, then on the private DNS name and finally on the private IP address, which handles clusters
+// where node names are not the EC2 private DNS name. If no node matches, the private DNS name is returned. The listed
+// nodes are cached, see ResolveInstances, and only listed again when an instance misses a list older than
+// nodeListMaxAge, so messages of instances outside the cluster don't list the nodes every time.
+func (n Node) FetchNodeNameByInstance(instanceID string, privateDNSName string, privateIP string) (string, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msgf("Would have looked up the node for instance %s, but dry-run flag was set", instanceID)
+		return privateDNSName, nil
+	}
+	if nodeName, ok := n.instanceNodes.resolve(instanceID, privateDNSName, privateIP); ok {
+		return nodeName, nil
+	}
+	if !n.instanceNodes.listedWithin(nodeListMaxAge) {
+		nodes, err := n.drainHelper.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return "", fmt.Errorf("Unable to list nodes to find the node for instance %s: %w", instanceID, err)
+		}
+		n.instanceNodes.update(nodes.Items)
+		if nodeName, ok := n.instanceNodes.resolve(instanceID, privateDNSName, privateIP); ok {
+			return nodeName, nil
+		}
+	}
+	log.Warn().Str("instance_id", instanceID).Msgf("No node matched the instance, falling back to the private DNS name %s", privateDNSName)
+	return privateDNSName, nil
+}
+
+// AreInstancesReady returns true if every EC2 instance is backing a kubernetes node which is Ready. Nodes are matched on spec.providerID.
+func (n Node) AreInstancesReady(instanceIDs []string) (bool, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msg("Would have checked if the instances' nodes are ready, but dry-run flag was set")
+		return true, nil
+	}
+	nodes, err := n.drainHelper.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("Unable to list nodes to check readiness: %w", err)
+	}
+	for _, instanceID := range instanceIDs {
+		ready := false
+		for _, node := range nodes.Items {
+			if strings.HasSuffix(node.Spec.ProviderID, "/"+instanceID) {
+				ready = isNodeReady(node)
+				break
+			}
+		}
+		if !ready {
+			log.Debug().Str("instance_id", instanceID).Msg("Instance does not have a Ready node yet")
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// HasReadyReplacement returns true if a schedulable, Ready node in the same zone and node group as the given node was created since the given time
+func (n Node) HasReadyReplacement(nodeName string, since time.Time) (bool, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msg("Would have checked for a replacement node, but dry-run flag was set")
+		return true, nil
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return false, err
+	}
+	nodes, err := n.drainHelper.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("Unable to list nodes to find a replacement: %w", err)
+	}
+	for _, candidate := range nodes.Items {
+		if candidate.Name == node.Name || candidate.Spec.Unschedulable || candidate.CreationTimestamp.Time.Before(since) {
+			continue
+		}
+		if !hasSameLabels(*node, candidate, append([]string{corev1.LabelTopologyZone}, nodeGroupLabelKeys...)...) {
+			continue
+		}
+		if isNodeReady(candidate) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasSameLabels returns true if the nodes have equal values for each of the label keys present on the first node
+func hasSameLabels(node corev1.Node, candidate corev1.Node, keys ...string) bool {
+	for _, key := range keys {
+		value, ok := node.Labels[key]
+		if ok && candidate.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func isNodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
 func (n Node) fetchAllPods(nodeName string) (*corev1.PodList, error) {
 	if n.nthConfig.DryRun {
 		log.Info().Msgf("Would have retrieved running pod list on node %s, but dry-run flag was set", nodeName)
 		return &corev1.PodList{}, nil
 	}
+	if pods, ok := n.cachedPods(nodeName); ok {
+		return pods, nil
+	}
 	return n.drainHelper.Client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
 		FieldSelector: "spec.nodeName=" + nodeName,
 	})
@@ -522,7 +1002,7 @@ func getDrainHelper(nthConfig config.Config) (*drain.Helper, error) {
 		return drainHelper, nil
 	}
 
-	clusterConfig, err := rest.InClusterConfig()
+	clusterConfig, err := nthConfig.KubernetesClientConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -547,53 +1027,35 @@ func addTaint(node *corev1.Node, nth Node, taintKey string, taintValue string, e
 		return nil
 	}
 
-	retryDeadline := time.Now().Add(maxRetryDeadline)
-	freshNode := node.DeepCopy()
 	client := nth.drainHelper.Client
-	var err error
-	refresh := false
-	for {
-		if refresh {
-			// Get the newest version of the node.
-			freshNode, err = client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
-			if err != nil || freshNode == nil {
-				nodeErr := fmt.Errorf("failed to get node %v: %w", node.Name, err)
-				log.Err(nodeErr).
-					Str("taint_key", taintKey).
-					Str("node_name", node.Name).
-					Msg("Error while adding taint on node")
-				return nodeErr
-			}
+	added := false
+	err := retryOnConflict(func() error {
+		// Get the newest version of the node.
+		freshNode, err := client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get node %v: %w", node.Name, err)
 		}
-
-		if !addTaintToSpec(freshNode, taintKey, taintValue, effect) {
-			if !refresh {
-				// Make sure we have the latest version before skipping update.
-				refresh = true
-				continue
-			}
+		added = addTaintToSpec(freshNode, taintKey, taintValue, effect)
+		if !added {
 			return nil
 		}
-		_, err = client.CoreV1().Nodes().Update(context.TODO(), freshNode, metav1.UpdateOptions{})
-		if err != nil && errors.IsConflict(err) && time.Now().Before(retryDeadline) {
-			refresh = true
-			time.Sleep(conflictRetryInterval)
-			continue
-		}
-
-		if err != nil {
-			log.Err(err).
-				Str("taint_key", taintKey).
-				Str("node_name", node.Name).
-				Msg("Error while adding taint on node")
-			return err
-		}
+		_, err = patchTaints(client, freshNode)
+		return err
+	})
+	if err != nil {
+		log.Err(err).
+			Str("taint_key", taintKey).
+			Str("node_name", node.Name).
+			Msg("Error while adding taint on node")
+		return err
+	}
+	if added {
 		log.Warn().
 			Str("taint_key", taintKey).
 			Str("node_name", node.Name).
 			Msg("Successfully added taint on node")
-		return nil
 	}
+	return nil
 }
 
 func addTaintToSpec(node *corev1.Node, taintKey string, taintValue string, effect corev1.TaintEffect) bool {
@@ -616,17 +1078,12 @@ func addTaintToSpec(node *corev1.Node, taintKey string, taintValue string, effec
 }
 
 func removeTaint(node *corev1.Node, client kubernetes.Interface, taintKey string) (bool, error) {
-	retryDeadline := time.Now().Add(maxRetryDeadline)
-	freshNode := node.DeepCopy()
-	var err error
-	refresh := false
-	for {
-		if refresh {
-			// Get the newest version of the node.
-			freshNode, err = client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
-			if err != nil || freshNode == nil {
-				return false, fmt.Errorf("failed to get node %v: %v", node.Name, err)
-			}
+	removed := false
+	err := retryOnConflict(func() error {
+		// Get the newest version of the node.
+		freshNode, err := client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get node %v: %w", node.Name, err)
 		}
 		newTaints := make([]corev1.Taint, 0)
 		for _, taint := range freshNode.Spec.Taints {
@@ -639,37 +1096,28 @@ func removeTaint(node *corev1.Node, client kubernetes.Interface, taintKey string
 				newTaints = append(newTaints, taint)
 			}
 		}
-		if len(newTaints) == len(freshNode.Spec.Taints) {
-			if !refresh {
-				// Make sure we have the latest version before skipping update.
-				refresh = true
-				continue
-			}
-			return false, nil
+		removed = len(newTaints) != len(freshNode.Spec.Taints)
+		if !removed {
+			return nil
 		}
-
 		freshNode.Spec.Taints = newTaints
-		_, err = client.CoreV1().Nodes().Update(context.TODO(), freshNode, metav1.UpdateOptions{})
-
-		if err != nil && errors.IsConflict(err) && time.Now().Before(retryDeadline) {
-			refresh = true
-			time.Sleep(conflictRetryInterval)
-			continue
-		}
-
-		if err != nil {
-			log.Err(err).
-				Str("taint_key", taintKey).
-				Str("node_name", node.Name).
-				Msg("Error while releasing taint on node")
-			return false, err
-		}
+		_, err = patchTaints(client, freshNode)
+		return err
+	})
+	if err != nil {
+		log.Err(err).
+			Str("taint_key", taintKey).
+			Str("node_name", node.Name).
+			Msg("Error while releasing taint on node")
+		return false, err
+	}
+	if removed {
 		log.Info().
 			Str("taint_key", taintKey).
 			Str("node_name", node.Name).
 			Msg("Successfully released taint on node")
-		return true, nil
 	}
+	return removed, nil
 }
 
 func getUptimeFunc(uptimeFile string) uptime.UptimeFuncType {
@@ -66,7 +66,7 @@ func getNode(t *testing.T, drainHelper *drain.Helper, uptime uptime.UptimeFuncTy
 }
 
 func TestUncordonIfRebootedFileReadError(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -89,7 +89,7 @@ func TestUncordonIfRebootedSystemNotRestarted(t *testing.T) {
 	err := ioutil.WriteFile(testFile, d1, 0644)
 	h.Ok(t, err)
 
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err = client.CoreV1().Nodes().Create(context.TODO(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -113,7 +113,7 @@ func TestUncordonIfRebootedFailureToRemoveLabel(t *testing.T) {
 	err := ioutil.WriteFile(testFile, d1, 0644)
 	h.Ok(t, err)
 
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err = client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -137,7 +137,7 @@ func TestUncordonIfRebootedFailureSuccess(t *testing.T) {
 	err := ioutil.WriteFile(testFile, d1, 0644)
 	h.Ok(t, err)
 
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err = client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -15,10 +15,14 @@ package node_test
 
 import (
 	"context"
+	"fmt"
+	"io/ioutil"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/aws/aws-node-termination-handler/pkg/config"
@@ -26,8 +30,11 @@ import (
 	h "github.com/aws/aws-node-termination-handler/pkg/test"
 	"github.com/aws/aws-node-termination-handler/pkg/uptime"
 	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/kubectl/pkg/drain"
 )
 
@@ -46,7 +53,7 @@ func getDrainHelper(client *fake.Clientset) *drain.Helper {
 	}
 }
 
-func getNode(t *testing.T, drainHelper *drain.Helper) *node.Node {
+func getNode(t testing.TB, drainHelper *drain.Helper) *node.Node {
 	nthConfig := config.Config{
 		NodeName: nodeName,
 	}
@@ -98,7 +105,7 @@ func TestNewFailure(t *testing.T) {
 }
 
 func TestDrainSuccess(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(
 		context.Background(),
 		&v1.Node{
@@ -112,13 +119,13 @@ func TestDrainSuccess(t *testing.T) {
 }
 
 func TestDrainCordonNodeFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := tNode.CordonAndDrain(nodeName)
 	h.Assert(t, true, "Failed to return error on CordonAndDrain failing to cordon node", err != nil)
 }
 
 func TestUncordonSuccess(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(
 		context.Background(),
 		&v1.Node{
@@ -132,13 +139,13 @@ func TestUncordonSuccess(t *testing.T) {
 }
 
 func TestUncordonFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := tNode.Uncordon(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on Uncordon failing to fetch node")
 }
 
 func TestIsUnschedulableSuccess(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(
 		context.Background(),
 		&v1.Node{
@@ -153,14 +160,14 @@ func TestIsUnschedulableSuccess(t *testing.T) {
 }
 
 func TestIsUnschedulableFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	value, err := tNode.IsUnschedulable(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on IsUnschedulable failing to fetch node")
 	h.Equals(t, true, value)
 }
 
 func TestMarkWithEventIDSuccess(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(
 		context.Background(),
 		&v1.Node{
@@ -174,13 +181,13 @@ func TestMarkWithEventIDSuccess(t *testing.T) {
 }
 
 func TestMarkWithEventIDFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := tNode.MarkWithEventID(nodeName, "EventID")
 	h.Assert(t, err != nil, "Failed to return error on MarkWithEventID failing to fetch node")
 }
 
 func TestRemoveNTHLablesFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := tNode.RemoveNTHLabels(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on failing RemoveNTHLabels")
 }
@@ -188,7 +195,7 @@ func TestRemoveNTHLablesFailure(t *testing.T) {
 func TestGetEventIDSuccess(t *testing.T) {
 	var labelValue = "bla"
 
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -205,13 +212,13 @@ func TestGetEventIDSuccess(t *testing.T) {
 }
 
 func TestGetEventIDNoNodeFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	_, err := tNode.GetEventID(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on GetEventID failed to find node")
 }
 
 func TestGetEventIDNoLabelFailure(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(
 		context.Background(),
 		&v1.Node{
@@ -225,13 +232,13 @@ func TestGetEventIDNoLabelFailure(t *testing.T) {
 }
 
 func TestMarkForUncordonAfterRebootAddActionLabelFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := tNode.MarkForUncordonAfterReboot(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on MarkForUncordonAfterReboot failing to add action Label")
 }
 
 func TestFetchPodsNameList(t *testing.T) {
-	client := fake.NewSimpleClientset(
+	client := h.NewFakeClientset(
 		&v1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: "myPod",
@@ -254,7 +261,7 @@ func TestFetchPodsNameList(t *testing.T) {
 }
 
 func TestLogPods(t *testing.T) {
-	client := fake.NewSimpleClientset(
+	client := h.NewFakeClientset(
 		&v1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: "myPod",
@@ -276,13 +283,13 @@ func TestLogPods(t *testing.T) {
 }
 
 func TestIsLableledWithActionFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	_, err := tNode.IsLabeledWithAction(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on IsLabeledWithAction failure")
 }
 
 func TestUncordonIfRebootedDefaultSuccess(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -301,13 +308,13 @@ func TestUncordonIfRebootedDefaultSuccess(t *testing.T) {
 }
 
 func TestUncordonIfRebootedNodeFetchFailure(t *testing.T) {
-	tNode := getNode(t, getDrainHelper(fake.NewSimpleClientset()))
+	tNode := getNode(t, getDrainHelper(h.NewFakeClientset()))
 	err := tNode.UncordonIfRebooted(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on UncordonIfReboted failure to find node")
 }
 
 func TestUncordonIfRebootedTimeParseFailure(t *testing.T) {
-	client := fake.NewSimpleClientset()
+	client := h.NewFakeClientset()
 	_, err := client.CoreV1().Nodes().Create(context.Background(),
 		&v1.Node{
 			ObjectMeta: metav1.ObjectMeta{
@@ -324,3 +331,346 @@ func TestUncordonIfRebootedTimeParseFailure(t *testing.T) {
 	err = tNode.UncordonIfRebooted(nodeName)
 	h.Assert(t, err != nil, "Failed to return error on UncordonIfReboted failure to parse time")
 }
+
+func TestGetNodeZone(t *testing.T) {
+	client := h.NewFakeClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "topology", Labels: map[string]string{v1.LabelTopologyZone: "us-east-1a"}},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "failure-domain", Labels: map[string]string{v1.LabelFailureDomainBetaZone: "us-east-1b"}},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-zone"},
+		},
+	)
+	tNode := getNode(t, getDrainHelper(client))
+
+	zone, err := tNode.GetNodeZone("topology")
+	h.Ok(t, err)
+	h.Equals(t, "us-east-1a", zone)
+	zone, err = tNode.GetNodeZone("failure-domain")
+	h.Ok(t, err)
+	h.Equals(t, "us-east-1b", zone)
+	zone, err = tNode.GetNodeZone("no-zone")
+	h.Ok(t, err)
+	h.Equals(t, "", zone)
+	_, err = tNode.GetNodeZone("missing")
+	h.Assert(t, err != nil, "Expected an error for a missing node")
+}
+
+func TestFetchNodeNameByInstance(t *testing.T) {
+	client := h.NewFakeClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "by-provider-id"},
+			Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1a/i-providerid"},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "by-hostname", Labels: map[string]string{"kubernetes.io/hostname": "ip-10-0-0-1.ec2.internal"}},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "by-ip"},
+			Status:     v1.NodeStatus{Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.2"}}},
+		},
+	)
+	tNode := getNode(t, getDrainHelper(client))
+
+	nodeName, err := tNode.FetchNodeNameByInstance("i-providerid", "ip-10-0-0-9.ec2.internal", "10.0.0.2")
+	h.Ok(t, err)
+	h.Equals(t, "by-provider-id", nodeName)
+
+	nodeName, err = tNode.FetchNodeNameByInstance("i-other", "ip-10-0-0-1.ec2.internal", "10.0.0.2")
+	h.Ok(t, err)
+	h.Equals(t, "by-hostname", nodeName)
+
+	nodeName, err = tNode.FetchNodeNameByInstance("i-other", "ip-10-0-0-2.ec2.internal", "10.0.0.2")
+	h.Ok(t, err)
+	h.Equals(t, "by-ip", nodeName)
+
+	nodeName, err = tNode.FetchNodeNameByInstance("i-other", "ip-10-0-0-3.ec2.internal", "10.0.0.3")
+	h.Ok(t, err)
+	h.Equals(t, "ip-10-0-0-3.ec2.internal", nodeName)
+
+	lists := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "list" && action.GetResource().Resource == "nodes" {
+			lists++
+		}
+	}
+	h.Equals(t, 1, lists)
+}
+
+func TestCordonAndUncordonClusterAutoscalerCoordination(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode, err := node.NewWithValues(config.Config{ClusterAutoscalerCoordination: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	err = tNode.Cordon(nodeName)
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "true", k8sNode.Annotations[node.ClusterAutoscalerScaleDownDisabledAnnotation])
+	h.Equals(t, 1, len(k8sNode.Spec.Taints))
+	h.Equals(t, node.ClusterAutoscalerToBeDeletedTaint, k8sNode.Spec.Taints[0].Key)
+
+	err = tNode.Uncordon(nodeName)
+	h.Ok(t, err)
+	k8sNode, err = client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	_, ok := k8sNode.Annotations[node.ClusterAutoscalerScaleDownDisabledAnnotation]
+	h.Assert(t, !ok, "Expected the cluster-autoscaler annotation to be removed")
+	_, ok = k8sNode.Annotations[node.ScaleDownDisabledAnnotation]
+	h.Assert(t, !ok, "Expected the scale-down-disabled marker to be removed")
+	h.Equals(t, 0, len(k8sNode.Spec.Taints))
+}
+
+func TestUncordonKeepsOperatorScaleDownDisabledAnnotation(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:        nodeName,
+		Annotations: map[string]string{node.ClusterAutoscalerScaleDownDisabledAnnotation: "true"},
+	}})
+	tNode, err := node.NewWithValues(config.Config{ClusterAutoscalerCoordination: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	h.Ok(t, tNode.Cordon(nodeName))
+	h.Ok(t, tNode.Uncordon(nodeName))
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, "true", k8sNode.Annotations[node.ClusterAutoscalerScaleDownDisabledAnnotation])
+	h.Equals(t, 0, len(k8sNode.Spec.Taints))
+}
+
+func TestCordonAndDrainSkipsEvictionWhenKubeletIsShuttingDown(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{
+			Type:    v1.NodeReady,
+			Status:  v1.ConditionFalse,
+			Reason:  "KubeletNotReady",
+			Message: "node is shutting down",
+		}}},
+	})
+	tNode, err := node.NewWithValues(config.Config{KubeletShutdownCoordination: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	err = tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Assert(t, k8sNode.Spec.Unschedulable, "Expected node to be cordoned")
+	_, ok := k8sNode.Annotations[node.DrainStartedAnnotation]
+	h.Assert(t, !ok, "Expected no drain-started annotation when kubelet is shutting down")
+}
+
+func TestCordonAndDrainAnnotatesForKubeletShutdownCoordination(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode, err := node.NewWithValues(config.Config{KubeletShutdownCoordination: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	err = tNode.CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	_, ok := k8sNode.Annotations[node.DrainStartedAnnotation]
+	h.Assert(t, ok, "Expected drain-started annotation to be set")
+
+	err = tNode.Uncordon(nodeName)
+	h.Ok(t, err)
+	k8sNode, err = client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	_, ok = k8sNode.Annotations[node.DrainStartedAnnotation]
+	h.Assert(t, !ok, "Expected drain-started annotation to be removed")
+}
+
+func TestScheduleDrain(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode := getNode(t, getDrainHelper(client))
+	drainTime := time.Date(2021, time.June, 5, 8, 0, 0, 0, time.UTC)
+
+	scheduled, err := tNode.ScheduleDrain(nodeName, "event-1", drainTime)
+	h.Ok(t, err)
+	h.Equals(t, drainTime, scheduled)
+
+	// a restart recomputes the drain time for the same event, but the persisted one is kept
+	scheduled, err = tNode.ScheduleDrain(nodeName, "event-1", drainTime.Add(time.Hour))
+	h.Ok(t, err)
+	h.Equals(t, drainTime, scheduled)
+
+	scheduled, err = tNode.ScheduleDrain(nodeName, "event-2", drainTime.Add(time.Hour))
+	h.Ok(t, err)
+	h.Equals(t, drainTime.Add(time.Hour), scheduled)
+
+	err = tNode.Uncordon(nodeName)
+	h.Ok(t, err)
+	n, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	_, ok := n.Annotations[node.ScheduledDrainAnnotation]
+	h.Equals(t, false, ok)
+}
+
+func TestUncordonIfRestartedDifferentInstance(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeName,
+			Labels: map[string]string{
+				"aws-node-termination-handler/action":      "UncordonAfterReboot",
+				"aws-node-termination-handler/action-time": strconv.FormatInt(time.Now().Unix(), 10),
+				"aws-node-termination-handler/event-id":    "instance-event-1",
+			},
+			Annotations: map[string]string{node.StoppedInstanceAnnotation: "i-0123456789"},
+		},
+		Spec: v1.NodeSpec{Unschedulable: true},
+	})
+	tNode := getNode(t, getDrainHelper(client))
+	err := tNode.UncordonIfRestarted(nodeName, "i-9876543210")
+	h.Ok(t, err)
+
+	n, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, true, n.Spec.Unschedulable)
+	_, ok := n.Labels["aws-node-termination-handler/action"]
+	h.Equals(t, false, ok)
+	_, ok = n.Annotations[node.StoppedInstanceAnnotation]
+	h.Equals(t, false, ok)
+}
+
+func TestUncordonIfStarted(t *testing.T) {
+	markedAt := time.Now()
+	client := h.NewFakeClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: nodeName,
+			Labels: map[string]string{
+				"aws-node-termination-handler/action":      "UncordonAfterReboot",
+				"aws-node-termination-handler/action-time": strconv.FormatInt(markedAt.Unix(), 10),
+				"aws-node-termination-handler/event-id":    "spot-itn-event-1",
+			},
+			Annotations: map[string]string{node.StoppedInstanceAnnotation: "i-0123456789"},
+		},
+		Spec: v1.NodeSpec{Unschedulable: true},
+	})
+	tNode := getNode(t, getDrainHelper(client))
+
+	// the instance was started before it was stopped, e.g. for a delayed message
+	uncordoned, err := tNode.UncordonIfStarted(nodeName, "i-0123456789", markedAt.Add(-time.Hour))
+	h.Ok(t, err)
+	h.Equals(t, false, uncordoned)
+
+	uncordoned, err = tNode.UncordonIfStarted(nodeName, "i-9876543210", markedAt.Add(time.Minute))
+	h.Ok(t, err)
+	h.Equals(t, false, uncordoned)
+
+	uncordoned, err = tNode.UncordonIfStarted(nodeName, "i-0123456789", markedAt.Add(time.Minute))
+	h.Ok(t, err)
+	h.Equals(t, true, uncordoned)
+	n, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Equals(t, false, n.Spec.Unschedulable)
+	_, ok := n.Labels["aws-node-termination-handler/action"]
+	h.Equals(t, false, ok)
+	_, ok = n.Annotations[node.StoppedInstanceAnnotation]
+	h.Equals(t, false, ok)
+}
+
+// benchmarkClusterSizes are the node and pod counts of the benchmarks, up to the size of large clusters
+var benchmarkClusterSizes = []int{10, 100, 1000, 5000}
+
+// BenchmarkFetchNodeNameByInstance resolves the node of an instance in clusters of increasing size, by provider ID with
+// cold and prewarmed caches and, for nodes registered without one, by private DNS name
+func BenchmarkFetchNodeNameByInstance(b *testing.B) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	for _, size := range benchmarkClusterSizes {
+		nodes := make([]runtime.Object, 0, size)
+		for i := 0; i < size; i++ {
+			nodes = append(nodes, &v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("ip-10-0-%d-%d.ec2.internal", i/256, i%256)},
+				Spec:       v1.NodeSpec{ProviderID: fmt.Sprintf("aws:///us-east-1a/i-%017d", i)},
+			})
+		}
+		drainHelper := getDrainHelper(h.NewFakeClientset(nodes...))
+		tNode, err := node.NewWithValues(config.Config{}, drainHelper, uptime.Uptime)
+		h.Ok(b, err)
+		last := size - 1
+		b.Run(fmt.Sprintf("ProviderID/%d-nodes", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// a new node has cold caches, like after a restart without prewarming
+				b.StopTimer()
+				coldNode, err := node.NewWithValues(config.Config{}, drainHelper, uptime.Uptime)
+				h.Ok(b, err)
+				b.StartTimer()
+				_, err = coldNode.FetchNodeNameByInstance(fmt.Sprintf("i-%017d", last), "", "")
+				h.Ok(b, err)
+			}
+		})
+		b.Run(fmt.Sprintf("Prewarmed/%d-nodes", size), func(b *testing.B) {
+			_, err := tNode.ResolveInstances()
+			h.Ok(b, err)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := tNode.FetchNodeNameByInstance(fmt.Sprintf("i-%017d", last), "", "")
+				h.Ok(b, err)
+			}
+		})
+		b.Run(fmt.Sprintf("PrivateDNSName/%d-nodes", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := tNode.FetchNodeNameByInstance("i-unknown", fmt.Sprintf("ip-10-0-%d-%d.ec2.internal", last/256, last%256), "")
+				h.Ok(b, err)
+			}
+		})
+	}
+}
+
+// BenchmarkCordonAndDrain cordons and drains a node with an increasing number of pods through the eviction API, which
+// evicts the pods concurrently
+func BenchmarkCordonAndDrain(b *testing.B) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	for _, pods := range benchmarkClusterSizes[:3] {
+		b.Run(fmt.Sprintf("%d-pods", pods), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				client, evictions := newEvictingClientset(pods)
+				drainHelper := getDrainHelper(client)
+				// the drain helper writes to the logger directly, which fails while logging is disabled
+				drainHelper.Out, drainHelper.ErrOut = ioutil.Discard, ioutil.Discard
+				tNode := getNode(b, drainHelper)
+				b.StartTimer()
+				h.Ok(b, tNode.CordonAndDrain(nodeName))
+				b.StopTimer()
+				h.Equals(b, int32(pods), atomic.LoadInt32(evictions))
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+// newEvictingClientset returns a fake clientset with the node and its pods, which supports the eviction API and deletes
+// evicted pods right away, and the counter of the evictions
+func newEvictingClientset(pods int) (*fake.Clientset, *int32) {
+	objects := []runtime.Object{&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}}
+	for i := 0; i < pods; i++ {
+		objects = append(objects, &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("pod-%d", i),
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "rs", Controller: &[]bool{true}[0]}},
+			},
+			Spec: v1.PodSpec{NodeName: nodeName},
+		})
+	}
+	client := h.NewFakeClientset(objects...)
+	client.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "policy/v1beta1", APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget"}}},
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: drain.EvictionSubresource, Kind: drain.EvictionKind}}},
+	}
+	var evictions int32
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		atomic.AddInt32(&evictions, 1)
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+		return true, nil, client.Tracker().Delete(v1.SchemeGroupVersion.WithResource("pods"), action.GetNamespace(), eviction.Name)
+	})
+	return client, &evictions
+}
@@ -0,0 +1,68 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// BlockingPDBs returns the namespace/name of the pod disruption budgets which allow no disruptions and select pods still
+// running on the node, e.g. after evictions were blocked until the drain timed out
+func (n Node) BlockingPDBs(nodeName string) ([]string, error) {
+	podList, errs := n.drainHelper.GetPodsForDeletion(nodeName)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("Unable to list pods for deletion on node %s: %v", nodeName, errs)
+	}
+	blocking := map[string]bool{}
+	listed := map[string]bool{}
+	for _, pod := range podList.Pods() {
+		if listed[pod.Namespace] {
+			continue
+		}
+		listed[pod.Namespace] = true
+		pdbs, err := n.drainHelper.Client.PolicyV1beta1().PodDisruptionBudgets(pod.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to list the pod disruption budgets in namespace %s: %w", pod.Namespace, err)
+		}
+		for _, pdb := range pdbs.Items {
+			if pdb.Status.DisruptionsAllowed > 0 {
+				continue
+			}
+			if pdb.Spec.Selector == nil {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil {
+				continue
+			}
+			for _, remaining := range podList.Pods() {
+				if remaining.Namespace == pdb.Namespace && selector.Matches(labels.Set(remaining.Labels)) {
+					blocking[pdb.Namespace+"/"+pdb.Name] = true
+					break
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(blocking))
+	for name := range blocking {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
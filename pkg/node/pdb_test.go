@@ -0,0 +1,61 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func createPDB(t *testing.T, client *fake.Clientset, name string, matchLabels map[string]string, disruptionsAllowed int32) {
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       policyv1beta1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: matchLabels}},
+		Status:     policyv1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: disruptionsAllowed},
+	}
+	_, err := client.PolicyV1beta1().PodDisruptionBudgets("default").Create(context.Background(), pdb, metav1.CreateOptions{})
+	h.Ok(t, err)
+}
+
+func TestBlockingPDBs(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client,
+		v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "database-0", Labels: map[string]string{"app": "database"}}},
+		v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Labels: map[string]string{"app": "web"}}})
+	createPDB(t, client, "database", map[string]string{"app": "database"}, 0)
+	createPDB(t, client, "web", map[string]string{"app": "web"}, 1)
+	createPDB(t, client, "queue", map[string]string{"app": "queue"}, 0)
+	tNode := getNode(t, getDrainHelper(client))
+
+	pdbs, err := tNode.BlockingPDBs(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"default/database"}, pdbs)
+}
+
+func TestBlockingPDBsWithoutPods(t *testing.T) {
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client)
+	createPDB(t, client, "database", map[string]string{"app": "database"}, 0)
+	tNode := getNode(t, getDrainHelper(client))
+
+	pdbs, err := tNode.BlockingPDBs(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{}, pdbs)
+}
@@ -0,0 +1,137 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/drainpolicy"
+	"github.com/rs/zerolog/log"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Permission is a kubernetes api access node termination handler needs to perform its configured actions
+type Permission struct {
+	Verb        string
+	Group       string
+	Resource    string
+	Subresource string
+	Namespace   string
+}
+
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource = fmt.Sprintf("%s.%s", p.Resource, p.Group)
+	}
+	if p.Subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, p.Subresource)
+	}
+	if p.Namespace != "" {
+		return fmt.Sprintf("%s %s in namespace %s", p.Verb, resource, p.Namespace)
+	}
+	return fmt.Sprintf("%s %s", p.Verb, resource)
+}
+
+// RequiredPermissions returns the kubernetes api permissions needed for the given configuration
+func RequiredPermissions(nthConfig config.Config) []Permission {
+	permissions := []Permission{
+		{Verb: "get", Resource: "nodes"},
+		{Verb: "list", Resource: "nodes"},
+		{Verb: "patch", Resource: "nodes"},
+		{Verb: "update", Resource: "nodes"},
+	}
+	if !nthConfig.CordonOnly {
+		permissions = append(permissions,
+			Permission{Verb: "list", Resource: "pods"},
+			Permission{Verb: "get", Resource: "pods"},
+			Permission{Verb: "create", Resource: "pods", Subresource: "eviction"},
+			Permission{Verb: "get", Group: "apps", Resource: "daemonsets"},
+			Permission{Verb: "list", Group: "policy", Resource: "poddisruptionbudgets"},
+		)
+	}
+	if nthConfig.EnableNamespaceDrainPolicies && !nthConfig.CordonOnly {
+		permissions = append(permissions,
+			Permission{Verb: "get", Resource: "namespaces"},
+			Permission{Verb: "patch", Resource: "pods"},
+		)
+	}
+	if nthConfig.RescheduleVerificationTimeout > 0 && !nthConfig.CordonOnly {
+		permissions = append(permissions,
+			Permission{Verb: "get", Group: "apps", Resource: "deployments"},
+			Permission{Verb: "get", Group: "apps", Resource: "replicasets"},
+			Permission{Verb: "get", Group: "apps", Resource: "statefulsets"},
+		)
+	}
+	if usesPodInformer(nthConfig) {
+		permissions = append(permissions, Permission{Verb: "watch", Resource: "pods"})
+	}
+	if nthConfig.EnableDrainPolicies && !nthConfig.CordonOnly {
+		permissions = append(permissions, Permission{Verb: "list", Group: drainpolicy.Group, Resource: drainpolicy.Resource})
+	}
+	if nthConfig.KarpenterNodeHandling == config.KarpenterNodeHandlingDelete || nthConfig.HasAction(config.ActionDrainAndDeleteNode) || nthConfig.OrphanedNodeGCInterval > 0 {
+		permissions = append(permissions, Permission{Verb: "delete", Resource: "nodes"})
+	}
+	if nthConfig.PublishNodeConditions {
+		permissions = append(permissions, Permission{Verb: "patch", Resource: "nodes", Subresource: "status"})
+	}
+	if nthConfig.EnableContainerCheckpoints && !nthConfig.CordonOnly {
+		permissions = append(permissions,
+			Permission{Verb: "create", Resource: "nodes", Subresource: "proxy"},
+			Permission{Verb: "patch", Resource: "pods"},
+		)
+	}
+	if nthConfig.EmitKubernetesEvents {
+		permissions = append(permissions,
+			Permission{Verb: "create", Resource: "events", Namespace: "default"},
+			Permission{Verb: "patch", Resource: "events", Namespace: "default"},
+		)
+	}
+	return permissions
+}
+
+// CheckPermissions uses SelfSubjectAccessReviews to verify node termination handler holds every kubernetes api permission
+// required by its configuration, along with the extra permissions of the packages which depend on this one, like the
+// TerminationEvent recorder. The permissions which are not allowed are returned.
+func (n Node) CheckPermissions(extra ...Permission) ([]Permission, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msg("Permissions would have been checked, but dry-run flag was set")
+		return nil, nil
+	}
+	var missing []Permission
+	for _, permission := range append(RequiredPermissions(n.nthConfig), extra...) {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Verb:        permission.Verb,
+					Group:       permission.Group,
+					Resource:    permission.Resource,
+					Subresource: permission.Subresource,
+					Namespace:   permission.Namespace,
+				},
+			},
+		}
+		result, err := n.drainHelper.Client.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+		if err != nil {
+			return missing, fmt.Errorf("Unable to check permission to %s: %w", permission, err)
+		}
+		if !result.Status.Allowed {
+			missing = append(missing, permission)
+		}
+	}
+	return missing, nil
+}
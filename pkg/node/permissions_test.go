@@ -0,0 +1,127 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func allowAllExcept(client *fake.Clientset, deniedResource string) {
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Resource != deniedResource
+		return true, review, nil
+	})
+}
+
+func TestRequiredPermissionsCordonOnly(t *testing.T) {
+	permissions := node.RequiredPermissions(config.Config{CordonOnly: true})
+	for _, permission := range permissions {
+		h.Assert(t, permission.Resource == "nodes", fmt.Sprintf("Unexpected permission required in cordon-only mode: %s", permission))
+	}
+}
+
+func TestRequiredPermissionsEvents(t *testing.T) {
+	permissions := node.RequiredPermissions(config.Config{EmitKubernetesEvents: true})
+	found := false
+	for _, permission := range permissions {
+		if permission.Resource == "events" && permission.Verb == "create" {
+			found = true
+		}
+	}
+	h.Assert(t, found, "Expected create events permission to be required when emitting kubernetes events")
+}
+
+func TestRequiredPermissionsContainerCheckpoints(t *testing.T) {
+	permissions := node.RequiredPermissions(config.Config{EnableContainerCheckpoints: true})
+	found := false
+	for _, permission := range permissions {
+		if permission.String() == "create nodes/proxy" {
+			found = true
+		}
+	}
+	h.Assert(t, found, "Expected create nodes/proxy permission to be required when checkpointing containers")
+}
+
+func TestRequiredPermissionsOrphanedNodeGC(t *testing.T) {
+	permissions := node.RequiredPermissions(config.Config{EnableSQSTerminationDraining: true, OrphanedNodeGCInterval: 300})
+	found := false
+	for _, permission := range permissions {
+		if permission.String() == "delete nodes" {
+			found = true
+		}
+	}
+	h.Assert(t, found, "Expected delete nodes permission to be required when collecting orphaned nodes")
+}
+
+func TestRequiredPermissionsRescheduleVerification(t *testing.T) {
+	required := map[string]bool{}
+	for _, permission := range node.RequiredPermissions(config.Config{RescheduleVerificationTimeout: 300}) {
+		required[permission.String()] = true
+	}
+	for _, resource := range []string{"deployments", "replicasets", "statefulsets"} {
+		h.Assert(t, required["get "+resource+".apps"], "Expected get %s permission to be required when verifying rescheduling", resource)
+	}
+}
+
+func TestCheckPermissionsAllowed(t *testing.T) {
+	client := h.NewFakeClientset()
+	allowAllExcept(client, "")
+	tNode := getNode(t, getDrainHelper(client))
+
+	missing, err := tNode.CheckPermissions()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(missing))
+}
+
+func TestCheckPermissionsMissing(t *testing.T) {
+	client := h.NewFakeClientset()
+	allowAllExcept(client, "pods")
+	tNode := getNode(t, getDrainHelper(client))
+
+	missing, err := tNode.CheckPermissions()
+	h.Ok(t, err)
+	h.Equals(t, 4, len(missing))
+	h.Equals(t, "create pods/eviction", missing[2].String())
+	h.Equals(t, "watch pods", missing[3].String())
+}
+
+func TestCheckPermissionsExtra(t *testing.T) {
+	client := h.NewFakeClientset()
+	allowAllExcept(client, "terminationevents")
+	tNode := getNode(t, getDrainHelper(client))
+
+	missing, err := tNode.CheckPermissions(node.Permission{Verb: "create", Group: "nodeterminationhandler.aws.amazon.com", Resource: "terminationevents"})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(missing))
+	h.Equals(t, "create terminationevents.nodeterminationhandler.aws.amazon.com", missing[0].String())
+}
+
+func TestCheckPermissionsDryRun(t *testing.T) {
+	tNode, err := node.NewWithValues(config.Config{DryRun: true}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Ok(t, err)
+	missing, err := tNode.CheckPermissions()
+	h.Ok(t, err)
+	h.Equals(t, 0, len(missing))
+}
@@ -0,0 +1,173 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// lastAppliedConfigAnnotation holds a copy of the whole pod applied with kubectl, which is never needed to drain it
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// usesPodInformer returns whether the pods of the node are cached. In IMDS mode the handler only ever drains its own
+// node, so watching its pods replaces listing them on every drain.
+func usesPodInformer(nthConfig config.Config) bool {
+	if nthConfig.DryRun || nthConfig.CordonOnly {
+		return false
+	}
+	return !nthConfig.EnableSQSTerminationDraining || nthConfig.EnableCombinedMode
+}
+
+// newPodInformer returns an informer of the pods scheduled on the node. Only the pods of the node are listed and
+// watched, and the pods are stripped of the data node termination handler doesn't use before they're cached, so the
+// cache stays small on nodes with hundreds of pods.
+func newPodInformer(client kubernetes.Interface, nodeName string) cache.SharedIndexInformer {
+	fieldSelector := fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.TODO(), options)
+			if err != nil {
+				return nil, err
+			}
+			for i := range pods.Items {
+				stripPod(&pods.Items[i])
+			}
+			return pods, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			watcher, err := client.CoreV1().Pods(metav1.NamespaceAll).Watch(context.TODO(), options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(watcher, func(event watch.Event) (watch.Event, bool) {
+				if pod, ok := event.Object.(*corev1.Pod); ok {
+					stripPod(pod)
+				}
+				return event, true
+			}), nil
+		},
+	}
+	return cache.NewSharedIndexInformer(listWatch, &corev1.Pod{}, 0, cache.Indexers{})
+}
+
+// stripPod removes the managed fields, the last applied configuration and the container data which isn't needed to
+// select, order or evict the pod. Resource requests, owners, volumes, labels and other annotations are kept.
+func stripPod(pod *corev1.Pod) {
+	pod.ManagedFields = nil
+	delete(pod.Annotations, lastAppliedConfigAnnotation)
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			container := &containers[i]
+			container.Command = nil
+			container.Args = nil
+			container.Env = nil
+			container.EnvFrom = nil
+			container.VolumeMounts = nil
+			container.LivenessProbe = nil
+			container.ReadinessProbe = nil
+			container.StartupProbe = nil
+			container.Lifecycle = nil
+		}
+	}
+	pod.Spec.EphemeralContainers = nil
+}
+
+// cachedPods returns the pods on the node from the informer, if the informer caches the pods of the node and synced
+func (n Node) cachedPods(nodeName string) (*corev1.PodList, bool) {
+	if n.pods == nil || nodeName != n.nthConfig.NodeName || !n.pods.HasSynced() {
+		return nil, false
+	}
+	return listCachedPods(n.pods, labels.Everything()), true
+}
+
+// listCachedPods returns the pods of the informer which match the selector
+func listCachedPods(informer cache.SharedIndexInformer, selector labels.Selector) *corev1.PodList {
+	podList := &corev1.PodList{}
+	for _, obj := range informer.GetStore().List() {
+		pod := obj.(*corev1.Pod)
+		if selector.Matches(labels.Set(pod.Labels)) {
+			podList.Items = append(podList.Items, *pod)
+		}
+	}
+	return podList
+}
+
+// WithPodInformer returns a copy of the node which reads the pods of its own node from the informer, including when the
+// drain helper lists the pods to evict
+func (n Node) WithPodInformer(informer cache.SharedIndexInformer) Node {
+	n.pods = informer
+	drainHelper := *n.drainHelper
+	drainHelper.Client = podCacheClient{Interface: drainHelper.Client, pods: informer, nodeName: n.nthConfig.NodeName}
+	n.drainHelper = &drainHelper
+	return n
+}
+
+// podCacheClient serves listing the pods of the node from the informer, all other requests go to the API server
+type podCacheClient struct {
+	kubernetes.Interface
+	pods     cache.SharedIndexInformer
+	nodeName string
+}
+
+func (c podCacheClient) CoreV1() corev1client.CoreV1Interface {
+	return podCacheCoreV1{CoreV1Interface: c.Interface.CoreV1(), client: c}
+}
+
+// list returns the cached pods if the options select the pods of the node in all namespaces and the informer synced
+func (c podCacheClient) list(namespace string, options metav1.ListOptions) (*corev1.PodList, bool) {
+	if namespace != metav1.NamespaceAll || options.FieldSelector != fields.OneTermEqualSelector("spec.nodeName", c.nodeName).String() || !c.pods.HasSynced() {
+		return nil, false
+	}
+	selector, err := labels.Parse(options.LabelSelector)
+	if err != nil {
+		return nil, false
+	}
+	return listCachedPods(c.pods, selector), true
+}
+
+type podCacheCoreV1 struct {
+	corev1client.CoreV1Interface
+	client podCacheClient
+}
+
+func (c podCacheCoreV1) Pods(namespace string) corev1client.PodInterface {
+	return podCachePods{PodInterface: c.CoreV1Interface.Pods(namespace), namespace: namespace, client: c.client}
+}
+
+type podCachePods struct {
+	corev1client.PodInterface
+	namespace string
+	client    podCacheClient
+}
+
+func (p podCachePods) List(ctx context.Context, options metav1.ListOptions) (*corev1.PodList, error) {
+	if pods, ok := p.client.list(p.namespace, options); ok {
+		return pods, nil
+	}
+	return p.PodInterface.List(ctx, options)
+}
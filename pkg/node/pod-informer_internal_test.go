@@ -0,0 +1,135 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPodInformerStripsPods(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:          "pod",
+			Namespace:     "default",
+			Annotations:   map[string]string{lastAppliedConfigAnnotation: "{}", "keep": "true"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+			Containers: []v1.Container{{
+				Name:      "app",
+				Env:       []v1.EnvVar{{Name: "KEY", Value: "value"}},
+				Command:   []string{"app"},
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}},
+			}},
+		},
+	}
+	client := h.NewFakeClientset(pod)
+	var fieldSelector string
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		fieldSelector = action.(k8stesting.ListAction).GetListRestrictions().Fields.String()
+		return false, nil, nil
+	})
+	informer := newPodInformer(client, nodeName)
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+	h.Assert(t, cache.WaitForCacheSync(stop, informer.HasSynced), "Pod informer should sync")
+	h.Equals(t, "spec.nodeName="+nodeName, fieldSelector)
+
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName}, getTestDrainHelper(client), nil)
+	h.Ok(t, err)
+	withPods := tNode.WithPodInformer(informer)
+	pods, ok := withPods.cachedPods(nodeName)
+	h.Assert(t, ok, "Pods of the node should be cached")
+	h.Equals(t, 1, len(pods.Items))
+	cached := pods.Items[0]
+	h.Equals(t, 0, len(cached.ManagedFields))
+	h.Equals(t, map[string]string{"keep": "true"}, cached.Annotations)
+	h.Equals(t, 0, len(cached.Spec.Containers[0].Env))
+	h.Equals(t, 0, len(cached.Spec.Containers[0].Command))
+	h.Equals(t, pod.Spec.Containers[0].Resources, cached.Spec.Containers[0].Resources)
+
+	_, ok = withPods.cachedPods("other-node")
+	h.Assert(t, !ok, "Pods of other nodes should not be read from the cache")
+
+	names, err := withPods.FetchPodNameList(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"pod"}, names)
+}
+
+func TestWaitForCacheSync(t *testing.T) {
+	client := h.NewFakeClientset()
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName}, getTestDrainHelper(client), nil)
+	h.Ok(t, err)
+	h.Assert(t, tNode.WaitForCacheSync(time.Millisecond), "A node without a pod cache should not wait")
+
+	informer := newPodInformer(client, nodeName)
+	withPods := tNode.WithPodInformer(informer)
+	h.Assert(t, !withPods.WaitForCacheSync(100*time.Millisecond), "The pod cache should not sync before the informer runs")
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+	h.Assert(t, withPods.WaitForCacheSync(10*time.Second), "The pod cache should sync")
+}
+
+func TestDrainListsPodsFromInformer(t *testing.T) {
+	newPod := func(name string, app string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": app}},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+		}
+	}
+	client := h.NewFakeClientset(newPod("web", "web"), newPod("db", "db"))
+	lists := 0
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists++
+		return false, nil, nil
+	})
+	informer := newPodInformer(client, nodeName)
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+	h.Assert(t, cache.WaitForCacheSync(stop, informer.HasSynced), "Pod informer should sync")
+	listsBeforeDrain := lists
+
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName}, getTestDrainHelper(client), nil)
+	h.Ok(t, err)
+	withPods := tNode.WithPodInformer(informer)
+	podList, errs := withPods.drainHelper.GetPodsForDeletion(nodeName)
+	h.Equals(t, 0, len(errs))
+	h.Equals(t, 2, len(podList.Pods()))
+
+	withPods.drainHelper.PodSelector = "app=web"
+	podList, errs = withPods.drainHelper.GetPodsForDeletion(nodeName)
+	h.Equals(t, 0, len(errs))
+	h.Equals(t, 1, len(podList.Pods()))
+	h.Equals(t, listsBeforeDrain, lists)
+
+	// the pods of other nodes are listed from the API server
+	_, errs = withPods.drainHelper.GetPodsForDeletion("other-node")
+	h.Equals(t, 0, len(errs))
+	h.Equals(t, listsBeforeDrain+1, lists)
+}
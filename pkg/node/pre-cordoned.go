@@ -0,0 +1,91 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PreCordonedAnnotation is set to the reason a node was already cordoned or tainted for termination by another
+	// controller when node termination handler adopted it, so node termination handler never makes it schedulable
+	PreCordonedAnnotation = "aws-node-termination-handler/pre-cordoned"
+	// CordonedAnnotation is set on the nodes node termination handler cordoned itself when cordoned-node-handling is
+	// set, so they are not mistaken for nodes cordoned by other controllers when an event is handled again
+	CordonedAnnotation = "aws-node-termination-handler/cordoned"
+
+	// UnschedulableReason is the reason of nodes which were cordoned by another controller
+	UnschedulableReason = "unschedulable"
+)
+
+// terminationTaints are the taints other controllers place on nodes they are terminating
+var terminationTaints = []string{ClusterAutoscalerToBeDeletedTaint, "karpenter.sh/disruption", "karpenter.sh/disrupted"}
+
+// CordonedByOtherController returns why the node is already unschedulable because of another controller, either
+// UnschedulableReason or the key of the other controller's termination taint, or an empty string if it is not
+func (n Node) CordonedByOtherController(nodeName string) (string, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Would have checked if the node is cordoned by another controller, but dry-run flag was set")
+		return "", nil
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return "", err
+	}
+	return cordonedByOtherController(node), nil
+}
+
+func cordonedByOtherController(node *corev1.Node) string {
+	if reason, ok := node.Annotations[PreCordonedAnnotation]; ok {
+		return reason
+	}
+	if _, ok := node.Annotations[CordonedAnnotation]; ok {
+		return ""
+	}
+	for _, taint := range terminationTaints {
+		if hasTaint(node, taint) {
+			return taint
+		}
+	}
+	if node.Spec.Unschedulable {
+		return UnschedulableReason
+	}
+	return ""
+}
+
+// tracksCordonedNodes returns true if the nodes node termination handler cordons itself are told apart from the nodes
+// other controllers cordoned
+func (n Node) tracksCordonedNodes() bool {
+	switch n.nthConfig.CordonedNodeHandling {
+	case config.CordonedNodeHandlingSkip, config.CordonedNodeHandlingDrainOnly, config.CordonedNodeHandlingAdopt:
+		return true
+	}
+	return false
+}
+
+// AdoptCordonedNode records that the node was cordoned by another controller for the reason, so uncordoning the node
+// after the event only removes what node termination handler added and leaves the node unschedulable
+func (n Node) AdoptCordonedNode(nodeName string, reason string) error {
+	return n.addAnnotation(nodeName, PreCordonedAnnotation, reason)
+}
+
+// WithDrainOnly returns a copy of the node which evicts the pods of nodes without cordoning or tainting them, for nodes
+// another controller already made unschedulable
+func (n Node) WithDrainOnly() Node {
+	n.drainOnly = true
+	n.nthConfig.TaintNode = false
+	return n
+}
@@ -0,0 +1,107 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func getCordonedNodeHandlingNode(t *testing.T, client *fake.Clientset, handling string) *node.Node {
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, CordonedNodeHandling: handling}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	return tNode
+}
+
+func fetchNode(t *testing.T, client *fake.Clientset) *v1.Node {
+	k8sNode, err := client.CoreV1().Nodes().Get(context.Background(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	return k8sNode
+}
+
+func TestCordonedByOtherController(t *testing.T) {
+	for _, test := range []struct {
+		node   *v1.Node
+		reason string
+	}{
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}, reason: ""},
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}, Spec: v1.NodeSpec{Unschedulable: true}}, reason: node.UnschedulableReason},
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}, Spec: v1.NodeSpec{Taints: []v1.Taint{{Key: "karpenter.sh/disruption", Value: "disrupting", Effect: v1.TaintEffectNoSchedule}}}}, reason: "karpenter.sh/disruption"},
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}, Spec: v1.NodeSpec{Unschedulable: true, Taints: []v1.Taint{{Key: node.ClusterAutoscalerToBeDeletedTaint, Effect: v1.TaintEffectNoSchedule}}}}, reason: node.ClusterAutoscalerToBeDeletedTaint},
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Annotations: map[string]string{node.CordonedAnnotation: "1700000000"}}, Spec: v1.NodeSpec{Unschedulable: true}}, reason: ""},
+		{node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Annotations: map[string]string{node.PreCordonedAnnotation: node.UnschedulableReason, node.CordonedAnnotation: "1700000000"}}, Spec: v1.NodeSpec{Unschedulable: true}}, reason: node.UnschedulableReason},
+	} {
+		tNode := getCordonedNodeHandlingNode(t, h.NewFakeClientset(test.node), config.CordonedNodeHandlingAdopt)
+		reason, err := tNode.CordonedByOtherController(nodeName)
+		h.Ok(t, err)
+		h.Equals(t, test.reason, reason)
+	}
+}
+
+func TestCordonMarksNodesCordonedByNTH(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode := getCordonedNodeHandlingNode(t, client, config.CordonedNodeHandlingSkip)
+	h.Ok(t, tNode.Cordon(nodeName))
+	reason, err := tNode.CordonedByOtherController(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, "", reason)
+
+	h.Ok(t, tNode.Uncordon(nodeName))
+	k8sNode := fetchNode(t, client)
+	h.Equals(t, false, k8sNode.Spec.Unschedulable)
+	_, ok := k8sNode.Annotations[node.CordonedAnnotation]
+	h.Assert(t, !ok, "Expected the cordoned annotation to be removed when uncordoning")
+}
+
+func TestCordonLeavesNodesUnmarkedByDefault(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	tNode := getNode(t, getDrainHelper(client))
+	h.Ok(t, tNode.Cordon(nodeName))
+	_, ok := fetchNode(t, client).Annotations[node.CordonedAnnotation]
+	h.Assert(t, !ok, "Expected no cordoned annotation without cordoned-node-handling")
+}
+
+func TestUncordonLeavesAdoptedNodeUnschedulable(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}, Spec: v1.NodeSpec{Unschedulable: true}})
+	tNode := getCordonedNodeHandlingNode(t, client, config.CordonedNodeHandlingAdopt)
+	h.Ok(t, tNode.AdoptCordonedNode(nodeName, node.UnschedulableReason))
+	h.Ok(t, tNode.CordonAndDrain(nodeName))
+
+	h.Ok(t, tNode.Uncordon(nodeName))
+	k8sNode := fetchNode(t, client)
+	h.Equals(t, true, k8sNode.Spec.Unschedulable)
+	_, ok := k8sNode.Annotations[node.PreCordonedAnnotation]
+	h.Assert(t, !ok, "Expected the pre-cordoned annotation to be removed once the node is released")
+}
+
+func TestWithDrainOnlyDoesNotCordonOrTaint(t *testing.T) {
+	client := h.NewFakeClientset(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}, Spec: v1.NodeSpec{Taints: []v1.Taint{{Key: "karpenter.sh/disruption", Effect: v1.TaintEffectNoSchedule}}}})
+	tNode := getCordonedNodeHandlingNode(t, client, config.CordonedNodeHandlingDrainOnly).WithTaintNode().WithDrainOnly()
+	h.Ok(t, tNode.CordonAndDrain(nodeName))
+	h.Ok(t, tNode.TaintSpotItn(nodeName, "spot-itn-123"))
+
+	k8sNode := fetchNode(t, client)
+	h.Equals(t, false, k8sNode.Spec.Unschedulable)
+	h.Equals(t, 1, len(k8sNode.Spec.Taints))
+	_, ok := k8sNode.Annotations[node.CordonedAnnotation]
+	h.Assert(t, !ok, "Expected no cordoned annotation for drain-only nodes")
+}
@@ -0,0 +1,165 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// nodeListMaxAge is how long the listed nodes are used to resolve instances which don't match any of them, before the
+// nodes are listed again
+var nodeListMaxAge = 30 * time.Second
+
+// instanceNodes maps the EC2 instance IDs, private DNS names and private IP addresses of the nodes to the node names,
+// as of the last time the nodes were listed. The node of an instance never changes, so a cached name stays valid while
+// instances which are added later miss.
+type instanceNodes struct {
+	sync.RWMutex
+	names    map[string]string
+	dnsNames map[string]string
+	ips      map[string]string
+	listedAt time.Time
+}
+
+func (c *instanceNodes) get(instanceID string) (string, bool) {
+	if c == nil || instanceID == "" {
+		return "", false
+	}
+	c.RLock()
+	defer c.RUnlock()
+	name, ok := c.names[instanceID]
+	return name, ok
+}
+
+// resolve returns the name of the node matching the instance ID, else the private DNS name and finally the private IP
+// address of the instance
+func (c *instanceNodes) resolve(instanceID string, privateDNSName string, privateIP string) (string, bool) {
+	if name, ok := c.get(instanceID); ok || c == nil {
+		return name, ok
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if name, ok := c.dnsNames[privateDNSName]; ok && privateDNSName != "" {
+		return name, true
+	}
+	if name, ok := c.ips[privateIP]; ok && privateIP != "" {
+		return name, true
+	}
+	return "", false
+}
+
+// listedWithin returns whether the nodes were listed within the max age
+func (c *instanceNodes) listedWithin(maxAge time.Duration) bool {
+	if c == nil {
+		return false
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return !c.listedAt.IsZero() && time.Since(c.listedAt) < maxAge
+}
+
+// update replaces the cached names with the names of the listed nodes, so nodes which are gone are dropped
+func (c *instanceNodes) update(nodes []corev1.Node) int {
+	if c == nil {
+		return 0
+	}
+	names := make(map[string]string, len(nodes))
+	dnsNames := make(map[string]string, len(nodes))
+	ips := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if instanceID := providerInstanceID(node.Spec.ProviderID); instanceID != "" {
+			names[instanceID] = node.Name
+		}
+		dnsNames[node.Name] = node.Name
+		if hostname := node.Labels["kubernetes.io/hostname"]; hostname != "" {
+			dnsNames[hostname] = node.Name
+		}
+		for _, address := range node.Status.Addresses {
+			switch address.Type {
+			case corev1.NodeInternalDNS:
+				dnsNames[address.Address] = node.Name
+			case corev1.NodeInternalIP:
+				ips[address.Address] = node.Name
+			}
+		}
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.names = names
+	c.dnsNames = dnsNames
+	c.ips = ips
+	c.listedAt = time.Now()
+	return len(names)
+}
+
+// providerInstanceID returns the last segment of a spec.providerID like aws:///us-east-1a/i-0123456789abcdef0, which
+// is the instance ID for EC2 instances
+func providerInstanceID(providerID string) string {
+	if i := strings.LastIndex(providerID, "/"); i >= 0 && i < len(providerID)-1 {
+		return providerID[i+1:]
+	}
+	return ""
+}
+
+// WaitForCacheSync waits until the pods of the node are cached, returning false if they weren't within the timeout
+func (n Node) WaitForCacheSync(timeout time.Duration) bool {
+	if n.pods == nil {
+		return true
+	}
+	stopCh := make(chan struct{})
+	timer := time.AfterFunc(timeout, func() { close(stopCh) })
+	defer timer.Stop()
+	return cache.WaitForCacheSync(stopCh, n.pods.HasSynced)
+}
+
+// ResolveInstances lists the nodes to cache the node names of their instances, so the first event after a restart
+// doesn't wait on listing the nodes to find the node of its instance. The number of resolved instances is returned.
+func (n Node) ResolveInstances() (int, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Msg("Would have resolved the nodes of the instances, but dry-run flag was set")
+		return 0, nil
+	}
+	nodes, err := n.drainHelper.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Unable to list nodes to resolve the nodes of the instances: %w", err)
+	}
+	return n.instanceNodes.update(nodes.Items), nil
+}
+
+// NodesByInstance lists the nodes backed by EC2 instances, by the instance ID in their spec.providerID. Nodes of other
+// providers, like Fargate, are left out.
+func (n Node) NodesByInstance() (map[string]corev1.Node, error) {
+	nodes, err := n.drainHelper.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list nodes: %w", err)
+	}
+	n.instanceNodes.update(nodes.Items)
+	byInstance := map[string]corev1.Node{}
+	for _, node := range nodes.Items {
+		instanceID := providerInstanceID(node.Spec.ProviderID)
+		if strings.HasPrefix(node.Spec.ProviderID, "aws://") && strings.HasPrefix(instanceID, "i-") {
+			byInstance[instanceID] = node
+		}
+	}
+	return byInstance, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFetchNodeNameByInstanceListsAgainAfterMaxAge(t *testing.T) {
+	defer func(maxAge time.Duration) { nodeListMaxAge = maxAge }(nodeListMaxAge)
+	client := h.NewFakeClientset()
+	tNode, err := NewWithValues(config.Config{}, getTestDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	_, err = tNode.ResolveInstances()
+	h.Ok(t, err)
+
+	h.Ok(t, client.Tracker().Add(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1b/i-2"},
+	}))
+	nodeName, err := tNode.FetchNodeNameByInstance("i-2", "ip-10-0-0-2.ec2.internal", "10.0.0.2")
+	h.Ok(t, err)
+	h.Equals(t, "ip-10-0-0-2.ec2.internal", nodeName)
+
+	nodeListMaxAge = 0
+	nodeName, err = tNode.FetchNodeNameByInstance("i-2", "ip-10-0-0-2.ec2.internal", "10.0.0.2")
+	h.Ok(t, err)
+	h.Equals(t, "node-2", nodeName)
+}
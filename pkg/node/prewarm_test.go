@@ -0,0 +1,63 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveInstances(t *testing.T) {
+	client := h.NewFakeClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Spec:       v1.NodeSpec{ProviderID: "aws:///us-east-1a/i-1"},
+		},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "without-provider-id"}},
+	)
+	tNode := getNode(t, getDrainHelper(client))
+
+	instances, err := tNode.ResolveInstances()
+	h.Ok(t, err)
+	h.Equals(t, 1, instances)
+
+	client.ClearActions()
+	nodeName, err := tNode.FetchNodeNameByInstance("i-1", "ip-10-0-0-1.ec2.internal", "10.0.0.1")
+	h.Ok(t, err)
+	h.Equals(t, "node-1", nodeName)
+	h.Equals(t, 0, len(client.Actions()))
+
+	// instances outside the cluster don't list the nodes again while the listed nodes are recent
+	nodeName, err = tNode.FetchNodeNameByInstance("i-outside", "ip-10-0-0-9.ec2.internal", "10.0.0.9")
+	h.Ok(t, err)
+	h.Equals(t, "ip-10-0-0-9.ec2.internal", nodeName)
+	h.Equals(t, 0, len(client.Actions()))
+}
+
+func TestResolveInstancesDryRun(t *testing.T) {
+	client := h.NewFakeClientset()
+	tNode, err := node.NewWithValues(config.Config{DryRun: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	instances, err := tNode.ResolveInstances()
+	h.Ok(t, err)
+	h.Equals(t, 0, instances)
+	h.Equals(t, 0, len(client.Actions()))
+}
@@ -0,0 +1,196 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ReschedulingRecovered is reported when the drained workloads have their desired ready replicas again
+	ReschedulingRecovered = "recovered"
+	// ReschedulingUnrecovered is reported when drained workloads lack ready replicas at the end of the verification
+	ReschedulingUnrecovered = "unrecovered"
+)
+
+// rescheduleCheckInterval is how often the replicas of the drained workloads are checked
+var rescheduleCheckInterval = 5 * time.Second
+
+// DrainedWorkload is a controller which is expected to replace the pods drained from a node on other nodes
+type DrainedWorkload struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// String returns the workload as kind/namespace/name, e.g. Deployment/default/web
+func (w DrainedWorkload) String() string {
+	return w.Kind + "/" + w.Namespace + "/" + w.Name
+}
+
+// DrainedWorkloads returns the deployments, stateful sets and replica sets without a deployment which have pods on the
+// node. Pods of daemon sets and jobs, and pods without a controller, are not replaced elsewhere, so they're left out.
+func (n Node) DrainedWorkloads(nodeName string) ([]DrainedWorkload, error) {
+	pods, err := n.fetchAllPods(nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list the pods of node %s: %w", nodeName, err)
+	}
+	seen := map[DrainedWorkload]bool{}
+	var workloads []DrainedWorkload
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		workload, ok, err := n.podWorkload(pod)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || seen[workload] {
+			continue
+		}
+		seen[workload] = true
+		workloads = append(workloads, workload)
+	}
+	sort.Slice(workloads, func(i, j int) bool { return workloads[i].String() < workloads[j].String() })
+	return workloads, nil
+}
+
+// podWorkload returns the workload replacing the pod, resolving replica sets to the deployment controlling them
+func (n Node) podWorkload(pod corev1.Pod) (DrainedWorkload, bool, error) {
+	controller := metav1.GetControllerOf(&pod)
+	if controller == nil {
+		return DrainedWorkload{}, false, nil
+	}
+	switch controller.Kind {
+	case "StatefulSet":
+		return DrainedWorkload{Kind: controller.Kind, Namespace: pod.Namespace, Name: controller.Name}, true, nil
+	case "ReplicaSet":
+		replicaSet, err := n.drainHelper.Client.AppsV1().ReplicaSets(pod.Namespace).Get(context.TODO(), controller.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return DrainedWorkload{}, false, nil
+		}
+		if err != nil {
+			return DrainedWorkload{}, false, fmt.Errorf("Unable to get the replica set %s/%s: %w", pod.Namespace, controller.Name, err)
+		}
+		if owner := metav1.GetControllerOf(replicaSet); owner != nil && owner.Kind == "Deployment" {
+			return DrainedWorkload{Kind: owner.Kind, Namespace: pod.Namespace, Name: owner.Name}, true, nil
+		}
+		return DrainedWorkload{Kind: controller.Kind, Namespace: pod.Namespace, Name: controller.Name}, true, nil
+	}
+	return DrainedWorkload{}, false, nil
+}
+
+// VerifyRescheduled waits until each workload has its desired number of ready, available and updated replicas, up to the
+// timeout. The workloads are first checked one interval after the drain, as their controllers may not have noticed the
+// evicted pods yet. The workloads which did not recover in time are returned as kind/namespace/name.
+func (n Node) VerifyRescheduled(workloads []DrainedWorkload, timeout time.Duration) []string {
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining > rescheduleCheckInterval {
+			remaining = rescheduleCheckInterval
+		}
+		time.Sleep(remaining)
+		unrecovered := n.unrecoveredWorkloads(workloads)
+		if len(unrecovered) == 0 || !time.Now().Before(deadline) {
+			return unrecovered
+		}
+	}
+}
+
+// unrecoveredWorkloads returns the workloads with fewer recovered replicas than desired. Deleted workloads want no
+// replicas, so they count as recovered.
+func (n Node) unrecoveredWorkloads(workloads []DrainedWorkload) []string {
+	var unrecovered []string
+	for _, workload := range workloads {
+		desired, recovered, err := n.workloadReplicas(workload)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("workload", workload.String()).Msg("Unable to get the replicas of the workload")
+			unrecovered = append(unrecovered, workload.String())
+			continue
+		}
+		if recovered < desired {
+			unrecovered = append(unrecovered, workload.String())
+		}
+	}
+	return unrecovered
+}
+
+// workloadReplicas returns the desired replicas of the workload and how many of them recovered, which are the replicas
+// counted as ready, available and, if the controller reports it, updated. The status of a controller which did not
+// observe the latest spec yet is outdated, so none of its replicas count as recovered.
+func (n Node) workloadReplicas(workload DrainedWorkload) (int32, int32, error) {
+	apps := n.drainHelper.Client.AppsV1()
+	switch workload.Kind {
+	case "Deployment":
+		deployment, err := apps.Deployments(workload.Namespace).Get(context.TODO(), workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		if deployment.Status.ObservedGeneration < deployment.Generation {
+			return desiredReplicas(deployment.Spec.Replicas), 0, nil
+		}
+		status := deployment.Status
+		return desiredReplicas(deployment.Spec.Replicas), minReplicas(status.ReadyReplicas, status.AvailableReplicas, status.UpdatedReplicas), nil
+	case "StatefulSet":
+		statefulSet, err := apps.StatefulSets(workload.Namespace).Get(context.TODO(), workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		if statefulSet.Status.ObservedGeneration < statefulSet.Generation {
+			return desiredReplicas(statefulSet.Spec.Replicas), 0, nil
+		}
+		status := statefulSet.Status
+		return desiredReplicas(statefulSet.Spec.Replicas), minReplicas(status.ReadyReplicas, status.UpdatedReplicas), nil
+	default:
+		replicaSet, err := apps.ReplicaSets(workload.Namespace).Get(context.TODO(), workload.Name, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		if replicaSet.Status.ObservedGeneration < replicaSet.Generation {
+			return desiredReplicas(replicaSet.Spec.Replicas), 0, nil
+		}
+		status := replicaSet.Status
+		return desiredReplicas(replicaSet.Spec.Replicas), minReplicas(status.ReadyReplicas, status.AvailableReplicas), nil
+	}
+}
+
+func minReplicas(replicas ...int32) int32 {
+	min := replicas[0]
+	for _, count := range replicas[1:] {
+		if count < min {
+			min = count
+		}
+	}
+	return min
+}
+
+// desiredReplicas returns the replicas of a workload spec, which default to 1
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
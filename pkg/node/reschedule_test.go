@@ -0,0 +1,96 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func controlledBy(kind string, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, Controller: &controller}}
+}
+
+func podControlledBy(name string, kind string, controllerName string) v1.Pod {
+	return v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: controlledBy(kind, controllerName)}}
+}
+
+func TestDrainedWorkloads(t *testing.T) {
+	client := h.NewFakeClientset(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1234", Namespace: "default", OwnerReferences: controlledBy("Deployment", "web")}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"}},
+	)
+	createNodeWithPods(t, client,
+		podControlledBy("web-1234-a", "ReplicaSet", "web-1234"),
+		podControlledBy("web-1234-b", "ReplicaSet", "web-1234"),
+		podControlledBy("bare-a", "ReplicaSet", "bare"),
+		podControlledBy("db-0", "StatefulSet", "db"),
+		podControlledBy("agent-a", "DaemonSet", "agent"),
+		podControlledBy("backup-a", "Job", "backup"),
+		v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "standalone"}})
+
+	workloads, err := getNode(t, getDrainHelper(client)).DrainedWorkloads(nodeName)
+	h.Ok(t, err)
+	names := []string{}
+	for _, workload := range workloads {
+		names = append(names, workload.String())
+	}
+	h.Equals(t, []string{"Deployment/default/web", "ReplicaSet/default/bare", "StatefulSet/default/db"}, names)
+}
+
+func TestVerifyRescheduled(t *testing.T) {
+	replicas := int32(3)
+	client := h.NewFakeClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 3, AvailableReplicas: 3, UpdatedReplicas: 3},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 2, UpdatedReplicas: 3},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "starting", Namespace: "default"},
+			Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas},
+			Status:     appsv1.ReplicaSetStatus{ReadyReplicas: 3, AvailableReplicas: 1},
+		},
+	)
+	tNode := getNode(t, getDrainHelper(client))
+	web := node.DrainedWorkload{Kind: "Deployment", Namespace: "default", Name: "web"}
+	db := node.DrainedWorkload{Kind: "StatefulSet", Namespace: "default", Name: "db"}
+	deleted := node.DrainedWorkload{Kind: "ReplicaSet", Namespace: "default", Name: "deleted"}
+	// ready replicas which are not available yet, e.g. within their minReadySeconds, did not recover
+	starting := node.DrainedWorkload{Kind: "ReplicaSet", Namespace: "default", Name: "starting"}
+
+	h.Equals(t, 0, len(tNode.VerifyRescheduled([]node.DrainedWorkload{web, deleted}, 10*time.Millisecond)))
+	h.Equals(t, []string{"StatefulSet/default/db"}, tNode.VerifyRescheduled([]node.DrainedWorkload{web, db}, 10*time.Millisecond))
+	h.Equals(t, []string{"ReplicaSet/default/starting"}, tNode.VerifyRescheduled([]node.DrainedWorkload{starting}, 10*time.Millisecond))
+
+	statefulSet, err := client.AppsV1().StatefulSets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	h.Ok(t, err)
+	statefulSet.Status.ReadyReplicas = 3
+	_, err = client.AppsV1().StatefulSets("default").Update(context.Background(), statefulSet, metav1.UpdateOptions{})
+	h.Ok(t, err)
+	h.Equals(t, 0, len(tNode.VerifyRescheduled([]node.DrainedWorkload{web, db}, 10*time.Millisecond)))
+}
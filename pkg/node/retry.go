@@ -0,0 +1,36 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// conflictBackoff bounds the attempts of node updates which conflict with concurrent updates of other controllers, like
+// the cluster autoscaler or the kubelet. The jitter keeps the handlers of many nodes from retrying in lockstep.
+var conflictBackoff = wait.Backoff{
+	Steps:    6,
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.5,
+}
+
+// retryOnConflict runs the update again while it fails with a conflict. The update must read the latest version of
+// the node on every attempt.
+func retryOnConflict(update func() error) error {
+	return retry.RetryOnConflict(conflictBackoff, update)
+}
@@ -0,0 +1,72 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// conflictingPatches fails the first patches of nodes with a conflict, like concurrent updates of other controllers
+func conflictingPatches(conflicts int) (k8stesting.ReactionFunc, *int) {
+	attempts := 0
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		attempts++
+		if attempts <= conflicts {
+			return true, nil, errors.NewConflict(schema.GroupResource{Resource: "nodes"}, nodeName, nil)
+		}
+		return false, nil, nil
+	}, &attempts
+}
+
+func TestAddTaintRetriesConflicts(t *testing.T) {
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	client := h.NewFakeClientset(node)
+	reaction, attempts := conflictingPatches(2)
+	client.PrependReactor("patch", "nodes", reaction)
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName}, getTestDrainHelper(client), nil)
+	h.Ok(t, err)
+
+	h.Ok(t, addTaint(node, *tNode, SpotInterruptionTaint, "event", v1.TaintEffectNoSchedule))
+	h.Equals(t, 3, *attempts)
+	updated, err := client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	h.Ok(t, err)
+	h.Assert(t, hasTaint(updated, SpotInterruptionTaint), "Taint should have been added after the conflicts")
+}
+
+func TestAddLabelGivesUpOnConflicts(t *testing.T) {
+	defer func(backoff wait.Backoff) { conflictBackoff = backoff }(conflictBackoff)
+	conflictBackoff.Duration = time.Millisecond
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+	client := h.NewFakeClientset(node)
+	reaction, attempts := conflictingPatches(conflictBackoff.Steps + 1)
+	client.PrependReactor("patch", "nodes", reaction)
+	tNode, err := NewWithValues(config.Config{NodeName: nodeName}, getTestDrainHelper(client), nil)
+	h.Ok(t, err)
+
+	err = tNode.addLabel(nodeName, ActionLabelKey, UncordonAfterRebootLabelVal)
+	h.Assert(t, errors.IsConflict(err), "Conflict should be returned once the attempts are exhausted")
+	h.Equals(t, conflictBackoff.Steps, *attempts)
+}
@@ -0,0 +1,67 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SafeToEvictAnnotation is set to "false" by app teams on pods cluster-autoscaler must not evict
+const SafeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+// drainRespectingSafeToEvict drains the node with the drain function, leaving the pods which are not safe to evict for
+// the end of the drain or running, depending on safe-to-evict-handling, so the pods cluster-autoscaler keeps running
+// are handled the same way by node termination handler
+func (n Node) drainRespectingSafeToEvict(node *corev1.Node, drainFn func(n Node, node *corev1.Node) error) error {
+	handling := n.nthConfig.SafeToEvictHandling
+	if handling != config.SafeToEvictHandlingLast && handling != config.SafeToEvictHandlingSkip {
+		return drainFn(n, node)
+	}
+	podList, errs := n.drainHelper.GetPodsForDeletion(node.Name)
+	if len(errs) > 0 {
+		return fmt.Errorf("Unable to list pods for deletion on node %s: %v", node.Name, errs)
+	}
+	var unsafePods []corev1.Pod
+	unsafe := map[string]bool{}
+	for _, pod := range podList.Pods() {
+		if !isSafeToEvict(pod) {
+			unsafePods = append(unsafePods, pod)
+			unsafe[pod.Namespace+"/"+pod.Name] = true
+		}
+	}
+	if len(unsafePods) == 0 {
+		return drainFn(n, node)
+	}
+	safe := n.withPodFilter(func(pod corev1.Pod) bool { return !unsafe[pod.Namespace+"/"+pod.Name] })
+	err := drainFn(safe, node)
+	if err != nil {
+		return err
+	}
+	if handling == config.SafeToEvictHandlingSkip {
+		for _, pod := range unsafePods {
+			log.Info().Str("pod", pod.Namespace+"/"+pod.Name).Msgf("Pod is annotated with %s=false, leaving it running", SafeToEvictAnnotation)
+		}
+		return nil
+	}
+	log.Info().Str("node_name", node.Name).Msgf("Evicting %d pods annotated with %s=false last", len(unsafePods), SafeToEvictAnnotation)
+	return n.deleteOrEvictPods(node.Name, unsafePods)
+}
+
+func isSafeToEvict(pod corev1.Pod) bool {
+	return pod.Annotations[SafeToEvictAnnotation] != "false"
+}
@@ -0,0 +1,82 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func getNodeWithSafeToEvictHandling(t *testing.T, client *fake.Clientset, handling string) *node.Node {
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, DrainStrategy: node.DrainStrategyPriorityTiered, SafeToEvictHandling: handling}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+	return tNode
+}
+
+func notSafeToEvict(pod v1.Pod) v1.Pod {
+	pod.Annotations = map[string]string{node.SafeToEvictAnnotation: "false"}
+	return pod
+}
+
+func TestSafeToEvictHandling(t *testing.T) {
+	for _, test := range []struct {
+		handling string
+		deleted  []string
+	}{
+		{handling: config.SafeToEvictHandlingIgnore, deleted: []string{"batch", "web", "critical"}},
+		{handling: config.SafeToEvictHandlingLast, deleted: []string{"web", "critical", "batch"}},
+		{handling: config.SafeToEvictHandlingSkip, deleted: []string{"web", "critical"}},
+	} {
+		client := h.NewFakeClientset()
+		createNodeWithPods(t, client, podWithPriority("critical", 1000), notSafeToEvict(podWithPriority("batch", -10)), podWithPriority("web", 0))
+		deleted := recordPodDeletions(client)
+		err := getNodeWithSafeToEvictHandling(t, client, test.handling).CordonAndDrain(nodeName)
+		h.Ok(t, err)
+		h.Equals(t, test.deleted, *deleted)
+	}
+}
+
+func TestSafeToEvictHandlingWithoutAnnotatedPods(t *testing.T) {
+	client := h.NewFakeClientset()
+	safe := podWithPriority("web", 0)
+	safe.Annotations = map[string]string{node.SafeToEvictAnnotation: "true"}
+	createNodeWithPods(t, client, safe)
+	deleted := recordPodDeletions(client)
+	err := getNodeWithSafeToEvictHandling(t, client, config.SafeToEvictHandlingSkip).CordonAndDrain(nodeName)
+	h.Ok(t, err)
+	h.Equals(t, []string{"web"}, *deleted)
+}
+
+func TestSafeToEvictHandlingLastSharesDrainTimeout(t *testing.T) {
+	node.RegisterDrainStrategy("test-slow-drain", node.DrainStrategyFunc(func(n node.Node, k8sNode *v1.Node) error {
+		time.Sleep(n.DrainHelper().Timeout)
+		return nil
+	}))
+	client := h.NewFakeClientset()
+	createNodeWithPods(t, client, notSafeToEvict(podWithPriority("batch", -10)), podWithPriority("web", 0))
+	deleted := recordPodDeletions(client)
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, DrainStrategy: "test-slow-drain", SafeToEvictHandling: config.SafeToEvictHandlingLast}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	err = tNode.WithDrainTimeout(100 * time.Millisecond).CordonAndDrain(nodeName)
+	h.Nok(t, err)
+	h.Equals(t, 0, len(*deleted))
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ScheduledDrainAnnotation holds the scheduled event ID and the time its drain is scheduled for, as <event-id>,<RFC3339 time>,
+// so a drain scheduled ahead of the event keeps its start time when node termination handler restarts
+const ScheduledDrainAnnotation = "aws-node-termination-handler/scheduled-drain"
+
+// ScheduleDrain persists the drain time for the scheduled event on the node. If a drain time was already persisted for
+// the same event, it is returned instead of drainTime.
+func (n Node) ScheduleDrain(nodeName string, eventID string, drainTime time.Time) (time.Time, error) {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msgf("Drain would have been scheduled for %s, but dry-run flag was set", drainTime)
+		return drainTime, nil
+	}
+	node, err := n.fetchKubernetesNode(nodeName)
+	if err != nil {
+		return drainTime, err
+	}
+	if persistedID, persistedTime, ok := parseScheduledDrain(node.Annotations[ScheduledDrainAnnotation]); ok && persistedID == eventID {
+		return persistedTime, nil
+	}
+	value := fmt.Sprintf("%s,%s", eventID, drainTime.UTC().Format(time.RFC3339))
+	err = n.addAnnotation(nodeName, ScheduledDrainAnnotation, value)
+	if err != nil {
+		return drainTime, fmt.Errorf("Unable to annotate node with scheduled drain %s=%s: %w", ScheduledDrainAnnotation, value, err)
+	}
+	return drainTime, nil
+}
+
+func parseScheduledDrain(value string) (string, time.Time, bool) {
+	parts := strings.SplitN(value, ",", 2)
+	if len(parts) != 2 {
+		return "", time.Time{}, false
+	}
+	drainTime, err := time.Parse(time.RFC3339, parts[1])
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return parts[0], drainTime, true
+}
@@ -0,0 +1,139 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// TerminationNoticePortAnnotation holds the port of the pod IP termination notices are posted to
+	TerminationNoticePortAnnotation = "aws-node-termination-handler/termination-notice-port"
+	// TerminationNoticePathAnnotation holds the path termination notices are posted to on the termination notice port
+	TerminationNoticePathAnnotation = "aws-node-termination-handler/termination-notice-path"
+
+	defaultTerminationNoticePath = "/termination-notice"
+)
+
+var terminationNoticeTimeout = 5 * time.Second
+
+// TerminationNotice is the body of the POST request sent to pods before they are evicted
+type TerminationNotice struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Node      string `json:"node"`
+	EventID   string `json:"eventId"`
+	Kind      string `json:"kind"`
+	// Deadline is the RFC3339 time the instance is interrupted at, if known
+	Deadline string `json:"deadline,omitempty"`
+}
+
+// SendTerminationNotices posts the termination notice to the pods of the node which annotate a termination notice port,
+// giving applications an earlier and richer signal than SIGTERM to start draining connections and handing off
+// state. The notices are sent concurrently. Failed notices are logged and don't keep the pods from being evicted. The
+// number of pods which accepted the notice is returned.
+func (n Node) SendTerminationNotices(nodeName string, notice TerminationNotice) int {
+	if n.nthConfig.DryRun {
+		log.Info().Str("node_name", nodeName).Msg("Termination notices would have been sent to pods, but dry-run flag was set")
+		return 0
+	}
+	podList, errs := n.drainHelper.GetPodsForDeletion(nodeName)
+	if len(errs) > 0 {
+		log.Warn().Str("node_name", nodeName).Msgf("Unable to list pods to send termination notices to: %v", errs)
+		return 0
+	}
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	notified := 0
+	for _, pod := range podList.Pods() {
+		noticeURL := terminationNoticeURL(pod)
+		if noticeURL == "" {
+			continue
+		}
+		podNotice := notice
+		podNotice.Namespace = pod.Namespace
+		podNotice.Pod = pod.Name
+		podNotice.Node = nodeName
+		wg.Add(1)
+		go func(pod corev1.Pod) {
+			defer wg.Done()
+			err := sendTerminationNotice(noticeURL, podNotice)
+			if err != nil {
+				log.Warn().Err(err).Str("pod", pod.Namespace+"/"+pod.Name).Msg("Unable to send the termination notice to the pod")
+				return
+			}
+			log.Info().Str("pod", pod.Namespace+"/"+pod.Name).Str("url", noticeURL).Msg("Sent the termination notice to the pod")
+			mutex.Lock()
+			notified++
+			mutex.Unlock()
+		}(pod)
+	}
+	wg.Wait()
+	return notified
+}
+
+// terminationNoticeURL returns the URL the termination notice of the pod is posted to, or "" if the pod doesn't want one.
+// Notices are only sent to the pod's own IP, so pods can't make NTH send requests to other hosts.
+func terminationNoticeURL(pod corev1.Pod) string {
+	port := pod.Annotations[TerminationNoticePortAnnotation]
+	if port == "" || pod.Status.PodIP == "" {
+		return ""
+	}
+	if portNumber, err := strconv.Atoi(port); err != nil || portNumber < 1 || portNumber > 65535 {
+		log.Warn().Str("pod", pod.Namespace+"/"+pod.Name).Msgf("Ignoring the invalid termination notice port %q of the pod", port)
+		return ""
+	}
+	path := pod.Annotations[TerminationNoticePathAnnotation]
+	if path == "" {
+		path = defaultTerminationNoticePath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	noticeURL := url.URL{Scheme: "http", Host: net.JoinHostPort(pod.Status.PodIP, port), Path: path}
+	return noticeURL.String()
+}
+
+func sendTerminationNotice(noticeURL string, notice TerminationNotice) error {
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+	client := http.Client{
+		Timeout: terminationNoticeTimeout,
+		// redirects could lead the notice to another host
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	response, err := client.Post(noticeURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("termination notice URL %s responded with status %d", noticeURL, response.StatusCode)
+	}
+	return nil
+}
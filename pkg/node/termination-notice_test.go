@@ -0,0 +1,82 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package node_test
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSendTerminationNotices(t *testing.T) {
+	var mutex sync.Mutex
+	received := map[string]node.TerminationNotice{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/failing":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case "/redirect":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+			return
+		}
+		notice := node.TerminationNotice{}
+		h.Ok(t, json.NewDecoder(r.Body).Decode(&notice))
+		mutex.Lock()
+		received[r.URL.Path+" "+notice.Pod] = notice
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	h.Ok(t, err)
+
+	client := h.NewFakeClientset()
+	byPath := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "by-path", Annotations: map[string]string{node.TerminationNoticePortAnnotation: port, node.TerminationNoticePathAnnotation: "notice"}}, Status: v1.PodStatus{PodIP: host}}
+	byPort := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "by-port", Annotations: map[string]string{node.TerminationNoticePortAnnotation: port}}, Status: v1.PodStatus{PodIP: host}}
+	failing := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "failing", Annotations: map[string]string{node.TerminationNoticePortAnnotation: port, node.TerminationNoticePathAnnotation: "failing"}}, Status: v1.PodStatus{PodIP: host}}
+	redirecting := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redirecting", Annotations: map[string]string{node.TerminationNoticePortAnnotation: port, node.TerminationNoticePathAnnotation: "/redirect"}}, Status: v1.PodStatus{PodIP: host}}
+	// the notices are only sent to the pod IP
+	byURL := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "by-url", Annotations: map[string]string{"aws-node-termination-handler/termination-notice-url": server.URL + "/notice"}}}
+	otherHost := v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other-host", Annotations: map[string]string{node.TerminationNoticePortAnnotation: port + "@169.254.169.254"}}, Status: v1.PodStatus{PodIP: host}}
+	createNodeWithPods(t, client, byPath, byPort, failing, redirecting, byURL, otherHost, v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}})
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, EnableTerminationNotices: true}, getDrainHelper(client), uptime.Uptime)
+	h.Ok(t, err)
+
+	notified := tNode.SendTerminationNotices(nodeName, node.TerminationNotice{EventID: "spot-itn-1", Kind: "SPOT_ITN", Deadline: "2021-06-05T08:00:00Z"})
+	h.Equals(t, 2, notified)
+	paths := []string{}
+	for path := range received {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	h.Equals(t, []string{"/notice by-path", "/termination-notice by-port"}, paths)
+	h.Equals(t, node.TerminationNotice{Namespace: "default", Pod: "by-port", Node: nodeName, EventID: "spot-itn-1", Kind: "SPOT_ITN", Deadline: "2021-06-05T08:00:00Z"}, received["/termination-notice by-port"])
+}
+
+func TestSendTerminationNoticesDryRun(t *testing.T) {
+	tNode, err := node.NewWithValues(config.Config{NodeName: nodeName, DryRun: true}, getDrainHelper(h.NewFakeClientset()), uptime.Uptime)
+	h.Ok(t, err)
+	h.Equals(t, 0, tNode.SendTerminationNotices(nodeName, node.TerminationNotice{}))
+}
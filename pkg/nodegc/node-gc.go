@@ -0,0 +1,178 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package nodegc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-node-termination-handler/pkg/observability"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/rs/zerolog/log"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DeleteOrphanedNodeAction is the node action of deleted orphaned nodes in metrics
+	DeleteOrphanedNodeAction = "delete-orphaned-node"
+
+	// defaultMinNodeAge leaves nodes alone whose instances may not be visible to DescribeInstances yet
+	defaultMinNodeAge = 10 * time.Minute
+	// describeInstancesBatchSize is the maximum number of values of a DescribeInstances filter
+	describeInstancesBatchSize = 200
+)
+
+// EC2API is the part of the EC2 API the Collector uses
+type EC2API interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+}
+
+// Collector deletes the nodes whose EC2 instances no longer exist, which catches terminations that produced no
+// interruption event
+type Collector struct {
+	EC2      EC2API
+	Node     node.Node
+	Metrics  observability.Metrics
+	Recorder observability.K8sEventRecorder
+	// Region is the region of the EC2 client. The nodes of instances in other regions are never collected, since their
+	// instances are unknown to the client.
+	Region string
+	// MinNodeAge is the age a node must have before it is considered orphaned
+	MinNodeAge time.Duration
+}
+
+// New constructs a Collector
+func New(ec2 EC2API, region string, node node.Node, metrics observability.Metrics, recorder observability.K8sEventRecorder) Collector {
+	return Collector{
+		EC2:        ec2,
+		Region:     region,
+		Node:       node,
+		Metrics:    metrics,
+		Recorder:   recorder,
+		MinNodeAge: defaultMinNodeAge,
+	}
+}
+
+// Run collects the orphaned nodes every interval, forever
+func (c Collector) Run(interval time.Duration) {
+	log.Info().Msgf("Started collecting orphaned nodes every %s", interval)
+	for range time.NewTicker(interval).C {
+		if _, err := c.Collect(); err != nil {
+			log.Warn().Err(err).Msg("There was a problem collecting orphaned nodes")
+		}
+	}
+}
+
+// Collect deletes the nodes which are older than the minimum age, are not Ready, are in the region of the client, and
+// whose instances are terminated or unknown to EC2. The names of the deleted nodes are returned.
+func (c Collector) Collect() ([]string, error) {
+	nodes, err := c.Node.NodesByInstance()
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{}
+	for instanceID, k8sNode := range nodes {
+		if time.Since(k8sNode.CreationTimestamp.Time) >= c.MinNodeAge && !isReady(k8sNode) && inRegion(k8sNode.Spec.ProviderID, c.Region) {
+			candidates = append(candidates, instanceID)
+		}
+	}
+	if len(candidates) == 0 {
+		return []string{}, nil
+	}
+	sort.Strings(candidates)
+	existing, err := c.existingInstances(candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, instanceID := range candidates {
+		if existing[instanceID] {
+			continue
+		}
+		nodeName := nodes[instanceID].Name
+		log.Info().Str("node_name", nodeName).Str("instance_id", instanceID).Msg("Deleting the node of an instance which no longer exists")
+		err := c.Node.DeleteNode(nodeName)
+		c.Metrics.NodeActionsInc(DeleteOrphanedNodeAction, nodeName, err)
+		if err != nil {
+			log.Err(err).Str("node_name", nodeName).Msg("Unable to delete the orphaned node")
+			c.Recorder.Emit(nodeName, observability.Warning, observability.OrphanedNodeErrReason, observability.OrphanedNodeErrMsgFmt, instanceID, err.Error())
+			continue
+		}
+		c.Recorder.Emit(nodeName, observability.Normal, observability.OrphanedNodeReason, observability.OrphanedNodeMsgFmt, instanceID)
+		deleted = append(deleted, nodeName)
+	}
+	return deleted, nil
+}
+
+// existingInstances returns the instances which exist and are not terminated. Unlike instance IDs, filters don't fail
+// the request for unknown instances.
+func (c Collector) existingInstances(instanceIDs []string) (map[string]bool, error) {
+	existing := map[string]bool{}
+	for start := 0; start < len(instanceIDs); start += describeInstancesBatchSize {
+		end := start + describeInstancesBatchSize
+		if end > len(instanceIDs) {
+			end = len(instanceIDs)
+		}
+		input := &ec2.DescribeInstancesInput{
+			Filters: []types.Filter{{Name: aws.String("instance-id"), Values: instanceIDs[start:end]}},
+		}
+		for {
+			result, err := c.EC2.DescribeInstances(context.TODO(), input)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to describe the instances of the nodes: %w", err)
+			}
+			for _, reservation := range result.Reservations {
+				for _, instance := range reservation.Instances {
+					if instance.State != nil && instance.State.Name == types.InstanceStateNameTerminated {
+						continue
+					}
+					existing[aws.ToString(instance.InstanceId)] = true
+				}
+			}
+			if aws.ToString(result.NextToken) == "" {
+				break
+			}
+			input.NextToken = result.NextToken
+		}
+	}
+	return existing, nil
+}
+
+// inRegion returns true if the zone of the provider ID, like aws:///us-east-1a/i-0123456789abcdef0, is in the region.
+// The names of availability, local and wavelength zones start with the name of their region.
+func inRegion(providerID string, region string) bool {
+	zone := strings.SplitN(strings.TrimPrefix(providerID, "aws:///"), "/", 2)[0]
+	if !strings.HasPrefix(zone, region) || len(zone) == len(region) {
+		return false
+	}
+	// us-east-1 is not the region of a zone of us-east-10
+	next := zone[len(region)]
+	return next < '0' || next > '9'
+}
+
+func isReady(k8sNode corev1.Node) bool {
+	for _, condition := range k8sNode.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package nodegc_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/aws/aws-node-termination-handler/pkg/nodegc"
+	"github.com/aws/aws-node-termination-handler/pkg/observability"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/pkg/uptime"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubectl/pkg/drain"
+)
+
+func k8sNode(name string, providerID string, age time.Duration, ready v1.ConditionStatus) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(time.Now().Add(-age))},
+		Spec:       v1.NodeSpec{ProviderID: providerID},
+		Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: ready}}},
+	}
+}
+
+func instance(instanceID string, state types.InstanceStateName) types.Instance {
+	return types.Instance{InstanceId: aws.String(instanceID), State: &types.InstanceState{Name: state}}
+}
+
+func getCollector(t *testing.T, ec2Mock h.MockedEC2, nodes ...*v1.Node) (nodegc.Collector, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	for _, n := range nodes {
+		h.Ok(t, client.Tracker().Add(n))
+	}
+	tNode, err := node.NewWithValues(config.Config{}, &drain.Helper{Client: client}, uptime.Uptime)
+	h.Ok(t, err)
+	return nodegc.New(ec2Mock, "us-east-1", *tNode, observability.Metrics{}, observability.K8sEventRecorder{}), client
+}
+
+func remainingNodes(t *testing.T, client *fake.Clientset) []string {
+	nodes, err := client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	h.Ok(t, err)
+	names := []string{}
+	for _, n := range nodes.Items {
+		names = append(names, n.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCollect(t *testing.T) {
+	ec2Mock := h.MockedEC2{DescribeInstancesResp: ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: []types.Instance{
+		instance("i-running", types.InstanceStateNameRunning),
+		instance("i-terminated", types.InstanceStateNameTerminated),
+	}}}}}
+	collector, client := getCollector(t, ec2Mock,
+		k8sNode("running", "aws:///us-east-1a/i-running", time.Hour, v1.ConditionUnknown),
+		k8sNode("terminated", "aws:///us-east-1a/i-terminated", time.Hour, v1.ConditionUnknown),
+		k8sNode("unknown", "aws:///us-east-1a/i-unknown", time.Hour, v1.ConditionUnknown),
+		k8sNode("local-zone", "aws:///us-east-1-bos-1a/i-local-zone", time.Hour, v1.ConditionUnknown),
+		k8sNode("other-region", "aws:///us-west-2a/i-other-region", time.Hour, v1.ConditionUnknown),
+		k8sNode("ready", "aws:///us-east-1a/i-ready", time.Hour, v1.ConditionTrue),
+		k8sNode("new", "aws:///us-east-1a/i-new", time.Minute, v1.ConditionUnknown),
+		k8sNode("fargate", "aws:///us-east-1a/0123456789/fargate-ip-10-0-0-1.ec2.internal", time.Hour, v1.ConditionUnknown))
+
+	deleted, err := collector.Collect()
+	h.Ok(t, err)
+	sort.Strings(deleted)
+	h.Equals(t, []string{"local-zone", "terminated", "unknown"}, deleted)
+	h.Equals(t, []string{"fargate", "new", "other-region", "ready", "running"}, remainingNodes(t, client))
+}
+
+func TestCollectDescribeInstancesFailure(t *testing.T) {
+	ec2Mock := h.MockedEC2{DescribeInstancesErr: errors.New("throttled")}
+	collector, client := getCollector(t, ec2Mock, k8sNode("unknown", "aws:///us-east-1a/i-unknown", time.Hour, v1.ConditionUnknown))
+
+	_, err := collector.Collect()
+	h.Assert(t, err != nil, "Expected an error when the instances can't be described")
+	h.Equals(t, []string{"unknown"}, remainingNodes(t, client))
+}
@@ -0,0 +1,172 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package observability
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxMetricDataPerRequest is the number of datums a PutMetricData request is limited to
+	maxMetricDataPerRequest = 20
+
+	cloudWatchInterruptionEvents = "InterruptionEvents"
+	cloudWatchNodeActions        = "NodeActions"
+	cloudWatchErrorEvents        = "ErrorEvents"
+	cloudWatchDrainDeferrals     = "DrainDeferrals"
+	cloudWatchAWSThrottles       = "AWSThrottles"
+	cloudWatchBlockedEvictions   = "BlockedEvictions"
+	cloudWatchEvictionFailures   = "EvictionFailures"
+)
+
+// CloudWatchAPI is the part of the CloudWatch API the publisher uses
+type CloudWatchAPI interface {
+	PutMetricData(ctx context.Context, params *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error)
+}
+
+// CloudWatchPublisher sums up counters and publishes them periodically as Amazon CloudWatch custom metrics. Node names
+// are not used as dimensions, as every node would add custom metrics.
+type CloudWatchPublisher struct {
+	cloudWatch CloudWatchAPI
+	namespace  string
+	dimensions []types.Dimension
+	mutex      sync.Mutex
+	counts     map[string]*cloudWatchCount
+}
+
+type cloudWatchCount struct {
+	name       string
+	dimensions []types.Dimension
+	value      float64
+}
+
+// InitCloudWatchMetrics publishes the counters of the metrics to CloudWatch under the namespace, with the dimensions
+// Name=Value pairs added to every metric, and only if enabled
+func InitCloudWatchMetrics(metrics Metrics, enabled bool, cloudWatch CloudWatchAPI, namespace string, dimensionsStr string, interval time.Duration) (Metrics, error) {
+	if !enabled {
+		return metrics, nil
+	}
+	publisher, err := NewCloudWatchPublisher(cloudWatch, namespace, dimensionsStr)
+	if err != nil {
+		return metrics, err
+	}
+	go func() {
+		log.Info().Msgf("Starting to publish metrics to the CloudWatch namespace %s every %s", namespace, interval)
+		for range time.Tick(interval) {
+			publisher.Publish()
+		}
+	}()
+	metrics.cloudWatch = publisher
+	return metrics, nil
+}
+
+// NewCloudWatchPublisher creates a publisher for the namespace, with the dimensions Name=Value pairs added to every metric
+func NewCloudWatchPublisher(cloudWatch CloudWatchAPI, namespace string, dimensionsStr string) (*CloudWatchPublisher, error) {
+	publisher := &CloudWatchPublisher{
+		cloudWatch: cloudWatch,
+		namespace:  namespace,
+		counts:     map[string]*cloudWatchCount{},
+	}
+	if dimensionsStr == "" {
+		return publisher, nil
+	}
+	for _, part := range strings.Split(dimensionsStr, ",") {
+		nameValue := strings.SplitN(part, "=", 2)
+		if len(nameValue) != 2 || nameValue[0] == "" || nameValue[1] == "" {
+			return nil, fmt.Errorf("error parsing CloudWatch metric dimension %q, must be Name=Value", part)
+		}
+		publisher.dimensions = append(publisher.dimensions, types.Dimension{Name: aws.String(nameValue[0]), Value: aws.String(nameValue[1])})
+	}
+	return publisher, nil
+}
+
+// add increments the metric with the dimension name and value pairs, a nil publisher ignores it
+func (p *CloudWatchPublisher) add(name string, nameValues ...string) {
+	if p == nil {
+		return
+	}
+	dimensions := append([]types.Dimension{}, p.dimensions...)
+	key := name
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		dimensions = append(dimensions, types.Dimension{Name: aws.String(nameValues[i]), Value: aws.String(nameValues[i+1])})
+		key += "," + nameValues[i] + "=" + nameValues[i+1]
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	count, ok := p.counts[key]
+	if !ok {
+		count = &cloudWatchCount{name: name, dimensions: dimensions}
+		p.counts[key] = count
+	}
+	count.value++
+}
+
+// Publish sends the sums since the last publish to CloudWatch. Sums which fail to be sent are kept for the next publish.
+func (p *CloudWatchPublisher) Publish() {
+	p.mutex.Lock()
+	counts := p.counts
+	p.counts = map[string]*cloudWatchCount{}
+	p.mutex.Unlock()
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	now := time.Now()
+	for start := 0; start < len(keys); start += maxMetricDataPerRequest {
+		end := start + maxMetricDataPerRequest
+		if end > len(keys) {
+			end = len(keys)
+		}
+		var data []types.MetricDatum
+		for _, key := range keys[start:end] {
+			count := counts[key]
+			data = append(data, types.MetricDatum{
+				MetricName: aws.String(count.name),
+				Dimensions: count.dimensions,
+				Timestamp:  aws.Time(now),
+				Unit:       types.StandardUnitCount,
+				Value:      aws.Float64(count.value),
+			})
+		}
+		_, err := p.cloudWatch.PutMetricData(context.TODO(), &cloudwatch.PutMetricDataInput{Namespace: aws.String(p.namespace), MetricData: data})
+		if err != nil {
+			log.Warn().Err(err).Msg("Unable to publish metrics to CloudWatch, retrying with the next publish")
+			p.restore(keys[start:end], counts)
+		}
+	}
+}
+
+func (p *CloudWatchPublisher) restore(keys []string, counts map[string]*cloudWatchCount) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, key := range keys {
+		if count, ok := p.counts[key]; ok {
+			count.value += counts[key].value
+		} else {
+			p.counts[key] = counts[key]
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package observability
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+)
+
+func TestCloudWatchPublish(t *testing.T) {
+	var inputs []*cloudwatch.PutMetricDataInput
+	publisher, err := NewCloudWatchPublisher(h.MockedCloudWatch{PutMetricDataInputs: &inputs}, "NTH", "ClusterName=prod")
+	h.Ok(t, err)
+	metrics := Metrics{cloudWatch: publisher}
+	metrics.InterruptionEventsInc("SQS_TERMINATE")
+	metrics.NodeActionsInc("cordon-and-drain", "node-1", nil)
+	metrics.NodeActionsInc("cordon-and-drain", "node-2", nil)
+	metrics.NodeActionsInc("cordon-and-drain", "node-3", errors.New("eviction failed"))
+
+	publisher.Publish()
+	h.Equals(t, 1, len(inputs))
+	h.Equals(t, "NTH", *inputs[0].Namespace)
+	data := inputs[0].MetricData
+	h.Equals(t, 3, len(data))
+	h.Equals(t, "InterruptionEvents", *data[0].MetricName)
+	h.Equals(t, []types.Dimension{
+		{Name: aws.String("ClusterName"), Value: aws.String("prod")},
+		{Name: aws.String("EventKind"), Value: aws.String("SQS_TERMINATE")},
+	}, data[0].Dimensions)
+	h.Equals(t, "error", *data[1].Dimensions[2].Value)
+	h.Equals(t, 1.0, *data[1].Value)
+	h.Equals(t, "success", *data[2].Dimensions[2].Value)
+	h.Equals(t, 2.0, *data[2].Value)
+
+	publisher.Publish()
+	h.Equals(t, 1, len(inputs))
+}
+
+func TestCloudWatchPublishBatchesAndRetries(t *testing.T) {
+	var inputs []*cloudwatch.PutMetricDataInput
+	cloudWatch := h.MockedCloudWatch{PutMetricDataInputs: &inputs, PutMetricDataErr: errors.New("throttled")}
+	publisher, err := NewCloudWatchPublisher(cloudWatch, "NTH", "")
+	h.Ok(t, err)
+	for i := 0; i < 25; i++ {
+		publisher.add(cloudWatchErrorEvents, "Where", fmt.Sprintf("monitor-%02d", i))
+	}
+	publisher.Publish()
+	h.Equals(t, 2, len(inputs))
+	h.Equals(t, 20, len(inputs[0].MetricData))
+	h.Equals(t, 5, len(inputs[1].MetricData))
+
+	publisher.add(cloudWatchErrorEvents, "Where", "monitor-00")
+	publisher.cloudWatch = h.MockedCloudWatch{PutMetricDataInputs: &inputs}
+	publisher.Publish()
+	h.Equals(t, 4, len(inputs))
+	h.Equals(t, 2.0, *inputs[2].MetricData[0].Value)
+}
+
+func TestNewCloudWatchPublisherInvalidDimensions(t *testing.T) {
+	_, err := NewCloudWatchPublisher(h.MockedCloudWatch{}, "NTH", "ClusterName")
+	h.Assert(t, err != nil, "Failed to return error for a dimension without a value")
+}
+
+func TestMetricsWithoutCloudWatch(t *testing.T) {
+	// disabled metrics must not panic
+	Metrics{}.InterruptionEventsInc("SQS_TERMINATE")
+	Metrics{}.NodeActionsInc("cordon", "node", nil)
+}
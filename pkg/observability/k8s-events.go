@@ -18,10 +18,13 @@ import (
 	"strings"
 
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/pluginevent"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/rebalancerecommendation"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/scheduledevent"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/spotitn"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -32,30 +35,54 @@ import (
 
 // Kubernetes event types, reasons and messages
 const (
-	Normal                  = corev1.EventTypeNormal
-	Warning                 = corev1.EventTypeWarning
-	MonitorErrReason        = "MonitorError"
-	MonitorErrMsgFmt        = "There was a problem monitoring for events in monitor '%s'"
-	UncordonErrReason       = "UncordonError"
-	UncordonErrMsgFmt       = "There was a problem while trying to uncordon the node: %s"
-	UncordonReason          = "Uncordon"
-	UncordonMsg             = "Node successfully uncordoned"
-	PreDrainErrReason       = "PreDrainError"
-	PreDrainErrMsgFmt       = "There was a problem executing the pre-drain task: %s"
-	PreDrainReason          = "PreDrain"
-	PreDrainMsg             = "Pre-drain task successfully executed"
-	CordonErrReason         = "CordonError"
-	CordonErrMsgFmt         = "There was a problem while trying to cordon the node: %s"
-	CordonReason            = "Cordon"
-	CordonMsg               = "Node successfully cordoned"
-	CordonAndDrainErrReason = "CordonAndDrainError"
-	CordonAndDrainErrMsgFmt = "There was a problem while trying to cordon and drain the node: %s"
-	CordonAndDrainReason    = "CordonAndDrain"
-	CordonAndDrainMsg       = "Node successfully cordoned and drained"
-	PostDrainErrReason      = "PostDrainError"
-	PostDrainErrMsgFmt      = "There was a problem executing the post-drain task: %s"
-	PostDrainReason         = "PostDrain"
-	PostDrainMsg            = "Post-drain task successfully executed"
+	Normal                    = corev1.EventTypeNormal
+	Warning                   = corev1.EventTypeWarning
+	MonitorErrReason          = "MonitorError"
+	MonitorErrMsgFmt          = "There was a problem monitoring for events in monitor '%s'"
+	UncordonErrReason         = "UncordonError"
+	UncordonErrMsgFmt         = "There was a problem while trying to uncordon the node: %s"
+	UncordonReason            = "Uncordon"
+	UncordonMsg               = "Node successfully uncordoned"
+	PreDrainErrReason         = "PreDrainError"
+	PreDrainErrMsgFmt         = "There was a problem executing the pre-drain task: %s"
+	PreDrainReason            = "PreDrain"
+	PreDrainMsg               = "Pre-drain task successfully executed"
+	CordonErrReason           = "CordonError"
+	CordonErrMsgFmt           = "There was a problem while trying to cordon the node: %s"
+	CordonReason              = "Cordon"
+	CordonMsg                 = "Node successfully cordoned"
+	CordonAndDrainErrReason   = "CordonAndDrainError"
+	CordonAndDrainErrMsgFmt   = "There was a problem while trying to cordon and drain the node: %s"
+	CordonAndDrainReason      = "CordonAndDrain"
+	CordonAndDrainMsg         = "Node successfully cordoned and drained"
+	PostDrainErrReason        = "PostDrainError"
+	PostDrainErrMsgFmt        = "There was a problem executing the post-drain task: %s"
+	PostDrainReason           = "PostDrain"
+	PostDrainMsg              = "Post-drain task successfully executed"
+	MissingPermissionsReason  = "MissingPermissions"
+	MissingPermissionsMsgFmt  = "Node termination handler is missing kubernetes permissions required by its configuration: %s"
+	KarpenterDeleteErrReason  = "KarpenterDeleteError"
+	KarpenterDeleteErrMsgFmt  = "There was a problem while trying to delete the karpenter node: %s"
+	KarpenterDeleteReason     = "KarpenterDelete"
+	KarpenterDeleteMsg        = "Node deleted so karpenter launches replacement capacity"
+	AcceleratorEvictErrReason = "CordonAndEvictAcceleratorPodsError"
+	AcceleratorEvictErrMsgFmt = "There was a problem while trying to cordon the node and evict accelerator pods: %s"
+	AcceleratorEvictReason    = "CordonAndEvictAcceleratorPods"
+	AcceleratorEvictMsg       = "Node successfully cordoned and pods requesting accelerators evicted"
+	HookErrReason             = "HookError"
+	HookErrMsgFmt             = "There was a problem executing the %s hook: %s"
+	HookReason                = "Hook"
+	HookMsgFmt                = "The %s hook was successfully executed"
+	EvictionBlockedReason     = "EvictionBlocked"
+	EvictionBlockedMsgFmt     = "Evictions were blocked by the pod disruption budgets %s"
+	OrphanedNodeErrReason     = "OrphanedNodeDeleteError"
+	OrphanedNodeErrMsgFmt     = "There was a problem while trying to delete the node of the terminated instance %s: %s"
+	OrphanedNodeReason        = "OrphanedNodeDelete"
+	OrphanedNodeMsgFmt        = "Node deleted as its instance %s no longer exists"
+	RescheduleErrReason       = "RescheduleVerificationError"
+	RescheduleErrMsgFmt       = "Workloads of the drained pods did not have their desired ready replicas within %s: %s"
+	RescheduleReason          = "RescheduleVerified"
+	RescheduleMsg             = "Workloads of the drained pods have their desired ready replicas again"
 )
 
 // Interruption event reasons
@@ -64,6 +91,8 @@ const (
 	spotITNReason                 = "SpotInterruption"
 	sqsTerminateReason            = "SQSTermination"
 	rebalanceRecommendationReason = "RebalanceRecommendation"
+	pluginEventReason             = "PluginEvent"
+	simulatedEventReason          = "SimulatedEvent"
 	unknownReason                 = "UnknownInterruption"
 )
 
@@ -75,7 +104,7 @@ type K8sEventRecorder struct {
 }
 
 // InitK8sEventRecorder creates a Kubernetes event recorder
-func InitK8sEventRecorder(enabled bool, nodeName string, sqsMode bool, nodeMetadata ec2metadata.NodeMetadata, extraAnnotationsStr string) (K8sEventRecorder, error) {
+func InitK8sEventRecorder(enabled bool, nodeName string, sqsMode bool, nodeMetadata ec2metadata.NodeMetadata, extraAnnotationsStr string, clientConfig func() (*rest.Config, error)) (K8sEventRecorder, error) {
 	if !enabled {
 		return K8sEventRecorder{}, nil
 	}
@@ -102,7 +131,7 @@ func InitK8sEventRecorder(enabled bool, nodeName string, sqsMode bool, nodeMetad
 		}
 	}
 
-	config, err := rest.InClusterConfig()
+	config, err := clientConfig()
 	if err != nil {
 		return K8sEventRecorder{}, err
 	}
@@ -151,11 +180,23 @@ func GetReasonForKind(kind string) string {
 		return sqsTerminateReason
 	case rebalancerecommendation.RebalanceRecommendationKind:
 		return rebalanceRecommendationReason
+	case pluginevent.PluginEventKind:
+		return pluginEventReason
+	case status.SimulatedEventKind:
+		return simulatedEventReason
 	default:
 		return unknownReason
 	}
 }
 
+// GetNodeConditionTypeForEvent returns the node condition type published for the given interruption event
+func GetNodeConditionTypeForEvent(event *monitor.InterruptionEvent) string {
+	if event.IsRebalanceRecommendation() {
+		return rebalanceRecommendationReason
+	}
+	return GetReasonForKind(event.Kind)
+}
+
 // Parse the given extra annotations string into a map
 func parseExtraAnnotations(annotations map[string]string, extraAnnotationsStr string) (map[string]string, error) {
 	parts := strings.Split(extraAnnotationsStr, ",")
@@ -15,10 +15,10 @@ package observability
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/aws/aws-node-termination-handler/pkg/httpserver"
 	"github.com/rs/zerolog/log"
 	"go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel/attribute"
@@ -32,18 +32,35 @@ var (
 	labelNodeActionKey = attribute.Key("node/action")
 	labelNodeStatusKey = attribute.Key("node/status")
 	labelNodeNameKey   = attribute.Key("node/name")
+
+	labelDeferralDecisionKey = attribute.Key("deferral/decision")
+
+	labelEventKindKey = attribute.Key("event/kind")
+
+	labelAWSServiceKey = attribute.Key("aws/service")
+
+	labelPDBKey = attribute.Key("pdb")
+
+	labelEvictionFailureReasonKey = attribute.Key("eviction/failure/reason")
 )
 
 // Metrics represents the stats for observability
 type Metrics struct {
-	enabled            bool
-	meter              metric.Meter
-	actionsCounter     metric.Int64Counter
-	errorEventsCounter metric.Int64Counter
+	enabled                   bool
+	meter                     metric.Meter
+	actionsCounter            metric.Int64Counter
+	errorEventsCounter        metric.Int64Counter
+	deferralsCounter          metric.Int64Counter
+	interruptionEventsCounter metric.Int64Counter
+	awsThrottlesCounter       metric.Int64Counter
+	blockedEvictionsCounter   metric.Int64Counter
+	evictionFailuresCounter   metric.Int64Counter
+	cloudWatch                *CloudWatchPublisher
 }
 
 // InitMetrics will initialize, register and expose, via http server, the metrics with Opentelemetry.
-func InitMetrics(enabled bool, port int) (Metrics, error) {
+// The server listens as configured by the options, e.g. over HTTPS and requiring a bearer token.
+func InitMetrics(enabled bool, port int, options httpserver.Options) (Metrics, error) {
 	if !enabled {
 		return Metrics{}, nil
 	}
@@ -66,12 +83,14 @@ func InitMetrics(enabled bool, port int) (Metrics, error) {
 		return Metrics{}, err
 	}
 
-	// Starts HTTP server exposing the prometheus `/metrics` path
+	// Starts HTTP server exposing the prometheus `/metrics` path. The server has its own mux, so the metrics aren't
+	// served by the probes server, which uses the default mux.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", exporter.ServeHTTP)
+	server := &http.Server{Addr: options.Addr(port), Handler: options.Handler(mux)}
 	go func() {
-		log.Info().Msgf("Starting to serve handler /metrics, port %d", port)
-		http.HandleFunc("/metrics", exporter.ServeHTTP)
-		err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
-		if err != nil {
+		log.Info().Msgf("Starting to serve handler /metrics over %s, address %s", options.Scheme(), server.Addr)
+		if err := options.ListenAndServe(server); err != nil {
 			log.Err(err).Msg("Failed to listen and serve http server")
 		}
 	}()
@@ -81,6 +100,7 @@ func InitMetrics(enabled bool, port int) (Metrics, error) {
 
 // ErrorEventsInc will increment one for the event errors counter, partitioned by action, and only if metrics are enabled.
 func (m Metrics) ErrorEventsInc(where string) {
+	m.cloudWatch.add(cloudWatchErrorEvents, "Where", where)
 	if !m.enabled {
 		return
 	}
@@ -89,20 +109,66 @@ func (m Metrics) ErrorEventsInc(where string) {
 
 // NodeActionsInc will increment one for the node stats counter, partitioned by action, nodeName and status, and only if metrics are enabled.
 func (m Metrics) NodeActionsInc(action, nodeName string, err error) {
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	m.cloudWatch.add(cloudWatchNodeActions, "Action", action, "Status", status)
 	if !m.enabled {
 		return
 	}
 
-	labels := []attribute.KeyValue{labelNodeActionKey.String(action), labelNodeNameKey.String(nodeName)}
-	if err != nil {
-		labels = append(labels, labelNodeStatusKey.String("error"))
-	} else {
-		labels = append(labels, labelNodeStatusKey.String("success"))
-	}
+	labels := []attribute.KeyValue{labelNodeActionKey.String(action), labelNodeNameKey.String(nodeName), labelNodeStatusKey.String(status)}
 
 	m.actionsCounter.Add(context.Background(), 1, labels...)
 }
 
+// DrainDeferralsInc will increment one for the drain deferral decisions counter, partitioned by decision and nodeName, and only if metrics are enabled.
+func (m Metrics) DrainDeferralsInc(decision, nodeName string) {
+	m.cloudWatch.add(cloudWatchDrainDeferrals, "Decision", decision)
+	if !m.enabled {
+		return
+	}
+	m.deferralsCounter.Add(context.Background(), 1, labelDeferralDecisionKey.String(decision), labelNodeNameKey.String(nodeName))
+}
+
+// InterruptionEventsInc will increment one for the received interruption events counter, partitioned by kind, and only if metrics are enabled.
+func (m Metrics) InterruptionEventsInc(kind string) {
+	m.cloudWatch.add(cloudWatchInterruptionEvents, "EventKind", kind)
+	if !m.enabled {
+		return
+	}
+	m.interruptionEventsCounter.Add(context.Background(), 1, labelEventKindKey.String(kind))
+}
+
+// AWSThrottlesInc will increment one for the throttled AWS API calls counter, partitioned by service, and only if metrics are enabled.
+func (m Metrics) AWSThrottlesInc(service string) {
+	m.cloudWatch.add(cloudWatchAWSThrottles, "Service", service)
+	if !m.enabled {
+		return
+	}
+	m.awsThrottlesCounter.Add(context.Background(), 1, labelAWSServiceKey.String(service))
+}
+
+// BlockedEvictionsInc will increment one for the blocked evictions counter, partitioned by the namespace/name of the
+// blocking pod disruption budget and nodeName, and only if metrics are enabled.
+func (m Metrics) BlockedEvictionsInc(pdb, nodeName string) {
+	m.cloudWatch.add(cloudWatchBlockedEvictions, "PodDisruptionBudget", pdb)
+	if !m.enabled {
+		return
+	}
+	m.blockedEvictionsCounter.Add(context.Background(), 1, labelPDBKey.String(pdb), labelNodeNameKey.String(nodeName))
+}
+
+// EvictionFailuresInc will increment one for the failed eviction attempts counter, partitioned by reason and nodeName, and only if metrics are enabled.
+func (m Metrics) EvictionFailuresInc(reason, nodeName string) {
+	m.cloudWatch.add(cloudWatchEvictionFailures, "Reason", reason)
+	if !m.enabled {
+		return
+	}
+	m.evictionFailuresCounter.Add(context.Background(), 1, labelEvictionFailureReasonKey.String(reason), labelNodeNameKey.String(nodeName))
+}
+
 func registerMetricsWith(provider metric.MeterProvider) (Metrics, error) {
 	meter := provider.Meter("aws.node.termination.handler")
 
@@ -116,10 +182,40 @@ func registerMetricsWith(provider metric.MeterProvider) (Metrics, error) {
 		return Metrics{}, err
 	}
 
+	deferralsCounter, err := meter.NewInt64Counter("drain.deferrals", metric.WithDescription("Number of capacity-aware drain deferral decisions per node"))
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	interruptionEventsCounter, err := meter.NewInt64Counter("events.interruption", metric.WithDescription("Number of received interruption events"))
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	awsThrottlesCounter, err := meter.NewInt64Counter("aws.throttles", metric.WithDescription("Number of AWS API calls throttled per service"))
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	blockedEvictionsCounter, err := meter.NewInt64Counter("evictions.blocked", metric.WithDescription("Number of drains whose evictions were blocked per pod disruption budget and node"))
+	if err != nil {
+		return Metrics{}, err
+	}
+
+	evictionFailuresCounter, err := meter.NewInt64Counter("evictions.failed", metric.WithDescription("Number of failed eviction and pod deletion attempts per reason and node"))
+	if err != nil {
+		return Metrics{}, err
+	}
+
 	return Metrics{
-		enabled:            true,
-		meter:              meter,
-		errorEventsCounter: errorEventsCounter,
-		actionsCounter:     actionsCounter,
+		enabled:                   true,
+		interruptionEventsCounter: interruptionEventsCounter,
+		awsThrottlesCounter:       awsThrottlesCounter,
+		meter:                     meter,
+		errorEventsCounter:        errorEventsCounter,
+		actionsCounter:            actionsCounter,
+		deferralsCounter:          deferralsCounter,
+		blockedEvictionsCounter:   blockedEvictionsCounter,
+		evictionFailuresCounter:   evictionFailuresCounter,
 	}, nil
 }
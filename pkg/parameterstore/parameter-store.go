@@ -0,0 +1,77 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parameterstore
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/rs/zerolog/log"
+)
+
+// ParameterStore loads configuration values from AWS Systems Manager Parameter Store
+type ParameterStore struct {
+	SSM  ssm.GetParametersByPathAPIClient
+	Path string
+}
+
+// New constructs a ParameterStore which reads parameters under the given path
+func New(ssmClient ssm.GetParametersByPathAPIClient, parameterPath string) ParameterStore {
+	return ParameterStore{
+		SSM:  ssmClient,
+		Path: parameterPath,
+	}
+}
+
+// Load retrieves all parameters under the configured path (recursively, decrypting SecureStrings)
+// and returns them keyed by the last element of the parameter name
+func (p ParameterStore) Load() (map[string]string, error) {
+	parameters := make(map[string]string)
+	paginator := ssm.NewGetParametersByPathPaginator(p.SSM, &ssm.GetParametersByPathInput{
+		Path:           aws.String(p.Path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("Unable to retrieve parameters under path %s: %w", p.Path, err)
+		}
+		for _, parameter := range page.Parameters {
+			if parameter.Name == nil || parameter.Value == nil {
+				continue
+			}
+			parameters[path.Base(*parameter.Name)] = *parameter.Value
+		}
+	}
+	return parameters, nil
+}
+
+// Watch reloads the parameters on the given interval and sends them to the passed in channel
+func (p ParameterStore) Watch(interval time.Duration, parameterChan chan<- map[string]string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		parameters, err := p.Load()
+		if err != nil {
+			log.Warn().Err(err).Msg("There was a problem refreshing configuration from SSM Parameter Store")
+			continue
+		}
+		parameterChan <- parameters
+	}
+}
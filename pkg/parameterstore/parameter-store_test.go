@@ -0,0 +1,55 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parameterstore_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/pkg/parameterstore"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+func TestLoadSuccess(t *testing.T) {
+	ssmMock := h.MockedSSM{
+		GetParametersByPathResp: ssm.GetParametersByPathOutput{
+			Parameters: []types.Parameter{
+				{Name: aws.String("/nth/prod/WEBHOOK_URL"), Value: aws.String("https://example.com/hook")},
+				{Name: aws.String("/nth/prod/sqs/QUEUE_URL"), Value: aws.String("https://sqs.us-east-1.amazonaws.com/123456789012/queue")},
+				{Name: aws.String("/nth/prod/EMPTY")},
+			},
+		},
+	}
+	store := parameterstore.New(ssmMock, "/nth/prod")
+
+	parameters, err := store.Load()
+	h.Ok(t, err)
+	h.Equals(t, map[string]string{
+		"WEBHOOK_URL": "https://example.com/hook",
+		"QUEUE_URL":   "https://sqs.us-east-1.amazonaws.com/123456789012/queue",
+	}, parameters)
+}
+
+func TestLoadFailure(t *testing.T) {
+	ssmMock := h.MockedSSM{
+		GetParametersByPathErr: fmt.Errorf("AccessDeniedException"),
+	}
+	store := parameterstore.New(ssmMock, "/nth/prod")
+
+	_, err := store.Load()
+	h.Nok(t, err)
+}
@@ -0,0 +1,271 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pushreceiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// EventsPath is the path pushed events are accepted on
+	EventsPath = "/events"
+	// SNSPath is the path of the Amazon SNS HTTPS subscription endpoint
+	SNSPath      = "/sns"
+	maxEventSize = 256 * 1024
+	// clockSkew is tolerated when checking the expiry and not before times of JWTs
+	clockSkew = time.Minute
+)
+
+var errUnauthorized = errors.New("unauthorized")
+
+// EventProcessor returns the interruption events for a pushed Amazon EventBridge event, none if it does not need to be
+// handled
+type EventProcessor interface {
+	ProcessEvents(body []byte) ([]monitor.InterruptionEvent, error)
+}
+
+// Receiver accepts Amazon EventBridge events pushed by external systems, e.g. EventBridge API destinations, or delivered
+// by Amazon SNS, and sends their interruption events to the interruption channel
+type Receiver struct {
+	Processor        EventProcessor
+	InterruptionChan chan<- monitor.InterruptionEvent
+	// Secret authenticates pushed events, sent as a bearer token, used to sign an HS256 JWT bearer token, or sent as
+	// the basic authentication password
+	Secret string
+	// SNSTopicARNs are the Amazon SNS topics the SNS endpoint accepts messages from, it is not served if empty
+	SNSTopicARNs []string
+	// HTTPClient fetches SNS signing certificates and confirms SNS subscriptions, http.DefaultClient is used if nil
+	HTTPClient *http.Client
+}
+
+// Handler returns the handler of the receiver endpoints
+func (r Receiver) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(EventsPath, r.receive)
+	if len(r.SNSTopicARNs) > 0 {
+		subscriber := newSNSSubscriber(r.SNSTopicARNs, r.HTTPClient)
+		mux.HandleFunc(SNSPath, func(w http.ResponseWriter, req *http.Request) {
+			body, ok := r.read(w, req)
+			if !ok {
+				return
+			}
+			message, err := subscriber.verify(body)
+			if err != nil {
+				log.Warn().Err(err).Str("remote_addr", req.RemoteAddr).Msg("Rejecting SNS message")
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			switch message.Type {
+			case snsSubscriptionConfirmation:
+				if err := subscriber.confirm(message); err != nil {
+					log.Err(err).Str("topic_arn", message.TopicArn).Msg("Unable to confirm the SNS subscription")
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				log.Info().Str("topic_arn", message.TopicArn).Msg("Confirmed the SNS subscription")
+				w.WriteHeader(http.StatusOK)
+			case snsNotification:
+				r.handle(w, []byte(message.Message))
+			case snsUnsubscribeConfirmation:
+				log.Warn().Str("topic_arn", message.TopicArn).Msg("The SNS subscription was unsubscribed")
+				w.WriteHeader(http.StatusOK)
+			default:
+				w.WriteHeader(http.StatusOK)
+			}
+		})
+	}
+	return mux
+}
+
+// Serve starts accepting pushed events on the port, over HTTPS if a certificate and key are passed
+func (r Receiver) Serve(port int, certFile string, keyFile string) {
+	server := &http.Server{
+		Addr:         net.JoinHostPort("", strconv.Itoa(port)),
+		Handler:      r.Handler(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() {
+		var err error
+		if certFile != "" {
+			log.Info().Msgf("Starting to accept pushed events over HTTPS on port %d", port)
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			log.Warn().Msgf("Starting to accept pushed events over plain HTTP on port %d, terminate TLS in front of the handler", port)
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Err(err).Msg("Failed to listen and serve pushed events")
+		}
+	}()
+}
+
+func (r Receiver) receive(w http.ResponseWriter, req *http.Request) {
+	body, ok := r.read(w, req)
+	if ok {
+		r.handle(w, body)
+	}
+}
+
+// read authenticates the request and returns its body, ok is false if an error was written instead
+func (r Receiver) read(w http.ResponseWriter, req *http.Request) ([]byte, bool) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	if err := r.authenticate(req.Header.Get("Authorization"), time.Now()); err != nil {
+		log.Warn().Err(err).Str("remote_addr", req.RemoteAddr).Msg("Rejecting pushed event")
+		http.Error(w, errUnauthorized.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to read the event: %v", err), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return body, true
+}
+
+// handle sends the interruption events of the Amazon EventBridge event to the interruption channel
+func (r Receiver) handle(w http.ResponseWriter, body []byte) {
+	interruptionEvents, err := r.Processor.ProcessEvents(body)
+	interruptionEvents = sqsTerminateEvents(interruptionEvents)
+	switch {
+	case errors.Is(err, sqsevent.ErrUnsupportedEvent):
+		log.Warn().Err(err).Msg("Rejecting pushed event")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sqsevent.ErrNodeStateNotRunning):
+		log.Warn().Err(err).Msg("dropping pushed event for an already terminated node")
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, sqsevent.ErrClusterNotServed):
+		log.Debug().Err(err).Msg("dropping pushed event for an instance of a cluster which is not served")
+		w.WriteHeader(http.StatusOK)
+	case err != nil:
+		// the sender retries, e.g. when the EC2 API was unavailable to resolve the node
+		log.Err(err).Msg("Unable to process pushed event")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case len(interruptionEvents) == 0:
+		w.WriteHeader(http.StatusOK)
+	default:
+		for _, interruptionEvent := range interruptionEvents {
+			log.Debug().Str("event_id", interruptionEvent.EventID).Msg("Sending pushed interruption event to the interruption channel")
+			r.InterruptionChan <- interruptionEvent
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+// sqsTerminateEvents returns the events which drain their node
+func sqsTerminateEvents(interruptionEvents []monitor.InterruptionEvent) []monitor.InterruptionEvent {
+	var terminateEvents []monitor.InterruptionEvent
+	for _, interruptionEvent := range interruptionEvents {
+		if interruptionEvent.Kind == sqsevent.SQSTerminateKind {
+			terminateEvents = append(terminateEvents, interruptionEvent)
+		}
+	}
+	return terminateEvents
+}
+
+// authenticate checks the authorization header, which holds either a bearer token which is the secret or a JWT signed
+// with it, or basic authentication credentials with the secret as password, as sent by Amazon SNS
+func (r Receiver) authenticate(authorization string, now time.Time) error {
+	if strings.HasPrefix(authorization, "Basic ") {
+		credentials, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(authorization, "Basic "))
+		if err != nil {
+			return fmt.Errorf("invalid basic authentication credentials: %w", err)
+		}
+		password := string(credentials[strings.Index(string(credentials), ":")+1:])
+		if subtle.ConstantTimeCompare([]byte(password), []byte(r.Secret)) != 1 {
+			return fmt.Errorf("invalid basic authentication password")
+		}
+		return nil
+	}
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization || token == "" {
+		return fmt.Errorf("missing bearer token")
+	}
+	if strings.Count(token, ".") == 2 {
+		return verifyJWT(token, r.Secret, now)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(r.Secret)) != 1 {
+		return fmt.Errorf("invalid bearer token")
+	}
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// verifyJWT checks the HS256 signature and the expiry and not before times of the JWT
+func verifyJWT(token string, secret string, now time.Time) error {
+	parts := strings.Split(token, ".")
+	header := jwtHeader{}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return fmt.Errorf("invalid JWT header: %w", err)
+	}
+	if header.Alg != "HS256" {
+		return fmt.Errorf("unsupported JWT algorithm %s", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("invalid JWT signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return fmt.Errorf("invalid JWT signature")
+	}
+	claims := jwtClaims{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	if claims.ExpiresAt != nil && now.Add(-clockSkew).After(time.Unix(*claims.ExpiresAt, 0)) {
+		return fmt.Errorf("expired JWT")
+	}
+	if claims.NotBefore != nil && now.Add(clockSkew).Before(time.Unix(*claims.NotBefore, 0)) {
+		return fmt.Errorf("JWT is not valid yet")
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, value interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, value)
+}
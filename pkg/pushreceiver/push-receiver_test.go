@@ -0,0 +1,126 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pushreceiver_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	"github.com/aws/aws-node-termination-handler/pkg/pushreceiver"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+const secret = "s3cr3t"
+
+type fakeProcessor struct {
+	events []monitor.InterruptionEvent
+	err    error
+}
+
+func (p fakeProcessor) ProcessEvents(body []byte) ([]monitor.InterruptionEvent, error) {
+	return p.events, p.err
+}
+
+func push(t *testing.T, processor fakeProcessor, method string, authorization string) (int, chan monitor.InterruptionEvent) {
+	interruptionChan := make(chan monitor.InterruptionEvent, 2)
+	receiver := pushreceiver.Receiver{Processor: processor, InterruptionChan: interruptionChan, Secret: secret}
+	request := httptest.NewRequest(method, pushreceiver.EventsPath, strings.NewReader(`{"source": "aws.ec2"}`))
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	receiver.Handler().ServeHTTP(recorder, request)
+	return recorder.Code, interruptionChan
+}
+
+func jwt(t *testing.T, key string, claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	unsigned := encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(key))
+	_, err := mac.Write([]byte(unsigned))
+	h.Ok(t, err)
+	return "Bearer " + unsigned + "." + encode(mac.Sum(nil))
+}
+
+var spotEvent = monitor.InterruptionEvent{EventID: "spot-itn-event-1", Kind: sqsevent.SQSTerminateKind, NodeName: "node"}
+
+func TestPushSharedSecret(t *testing.T) {
+	code, interruptionChan := push(t, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent}}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusAccepted, code)
+	h.Equals(t, spotEvent.EventID, (<-interruptionChan).EventID)
+}
+
+func TestPushJWT(t *testing.T) {
+	exp := time.Now().Add(5 * time.Minute).Unix()
+	code, interruptionChan := push(t, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent}}, http.MethodPost, jwt(t, secret, fmt.Sprintf(`{"exp":%d}`, exp)))
+	h.Equals(t, http.StatusAccepted, code)
+	h.Equals(t, spotEvent.EventID, (<-interruptionChan).EventID)
+}
+
+func TestPushUnauthorized(t *testing.T) {
+	expired := time.Now().Add(-time.Hour).Unix()
+	for _, authorization := range []string{
+		"",
+		secret,
+		"Bearer wrong",
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("nth:wrong")),
+		jwt(t, "wrong", `{}`),
+		jwt(t, secret, fmt.Sprintf(`{"exp":%d}`, expired)),
+	} {
+		code, interruptionChan := push(t, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent}}, http.MethodPost, authorization)
+		h.Equals(t, http.StatusUnauthorized, code)
+		h.Equals(t, 0, len(interruptionChan))
+	}
+}
+
+func TestPushEventOfSeveralInstances(t *testing.T) {
+	otherEvent := monitor.InterruptionEvent{EventID: "health-event-1-i-2", Kind: sqsevent.SQSTerminateKind, NodeName: "other-node"}
+	code, interruptionChan := push(t, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent, otherEvent}}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusAccepted, code)
+	h.Equals(t, spotEvent.EventID, (<-interruptionChan).EventID)
+	h.Equals(t, otherEvent.EventID, (<-interruptionChan).EventID)
+}
+
+func TestPushMethodNotAllowed(t *testing.T) {
+	code, _ := push(t, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent}}, http.MethodGet, "Bearer "+secret)
+	h.Equals(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestPushProcessingErrors(t *testing.T) {
+	code, _ := push(t, fakeProcessor{err: fmt.Errorf("%w: Event source (aws.unknown) is not supported", sqsevent.ErrUnsupportedEvent)}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusBadRequest, code)
+
+	code, _ = push(t, fakeProcessor{err: sqsevent.ErrNodeStateNotRunning}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusOK, code)
+
+	code, _ = push(t, fakeProcessor{err: sqsevent.ErrClusterNotServed}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusOK, code)
+
+	code, _ = push(t, fakeProcessor{err: fmt.Errorf("RequestLimitExceeded")}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusInternalServerError, code)
+
+	// e.g. a launch lifecycle action, which is completed without handling the node
+	code, interruptionChan := push(t, fakeProcessor{}, http.MethodPost, "Bearer "+secret)
+	h.Equals(t, http.StatusOK, code)
+	h.Equals(t, 0, len(interruptionChan))
+}
@@ -0,0 +1,208 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pushreceiver
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	snsNotification             = "Notification"
+	snsSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+	maxCertificateSize          = 64 * 1024
+)
+
+// snsHostPattern matches the hosts of the Amazon SNS endpoints signing certificates and subscription confirmations are served from
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SNSMessage is a message delivered by Amazon SNS to an HTTPS subscription
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token,omitempty"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject,omitempty"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL,omitempty"`
+}
+
+// snsSubscriber verifies the messages of an Amazon SNS HTTPS subscription and confirms subscriptions
+type snsSubscriber struct {
+	topicARNs    map[string]struct{}
+	client       *http.Client
+	mutex        sync.Mutex
+	certificates map[string]*x509.Certificate
+}
+
+func newSNSSubscriber(topicARNs []string, client *http.Client) *snsSubscriber {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	subscriber := &snsSubscriber{
+		topicARNs:    map[string]struct{}{},
+		client:       client,
+		certificates: map[string]*x509.Certificate{},
+	}
+	for _, topicARN := range topicARNs {
+		subscriber.topicARNs[topicARN] = struct{}{}
+	}
+	return subscriber
+}
+
+// verify returns the message if it is from an accepted topic and its signature is valid. Signature version 1 messages are
+// signed with SHA1withRSA, version 2 messages with SHA256withRSA.
+func (s *snsSubscriber) verify(body []byte) (SNSMessage, error) {
+	message := SNSMessage{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return message, fmt.Errorf("invalid SNS message: %w", err)
+	}
+	if _, ok := s.topicARNs[message.TopicArn]; !ok {
+		return message, fmt.Errorf("SNS topic %s is not accepted", message.TopicArn)
+	}
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return message, fmt.Errorf("unsupported SNS signature version %s", message.SignatureVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return message, fmt.Errorf("invalid SNS signature: %w", err)
+	}
+	certificate, err := s.certificate(message.SigningCertURL)
+	if err != nil {
+		return message, err
+	}
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return message, fmt.Errorf("the SNS signing certificate does not hold an RSA public key")
+	}
+	if err := rsa.VerifyPKCS1v15(publicKey, hash, digest(hash, signingString(message)), signature); err != nil {
+		return message, fmt.Errorf("invalid SNS signature: %w", err)
+	}
+	return message, nil
+}
+
+// confirm confirms the subscription by visiting the subscribe URL of the subscription confirmation
+func (s *snsSubscriber) confirm(message SNSMessage) error {
+	if err := validateSNSURL(message.SubscribeURL); err != nil {
+		return err
+	}
+	resp, err := s.client.Get(message.SubscribeURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("confirming the SNS subscription returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// certificate returns the signing certificate, which is cached as SNS signs all messages with few certificates
+func (s *snsSubscriber) certificate(certURL string) (*x509.Certificate, error) {
+	if err := validateSNSURL(certURL); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if certificate, ok := s.certificates[certURL]; ok {
+		return certificate, nil
+	}
+	resp, err := s.client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch the SNS signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching the SNS signing certificate returned status %d", resp.StatusCode)
+	}
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCertificateSize))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the SNS signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("the SNS signing certificate is not PEM encoded")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the SNS signing certificate: %w", err)
+	}
+	s.certificates[certURL] = certificate
+	return certificate, nil
+}
+
+// validateSNSURL checks that the URL is served over HTTPS by Amazon SNS
+func validateSNSURL(rawURL string) error {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid SNS URL %s: %w", rawURL, err)
+	}
+	if parsedURL.Scheme != "https" || !snsHostPattern.MatchString(parsedURL.Hostname()) {
+		return fmt.Errorf("the URL %s is not an Amazon SNS HTTPS URL", rawURL)
+	}
+	return nil
+}
+
+// signingString returns the string SNS signs for the message type
+func signingString(message SNSMessage) string {
+	fields := [][2]string{{"Message", message.Message}, {"MessageId", message.MessageID}}
+	if message.Type == snsNotification {
+		if message.Subject != "" {
+			fields = append(fields, [2]string{"Subject", message.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", message.Timestamp})
+	} else {
+		fields = append(fields, [2]string{"SubscribeURL", message.SubscribeURL}, [2]string{"Timestamp", message.Timestamp}, [2]string{"Token", message.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", message.TopicArn}, [2]string{"Type", message.Type})
+	var builder strings.Builder
+	for _, field := range fields {
+		builder.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return builder.String()
+}
+
+func digest(hash crypto.Hash, content string) []byte {
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(content))
+		return sum[:]
+	}
+	sum := sha256.Sum256([]byte(content))
+	return sum[:]
+}
@@ -0,0 +1,184 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package pushreceiver_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/pushreceiver"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+const (
+	topicARN     = "arn:aws:sns:us-east-1:123456789012:nth-events"
+	certURL      = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-0000.pem"
+	subscribeURL = "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&TopicArn=" + topicARN + "&Token=token"
+)
+
+// fakeSNS serves the signing certificate and records the visited URLs
+type fakeSNS struct {
+	certificate []byte
+	visited     []string
+}
+
+func (f *fakeSNS) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.visited = append(f.visited, req.URL.String())
+	body := []byte("<ConfirmSubscriptionResponse/>")
+	if req.URL.String() == certURL {
+		body = f.certificate
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader(body)), Header: http.Header{}}, nil
+}
+
+func signingKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	h.Ok(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	h.Ok(t, err)
+	return key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, message pushreceiver.SNSMessage) pushreceiver.SNSMessage {
+	content := "Message\n" + message.Message + "\nMessageId\n" + message.MessageID + "\n"
+	if message.Type == "Notification" {
+		content += "Timestamp\n" + message.Timestamp + "\n"
+	} else {
+		content += "SubscribeURL\n" + message.SubscribeURL + "\nTimestamp\n" + message.Timestamp + "\nToken\n" + message.Token + "\n"
+	}
+	content += "TopicArn\n" + message.TopicArn + "\nType\n" + message.Type + "\n"
+	var signature []byte
+	var err error
+	if message.SignatureVersion == "1" {
+		sum := sha1.Sum([]byte(content))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA1, sum[:])
+	} else {
+		sum := sha256.Sum256([]byte(content))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	}
+	h.Ok(t, err)
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+	message.SigningCertURL = certURL
+	return message
+}
+
+func deliver(t *testing.T, receiver pushreceiver.Receiver, message pushreceiver.SNSMessage) int {
+	body, err := json.Marshal(message)
+	h.Ok(t, err)
+	request := httptest.NewRequest(http.MethodPost, pushreceiver.SNSPath, bytes.NewReader(body))
+	request.SetBasicAuth("nth", secret)
+	recorder := httptest.NewRecorder()
+	receiver.Handler().ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func snsReceiver(sns *fakeSNS, processor fakeProcessor, interruptionChan chan monitor.InterruptionEvent) pushreceiver.Receiver {
+	return pushreceiver.Receiver{
+		Processor:        processor,
+		InterruptionChan: interruptionChan,
+		Secret:           secret,
+		SNSTopicARNs:     []string{topicARN},
+		HTTPClient:       &http.Client{Transport: sns},
+	}
+}
+
+func TestSNSSubscriptionConfirmation(t *testing.T) {
+	key, certificate := signingKey(t)
+	sns := &fakeSNS{certificate: certificate}
+	receiver := snsReceiver(sns, fakeProcessor{}, nil)
+	message := sign(t, key, pushreceiver.SNSMessage{
+		Type:             "SubscriptionConfirmation",
+		MessageID:        "165545c9-2a5c-472c-8df2-7ff2be2b3b1b",
+		Token:            "token",
+		TopicArn:         topicARN,
+		Message:          "You have chosen to subscribe to the topic",
+		SubscribeURL:     subscribeURL,
+		Timestamp:        "2021-06-05T08:00:00.000Z",
+		SignatureVersion: "1",
+	})
+	h.Equals(t, http.StatusOK, deliver(t, receiver, message))
+	h.Equals(t, []string{certURL, subscribeURL}, sns.visited)
+}
+
+func TestSNSNotification(t *testing.T) {
+	key, certificate := signingKey(t)
+	interruptionChan := make(chan monitor.InterruptionEvent, 1)
+	receiver := snsReceiver(&fakeSNS{certificate: certificate}, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent}}, interruptionChan)
+	message := sign(t, key, pushreceiver.SNSMessage{
+		Type:             "Notification",
+		MessageID:        "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:         topicARN,
+		Message:          `{"source": "aws.ec2"}`,
+		Timestamp:        "2021-06-05T08:00:00.000Z",
+		SignatureVersion: "2",
+	})
+	h.Equals(t, http.StatusAccepted, deliver(t, receiver, message))
+	h.Equals(t, spotEvent.EventID, (<-interruptionChan).EventID)
+}
+
+func TestSNSRejected(t *testing.T) {
+	key, certificate := signingKey(t)
+	otherKey, _ := signingKey(t)
+	notification := pushreceiver.SNSMessage{
+		Type:             "Notification",
+		MessageID:        "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:         topicARN,
+		Message:          `{"source": "aws.ec2"}`,
+		Timestamp:        "2021-06-05T08:00:00.000Z",
+		SignatureVersion: "2",
+	}
+	otherTopic := notification
+	otherTopic.TopicArn = "arn:aws:sns:us-east-1:210987654321:other"
+	foreignCert := sign(t, key, notification)
+	foreignCert.SigningCertURL = "https://example.com/cert.pem"
+
+	for name, message := range map[string]pushreceiver.SNSMessage{
+		"invalid signature":     sign(t, otherKey, notification),
+		"unaccepted topic":      sign(t, key, otherTopic),
+		"foreign certificate":   foreignCert,
+		"tampered notification": func() pushreceiver.SNSMessage { m := sign(t, key, notification); m.Message = "{}"; return m }(),
+	} {
+		interruptionChan := make(chan monitor.InterruptionEvent, 1)
+		receiver := snsReceiver(&fakeSNS{certificate: certificate}, fakeProcessor{events: []monitor.InterruptionEvent{spotEvent}}, interruptionChan)
+		h.Assert(t, deliver(t, receiver, message) == http.StatusBadRequest, "Expected the message to be rejected: "+name)
+		h.Equals(t, 0, len(interruptionChan))
+	}
+}
+
+func TestSNSDisabled(t *testing.T) {
+	receiver := pushreceiver.Receiver{Processor: fakeProcessor{}, Secret: secret}
+	h.Equals(t, http.StatusNotFound, deliver(t, receiver, pushreceiver.SNSMessage{}))
+}
@@ -0,0 +1,140 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replay
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+)
+
+// Kind is the monitor kind of the replay
+const Kind = "REPLAY"
+
+// Monitor sends the events of the records to the interruption channel, paced like they were recorded
+type Monitor struct {
+	InterruptionChan chan<- monitor.InterruptionEvent
+	// Speed divides the recorded time between the events, the events are sent at once if 0
+	Speed   float64
+	records []status.Record
+	mutex   sync.Mutex
+	next    int
+	started time.Time
+	now     func() time.Time
+}
+
+// NewMonitor creates a monitor replaying the records, which are expected in the order their handling started
+func NewMonitor(records []status.Record, interruptionChan chan<- monitor.InterruptionEvent, speed float64) *Monitor {
+	return &Monitor{InterruptionChan: interruptionChan, Speed: speed, records: records, now: time.Now}
+}
+
+// Monitor sends the events which are due
+func (m *Monitor) Monitor() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := m.now()
+	if m.started.IsZero() {
+		m.started = now
+	}
+	for ; m.next < len(m.records); m.next++ {
+		record := m.records[m.next]
+		if m.Speed > 0 {
+			offset := record.StartedAt.Sub(m.records[0].StartedAt)
+			if now.Sub(m.started) < time.Duration(float64(offset)/m.Speed) {
+				break
+			}
+		}
+		m.InterruptionChan <- Event(record, now)
+	}
+	return nil
+}
+
+// Kind denotes the kind of monitor
+func (m *Monitor) Kind() string {
+	return Kind
+}
+
+// Finished returns true once all events were sent and the history holds a completed record for each of them. Events
+// which failed are not waited for until their retry succeeds.
+func (m *Monitor) Finished(history []status.Record) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.next < len(m.records) {
+		return false
+	}
+	completed := map[string]struct{}{}
+	for _, record := range history {
+		if !record.CompletedAt.IsZero() {
+			completed[record.EventID] = struct{}{}
+		}
+	}
+	for _, record := range m.records {
+		if _, ok := completed[record.EventID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Report writes the recorded and the replayed outcome of each event, so changes of the decisions stand out
+func (m *Monitor) Report(out io.Writer, history []status.Record) error {
+	replayed := map[string]status.Record{}
+	for _, record := range history {
+		replayed[record.EventID] = record
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT\tKIND\tNODE\tRECORDED\tREPLAYED\tCHANGED\tERROR")
+	changes := 0
+	for _, record := range m.records {
+		result, ok := replayed[record.EventID]
+		recordedOutcome, replayedOutcome := outcome(record), "-"
+		if ok {
+			replayedOutcome = outcome(result)
+		}
+		changed := recordedOutcome != replayedOutcome
+		if changed {
+			changes++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n", record.EventID, record.Kind, record.NodeName, recordedOutcome, replayedOutcome, changed, result.Error)
+	}
+	fmt.Fprintf(w, "\n%d event(s) replayed, %d with a changed outcome\n", len(m.records), changes)
+	return w.Flush()
+}
+
+// Event returns the interruption event of the record, which is due at now
+func Event(record status.Record, now time.Time) monitor.InterruptionEvent {
+	return monitor.InterruptionEvent{
+		EventID:     record.EventID,
+		Kind:        record.Kind,
+		Code:        record.Code,
+		Description: fmt.Sprintf("Replay of the %s event %s handled at %s", record.Kind, record.EventID, record.StartedAt.Format(time.RFC3339)),
+		NodeName:    record.NodeName,
+		Cluster:     record.Cluster,
+		InstanceID:  record.InstanceID,
+		StartTime:   now,
+	}
+}
+
+// outcome is the action taken for the event and the phase handling it ended in, e.g. cordon-and-drain/succeeded
+func outcome(record status.Record) string {
+	if record.Action == "" {
+		return record.Phase
+	}
+	return record.Action + "/" + record.Phase
+}
@@ -0,0 +1,255 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+)
+
+const (
+	// Command is the subcommand of the handler which replays events
+	Command = "replay"
+	// Usage describes the replay subcommand
+	Usage = `Usage: node-termination-handler replay --audit-log <file> [flags] [-- handler flags]
+
+Replays the events recorded in the audit log through the handler. The handler is configured as usual, from the
+environment and the handler flags after --, and only acts on the nodes with --live.
+
+Flags:
+`
+)
+
+// Options select the recorded events to replay and how
+type Options struct {
+	// AuditLog is the file holding the records, "-" reads them from stdin
+	AuditLog string
+	// Live handles the events for real, they are handled in dry-run mode otherwise
+	Live bool
+	// Speed replays the events with the recorded time between them divided by speed, or as fast as possible if 0
+	Speed  float64
+	Filter Filter
+}
+
+// Filter selects the records of the events to replay, empty fields match all records
+type Filter struct {
+	EventIDs []string
+	Nodes    []string
+	Kinds    []string
+	Since    time.Time
+	Until    time.Time
+}
+
+// ParseArgs parses the flags of the replay subcommand, returning the handler flags which follow them
+func ParseArgs(args []string) (Options, []string, error) {
+	options := Options{}
+	var eventIDs, nodes, kinds, since, until string
+	flags := flag.NewFlagSet(Command, flag.ContinueOnError)
+	flags.StringVar(&options.AuditLog, "audit-log", "", "The file holding the records of the events as newline delimited JSON, e.g. an S3 export object, or - to read stdin.")
+	flags.BoolVar(&options.Live, "live", false, "If true, cordon and drain the nodes of the events. Otherwise the events are handled in dry-run mode.")
+	flags.Float64Var(&options.Speed, "speed", 0, "Replays the events with the recorded time between them divided by speed, e.g. 10 for ten times faster. With 0, the events are replayed as fast as possible.")
+	flags.StringVar(&eventIDs, "event-id", "", "Comma separated IDs of the events to replay.")
+	flags.StringVar(&nodes, "node", "", "Comma separated names of the nodes to replay the events of.")
+	flags.StringVar(&kinds, "kind", "", "Comma separated kinds of the events to replay, e.g. SPOT_ITN.")
+	flags.StringVar(&since, "since", "", "Replays the events handled at or after the RFC 3339 time.")
+	flags.StringVar(&until, "until", "", "Replays the events handled before the RFC 3339 time.")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), Usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return Options{}, nil, err
+	}
+	if options.AuditLog == "" {
+		return Options{}, nil, fmt.Errorf("--audit-log is required")
+	}
+	if options.Speed < 0 {
+		return Options{}, nil, fmt.Errorf("--speed must not be negative")
+	}
+	options.Filter = Filter{EventIDs: split(eventIDs), Nodes: split(nodes), Kinds: split(kinds)}
+	var err error
+	if options.Filter.Since, err = parseTime("since", since); err != nil {
+		return Options{}, nil, err
+	}
+	if options.Filter.Until, err = parseTime("until", until); err != nil {
+		return Options{}, nil, err
+	}
+	return options, flags.Args(), nil
+}
+
+// Configure adapts the handler configuration to replaying events. Events which are not replayed live only pass
+// through the handler's decisions, so webhooks, hooks, Kubernetes events and audit records are disabled as well.
+func (o Options) Configure(nthConfig *config.Config) {
+	// the replayed events are the only events handled
+	nthConfig.EnablePushReceiver = false
+	nthConfig.KafkaBrokers = ""
+	nthConfig.NATSURL = ""
+	nthConfig.MonitorPluginCommand = ""
+	nthConfig.EnableWorkerAutoscaling = false
+	// a replay is short-lived and may run next to the handler
+	nthConfig.EnablePrometheus = false
+	nthConfig.EnableProbes = false
+	nthConfig.EnableStatusAPI = false
+	// the replayed events are not handled again after a restart, nor after a failed drain
+	nthConfig.EventJournalFile = ""
+	nthConfig.DrainMaxAttempts = 1
+	if o.Live {
+		return
+	}
+	nthConfig.DryRun = true
+	nthConfig.WebhookURL = ""
+	nthConfig.PreDrainHook = ""
+	nthConfig.PostDrainHook = ""
+	nthConfig.PostUncordonHook = ""
+	nthConfig.PreDrainLambdaHook = ""
+	nthConfig.PostDrainLambdaHook = ""
+	nthConfig.PreDrainSSMDocument = ""
+	nthConfig.EmitKubernetesEvents = false
+	nthConfig.EnableTerminationEventResources = false
+	nthConfig.EnableCloudWatchMetrics = false
+	nthConfig.CloudWatchLogsGroup = ""
+	nthConfig.S3ExportBucket = ""
+	nthConfig.EnableEventBridgeEvents = false
+	nthConfig.DetachFromASG = false
+	nthConfig.WaitForRebalanceReplacement = false
+	nthConfig.RequireCapacityRebalance = false
+}
+
+// Load reads the records of the audit log selected by the filter, ordered by the time their handling started
+func (o Options) Load() ([]status.Record, error) {
+	reader := io.Reader(os.Stdin)
+	if o.AuditLog != "-" {
+		file, err := os.Open(o.AuditLog)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open the audit log: %w", err)
+		}
+		defer file.Close()
+		reader = file
+	}
+	records, err := ReadRecords(reader)
+	if err != nil {
+		return nil, err
+	}
+	return o.Filter.Select(records), nil
+}
+
+// ReadRecords reads records written as newline delimited JSON by the audit sinks. JSON arrays of records and the
+// events of the status API are read as well.
+func ReadRecords(reader io.Reader) ([]status.Record, error) {
+	var records []status.Record
+	decoder := json.NewDecoder(reader)
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the record after %d record(s): %w", len(records), err)
+		}
+		value = bytes.TrimSpace(value)
+		switch {
+		case len(value) > 0 && value[0] == '[':
+			var batch []status.Record
+			if err := json.Unmarshal(value, &batch); err != nil {
+				return nil, fmt.Errorf("Unable to read the records after %d record(s): %w", len(records), err)
+			}
+			records = append(records, batch...)
+		case bytes.Contains(value, []byte(`"history"`)):
+			events := status.Events{}
+			if err := json.Unmarshal(value, &events); err != nil {
+				return nil, fmt.Errorf("Unable to read the status API events: %w", err)
+			}
+			records = append(records, events.History...)
+		default:
+			record := status.Record{}
+			if err := json.Unmarshal(value, &record); err != nil {
+				return nil, fmt.Errorf("Unable to read the record after %d record(s): %w", len(records), err)
+			}
+			records = append(records, record)
+		}
+	}
+}
+
+// Select returns the records matched by the filter, ordered by the time their handling started. A record is only
+// returned once per event, an event handled several times is replayed from its first record.
+func (f Filter) Select(records []status.Record) []status.Record {
+	seen := map[string]struct{}{}
+	var selected []status.Record
+	sorted := append([]status.Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartedAt.Before(sorted[j].StartedAt) })
+	for _, record := range sorted {
+		if _, ok := seen[record.EventID]; ok || record.EventID == "" || record.NodeName == "" || !f.Match(record) {
+			continue
+		}
+		seen[record.EventID] = struct{}{}
+		selected = append(selected, record)
+	}
+	return selected
+}
+
+// Match returns true if the filter selects the record
+func (f Filter) Match(record status.Record) bool {
+	if !f.Since.IsZero() && record.StartedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !record.StartedAt.Before(f.Until) {
+		return false
+	}
+	return matchAny(f.EventIDs, record.EventID) && matchAny(f.Nodes, record.NodeName) && matchAny(f.Kinds, record.Kind)
+}
+
+func matchAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func split(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func parseTime(name string, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("--%s must be an RFC 3339 time: %w", name, err)
+	}
+	return t, nil
+}
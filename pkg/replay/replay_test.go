@@ -0,0 +1,195 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replay_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/replay"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+var startedAt = time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+func TestParseArgs(t *testing.T) {
+	options, handlerArgs, err := replay.ParseArgs([]string{
+		"--audit-log", "events.json", "--live", "--speed", "10", "--event-id", "a, b", "--kind", "SPOT_ITN",
+		"--since", "2021-06-01T00:00:00Z", "--", "--node-name", "node-1",
+	})
+	h.Ok(t, err)
+	h.Equals(t, "events.json", options.AuditLog)
+	h.Equals(t, true, options.Live)
+	h.Equals(t, 10.0, options.Speed)
+	h.Equals(t, []string{"a", "b"}, options.Filter.EventIDs)
+	h.Equals(t, []string{"SPOT_ITN"}, options.Filter.Kinds)
+	h.Equals(t, []string(nil), options.Filter.Nodes)
+	h.Equals(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), options.Filter.Since)
+	h.Equals(t, true, options.Filter.Until.IsZero())
+	h.Equals(t, []string{"--node-name", "node-1"}, handlerArgs)
+}
+
+func TestParseArgsInvalid(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"--audit-log", "events.json", "--speed", "-1"},
+		{"--audit-log", "events.json", "--since", "yesterday"},
+		{"--audit-log", "events.json", "--unknown"},
+	} {
+		_, _, err := replay.ParseArgs(args)
+		h.Assert(t, err != nil, "Expected an error for %v", args)
+	}
+}
+
+func TestConfigureDryRun(t *testing.T) {
+	nthConfig := config.Config{
+		WebhookURL:           "https://example.com/hook",
+		PreDrainHook:         "/hooks/pre-drain",
+		EmitKubernetesEvents: true,
+		S3ExportBucket:       "bucket",
+		EnableStatusAPI:      true,
+		KafkaBrokers:         "broker:9092",
+		DetachFromASG:        true,
+	}
+	replay.Options{}.Configure(&nthConfig)
+	h.Equals(t, true, nthConfig.DryRun)
+	h.Equals(t, "", nthConfig.WebhookURL)
+	h.Equals(t, "", nthConfig.PreDrainHook)
+	h.Equals(t, false, nthConfig.EmitKubernetesEvents)
+	h.Equals(t, "", nthConfig.S3ExportBucket)
+	h.Equals(t, false, nthConfig.EnableStatusAPI)
+	h.Equals(t, "", nthConfig.KafkaBrokers)
+	h.Equals(t, false, nthConfig.DetachFromASG)
+}
+
+func TestConfigureLive(t *testing.T) {
+	nthConfig := config.Config{WebhookURL: "https://example.com/hook", EnableStatusAPI: true, NATSURL: "nats://nats:4222"}
+	replay.Options{Live: true}.Configure(&nthConfig)
+	h.Equals(t, false, nthConfig.DryRun)
+	h.Equals(t, "https://example.com/hook", nthConfig.WebhookURL)
+	h.Equals(t, false, nthConfig.EnableStatusAPI)
+	h.Equals(t, "", nthConfig.NATSURL)
+}
+
+func TestReadRecords(t *testing.T) {
+	input := `{"eventId":"a","kind":"SPOT_ITN","nodeName":"node-1","phase":"succeeded","startedAt":"2021-06-01T10:00:00Z"}
+
+{"eventId":"b","kind":"SCHEDULED_EVENT","nodeName":"node-2","phase":"failed","startedAt":"2021-06-01T10:01:00Z"}
+[{"eventId":"c","kind":"REBALANCE_RECOMMENDATION","nodeName":"node-3","phase":"succeeded","startedAt":"2021-06-01T10:02:00Z"}]
+{"active":[],"history":[{"eventId":"d","kind":"SPOT_ITN","nodeName":"node-4","phase":"succeeded","startedAt":"2021-06-01T10:03:00Z"}]}
+`
+	records, err := replay.ReadRecords(strings.NewReader(input))
+	h.Ok(t, err)
+	h.Equals(t, 4, len(records))
+	for i, eventID := range []string{"a", "b", "c", "d"} {
+		h.Equals(t, eventID, records[i].EventID)
+	}
+	h.Equals(t, "node-2", records[1].NodeName)
+	h.Equals(t, startedAt.Add(time.Minute), records[1].StartedAt)
+}
+
+func TestReadRecordsMalformed(t *testing.T) {
+	_, err := replay.ReadRecords(strings.NewReader(`{"eventId":"a","nodeName":"node-1"}` + "\n" + `{"eventId":`))
+	h.Nok(t, err)
+	_, err = replay.ReadRecords(strings.NewReader(`{"eventId":1}`))
+	h.Nok(t, err)
+}
+
+func TestFilterSelect(t *testing.T) {
+	records := []status.Record{
+		{EventID: "c", Kind: "SPOT_ITN", NodeName: "node-1", StartedAt: startedAt.Add(2 * time.Minute)},
+		{EventID: "a", Kind: "SPOT_ITN", NodeName: "node-1", StartedAt: startedAt},
+		{EventID: "b", Kind: "SCHEDULED_EVENT", NodeName: "node-2", StartedAt: startedAt.Add(time.Minute)},
+		// a retried event is replayed once
+		{EventID: "a", Kind: "SPOT_ITN", NodeName: "node-1", StartedAt: startedAt.Add(3 * time.Minute)},
+		// records without a node can't be handled
+		{EventID: "d", Kind: "SPOT_ITN", StartedAt: startedAt},
+	}
+	eventIDs := func(records []status.Record) []string {
+		var ids []string
+		for _, record := range records {
+			ids = append(ids, record.EventID)
+		}
+		return ids
+	}
+	h.Equals(t, []string{"a", "b", "c"}, eventIDs(replay.Filter{}.Select(records)))
+	h.Equals(t, []string{"a", "c"}, eventIDs(replay.Filter{Kinds: []string{"SPOT_ITN"}}.Select(records)))
+	h.Equals(t, []string{"b"}, eventIDs(replay.Filter{Nodes: []string{"node-2"}}.Select(records)))
+	h.Equals(t, []string{"b", "c"}, eventIDs(replay.Filter{EventIDs: []string{"b", "c"}}.Select(records)))
+	h.Equals(t, []string{"b"}, eventIDs(replay.Filter{Since: startedAt.Add(time.Minute), Until: startedAt.Add(2 * time.Minute)}.Select(records)))
+}
+
+func TestEvent(t *testing.T) {
+	now := time.Now()
+	event := replay.Event(status.Record{EventID: "a", Kind: "SCHEDULED_EVENT", Code: "system-reboot", NodeName: "node-1", Cluster: "prod", InstanceID: "i-1", StartedAt: startedAt}, now)
+	h.Equals(t, "a", event.EventID)
+	h.Equals(t, "SCHEDULED_EVENT", event.Kind)
+	h.Equals(t, "system-reboot", event.Code)
+	h.Equals(t, "node-1", event.NodeName)
+	h.Equals(t, "prod", event.Cluster)
+	h.Equals(t, "i-1", event.InstanceID)
+	h.Equals(t, now, event.StartTime)
+	h.Assert(t, strings.Contains(event.Description, "2021-06-01T10:00:00Z"), "Expected the recorded time in the description")
+}
+
+func TestMonitor(t *testing.T) {
+	records := []status.Record{
+		{EventID: "a", Kind: "SPOT_ITN", NodeName: "node-1", Action: "cordon-and-drain", Phase: "succeeded", StartedAt: startedAt},
+		{EventID: "b", Kind: "SPOT_ITN", NodeName: "node-2", Action: "cordon-and-drain", Phase: "succeeded", StartedAt: startedAt.Add(time.Second)},
+	}
+	interruptionChan := make(chan monitor.InterruptionEvent, len(records))
+	replayMonitor := replay.NewMonitor(records, interruptionChan, 0)
+	h.Equals(t, replay.Kind, replayMonitor.Kind())
+	h.Equals(t, false, replayMonitor.Finished(nil))
+
+	h.Ok(t, replayMonitor.Monitor())
+	h.Equals(t, 2, len(interruptionChan))
+	h.Equals(t, "a", (<-interruptionChan).EventID)
+	h.Equals(t, "b", (<-interruptionChan).EventID)
+	h.Ok(t, replayMonitor.Monitor())
+	h.Equals(t, 0, len(interruptionChan))
+
+	history := []status.Record{{EventID: "a", Action: "cordon-and-drain", Phase: "succeeded", CompletedAt: time.Now()}}
+	h.Equals(t, false, replayMonitor.Finished(history))
+	history = append(history, status.Record{EventID: "b", Action: "cordon", Phase: "failed", CompletedAt: time.Now(), Error: "node not found"})
+	h.Equals(t, true, replayMonitor.Finished(history))
+
+	out := bytes.Buffer{}
+	h.Ok(t, replayMonitor.Report(&out, history))
+	lines := strings.Split(out.String(), "\n")
+	h.Equals(t, "EVENT  KIND      NODE    RECORDED                    REPLAYED                    CHANGED  ERROR", strings.TrimSpace(lines[0]))
+	h.Assert(t, strings.Contains(lines[1], "false"), "Expected the outcome of a to be unchanged: %s", lines[1])
+	h.Assert(t, strings.Contains(lines[2], "cordon/failed") && strings.Contains(lines[2], "true") && strings.Contains(lines[2], "node not found"), "Expected the outcome of b to be changed: %s", lines[2])
+	h.Assert(t, strings.Contains(out.String(), "2 event(s) replayed, 1 with a changed outcome"), "Expected the summary: %s", out.String())
+}
+
+func TestMonitorPacing(t *testing.T) {
+	records := []status.Record{
+		{EventID: "a", NodeName: "node-1", StartedAt: startedAt},
+		{EventID: "b", NodeName: "node-2", StartedAt: startedAt.Add(time.Hour)},
+	}
+	interruptionChan := make(chan monitor.InterruptionEvent, len(records))
+	replayMonitor := replay.NewMonitor(records, interruptionChan, 1)
+	h.Ok(t, replayMonitor.Monitor())
+	h.Equals(t, 1, len(interruptionChan))
+	h.Equals(t, "a", (<-interruptionChan).EventID)
+	h.Ok(t, replayMonitor.Monitor())
+	h.Equals(t, 0, len(interruptionChan))
+	h.Equals(t, false, replayMonitor.Finished([]status.Record{{EventID: "a", CompletedAt: time.Now()}}))
+}
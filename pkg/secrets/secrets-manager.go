@@ -0,0 +1,87 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+const secretKeySeparator = "#"
+
+// secretsManagerARNPrefix matches the Secrets Manager ARNs of every partition, like aws, aws-cn and aws-us-gov
+var secretsManagerARNPrefix = regexp.MustCompile(`^arn:aws[a-z-]*:secretsmanager:`)
+
+// SecretsManagerAPI is the part of the Secrets Manager API the provider uses
+type SecretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// SecretsManagerProvider resolves AWS Secrets Manager secret ARNs.
+// A reference may select a key of a JSON secret by appending "#<key>" to the ARN.
+type SecretsManagerProvider struct {
+	SecretsManager SecretsManagerAPI
+}
+
+// Supports returns true if the reference is a Secrets Manager secret ARN
+func (p SecretsManagerProvider) Supports(reference string) bool {
+	return secretsManagerARNPrefix.MatchString(reference)
+}
+
+// Fetch retrieves the current value of the referenced secret
+func (p SecretsManagerProvider) Fetch(reference string) (string, error) {
+	secretID, key := splitSecretKey(reference)
+	output, err := p.SecretsManager.GetSecretValue(context.TODO(), &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Unable to retrieve secret %s from Secrets Manager: %w", secretID, err)
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("Secret %s does not contain a secret string", secretID)
+	}
+	if key == "" {
+		return *output.SecretString, nil
+	}
+	return lookupJSONKey(*output.SecretString, secretID, key)
+}
+
+func splitSecretKey(reference string) (string, string) {
+	parts := strings.SplitN(reference, secretKeySeparator, 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return reference, ""
+}
+
+func lookupJSONKey(secret string, secretID string, key string) (string, error) {
+	values := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("Unable to parse secret %s as JSON to look up key %s: %w", secretID, key, err)
+	}
+	value, ok := values[key]
+	if !ok {
+		return "", fmt.Errorf("Key %s was not found in secret %s", key, secretID)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
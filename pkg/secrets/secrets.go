@@ -0,0 +1,120 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Provider knows how to fetch the value of secret references it supports
+type Provider interface {
+	Supports(reference string) bool
+	Fetch(reference string) (string, error)
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// secretFetch is a fetch of a secret reference in flight, shared by the callers resolving the reference meanwhile
+type secretFetch struct {
+	done  chan struct{}
+	value string
+	err   error
+}
+
+// Resolver resolves config values which reference external secrets, caching resolved values
+// so that rotated secrets are picked up once the refresh interval has passed
+type Resolver struct {
+	sync.Mutex
+	providers       []Provider
+	refreshInterval time.Duration
+	cache           map[string]cachedSecret
+	fetches         map[string]*secretFetch
+}
+
+// NewResolver constructs a secret resolver using the passed in providers
+func NewResolver(refreshInterval time.Duration, providers ...Provider) *Resolver {
+	return &Resolver{
+		providers:       providers,
+		refreshInterval: refreshInterval,
+		cache:           make(map[string]cachedSecret),
+		fetches:         make(map[string]*secretFetch),
+	}
+}
+
+// Resolve returns the secret value for a supported secret reference, or the value itself if it is not a reference.
+// Secrets are fetched without holding the lock, so a slow provider only delays the callers resolving the same reference,
+// which share its fetch.
+func (r *Resolver) Resolve(value string) (string, error) {
+	if r == nil {
+		return value, nil
+	}
+	provider := r.providerFor(value)
+	if provider == nil {
+		return value, nil
+	}
+
+	r.Lock()
+	cached, ok := r.cache[value]
+	if ok && time.Since(cached.fetchedAt) < r.refreshInterval {
+		r.Unlock()
+		return cached.value, nil
+	}
+	fetch, fetching := r.fetches[value]
+	if !fetching {
+		fetch = &secretFetch{done: make(chan struct{})}
+		r.fetches[value] = fetch
+	}
+	r.Unlock()
+
+	if fetching {
+		<-fetch.done
+	} else {
+		fetch.value, fetch.err = provider.Fetch(value)
+		r.Lock()
+		delete(r.fetches, value)
+		if fetch.err == nil {
+			r.cache[value] = cachedSecret{value: fetch.value, fetchedAt: time.Now()}
+		}
+		r.Unlock()
+		close(fetch.done)
+	}
+	if fetch.err != nil {
+		if ok {
+			log.Warn().Err(fetch.err).Msg("Unable to refresh secret, continuing with the previously resolved value")
+			return cached.value, nil
+		}
+		return "", fetch.err
+	}
+	return fetch.value, nil
+}
+
+// IsReference returns true if the value is a secret reference supported by one of the providers
+func (r *Resolver) IsReference(value string) bool {
+	return r != nil && r.providerFor(value) != nil
+}
+
+func (r *Resolver) providerFor(value string) Provider {
+	for _, provider := range r.providers {
+		if provider.Supports(value) {
+			return provider
+		}
+	}
+	return nil
+}
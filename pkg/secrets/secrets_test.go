@@ -0,0 +1,176 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/secrets"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+const secretARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:nth-webhook-AbCdEf"
+
+type countingProvider struct {
+	fetches *int
+	value   string
+	err     error
+}
+
+func (p countingProvider) Supports(reference string) bool {
+	return strings.HasPrefix(reference, "test:")
+}
+
+func (p countingProvider) Fetch(reference string) (string, error) {
+	*p.fetches++
+	return p.value, p.err
+}
+
+func TestResolvePlainValue(t *testing.T) {
+	fetches := 0
+	resolver := secrets.NewResolver(time.Minute, countingProvider{fetches: &fetches})
+	value, err := resolver.Resolve("https://example.com")
+	h.Ok(t, err)
+	h.Equals(t, "https://example.com", value)
+	h.Equals(t, 0, fetches)
+}
+
+func TestResolveNilResolver(t *testing.T) {
+	var resolver *secrets.Resolver
+	value, err := resolver.Resolve(secretARN)
+	h.Ok(t, err)
+	h.Equals(t, secretARN, value)
+}
+
+func TestResolveCachesValue(t *testing.T) {
+	fetches := 0
+	resolver := secrets.NewResolver(time.Minute, countingProvider{fetches: &fetches, value: "secret"})
+	for i := 0; i < 3; i++ {
+		value, err := resolver.Resolve("test:webhook")
+		h.Ok(t, err)
+		h.Equals(t, "secret", value)
+	}
+	h.Equals(t, 1, fetches)
+}
+
+func TestResolveRefreshesExpiredValue(t *testing.T) {
+	fetches := 0
+	resolver := secrets.NewResolver(0, countingProvider{fetches: &fetches, value: "secret"})
+	_, err := resolver.Resolve("test:webhook")
+	h.Ok(t, err)
+	_, err = resolver.Resolve("test:webhook")
+	h.Ok(t, err)
+	h.Equals(t, 2, fetches)
+}
+
+type blockingProvider struct {
+	fetches *int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p blockingProvider) Supports(reference string) bool {
+	return strings.HasPrefix(reference, "test:")
+}
+
+func (p blockingProvider) Fetch(reference string) (string, error) {
+	atomic.AddInt32(p.fetches, 1)
+	if reference == "test:slow" {
+		close(p.started)
+		<-p.release
+	}
+	return reference + "-secret", nil
+}
+
+func TestResolveFetchesOutsideTheLock(t *testing.T) {
+	var fetches int32
+	provider := blockingProvider{fetches: &fetches, started: make(chan struct{}), release: make(chan struct{})}
+	resolver := secrets.NewResolver(time.Minute, provider)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := resolver.Resolve("test:slow")
+			h.Ok(t, err)
+			h.Equals(t, "test:slow-secret", value)
+		}()
+	}
+
+	// other references are resolved while the slow fetch is in flight
+	<-provider.started
+	value, err := resolver.Resolve("test:fast")
+	h.Ok(t, err)
+	h.Equals(t, "test:fast-secret", value)
+
+	close(provider.release)
+	wg.Wait()
+	_, err = resolver.Resolve("test:slow")
+	h.Ok(t, err)
+	// concurrent resolves of a reference share its fetch and the value is cached afterwards
+	h.Equals(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestResolveFailure(t *testing.T) {
+	fetches := 0
+	resolver := secrets.NewResolver(time.Minute, countingProvider{fetches: &fetches, err: fmt.Errorf("denied")})
+	_, err := resolver.Resolve("test:webhook")
+	h.Nok(t, err)
+}
+
+func TestSecretsManagerProvider(t *testing.T) {
+	provider := secrets.SecretsManagerProvider{
+		SecretsManager: h.MockedSecretsManager{
+			GetSecretValueResp: secretsmanager.GetSecretValueOutput{SecretString: aws.String("https://hooks.example.com/abc")},
+		},
+	}
+	h.Assert(t, provider.Supports(secretARN), "Expected Secrets Manager ARN to be supported")
+	h.Assert(t, provider.Supports("arn:aws-cn:secretsmanager:cn-north-1:123456789012:secret:nth-webhook-AbCdEf"), "Expected China partition ARN to be supported")
+	h.Assert(t, provider.Supports("arn:aws-us-gov:secretsmanager:us-gov-west-1:123456789012:secret:nth-webhook-AbCdEf"), "Expected GovCloud partition ARN to be supported")
+	h.Assert(t, !provider.Supports("arn:aws:ssm:us-east-1:123456789012:parameter/nth-webhook"), "Expected other service ARNs not to be supported")
+	h.Assert(t, !provider.Supports("https://example.com"), "Expected plain URL not to be supported")
+
+	value, err := provider.Fetch(secretARN)
+	h.Ok(t, err)
+	h.Equals(t, "https://hooks.example.com/abc", value)
+}
+
+func TestSecretsManagerProviderJSONKey(t *testing.T) {
+	provider := secrets.SecretsManagerProvider{
+		SecretsManager: h.MockedSecretsManager{
+			GetSecretValueResp: secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"url":"https://hooks.example.com/abc","token":"t0k3n"}`)},
+		},
+	}
+	value, err := provider.Fetch(secretARN + "#token")
+	h.Ok(t, err)
+	h.Equals(t, "t0k3n", value)
+
+	_, err = provider.Fetch(secretARN + "#missing")
+	h.Nok(t, err)
+}
+
+func TestSecretsManagerProviderFailure(t *testing.T) {
+	provider := secrets.SecretsManagerProvider{
+		SecretsManager: h.MockedSecretsManager{GetSecretValueErr: fmt.Errorf("AccessDeniedException")},
+	}
+	_, err := provider.Fetch(secretARN)
+	h.Nok(t, err)
+}
@@ -0,0 +1,245 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	vaultReferencePrefix = "vault:"
+	vaultTokenHeader     = "X-Vault-Token"
+	vaultNamespaceHeader = "X-Vault-Namespace"
+	// DefaultServiceAccountTokenPath is where kubernetes mounts the pod's service account token
+	DefaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// tokens are renewed this long before their lease expires, or halfway through shorter leases
+	vaultTokenRenewalBuffer = 30 * time.Second
+)
+
+// VaultConfig holds the settings used to authenticate to HashiCorp Vault with the kubernetes auth method
+type VaultConfig struct {
+	Address                 string
+	Role                    string
+	AuthMountPath           string
+	Namespace               string
+	CACertFile              string
+	ServiceAccountTokenPath string
+}
+
+// VaultProvider resolves references of the form "vault:<secret-path>#<key>" against HashiCorp Vault.
+// Both KV version 1 and version 2 secret engines are supported.
+type VaultProvider struct {
+	sync.Mutex
+	config     VaultConfig
+	httpClient http.Client
+	token      string
+	renewable  bool
+	// tokenExpiry is when the token is renewed, the zero time for tokens which don't expire
+	tokenExpiry time.Time
+}
+
+type vaultLoginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// vaultStatusError is returned for requests Vault responded to with an unsuccessful http status code
+type vaultStatusError struct {
+	statusCode int
+}
+
+func (e vaultStatusError) Error() string {
+	return fmt.Sprintf("Vault responded with http status code %d", e.statusCode)
+}
+
+type vaultSecretResponse struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// NewVaultProvider constructs a Vault secret provider
+func NewVaultProvider(vaultConfig VaultConfig) (*VaultProvider, error) {
+	if vaultConfig.Address == "" || vaultConfig.Role == "" {
+		return nil, fmt.Errorf("Vault address and role must both be provided to use the Vault secret provider")
+	}
+	if vaultConfig.AuthMountPath == "" {
+		vaultConfig.AuthMountPath = "kubernetes"
+	}
+	if vaultConfig.ServiceAccountTokenPath == "" {
+		vaultConfig.ServiceAccountTokenPath = DefaultServiceAccountTokenPath
+	}
+	transport := &http.Transport{}
+	if vaultConfig.CACertFile != "" {
+		caCert, err := ioutil.ReadFile(vaultConfig.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read Vault CA certificate: %w", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("Unable to parse Vault CA certificate %s", vaultConfig.CACertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: caCertPool}
+	}
+	return &VaultProvider{
+		config: vaultConfig,
+		httpClient: http.Client{
+			Timeout:   5 * time.Second,
+			Transport: transport,
+		},
+	}, nil
+}
+
+// Supports returns true if the reference is a vault secret reference
+func (p *VaultProvider) Supports(reference string) bool {
+	return strings.HasPrefix(reference, vaultReferencePrefix)
+}
+
+// Fetch reads the referenced key from the vault secret
+func (p *VaultProvider) Fetch(reference string) (string, error) {
+	secretPath, key := splitSecretKey(strings.TrimPrefix(reference, vaultReferencePrefix))
+	if key == "" {
+		return "", fmt.Errorf("Vault secret reference %s must specify a key using \"#<key>\"", reference)
+	}
+	token, err := p.getToken()
+	if err != nil {
+		return "", err
+	}
+
+	secret := vaultSecretResponse{}
+	err = p.do(http.MethodGet, "/v1/"+strings.TrimPrefix(secretPath, "/"), token, nil, &secret)
+	if statusErr, ok := err.(vaultStatusError); ok && statusErr.statusCode == http.StatusForbidden {
+		// the token may have been revoked, so force a new login on the next request
+		p.dropToken(token)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Unable to read secret %s from Vault: %w", secretPath, err)
+	}
+	data := secret.Data
+	// KV version 2 nests the secret values within an additional data field
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("Key %s was not found in Vault secret %s", key, secretPath)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// getToken returns the vault token, renewing it once its lease is about to expire. It logs in with the pod's service
+// account token if there is no token yet or it can't be renewed.
+func (p *VaultProvider) getToken() (string, error) {
+	p.Lock()
+	defer p.Unlock()
+	if p.token != "" && (p.tokenExpiry.IsZero() || time.Now().Before(p.tokenExpiry)) {
+		return p.token, nil
+	}
+	if p.token != "" && p.renewable {
+		renewal := vaultLoginResponse{}
+		err := p.do(http.MethodPost, "/v1/auth/token/renew-self", p.token, map[string]string{}, &renewal)
+		if err == nil && renewal.Auth.ClientToken != "" {
+			p.setToken(renewal)
+			log.Debug().Str("role", p.config.Role).Msg("Renewed the Vault token")
+			return p.token, nil
+		}
+		log.Debug().Err(err).Str("role", p.config.Role).Msg("Unable to renew the Vault token, logging in again")
+	}
+	jwt, err := ioutil.ReadFile(p.config.ServiceAccountTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("Unable to read service account token for Vault login: %w", err)
+	}
+	loginRequest := map[string]string{
+		"role": p.config.Role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}
+	login := vaultLoginResponse{}
+	err = p.do(http.MethodPost, fmt.Sprintf("/v1/auth/%s/login", strings.Trim(p.config.AuthMountPath, "/")), "", loginRequest, &login)
+	if err != nil {
+		return "", fmt.Errorf("Unable to log in to Vault with role %s: %w", p.config.Role, err)
+	}
+	if login.Auth.ClientToken == "" {
+		return "", fmt.Errorf("Vault login with role %s did not return a client token", p.config.Role)
+	}
+	p.setToken(login)
+	log.Debug().Str("role", p.config.Role).Msg("Logged in to Vault")
+	return p.token, nil
+}
+
+// setToken keeps the token of a login or renewal until shortly before its lease expires
+func (p *VaultProvider) setToken(login vaultLoginResponse) {
+	p.token = login.Auth.ClientToken
+	p.renewable = login.Auth.Renewable
+	p.tokenExpiry = time.Time{}
+	if login.Auth.LeaseDuration > 0 {
+		lease := time.Duration(login.Auth.LeaseDuration) * time.Second
+		buffer := vaultTokenRenewalBuffer
+		if buffer > lease/2 {
+			buffer = lease / 2
+		}
+		p.tokenExpiry = time.Now().Add(lease - buffer)
+	}
+}
+
+// dropToken forgets the token, unless another request replaced it already
+func (p *VaultProvider) dropToken(token string) {
+	p.Lock()
+	defer p.Unlock()
+	if p.token == token {
+		p.token = ""
+	}
+}
+
+func (p *VaultProvider) do(method string, path string, token string, body interface{}, out interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(p.config.Address, "/")+path, &reqBody)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set(vaultTokenHeader, token)
+	}
+	if p.config.Namespace != "" {
+		req.Header.Set(vaultNamespaceHeader, p.config.Namespace)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return vaultStatusError{statusCode: resp.StatusCode}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,146 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package secrets_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/secrets"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+const (
+	vaultRole        = "nth"
+	vaultClientToken = "s.client-token"
+	saToken          = "service-account-jwt"
+)
+
+func vaultServer(t *testing.T, logins *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			*logins++
+			login := map[string]string{}
+			h.Ok(t, json.NewDecoder(req.Body).Decode(&login))
+			h.Equals(t, vaultRole, login["role"])
+			h.Equals(t, saToken, login["jwt"])
+			rw.Write([]byte(`{"auth":{"client_token":"` + vaultClientToken + `","lease_duration":3600}}`))
+		case "/v1/secret/data/nth":
+			h.Equals(t, vaultClientToken, req.Header.Get("X-Vault-Token"))
+			rw.Write([]byte(`{"data":{"data":{"url":"https://kv2.example.com"},"metadata":{"version":1}}}`))
+		case "/v1/kv/nth":
+			h.Equals(t, vaultClientToken, req.Header.Get("X-Vault-Token"))
+			rw.Write([]byte(`{"data":{"url":"https://kv1.example.com"}}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestVaultProvider(t *testing.T, address string) *secrets.VaultProvider {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	h.Ok(t, ioutil.WriteFile(tokenPath, []byte(saToken+"\n"), os.ModePerm))
+	provider, err := secrets.NewVaultProvider(secrets.VaultConfig{
+		Address:                 address,
+		Role:                    vaultRole,
+		ServiceAccountTokenPath: tokenPath,
+	})
+	h.Ok(t, err)
+	return provider
+}
+
+func TestVaultProviderKV2(t *testing.T) {
+	logins := 0
+	server := vaultServer(t, &logins)
+	defer server.Close()
+	provider := newTestVaultProvider(t, server.URL)
+
+	h.Assert(t, provider.Supports("vault:secret/data/nth#url"), "Expected vault reference to be supported")
+	h.Assert(t, !provider.Supports(secretARN), "Expected secrets manager reference to not be supported")
+	value, err := provider.Fetch("vault:secret/data/nth#url")
+	h.Ok(t, err)
+	h.Equals(t, "https://kv2.example.com", value)
+}
+
+func TestVaultProviderKV1ReusesToken(t *testing.T) {
+	logins := 0
+	server := vaultServer(t, &logins)
+	defer server.Close()
+	provider := newTestVaultProvider(t, server.URL)
+
+	for i := 0; i < 2; i++ {
+		value, err := provider.Fetch("vault:kv/nth#url")
+		h.Ok(t, err)
+		h.Equals(t, "https://kv1.example.com", value)
+	}
+	h.Equals(t, 1, logins)
+}
+
+func TestVaultProviderRenewsToken(t *testing.T) {
+	logins, renewals := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			logins++
+			rw.Write([]byte(`{"auth":{"client_token":"` + vaultClientToken + `","lease_duration":1,"renewable":true}}`))
+		case "/v1/auth/token/renew-self":
+			renewals++
+			h.Equals(t, vaultClientToken, req.Header.Get("X-Vault-Token"))
+			rw.Write([]byte(`{"auth":{"client_token":"` + vaultClientToken + `","lease_duration":3600,"renewable":true}}`))
+		case "/v1/kv/nth":
+			rw.Write([]byte(`{"data":{"url":"https://kv1.example.com"}}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	provider := newTestVaultProvider(t, server.URL)
+
+	_, err := provider.Fetch("vault:kv/nth#url")
+	h.Ok(t, err)
+	// the token is renewed halfway through its lease of a second
+	time.Sleep(600 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		_, err = provider.Fetch("vault:kv/nth#url")
+		h.Ok(t, err)
+	}
+	h.Equals(t, 1, logins)
+	h.Equals(t, 1, renewals)
+}
+
+func TestVaultProviderMissingKey(t *testing.T) {
+	logins := 0
+	server := vaultServer(t, &logins)
+	defer server.Close()
+	provider := newTestVaultProvider(t, server.URL)
+
+	_, err := provider.Fetch("vault:kv/nth")
+	h.Nok(t, err)
+	_, err = provider.Fetch("vault:kv/nth#token")
+	h.Nok(t, err)
+	_, err = provider.Fetch("vault:kv/missing#url")
+	h.Nok(t, err)
+}
+
+func TestNewVaultProviderRequiresRole(t *testing.T) {
+	_, err := secrets.NewVaultProvider(secrets.VaultConfig{Address: "https://vault.example.com"})
+	h.Nok(t, err)
+}
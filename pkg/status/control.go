@@ -0,0 +1,136 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+)
+
+const (
+	// SimulatedEventKind is the kind of the interruption events created by the simulate endpoint of the control API
+	SimulatedEventKind = "SIMULATED_EVENT"
+	// ModeIMDS is the mode of handlers monitoring the instance metadata service of their node
+	ModeIMDS = "imds"
+	// ModeQueue is the mode of handlers processing an SQS queue
+	ModeQueue = "queue"
+	// ModeCombined is the mode of handlers monitoring the instance metadata service of their node and processing an SQS queue
+	ModeCombined = "combined"
+	nodesPath    = "/api/nodes/"
+)
+
+// Status is the response of the status endpoint and of the control endpoints
+type Status struct {
+	Mode string `json:"mode"`
+	// NodeName is the node of handlers in IMDS or combined mode
+	NodeName      string   `json:"nodeName,omitempty"`
+	ControlAPI    bool     `json:"controlAPI"`
+	Paused        bool     `json:"paused"`
+	ApprovedNodes []string `json:"approvedNodes"`
+	Pending       int      `json:"pending"`
+	Draining      int      `json:"draining"`
+	Processed     int      `json:"processed"`
+}
+
+func currentStatus(store Store, nthConfig config.Config) Status {
+	status := Status{
+		Mode:          ModeQueue,
+		ControlAPI:    nthConfig.EnableControlAPI,
+		Paused:        store.Paused(),
+		ApprovedNodes: store.ApprovedNodes(),
+	}
+	if !nthConfig.EnableSQSTerminationDraining {
+		status.Mode = ModeIMDS
+		status.NodeName = nthConfig.NodeName
+	} else if nthConfig.EnableCombinedMode {
+		status.Mode = ModeCombined
+		status.NodeName = nthConfig.NodeName
+	}
+	for _, event := range store.Snapshot() {
+		switch eventState(event) {
+		case "processed":
+			status.Processed++
+		case "draining":
+			status.Draining++
+		default:
+			status.Pending++
+		}
+	}
+	return status
+}
+
+func handleControl(mux *http.ServeMux, store Store, nthConfig config.Config) {
+	mux.HandleFunc("/api/pause", post(func(w http.ResponseWriter, r *http.Request) {
+		store.Pause()
+		writeJSON(w, currentStatus(store, nthConfig))
+	}))
+	mux.HandleFunc("/api/resume", post(func(w http.ResponseWriter, r *http.Request) {
+		store.Resume()
+		writeJSON(w, currentStatus(store, nthConfig))
+	}))
+	mux.HandleFunc(nodesPath, post(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, nodesPath), "/")
+		if len(parts) != 2 || parts[0] == "" {
+			http.NotFound(w, r)
+			return
+		}
+		nodeName, action := parts[0], parts[1]
+		if !nthConfig.EnableSQSTerminationDraining && nodeName != nthConfig.NodeName {
+			http.Error(w, fmt.Sprintf("this handler only handles node %s", nthConfig.NodeName), http.StatusBadRequest)
+			return
+		}
+		switch action {
+		case "approve":
+			store.ApproveNode(nodeName)
+			writeJSON(w, currentStatus(store, nthConfig))
+		case "simulate":
+			if !nthConfig.EnableSimulateAPI {
+				http.NotFound(w, r)
+				return
+			}
+			event := simulatedEvent(nodeName, time.Now())
+			store.AddInterruptionEvent(event)
+			writeJSON(w, activeEvents([]monitor.InterruptionEvent{*event})[0])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// simulatedEvent returns an interruption event which is due now, so the node is handled as if it was interrupted
+func simulatedEvent(nodeName string, now time.Time) *monitor.InterruptionEvent {
+	return &monitor.InterruptionEvent{
+		EventID:     fmt.Sprintf("simulated-%s-%d", nodeName, now.UnixNano()),
+		Kind:        SimulatedEventKind,
+		Description: fmt.Sprintf("Simulated interruption event received for node %s", nodeName),
+		NodeName:    nodeName,
+		StartTime:   now,
+	}
+}
+
+func post(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package status
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+)
+
+const defaultHistorySize = 200
+
+// Record is the outcome of handling an interruption event
+type Record struct {
+	EventID    string `json:"eventId"`
+	Kind       string `json:"kind"`
+	Code       string `json:"code,omitempty"`
+	NodeName   string `json:"nodeName"`
+	Cluster    string `json:"cluster,omitempty"`
+	InstanceID string `json:"instanceId,omitempty"`
+	Phase      string `json:"phase"`
+	// Action is the action decided for the node, e.g. cordon-and-drain, or why none was taken, e.g. skip-karpenter
+	Action      string    `json:"action,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+	Pods        int       `json:"pods"`
+	// EvictionFailures counts the failed eviction and pod deletion attempts by reason, e.g. pdb or timeout
+	EvictionFailures map[string]int `json:"evictionFailures,omitempty"`
+	// Rescheduling is recovered or unrecovered when the workloads of the drained pods were verified after the drain
+	Rescheduling         string   `json:"rescheduling,omitempty"`
+	UnrecoveredWorkloads []string `json:"unrecoveredWorkloads,omitempty"`
+	// DrainDurationMilliseconds is how long the action on the node, e.g. the cordon and drain, took
+	DrainDurationMilliseconds int64 `json:"drainDurationMilliseconds,omitempty"`
+	// Hooks has the outcome of the hooks run for the event by phase, e.g. pre-drain: succeeded
+	Hooks map[string]string `json:"hooks,omitempty"`
+	Error string            `json:"error,omitempty"`
+}
+
+// RecordSink receives the record of each event once handling it finished, e.g. to keep an audit trail
+type RecordSink interface {
+	Write(record Record)
+}
+
+// flushingSink is a sink which queues the records and ships them when it is flushed
+type flushingSink interface {
+	Flush() error
+}
+
+// History keeps the records of the most recently handled interruption events in memory
+type History struct {
+	sync.RWMutex
+	size    int
+	records []Record
+	sinks   []RecordSink
+	now     func() time.Time
+}
+
+// NewHistory returns a history keeping up to size records
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &History{size: size, now: time.Now}
+}
+
+// Start records that handling the event started, replacing the record of an earlier attempt
+func (h *History) Start(event monitor.InterruptionEvent, phase string) {
+	h.Lock()
+	defer h.Unlock()
+	h.remove(event.EventID)
+	h.records = append(h.records, Record{
+		EventID:    event.EventID,
+		Kind:       event.Kind,
+		Code:       event.Code,
+		NodeName:   event.NodeName,
+		Cluster:    event.Cluster,
+		InstanceID: event.InstanceID,
+		Phase:      phase,
+		StartedAt:  h.now(),
+	})
+	if len(h.records) > h.size {
+		h.records = h.records[len(h.records)-h.size:]
+	}
+}
+
+// AddSink passes the records of finished events to the sink as well
+func (h *History) AddSink(sink RecordSink) {
+	h.Lock()
+	defer h.Unlock()
+	h.sinks = append(h.sinks, sink)
+}
+
+// Finish records the final phase of handling the event and the action decided for its node
+func (h *History) Finish(event monitor.InterruptionEvent, phase string, action string, handlingErr error) {
+	h.Lock()
+	var finished []Record
+	for i := range h.records {
+		if h.records[i].EventID != event.EventID {
+			continue
+		}
+		h.records[i].Phase = phase
+		h.records[i].Action = action
+		h.records[i].CompletedAt = h.now()
+		h.records[i].Pods = len(event.Pods)
+		h.records[i].EvictionFailures = event.EvictionFailures
+		h.records[i].Rescheduling = event.Rescheduling
+		h.records[i].UnrecoveredWorkloads = event.UnrecoveredWorkloads
+		h.records[i].DrainDurationMilliseconds = event.DrainDuration.Milliseconds()
+		h.records[i].Hooks = event.HookOutcomes
+		if handlingErr != nil {
+			h.records[i].Error = handlingErr.Error()
+		}
+		finished = append(finished, h.records[i])
+	}
+	sinks := h.sinks
+	h.Unlock()
+	for _, record := range finished {
+		for _, sink := range sinks {
+			sink.Write(record)
+		}
+	}
+}
+
+// Flush ships the records the sinks queued, e.g. before shutting down. The first error of the sinks is returned.
+func (h *History) Flush() error {
+	h.RLock()
+	sinks := h.sinks
+	h.RUnlock()
+	var firstErr error
+	for _, sink := range sinks {
+		if flushing, ok := sink.(flushingSink); ok {
+			if err := flushing.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// Records returns the records, most recent first
+func (h *History) Records() []Record {
+	h.RLock()
+	defer h.RUnlock()
+	records := make([]Record, len(h.records))
+	for i, record := range h.records {
+		records[len(h.records)-1-i] = record
+	}
+	return records
+}
+
+func (h *History) remove(eventID string) {
+	for i, record := range h.records {
+		if record.EventID == eventID {
+			h.records = append(h.records[:i], h.records[i+1:]...)
+			return
+		}
+	}
+}
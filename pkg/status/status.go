@@ -0,0 +1,163 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package status
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/httpserver"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/rs/zerolog/log"
+)
+
+const redacted = "<redacted>"
+
+// redactedConfigFields may hold credentials, so they are not served
+var redactedConfigFields = []string{"WebhookURL", "WebhookHeaders", "WebhookProxy", "PreDrainSSMParameters", "PreDrainSSMParameterValues", "PushReceiverSecret", "KafkaSASLPassword", "NATSToken", "NATSPassword", "MetricsBearerToken"}
+
+//go:embed dashboard
+var dashboard embed.FS
+
+// Store holds the interruption events which are currently known and whether handling them is paused
+type Store interface {
+	Snapshot() []monitor.InterruptionEvent
+	AddInterruptionEvent(interruptionEvent *monitor.InterruptionEvent)
+	Pause()
+	Resume()
+	Paused() bool
+	ApproveNode(nodeName string)
+	ApprovedNodes() []string
+}
+
+// ActiveEvent is an interruption event which is currently known, with its handling state
+type ActiveEvent struct {
+	EventID     string    `json:"eventId"`
+	Kind        string    `json:"kind"`
+	Code        string    `json:"code,omitempty"`
+	Description string    `json:"description,omitempty"`
+	NodeName    string    `json:"nodeName"`
+	InstanceID  string    `json:"instanceId,omitempty"`
+	State       string    `json:"state"`
+	StartTime   time.Time `json:"startTime"`
+	DrainTime   time.Time `json:"drainTime,omitempty"`
+	Pods        int       `json:"pods"`
+}
+
+// Events is the response of the events endpoint
+type Events struct {
+	Active  []ActiveEvent `json:"active"`
+	History []Record      `json:"history"`
+}
+
+// Handler returns the handler of the status API and dashboard, and of the control API when it is enabled
+func Handler(store Store, history *History, nthConfig config.Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, currentStatus(store, nthConfig))
+	})
+	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, Events{Active: activeEvents(store.Snapshot()), History: history.Records()})
+	})
+	mux.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, redactConfig(nthConfig))
+	})
+	if nthConfig.EnableControlAPI {
+		handleControl(mux, store, nthConfig)
+	}
+	content, err := fs.Sub(dashboard, "dashboard")
+	if err != nil {
+		log.Err(err).Msg("Unable to load the dashboard")
+	} else {
+		mux.Handle("/", http.FileServer(http.FS(content)))
+	}
+	return mux
+}
+
+// Serve starts serving the status API and dashboard on the port, listening like the prometheus server
+func Serve(port int, store Store, history *History, nthConfig config.Config) {
+	options := httpserver.NewOptions(nthConfig)
+	server := &http.Server{
+		Addr:         options.Addr(port),
+		Handler:      options.Handler(Handler(store, history, nthConfig)),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+	}
+	go func() {
+		log.Info().Msgf("Starting to serve the status API and dashboard over %s on %s", options.Scheme(), server.Addr)
+		if err := options.ListenAndServe(server); err != nil && err != http.ErrServerClosed {
+			log.Err(err).Msg("Failed to listen and serve the status API")
+		}
+	}()
+}
+
+func activeEvents(events []monitor.InterruptionEvent) []ActiveEvent {
+	active := make([]ActiveEvent, 0, len(events))
+	for _, event := range events {
+		active = append(active, ActiveEvent{
+			EventID:     event.EventID,
+			Kind:        event.Kind,
+			Code:        event.Code,
+			Description: event.Description,
+			NodeName:    event.NodeName,
+			InstanceID:  event.InstanceID,
+			State:       eventState(event),
+			StartTime:   event.StartTime,
+			DrainTime:   event.DrainTime,
+			Pods:        len(event.Pods),
+		})
+	}
+	return active
+}
+
+func eventState(event monitor.InterruptionEvent) string {
+	switch {
+	case event.NodeProcessed:
+		return "processed"
+	case event.InProgress:
+		return "draining"
+	default:
+		return "pending"
+	}
+}
+
+func redactConfig(nthConfig config.Config) map[string]interface{} {
+	fields := map[string]interface{}{}
+	content, err := json.Marshal(nthConfig)
+	if err != nil {
+		return fields
+	}
+	err = json.Unmarshal(content, &fields)
+	if err != nil {
+		return fields
+	}
+	for _, field := range redactedConfigFields {
+		if value, ok := fields[field]; ok && value != "" && value != nil {
+			fields[field] = redacted
+		}
+	}
+	return fields
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Add("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		log.Err(err).Msg("Unable to write the status API response")
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package status_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/config"
+	"github.com/aws/aws-node-termination-handler/pkg/interruptioneventstore"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/status"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+var event = monitor.InterruptionEvent{
+	EventID:   "spot-itn-1",
+	Kind:      "SPOT_ITN",
+	NodeName:  "ip-10-0-0-1.ec2.internal",
+	StartTime: time.Date(2021, time.June, 5, 8, 0, 0, 0, time.UTC),
+	Pods:      []string{"web-1", "web-2"},
+}
+
+type fakeSink struct {
+	records []status.Record
+}
+
+func (s *fakeSink) Write(record status.Record) {
+	s.records = append(s.records, record)
+}
+
+func TestHistory(t *testing.T) {
+	history := status.NewHistory(2)
+	sink := &fakeSink{}
+	history.AddSink(sink)
+	history.Start(event, "Draining")
+	h.Equals(t, 0, len(sink.records))
+	history.Finish(event, "Failed", "cordon-and-drain", errors.New("eviction blocked"))
+	records := history.Records()
+	h.Equals(t, 1, len(records))
+	h.Equals(t, "Failed", records[0].Phase)
+	h.Equals(t, "cordon-and-drain", records[0].Action)
+	h.Equals(t, records, sink.records)
+	h.Equals(t, "eviction blocked", records[0].Error)
+	h.Equals(t, 2, records[0].Pods)
+
+	drained := event
+	drained.DrainDuration = 450 * time.Millisecond
+	drained.HookOutcomes = map[string]string{"pre-drain": "succeeded"}
+	history.Start(drained, "Draining")
+	history.Finish(drained, "Succeeded", "cordon-and-drain", nil)
+	records = history.Records()
+	h.Equals(t, int64(450), records[0].DrainDurationMilliseconds)
+	h.Equals(t, drained.HookOutcomes, records[0].Hooks)
+
+	history.Start(event, "Draining")
+	records = history.Records()
+	h.Equals(t, 1, len(records))
+	h.Equals(t, "", records[0].Error)
+}
+
+type flushingSink struct {
+	fakeSink
+	flushes int
+	err     error
+}
+
+func (s *flushingSink) Flush() error {
+	s.flushes++
+	return s.err
+}
+
+func TestHistoryFlush(t *testing.T) {
+	history := status.NewHistory(2)
+	failing := &flushingSink{err: errors.New("throttled")}
+	flushing := &flushingSink{}
+	history.AddSink(&fakeSink{})
+	history.AddSink(failing)
+	history.AddSink(flushing)
+
+	err := history.Flush()
+	h.Equals(t, failing.err, err)
+	h.Equals(t, 1, failing.flushes)
+	h.Equals(t, 1, flushing.flushes)
+}
+
+func TestHistorySize(t *testing.T) {
+	history := status.NewHistory(2)
+	for i := 0; i < 3; i++ {
+		e := event
+		e.EventID = fmt.Sprintf("event-%d", i)
+		history.Start(e, "Draining")
+	}
+	records := history.Records()
+	h.Equals(t, 2, len(records))
+	h.Equals(t, "event-2", records[0].EventID)
+	h.Equals(t, "event-1", records[1].EventID)
+}
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	return request(t, handler, http.MethodGet, path)
+}
+
+func request(t *testing.T, handler http.Handler, method string, path string) (int, string) {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+	body, err := ioutil.ReadAll(recorder.Body)
+	h.Ok(t, err)
+	return recorder.Code, string(body)
+}
+
+func TestEventsEndpoint(t *testing.T) {
+	inProgress := event
+	inProgress.InProgress = true
+	store := interruptioneventstore.New(config.Config{})
+	store.AddInterruptionEvent(&inProgress)
+	history := status.NewHistory(0)
+	history.Start(event, "Draining")
+	handler := status.Handler(store, history, config.Config{})
+
+	code, body := get(t, handler, "/api/events")
+	h.Equals(t, http.StatusOK, code)
+	events := status.Events{}
+	h.Ok(t, json.Unmarshal([]byte(body), &events))
+	h.Equals(t, 1, len(events.Active))
+	h.Equals(t, "draining", events.Active[0].State)
+	h.Equals(t, 2, events.Active[0].Pods)
+	h.Equals(t, 1, len(events.History))
+}
+
+func TestConfigEndpointRedactsCredentials(t *testing.T) {
+	handler := status.Handler(interruptioneventstore.New(config.Config{}), status.NewHistory(0), config.Config{NodeName: "node", WebhookURL: "https://hooks.example.com/secret"})
+	code, body := get(t, handler, "/api/config")
+	h.Equals(t, http.StatusOK, code)
+	h.Assert(t, !strings.Contains(body, "hooks.example.com"), "Expected the webhook URL to be redacted")
+	h.Assert(t, strings.Contains(body, `"NodeName":"node"`), "Expected the node name to be served")
+}
+
+func TestDashboard(t *testing.T) {
+	handler := status.Handler(interruptioneventstore.New(config.Config{}), status.NewHistory(0), config.Config{})
+	code, body := get(t, handler, "/")
+	h.Equals(t, http.StatusOK, code)
+	h.Assert(t, strings.Contains(body, "AWS Node Termination Handler"), "Expected the dashboard to be served")
+}
+
+func TestControlAPIDisabled(t *testing.T) {
+	handler := status.Handler(interruptioneventstore.New(config.Config{}), status.NewHistory(0), config.Config{})
+	code, _ := request(t, handler, http.MethodPost, "/api/pause")
+	h.Equals(t, http.StatusNotFound, code)
+}
+
+func TestControlAPIPauseAndApprove(t *testing.T) {
+	nthConfig := config.Config{EnableStatusAPI: true, EnableControlAPI: true, NodeName: event.NodeName}
+	store := interruptioneventstore.New(nthConfig)
+	handler := status.Handler(store, status.NewHistory(0), nthConfig)
+
+	code, _ := get(t, handler, "/api/pause")
+	h.Equals(t, http.StatusMethodNotAllowed, code)
+	code, _ = request(t, handler, http.MethodPost, "/api/pause")
+	h.Equals(t, http.StatusOK, code)
+	h.Equals(t, true, store.Paused())
+
+	code, body := request(t, handler, http.MethodPost, "/api/nodes/"+event.NodeName+"/approve")
+	h.Equals(t, http.StatusOK, code)
+	current := status.Status{}
+	h.Ok(t, json.Unmarshal([]byte(body), &current))
+	h.Equals(t, status.ModeIMDS, current.Mode)
+	h.Equals(t, true, current.Paused)
+	h.Equals(t, []string{event.NodeName}, current.ApprovedNodes)
+
+	// in IMDS mode a handler only handles its own node
+	code, _ = request(t, handler, http.MethodPost, "/api/nodes/other-node/approve")
+	h.Equals(t, http.StatusBadRequest, code)
+
+	code, _ = request(t, handler, http.MethodPost, "/api/resume")
+	h.Equals(t, http.StatusOK, code)
+	h.Equals(t, false, store.Paused())
+}
+
+func TestControlAPISimulate(t *testing.T) {
+	nthConfig := config.Config{EnableStatusAPI: true, EnableControlAPI: true, EnableSimulateAPI: true, EnableSQSTerminationDraining: true, MetricsBearerToken: "token"}
+	store := interruptioneventstore.New(nthConfig)
+	handler := status.Handler(store, status.NewHistory(0), nthConfig)
+
+	code, body := request(t, handler, http.MethodPost, "/api/nodes/"+event.NodeName+"/simulate")
+	h.Equals(t, http.StatusOK, code)
+	simulated := status.ActiveEvent{}
+	h.Ok(t, json.Unmarshal([]byte(body), &simulated))
+	h.Equals(t, status.SimulatedEventKind, simulated.Kind)
+	h.Assert(t, store.HasEvent(simulated.EventID), "Expected the simulated event to be stored")
+	activeEvent, isActive := store.GetActiveEvent()
+	h.Equals(t, true, isActive)
+	h.Equals(t, event.NodeName, activeEvent.NodeName)
+
+	code, _ = request(t, handler, http.MethodPost, "/api/nodes/"+event.NodeName+"/unknown")
+	h.Equals(t, http.StatusNotFound, code)
+}
+
+func TestControlAPISimulateDisabled(t *testing.T) {
+	nthConfig := config.Config{EnableStatusAPI: true, EnableControlAPI: true, EnableSQSTerminationDraining: true}
+	handler := status.Handler(interruptioneventstore.New(nthConfig), status.NewHistory(0), nthConfig)
+	code, _ := request(t, handler, http.MethodPost, "/api/nodes/"+event.NodeName+"/simulate")
+	h.Equals(t, http.StatusNotFound, code)
+}
+
+func TestStatusCombinedMode(t *testing.T) {
+	nthConfig := config.Config{EnableStatusAPI: true, EnableSQSTerminationDraining: true, EnableCombinedMode: true, NodeName: event.NodeName}
+	handler := status.Handler(interruptioneventstore.New(nthConfig), status.NewHistory(0), nthConfig)
+
+	code, body := get(t, handler, "/api/status")
+	h.Equals(t, http.StatusOK, code)
+	current := status.Status{}
+	h.Ok(t, json.Unmarshal([]byte(body), &current))
+	h.Equals(t, status.ModeCombined, current.Mode)
+	h.Equals(t, event.NodeName, current.NodeName)
+}
@@ -0,0 +1,107 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stepfunctions
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// TaskErrorAborted is the error reported to the execution when a hook aborted handling the event
+	TaskErrorAborted = "NTH.Aborted"
+)
+
+// TaskOutput is the output reported to the execution once the event was handled
+type TaskOutput struct {
+	EventID    string   `json:"eventId"`
+	Kind       string   `json:"kind"`
+	NodeName   string   `json:"nodeName"`
+	InstanceID string   `json:"instanceId"`
+	Pods       []string `json:"pods"`
+}
+
+// SFNAPI is the part of the Step Functions API the callback uses
+type SFNAPI interface {
+	SendTaskSuccess(ctx context.Context, params *sfn.SendTaskSuccessInput, optFns ...func(*sfn.Options)) (*sfn.SendTaskSuccessOutput, error)
+	SendTaskFailure(ctx context.Context, params *sfn.SendTaskFailureInput, optFns ...func(*sfn.Options)) (*sfn.SendTaskFailureOutput, error)
+	SendTaskHeartbeat(ctx context.Context, params *sfn.SendTaskHeartbeatInput, optFns ...func(*sfn.Options)) (*sfn.SendTaskHeartbeatOutput, error)
+}
+
+// TaskCallback reports the progress of handling interruption events to the Step Functions executions which sent them
+// with a task token
+type TaskCallback struct {
+	SFN               SFNAPI
+	HeartbeatInterval time.Duration
+}
+
+// StartHeartbeat periodically sends heartbeats for the task of the event until the returned function is called
+func (c TaskCallback) StartHeartbeat(event monitor.InterruptionEvent) func() {
+	stop := make(chan struct{})
+	if event.TaskToken == "" || c.HeartbeatInterval <= 0 {
+		return func() {}
+	}
+	go func() {
+		ticker := time.NewTicker(c.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				_, err := c.SFN.SendTaskHeartbeat(context.TODO(), &sfn.SendTaskHeartbeatInput{TaskToken: aws.String(event.TaskToken)})
+				if err != nil {
+					log.Warn().Err(err).Str("event_id", event.EventID).Msg("Unable to send the Step Functions task heartbeat")
+				}
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// Complete reports the task of the event as succeeded, or as failed with the error
+func (c TaskCallback) Complete(event monitor.InterruptionEvent, taskErr error) error {
+	if event.TaskToken == "" {
+		return nil
+	}
+	if taskErr != nil {
+		_, err := c.SFN.SendTaskFailure(context.TODO(), &sfn.SendTaskFailureInput{
+			TaskToken: aws.String(event.TaskToken),
+			Error:     aws.String(TaskErrorAborted),
+			Cause:     aws.String(taskErr.Error()),
+		})
+		return err
+	}
+	output, err := json.Marshal(TaskOutput{
+		EventID:    event.EventID,
+		Kind:       event.Kind,
+		NodeName:   event.NodeName,
+		InstanceID: event.InstanceID,
+		Pods:       event.Pods,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.SFN.SendTaskSuccess(context.TODO(), &sfn.SendTaskSuccessInput{
+		TaskToken: aws.String(event.TaskToken),
+		Output:    aws.String(string(output)),
+	})
+	return err
+}
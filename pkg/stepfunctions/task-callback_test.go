@@ -0,0 +1,86 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stepfunctions_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/stepfunctions"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+var event = monitor.InterruptionEvent{
+	EventID:    "spot-itn-1",
+	Kind:       "SQS_TERMINATE",
+	NodeName:   "test-node",
+	InstanceID: "i-0123456789",
+	TaskToken:  "token",
+}
+
+func TestCompleteSuccess(t *testing.T) {
+	calls := make(chan string, 1)
+	callback := stepfunctions.TaskCallback{SFN: h.MockedSFN{Calls: calls}}
+	err := callback.Complete(event, nil)
+	h.Ok(t, err)
+	h.Equals(t, "SendTaskSuccess", <-calls)
+}
+
+func TestCompleteFailure(t *testing.T) {
+	calls := make(chan string, 1)
+	callback := stepfunctions.TaskCallback{SFN: h.MockedSFN{Calls: calls}}
+	err := callback.Complete(event, errors.New("aborted by hook"))
+	h.Ok(t, err)
+	h.Equals(t, "SendTaskFailure", <-calls)
+}
+
+func TestCompleteError(t *testing.T) {
+	callback := stepfunctions.TaskCallback{SFN: h.MockedSFN{SendTaskSuccessErr: errors.New("task timed out")}}
+	err := callback.Complete(event, nil)
+	h.Assert(t, err != nil, "Expected the SendTaskSuccess error to be returned")
+}
+
+func TestCompleteWithoutTaskToken(t *testing.T) {
+	calls := make(chan string, 1)
+	callback := stepfunctions.TaskCallback{SFN: h.MockedSFN{Calls: calls}}
+	imdsEvent := event
+	imdsEvent.TaskToken = ""
+	err := callback.Complete(imdsEvent, nil)
+	h.Ok(t, err)
+	h.Equals(t, 0, len(calls))
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	calls := make(chan string, 10)
+	callback := stepfunctions.TaskCallback{SFN: h.MockedSFN{Calls: calls}, HeartbeatInterval: 10 * time.Millisecond}
+	stop := callback.StartHeartbeat(event)
+	select {
+	case call := <-calls:
+		h.Equals(t, "SendTaskHeartbeat", call)
+	case <-time.After(time.Second):
+		t.Fatal("Expected a heartbeat to be sent")
+	}
+	stop()
+}
+
+func TestStartHeartbeatDisabled(t *testing.T) {
+	calls := make(chan string, 10)
+	callback := stepfunctions.TaskCallback{SFN: h.MockedSFN{Calls: calls}}
+	stop := callback.StartHeartbeat(event)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	h.Equals(t, 0, len(calls))
+}
@@ -0,0 +1,126 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package streamevent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const kafkaDialTimeout = 10 * time.Second
+
+// KafkaOptions configures the consumer of a Kafka topic
+type KafkaOptions struct {
+	// Brokers are the comma separated bootstrap brokers
+	Brokers string
+	Topic   string
+	GroupID string
+	TLS     bool
+	// TLSCAFile verifies the broker certificates, the system CAs are used if empty
+	TLSCAFile string
+	// SASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, SASL is not used if empty
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+}
+
+// KafkaSource consumes a Kafka topic as a member of a consumer group, so replicas sharing the group each receive a
+// share of the messages. Messages are committed once they are handled, so events are redelivered if handling them
+// fails or the handler stops before.
+type KafkaSource struct {
+	Options KafkaOptions
+}
+
+// Consume joins the consumer group and passes the messages to handle until fetching, handling or committing a message
+// fails
+func (s KafkaSource) Consume(handle func(event []byte, ack func()) error) error {
+	dialer, err := kafkaDialer(s.Options)
+	if err != nil {
+		return err
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  strings.Split(s.Options.Brokers, ","),
+		Topic:    s.Options.Topic,
+		GroupID:  s.Options.GroupID,
+		Dialer:   dialer,
+		MaxBytes: maxEventSize,
+		// a new consumer group starts at the end of the topic
+		StartOffset: kafka.LastOffset,
+	})
+	defer reader.Close()
+	log.Info().Str("topic", s.Options.Topic).Str("group_id", s.Options.GroupID).Msg("Started consuming the Kafka topic")
+	ctx := context.Background()
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("Unable to fetch a message of the Kafka topic %s: %w", s.Options.Topic, err)
+		}
+		if err := handle(message.Value, nil); err != nil {
+			// the message is not committed, so it is fetched again once the reader is restarted
+			return err
+		}
+		if err := reader.CommitMessages(ctx, message); err != nil {
+			return fmt.Errorf("Unable to commit the message of the Kafka topic %s: %w", s.Options.Topic, err)
+		}
+	}
+}
+
+// kafkaDialer returns the dialer which connects to the brokers with the TLS and SASL options
+func kafkaDialer(options KafkaOptions) (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{Timeout: kafkaDialTimeout, DualStack: true}
+	if options.TLS {
+		dialer.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+		if options.TLSCAFile != "" {
+			ca, err := ioutil.ReadFile(options.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to read the Kafka CA certificate: %w", err)
+			}
+			dialer.TLS.RootCAs = x509.NewCertPool()
+			if !dialer.TLS.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("the Kafka CA certificate %s holds no PEM certificates", options.TLSCAFile)
+			}
+		}
+	}
+	mechanism, err := kafkaSASLMechanism(options)
+	if err != nil {
+		return nil, err
+	}
+	dialer.SASLMechanism = mechanism
+	return dialer, nil
+}
+
+func kafkaSASLMechanism(options KafkaOptions) (sasl.Mechanism, error) {
+	switch options.SASLMechanism {
+	case "":
+		return nil, nil
+	case "PLAIN":
+		return plain.Mechanism{Username: options.SASLUsername, Password: options.SASLPassword}, nil
+	case "SCRAM-SHA-256":
+		return scram.Mechanism(scram.SHA256, options.SASLUsername, options.SASLPassword)
+	case "SCRAM-SHA-512":
+		return scram.Mechanism(scram.SHA512, options.SASLUsername, options.SASLPassword)
+	}
+	return nil, fmt.Errorf("unsupported Kafka SASL mechanism %s", options.SASLMechanism)
+}
@@ -0,0 +1,51 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package streamevent
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestKafkaDialer(t *testing.T) {
+	dialer, err := kafkaDialer(KafkaOptions{Brokers: "b-1:9092", Topic: "events", GroupID: "nth"})
+	h.Ok(t, err)
+	h.Assert(t, dialer.TLS == nil, "Expected a plaintext dialer")
+	h.Assert(t, dialer.SASLMechanism == nil, "Expected a dialer without SASL")
+}
+
+func TestKafkaDialerTLSAndSASL(t *testing.T) {
+	for _, mechanism := range []string{"PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512"} {
+		dialer, err := kafkaDialer(KafkaOptions{TLS: true, SASLMechanism: mechanism, SASLUsername: "nth", SASLPassword: "secret"})
+		h.Ok(t, err)
+		h.Assert(t, dialer.TLS != nil && dialer.TLS.RootCAs == nil, "Expected a TLS dialer verifying with the system CAs")
+		h.Equals(t, mechanism, dialer.SASLMechanism.Name())
+	}
+
+	_, err := kafkaDialer(KafkaOptions{SASLMechanism: "GSSAPI"})
+	h.Assert(t, err != nil, "Expected an error for an unsupported SASL mechanism")
+}
+
+func TestKafkaDialerCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	h.Ok(t, ioutil.WriteFile(caFile, []byte("not a certificate"), 0600))
+	_, err := kafkaDialer(KafkaOptions{TLS: true, TLSCAFile: caFile})
+	h.Assert(t, err != nil, "Expected an error for a CA file without certificates")
+
+	_, err = kafkaDialer(KafkaOptions{TLS: true, TLSCAFile: filepath.Join(t.TempDir(), "missing.crt")})
+	h.Assert(t, err != nil, "Expected an error for a missing CA file")
+}
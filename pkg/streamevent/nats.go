@@ -0,0 +1,216 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package streamevent
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	natsDialTimeout = 10 * time.Second
+	// natsPullExpiry is how long a JetStream pull request or a core NATS subscription waits for a message
+	natsPullExpiry = 30 * time.Second
+	// natsAckWait is how long JetStream waits for the acknowledgement of a message of the consumers NTH creates, before
+	// it delivers the message again. NTH reports the messages whose events are handled as in progress meanwhile.
+	natsAckWait = time.Minute
+	// natsMaxDeliver bounds the deliveries of a message whose event is never handled, e.g. because it was canceled
+	natsMaxDeliver = 10
+	// natsMaxHandlingTime bounds how long a message is reported as in progress
+	natsMaxHandlingTime = time.Hour
+)
+
+// NATSOptions configures the consumer of a NATS subject or JetStream stream
+type NATSOptions struct {
+	// Servers are the comma separated server URLs, e.g. nats://nats:4222. tls:// URLs connect over TLS.
+	Servers string
+	// Subject is subscribed to, or filters the messages of the stream if set in JetStream mode
+	Subject string
+	// Stream is the JetStream stream to consume with a durable pull consumer, core NATS is used if empty
+	Stream string
+	// ConsumerName is the durable consumer in JetStream mode and the queue group in core NATS mode, which replicas share
+	ConsumerName string
+	// TLSCAFile verifies the server certificates, the system CAs are used if empty
+	TLSCAFile string
+	Token     string
+	Username  string
+	Password  string
+}
+
+// NATSSource consumes a NATS subject, or a JetStream stream through a durable pull consumer. JetStream messages are
+// acknowledged once their event is handled, i.e. its node was drained or the event was skipped, so events are
+// redelivered to another replica if the handler fails before.
+type NATSSource struct {
+	Options NATSOptions
+}
+
+// natsMessage is the part of a JetStream message the acknowledgements need
+type natsMessage interface {
+	Ack(opts ...nats.AckOpt) error
+	Nak(opts ...nats.AckOpt) error
+	InProgress(opts ...nats.AckOpt) error
+}
+
+// Consume connects to the servers and passes the messages to handle until the connection fails
+func (s NATSSource) Consume(handle func(event []byte, ack func()) error) error {
+	conn, err := nats.Connect(s.Options.Servers, s.connectOptions()...)
+	if err != nil {
+		return fmt.Errorf("Unable to connect to NATS: %w", err)
+	}
+	defer conn.Close()
+	if s.Options.Stream != "" {
+		return s.consumeStream(conn, handle)
+	}
+	subscription, err := conn.QueueSubscribeSync(s.Options.Subject, s.Options.ConsumerName)
+	if err != nil {
+		return fmt.Errorf("Unable to subscribe to the NATS subject %s: %w", s.Options.Subject, err)
+	}
+	log.Info().Str("subject", s.Options.Subject).Msg("Subscribed to the NATS subject")
+	for {
+		message, err := subscription.NextMsg(natsPullExpiry)
+		if errors.Is(err, nats.ErrTimeout) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(message.Data) == 0 {
+			continue
+		}
+		if err := handle(message.Data, nil); err != nil {
+			// core NATS doesn't deliver messages again
+			log.Err(err).Str("subject", s.Options.Subject).Msg("Dropping the NATS message")
+		}
+	}
+}
+
+// consumeStream pulls the messages of the durable consumer one at a time. They are acknowledged once their event is
+// handled, and reported as in progress until then.
+func (s NATSSource) consumeStream(conn *nats.Conn, handle func(event []byte, ack func()) error) error {
+	js, err := conn.JetStream()
+	if err != nil {
+		return err
+	}
+	consumer, err := s.consumer(js)
+	if err != nil {
+		return err
+	}
+	subscription, err := js.PullSubscribe(consumer.Config.FilterSubject, s.Options.ConsumerName, nats.Bind(s.Options.Stream, s.Options.ConsumerName))
+	if err != nil {
+		return fmt.Errorf("Unable to subscribe to the JetStream consumer %s: %w", s.Options.ConsumerName, err)
+	}
+	log.Info().Str("stream", s.Options.Stream).Str("consumer", s.Options.ConsumerName).Msg("Consuming the JetStream stream")
+	for {
+		messages, err := subscription.Fetch(1, nats.MaxWait(natsPullExpiry))
+		if errors.Is(err, nats.ErrTimeout) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("JetStream pull request for consumer %s failed: %w", s.Options.ConsumerName, err)
+		}
+		for _, message := range messages {
+			handleStreamMessage(message, message.Data, consumer.Config.AckWait, handle)
+		}
+	}
+}
+
+// handleStreamMessage passes the message to handle, which acknowledges it once its event is handled. Messages which
+// fail to be handled are delivered again.
+func handleStreamMessage(message natsMessage, data []byte, ackWait time.Duration, handle func(event []byte, ack func()) error) {
+	handled := make(chan struct{})
+	var once sync.Once
+	ack := func() {
+		once.Do(func() {
+			close(handled)
+			if err := message.Ack(); err != nil {
+				log.Warn().Err(err).Msg("Unable to acknowledge the JetStream message, it is delivered again")
+			}
+		})
+	}
+	if err := handle(data, ack); err != nil {
+		log.Warn().Err(err).Msg("Unable to handle the JetStream message, it is delivered again")
+		once.Do(func() { close(handled) })
+		if err := message.Nak(); err != nil {
+			log.Warn().Err(err).Msg("Unable to reject the JetStream message")
+		}
+		return
+	}
+	go reportInProgress(message, ackWait/2, handled)
+}
+
+// reportInProgress keeps JetStream from delivering the message again while its event is handled
+func reportInProgress(message natsMessage, interval time.Duration, handled <-chan struct{}) {
+	if interval <= 0 {
+		interval = natsAckWait / 2
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.After(natsMaxHandlingTime)
+	for {
+		select {
+		case <-handled:
+			return
+		case <-deadline:
+			return
+		case <-ticker.C:
+			if err := message.InProgress(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// consumer returns the durable pull consumer, which is created if it does not exist yet. Existing consumers are used
+// as they are. New consumers start with the messages published after their creation.
+func (s NATSSource) consumer(js nats.JetStreamContext) (*nats.ConsumerInfo, error) {
+	info, err := js.ConsumerInfo(s.Options.Stream, s.Options.ConsumerName)
+	if err == nil {
+		return info, nil
+	}
+	if !errors.Is(err, nats.ErrConsumerNotFound) {
+		return nil, fmt.Errorf("Unable to get the JetStream consumer %s: %w", s.Options.ConsumerName, err)
+	}
+	info, err = js.AddConsumer(s.Options.Stream, &nats.ConsumerConfig{
+		Durable:       s.Options.ConsumerName,
+		AckPolicy:     nats.AckExplicitPolicy,
+		DeliverPolicy: nats.DeliverNewPolicy,
+		FilterSubject: s.Options.Subject,
+		AckWait:       natsAckWait,
+		MaxDeliver:    natsMaxDeliver,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create the JetStream consumer %s: %w", s.Options.ConsumerName, err)
+	}
+	return info, nil
+}
+
+func (s NATSSource) connectOptions() []nats.Option {
+	// the consumer reconnects with an exponential backoff once the connection failed
+	options := []nats.Option{nats.Name("aws-node-termination-handler"), nats.Timeout(natsDialTimeout), nats.DontRandomize(), nats.NoReconnect()}
+	if s.Options.TLSCAFile != "" {
+		options = append(options, nats.RootCAs(s.Options.TLSCAFile))
+	}
+	if s.Options.Token != "" {
+		options = append(options, nats.Token(s.Options.Token))
+	}
+	if s.Options.Username != "" {
+		options = append(options, nats.UserInfo(s.Options.Username, s.Options.Password))
+	}
+	return options
+}
@@ -0,0 +1,114 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package streamevent_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/streamevent"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+// fakeNATSServer accepts a single connection and runs the script against it
+func fakeNATSServer(t *testing.T, script func(reader *bufio.Reader, conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	h.Ok(t, err)
+	go func() {
+		defer listener.Close()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"headers\":true}\r\n")
+		script(bufio.NewReader(conn), conn)
+	}()
+	return "nats://" + listener.Addr().String()
+}
+
+// expect reads a protocol line and returns its fields, failing if it does not start with the command
+func expect(t *testing.T, reader *bufio.Reader, command string) []string {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Errorf("expected %s: %v", command, err)
+		return nil
+	}
+	if !strings.HasPrefix(line, command) {
+		t.Errorf("expected %s, got %q", command, line)
+	}
+	return strings.Fields(line)
+}
+
+// check reports a failure without stopping the fake server
+func check(t *testing.T, condition bool, msg string) {
+	if !condition {
+		t.Error(msg)
+	}
+}
+
+// accept completes the handshake of the client
+func accept(t *testing.T, reader *bufio.Reader, conn net.Conn) string {
+	connect := expect(t, reader, "CONNECT")
+	expect(t, reader, "PING")
+	fmt.Fprint(conn, "PONG\r\n")
+	return strings.Join(connect[1:], " ")
+}
+
+func TestNATSSubject(t *testing.T) {
+	var connect string
+	received := make(chan struct{})
+	server := fakeNATSServer(t, func(reader *bufio.Reader, conn net.Conn) {
+		connect = accept(t, reader, conn)
+		check(t, strings.Join(expect(t, reader, "SUB"), " ") == "SUB aws.events nth 1", "Expected a queue subscription")
+		fmt.Fprint(conn, "PING\r\n")
+		expect(t, reader, "PONG")
+		fmt.Fprint(conn, "MSG aws.events 1 10\r\n{\"id\":\"1\"}\r\n")
+		headers := "NATS/1.0\r\nKey: value\r\n\r\n"
+		fmt.Fprintf(conn, "HMSG aws.events 1 _INBOX.reply %d %d\r\n%s{\"id\":\"2\"}\r\n", len(headers), len(headers)+10, headers)
+		// pending messages are discarded once the connection is closed
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("expected the messages to be received")
+		}
+	})
+	var events []string
+	source := streamevent.NATSSource{Options: streamevent.NATSOptions{Servers: server, Subject: "aws.events", ConsumerName: "nth", Token: "s3cr3t"}}
+	err := source.Consume(func(event []byte, ack func()) error {
+		check(t, ack == nil, "Expected core NATS messages without acknowledgements")
+		events = append(events, string(event))
+		if len(events) == 2 {
+			close(received)
+		}
+		return nil
+	})
+	h.Assert(t, err != nil, "Expected an error once the server closed the connection")
+	h.Equals(t, []string{`{"id":"1"}`, `{"id":"2"}`}, events)
+	h.Assert(t, strings.Contains(connect, `"auth_token":"s3cr3t"`), "Expected the token in the CONNECT options")
+}
+
+func TestNATSAuthorizationViolation(t *testing.T) {
+	server := fakeNATSServer(t, func(reader *bufio.Reader, conn net.Conn) {
+		expect(t, reader, "CONNECT")
+		expect(t, reader, "PING")
+		fmt.Fprint(conn, "-ERR 'Authorization Violation'\r\n")
+	})
+	err := streamevent.NATSSource{Options: streamevent.NATSOptions{Servers: server, Subject: "aws.events"}}.Consume(func(event []byte, ack func()) error { return nil })
+	h.Assert(t, err != nil && strings.Contains(strings.ToLower(err.Error()), "authorization violation"), "Expected the server error")
+}
@@ -0,0 +1,139 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package streamevent
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maxEventSize       = 256 * 1024
+	maxProcessAttempts = 3
+	minRestartDelay    = time.Second
+	maxRestartDelay    = time.Minute
+)
+
+var processRetryDelay = 2 * time.Second
+
+// EventProcessor returns the interruption events for an Amazon EventBridge event, none if it does not need to be handled
+type EventProcessor interface {
+	ProcessEvents(body []byte) ([]monitor.InterruptionEvent, error)
+}
+
+// Source delivers the Amazon EventBridge events of a stream to handle, until the stream fails. Events handle returns an
+// error for are delivered again. Sources which acknowledge events once they are handled pass an ack function, which is
+// called after the node of the event was drained or the event was skipped.
+type Source interface {
+	Consume(handle func(event []byte, ack func()) error) error
+}
+
+// Consumer consumes an event stream, e.g. a Kafka topic or a NATS subject, and sends the interruption events to the
+// interruption channel
+type Consumer struct {
+	// Name identifies the stream in logs, e.g. kafka
+	Name             string
+	Source           Source
+	Processor        EventProcessor
+	InterruptionChan chan<- monitor.InterruptionEvent
+}
+
+// Run consumes the stream, reconnecting with an exponential backoff whenever it fails
+func (c Consumer) Run() {
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		err := c.Source.Consume(c.handle)
+		if time.Since(started) > maxRestartDelay {
+			delay = minRestartDelay
+		}
+		log.Warn().Err(err).Str("stream", c.Name).Msgf("Consuming the stream failed, restarting in %s", delay)
+		time.Sleep(delay)
+		delay *= 2
+		if delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+	}
+}
+
+// handle sends the interruption events of the Amazon EventBridge event to the interruption channel. Events the EC2 API
+// was unavailable for are retried a few times before an error is returned, so the source delivers them again. Events
+// which are not handled are acknowledged right away, the others by the post-drain task of their last interruption event.
+func (c Consumer) handle(event []byte, ack func()) error {
+	for attempt := 1; ; attempt++ {
+		interruptionEvents, err := c.Processor.ProcessEvents(event)
+		interruptionEvents = sqsTerminateEvents(interruptionEvents)
+		switch {
+		case errors.Is(err, sqsevent.ErrUnsupportedEvent):
+			log.Warn().Err(err).Str("stream", c.Name).Msg("Skipping unsupported stream event")
+		case errors.Is(err, sqsevent.ErrNodeStateNotRunning):
+			log.Warn().Err(err).Str("stream", c.Name).Msg("dropping stream event for an already terminated node")
+		case errors.Is(err, sqsevent.ErrClusterNotServed):
+			log.Debug().Err(err).Str("stream", c.Name).Msg("dropping stream event for an instance of a cluster which is not served")
+		case err != nil && attempt < maxProcessAttempts:
+			log.Warn().Err(err).Str("stream", c.Name).Msgf("Unable to process stream event, retrying in %s", processRetryDelay)
+			time.Sleep(processRetryDelay)
+			continue
+		case err != nil:
+			return fmt.Errorf("Unable to process stream event: %w", err)
+		case len(interruptionEvents) == 0:
+		default:
+			if ack != nil {
+				for i := range interruptionEvents {
+					interruptionEvents[i].PostDrainTask = acknowledgeAfter(interruptionEvents[i].PostDrainTask, ack)
+				}
+				monitor.CompleteTogether(interruptionEvents)
+			}
+			for _, interruptionEvent := range interruptionEvents {
+				log.Debug().Str("stream", c.Name).Str("event_id", interruptionEvent.EventID).Msg("Sending stream interruption event to the interruption channel")
+				c.InterruptionChan <- interruptionEvent
+			}
+			return nil
+		}
+		if ack != nil {
+			ack()
+		}
+		return nil
+	}
+}
+
+// sqsTerminateEvents returns the events which drain their node
+func sqsTerminateEvents(interruptionEvents []monitor.InterruptionEvent) []monitor.InterruptionEvent {
+	var terminateEvents []monitor.InterruptionEvent
+	for _, interruptionEvent := range interruptionEvents {
+		if interruptionEvent.Kind == sqsevent.SQSTerminateKind {
+			terminateEvents = append(terminateEvents, interruptionEvent)
+		}
+	}
+	return terminateEvents
+}
+
+// acknowledgeAfter returns a post-drain task which runs the task and acknowledges the event once it succeeded
+func acknowledgeAfter(task monitor.DrainTask, ack func()) monitor.DrainTask {
+	return func(interruptionEvent monitor.InterruptionEvent, n node.Node) error {
+		if task != nil {
+			if err := task(interruptionEvent, n); err != nil {
+				return err
+			}
+		}
+		ack()
+		return nil
+	}
+}
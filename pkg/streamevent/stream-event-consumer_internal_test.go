@@ -0,0 +1,197 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package streamevent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/nats-io/nats.go"
+)
+
+type fakeProcessor struct {
+	bodies []string
+}
+
+func (p *fakeProcessor) ProcessEvents(body []byte) ([]monitor.InterruptionEvent, error) {
+	p.bodies = append(p.bodies, string(body))
+	switch string(body) {
+	case "unsupported":
+		return nil, sqsevent.ErrUnsupportedEvent
+	case "ignored":
+		return nil, nil
+	case "other-cluster":
+		return nil, sqsevent.ErrClusterNotServed
+	case "failing":
+		return nil, fmt.Errorf("EC2 API unavailable")
+	case "several-instances":
+		return []monitor.InterruptionEvent{
+			{EventID: "several-instances-1", Kind: sqsevent.SQSTerminateKind},
+			{EventID: "several-instances-2", Kind: sqsevent.SQSTerminateKind},
+		}, nil
+	}
+	return []monitor.InterruptionEvent{{EventID: string(body), Kind: sqsevent.SQSTerminateKind}}, nil
+}
+
+// fakeSource delivers its events, and stops at the first event handle returns an error for like a real source. Events
+// are acknowledged if acked is set.
+type fakeSource struct {
+	events []string
+	acked  *[]string
+}
+
+func (s fakeSource) Consume(handle func(event []byte, ack func()) error) error {
+	for _, event := range s.events {
+		var ack func()
+		if s.acked != nil {
+			event := event
+			ack = func() { *s.acked = append(*s.acked, event) }
+		}
+		if err := handle([]byte(event), ack); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("stream ended")
+}
+
+func TestConsume(t *testing.T) {
+	processor := &fakeProcessor{}
+	interruptionChan := make(chan monitor.InterruptionEvent, 2)
+	consumer := Consumer{
+		Name:             "test",
+		Source:           fakeSource{events: []string{"event-1", "unsupported", "ignored", "event-2"}},
+		Processor:        processor,
+		InterruptionChan: interruptionChan,
+	}
+	err := consumer.Source.Consume(consumer.handle)
+	h.Assert(t, err != nil && strings.Contains(err.Error(), "ended"), "Expected the source to report that the stream ended")
+	h.Equals(t, []string{"event-1", "unsupported", "ignored", "event-2"}, processor.bodies)
+	h.Equals(t, "event-1", (<-interruptionChan).EventID)
+	h.Equals(t, "event-2", (<-interruptionChan).EventID)
+}
+
+func TestConsumeFailedEventIsNotDropped(t *testing.T) {
+	processRetryDelay = time.Millisecond
+	defer func() { processRetryDelay = 2 * time.Second }()
+	processor := &fakeProcessor{}
+	consumer := Consumer{
+		Name:             "test",
+		Source:           fakeSource{events: []string{"failing", "event-1"}},
+		Processor:        processor,
+		InterruptionChan: make(chan monitor.InterruptionEvent, 1),
+	}
+	err := consumer.Source.Consume(consumer.handle)
+	h.Assert(t, err != nil && strings.Contains(err.Error(), "Unable to process"), "Expected the failed event to be returned as error")
+	h.Equals(t, []string{"failing", "failing", "failing"}, processor.bodies)
+}
+
+func TestConsumeAcknowledgesEventsOnceHandled(t *testing.T) {
+	acked := []string{}
+	interruptionChan := make(chan monitor.InterruptionEvent, 2)
+	consumer := Consumer{
+		Name:             "test",
+		Source:           fakeSource{events: []string{"event-1", "unsupported", "ignored", "other-cluster"}, acked: &acked},
+		Processor:        &fakeProcessor{},
+		InterruptionChan: interruptionChan,
+	}
+	_ = consumer.Source.Consume(consumer.handle)
+	// events which are not handled are acknowledged right away
+	h.Equals(t, []string{"unsupported", "ignored", "other-cluster"}, acked)
+
+	event := <-interruptionChan
+	h.Ok(t, event.PostDrainTask(event, node.Node{}))
+	h.Equals(t, []string{"unsupported", "ignored", "other-cluster", "event-1"}, acked)
+}
+
+func TestConsumeAcknowledgesEventOfSeveralInstancesOnceAllAreHandled(t *testing.T) {
+	acked := []string{}
+	interruptionChan := make(chan monitor.InterruptionEvent, 2)
+	consumer := Consumer{
+		Name:             "test",
+		Source:           fakeSource{events: []string{"several-instances"}, acked: &acked},
+		Processor:        &fakeProcessor{},
+		InterruptionChan: interruptionChan,
+	}
+	_ = consumer.Source.Consume(consumer.handle)
+	first, second := <-interruptionChan, <-interruptionChan
+	h.Equals(t, "several-instances-1", first.EventID)
+	h.Equals(t, "several-instances-2", second.EventID)
+
+	h.Ok(t, first.PostDrainTask(first, node.Node{}))
+	h.Equals(t, 0, len(acked))
+	h.Ok(t, second.PostDrainTask(second, node.Node{}))
+	h.Equals(t, []string{"several-instances"}, acked)
+}
+
+func TestHandleStreamMessage(t *testing.T) {
+	message := &fakeNATSMessage{}
+	var ack func()
+	handleStreamMessage(message, []byte("event-1"), 20*time.Millisecond, func(event []byte, eventAck func()) error {
+		ack = eventAck
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+	h.Assert(t, message.count("in-progress") > 0, "Expected the message to be reported in progress while it is handled")
+	h.Equals(t, 0, message.count("ack"))
+
+	ack()
+	ack()
+	h.Equals(t, 1, message.count("ack"))
+	time.Sleep(20 * time.Millisecond)
+	inProgress := message.count("in-progress")
+	time.Sleep(30 * time.Millisecond)
+	h.Equals(t, inProgress, message.count("in-progress"))
+
+	failing := &fakeNATSMessage{}
+	handleStreamMessage(failing, []byte("event-2"), time.Minute, func(event []byte, eventAck func()) error {
+		return fmt.Errorf("EC2 API unavailable")
+	})
+	h.Equals(t, 1, failing.count("nak"))
+	h.Equals(t, 0, failing.count("ack"))
+}
+
+type fakeNATSMessage struct {
+	sync.Mutex
+	calls []string
+}
+
+func (m *fakeNATSMessage) record(call string) error {
+	m.Lock()
+	defer m.Unlock()
+	m.calls = append(m.calls, call)
+	return nil
+}
+
+func (m *fakeNATSMessage) count(call string) int {
+	m.Lock()
+	defer m.Unlock()
+	count := 0
+	for _, c := range m.calls {
+		if c == call {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *fakeNATSMessage) Ack(...nats.AckOpt) error        { return m.record("ack") }
+func (m *fakeNATSMessage) Nak(...nats.AckOpt) error        { return m.record("nak") }
+func (m *fakeNATSMessage) InProgress(...nats.AckOpt) error { return m.record("in-progress") }
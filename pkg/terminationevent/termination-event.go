@@ -0,0 +1,257 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package terminationevent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/node"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// Group is the API group of the TerminationEvent custom resource
+	Group = "nodeterminationhandler.aws.amazon.com"
+	// Version is the API version of the TerminationEvent custom resource
+	Version = "v1alpha1"
+	// Resource is the plural resource name of the TerminationEvent custom resource
+	Resource = "terminationevents"
+	// Kind is the kind of the TerminationEvent custom resource
+	Kind = "TerminationEvent"
+
+	maxNameLength = 253
+)
+
+// Phases of a TerminationEvent
+const (
+	// PhaseDraining is set while the event is handled
+	PhaseDraining = "Draining"
+	// PhaseSucceeded is set once the event was handled
+	PhaseSucceeded = "Succeeded"
+	// PhaseFailed is set when handling the event failed. The event is handled again after a backoff, up to
+	// drain-max-attempts times, which resets the phase to PhaseDraining.
+	PhaseFailed = "Failed"
+	// PhaseAborted is set when a hook aborted handling the event
+	PhaseAborted = "Aborted"
+)
+
+// GroupVersionResource identifies the TerminationEvent custom resource
+var GroupVersionResource = schema.GroupVersionResource{Group: Group, Version: Version, Resource: Resource}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Spec describes the interruption event of a TerminationEvent
+type Spec struct {
+	EventID     string `json:"eventId"`
+	Kind        string `json:"kind"`
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description,omitempty"`
+	NodeName    string `json:"nodeName"`
+	InstanceID  string `json:"instanceId,omitempty"`
+	Deadline    string `json:"deadline,omitempty"`
+}
+
+// Status describes the handling of the interruption event of a TerminationEvent
+type Status struct {
+	Phase string `json:"phase"`
+	// Action is the action decided for the node, e.g. cordon-and-drain, or why none was taken, e.g. skip-karpenter
+	Action          string            `json:"action,omitempty"`
+	StartedAt       string            `json:"startedAt,omitempty"`
+	CompletedAt     string            `json:"completedAt,omitempty"`
+	DurationSeconds int64             `json:"durationSeconds,omitempty"`
+	PodsEvicted     int               `json:"podsEvicted,omitempty"`
+	Checkpoints     map[string]string `json:"checkpoints,omitempty"`
+	// EvictionFailures counts the failed eviction and pod deletion attempts by reason
+	EvictionFailures map[string]int `json:"evictionFailures,omitempty"`
+	// Rescheduling is recovered or unrecovered when the workloads of the drained pods were verified after the drain
+	Rescheduling         string   `json:"rescheduling,omitempty"`
+	UnrecoveredWorkloads []string `json:"unrecoveredWorkloads,omitempty"`
+	// DrainDurationMilliseconds is how long the action on the node, e.g. the cordon and drain, took
+	DrainDurationMilliseconds int64 `json:"drainDurationMilliseconds,omitempty"`
+	// Hooks has the outcome of the hooks run for the event by phase, e.g. pre-drain: succeeded
+	Hooks  map[string]string `json:"hooks,omitempty"`
+	Errors []string          `json:"errors,omitempty"`
+}
+
+// Recorder records handled interruption events as TerminationEvent custom resources
+type Recorder struct {
+	dynamic dynamic.Interface
+	now     func() time.Time
+}
+
+// InitRecorder returns a recorder for TerminationEvents, which does nothing if it is not enabled
+func InitRecorder(enabled bool, clientConfig func() (*rest.Config, error)) (Recorder, error) {
+	if !enabled {
+		return Recorder{}, nil
+	}
+	config, err := clientConfig()
+	if err != nil {
+		return Recorder{}, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return Recorder{}, err
+	}
+	return NewRecorder(dynamicClient), nil
+}
+
+// RequiredPermissions returns the kubernetes api permissions the recorder needs to create TerminationEvents, which are
+// none if it is not enabled
+func RequiredPermissions(enabled bool) []node.Permission {
+	if !enabled {
+		return nil
+	}
+	return []node.Permission{
+		{Verb: "create", Group: Group, Resource: Resource},
+		{Verb: "patch", Group: Group, Resource: Resource, Subresource: "status"},
+	}
+}
+
+// NewRecorder returns a recorder creating TerminationEvents with the dynamic client
+func NewRecorder(dynamicClient dynamic.Interface) Recorder {
+	return Recorder{dynamic: dynamicClient, now: time.Now}
+}
+
+// Name returns the TerminationEvent name of an interruption event
+func Name(eventID string) string {
+	name := strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(eventID), "-"), "-.")
+	if len(name) > maxNameLength {
+		name = strings.Trim(name[:maxNameLength], "-.")
+	}
+	return name
+}
+
+// Start creates the TerminationEvent of the interruption event in the draining phase and returns the time handling
+// the event started. The status of an existing TerminationEvent, whose handling is retried, is reset to the draining phase.
+func (r Recorder) Start(event monitor.InterruptionEvent) time.Time {
+	if r.dynamic == nil {
+		return time.Time{}
+	}
+	startedAt := r.now()
+	status := Status{Phase: PhaseDraining, StartedAt: startedAt.UTC().Format(time.RFC3339)}
+	object, err := toUnstructured(event, status)
+	if err != nil {
+		log.Warn().Err(err).Str("event_id", event.EventID).Msg("Unable to build the TerminationEvent")
+		return startedAt
+	}
+	_, err = r.dynamic.Resource(GroupVersionResource).Create(context.TODO(), object, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		log.Warn().Err(err).Str("event_id", event.EventID).Msg("Unable to record the TerminationEvent")
+		return startedAt
+	}
+	// the status subresource is ignored when creating the object, and results of earlier attempts are cleared
+	err = r.patchStatus(event, map[string]interface{}{
+		"phase":                     status.Phase,
+		"action":                    nil,
+		"startedAt":                 status.StartedAt,
+		"completedAt":               nil,
+		"durationSeconds":           nil,
+		"podsEvicted":               nil,
+		"checkpoints":               nil,
+		"evictionFailures":          nil,
+		"rescheduling":              nil,
+		"unrecoveredWorkloads":      nil,
+		"drainDurationMilliseconds": nil,
+		"hooks":                     nil,
+		"errors":                    nil,
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("event_id", event.EventID).Msg("Unable to update the TerminationEvent status")
+	}
+	return startedAt
+}
+
+// Finish sets the final phase of the TerminationEvent of the interruption event and the action decided for its node,
+// with the error of a failed or aborted handling
+func (r Recorder) Finish(event monitor.InterruptionEvent, phase string, action string, startedAt time.Time, handlingErr error) {
+	if r.dynamic == nil {
+		return
+	}
+	completedAt := r.now()
+	status := Status{
+		Phase:           phase,
+		Action:          action,
+		StartedAt:       startedAt.UTC().Format(time.RFC3339),
+		CompletedAt:     completedAt.UTC().Format(time.RFC3339),
+		DurationSeconds: int64(completedAt.Sub(startedAt).Seconds()),
+	}
+	if phase == PhaseSucceeded {
+		status.PodsEvicted = len(event.Pods)
+	}
+	status.Checkpoints = event.Checkpoints
+	status.EvictionFailures = event.EvictionFailures
+	status.Rescheduling = event.Rescheduling
+	status.UnrecoveredWorkloads = event.UnrecoveredWorkloads
+	status.DrainDurationMilliseconds = event.DrainDuration.Milliseconds()
+	status.Hooks = event.HookOutcomes
+	if handlingErr != nil {
+		status.Errors = []string{handlingErr.Error()}
+	}
+	err := r.patchStatus(event, status)
+	if err != nil {
+		log.Warn().Err(err).Str("event_id", event.EventID).Msg("Unable to update the TerminationEvent status")
+	}
+}
+
+func (r Recorder) patchStatus(event monitor.InterruptionEvent, status interface{}) error {
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return err
+	}
+	_, err = r.dynamic.Resource(GroupVersionResource).Patch(context.TODO(), Name(event.EventID), types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	return err
+}
+
+func toUnstructured(event monitor.InterruptionEvent, status Status) (*unstructured.Unstructured, error) {
+	spec := Spec{
+		EventID:     event.EventID,
+		Kind:        event.Kind,
+		Code:        event.Code,
+		Description: event.Description,
+		NodeName:    event.NodeName,
+		InstanceID:  event.InstanceID,
+	}
+	if !event.StartTime.IsZero() {
+		spec.Deadline = event.StartTime.UTC().Format(time.RFC3339)
+	}
+	content, err := json.Marshal(map[string]interface{}{
+		"apiVersion": Group + "/" + Version,
+		"kind":       Kind,
+		"metadata":   map[string]interface{}{"name": Name(event.EventID)},
+		"spec":       spec,
+		"status":     status,
+	})
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{}
+	err = object.UnmarshalJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to convert the TerminationEvent: %w", err)
+	}
+	return object, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package terminationevent_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/terminationevent"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+var event = monitor.InterruptionEvent{
+	EventID:    "spot-itn-4b6e7e1a",
+	Kind:       "SPOT_ITN",
+	NodeName:   "ip-10-0-0-1.ec2.internal",
+	InstanceID: "i-0123456789",
+	StartTime:  time.Date(2021, time.June, 5, 8, 0, 0, 0, time.UTC),
+	Pods:       []string{"web-1", "web-2"},
+}
+
+func newFakeClient() *fake.FakeDynamicClient {
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		terminationevent.GroupVersionResource: "TerminationEventList",
+	})
+}
+
+func getTerminationEvent(t *testing.T, client *fake.FakeDynamicClient, eventID string) *unstructured.Unstructured {
+	object, err := client.Resource(terminationevent.GroupVersionResource).Get(context.Background(), terminationevent.Name(eventID), metav1.GetOptions{})
+	h.Ok(t, err)
+	return object
+}
+
+func TestName(t *testing.T) {
+	h.Equals(t, "spot-itn-4b6e7e1a", terminationevent.Name("spot-itn-4b6e7e1a"))
+	h.Equals(t, "plugin-gpu-xid-79", terminationevent.Name("plugin-GPU_XID/79"))
+}
+
+func TestRequiredPermissions(t *testing.T) {
+	h.Equals(t, 0, len(terminationevent.RequiredPermissions(false)))
+	permissions := terminationevent.RequiredPermissions(true)
+	h.Equals(t, 2, len(permissions))
+	h.Equals(t, "create terminationevents.nodeterminationhandler.aws.amazon.com", permissions[0].String())
+	h.Equals(t, "patch terminationevents.nodeterminationhandler.aws.amazon.com/status", permissions[1].String())
+}
+
+func TestDisabledRecorder(t *testing.T) {
+	recorder, err := terminationevent.InitRecorder(false, nil)
+	h.Ok(t, err)
+	startedAt := recorder.Start(event)
+	recorder.Finish(event, terminationevent.PhaseSucceeded, "cordon-and-drain", startedAt, nil)
+}
+
+func TestStartAndFinish(t *testing.T) {
+	client := newFakeClient()
+	recorder := terminationevent.NewRecorder(client)
+
+	startedAt := recorder.Start(event)
+	object := getTerminationEvent(t, client, event.EventID)
+	kind, _, _ := unstructured.NestedString(object.Object, "spec", "kind")
+	h.Equals(t, event.Kind, kind)
+	deadline, _, _ := unstructured.NestedString(object.Object, "spec", "deadline")
+	h.Equals(t, "2021-06-05T08:00:00Z", deadline)
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	h.Equals(t, terminationevent.PhaseDraining, phase)
+
+	recorder.Finish(event, terminationevent.PhaseSucceeded, "cordon-and-drain", startedAt, nil)
+	object = getTerminationEvent(t, client, event.EventID)
+	phase, _, _ = unstructured.NestedString(object.Object, "status", "phase")
+	h.Equals(t, terminationevent.PhaseSucceeded, phase)
+	podsEvicted, _, _ := unstructured.NestedFieldNoCopy(object.Object, "status", "podsEvicted")
+	h.Equals(t, int64(2), podsEvicted)
+}
+
+func TestFinishFailedAndRetry(t *testing.T) {
+	client := newFakeClient()
+	recorder := terminationevent.NewRecorder(client)
+
+	startedAt := recorder.Start(event)
+	recorder.Finish(event, terminationevent.PhaseFailed, "cordon-and-drain", startedAt, errors.New("eviction blocked"))
+	object := getTerminationEvent(t, client, event.EventID)
+	errs, _, _ := unstructured.NestedStringSlice(object.Object, "status", "errors")
+	h.Equals(t, []string{"eviction blocked"}, errs)
+
+	recorder.Start(event)
+	object = getTerminationEvent(t, client, event.EventID)
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	h.Equals(t, terminationevent.PhaseDraining, phase)
+	_, found, _ := unstructured.NestedStringSlice(object.Object, "status", "errors")
+	h.Assert(t, !found, "Expected the errors of the failed attempt to be cleared")
+}
+
+func TestFinishRecordsCheckpoints(t *testing.T) {
+	client := newFakeClient()
+	recorder := terminationevent.NewRecorder(client)
+	checkpointed := event
+	checkpointed.Checkpoints = map[string]string{"default/web-1/app": "/var/lib/kubelet/checkpoints/checkpoint-web-1_default-app.tar"}
+
+	startedAt := recorder.Start(checkpointed)
+	recorder.Finish(checkpointed, terminationevent.PhaseSucceeded, "cordon-and-drain", startedAt, nil)
+	object := getTerminationEvent(t, client, event.EventID)
+	checkpoints, _, _ := unstructured.NestedStringMap(object.Object, "status", "checkpoints")
+	h.Equals(t, checkpointed.Checkpoints, checkpoints)
+}
+
+func TestFinishRecordsEvictionFailures(t *testing.T) {
+	client := newFakeClient()
+	recorder := terminationevent.NewRecorder(client)
+	failed := event
+	failed.EvictionFailures = map[string]int{"pdb": 3, "timeout": 1}
+
+	startedAt := recorder.Start(failed)
+	recorder.Finish(failed, terminationevent.PhaseFailed, "cordon-and-drain", startedAt, errors.New("drain timed out"))
+	object := getTerminationEvent(t, client, event.EventID)
+	evictionFailures, _, _ := unstructured.NestedMap(object.Object, "status", "evictionFailures")
+	h.Equals(t, map[string]interface{}{"pdb": int64(3), "timeout": int64(1)}, evictionFailures)
+}
+
+func TestFinishRecordsSummary(t *testing.T) {
+	client := newFakeClient()
+	recorder := terminationevent.NewRecorder(client)
+	drained := event
+	drained.DrainDuration = 450 * time.Millisecond
+	drained.HookOutcomes = map[string]string{"pre-drain": "succeeded", "post-drain": "failed"}
+
+	startedAt := recorder.Start(drained)
+	recorder.Finish(drained, terminationevent.PhaseSucceeded, "cordon-and-drain", startedAt, nil)
+	object := getTerminationEvent(t, client, event.EventID)
+	action, _, _ := unstructured.NestedString(object.Object, "status", "action")
+	h.Equals(t, "cordon-and-drain", action)
+	drainDuration, _, _ := unstructured.NestedFieldNoCopy(object.Object, "status", "drainDurationMilliseconds")
+	h.Equals(t, int64(450), drainDuration)
+	hookOutcomes, _, _ := unstructured.NestedStringMap(object.Object, "status", "hooks")
+	h.Equals(t, drained.HookOutcomes, hookOutcomes)
+
+	recorder.Start(drained)
+	object = getTerminationEvent(t, client, event.EventID)
+	_, found, _ := unstructured.NestedStringMap(object.Object, "status", "hooks")
+	h.Assert(t, !found, "Expected the hooks of the earlier attempt to be cleared")
+}
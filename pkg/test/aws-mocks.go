@@ -14,70 +14,299 @@
 package test
 
 import (
-	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
-	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
-	"github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
 )
 
 // MockedSQS mocks the SQS API
 type MockedSQS struct {
-	sqsiface.SQSAPI
-	ReceiveMessageResp sqs.ReceiveMessageOutput
-	ReceiveMessageErr  error
-	DeleteMessageResp  sqs.DeleteMessageOutput
-	DeleteMessageErr   error
+	ReceiveMessageResp     sqs.ReceiveMessageOutput
+	ReceiveMessageErr      error
+	DeleteMessageResp      sqs.DeleteMessageOutput
+	DeleteMessageErr       error
+	GetQueueAttributesResp sqs.GetQueueAttributesOutput
+	GetQueueAttributesErr  error
 }
 
 // ReceiveMessage mocks the sqs.ReceiveMessage API call
-func (m MockedSQS) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+func (m MockedSQS) ReceiveMessage(ctx context.Context, input *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
 	return &m.ReceiveMessageResp, m.ReceiveMessageErr
 }
 
 // DeleteMessage mocks the sqs.DeleteMessage API call
-func (m MockedSQS) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+func (m MockedSQS) DeleteMessage(ctx context.Context, input *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
 	return &m.DeleteMessageResp, m.DeleteMessageErr
 }
 
+// GetQueueAttributes mocks the sqs.GetQueueAttributes API call
+func (m MockedSQS) GetQueueAttributes(ctx context.Context, input *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	return &m.GetQueueAttributesResp, m.GetQueueAttributesErr
+}
+
 // MockedEC2 mocks the EC2 API
 type MockedEC2 struct {
-	ec2iface.EC2API
 	DescribeInstancesResp ec2.DescribeInstancesOutput
 	DescribeInstancesErr  error
 }
 
 // DescribeInstances mocks the ec2.DescribeInstances API call
-func (m MockedEC2) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+func (m MockedEC2) DescribeInstances(ctx context.Context, input *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
 	return &m.DescribeInstancesResp, m.DescribeInstancesErr
 }
 
 // MockedASG mocks the autoscaling API
 type MockedASG struct {
-	autoscalingiface.AutoScalingAPI
 	CompleteLifecycleActionResp      autoscaling.CompleteLifecycleActionOutput
 	CompleteLifecycleActionErr       error
 	DescribeAutoScalingInstancesResp autoscaling.DescribeAutoScalingInstancesOutput
 	DescribeAutoScalingInstancesErr  error
-	DescribeTagsPagesResp            autoscaling.DescribeTagsOutput
-	DescribeTagsPagesErr             error
+	DescribeTagsResp                 autoscaling.DescribeTagsOutput
+	DescribeTagsErr                  error
+	DetachInstancesResp              autoscaling.DetachInstancesOutput
+	DetachInstancesErr               error
+	DescribeAutoScalingGroupsResp    autoscaling.DescribeAutoScalingGroupsOutput
+	DescribeAutoScalingGroupsErr     error
 }
 
 // CompleteLifecycleAction mocks the autoscaling.CompleteLifecycleAction API call
-func (m MockedASG) CompleteLifecycleAction(input *autoscaling.CompleteLifecycleActionInput) (*autoscaling.CompleteLifecycleActionOutput, error) {
+func (m MockedASG) CompleteLifecycleAction(ctx context.Context, input *autoscaling.CompleteLifecycleActionInput, optFns ...func(*autoscaling.Options)) (*autoscaling.CompleteLifecycleActionOutput, error) {
 	return &m.CompleteLifecycleActionResp, m.CompleteLifecycleActionErr
 }
 
 // DescribeAutoScalingInstances mocks the autoscaling.DescribeAutoScalingInstances API call
-func (m MockedASG) DescribeAutoScalingInstances(input *autoscaling.DescribeAutoScalingInstancesInput) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
+func (m MockedASG) DescribeAutoScalingInstances(ctx context.Context, input *autoscaling.DescribeAutoScalingInstancesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
 	return &m.DescribeAutoScalingInstancesResp, m.DescribeAutoScalingInstancesErr
 }
 
-type describeTagsPagesFn = func(page *autoscaling.DescribeTagsOutput, lastPage bool) bool
+// DetachInstances mocks the autoscaling.DetachInstances API call
+func (m MockedASG) DetachInstances(ctx context.Context, input *autoscaling.DetachInstancesInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DetachInstancesOutput, error) {
+	return &m.DetachInstancesResp, m.DetachInstancesErr
+}
+
+// DescribeAutoScalingGroups mocks the autoscaling.DescribeAutoScalingGroups API call
+func (m MockedASG) DescribeAutoScalingGroups(ctx context.Context, input *autoscaling.DescribeAutoScalingGroupsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return &m.DescribeAutoScalingGroupsResp, m.DescribeAutoScalingGroupsErr
+}
+
+// DescribeTags mocks the autoscaling.DescribeTags API call
+func (m MockedASG) DescribeTags(ctx context.Context, input *autoscaling.DescribeTagsInput, optFns ...func(*autoscaling.Options)) (*autoscaling.DescribeTagsOutput, error) {
+	return &m.DescribeTagsResp, m.DescribeTagsErr
+}
+
+// MockedSSM mocks the SSM API
+type MockedSSM struct {
+	GetParametersByPathResp  ssm.GetParametersByPathOutput
+	GetParametersByPathErr   error
+	SendCommandResp          ssm.SendCommandOutput
+	SendCommandErr           error
+	GetCommandInvocationResp ssm.GetCommandInvocationOutput
+	GetCommandInvocationErr  error
+	CancelCommandErr         error
+}
+
+// GetParametersByPath mocks the ssm.GetParametersByPath API call
+func (m MockedSSM) GetParametersByPath(ctx context.Context, input *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	return &m.GetParametersByPathResp, m.GetParametersByPathErr
+}
+
+// SendCommand mocks the ssm.SendCommand API call
+func (m MockedSSM) SendCommand(ctx context.Context, input *ssm.SendCommandInput, optFns ...func(*ssm.Options)) (*ssm.SendCommandOutput, error) {
+	return &m.SendCommandResp, m.SendCommandErr
+}
+
+// GetCommandInvocation mocks the ssm.GetCommandInvocation API call
+func (m MockedSSM) GetCommandInvocation(ctx context.Context, input *ssm.GetCommandInvocationInput, optFns ...func(*ssm.Options)) (*ssm.GetCommandInvocationOutput, error) {
+	return &m.GetCommandInvocationResp, m.GetCommandInvocationErr
+}
+
+// CancelCommand mocks the ssm.CancelCommand API call
+func (m MockedSSM) CancelCommand(ctx context.Context, input *ssm.CancelCommandInput, optFns ...func(*ssm.Options)) (*ssm.CancelCommandOutput, error) {
+	return &ssm.CancelCommandOutput{}, m.CancelCommandErr
+}
+
+// MockedSecretsManager mocks the Secrets Manager API
+type MockedSecretsManager struct {
+	GetSecretValueResp secretsmanager.GetSecretValueOutput
+	GetSecretValueErr  error
+}
+
+// GetSecretValue mocks the secretsmanager.GetSecretValue API call
+func (m MockedSecretsManager) GetSecretValue(ctx context.Context, input *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return &m.GetSecretValueResp, m.GetSecretValueErr
+}
+
+// MockedLambda mocks the Lambda API
+type MockedLambda struct {
+	InvokeResp lambda.InvokeOutput
+	InvokeErr  error
+}
+
+// Invoke mocks the lambda.Invoke API call
+func (m MockedLambda) Invoke(ctx context.Context, input *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error) {
+	return &m.InvokeResp, m.InvokeErr
+}
+
+// MockedSFN mocks the Step Functions API
+type MockedSFN struct {
+	SendTaskSuccessErr   error
+	SendTaskFailureErr   error
+	SendTaskHeartbeatErr error
+	// Calls receives the name of each called API, if set
+	Calls chan string
+}
+
+func (m MockedSFN) called(name string) {
+	if m.Calls != nil {
+		m.Calls <- name
+	}
+}
+
+// SendTaskSuccess mocks the sfn.SendTaskSuccess API call
+func (m MockedSFN) SendTaskSuccess(ctx context.Context, input *sfn.SendTaskSuccessInput, optFns ...func(*sfn.Options)) (*sfn.SendTaskSuccessOutput, error) {
+	m.called("SendTaskSuccess")
+	return &sfn.SendTaskSuccessOutput{}, m.SendTaskSuccessErr
+}
+
+// SendTaskFailure mocks the sfn.SendTaskFailure API call
+func (m MockedSFN) SendTaskFailure(ctx context.Context, input *sfn.SendTaskFailureInput, optFns ...func(*sfn.Options)) (*sfn.SendTaskFailureOutput, error) {
+	m.called("SendTaskFailure")
+	return &sfn.SendTaskFailureOutput{}, m.SendTaskFailureErr
+}
+
+// SendTaskHeartbeat mocks the sfn.SendTaskHeartbeat API call
+func (m MockedSFN) SendTaskHeartbeat(ctx context.Context, input *sfn.SendTaskHeartbeatInput, optFns ...func(*sfn.Options)) (*sfn.SendTaskHeartbeatOutput, error) {
+	m.called("SendTaskHeartbeat")
+	return &sfn.SendTaskHeartbeatOutput{}, m.SendTaskHeartbeatErr
+}
+
+// MockedCloudWatch mocks the CloudWatch API
+type MockedCloudWatch struct {
+	PutMetricDataErr error
+	// PutMetricDataInputs records the input of each PutMetricData call, if set
+	PutMetricDataInputs *[]*cloudwatch.PutMetricDataInput
+}
+
+// PutMetricData mocks the cloudwatch.PutMetricData API call
+func (m MockedCloudWatch) PutMetricData(ctx context.Context, input *cloudwatch.PutMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.PutMetricDataOutput, error) {
+	if m.PutMetricDataInputs != nil {
+		*m.PutMetricDataInputs = append(*m.PutMetricDataInputs, input)
+	}
+	return &cloudwatch.PutMetricDataOutput{}, m.PutMetricDataErr
+}
+
+// MockedCloudWatchLogs mocks the CloudWatch Logs API
+type MockedCloudWatchLogs struct {
+	CreateLogGroupErr error
+	// CreateLogStreamErrs and PutLogEventsErrs are returned by the calls in order, if set
+	CreateLogStreamErrs *[]error
+	PutLogEventsErrs    *[]error
+	// Calls records the name of each call, if set
+	Calls *[]string
+	// PutLogEventsInputs records the input of each PutLogEvents call, if set
+	PutLogEventsInputs *[]*cloudwatchlogs.PutLogEventsInput
+}
+
+func (m MockedCloudWatchLogs) record(call string) {
+	if m.Calls != nil {
+		*m.Calls = append(*m.Calls, call)
+	}
+}
+
+func nextErr(errs *[]error) error {
+	if errs == nil || len(*errs) == 0 {
+		return nil
+	}
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
+}
+
+// CreateLogGroup mocks the cloudwatchlogs.CreateLogGroup API call
+func (m MockedCloudWatchLogs) CreateLogGroup(ctx context.Context, input *cloudwatchlogs.CreateLogGroupInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogGroupOutput, error) {
+	m.record("CreateLogGroup")
+	return &cloudwatchlogs.CreateLogGroupOutput{}, m.CreateLogGroupErr
+}
+
+// CreateLogStream mocks the cloudwatchlogs.CreateLogStream API call
+func (m MockedCloudWatchLogs) CreateLogStream(ctx context.Context, input *cloudwatchlogs.CreateLogStreamInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.CreateLogStreamOutput, error) {
+	m.record("CreateLogStream")
+	return &cloudwatchlogs.CreateLogStreamOutput{}, nextErr(m.CreateLogStreamErrs)
+}
+
+// PutLogEvents mocks the cloudwatchlogs.PutLogEvents API call, the next sequence token is the number of calls
+func (m MockedCloudWatchLogs) PutLogEvents(ctx context.Context, input *cloudwatchlogs.PutLogEventsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.PutLogEventsOutput, error) {
+	m.record("PutLogEvents")
+	if m.PutLogEventsInputs != nil {
+		*m.PutLogEventsInputs = append(*m.PutLogEventsInputs, input)
+	}
+	if err := nextErr(m.PutLogEventsErrs); err != nil {
+		return nil, err
+	}
+	token := "1"
+	if m.PutLogEventsInputs != nil {
+		token = strconv.Itoa(len(*m.PutLogEventsInputs))
+	}
+	return &cloudwatchlogs.PutLogEventsOutput{NextSequenceToken: &token}, nil
+}
+
+// MockedS3 mocks the S3 API
+type MockedS3 struct {
+	// PutObjectErrs are returned by the calls in order, if set
+	PutObjectErrs *[]error
+	// PutObjectInputs records the input of each PutObject call, if set
+	PutObjectInputs *[]*s3.PutObjectInput
+}
+
+// PutObject mocks the s3.PutObject API call
+func (m MockedS3) PutObject(ctx context.Context, input *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if m.PutObjectInputs != nil {
+		*m.PutObjectInputs = append(*m.PutObjectInputs, input)
+	}
+	return &s3.PutObjectOutput{}, nextErr(m.PutObjectErrs)
+}
+
+// MockedEventBridge mocks the EventBridge API
+type MockedEventBridge struct {
+	PutEventsErr error
+	// PutEventsFailedEntries fails the entries with the indexes in the first PutEvents call, if set
+	PutEventsFailedEntries []int
+	// PutEventsInputs records the input of each PutEvents call, if set
+	PutEventsInputs *[]*eventbridge.PutEventsInput
+}
 
-// DescribeTagsPages mocks the autoscaling.DescribeTagsPages API call
-func (m MockedASG) DescribeTagsPages(input *autoscaling.DescribeTagsInput, fn describeTagsPagesFn) error {
-	fn(&m.DescribeTagsPagesResp, true)
-	return m.DescribeTagsPagesErr
+// PutEvents mocks the eventbridge.PutEvents API call
+func (m MockedEventBridge) PutEvents(ctx context.Context, input *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	first := true
+	if m.PutEventsInputs != nil {
+		first = len(*m.PutEventsInputs) == 0
+		*m.PutEventsInputs = append(*m.PutEventsInputs, input)
+	}
+	if m.PutEventsErr != nil {
+		return nil, m.PutEventsErr
+	}
+	output := &eventbridge.PutEventsOutput{}
+	for range input.Entries {
+		output.Entries = append(output.Entries, types.PutEventsResultEntry{EventId: aws.String("id")})
+	}
+	if first {
+		for _, i := range m.PutEventsFailedEntries {
+			output.Entries[i] = types.PutEventsResultEntry{ErrorCode: aws.String("ThrottlingException"), ErrorMessage: aws.String("Rate exceeded")}
+		}
+		output.FailedEntryCount = int32(len(m.PutEventsFailedEntries))
+	}
+	return output, nil
 }
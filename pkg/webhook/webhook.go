@@ -27,6 +27,7 @@ import (
 	"github.com/aws/aws-node-termination-handler/pkg/config"
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/secrets"
 	"github.com/rs/zerolog/log"
 )
 
@@ -122,6 +123,42 @@ func Post(additionalInfo ec2metadata.NodeMetadata, event *monitor.InterruptionEv
 	log.Info().Msg("Webhook Success: Notification Sent!")
 }
 
+// ResolveSecrets returns a copy of the config with secret references in the webhook url and header values replaced by their secret values
+func ResolveSecrets(nthConfig config.Config, resolver *secrets.Resolver) (config.Config, error) {
+	webhookURL, err := resolver.Resolve(nthConfig.WebhookURL)
+	if err != nil {
+		return nthConfig, fmt.Errorf("Unable to resolve webhook url: %w", err)
+	}
+	nthConfig.WebhookURL = webhookURL
+
+	headerMap := make(map[string]interface{})
+	err = json.Unmarshal([]byte(nthConfig.WebhookHeaders), &headerMap)
+	if err != nil {
+		// Header parsing errors are reported when the webhook is sent
+		return nthConfig, nil
+	}
+	resolvedHeaders := false
+	for key, value := range headerMap {
+		strValue, ok := value.(string)
+		if !ok || !resolver.IsReference(strValue) {
+			continue
+		}
+		headerMap[key], err = resolver.Resolve(strValue)
+		if err != nil {
+			return nthConfig, fmt.Errorf("Unable to resolve webhook header %s: %w", key, err)
+		}
+		resolvedHeaders = true
+	}
+	if resolvedHeaders {
+		headers, err := json.Marshal(headerMap)
+		if err != nil {
+			return nthConfig, fmt.Errorf("Unable to marshal resolved webhook headers: %w", err)
+		}
+		nthConfig.WebhookHeaders = string(headers)
+	}
+	return nthConfig, nil
+}
+
 // ValidateWebhookConfig will check if the template provided in nthConfig with parse and execute
 func ValidateWebhookConfig(nthConfig config.Config) error {
 	if nthConfig.WebhookURL == "" {
@@ -28,8 +28,11 @@ import (
 	"github.com/aws/aws-node-termination-handler/pkg/config"
 	"github.com/aws/aws-node-termination-handler/pkg/ec2metadata"
 	"github.com/aws/aws-node-termination-handler/pkg/monitor"
+	"github.com/aws/aws-node-termination-handler/pkg/secrets"
 	h "github.com/aws/aws-node-termination-handler/pkg/test"
 	"github.com/aws/aws-node-termination-handler/pkg/webhook"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/rs/zerolog/log"
 )
 
@@ -238,3 +241,35 @@ func TestValidateWebhookConfig(t *testing.T) {
 	err = webhook.ValidateWebhookConfig(nthConfig)
 	h.Ok(t, err)
 }
+
+func TestResolveSecrets(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-east-1:123456789012:secret:nth-webhook-AbCdEf"
+	resolver := secrets.NewResolver(time.Minute, secrets.SecretsManagerProvider{
+		SecretsManager: h.MockedSecretsManager{
+			GetSecretValueResp: secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"url":"https://hooks.example.com/abc","token":"Bearer t0k3n"}`)},
+		},
+	})
+	nthConfig := config.Config{
+		WebhookURL:     secretARN + "#url",
+		WebhookHeaders: `{"Content-type":"application/json","Authorization":"` + secretARN + `#token"}`,
+	}
+
+	resolved, err := webhook.ResolveSecrets(nthConfig, resolver)
+	h.Ok(t, err)
+	h.Equals(t, "https://hooks.example.com/abc", resolved.WebhookURL)
+	headers := map[string]string{}
+	h.Ok(t, json.Unmarshal([]byte(resolved.WebhookHeaders), &headers))
+	h.Equals(t, map[string]string{"Content-type": "application/json", "Authorization": "Bearer t0k3n"}, headers)
+	// the original config keeps the secret references
+	h.Equals(t, secretARN+"#url", nthConfig.WebhookURL)
+}
+
+func TestResolveSecretsPlainValues(t *testing.T) {
+	nthConfig := config.Config{
+		WebhookURL:     "https://hooks.example.com/abc",
+		WebhookHeaders: testWebhookHeaders,
+	}
+	resolved, err := webhook.ResolveSecrets(nthConfig, nil)
+	h.Ok(t, err)
+	h.Equals(t, nthConfig, resolved)
+}
@@ -0,0 +1,154 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsmock
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+type completeLifecycleActionResponse struct {
+	XMLName          xml.Name         `xml:"CompleteLifecycleActionResponse"`
+	Result           struct{}         `xml:"CompleteLifecycleActionResult"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+type recordLifecycleActionHeartbeatResponse struct {
+	XMLName          xml.Name         `xml:"RecordLifecycleActionHeartbeatResponse"`
+	Result           struct{}         `xml:"RecordLifecycleActionHeartbeatResult"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+type autoScalingInstance struct {
+	InstanceID           string `xml:"InstanceId"`
+	AutoScalingGroupName string `xml:"AutoScalingGroupName"`
+	LifecycleState       string `xml:"LifecycleState"`
+	HealthStatus         string `xml:"HealthStatus"`
+}
+
+type describeAutoScalingInstancesResponse struct {
+	XMLName          xml.Name              `xml:"DescribeAutoScalingInstancesResponse"`
+	Instances        []autoScalingInstance `xml:"DescribeAutoScalingInstancesResult>AutoScalingInstances>member"`
+	ResponseMetadata responseMetadata      `xml:"ResponseMetadata"`
+}
+
+type asgTag struct {
+	Key               string `xml:"Key"`
+	Value             string `xml:"Value"`
+	ResourceID        string `xml:"ResourceId"`
+	ResourceType      string `xml:"ResourceType"`
+	PropagateAtLaunch bool   `xml:"PropagateAtLaunch"`
+}
+
+type describeTagsResponse struct {
+	XMLName          xml.Name         `xml:"DescribeTagsResponse"`
+	Tags             []asgTag         `xml:"DescribeTagsResult>Tags>member"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+// completeLifecycleAction records the lifecycle action
+func (s *Server) completeLifecycleAction(res http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	s.lifecycleActions = append(s.lifecycleActions, lifecycleAction(req))
+	s.mutex.Unlock()
+	s.write(res, http.StatusOK, completeLifecycleActionResponse{ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}})
+}
+
+// recordLifecycleActionHeartbeat records the heartbeat, failing like Auto Scaling if the action was completed
+func (s *Server) recordLifecycleActionHeartbeat(res http.ResponseWriter, req *http.Request) {
+	heartbeat := lifecycleAction(req)
+	s.mutex.Lock()
+	completed := false
+	for _, action := range s.lifecycleActions {
+		if action.AutoScalingGroupName == heartbeat.AutoScalingGroupName && action.LifecycleHookName == heartbeat.LifecycleHookName &&
+			action.InstanceID == heartbeat.InstanceID {
+			completed = true
+			break
+		}
+	}
+	if !completed {
+		s.heartbeats = append(s.heartbeats, heartbeat)
+	}
+	s.mutex.Unlock()
+	if completed {
+		s.writeError(res, http.StatusBadRequest, "ValidationError", "No active Lifecycle Action found with instance ID "+heartbeat.InstanceID)
+		return
+	}
+	s.write(res, http.StatusOK, recordLifecycleActionHeartbeatResponse{ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}})
+}
+
+func lifecycleAction(req *http.Request) LifecycleAction {
+	return LifecycleAction{
+		AutoScalingGroupName:  req.Form.Get("AutoScalingGroupName"),
+		LifecycleHookName:     req.Form.Get("LifecycleHookName"),
+		LifecycleActionToken:  req.Form.Get("LifecycleActionToken"),
+		InstanceID:            req.Form.Get("InstanceId"),
+		LifecycleActionResult: req.Form.Get("LifecycleActionResult"),
+	}
+}
+
+// describeAutoScalingInstances returns the requested instances which are part of an ASG
+func (s *Server) describeAutoScalingInstances(res http.ResponseWriter, req *http.Request) {
+	response := describeAutoScalingInstancesResponse{ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}}
+	s.mutex.Lock()
+	for _, id := range members(req, "InstanceIds.member") {
+		instance, ok := s.instances[id]
+		if !ok || instance.AutoScalingGroupName == "" {
+			continue
+		}
+		response.Instances = append(response.Instances, autoScalingInstance{
+			InstanceID:           instance.ID,
+			AutoScalingGroupName: instance.AutoScalingGroupName,
+			LifecycleState:       "InService",
+			HealthStatus:         "HEALTHY",
+		})
+	}
+	s.mutex.Unlock()
+	s.write(res, http.StatusOK, response)
+}
+
+// describeTags returns the tags of the ASGs named by auto-scaling-group filters
+func (s *Server) describeTags(res http.ResponseWriter, req *http.Request) {
+	response := describeTagsResponse{ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}}
+	s.mutex.Lock()
+	for i := 1; ; i++ {
+		prefix := "Filters.member." + strconv.Itoa(i)
+		name := req.Form.Get(prefix + ".Name")
+		if name == "" {
+			break
+		}
+		if name != "auto-scaling-group" {
+			continue
+		}
+		for _, asgName := range members(req, prefix+".Values.member") {
+			keys := make([]string, 0, len(s.asgTags[asgName]))
+			for key := range s.asgTags[asgName] {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				response.Tags = append(response.Tags, asgTag{
+					Key:          key,
+					Value:        s.asgTags[asgName][key],
+					ResourceID:   asgName,
+					ResourceType: "auto-scaling-group",
+				})
+			}
+		}
+	}
+	s.mutex.Unlock()
+	s.write(res, http.StatusOK, response)
+}
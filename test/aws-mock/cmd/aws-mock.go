@@ -0,0 +1,86 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	awsmock "github.com/aws/aws-node-termination-handler/test/aws-mock"
+)
+
+// lifecycleActionsPath serves the completed lifecycle actions and the heartbeats, for scripts to assert on
+const lifecycleActionsPath = "/lifecycle-actions"
+
+type lifecycleActions struct {
+	Completed  []awsmock.LifecycleAction `json:"completed"`
+	Heartbeats []awsmock.LifecycleAction `json:"heartbeats"`
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// parseInstances parses comma separated instances like <instance ID>=<private DNS name>[@<ASG name>]
+func parseInstances(value string) ([]awsmock.Instance, error) {
+	var instances []awsmock.Instance
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("the instance %q is not like <instance ID>=<private DNS name>[@<ASG name>]", entry)
+		}
+		instance := awsmock.Instance{ID: parts[0], PrivateDNSName: parts[1]}
+		if i := strings.Index(parts[1], "@"); i >= 0 {
+			instance.PrivateDNSName, instance.AutoScalingGroupName = parts[1][:i], parts[1][i+1:]
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func handleLifecycleActions(server *awsmock.Server) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(res).Encode(lifecycleActions{Completed: server.CompletedLifecycleActions(), Heartbeats: server.Heartbeats()})
+	}
+}
+
+func main() {
+	server := awsmock.New()
+	instances, err := parseInstances(os.Getenv("INSTANCES"))
+	if err != nil {
+		log.Fatalf("Unable to parse INSTANCES: %v", err)
+	}
+	for _, instance := range instances {
+		server.AddInstance(instance)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(lifecycleActionsPath, handleLifecycleActions(server))
+	mux.Handle("/", server)
+	address := ":" + getEnv("PORT", "4566")
+	log.Printf("Serving the AWS mock on %s with %d instances", address, len(instances))
+	log.Fatal(http.ListenAndServe(address, mux))
+}
@@ -0,0 +1,52 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	awsmock "github.com/aws/aws-node-termination-handler/test/aws-mock"
+)
+
+func TestParseInstances(t *testing.T) {
+	instances, err := parseInstances("i-1=node-1, i-2=node-2@asg,")
+	h.Ok(t, err)
+	h.Equals(t, []awsmock.Instance{
+		{ID: "i-1", PrivateDNSName: "node-1"},
+		{ID: "i-2", PrivateDNSName: "node-2", AutoScalingGroupName: "asg"},
+	}, instances)
+
+	instances, err = parseInstances("")
+	h.Ok(t, err)
+	h.Equals(t, 0, len(instances))
+
+	_, err = parseInstances("i-1")
+	h.Nok(t, err)
+	_, err = parseInstances("=node-1")
+	h.Nok(t, err)
+}
+
+func TestHandleLifecycleActions(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	handleLifecycleActions(awsmock.New())(recorder, httptest.NewRequest(http.MethodGet, lifecycleActionsPath, nil))
+	h.Equals(t, http.StatusOK, recorder.Code)
+	var actions lifecycleActions
+	h.Ok(t, json.Unmarshal(recorder.Body.Bytes(), &actions))
+	h.Equals(t, 0, len(actions.Completed))
+	h.Equals(t, 0, len(actions.Heartbeats))
+}
@@ -0,0 +1,91 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsmock
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ec2Tag struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+type ec2Instance struct {
+	InstanceID       string   `xml:"instanceId"`
+	PrivateDNSName   string   `xml:"privateDnsName"`
+	PrivateIPAddress string   `xml:"privateIpAddress"`
+	State            string   `xml:"instanceState>name"`
+	Tags             []ec2Tag `xml:"tagSet>item"`
+}
+
+type ec2Reservation struct {
+	Instances []ec2Instance `xml:"instancesSet>item"`
+}
+
+type describeInstancesResponse struct {
+	XMLName      xml.Name         `xml:"DescribeInstancesResponse"`
+	RequestID    string           `xml:"requestId"`
+	Reservations []ec2Reservation `xml:"reservationSet>item"`
+}
+
+// ec2ErrorResponse is the error format of the EC2 query protocol, which differs from the other services
+type ec2ErrorResponse struct {
+	XMLName   xml.Name `xml:"Response"`
+	Code      string   `xml:"Errors>Error>Code"`
+	Message   string   `xml:"Errors>Error>Message"`
+	RequestID string   `xml:"RequestID"`
+}
+
+// describeInstances returns a reservation per instance ID, failing like EC2 if any instance is unknown
+func (s *Server) describeInstances(res http.ResponseWriter, req *http.Request) {
+	response := describeInstancesResponse{RequestID: s.nextRequestID()}
+	var unknown []string
+	s.mutex.Lock()
+	for _, id := range members(req, "InstanceId") {
+		instance, ok := s.instances[id]
+		if !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+		state := instance.State
+		if state == "" {
+			state = "running"
+		}
+		var tags []ec2Tag
+		for key, value := range instance.Tags {
+			tags = append(tags, ec2Tag{Key: key, Value: value})
+		}
+		response.Reservations = append(response.Reservations, ec2Reservation{Instances: []ec2Instance{{
+			InstanceID:       instance.ID,
+			PrivateDNSName:   instance.PrivateDNSName,
+			PrivateIPAddress: instance.PrivateIPAddress,
+			State:            state,
+			Tags:             tags,
+		}}})
+	}
+	s.mutex.Unlock()
+	if len(unknown) > 0 {
+		s.write(res, http.StatusBadRequest, ec2ErrorResponse{
+			Code:      "InvalidInstanceID.NotFound",
+			Message:   fmt.Sprintf("The instance IDs '%s' do not exist", strings.Join(unknown, ", ")),
+			RequestID: response.RequestID,
+		})
+		return
+	}
+	s.write(res, http.StatusOK, response)
+}
@@ -0,0 +1,199 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package awsmock is a stand-in for the parts of the SQS, EC2 and Auto Scaling APIs node termination handler calls,
+// so queue processor mode can be tested without AWS or LocalStack. Every service is served on the same endpoint, like
+// --aws-endpoint configures it, and the query protocol actions of the services are told apart by their names.
+package awsmock
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// AccountID is the account of the queues
+const AccountID = "123456789012"
+
+// Instance is an EC2 instance
+type Instance struct {
+	ID               string
+	PrivateDNSName   string
+	PrivateIPAddress string
+	// State is the name of the instance state, running if empty
+	State string
+	Tags  map[string]string
+	// AutoScalingGroupName is the ASG the instance is part of, if any
+	AutoScalingGroupName string
+}
+
+// LifecycleAction is a lifecycle action completed with CompleteLifecycleAction, or a heartbeat of a lifecycle action
+// recorded with RecordLifecycleActionHeartbeat, which has no result
+type LifecycleAction struct {
+	AutoScalingGroupName  string
+	LifecycleHookName     string
+	LifecycleActionToken  string
+	InstanceID            string
+	LifecycleActionResult string
+}
+
+// Server serves the mocked APIs
+type Server struct {
+	mutex            sync.Mutex
+	queues           map[string]*queue
+	instances        map[string]Instance
+	asgTags          map[string]map[string]string
+	lifecycleActions []LifecycleAction
+	heartbeats       []LifecycleAction
+	requestID        int
+}
+
+// New creates a server without queues or instances
+func New() *Server {
+	return &Server{
+		queues:    map[string]*queue{},
+		instances: map[string]Instance{},
+		asgTags:   map[string]map[string]string{},
+	}
+}
+
+// QueueURL returns the URL of the queue served on the endpoint
+func QueueURL(endpoint string, name string) string {
+	return strings.TrimSuffix(endpoint, "/") + "/" + AccountID + "/" + name
+}
+
+// AddInstance adds or replaces the instance
+func (s *Server) AddInstance(instance Instance) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.instances[instance.ID] = instance
+}
+
+// TagAutoScalingGroup sets the tags of the ASG
+func (s *Server) TagAutoScalingGroup(name string, tags map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.asgTags[name] = tags
+}
+
+// CompletedLifecycleActions returns the lifecycle actions completed so far, in order
+func (s *Server) CompletedLifecycleActions() []LifecycleAction {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]LifecycleAction{}, s.lifecycleActions...)
+}
+
+// Heartbeats returns the lifecycle action heartbeats recorded so far, in order
+func (s *Server) Heartbeats() []LifecycleAction {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]LifecycleAction{}, s.heartbeats...)
+}
+
+// ServeHTTP dispatches the query protocol action of the request
+func (s *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		s.writeError(res, http.StatusBadRequest, "MalformedQueryString", err.Error())
+		return
+	}
+	action := req.Form.Get("Action")
+	log.Printf("AWS mock: %s", action)
+	switch action {
+	case "ReceiveMessage":
+		s.receiveMessage(res, req)
+	case "DeleteMessage":
+		s.deleteMessage(res, req)
+	case "ChangeMessageVisibility":
+		s.changeMessageVisibility(res, req)
+	case "GetQueueAttributes":
+		s.getQueueAttributes(res, req)
+	case "SendMessage":
+		s.sendMessage(res, req)
+	case "CreateQueue", "GetQueueUrl":
+		s.queueURL(res, req)
+	case "DescribeInstances":
+		s.describeInstances(res, req)
+	case "CompleteLifecycleAction":
+		s.completeLifecycleAction(res, req)
+	case "RecordLifecycleActionHeartbeat":
+		s.recordLifecycleActionHeartbeat(res, req)
+	case "DescribeAutoScalingInstances":
+		s.describeAutoScalingInstances(res, req)
+	case "DescribeTags":
+		s.describeTags(res, req)
+	default:
+		s.writeError(res, http.StatusBadRequest, "InvalidAction", fmt.Sprintf("The action %s is not valid for this endpoint", action))
+	}
+}
+
+// responseMetadata ends every query protocol response
+type responseMetadata struct {
+	RequestID string `xml:"RequestId"`
+}
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestID string   `xml:"RequestId"`
+}
+
+func (s *Server) nextRequestID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requestID++
+	return strconv.Itoa(s.requestID)
+}
+
+func (s *Server) writeError(res http.ResponseWriter, status int, code string, message string) {
+	s.write(res, status, errorResponse{Type: "Sender", Code: code, Message: message, RequestID: s.nextRequestID()})
+}
+
+func (s *Server) write(res http.ResponseWriter, status int, body interface{}) {
+	res.Header().Set("Content-Type", "text/xml")
+	res.WriteHeader(status)
+	if err := xml.NewEncoder(res).Encode(body); err != nil {
+		log.Printf("AWS mock: unable to write the response: %v", err)
+	}
+}
+
+// members returns the values of a query protocol list, e.g. InstanceId.1, InstanceId.2 with the prefix InstanceId
+func members(req *http.Request, prefix string) []string {
+	type member struct {
+		index int
+		value string
+	}
+	var found []member
+	for key, values := range req.Form {
+		if !strings.HasPrefix(key, prefix+".") || len(values) == 0 {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(key, prefix+"."))
+		if err != nil {
+			continue
+		}
+		found = append(found, member{index: index, value: values[0]})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].index < found[j].index })
+	list := make([]string, 0, len(found))
+	for _, member := range found {
+		list = append(list, member.value)
+	}
+	return list
+}
@@ -0,0 +1,278 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsmock_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	asgtypes "github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	awsmock "github.com/aws/aws-node-termination-handler/test/aws-mock"
+)
+
+const queueName = "test-queue"
+
+func newServer(t *testing.T) (*awsmock.Server, string) {
+	mock := awsmock.New()
+	server := httptest.NewServer(mock)
+	t.Cleanup(server.Close)
+	return mock, server.URL
+}
+
+func newSQS(endpoint string) *sqs.Client {
+	return sqs.New(sqs.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: sqs.EndpointResolverFromURL(endpoint),
+	})
+}
+
+func TestReceiveAndDeleteMessage(t *testing.T) {
+	mock, endpoint := newServer(t)
+	client := newSQS(endpoint)
+	queueURL := awsmock.QueueURL(endpoint, queueName)
+	mock.SendMessage(queueName, "first")
+	mock.SendMessage(queueName, "second")
+
+	result, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: 1,
+		AttributeNames:      []sqstypes.QueueAttributeName{sqstypes.QueueAttributeName(sqstypes.MessageSystemAttributeNameSentTimestamp)},
+	})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(result.Messages))
+	h.Equals(t, "first", aws.ToString(result.Messages[0].Body))
+	h.Assert(t, result.Messages[0].Attributes["SentTimestamp"] != "", "SentTimestamp should be set")
+
+	_, err = client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(queueURL),
+		ReceiptHandle: result.Messages[0].ReceiptHandle,
+	})
+	h.Ok(t, err)
+	h.Equals(t, 1, mock.Messages(queueName))
+}
+
+func TestReceivedMessagesAreInvisible(t *testing.T) {
+	mock, endpoint := newServer(t)
+	client := newSQS(endpoint)
+	queueURL := awsmock.QueueURL(endpoint, queueName)
+	mock.SendMessage(queueName, "body")
+
+	result, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{QueueUrl: aws.String(queueURL), VisibilityTimeout: 1})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(result.Messages))
+
+	attributes, err := client.GetQueueAttributes(context.Background(), &sqs.GetQueueAttributesInput{QueueUrl: aws.String(queueURL)})
+	h.Ok(t, err)
+	h.Equals(t, "0", attributes.Attributes["ApproximateNumberOfMessages"])
+	h.Equals(t, "1", attributes.Attributes["ApproximateNumberOfMessagesNotVisible"])
+
+	result, err = client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{QueueUrl: aws.String(queueURL)})
+	h.Ok(t, err)
+	h.Equals(t, 0, len(result.Messages))
+
+	// the long poll outlasts the visibility timeout
+	result, err = client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{QueueUrl: aws.String(queueURL), WaitTimeSeconds: 2})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(result.Messages))
+}
+
+func TestLongPollReturnsSentMessage(t *testing.T) {
+	mock, endpoint := newServer(t)
+	client := newSQS(endpoint)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		mock.SendMessage(queueName, "late")
+	}()
+
+	start := time.Now()
+	result, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+		QueueUrl:        aws.String(awsmock.QueueURL(endpoint, queueName)),
+		WaitTimeSeconds: 5,
+	})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(result.Messages))
+	h.Assert(t, time.Since(start) < 5*time.Second, "the long poll should return once the message is sent")
+}
+
+func TestDeleteMessageWithUnknownReceiptHandle(t *testing.T) {
+	_, endpoint := newServer(t)
+	_, err := newSQS(endpoint).DeleteMessage(context.Background(), &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(awsmock.QueueURL(endpoint, queueName)),
+		ReceiptHandle: aws.String("unknown"),
+	})
+	var apiErr smithy.APIError
+	h.Assert(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	h.Equals(t, "ReceiptHandleIsInvalid", apiErr.ErrorCode())
+}
+
+func TestChangeMessageVisibility(t *testing.T) {
+	mock, endpoint := newServer(t)
+	client := newSQS(endpoint)
+	queueURL := awsmock.QueueURL(endpoint, queueName)
+	mock.SendMessage(queueName, "body")
+
+	result, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{QueueUrl: aws.String(queueURL)})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(result.Messages))
+
+	_, err = client.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     result.Messages[0].ReceiptHandle,
+		VisibilityTimeout: 0,
+	})
+	h.Ok(t, err)
+
+	received, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{QueueUrl: aws.String(queueURL)})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(received.Messages))
+
+	// the first receipt handle is replaced by the second receive
+	_, err = client.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(queueURL),
+		ReceiptHandle:     result.Messages[0].ReceiptHandle,
+		VisibilityTimeout: 60,
+	})
+	var apiErr smithy.APIError
+	h.Assert(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	h.Equals(t, "ReceiptHandleIsInvalid", apiErr.ErrorCode())
+}
+
+func TestSendMessage(t *testing.T) {
+	mock, endpoint := newServer(t)
+	client := newSQS(endpoint)
+
+	queue, err := client.CreateQueue(context.Background(), &sqs.CreateQueueInput{QueueName: aws.String(queueName)})
+	h.Ok(t, err)
+	h.Equals(t, awsmock.QueueURL(endpoint, queueName), aws.ToString(queue.QueueUrl))
+
+	_, err = client.SendMessage(context.Background(), &sqs.SendMessageInput{QueueUrl: queue.QueueUrl, MessageBody: aws.String("body")})
+	h.Ok(t, err)
+	h.Equals(t, 1, mock.Messages(queueName))
+}
+
+func TestDescribeInstances(t *testing.T) {
+	mock, endpoint := newServer(t)
+	mock.AddInstance(awsmock.Instance{
+		ID:               "i-1",
+		PrivateDNSName:   "node-1",
+		PrivateIPAddress: "10.0.0.1",
+		Tags:             map[string]string{"cluster": "test"},
+	})
+	client := ec2.New(ec2.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: ec2.EndpointResolverFromURL(endpoint),
+	})
+
+	result, err := client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{InstanceIds: []string{"i-1"}})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(result.Reservations))
+	instance := result.Reservations[0].Instances[0]
+	h.Equals(t, "node-1", aws.ToString(instance.PrivateDnsName))
+	h.Equals(t, "10.0.0.1", aws.ToString(instance.PrivateIpAddress))
+	h.Equals(t, "running", string(instance.State.Name))
+	h.Equals(t, "cluster", aws.ToString(instance.Tags[0].Key))
+	h.Equals(t, "test", aws.ToString(instance.Tags[0].Value))
+
+	_, err = client.DescribeInstances(context.Background(), &ec2.DescribeInstancesInput{InstanceIds: []string{"i-2"}})
+	var apiErr smithy.APIError
+	h.Assert(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	h.Equals(t, "InvalidInstanceID.NotFound", apiErr.ErrorCode())
+}
+
+func TestAutoScaling(t *testing.T) {
+	mock, endpoint := newServer(t)
+	mock.AddInstance(awsmock.Instance{ID: "i-1", AutoScalingGroupName: "asg"})
+	mock.TagAutoScalingGroup("asg", map[string]string{"aws-node-termination-handler/managed": ""})
+	client := autoscaling.New(autoscaling.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: autoscaling.EndpointResolverFromURL(endpoint),
+	})
+
+	instances, err := client.DescribeAutoScalingInstances(context.Background(), &autoscaling.DescribeAutoScalingInstancesInput{InstanceIds: []string{"i-1"}})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(instances.AutoScalingInstances))
+	h.Equals(t, "asg", aws.ToString(instances.AutoScalingInstances[0].AutoScalingGroupName))
+
+	tags, err := client.DescribeTags(context.Background(), &autoscaling.DescribeTagsInput{
+		Filters: []asgtypes.Filter{{Name: aws.String("auto-scaling-group"), Values: []string{"asg"}}},
+	})
+	h.Ok(t, err)
+	h.Equals(t, 1, len(tags.Tags))
+	h.Equals(t, "aws-node-termination-handler/managed", aws.ToString(tags.Tags[0].Key))
+
+	_, err = client.CompleteLifecycleAction(context.Background(), &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String("asg"),
+		LifecycleHookName:     aws.String("hook"),
+		LifecycleActionResult: aws.String("CONTINUE"),
+		InstanceId:            aws.String("i-1"),
+	})
+	h.Ok(t, err)
+	h.Equals(t, []awsmock.LifecycleAction{{
+		AutoScalingGroupName:  "asg",
+		LifecycleHookName:     "hook",
+		InstanceID:            "i-1",
+		LifecycleActionResult: "CONTINUE",
+	}}, mock.CompletedLifecycleActions())
+}
+
+func TestRecordLifecycleActionHeartbeat(t *testing.T) {
+	mock, endpoint := newServer(t)
+	client := autoscaling.New(autoscaling.Options{
+		Region:           "us-east-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: autoscaling.EndpointResolverFromURL(endpoint),
+	})
+	heartbeat := &autoscaling.RecordLifecycleActionHeartbeatInput{
+		AutoScalingGroupName: aws.String("asg"),
+		LifecycleHookName:    aws.String("hook"),
+		LifecycleActionToken: aws.String("token"),
+		InstanceId:           aws.String("i-1"),
+	}
+
+	_, err := client.RecordLifecycleActionHeartbeat(context.Background(), heartbeat)
+	h.Ok(t, err)
+	h.Equals(t, []awsmock.LifecycleAction{{
+		AutoScalingGroupName: "asg",
+		LifecycleHookName:    "hook",
+		LifecycleActionToken: "token",
+		InstanceID:           "i-1",
+	}}, mock.Heartbeats())
+
+	_, err = client.CompleteLifecycleAction(context.Background(), &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String("asg"),
+		LifecycleHookName:     aws.String("hook"),
+		LifecycleActionResult: aws.String("CONTINUE"),
+		InstanceId:            aws.String("i-1"),
+	})
+	h.Ok(t, err)
+	_, err = client.RecordLifecycleActionHeartbeat(context.Background(), heartbeat)
+	var apiErr smithy.APIError
+	h.Assert(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	h.Equals(t, "ValidationError", apiErr.ErrorCode())
+	h.Equals(t, 1, len(mock.Heartbeats()))
+}
@@ -0,0 +1,285 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package awsmock
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+)
+
+// pollInterval is how often a long poll checks for messages
+const pollInterval = 100 * time.Millisecond
+
+// queue holds the messages of an SQS queue
+type queue struct {
+	messages []*message
+	sent     int
+}
+
+type message struct {
+	id            string
+	body          string
+	sentAt        time.Time
+	receiptHandle string
+	// invisibleUntil is set while the message is received and not deleted
+	invisibleUntil time.Time
+}
+
+func (m *message) visible(now time.Time) bool {
+	return !now.Before(m.invisibleUntil)
+}
+
+// SendMessage adds the message to the queue, creating the queue if needed, and returns its ID
+func (s *Server) SendMessage(queueName string, body string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	q := s.queue(queueName)
+	q.sent++
+	m := &message{id: queueName + "-" + strconv.Itoa(q.sent), body: body, sentAt: time.Now()}
+	q.messages = append(q.messages, m)
+	return m.id
+}
+
+// Messages returns the number of messages in the queue which were not deleted
+func (s *Server) Messages(queueName string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.queue(queueName).messages)
+}
+
+// queue returns the queue, creating it if needed. The mutex must be held.
+func (s *Server) queue(name string) *queue {
+	q, ok := s.queues[name]
+	if !ok {
+		q = &queue{}
+		s.queues[name] = q
+	}
+	return q
+}
+
+type sqsMessage struct {
+	MessageID     string         `xml:"MessageId"`
+	ReceiptHandle string         `xml:"ReceiptHandle"`
+	MD5OfBody     string         `xml:"MD5OfBody"`
+	Body          string         `xml:"Body"`
+	Attributes    []sqsAttribute `xml:"Attribute"`
+}
+
+type sqsAttribute struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+type receiveMessageResponse struct {
+	XMLName          xml.Name         `xml:"ReceiveMessageResponse"`
+	Messages         []sqsMessage     `xml:"ReceiveMessageResult>Message"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+type deleteMessageResponse struct {
+	XMLName          xml.Name         `xml:"DeleteMessageResponse"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+type changeMessageVisibilityResponse struct {
+	XMLName          xml.Name         `xml:"ChangeMessageVisibilityResponse"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+type sendMessageResponse struct {
+	XMLName          xml.Name         `xml:"SendMessageResponse"`
+	MessageID        string           `xml:"SendMessageResult>MessageId"`
+	MD5OfMessageBody string           `xml:"SendMessageResult>MD5OfMessageBody"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+// queueURLResponse is named after the action, i.e. CreateQueueResponse with a CreateQueueResult
+type queueURLResponse struct {
+	XMLName          xml.Name
+	Result           queueURLResult
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+type queueURLResult struct {
+	XMLName  xml.Name
+	QueueURL string `xml:"QueueUrl"`
+}
+
+type getQueueAttributesResponse struct {
+	XMLName          xml.Name         `xml:"GetQueueAttributesResponse"`
+	Attributes       []sqsAttribute   `xml:"GetQueueAttributesResult>Attribute"`
+	ResponseMetadata responseMetadata `xml:"ResponseMetadata"`
+}
+
+// receiveMessage returns up to MaxNumberOfMessages visible messages, waiting up to WaitTimeSeconds for one
+func (s *Server) receiveMessage(res http.ResponseWriter, req *http.Request) {
+	queueName := path.Base(req.Form.Get("QueueUrl"))
+	maxMessages := formInt(req, "MaxNumberOfMessages", 1)
+	visibilityTimeout := time.Duration(formInt(req, "VisibilityTimeout", 30)) * time.Second
+	deadline := time.Now().Add(time.Duration(formInt(req, "WaitTimeSeconds", 0)) * time.Second)
+	for {
+		messages := s.receive(queueName, maxMessages, visibilityTimeout)
+		if len(messages) > 0 || !time.Now().Before(deadline) {
+			s.write(res, http.StatusOK, receiveMessageResponse{Messages: messages, ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}})
+			return
+		}
+		select {
+		case <-req.Context().Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// receive hides up to max visible messages of the queue for the visibility timeout and returns them
+func (s *Server) receive(queueName string, max int, visibilityTimeout time.Duration) []sqsMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	var received []sqsMessage
+	for _, m := range s.queue(queueName).messages {
+		if len(received) == max {
+			break
+		}
+		if !m.visible(now) {
+			continue
+		}
+		s.requestID++
+		m.receiptHandle = m.id + "-" + strconv.Itoa(s.requestID)
+		m.invisibleUntil = now.Add(visibilityTimeout)
+		received = append(received, sqsMessage{
+			MessageID:     m.id,
+			ReceiptHandle: m.receiptHandle,
+			MD5OfBody:     md5Hex(m.body),
+			Body:          m.body,
+			Attributes:    []sqsAttribute{{Name: "SentTimestamp", Value: strconv.FormatInt(m.sentAt.UnixNano()/int64(time.Millisecond), 10)}},
+		})
+	}
+	return received
+}
+
+// deleteMessage deletes the message received with the receipt handle
+func (s *Server) deleteMessage(res http.ResponseWriter, req *http.Request) {
+	queueName := path.Base(req.Form.Get("QueueUrl"))
+	receiptHandle := req.Form.Get("ReceiptHandle")
+	s.mutex.Lock()
+	q := s.queue(queueName)
+	deleted := false
+	for i, m := range q.messages {
+		if m.receiptHandle != "" && m.receiptHandle == receiptHandle {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			deleted = true
+			break
+		}
+	}
+	s.mutex.Unlock()
+	if !deleted {
+		s.writeError(res, http.StatusBadRequest, "ReceiptHandleIsInvalid", "The receipt handle is not valid")
+		return
+	}
+	s.write(res, http.StatusOK, deleteMessageResponse{ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}})
+}
+
+// changeMessageVisibility hides the received message for the visibility timeout from now on, or makes it visible
+// again with a timeout of 0
+func (s *Server) changeMessageVisibility(res http.ResponseWriter, req *http.Request) {
+	queueName := path.Base(req.Form.Get("QueueUrl"))
+	receiptHandle := req.Form.Get("ReceiptHandle")
+	visibilityTimeout := time.Duration(formInt(req, "VisibilityTimeout", 0)) * time.Second
+	s.mutex.Lock()
+	now := time.Now()
+	code := "ReceiptHandleIsInvalid"
+	for _, m := range s.queue(queueName).messages {
+		if m.receiptHandle == "" || m.receiptHandle != receiptHandle {
+			continue
+		}
+		if m.visible(now) {
+			code = "AWS.SimpleQueueService.MessageNotInflight"
+			break
+		}
+		m.invisibleUntil = now.Add(visibilityTimeout)
+		code = ""
+		break
+	}
+	s.mutex.Unlock()
+	if code != "" {
+		s.writeError(res, http.StatusBadRequest, code, "The message "+receiptHandle+" is not in flight")
+		return
+	}
+	s.write(res, http.StatusOK, changeMessageVisibilityResponse{ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}})
+}
+
+// sendMessage adds the message to the queue, so scripts can send messages with the AWS CLI
+func (s *Server) sendMessage(res http.ResponseWriter, req *http.Request) {
+	body := req.Form.Get("MessageBody")
+	id := s.SendMessage(path.Base(req.Form.Get("QueueUrl")), body)
+	s.write(res, http.StatusOK, sendMessageResponse{
+		MessageID:        id,
+		MD5OfMessageBody: md5Hex(body),
+		ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()},
+	})
+}
+
+// queueURL answers CreateQueue and GetQueueUrl with the URL of the queue on the endpoint of the request, queues are
+// created on their first use anyway
+func (s *Server) queueURL(res http.ResponseWriter, req *http.Request) {
+	action := req.Form.Get("Action")
+	s.write(res, http.StatusOK, queueURLResponse{
+		XMLName: xml.Name{Local: action + "Response"},
+		Result: queueURLResult{
+			XMLName:  xml.Name{Local: action + "Result"},
+			QueueURL: QueueURL("http://"+req.Host, req.Form.Get("QueueName")),
+		},
+		ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()},
+	})
+}
+
+// getQueueAttributes returns the approximate numbers of visible and received messages
+func (s *Server) getQueueAttributes(res http.ResponseWriter, req *http.Request) {
+	queueName := path.Base(req.Form.Get("QueueUrl"))
+	s.mutex.Lock()
+	now := time.Now()
+	visible, notVisible := 0, 0
+	for _, m := range s.queue(queueName).messages {
+		if m.visible(now) {
+			visible++
+		} else {
+			notVisible++
+		}
+	}
+	s.mutex.Unlock()
+	attributes := []sqsAttribute{
+		{Name: "ApproximateNumberOfMessages", Value: strconv.Itoa(visible)},
+		{Name: "ApproximateNumberOfMessagesNotVisible", Value: strconv.Itoa(notVisible)},
+	}
+	s.write(res, http.StatusOK, getQueueAttributesResponse{Attributes: attributes, ResponseMetadata: responseMetadata{RequestID: s.nextRequestID()}})
+}
+
+func md5Hex(body string) string {
+	sum := md5.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+func formInt(req *http.Request, key string, fallback int) int {
+	value, err := strconv.Atoi(req.Form.Get(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
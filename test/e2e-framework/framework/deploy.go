@@ -0,0 +1,191 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	releaseName       = "nth-e2e"
+	metadataProxyName = "ec2-metadata-test-proxy"
+	metadataProxyPort = 1338
+	hostnameLabel     = "kubernetes.io/hostname"
+	pauseImage        = "k8s.gcr.io/pause:3.5"
+	nthTaintPrefix    = "aws-node-termination-handler/"
+)
+
+// InstallNTH installs the chart of the working tree with the image of the working tree. The values are set with
+// helm's --set, on top of the ones pointing node termination handler at the AWS mock.
+func (f *Framework) InstallNTH(values map[string]string) error {
+	args := append([]string{"--kubeconfig", f.kubeconfig}, helmInstallArgs(f.AWSEndpoint, f.QueueURL(), f.Config.Timeout.String(), values)...)
+	return run(f.repoRoot, "helm", args...)
+}
+
+// UninstallNTH uninstalls the chart, if it is installed
+func (f *Framework) UninstallNTH() error {
+	return run(f.repoRoot, "helm", "--kubeconfig", f.kubeconfig, "uninstall", releaseName, "--namespace", Namespace, "--wait")
+}
+
+func helmInstallArgs(awsEndpoint string, queueURL string, timeout string, values map[string]string) []string {
+	repository := strings.SplitN(nthImage, ":", 2)
+	all := map[string]string{
+		"image.repository":   repository[0],
+		"image.tag":          repository[1],
+		"image.pullPolicy":   "IfNotPresent",
+		"awsEndpoint":        awsEndpoint,
+		"awsRegion":          Region,
+		"awsAccessKeyID":     "e2e",
+		"awsSecretAccessKey": "e2e",
+		"queueURL":           queueURL,
+	}
+	for key, value := range values {
+		all[key] = value
+	}
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	args := []string{
+		"upgrade", "--install", releaseName, filepath.Join("config", "helm", "aws-node-termination-handler"),
+		"--namespace", Namespace, "--wait", "--timeout", timeout,
+	}
+	for _, key := range keys {
+		args = append(args, "--set", key+"="+all[key])
+	}
+	return args
+}
+
+// NodeSelectorValue is the helm value pinning node termination handler to the node
+func NodeSelectorValue(node string) (string, string) {
+	return "nodeSelector." + strings.ReplaceAll(hostnameLabel, ".", `\.`), node
+}
+
+// DeployMetadataProxy deploys the EC2 metadata test proxy configured with the environment variables on the control
+// plane node and returns its URL, which is reachable from the host network of every node
+func (f *Framework) DeployMetadataProxy(env map[string]string) (string, error) {
+	labels := map[string]string{"app": metadataProxyName}
+	container := corev1.Container{
+		Name:            metadataProxyName,
+		Image:           proxyImage,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Ports:           []corev1.ContainerPort{{ContainerPort: metadataProxyPort}},
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		container.Env = append(container.Env, corev1.EnvVar{Name: key, Value: env[key]})
+	}
+	deployment := f.pinnedDeployment(metadataProxyName, f.ControlPlaneNode, labels, container)
+	deployment.Spec.Template.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	if err := f.createDeployment(deployment); err != nil {
+		return "", err
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: metadataProxyName, Namespace: WorkloadNamespace},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Port: metadataProxyPort, TargetPort: intstr.FromInt(metadataProxyPort)}},
+		},
+	}
+	created, err := f.Client.CoreV1().Services(WorkloadNamespace).Create(context.Background(), service, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to create the metadata proxy service: %w", err)
+	}
+	// node termination handler runs on the host network by default, where the service is not resolvable by name
+	return fmt.Sprintf("http://%s:%d", created.Spec.ClusterIP, metadataProxyPort), nil
+}
+
+// DeleteMetadataProxy deletes the EC2 metadata test proxy
+func (f *Framework) DeleteMetadataProxy() error {
+	err := f.Client.CoreV1().Services(WorkloadNamespace).Delete(context.Background(), metadataProxyName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return f.DeleteWorkload(metadataProxyName)
+}
+
+// DeployWorkload deploys pause pods pinned to the node, so evicted pods are not rescheduled, and waits for them to run
+func (f *Framework) DeployWorkload(name string, node string, replicas int32) error {
+	container := corev1.Container{Name: "pause", Image: pauseImage}
+	deployment := f.pinnedDeployment(name, node, map[string]string{"app": name}, container)
+	deployment.Spec.Replicas = &replicas
+	return f.createDeployment(deployment)
+}
+
+// DeleteWorkload deletes the deployment
+func (f *Framework) DeleteWorkload(name string) error {
+	err := f.Client.AppsV1().Deployments(WorkloadNamespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ResetNode uncordons the node and removes the taints of node termination handler, for the next scenario
+func (f *Framework) ResetNode(name string) error {
+	node, err := f.Client.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	taints := []corev1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if !strings.HasPrefix(taint.Key, nthTaintPrefix) {
+			taints = append(taints, taint)
+		}
+	}
+	node.Spec.Taints = taints
+	node.Spec.Unschedulable = false
+	_, err = f.Client.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+	return err
+}
+
+func (f *Framework) pinnedDeployment(name string, node string, labels map[string]string, container corev1.Container) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: WorkloadNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{hostnameLabel: node},
+					Containers:   []corev1.Container{container},
+				},
+			},
+		},
+	}
+}
+
+func (f *Framework) createDeployment(deployment *appsv1.Deployment) error {
+	_, err := f.Client.AppsV1().Deployments(deployment.Namespace).Create(context.Background(), deployment, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create the deployment %s: %w", deployment.Name, err)
+	}
+	return f.WaitForDeploymentAvailable(types.NamespacedName{Namespace: deployment.Namespace, Name: deployment.Name})
+}
@@ -0,0 +1,302 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package framework runs node termination handler in a kind cluster against the EC2 metadata test proxy and the AWS
+// mock, for scenarios asserting what it does to the cluster, e.g. that the node is cordoned and its pods evicted.
+package framework
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	awsmock "github.com/aws/aws-node-termination-handler/test/aws-mock"
+)
+
+const (
+	// Namespace is where node termination handler is installed
+	Namespace = "kube-system"
+	// WorkloadNamespace is where the metadata proxy and the workloads are deployed
+	WorkloadNamespace = "default"
+	// Region is the region of the mocked AWS APIs
+	Region = "us-east-1"
+	// QueueName is the name of the mocked queue
+	QueueName = "nth-e2e-queue"
+
+	nthImage   = "nth-e2e:latest-e2e"
+	proxyImage = "ec2-metadata-test-proxy:latest-e2e"
+)
+
+// Config configures the framework, see NewConfigFromEnv
+type Config struct {
+	// ClusterName is the name of the kind cluster, which is reused if it exists
+	ClusterName string
+	// NodeImage is the kind node image, the default of kind if empty
+	NodeImage string
+	// PreserveCluster keeps the cluster after the tests, e.g. to debug them or to speed up the next run
+	PreserveCluster bool
+	// HostAddress is the address of the host the pods reach the AWS mock on, the gateway of the kind network if empty
+	HostAddress string
+	// SkipImageBuild loads the images built by an earlier run instead of building them
+	SkipImageBuild bool
+	// Timeout bounds every wait
+	Timeout time.Duration
+}
+
+// NewConfigFromEnv reads the configuration from the E2E_ environment variables
+func NewConfigFromEnv() (Config, error) {
+	config := Config{
+		ClusterName: getEnv("E2E_CLUSTER_NAME", "nth-e2e"),
+		NodeImage:   os.Getenv("E2E_KIND_NODE_IMAGE"),
+		HostAddress: os.Getenv("E2E_HOST_ADDRESS"),
+	}
+	var err error
+	if config.PreserveCluster, err = strconv.ParseBool(getEnv("E2E_PRESERVE_CLUSTER", "false")); err != nil {
+		return config, fmt.Errorf("E2E_PRESERVE_CLUSTER is not a boolean: %w", err)
+	}
+	if config.SkipImageBuild, err = strconv.ParseBool(getEnv("E2E_SKIP_IMAGE_BUILD", "false")); err != nil {
+		return config, fmt.Errorf("E2E_SKIP_IMAGE_BUILD is not a boolean: %w", err)
+	}
+	if config.Timeout, err = time.ParseDuration(getEnv("E2E_TIMEOUT", "5m")); err != nil {
+		return config, fmt.Errorf("E2E_TIMEOUT is not a duration: %w", err)
+	}
+	return config, nil
+}
+
+// Framework is a kind cluster with the images loaded and the AWS mock running on the host
+type Framework struct {
+	Config Config
+	// Client is a client of the cluster
+	Client kubernetes.Interface
+	// AWS is the mock of the SQS, EC2 and Auto Scaling APIs
+	AWS *awsmock.Server
+	// AWSEndpoint is the endpoint of the AWS mock, as the pods reach it
+	AWSEndpoint string
+	// WorkerNodes are the names of the worker nodes, which scenarios interrupt
+	WorkerNodes []string
+	// ControlPlaneNode is the name of the control plane node, where the test infrastructure runs
+	ControlPlaneNode string
+
+	repoRoot       string
+	kubeconfig     string
+	createdCluster bool
+	awsServer      *http.Server
+}
+
+// New creates or reuses the cluster, loads the images and starts the AWS mock
+func New(config Config) (*Framework, error) {
+	f := &Framework{Config: config, AWS: awsmock.New(), repoRoot: repoRoot()}
+	if err := f.setUpCluster(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.loadImages(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.startAWSMock(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Close stops the AWS mock and deletes the cluster it created, unless it is preserved
+func (f *Framework) Close() {
+	if f.awsServer != nil {
+		f.awsServer.Close()
+	}
+	if f.createdCluster && !f.Config.PreserveCluster {
+		if err := run(f.repoRoot, "kind", "delete", "cluster", "--name", f.Config.ClusterName); err != nil {
+			log.Printf("Unable to delete the cluster %s: %v", f.Config.ClusterName, err)
+		}
+	}
+	if f.kubeconfig != "" {
+		os.Remove(f.kubeconfig)
+	}
+}
+
+// QueueURL is the URL of the mocked queue
+func (f *Framework) QueueURL() string {
+	return awsmock.QueueURL(f.AWSEndpoint, QueueName)
+}
+
+func (f *Framework) setUpCluster() error {
+	clusters, err := output(f.repoRoot, "kind", "get", "clusters")
+	if err != nil {
+		return err
+	}
+	if !contains(strings.Fields(clusters), f.Config.ClusterName) {
+		if err := f.createCluster(); err != nil {
+			return err
+		}
+		f.createdCluster = true
+	}
+	kubeconfig, err := output(f.repoRoot, "kind", "get", "kubeconfig", "--name", f.Config.ClusterName)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile("", "nth-e2e-kubeconfig")
+	if err != nil {
+		return err
+	}
+	f.kubeconfig = file.Name()
+	if _, err := file.WriteString(kubeconfig); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+	if err != nil {
+		return fmt.Errorf("unable to read the kubeconfig of the cluster: %w", err)
+	}
+	if f.Client, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return err
+	}
+	return f.findNodes()
+}
+
+// kindConfig has two workers, so a scenario interrupting one still leaves a node for the evicted pods
+const kindConfig = `kind: Cluster
+apiVersion: kind.x-k8s.io/v1alpha4
+nodes:
+- role: control-plane
+- role: worker
+- role: worker
+`
+
+func (f *Framework) createCluster() error {
+	file, err := ioutil.TempFile("", "nth-e2e-kind")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(kindConfig); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+	args := []string{"create", "cluster", "--name", f.Config.ClusterName, "--config", file.Name(), "--wait", f.Config.Timeout.String()}
+	if f.Config.NodeImage != "" {
+		args = append(args, "--image", f.Config.NodeImage)
+	}
+	return run(f.repoRoot, "kind", args...)
+}
+
+func (f *Framework) findNodes() error {
+	nodes, err := f.Client.CoreV1().Nodes().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list the nodes of the cluster: %w", err)
+	}
+	for _, node := range nodes.Items {
+		if _, ok := node.Labels["node-role.kubernetes.io/control-plane"]; ok {
+			f.ControlPlaneNode = node.Name
+		} else if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
+			f.ControlPlaneNode = node.Name
+		} else {
+			f.WorkerNodes = append(f.WorkerNodes, node.Name)
+		}
+	}
+	if f.ControlPlaneNode == "" || len(f.WorkerNodes) < 2 {
+		return fmt.Errorf("the cluster %s needs a control plane node and two workers", f.Config.ClusterName)
+	}
+	return nil
+}
+
+// loadImages builds the node termination handler and metadata proxy images from the working tree and loads them
+// into the cluster
+func (f *Framework) loadImages() error {
+	images := []struct{ tag, dockerfile string }{
+		{nthImage, "Dockerfile"},
+		{proxyImage, filepath.Join("test", "ec2-metadata-test-proxy", "Dockerfile")},
+	}
+	for _, image := range images {
+		if !f.Config.SkipImageBuild {
+			if err := run(f.repoRoot, "docker", "build", "-t", image.tag, "-f", image.dockerfile, "."); err != nil {
+				return err
+			}
+		}
+		if err := run(f.repoRoot, "kind", "load", "docker-image", image.tag, "--name", f.Config.ClusterName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startAWSMock serves the AWS mock on every address of the host, as the pods reach it through the kind network
+func (f *Framework) startAWSMock() error {
+	host := f.Config.HostAddress
+	if host == "" {
+		gateway, err := output(f.repoRoot, "docker", "network", "inspect", "kind", "--format", "{{range .IPAM.Config}}{{if .Gateway}}{{.Gateway}} {{end}}{{end}}")
+		if err != nil {
+			return err
+		}
+		for _, address := range strings.Fields(gateway) {
+			if ip := net.ParseIP(address); ip != nil && ip.To4() != nil {
+				host = address
+				break
+			}
+		}
+		if host == "" {
+			return fmt.Errorf("unable to find the gateway of the kind network, set E2E_HOST_ADDRESS")
+		}
+	}
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return fmt.Errorf("unable to listen for the AWS mock: %w", err)
+	}
+	f.awsServer = &http.Server{Handler: f.AWS}
+	go func() {
+		if err := f.awsServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("The AWS mock stopped: %v", err)
+		}
+	}()
+	f.AWSEndpoint = fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)))
+	log.Printf("Serving the AWS mock on %s", f.AWSEndpoint)
+	return nil
+}
+
+// repoRoot is the root of the repository the framework is in, which the images and the chart are built from
+func repoRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..")
+}
+
+func getEnv(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
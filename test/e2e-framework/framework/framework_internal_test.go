@@ -0,0 +1,77 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package framework
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestNewConfigFromEnvDefaults(t *testing.T) {
+	for _, key := range []string{"E2E_CLUSTER_NAME", "E2E_KIND_NODE_IMAGE", "E2E_PRESERVE_CLUSTER", "E2E_HOST_ADDRESS", "E2E_SKIP_IMAGE_BUILD", "E2E_TIMEOUT"} {
+		os.Unsetenv(key)
+	}
+	config, err := NewConfigFromEnv()
+	h.Ok(t, err)
+	h.Equals(t, Config{ClusterName: "nth-e2e", Timeout: 5 * time.Minute}, config)
+}
+
+func TestNewConfigFromEnv(t *testing.T) {
+	os.Setenv("E2E_CLUSTER_NAME", "test")
+	os.Setenv("E2E_PRESERVE_CLUSTER", "true")
+	os.Setenv("E2E_HOST_ADDRESS", "172.18.0.1")
+	os.Setenv("E2E_TIMEOUT", "1m")
+	defer func() {
+		os.Unsetenv("E2E_CLUSTER_NAME")
+		os.Unsetenv("E2E_PRESERVE_CLUSTER")
+		os.Unsetenv("E2E_HOST_ADDRESS")
+		os.Unsetenv("E2E_TIMEOUT")
+	}()
+	config, err := NewConfigFromEnv()
+	h.Ok(t, err)
+	h.Equals(t, "test", config.ClusterName)
+	h.Equals(t, true, config.PreserveCluster)
+	h.Equals(t, "172.18.0.1", config.HostAddress)
+	h.Equals(t, time.Minute, config.Timeout)
+
+	os.Setenv("E2E_TIMEOUT", "soon")
+	_, err = NewConfigFromEnv()
+	h.Nok(t, err)
+}
+
+func TestHelmInstallArgs(t *testing.T) {
+	key, node := NodeSelectorValue("nth-e2e-worker")
+	args := helmInstallArgs("http://172.18.0.1:1234", "http://172.18.0.1:1234/123456789012/queue", "5m0s", map[string]string{
+		"enableSqsTerminationDraining": "true",
+		"awsRegion":                    "us-west-2",
+		key:                            node,
+	})
+	h.Equals(t, []string{
+		"upgrade", "--install", "nth-e2e", "config/helm/aws-node-termination-handler",
+		"--namespace", "kube-system", "--wait", "--timeout", "5m0s",
+		"--set", "awsAccessKeyID=e2e",
+		"--set", "awsEndpoint=http://172.18.0.1:1234",
+		"--set", "awsRegion=us-west-2",
+		"--set", "awsSecretAccessKey=e2e",
+		"--set", "enableSqsTerminationDraining=true",
+		"--set", "image.pullPolicy=IfNotPresent",
+		"--set", "image.repository=nth-e2e",
+		"--set", "image.tag=latest-e2e",
+		"--set", `nodeSelector.kubernetes\.io/hostname=nth-e2e-worker`,
+		"--set", "queueURL=http://172.18.0.1:1234/123456789012/queue",
+	}, args)
+}
@@ -0,0 +1,117 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	awsmock "github.com/aws/aws-node-termination-handler/test/aws-mock"
+)
+
+const pollInterval = 2 * time.Second
+
+// WaitForDeploymentAvailable waits for every replica of the deployment to be available
+func (f *Framework) WaitForDeploymentAvailable(name types.NamespacedName) error {
+	return f.poll(fmt.Sprintf("the deployment %s to be available", name), func() (bool, error) {
+		deployment, err := f.Client.AppsV1().Deployments(name.Namespace).Get(context.Background(), name.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		return deployment.Status.AvailableReplicas == replicas, nil
+	})
+}
+
+// WaitForNodeCordoned waits for the node to be unschedulable
+func (f *Framework) WaitForNodeCordoned(name string) error {
+	return f.pollNode(name, "to be cordoned", func(node *corev1.Node) bool {
+		return node.Spec.Unschedulable
+	})
+}
+
+// WaitForNodeTaint waits for the node to have a taint with the key
+func (f *Framework) WaitForNodeTaint(name string, key string) error {
+	return f.pollNode(name, "to be tainted with "+key, func(node *corev1.Node) bool {
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == key {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WaitForPodsEvicted waits for the node to run no pods of the workload
+func (f *Framework) WaitForPodsEvicted(workload string, node string) error {
+	return f.poll(fmt.Sprintf("the pods of %s to be evicted from %s", workload, node), func() (bool, error) {
+		pods, err := f.Client.CoreV1().Pods(WorkloadNamespace).List(context.Background(), metav1.ListOptions{
+			LabelSelector: "app=" + workload,
+			FieldSelector: "spec.nodeName=" + node,
+		})
+		if err != nil {
+			return false, err
+		}
+		return len(pods.Items) == 0, nil
+	})
+}
+
+// WaitForLifecycleAction waits for the lifecycle action of the instance to be completed and returns it
+func (f *Framework) WaitForLifecycleAction(instanceID string) (awsmock.LifecycleAction, error) {
+	var completed awsmock.LifecycleAction
+	err := f.poll("the lifecycle action of "+instanceID+" to be completed", func() (bool, error) {
+		for _, action := range f.AWS.CompletedLifecycleActions() {
+			if action.InstanceID == instanceID {
+				completed = action
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	return completed, err
+}
+
+// WaitForQueueEmpty waits for every message of the mocked queue to be deleted
+func (f *Framework) WaitForQueueEmpty() error {
+	return f.poll("the messages to be deleted from "+QueueName, func() (bool, error) {
+		return f.AWS.Messages(QueueName) == 0, nil
+	})
+}
+
+func (f *Framework) pollNode(name string, description string, condition func(node *corev1.Node) bool) error {
+	return f.poll("the node "+name+" "+description, func() (bool, error) {
+		node, err := f.Client.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return condition(node), nil
+	})
+}
+
+// poll checks the condition until it is met, failing on the timeout of the configuration
+func (f *Framework) poll(description string, condition wait.ConditionFunc) error {
+	if err := wait.PollImmediate(pollInterval, f.Config.Timeout, condition); err != nil {
+		return fmt.Errorf("timed out waiting for %s: %w", description, err)
+	}
+	return nil
+}
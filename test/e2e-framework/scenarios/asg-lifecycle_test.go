@@ -0,0 +1,89 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package scenarios_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	awsmock "github.com/aws/aws-node-termination-handler/test/aws-mock"
+	"github.com/aws/aws-node-termination-handler/test/e2e-framework/framework"
+)
+
+const asgLifecycleEvent = `{
+  "version": "0",
+  "id": "782d5b4c-0f6f-1fd6-9d62-ecf6aed0a470",
+  "detail-type": "EC2 Instance-terminate Lifecycle Action",
+  "source": "aws.autoscaling",
+  "account": "123456789012",
+  "time": "%s",
+  "region": "%s",
+  "resources": [
+    "arn:aws:autoscaling:us-east-1:123456789012:autoScalingGroup:26e7234b-03a4-47fb-b0a9-2b241662774e:autoScalingGroupName/%s"
+  ],
+  "detail": {
+    "LifecycleActionToken": "%s",
+    "AutoScalingGroupName": "%s",
+    "LifecycleHookName": "%s",
+    "EC2InstanceId": "%s",
+    "LifecycleTransition": "autoscaling:EC2_INSTANCE_TERMINATING"
+  }
+}`
+
+func TestASGLifecycleDrainsNodeAndCompletesHook(t *testing.T) {
+	const (
+		instanceID = "i-0e2e0000000000001"
+		asgName    = "nth-e2e-asg"
+		hookName   = "nth-e2e-hook"
+		token      = "0befcbdb-6ecd-498a-9ff7-ae9b54447cd6"
+	)
+	node := f.WorkerNodes[1]
+	cleanUp(t, "reset the node", func() error { return f.ResetNode(node) })
+	f.AWS.AddInstance(awsmock.Instance{ID: instanceID, PrivateDNSName: node, AutoScalingGroupName: asgName})
+
+	h.Ok(t, f.DeployWorkload("asg-lifecycle-workload", node, 2))
+	cleanUp(t, "delete the workload", func() error { return f.DeleteWorkload("asg-lifecycle-workload") })
+
+	// queue processor mode runs as a deployment, which is kept on the control plane away from the drained node
+	nodeSelector, nodeName := framework.NodeSelectorValue(f.ControlPlaneNode)
+	h.Ok(t, f.InstallNTH(map[string]string{
+		"enableSqsTerminationDraining":   "true",
+		"enableSpotInterruptionDraining": "false",
+		"enableScheduledEventDraining":   "false",
+		"checkASGTagBeforeDraining":      "false",
+		nodeSelector:                     nodeName,
+	}))
+	cleanUp(t, "uninstall node termination handler", f.UninstallNTH)
+
+	f.AWS.SendMessage(framework.QueueName, fmt.Sprintf(asgLifecycleEvent,
+		time.Now().UTC().Format(time.RFC3339), framework.Region, asgName, token, asgName, hookName, instanceID))
+
+	h.Ok(t, f.WaitForNodeCordoned(node))
+	h.Ok(t, f.WaitForPodsEvicted("asg-lifecycle-workload", node))
+	action, err := f.WaitForLifecycleAction(instanceID)
+	h.Ok(t, err)
+	h.Equals(t, awsmock.LifecycleAction{
+		AutoScalingGroupName:  asgName,
+		LifecycleHookName:     hookName,
+		LifecycleActionToken:  token,
+		InstanceID:            instanceID,
+		LifecycleActionResult: "CONTINUE",
+	}, action)
+	h.Ok(t, f.WaitForQueueEmpty())
+}
@@ -0,0 +1,51 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package scenarios_test
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-node-termination-handler/test/e2e-framework/framework"
+)
+
+// f is shared by the scenarios, which run one after the other on the same cluster
+var f *framework.Framework
+
+func TestMain(m *testing.M) {
+	config, err := framework.NewConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Unable to configure the e2e framework: %v", err)
+	}
+	f, err = framework.New(config)
+	if err != nil {
+		log.Fatalf("Unable to set up the e2e framework: %v", err)
+	}
+	code := m.Run()
+	f.Close()
+	os.Exit(code)
+}
+
+// cleanUp runs the clean up function after the scenario, logging instead of failing on errors
+func cleanUp(t *testing.T, description string, cleanUp func() error) {
+	t.Cleanup(func() {
+		if err := cleanUp(); err != nil {
+			t.Logf("Unable to %s: %v", description, err)
+		}
+	})
+}
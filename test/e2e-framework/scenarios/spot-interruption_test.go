@@ -0,0 +1,50 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build e2e
+// +build e2e
+
+package scenarios_test
+
+import (
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+	"github.com/aws/aws-node-termination-handler/test/e2e-framework/framework"
+)
+
+func TestSpotInterruptionDrainsNode(t *testing.T) {
+	node := f.WorkerNodes[0]
+	cleanUp(t, "reset the node", func() error { return f.ResetNode(node) })
+
+	h.Ok(t, f.DeployWorkload("spot-itn-workload", node, 2))
+	cleanUp(t, "delete the workload", func() error { return f.DeleteWorkload("spot-itn-workload") })
+
+	metadataURL, err := f.DeployMetadataProxy(map[string]string{"ENABLE_SPOT_ITN": "true"})
+	cleanUp(t, "delete the metadata proxy", f.DeleteMetadataProxy)
+	h.Ok(t, err)
+
+	nodeSelector, nodeName := framework.NodeSelectorValue(node)
+	h.Ok(t, f.InstallNTH(map[string]string{
+		"instanceMetadataURL":            metadataURL,
+		"enableSpotInterruptionDraining": "true",
+		"enableScheduledEventDraining":   "false",
+		"taintNode":                      "true",
+		nodeSelector:                     nodeName,
+	}))
+	cleanUp(t, "uninstall node termination handler", f.UninstallNTH)
+
+	h.Ok(t, f.WaitForNodeCordoned(node))
+	h.Ok(t, f.WaitForNodeTaint(node, "aws-node-termination-handler/spot-itn"))
+	h.Ok(t, f.WaitForPodsEvicted("spot-itn-workload", node))
+}
@@ -0,0 +1,87 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// enableCancellation cancels the events a while after they were first served, to exercise uncordoning on
+	// cancellation and the handling of stale events
+	enableCancellation = getBoolEnv("ENABLE_EVENT_CANCELLATION", false)
+	cancellationDelay  = getDurationEnv("CANCELLATION_DELAY", 30)
+	// scheduledEventFinalState is the state scheduled events are flipped to, canceled or completed
+	scheduledEventFinalState = getEnv("SCHEDULED_EVENT_FINAL_STATE", "canceled")
+)
+
+// servedTracker tracks when the event paths of the instances were first served
+type servedTracker struct {
+	mutex  sync.Mutex
+	served map[servedKey]time.Time
+}
+
+type servedKey struct {
+	instance int
+	path     string
+}
+
+var served = &servedTracker{served: map[servedKey]time.Time{}}
+
+func (t *servedTracker) markServed(instance int, path string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	key := servedKey{instance: instance, path: path}
+	if _, ok := t.served[key]; !ok {
+		t.served[key] = time.Now()
+	}
+}
+
+// servedBefore returns whether the path of the instance was first served at least the duration ago
+func (t *servedTracker) servedBefore(instance int, path string, duration time.Duration) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	first, ok := t.served[servedKey{instance: instance, path: path}]
+	return ok && time.Since(first) >= duration
+}
+
+// cancel removes the spot ITN and flips the scheduled events to their final state once they were served for the
+// cancellation delay
+func (s eventState) cancel(instance int) eventState {
+	if !enableCancellation {
+		return s
+	}
+	if s.spotITN != nil && served.servedBefore(instance, instanceActionPath, cancellationDelay) {
+		s.spotITN = nil
+	}
+	if len(s.scheduledEvents) > 0 && served.servedBefore(instance, scheduledEventPath, cancellationDelay) {
+		events := make([]scheduledEvent, len(s.scheduledEvents))
+		for i, event := range s.scheduledEvents {
+			event.State = scheduledEventFinalState
+			events[i] = event
+		}
+		s.scheduledEvents = events
+	}
+	return s
+}
+
+func validateCancellation() {
+	switch scheduledEventFinalState {
+	case "canceled", "completed":
+	default:
+		log.Fatalf("Invalid SCHEDULED_EVENT_FINAL_STATE %q  Should be one of: canceled, completed", scheduledEventFinalState)
+	}
+}
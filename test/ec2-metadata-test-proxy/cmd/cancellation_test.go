@@ -0,0 +1,43 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestCancel(t *testing.T) {
+	enableCancellation, cancellationDelay = true, 0
+	served = &servedTracker{served: map[servedKey]time.Time{}}
+	defer func() { enableCancellation, cancellationDelay = false, 30*time.Second }()
+
+	state := eventState{
+		spotITN:         &instanceAction{Action: "terminate"},
+		scheduledEvents: []scheduledEvent{{Code: "system-reboot", State: "active"}},
+	}
+	cancelled := state.cancel(0)
+	h.Assert(t, cancelled.spotITN != nil, "Spot ITN removed before it was served")
+	h.Equals(t, "active", cancelled.scheduledEvents[0].State)
+
+	served.markServed(0, instanceActionPath)
+	served.markServed(0, scheduledEventPath)
+	h.Assert(t, state.cancel(1).spotITN != nil, "Spot ITN of another instance removed")
+	cancelled = state.cancel(0)
+	h.Assert(t, cancelled.spotITN == nil, "Spot ITN not removed after it was served")
+	h.Equals(t, "canceled", cancelled.scheduledEvents[0].State)
+	h.Equals(t, "active", state.scheduledEvents[0].State)
+}
@@ -0,0 +1,140 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	chaosSpotITN                 = "spot-itn"
+	chaosRebalanceRecommendation = "rebalance-recommendation"
+	chaosScheduledEvent          = "scheduled-event"
+	// chaosRebalanceLifetime is how long a rebalance recommendation is served
+	chaosRebalanceLifetime = 10 * time.Minute
+	// a scheduled event is noticed chaosScheduledEventNotice ahead of its window, and served until the window ends
+	chaosScheduledEventNotice = 5 * time.Minute
+	chaosScheduledEventWindow = 10 * time.Minute
+)
+
+var chaosScheduledEventCodes = []string{"instance-reboot", "system-reboot", "system-maintenance", "instance-retirement", "instance-stop"}
+
+// chaosMode emits randomized events at random intervals for every instance of the simulated fleet, to soak test the
+// deduplication, concurrency limits and metrics of the handler under sustained churn
+type chaosMode struct {
+	meanInterval time.Duration
+	kinds        []string
+	mutex        sync.Mutex
+	timelines    []*chaosTimeline
+}
+
+// chaosTimeline is the events of an instance, generated as far as they were needed
+type chaosTimeline struct {
+	random         *rand.Rand
+	events         []chaosEvent
+	generatedUntil time.Duration
+}
+
+type chaosEvent struct {
+	kind string
+	at   time.Duration
+	code string
+}
+
+// chaos, if ENABLE_CHAOS_MODE is set, replaces the configured events and the scenario
+var chaos *chaosMode
+
+// configuredChaos returns the chaos mode configured with the env vars, nil if it is not enabled
+func configuredChaos() *chaosMode {
+	if !getBoolEnv("ENABLE_CHAOS_MODE", false) {
+		return nil
+	}
+	fleetSize := int(getInt64Env("CHAOS_FLEET_SIZE", int64(len(fleet))))
+	meanInterval := getDurationEnv("CHAOS_MEAN_INTERVAL", 300)
+	if fleetSize < len(fleet) || meanInterval <= 0 {
+		log.Fatalf("CHAOS_FLEET_SIZE must be at least the number of instances and CHAOS_MEAN_INTERVAL positive")
+	}
+	kinds := strings.Split(getEnv("CHAOS_EVENTS", strings.Join([]string{chaosSpotITN, chaosRebalanceRecommendation, chaosScheduledEvent}, ",")), ",")
+	for _, kind := range kinds {
+		switch kind {
+		case chaosSpotITN, chaosRebalanceRecommendation, chaosScheduledEvent:
+		default:
+			log.Fatalf("Invalid CHAOS_EVENTS entry %q  Should be one of: %s, %s, %s", kind, chaosSpotITN, chaosRebalanceRecommendation, chaosScheduledEvent)
+		}
+	}
+	return newChaosMode(fleetSize, meanInterval, kinds, randomSeed)
+}
+
+// newChaosMode returns a chaos mode whose events are reproducible with the seed
+func newChaosMode(fleetSize int, meanInterval time.Duration, kinds []string, seed int64) *chaosMode {
+	mode := &chaosMode{meanInterval: meanInterval, kinds: kinds}
+	for index := 0; index < fleetSize; index++ {
+		mode.timelines = append(mode.timelines, &chaosTimeline{random: rand.New(rand.NewSource(seed + int64(index)))})
+	}
+	return mode
+}
+
+// stateAt returns the events served for the instance at the time since the start of the proxy
+func (c *chaosMode) stateAt(index int, elapsed time.Duration) eventState {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timeline := c.timelines[index]
+	for timeline.generatedUntil <= elapsed {
+		event := chaosEvent{
+			kind: c.kinds[timeline.random.Intn(len(c.kinds))],
+			at:   timeline.generatedUntil + time.Duration(timeline.random.ExpFloat64()*float64(c.meanInterval)),
+		}
+		if event.kind == chaosScheduledEvent {
+			event.code = chaosScheduledEventCodes[timeline.random.Intn(len(chaosScheduledEventCodes))]
+		}
+		timeline.events = append(timeline.events, event)
+		timeline.generatedUntil = event.at + time.Second
+	}
+
+	state := eventState{targetLifecycleState: targetLifecycleState, scheduledEvents: []scheduledEvent{}}
+	for i, event := range timeline.events {
+		if event.at > elapsed {
+			break
+		}
+		switch event.kind {
+		case chaosSpotITN:
+			// the notice is served until the interruption
+			if elapsed < event.at+spotITNDeadline {
+				state.spotITN = &instanceAction{Action: spotITNAction, Time: imdsTime(event.at + spotITNDeadline)}
+			}
+		case chaosRebalanceRecommendation:
+			if elapsed < event.at+chaosRebalanceLifetime {
+				state.rebalanceRecommendation = &rebalanceRecommendation{NoticeTime: imdsTime(event.at)}
+			}
+		case chaosScheduledEvent:
+			notBefore := event.at + chaosScheduledEventNotice
+			if elapsed < notBefore+chaosScheduledEventWindow {
+				state.scheduledEvents = withScheduledEvent(state.scheduledEvents, scheduledEventConfig{
+					EventID:     fmt.Sprintf("instance-event-%08x%09x", index, i),
+					Code:        event.code,
+					Description: event.code,
+					State:       "active",
+					NotBefore:   int(notBefore.Seconds()),
+					NotAfter:    int((notBefore + chaosScheduledEventWindow).Seconds()),
+				})
+			}
+		}
+	}
+	return state
+}
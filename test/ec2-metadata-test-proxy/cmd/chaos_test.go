@@ -0,0 +1,44 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestChaosMode(t *testing.T) {
+	kinds := []string{chaosSpotITN, chaosRebalanceRecommendation, chaosScheduledEvent}
+	mode := newChaosMode(3, time.Minute, kinds, 42)
+	replay := newChaosMode(3, time.Minute, kinds, 42)
+
+	exposed := 0
+	for elapsed := time.Duration(0); elapsed < 2*time.Hour; elapsed += 30 * time.Second {
+		for index := 0; index < 3; index++ {
+			state := mode.stateAt(index, elapsed)
+			h.Equals(t, state, replay.stateAt(index, elapsed))
+			if state.spotITN != nil || state.rebalanceRecommendation != nil || len(state.scheduledEvents) > 0 {
+				exposed++
+			}
+		}
+	}
+	h.Assert(t, exposed > 0, "No random events exposed in two hours")
+	h.Assert(t, len(mode.timelines[0].events) != len(mode.timelines[1].events) || mode.timelines[0].events[0] != mode.timelines[1].events[0], "Instances got the same events")
+
+	onlySpot := newChaosMode(1, time.Minute, []string{chaosSpotITN}, 42)
+	state := onlySpot.stateAt(0, 2*time.Hour)
+	h.Assert(t, state.rebalanceRecommendation == nil && len(state.scheduledEvents) == 0, "Events exposed which are not in CHAOS_EVENTS")
+}
@@ -0,0 +1,292 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	instanceActionPath          = "/latest/meta-data/spot/instance-action"
+	scheduledEventPath          = "/latest/meta-data/events/maintenance/scheduled"
+	rebalanceRecommendationPath = "/latest/meta-data/events/recommendations/rebalance"
+	tokenPath                   = "/latest/api/token"
+	instanceIDPath              = "/latest/meta-data/instance-id"
+	instanceLifeCyclePath       = "/latest/meta-data/instance-life-cycle"
+	instanceTypePath            = "/latest/meta-data/instance-type"
+	publicHostnamePath          = "/latest/meta-data/public-hostname"
+	publicIPPath                = "/latest/meta-data/public-ipv4"
+	localHostnamePath           = "/latest/meta-data/local-hostname"
+	localIPPath                 = "/latest/meta-data/local-ipv4"
+	azPath                      = "/latest/meta-data/placement/availability-zone"
+	targetLifecycleStatePath    = "/latest/meta-data/autoscaling/target-lifecycle-state"
+
+	tokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds"
+	tokenHeader    = "X-aws-ec2-metadata-token"
+
+	// imdsTimeFormat is the time format of the IMDS events
+	imdsTimeFormat = "2006-01-02T15:04:05Z"
+	// scheduledEventTimeFormat is the time format of the scheduled maintenance events
+	scheduledEventTimeFormat = "2 Jan 2006 15:04:05 GMT"
+)
+
+// the static metadata of the configured instance by path
+var staticMetadata = configuredMetadata().paths()
+
+var startTime = time.Now()
+
+// Get env var or default
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func getBoolEnv(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, strconv.FormatBool(fallback)))
+	if err != nil {
+		log.Fatalf("Unable to parse %s as a bool: %v", key, err)
+	}
+	return value
+}
+
+// getDurationEnv returns the env var in seconds as a duration
+func getDurationEnv(key string, fallback int) time.Duration {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(fallback)))
+	if err != nil {
+		log.Fatalf("Unable to parse %s as seconds: %v", key, err)
+	}
+	return time.Duration(value) * time.Second
+}
+
+// Get the port to listen on
+func getListenAddress() string {
+	port := getEnv("PORT", "1338")
+	return ":" + port
+}
+
+var (
+	enableIMDSv2                  = getBoolEnv("ENABLE_IMDS_V2", false)
+	enableSpotITN                 = getBoolEnv("ENABLE_SPOT_ITN", true)
+	enableScheduledEvents         = getBoolEnv("ENABLE_SCHEDULED_MAINTENANCE_EVENTS", false)
+	scheduledEvents               = configuredScheduledEvents()
+	enableRebalanceRecommendation = getBoolEnv("ENABLE_REBALANCE_RECOMMENDATION", false)
+	interruptionNoticeDelay       = getDurationEnv("INTERRUPTION_NOTICE_DELAY", 0)
+	spotITNAction                 = getEnv("SPOT_ITN_ACTION", "terminate")
+	spotITNDeadline               = getDurationEnv("SPOT_ITN_DEADLINE", 120)
+	rebalanceRecommendationDelay  = getDurationEnv("REBALANCE_RECOMMENDATION_DELAY", 0)
+	// rebalanceNoticeTime is the noticeTime of the rebalance recommendation, the time it is exposed by default
+	rebalanceNoticeTime  = getEnv("REBALANCE_RECOMMENDATION_NOTICE_TIME", startTime.Add(rebalanceRecommendationDelay).UTC().Format(imdsTimeFormat))
+	targetLifecycleState = getEnv("ASG_TARGET_LIFECYCLE_STATE", "InService")
+	// targetLifecycleStateDelay, if set, is the time after start when the target lifecycle state transitions to
+	// ASG_NEXT_TARGET_LIFECYCLE_STATE
+	targetLifecycleStateDelay = getDurationEnv("ASG_TARGET_LIFECYCLE_STATE_DELAY", 0)
+	nextTargetLifecycleState  = getEnv("ASG_NEXT_TARGET_LIFECYCLE_STATE", "Terminated")
+)
+
+// instanceAction is the spot interruption notice
+type instanceAction struct {
+	Action string `json:"action"`
+	Time   string `json:"time"`
+}
+
+// scheduledEvent is a scheduled maintenance event
+type scheduledEvent struct {
+	NotBefore   string `json:"NotBefore"`
+	Code        string `json:"Code"`
+	Description string `json:"Description"`
+	EventID     string `json:"EventId"`
+	NotAfter    string `json:"NotAfter"`
+	State       string `json:"State"`
+}
+
+// rebalanceRecommendation is the rebalance recommendation signal
+type rebalanceRecommendation struct {
+	NoticeTime string `json:"noticeTime"`
+}
+
+func writeJSON(res http.ResponseWriter, body interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(res).Encode(body); err != nil {
+		log.Println("Unable to write the response: ", err)
+	}
+}
+
+// eventState is what the proxy serves at a point in time
+type eventState struct {
+	spotITN                 *instanceAction
+	rebalanceRecommendation *rebalanceRecommendation
+	scheduledEvents         []scheduledEvent
+	targetLifecycleState    string
+	// errorStatus, if set, is returned for every request
+	errorStatus int
+	faults      []fault
+}
+
+// scenario, if SCENARIO_FILE is set, replaces the events configured with the env vars
+var scenario *scenarioPlayback
+
+// imdsTime formats the time offset from the start of the proxy like IMDS
+func imdsTime(offset time.Duration) string {
+	return startTime.Add(offset).UTC().Format(imdsTimeFormat)
+}
+
+// configuredState returns the state configured with the env vars
+func configuredState(elapsed time.Duration) eventState {
+	state := eventState{targetLifecycleState: targetLifecycleState, scheduledEvents: []scheduledEvent{}}
+	if enableSpotITN && elapsed >= interruptionNoticeDelay {
+		state.spotITN = &instanceAction{Action: spotITNAction, Time: imdsTime(interruptionNoticeDelay + spotITNDeadline)}
+	}
+	if enableScheduledEvents && elapsed >= interruptionNoticeDelay {
+		for _, event := range scheduledEvents {
+			state.scheduledEvents = withScheduledEvent(state.scheduledEvents, event)
+		}
+	}
+	if enableRebalanceRecommendation && elapsed >= rebalanceRecommendationDelay {
+		state.rebalanceRecommendation = &rebalanceRecommendation{NoticeTime: rebalanceNoticeTime}
+	}
+	if targetLifecycleStateDelay > 0 && elapsed >= targetLifecycleStateDelay {
+		state.targetLifecycleState = nextTargetLifecycleState
+	}
+	return state
+}
+
+// currentState returns what is served for the instance now
+func currentState(instance int) eventState {
+	elapsed := time.Since(startTime)
+	state := configuredState(elapsed)
+	if chaos != nil {
+		state = chaos.stateAt(instance, elapsed)
+	} else if fleet[instance].scenario != nil {
+		state = fleet[instance].scenario.stateAt(elapsed)
+	} else if scenario != nil {
+		state = scenario.stateAt(elapsed)
+	}
+	if envFault != nil && envFault.activeAt(envFaultStart, elapsed) {
+		state.faults = append(state.faults, *envFault)
+	}
+	return state.cancel(instance)
+}
+
+func handleRequest(res http.ResponseWriter, req *http.Request) {
+	log.Println("GOT REQUEST: ", req.URL.Path)
+	if throttle(res) {
+		return
+	}
+	instance := instanceOf(req)
+	state := currentState(instance)
+	if state.errorStatus != 0 {
+		res.WriteHeader(state.errorStatus)
+		return
+	}
+	if injectFaults(state.faults, res, req) {
+		return
+	}
+	if req.URL.Path == tokenPath {
+		handleToken(res, req)
+		return
+	}
+
+	// every metadata path requires the token like IMDS with http-tokens required
+	if !authorized(req) {
+		res.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch req.URL.Path {
+	case instanceActionPath:
+		if state.spotITN == nil {
+			http.NotFound(res, req)
+			return
+		}
+		served.markServed(instance, instanceActionPath)
+		writeJSON(res, state.spotITN)
+	case scheduledEventPath:
+		if len(state.scheduledEvents) > 0 {
+			served.markServed(instance, scheduledEventPath)
+		}
+		writeJSON(res, state.scheduledEvents)
+	case rebalanceRecommendationPath:
+		if state.rebalanceRecommendation == nil {
+			http.NotFound(res, req)
+			return
+		}
+		writeJSON(res, state.rebalanceRecommendation)
+	case targetLifecycleStatePath:
+		res.Write([]byte(state.targetLifecycleState))
+	default:
+		metadata := fleet[instance].metadata
+		if value, ok := metadata[req.URL.Path]; ok {
+			res.Write([]byte(value))
+			return
+		}
+		if children, ok := listing(state.leaves(metadata), req.URL.Path); ok {
+			res.Write([]byte(children))
+			return
+		}
+		http.NotFound(res, req)
+	}
+}
+
+func main() {
+	validateCancellation()
+	if !validSpotITNAction(spotITNAction) {
+		log.Fatalf("Invalid SPOT_ITN_ACTION %q  Should be one of: terminate, stop, hibernate", spotITNAction)
+	}
+	if scenarioFile := getEnv("SCENARIO_FILE", ""); scenarioFile != "" {
+		playback, err := loadScenario(scenarioFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		scenario = playback
+		log.Printf("Playing back the %d steps of the scenario %s", len(playback.steps), scenarioFile)
+	}
+	if instancesFile := getEnv("INSTANCES_FILE", ""); instancesFile != "" {
+		instances, err := loadFleet(instancesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fleet = instances
+	}
+	if chaos = configuredChaos(); chaos != nil {
+		fleet = extendFleet(fleet, len(chaos.timelines))
+		log.Printf("Emitting random events for a fleet of %d instances", len(fleet))
+	}
+	if len(fleet) > 1 {
+		log.Printf("Simulating %d instances, selected with %s<index or instance ID>/ or the %s header", len(fleet), instancePathPrefix, instanceHeader)
+	}
+	log.Println("The ec2-metadata-test-proxy started on port ", getListenAddress())
+	// start server
+	http.HandleFunc("/", selectInstance(countRequests(handleRequest)))
+	http.HandleFunc(metricsPath, handleMetrics)
+	for index, instance := range fleet {
+		if instance.port == 0 {
+			continue
+		}
+		address := ":" + strconv.Itoa(instance.port)
+		log.Printf("Serving instance %s on port %d", instance.metadata[instanceIDPath], instance.port)
+		go func(index int) {
+			log.Fatal(listenAndServe(address, pinInstance(index, http.DefaultServeMux)))
+		}(index)
+	}
+	if err := listenAndServe(getListenAddress(), nil); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fault injects latency and failures into the responses of matching paths
+type fault struct {
+	// Paths are the path prefixes the fault applies to, all paths if empty
+	Paths []string `json:"paths,omitempty"`
+	// Latency delays the responses, in milliseconds
+	Latency int `json:"latency,omitempty"`
+	// Status fails the responses with the status
+	Status int `json:"status,omitempty"`
+	// Reset closes the connections without a response
+	Reset bool `json:"reset,omitempty"`
+	// Probability is the probability of the fault applying to a request, 1 if zero
+	Probability float64 `json:"probability,omitempty"`
+	// Duration is the time in seconds the fault applies for in a scenario, until the end if zero
+	Duration int `json:"duration,omitempty"`
+}
+
+var (
+	randomMutex sync.Mutex
+	// randomSeed makes the random decisions reproducible, e.g. whether faults apply
+	randomSeed = getInt64Env("RANDOM_SEED", time.Now().UnixNano())
+	random     = rand.New(rand.NewSource(randomSeed))
+)
+
+func getInt64Env(key string, fallback int64) int64 {
+	value, err := strconv.ParseInt(getEnv(key, strconv.FormatInt(fallback, 10)), 10, 64)
+	if err != nil {
+		log.Fatalf("Unable to parse %s as an integer: %v", key, err)
+	}
+	return value
+}
+
+// configuredFault returns the fault configured with the env vars, nil if there is none
+func configuredFault() *fault {
+	configured := fault{
+		Latency: int(getInt64Env("FAULT_LATENCY", 0)),
+		Status:  int(getInt64Env("FAULT_STATUS", 0)),
+		Reset:   getBoolEnv("FAULT_RESET", false),
+		// the fault applies from FAULT_START for FAULT_DURATION seconds
+		Duration: int(getInt64Env("FAULT_DURATION", 0)),
+	}
+	if configured.Latency == 0 && configured.Status == 0 && !configured.Reset {
+		return nil
+	}
+	if paths := getEnv("FAULT_PATHS", ""); paths != "" {
+		configured.Paths = strings.Split(paths, ",")
+	}
+	probability, err := strconv.ParseFloat(getEnv("FAULT_PROBABILITY", "1"), 64)
+	if err != nil {
+		log.Fatalf("Unable to parse FAULT_PROBABILITY as a number: %v", err)
+	}
+	configured.Probability = probability
+	if err := configured.validate(); err != nil {
+		log.Fatalf("Invalid fault configuration: %v", err)
+	}
+	return &configured
+}
+
+func (f fault) validate() error {
+	if f.Status != 0 && f.Status < 400 {
+		return fmt.Errorf("status %d is not an error status", f.Status)
+	}
+	if f.Latency < 0 || f.Duration < 0 {
+		return fmt.Errorf("latency and duration must not be negative")
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("probability %v must be between 0 and 1", f.Probability)
+	}
+	return nil
+}
+
+var (
+	envFault      = configuredFault()
+	envFaultStart = getDurationEnv("FAULT_START", 0)
+)
+
+// activeAt returns whether a fault starting at start applies at the time since the start of the proxy
+func (f fault) activeAt(start time.Duration, elapsed time.Duration) bool {
+	return elapsed >= start && (f.Duration == 0 || elapsed < start+seconds(f.Duration))
+}
+
+func (f fault) matches(path string) bool {
+	if len(f.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range f.Paths {
+		if strings.HasPrefix(path, strings.TrimSpace(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fault) occurs() bool {
+	if f.Probability == 0 || f.Probability >= 1 {
+		return true
+	}
+	randomMutex.Lock()
+	defer randomMutex.Unlock()
+	return random.Float64() < f.Probability
+}
+
+// injectFaults applies the faults matching the request, it returns true if the request was failed
+func injectFaults(faults []fault, res http.ResponseWriter, req *http.Request) bool {
+	for _, f := range faults {
+		if !f.matches(req.URL.Path) || !f.occurs() {
+			continue
+		}
+		if f.Latency > 0 {
+			time.Sleep(time.Duration(f.Latency) * time.Millisecond)
+		}
+		if f.Reset {
+			resetConnection(res)
+			return true
+		}
+		if f.Status != 0 {
+			res.WriteHeader(f.Status)
+			return true
+		}
+	}
+	return false
+}
+
+// resetConnection closes the connection of the request without a response, with a TCP RST where possible
+func resetConnection(res http.ResponseWriter) {
+	hijacker, ok := res.(http.Hijacker)
+	if !ok {
+		log.Println("Unable to reset the connection, failing with a server error instead")
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		log.Println("Unable to reset the connection: ", err)
+		return
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
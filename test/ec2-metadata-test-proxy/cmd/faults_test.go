@@ -0,0 +1,37 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestInjectFaults(t *testing.T) {
+	faults := []fault{{Paths: []string{"/latest/meta-data/spot"}, Status: http.StatusInternalServerError}}
+
+	res := httptest.NewRecorder()
+	h.Assert(t, !injectFaults(faults, res, httptest.NewRequest(http.MethodGet, instanceIDPath, nil)), "Fault injected into a path it does not apply to")
+
+	res = httptest.NewRecorder()
+	h.Assert(t, injectFaults(faults, res, httptest.NewRequest(http.MethodGet, instanceActionPath, nil)), "Fault not injected")
+	h.Equals(t, http.StatusInternalServerError, res.Code)
+
+	faults[0].Probability = 0.000001
+	res = httptest.NewRecorder()
+	h.Assert(t, !injectFaults(faults, res, httptest.NewRequest(http.MethodGet, instanceActionPath, nil)), "Improbable fault injected")
+}
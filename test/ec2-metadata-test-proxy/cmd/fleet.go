@@ -0,0 +1,179 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// instancePathPrefix selects an instance of the simulated fleet by index or instance ID, e.g.
+	// /instances/2/latest/meta-data/instance-id, so a handler can be pointed at an instance with its metadata URL
+	instancePathPrefix = "/instances/"
+	// instanceHeader selects an instance of the simulated fleet by index or instance ID, for clients sending headers
+	instanceHeader = "X-Test-Proxy-Instance"
+)
+
+type instanceKey struct{}
+
+// simulatedInstance is an instance of the simulated fleet with its own metadata and events
+type simulatedInstance struct {
+	// metadata is the static metadata by path
+	metadata map[string]string
+	// scenario, if set, replaces the events of the proxy for the instance
+	scenario *scenarioPlayback
+	// port, if set, serves the instance on its own port
+	port int
+}
+
+// fleet is the simulated fleet, the first instance is the configured one
+var fleet = []simulatedInstance{{metadata: staticMetadata}}
+
+// instancesFile is the format of the INSTANCES_FILE
+type instancesFile struct {
+	Instances []instanceConfig `json:"instances"`
+}
+
+// instanceConfig configures an instance of the simulated fleet
+type instanceConfig struct {
+	// Metadata is like the METADATA_FILE, the values it does not set are derived from the configured metadata
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+	// ScenarioFile is relative to the instances file
+	ScenarioFile string `json:"scenarioFile,omitempty"`
+	Port         int    `json:"port,omitempty"`
+}
+
+// derivedMetadata returns the metadata of the instance with the index derived from the configured one, with its own
+// instance ID, private IP and hostname
+func derivedMetadata(index int) instanceMetadata {
+	metadata := configuredMetadata()
+	if index == 0 {
+		return metadata
+	}
+	metadata.InstanceID = fmt.Sprintf("i-%017x", index)
+	metadata.LocalIP = fmt.Sprintf("10.0.%d.%d", index/250, index%250+4)
+	metadata.LocalHostname = fmt.Sprintf("ip-%s.ec2.internal", strings.ReplaceAll(metadata.LocalIP, ".", "-"))
+	metadata.PublicIP = ""
+	metadata.PublicHostname = ""
+	return metadata
+}
+
+// loadFleet reads the instances of the simulated fleet in a YAML or JSON file
+func loadFleet(path string) ([]simulatedInstance, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open instances file: %w", err)
+	}
+	defer file.Close()
+	config := instancesFile{}
+	err = yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&config)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse instances file %s: %w", path, err)
+	}
+	if len(config.Instances) == 0 {
+		return nil, fmt.Errorf("Instances file %s has no instances", path)
+	}
+	instances := []simulatedInstance{}
+	instanceIDs := map[string]bool{}
+	for i, instanceConfig := range config.Instances {
+		metadata := derivedMetadata(i)
+		if len(instanceConfig.Metadata) > 0 {
+			if err := json.Unmarshal(instanceConfig.Metadata, &metadata); err != nil {
+				return nil, fmt.Errorf("Unable to parse the metadata of instance %d: %w", i, err)
+			}
+		}
+		if instanceIDs[metadata.InstanceID] {
+			return nil, fmt.Errorf("Instance %d has the instance ID %s of an earlier instance", i, metadata.InstanceID)
+		}
+		instanceIDs[metadata.InstanceID] = true
+		instance := simulatedInstance{metadata: metadata.paths(), port: instanceConfig.Port}
+		if scenarioFile := instanceConfig.ScenarioFile; scenarioFile != "" {
+			if !filepath.IsAbs(scenarioFile) {
+				scenarioFile = filepath.Join(filepath.Dir(path), scenarioFile)
+			}
+			if instance.scenario, err = loadScenario(scenarioFile); err != nil {
+				return nil, fmt.Errorf("Invalid scenario of instance %d: %w", i, err)
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// extendFleet adds instances derived from the configured one up to the size
+func extendFleet(instances []simulatedInstance, size int) []simulatedInstance {
+	for index := len(instances); index < size; index++ {
+		instances = append(instances, simulatedInstance{metadata: derivedMetadata(index).paths()})
+	}
+	return instances
+}
+
+// findInstance returns the index of the instance with the index or instance ID
+func findInstance(key string) (int, bool) {
+	if index, err := strconv.Atoi(key); err == nil {
+		return index, index >= 0 && index < len(fleet)
+	}
+	for index, instance := range fleet {
+		if instance.metadata[instanceIDPath] == key {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// selectInstance passes the index of the instance selected with the instance path prefix, which is stripped, or the
+// instance header in the request context. Requests without either are for the instance of the port.
+func selectInstance(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		index := instanceOf(req)
+		ok := true
+		if strings.HasPrefix(req.URL.Path, instancePathPrefix) {
+			parts := strings.SplitN(strings.TrimPrefix(req.URL.Path, instancePathPrefix), "/", 2)
+			index, ok = findInstance(parts[0])
+			req.URL.Path = "/"
+			if len(parts) == 2 {
+				req.URL.Path += parts[1]
+			}
+		} else if key := req.Header.Get(instanceHeader); key != "" {
+			index, ok = findInstance(key)
+		}
+		if !ok {
+			http.NotFound(res, req)
+			return
+		}
+		handler(res, req.WithContext(context.WithValue(req.Context(), instanceKey{}, index)))
+	}
+}
+
+// pinInstance serves the instance with the handler, for the port of the instance
+func pinInstance(index int, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		handler.ServeHTTP(res, req.WithContext(context.WithValue(req.Context(), instanceKey{}, index)))
+	})
+}
+
+// instanceOf returns the index of the instance the request is for
+func instanceOf(req *http.Request) int {
+	index, _ := req.Context().Value(instanceKey{}).(int)
+	return index
+}
@@ -0,0 +1,65 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestLoadFleet(t *testing.T) {
+	instances, err := loadFleet("../instances/two-nodes.yaml")
+	h.Ok(t, err)
+	h.Equals(t, 2, len(instances))
+	h.Equals(t, "i-0a1b2c3d4e5f60002", instances[1].metadata[instanceIDPath])
+	h.Equals(t, "us-east-1", instances[1].metadata[regionPath])
+	h.Equals(t, staticMetadata[instanceLifeCyclePath], instances[1].metadata[instanceLifeCyclePath])
+	h.Equals(t, 1340, instances[1].port)
+
+	// the instances play back their own scenarios
+	h.Assert(t, instances[0].scenario.stateAt(60*time.Second).rebalanceRecommendation != nil, "Rebalance recommendation of the first instance not exposed")
+	h.Assert(t, instances[1].scenario.stateAt(60*time.Second).rebalanceRecommendation == nil, "Rebalance recommendation of the first instance exposed on the second")
+	h.Equals(t, 1, len(instances[1].scenario.stateAt(30*time.Second).scheduledEvents))
+}
+
+func TestSelectInstance(t *testing.T) {
+	configured := fleet
+	defer func() { fleet = configured }()
+	fleet = extendFleet(fleet, 3)
+
+	get := func(handler http.Handler, path string, header string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if header != "" {
+			req.Header.Set(instanceHeader, header)
+		}
+		handler.ServeHTTP(res, req)
+		return res
+	}
+	handler := selectInstance(handleRequest)
+
+	res := get(handler, instancePathPrefix+"2"+instanceIDPath, "")
+	h.Equals(t, http.StatusOK, res.Code)
+	h.Equals(t, "i-00000000000000002", res.Body.String())
+	h.Equals(t, "10.0.0.6", get(handler, instancePathPrefix+"i-00000000000000002"+localIPPath, "").Body.String())
+	h.Equals(t, staticMetadata[instanceIDPath], get(handler, instanceIDPath, "").Body.String())
+	h.Equals(t, "i-00000000000000001", get(handler, instanceIDPath, "1").Body.String())
+	h.Equals(t, "i-00000000000000001", get(pinInstance(1, handler), instanceIDPath, "").Body.String())
+	h.Equals(t, http.StatusNotFound, get(handler, instancePathPrefix+"3"+instanceIDPath, "").Code)
+	h.Equals(t, http.StatusNotFound, get(handler, instanceIDPath, "i-unknown").Code)
+}
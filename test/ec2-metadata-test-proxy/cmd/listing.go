@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// leaves returns the paths of the metadata served in the state, with the static metadata of the instance
+func (s eventState) leaves(metadata map[string]string) []string {
+	leaves := []string{scheduledEventPath, targetLifecycleStatePath}
+	for path := range metadata {
+		leaves = append(leaves, path)
+	}
+	if s.spotITN != nil {
+		leaves = append(leaves, instanceActionPath)
+	}
+	if s.rebalanceRecommendation != nil {
+		leaves = append(leaves, rebalanceRecommendationPath)
+	}
+	return leaves
+}
+
+// listing returns the newline separated children of the directory like IMDS, directories with a trailing slash, and
+// false if the path is not a directory
+func listing(leaves []string, path string) (string, bool) {
+	dir := strings.TrimSuffix(path, "/") + "/"
+	children := map[string]bool{}
+	for _, leaf := range leaves {
+		if !strings.HasPrefix(leaf, dir) {
+			continue
+		}
+		child := strings.TrimPrefix(leaf, dir)
+		if i := strings.Index(child, "/"); i >= 0 {
+			child = child[:i+1]
+		}
+		children[child] = true
+	}
+	if len(children) == 0 {
+		return "", false
+	}
+	names := make([]string, 0, len(children))
+	for child := range children {
+		names = append(names, child)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "\n"), true
+}
@@ -0,0 +1,41 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestListing(t *testing.T) {
+	leaves := eventState{}.leaves(staticMetadata)
+
+	children, ok := listing(leaves, "/latest/meta-data/")
+	h.Assert(t, ok, "/latest/meta-data/ is not a directory")
+	h.Equals(t, "ami-id\nautoscaling/\nevents/\ninstance-id\ninstance-life-cycle\ninstance-type\nlocal-hostname\nlocal-ipv4\nplacement/\npublic-hostname\npublic-ipv4", children)
+
+	children, ok = listing(leaves, "/latest/meta-data/events")
+	h.Assert(t, ok, "/latest/meta-data/events is not a directory")
+	h.Equals(t, "maintenance/", children)
+
+	_, ok = listing(leaves, "/latest/meta-data/instance-id")
+	h.Assert(t, !ok, "A leaf is listed as a directory")
+
+	res := httptest.NewRecorder()
+	handleRequest(res, httptest.NewRequest(http.MethodGet, "/latest/meta-data/unknown", nil))
+	h.Equals(t, http.StatusNotFound, res.Code)
+}
@@ -0,0 +1,193 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	regionPath                = "/latest/meta-data/placement/region"
+	amiIDPath                 = "/latest/meta-data/ami-id"
+	identityDocumentPath      = "/latest/dynamic/instance-identity/document"
+	identitySignaturePath     = "/latest/dynamic/instance-identity/signature"
+	identityPKCS7Path         = "/latest/dynamic/instance-identity/pkcs7"
+	identityRSA2048Path       = "/latest/dynamic/instance-identity/rsa2048"
+	identitySignatureStub     = "c2lnbmF0dXJlIHN0dWIgb2YgdGhlIGVjMi1tZXRhZGF0YS10ZXN0LXByb3h5"
+	identityDocumentTimestamp = "2021-01-01T00:00:00Z"
+	instanceTagsPath          = "/latest/meta-data/tags/instance/"
+)
+
+// instanceMetadata is the static metadata of the simulated instance, set in the METADATA_FILE or with env vars
+type instanceMetadata struct {
+	AccountID         string `json:"accountId,omitempty"`
+	ImageID           string `json:"imageId,omitempty"`
+	Architecture      string `json:"architecture,omitempty"`
+	InstanceID        string `json:"instanceId,omitempty"`
+	InstanceType      string `json:"instanceType,omitempty"`
+	InstanceLifeCycle string `json:"instanceLifeCycle,omitempty"`
+	AvailabilityZone  string `json:"availabilityZone,omitempty"`
+	// Region is derived from the availability zone if empty
+	Region         string `json:"region,omitempty"`
+	PublicHostname string `json:"publicHostname,omitempty"`
+	PublicIP       string `json:"publicIpv4,omitempty"`
+	LocalHostname  string `json:"localHostname,omitempty"`
+	LocalIP        string `json:"localIpv4,omitempty"`
+	// Tags are the instance tags, served if there are any like with the instance metadata tags enabled
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+var defaultMetadata = instanceMetadata{
+	AccountID:         "123456789012",
+	ImageID:           "ami-0b69ea66ff7391e80",
+	Architecture:      "x86_64",
+	InstanceID:        "i-1234567890abcdef0",
+	InstanceType:      "m5.large",
+	InstanceLifeCycle: "spot",
+	AvailabilityZone:  "us-east-1a",
+	PublicHostname:    "ec2-192-0-2-54.compute-1.amazonaws.com",
+	PublicIP:          "192.0.2.54",
+	LocalHostname:     "ip-172-16-34-43.ec2.internal",
+	LocalIP:           "172.16.34.43",
+}
+
+// loadMetadata reads the metadata in a YAML or JSON file, the values it does not set are taken from defaults
+func loadMetadata(path string, defaults instanceMetadata) (instanceMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return defaults, fmt.Errorf("Unable to open metadata file: %w", err)
+	}
+	defer file.Close()
+	metadata := defaults
+	err = yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&metadata)
+	if err != nil {
+		return defaults, fmt.Errorf("Unable to parse metadata file %s: %w", path, err)
+	}
+	return metadata, nil
+}
+
+// configuredMetadata returns the metadata of the METADATA_FILE, overridden by the env vars
+func configuredMetadata() instanceMetadata {
+	metadata := defaultMetadata
+	if path := getEnv("METADATA_FILE", ""); path != "" {
+		var err error
+		if metadata, err = loadMetadata(path, defaultMetadata); err != nil {
+			log.Fatal(err)
+		}
+	}
+	metadata.AccountID = getEnv("ACCOUNT_ID", metadata.AccountID)
+	metadata.ImageID = getEnv("IMAGE_ID", metadata.ImageID)
+	metadata.Architecture = getEnv("ARCHITECTURE", metadata.Architecture)
+	metadata.InstanceID = getEnv("INSTANCE_ID", metadata.InstanceID)
+	metadata.InstanceType = getEnv("INSTANCE_TYPE", metadata.InstanceType)
+	metadata.InstanceLifeCycle = getEnv("INSTANCE_LIFE_CYCLE", metadata.InstanceLifeCycle)
+	metadata.AvailabilityZone = getEnv("AVAILABILITY_ZONE", metadata.AvailabilityZone)
+	metadata.Region = getEnv("REGION", metadata.Region)
+	metadata.PublicHostname = getEnv("PUBLIC_HOSTNAME", metadata.PublicHostname)
+	metadata.PublicIP = getEnv("PUBLIC_IPV4", metadata.PublicIP)
+	metadata.LocalHostname = getEnv("LOCAL_HOSTNAME", metadata.LocalHostname)
+	metadata.LocalIP = getEnv("LOCAL_IPV4", metadata.LocalIP)
+	if tags := getEnv("INSTANCE_TAGS", ""); tags != "" {
+		metadata.Tags = map[string]string{}
+		for _, tag := range strings.Split(tags, ",") {
+			keyValue := strings.SplitN(tag, "=", 2)
+			if len(keyValue) != 2 || keyValue[0] == "" {
+				log.Fatalf("Unable to parse INSTANCE_TAGS entry %q, must be key=value", tag)
+			}
+			metadata.Tags[keyValue[0]] = keyValue[1]
+		}
+	}
+	return metadata
+}
+
+func (m instanceMetadata) region() string {
+	if m.Region != "" {
+		return m.Region
+	}
+	return strings.TrimRight(m.AvailabilityZone, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// identityDocument is the instance identity document, consistent with the other metadata
+type identityDocument struct {
+	AccountID               string   `json:"accountId"`
+	Architecture            string   `json:"architecture"`
+	AvailabilityZone        string   `json:"availabilityZone"`
+	BillingProducts         []string `json:"billingProducts"`
+	DevpayProductCodes      []string `json:"devpayProductCodes"`
+	MarketplaceProductCodes []string `json:"marketplaceProductCodes"`
+	ImageID                 string   `json:"imageId"`
+	InstanceID              string   `json:"instanceId"`
+	InstanceType            string   `json:"instanceType"`
+	KernelID                *string  `json:"kernelId"`
+	PendingTime             string   `json:"pendingTime"`
+	PrivateIP               string   `json:"privateIp"`
+	RamdiskID               *string  `json:"ramdiskId"`
+	Region                  string   `json:"region"`
+	Version                 string   `json:"version"`
+}
+
+func (m instanceMetadata) identityDocument() string {
+	document, err := json.MarshalIndent(identityDocument{
+		AccountID:        m.AccountID,
+		Architecture:     m.Architecture,
+		AvailabilityZone: m.AvailabilityZone,
+		ImageID:          m.ImageID,
+		InstanceID:       m.InstanceID,
+		InstanceType:     m.InstanceType,
+		PendingTime:      identityDocumentTimestamp,
+		PrivateIP:        m.LocalIP,
+		Region:           m.region(),
+		Version:          "2017-09-30",
+	}, "", "  ")
+	if err != nil {
+		log.Fatalf("Unable to marshal the instance identity document: %v", err)
+	}
+	return string(document)
+}
+
+// paths returns the metadata by path
+func (m instanceMetadata) paths() map[string]string {
+	paths := map[string]string{
+		amiIDPath:             m.ImageID,
+		instanceIDPath:        m.InstanceID,
+		instanceLifeCyclePath: m.InstanceLifeCycle,
+		instanceTypePath:      m.InstanceType,
+		publicHostnamePath:    m.PublicHostname,
+		publicIPPath:          m.PublicIP,
+		localHostnamePath:     m.LocalHostname,
+		localIPPath:           m.LocalIP,
+		azPath:                m.AvailabilityZone,
+		regionPath:            m.region(),
+		identityDocumentPath:  m.identityDocument(),
+		// the signatures are stubs, they can not be verified
+		identitySignaturePath: identitySignatureStub,
+		identityPKCS7Path:     identitySignatureStub,
+		identityRSA2048Path:   identitySignatureStub,
+	}
+	for key, value := range m.Tags {
+		// IMDS does not serve tags with a slash in their key
+		if strings.Contains(key, "/") {
+			log.Printf("Not serving the instance tag %s, keys with a slash are not available in the instance metadata", key)
+			continue
+		}
+		paths[instanceTagsPath+key] = value
+	}
+	return paths
+}
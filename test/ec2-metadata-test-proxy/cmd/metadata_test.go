@@ -0,0 +1,58 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestLoadMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metadata")
+	h.Ok(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "metadata.yaml")
+	h.Ok(t, ioutil.WriteFile(path, []byte("instanceType: c6g.xlarge\navailabilityZone: eu-west-1b\ntags:\n  eks:cluster-name: prod\n  kubernetes.io/cluster/prod: owned\n"), 0600))
+
+	metadata, err := loadMetadata(path, defaultMetadata)
+	h.Ok(t, err)
+	paths := metadata.paths()
+	h.Equals(t, "c6g.xlarge", paths[instanceTypePath])
+	h.Equals(t, "eu-west-1", paths[regionPath])
+	h.Equals(t, defaultMetadata.InstanceID, paths[instanceIDPath])
+
+	document := identityDocument{}
+	h.Ok(t, json.Unmarshal([]byte(paths[identityDocumentPath]), &document))
+	h.Equals(t, "c6g.xlarge", document.InstanceType)
+	h.Equals(t, "eu-west-1", document.Region)
+	h.Equals(t, defaultMetadata.AccountID, document.AccountID)
+
+	children, ok := listing(leavesOf(paths), instanceTagsPath)
+	h.Assert(t, ok, "The instance tags are not listed")
+	h.Equals(t, "eks:cluster-name", children)
+	h.Equals(t, "prod", paths[instanceTagsPath+"eks:cluster-name"])
+}
+
+func leavesOf(paths map[string]string) []string {
+	leaves := []string{}
+	for path := range paths {
+		leaves = append(leaves, path)
+	}
+	return leaves
+}
@@ -0,0 +1,142 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const metricsPath = "/metrics"
+
+// requestCounts counts the requests by path and status, for soak tests to monitor the polling of the handler
+type requestCounts struct {
+	mutex  sync.Mutex
+	counts map[requestKey]int
+}
+
+type requestKey struct {
+	path   string
+	status string
+}
+
+var requests = &requestCounts{counts: map[requestKey]int{}}
+
+// statusRecorder records the status of the response, reset if the connection was hijacked to reset it
+type statusRecorder struct {
+	http.ResponseWriter
+	status string
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == "" {
+		r.status = strconv.Itoa(status)
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(body []byte) (int, error) {
+	if r.status == "" {
+		r.status = strconv.Itoa(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(body)
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer does not support hijacking")
+	}
+	r.status = "reset"
+	return hijacker.Hijack()
+}
+
+// countRequests counts the requests of the handler, the paths which are not served are counted as other to bound the
+// number of series
+func countRequests(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: res}
+		handler(recorder, req)
+		if recorder.status == "" {
+			recorder.status = strconv.Itoa(http.StatusOK)
+		}
+		path := req.URL.Path
+		if _, ok := fleet[instanceOf(req)].metadata[path]; !ok && !eventPath(path) {
+			path = "other"
+		}
+		requests.mutex.Lock()
+		defer requests.mutex.Unlock()
+		requests.counts[requestKey{path: path, status: recorder.status}]++
+	}
+}
+
+func eventPath(path string) bool {
+	switch path {
+	case tokenPath, instanceActionPath, scheduledEventPath, rebalanceRecommendationPath, targetLifecycleStatePath:
+		return true
+	}
+	return false
+}
+
+// handleMetrics serves the metrics in the Prometheus text format
+func handleMetrics(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintln(res, "# HELP ec2_metadata_test_proxy_requests_total Requests by path and status")
+	fmt.Fprintln(res, "# TYPE ec2_metadata_test_proxy_requests_total counter")
+	requests.mutex.Lock()
+	keys := make([]requestKey, 0, len(requests.counts))
+	for key := range requests.counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].status < keys[j].status
+	})
+	for _, key := range keys {
+		fmt.Fprintf(res, "ec2_metadata_test_proxy_requests_total{path=%q,status=%q} %d\n", key.path, key.status, requests.counts[key])
+	}
+	requests.mutex.Unlock()
+
+	// the events and faults are summed up over the instances of the simulated fleet
+	var rebalanceRecommendations, scheduledEvents, spotITNs, faults int
+	for index := range fleet {
+		state := currentState(index)
+		rebalanceRecommendations += boolValue(state.rebalanceRecommendation != nil)
+		scheduledEvents += len(state.scheduledEvents)
+		spotITNs += boolValue(state.spotITN != nil)
+		faults += len(state.faults) + boolValue(state.errorStatus != 0)
+	}
+	fmt.Fprintln(res, "# HELP ec2_metadata_test_proxy_events_exposed Simulated events currently served, by event")
+	fmt.Fprintln(res, "# TYPE ec2_metadata_test_proxy_events_exposed gauge")
+	fmt.Fprintf(res, "ec2_metadata_test_proxy_events_exposed{event=%q} %d\n", "rebalance_recommendation", rebalanceRecommendations)
+	fmt.Fprintf(res, "ec2_metadata_test_proxy_events_exposed{event=%q} %d\n", "scheduled_event", scheduledEvents)
+	fmt.Fprintf(res, "ec2_metadata_test_proxy_events_exposed{event=%q} %d\n", "spot_itn", spotITNs)
+	fmt.Fprintln(res, "# HELP ec2_metadata_test_proxy_faults_active Faults and server errors currently injected")
+	fmt.Fprintln(res, "# TYPE ec2_metadata_test_proxy_faults_active gauge")
+	fmt.Fprintf(res, "ec2_metadata_test_proxy_faults_active %d\n", faults)
+}
+
+func boolValue(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestMetrics(t *testing.T) {
+	handler := countRequests(handleRequest)
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, instanceIDPath, nil))
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/latest/meta-data/unknown", nil))
+
+	res := httptest.NewRecorder()
+	handleMetrics(res, httptest.NewRequest(http.MethodGet, metricsPath, nil))
+	metrics := res.Body.String()
+	h.Assert(t, strings.Contains(metrics, `ec2_metadata_test_proxy_requests_total{path="/latest/meta-data/instance-id",status="200"} 1`), "Request not counted: %s", metrics)
+	h.Assert(t, strings.Contains(metrics, `ec2_metadata_test_proxy_requests_total{path="other",status="404"} 1`), "Unknown path not counted as other: %s", metrics)
+}
@@ -0,0 +1,163 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// scenarioFile is the format of the SCENARIO_FILE, a timeline of changes to the served metadata
+type scenarioFile struct {
+	Steps []scenarioStep `json:"steps"`
+}
+
+// scenarioStep changes the served metadata At seconds after the start of the proxy
+type scenarioStep struct {
+	At      int              `json:"at"`
+	SpotITN *scenarioSpotITN `json:"spotITN,omitempty"`
+	// RebalanceRecommendation exposes the rebalance recommendation, noticed at the time of the step
+	RebalanceRecommendation *struct{}             `json:"rebalanceRecommendation,omitempty"`
+	ScheduledEvent          *scheduledEventConfig `json:"scheduledEvent,omitempty"`
+	TargetLifecycleState    string                `json:"targetLifecycleState,omitempty"`
+	ServerError             *scenarioServerError  `json:"serverError,omitempty"`
+	Fault                   *fault                `json:"fault,omitempty"`
+	// RemoveSpotITN and RemoveRebalanceRecommendation stop serving the events of earlier steps
+	RemoveSpotITN                 bool `json:"removeSpotITN,omitempty"`
+	RemoveRebalanceRecommendation bool `json:"removeRebalanceRecommendation,omitempty"`
+}
+
+// scenarioSpotITN exposes the spot interruption notice
+type scenarioSpotITN struct {
+	// Action is terminate, stop or hibernate, terminate by default
+	Action string `json:"action,omitempty"`
+	// Deadline is the time of the interruption in seconds after the start of the proxy
+	Deadline int `json:"deadline"`
+}
+
+// scenarioServerError fails every request with the status for Duration seconds
+type scenarioServerError struct {
+	// Status is 503 by default
+	Status   int `json:"status,omitempty"`
+	Duration int `json:"duration"`
+}
+
+// scenarioPlayback plays back the steps of a scenario deterministically, the served metadata only depends on the time
+// since the start of the proxy
+type scenarioPlayback struct {
+	steps []scenarioStep
+}
+
+// loadScenario reads and validates a scenario in a YAML or JSON file
+func loadScenario(path string) (*scenarioPlayback, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to open scenario file: %w", err)
+	}
+	defer file.Close()
+	scenario := scenarioFile{}
+	err = yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&scenario)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse scenario file %s: %w", path, err)
+	}
+	for i := range scenario.Steps {
+		step := &scenario.Steps[i]
+		if step.At < 0 {
+			return nil, fmt.Errorf("Scenario step %d has a negative time", i)
+		}
+		if step.SpotITN != nil {
+			if step.SpotITN.Action == "" {
+				step.SpotITN.Action = "terminate"
+			}
+			if !validSpotITNAction(step.SpotITN.Action) {
+				return nil, fmt.Errorf("Invalid spot ITN action %q in scenario step %d  Should be one of: terminate, stop, hibernate", step.SpotITN.Action, i)
+			}
+		}
+		if step.ScheduledEvent != nil {
+			if err := step.ScheduledEvent.setDefaults(i); err != nil {
+				return nil, fmt.Errorf("Invalid scheduled event in scenario step %d: %w", i, err)
+			}
+		}
+		if serverError := step.ServerError; serverError != nil {
+			if serverError.Status == 0 {
+				serverError.Status = http.StatusServiceUnavailable
+			}
+			if serverError.Status < 400 || serverError.Duration <= 0 {
+				return nil, fmt.Errorf("Server error in scenario step %d must have an error status and a positive duration", i)
+			}
+		}
+		if step.Fault != nil {
+			if err := step.Fault.validate(); err != nil {
+				return nil, fmt.Errorf("Invalid fault in scenario step %d: %w", i, err)
+			}
+		}
+	}
+	sort.SliceStable(scenario.Steps, func(i, j int) bool {
+		return scenario.Steps[i].At < scenario.Steps[j].At
+	})
+	return &scenarioPlayback{steps: scenario.Steps}, nil
+}
+
+// stateAt applies the steps up to the time since the start of the proxy
+func (p scenarioPlayback) stateAt(elapsed time.Duration) eventState {
+	state := eventState{targetLifecycleState: targetLifecycleState, scheduledEvents: []scheduledEvent{}}
+	for _, step := range p.steps {
+		at := seconds(step.At)
+		if at > elapsed {
+			break
+		}
+		if step.SpotITN != nil {
+			state.spotITN = &instanceAction{Action: step.SpotITN.Action, Time: imdsTime(seconds(step.SpotITN.Deadline))}
+		}
+		if step.RebalanceRecommendation != nil {
+			state.rebalanceRecommendation = &rebalanceRecommendation{NoticeTime: imdsTime(at)}
+		}
+		if step.RemoveSpotITN {
+			state.spotITN = nil
+		}
+		if step.RemoveRebalanceRecommendation {
+			state.rebalanceRecommendation = nil
+		}
+		if step.ScheduledEvent != nil {
+			state.scheduledEvents = withScheduledEvent(state.scheduledEvents, *step.ScheduledEvent)
+		}
+		if step.TargetLifecycleState != "" {
+			state.targetLifecycleState = step.TargetLifecycleState
+		}
+		if step.ServerError != nil && elapsed < at+seconds(step.ServerError.Duration) {
+			state.errorStatus = step.ServerError.Status
+		}
+		if step.Fault != nil && step.Fault.activeAt(at, elapsed) {
+			state.faults = append(state.faults, *step.Fault)
+		}
+	}
+	return state
+}
+
+func seconds(value int) time.Duration {
+	return time.Duration(value) * time.Second
+}
+
+func validSpotITNAction(action string) bool {
+	switch action {
+	case "terminate", "stop", "hibernate":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestScenarioPlayback(t *testing.T) {
+	playback, err := loadScenario("../scenarios/multi-stage.yaml")
+	h.Ok(t, err)
+
+	state := playback.stateAt(10 * time.Second)
+	h.Assert(t, state.rebalanceRecommendation == nil, "Rebalance recommendation exposed before its step")
+	h.Assert(t, state.spotITN == nil, "Spot ITN exposed before its step")
+
+	state = playback.stateAt(60 * time.Second)
+	h.Equals(t, &rebalanceRecommendation{NoticeTime: imdsTime(30 * time.Second)}, state.rebalanceRecommendation)
+	h.Assert(t, state.spotITN == nil, "Spot ITN exposed before its step")
+
+	state = playback.stateAt(95 * time.Second)
+	h.Equals(t, &instanceAction{Action: "terminate", Time: imdsTime(210 * time.Second)}, state.spotITN)
+	h.Equals(t, 0, state.errorStatus)
+
+	h.Equals(t, http.StatusServiceUnavailable, playback.stateAt(125*time.Second).errorStatus)
+	state = playback.stateAt(130 * time.Second)
+	h.Equals(t, 0, state.errorStatus)
+	h.Assert(t, state.spotITN != nil, "Spot ITN not exposed after the server errors")
+}
+
+func TestScenarioCancellation(t *testing.T) {
+	playback, err := loadScenario("../scenarios/maintenance-cancellation.yaml")
+	h.Ok(t, err)
+
+	events := playback.stateAt(30 * time.Second).scheduledEvents
+	h.Equals(t, 1, len(events))
+	h.Equals(t, "active", events[0].State)
+
+	events = playback.stateAt(90 * time.Second).scheduledEvents
+	h.Equals(t, 1, len(events))
+	h.Equals(t, "canceled", events[0].State)
+
+	events = playback.stateAt(150 * time.Second).scheduledEvents
+	h.Equals(t, 2, len(events))
+	h.Equals(t, "active", events[1].State)
+}
@@ -0,0 +1,102 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// scheduledEventConfig configures a scheduled event, an event with the same event ID as an earlier one replaces it
+type scheduledEventConfig struct {
+	EventID string `json:"eventId,omitempty"`
+	// Code is e.g. instance-reboot, system-reboot, system-maintenance, instance-retirement or instance-stop
+	Code string `json:"code"`
+	// Description is the code by default
+	Description string `json:"description,omitempty"`
+	// State is active by default
+	State string `json:"state,omitempty"`
+	// NotBefore and NotAfter are the window of the event in seconds after the start of the proxy
+	NotBefore int `json:"notBefore"`
+	NotAfter  int `json:"notAfter"`
+}
+
+// setDefaults validates the event and sets the defaults, the event ID is derived from the index of the event
+func (c *scheduledEventConfig) setDefaults(index int) error {
+	if c.Code == "" {
+		return fmt.Errorf("scheduled event must specify a code")
+	}
+	if c.NotAfter != 0 && c.NotAfter < c.NotBefore {
+		return fmt.Errorf("scheduled event %s ends before it starts", c.Code)
+	}
+	if c.EventID == "" {
+		c.EventID = fmt.Sprintf("instance-event-%017d", index)
+	}
+	if c.Description == "" {
+		c.Description = c.Code
+	}
+	if c.State == "" {
+		c.State = "active"
+	}
+	return nil
+}
+
+// configuredScheduledEvents returns the events of SCHEDULED_EVENTS, a YAML or JSON list, by default a system-reboot
+// two minutes after the interruption notice delay
+func configuredScheduledEvents() []scheduledEventConfig {
+	value := getEnv("SCHEDULED_EVENTS", "")
+	if value == "" {
+		notBefore := int((interruptionNoticeDelay + 2*time.Minute).Seconds())
+		return []scheduledEventConfig{{
+			EventID:     "instance-event-0d59937288b749b32",
+			Code:        "system-reboot",
+			Description: "scheduled reboot",
+			State:       "active",
+			NotBefore:   notBefore,
+			NotAfter:    notBefore + int((2 * time.Hour).Seconds()),
+		}}
+	}
+	var events []scheduledEventConfig
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(value), 4096).Decode(&events); err != nil {
+		log.Fatalf("Unable to parse SCHEDULED_EVENTS: %v", err)
+	}
+	for i := range events {
+		if err := events[i].setDefaults(i); err != nil {
+			log.Fatalf("Invalid scheduled event %d in SCHEDULED_EVENTS: %v", i, err)
+		}
+	}
+	return events
+}
+
+func withScheduledEvent(events []scheduledEvent, event scheduledEventConfig) []scheduledEvent {
+	scheduled := scheduledEvent{
+		NotBefore:   startTime.Add(seconds(event.NotBefore)).UTC().Format(scheduledEventTimeFormat),
+		Code:        event.Code,
+		Description: event.Description,
+		EventID:     event.EventID,
+		NotAfter:    startTime.Add(seconds(event.NotAfter)).UTC().Format(scheduledEventTimeFormat),
+		State:       event.State,
+	}
+	for i := range events {
+		if events[i].EventID == event.EventID {
+			events[i] = scheduled
+			return events
+		}
+	}
+	return append(events, scheduled)
+}
@@ -0,0 +1,37 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestConfiguredScheduledEvents(t *testing.T) {
+	os.Setenv("SCHEDULED_EVENTS", `[{"code": "instance-retirement", "notBefore": 60, "notAfter": 120}, {"code": "system-maintenance", "state": "completed"}]`)
+	defer os.Unsetenv("SCHEDULED_EVENTS")
+
+	events := []scheduledEvent{}
+	for _, event := range configuredScheduledEvents() {
+		events = withScheduledEvent(events, event)
+	}
+	h.Equals(t, 2, len(events))
+	h.Equals(t, "instance-retirement", events[0].Code)
+	h.Equals(t, "active", events[0].State)
+	h.Equals(t, "system-maintenance", events[1].Code)
+	h.Equals(t, "completed", events[1].State)
+	h.Assert(t, events[0].EventID != events[1].EventID, "Scheduled events have the same event ID")
+}
@@ -0,0 +1,84 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// throttler mimics IMDS throttling, requests exceeding the rate are rejected like IMDS does
+type throttler struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newThrottler returns a token bucket allowing rate requests per second with bursts of burst requests
+func newThrottler(rate float64, burst int) *throttler {
+	if burst <= 0 {
+		burst = int(rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return &throttler{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now(), now: time.Now}
+}
+
+// configuredThrottler returns the throttler configured with the env vars, nil if requests are not throttled
+func configuredThrottler() *throttler {
+	rate, err := strconv.ParseFloat(getEnv("THROTTLE_RATE", "0"), 64)
+	if err != nil || rate < 0 {
+		log.Fatalf("Unable to parse THROTTLE_RATE as a positive number: %v", err)
+	}
+	if rate == 0 {
+		return nil
+	}
+	return newThrottler(rate, int(getInt64Env("THROTTLE_BURST", 0)))
+}
+
+var requestThrottler = configuredThrottler()
+
+// allow takes a token from the bucket, it returns false if there is none left
+func (t *throttler) allow() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.burst {
+		t.tokens = t.burst
+	}
+	t.last = now
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// throttle rejects the request if the rate was exceeded, it returns true if the request was rejected
+func throttle(res http.ResponseWriter) bool {
+	if requestThrottler == nil || requestThrottler.allow() {
+		return false
+	}
+	res.WriteHeader(http.StatusTooManyRequests)
+	res.Write([]byte("Request limit exceeded"))
+	return true
+}
@@ -0,0 +1,37 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestThrottler(t *testing.T) {
+	now := time.Now()
+	throttler := newThrottler(2, 3)
+	throttler.now = func() time.Time { return now }
+	throttler.last = now
+
+	for i := 0; i < 3; i++ {
+		h.Assert(t, throttler.allow(), "Request within the burst throttled")
+	}
+	h.Assert(t, !throttler.allow(), "Request exceeding the burst allowed")
+
+	now = now.Add(500 * time.Millisecond)
+	h.Assert(t, throttler.allow(), "Request within the rate throttled")
+	h.Assert(t, !throttler.allow(), "Request exceeding the rate allowed")
+}
@@ -0,0 +1,127 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxTokenTTL is the longest TTL IMDS accepts for a token, 6 hours
+	maxTokenTTL = 21600
+	// maxDroppedResponseWait bounds how long a request whose response is dropped is held
+	maxDroppedResponseWait = time.Minute
+)
+
+var (
+	// enableHopLimit emulates an HttpPutResponseHopLimit of 1, token responses to requests which passed another hop
+	// are dropped
+	enableHopLimit = getBoolEnv("ENABLE_HOP_LIMIT", false)
+	// hopLimitMarkerHeader is a header marking requests which passed another hop, besides X-Forwarded-For
+	hopLimitMarkerHeader = getEnv("HOP_LIMIT_MARKER_HEADER", "")
+)
+
+// tokenStore tracks the IMDSv2 tokens issued and when they expire
+type tokenStore struct {
+	mutex   sync.Mutex
+	expires map[string]time.Time
+	now     func() time.Time
+}
+
+var tokens = &tokenStore{expires: map[string]time.Time{}, now: time.Now}
+
+// issue returns a new random token valid for ttl, so handlers caching or sharing tokens incorrectly are caught
+func (s *tokenStore) issue(ttl time.Duration) (string, error) {
+	random := make([]byte, 42)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := base64.StdEncoding.EncodeToString(random)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := s.now()
+	for issued, expires := range s.expires {
+		if !now.Before(expires) {
+			delete(s.expires, issued)
+		}
+	}
+	s.expires[token] = now.Add(ttl)
+	return token, nil
+}
+
+// valid returns whether the token was issued and did not expire yet
+func (s *tokenStore) valid(value string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	expires, ok := s.expires[value]
+	return ok && s.now().Before(expires)
+}
+
+func handleToken(res http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPut {
+		res.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if enableHopLimit && extraHop(req) {
+		log.Println("Dropping the token response to a request which passed another hop")
+		dropResponse(res, req)
+		return
+	}
+	ttl, err := strconv.Atoi(req.Header.Get(tokenTTLHeader))
+	if err != nil || ttl < 1 || ttl > maxTokenTTL {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	issued, err := tokens.issue(time.Duration(ttl) * time.Second)
+	if err != nil {
+		log.Println("Unable to issue a token: ", err)
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set(tokenTTLHeader, strconv.Itoa(ttl))
+	res.Write([]byte(issued))
+}
+
+// authorized returns whether the request may read metadata, with IMDSv2 a valid token is required, otherwise a token
+// is optional but must be valid if present, like IMDS does
+func authorized(req *http.Request) bool {
+	value := req.Header.Get(tokenHeader)
+	if value == "" {
+		return !enableIMDSv2
+	}
+	return tokens.valid(value)
+}
+
+func extraHop(req *http.Request) bool {
+	if req.Header.Get("X-Forwarded-For") != "" {
+		return true
+	}
+	return hopLimitMarkerHeader != "" && req.Header.Get(hopLimitMarkerHeader) != ""
+}
+
+// dropResponse never responds, like a response whose IP TTL expired before reaching the client, until the client
+// gives up
+func dropResponse(res http.ResponseWriter, req *http.Request) {
+	select {
+	case <-req.Context().Done():
+	case <-time.After(maxDroppedResponseWait):
+	}
+	resetConnection(res)
+}
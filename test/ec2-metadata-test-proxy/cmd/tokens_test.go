@@ -0,0 +1,61 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestTokenTTL(t *testing.T) {
+	now := time.Now()
+	tokens = &tokenStore{expires: map[string]time.Time{}, now: func() time.Time { return now }}
+
+	req := httptest.NewRequest(http.MethodPut, tokenPath, nil)
+	res := httptest.NewRecorder()
+	handleToken(res, req)
+	h.Equals(t, http.StatusBadRequest, res.Code)
+
+	req.Header.Set(tokenTTLHeader, "10")
+	res = httptest.NewRecorder()
+	handleToken(res, req)
+	h.Equals(t, http.StatusOK, res.Code)
+	h.Equals(t, "10", res.Header().Get(tokenTTLHeader))
+	issued := res.Body.String()
+	h.Assert(t, tokens.valid(issued), "Issued token is not valid")
+	h.Assert(t, !tokens.valid("other"), "Token which was not issued is valid")
+
+	res = httptest.NewRecorder()
+	handleToken(res, req)
+	h.Assert(t, res.Body.String() != issued, "The same token was issued twice")
+	h.Assert(t, tokens.valid(issued), "Token is not valid anymore after another was issued")
+
+	now = now.Add(11 * time.Second)
+	h.Assert(t, !tokens.valid(issued), "Expired token is valid")
+}
+
+func TestIMDSv2RequiredOnEveryPath(t *testing.T) {
+	enableIMDSv2 = true
+	defer func() { enableIMDSv2 = false }()
+
+	for _, path := range []string{instanceIDPath, instanceTypePath, localHostnamePath, localIPPath, instanceActionPath} {
+		res := httptest.NewRecorder()
+		handleRequest(res, httptest.NewRequest(http.MethodGet, path, nil))
+		h.Equals(t, http.StatusUnauthorized, res.Code)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// the kinds of synthetic events, named after the monitor which handles them
+const (
+	spotITNKind                 = "spot-itn"
+	rebalanceRecommendationKind = "rebalance-recommendation"
+	ec2StateChangeKind          = "ec2-state-change"
+	asgLifecycleKind            = "asg-lifecycle"
+)
+
+var eventKinds = []string{spotITNKind, rebalanceRecommendationKind, ec2StateChangeKind, asgLifecycleKind}
+
+// eventBridgeEvent is the envelope of the EventBridge events NTH consumes from the queue
+type eventBridgeEvent struct {
+	Version    string      `json:"version"`
+	ID         string      `json:"id"`
+	DetailType string      `json:"detail-type"`
+	Source     string      `json:"source"`
+	Account    string      `json:"account"`
+	Time       string      `json:"time"`
+	Region     string      `json:"region"`
+	Resources  []string    `json:"resources"`
+	Detail     interface{} `json:"detail"`
+}
+
+// syntheticEvent returns the body of a message with an event of the kind for the instance
+func syntheticEvent(kind string, id string, instanceID string, region string, now time.Time) (string, error) {
+	event := eventBridgeEvent{
+		Version:   "0",
+		ID:        id,
+		Source:    "aws.ec2",
+		Account:   account,
+		Time:      now.UTC().Format(time.RFC3339),
+		Region:    region,
+		Resources: []string{fmt.Sprintf("arn:aws:ec2:%s:%s:instance/%s", region, account, instanceID)},
+	}
+	switch kind {
+	case spotITNKind:
+		event.DetailType = "EC2 Spot Instance Interruption Warning"
+		event.Detail = map[string]string{"instance-id": instanceID, "instance-action": "terminate"}
+	case rebalanceRecommendationKind:
+		event.DetailType = "EC2 Instance Rebalance Recommendation"
+		event.Detail = map[string]string{"instance-id": instanceID}
+	case ec2StateChangeKind:
+		event.DetailType = "EC2 Instance State-change Notification"
+		event.Detail = map[string]string{"instance-id": instanceID, "state": "stopping"}
+	case asgLifecycleKind:
+		event.DetailType = "EC2 Instance-terminate Lifecycle Action"
+		event.Source = "aws.autoscaling"
+		event.Resources = []string{fmt.Sprintf("arn:aws:autoscaling:%s:%s:autoScalingGroup:%s:autoScalingGroupName/%s", region, account, id, asgName)}
+		event.Detail = map[string]string{
+			"LifecycleActionToken": id,
+			"AutoScalingGroupName": asgName,
+			"LifecycleHookName":    lifecycleHookName,
+			"EC2InstanceId":        instanceID,
+			"LifecycleTransition":  "autoscaling:EC2_INSTANCE_TERMINATING",
+		}
+	default:
+		return "", fmt.Errorf("unknown event kind %q, should be one of: %s", kind, strings.Join(eventKinds, ", "))
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// sentID returns the ID of the message NTH handled from the ID of the interruption event it reports. NTH derives the
+// event ID from a prefix of the kind and the hex encoded ID of the EventBridge event, e.g. spot-itn-event-6c6f6164.
+func sentID(eventID string) (string, bool) {
+	encoded := eventID[strings.LastIndex(eventID, "-")+1:]
+	id, err := hex.DecodeString(encoded)
+	if err != nil || len(id) == 0 {
+		return "", false
+	}
+	return string(id), true
+}
@@ -0,0 +1,69 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-node-termination-handler/pkg/monitor/sqsevent"
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestSyntheticEvents(t *testing.T) {
+	for _, kind := range eventKinds {
+		body, err := syntheticEvent(kind, "abcd-1", "i-0123456789abcdef0", "us-east-1", time.Now())
+		h.Ok(t, err)
+		event := sqsevent.EventBridgeEvent{}
+		h.Ok(t, json.Unmarshal([]byte(body), &event))
+		h.Equals(t, "abcd-1", event.ID)
+		h.Assert(t, event.DetailType != "", "No detail type for %s", kind)
+		if kind == asgLifecycleKind {
+			detail := sqsevent.LifecycleDetail{}
+			h.Ok(t, json.Unmarshal(event.Detail, &detail))
+			h.Equals(t, "i-0123456789abcdef0", detail.EC2InstanceID)
+			continue
+		}
+		detail := map[string]string{}
+		h.Ok(t, json.Unmarshal(event.Detail, &detail))
+		h.Equals(t, "i-0123456789abcdef0", detail["instance-id"])
+	}
+}
+
+func TestSyntheticEventUnknownKind(t *testing.T) {
+	_, err := syntheticEvent("unknown", "abcd-1", "i-0123456789abcdef0", "us-east-1", time.Now())
+	h.Nok(t, err)
+}
+
+func TestSentID(t *testing.T) {
+	id, ok := sentID(fmt.Sprintf("spot-itn-event-%x", "abcd-1"))
+	h.Assert(t, ok, "Expected the ID to be decoded")
+	h.Equals(t, "abcd-1", id)
+
+	_, ok = sentID("spot-itn-event-xyz")
+	h.Assert(t, !ok, "Expected an ID which is not hex encoded not to be decoded")
+}
+
+func TestGenerateMessagesCyclesKindsAndInstances(t *testing.T) {
+	messages, err := generateMessages("abcd", 4, []string{spotITNKind, rebalanceRecommendationKind}, []string{"i-1", "i-2", "i-3"}, time.Now())
+	h.Ok(t, err)
+	h.Equals(t, 4, len(messages))
+	h.Equals(t, "abcd-3", messages[3].id)
+	event := sqsevent.EventBridgeEvent{}
+	h.Ok(t, json.Unmarshal([]byte(messages[3].body), &event))
+	h.Equals(t, "EC2 Instance Rebalance Recommendation", event.DetailType)
+	h.Equals(t, []string{"arn:aws:ec2:us-east-1:123456789012:instance/i-1"}, event.Resources)
+}
@@ -0,0 +1,164 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// results tracks when each message was sent and when NTH reported handling it
+type results struct {
+	mutex      sync.Mutex
+	sent       map[string]time.Time
+	handled    map[string]time.Duration
+	sendErrors int
+	duplicates int
+	unknown    int
+	firstSent  time.Time
+	lastHandle time.Time
+}
+
+func newResults() *results {
+	return &results{sent: map[string]time.Time{}, handled: map[string]time.Duration{}}
+}
+
+// markSent records the messages the queue accepted
+func (r *results) markSent(ids []string, at time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.firstSent.IsZero() || at.Before(r.firstSent) {
+		r.firstSent = at
+	}
+	for _, id := range ids {
+		r.sent[id] = at
+	}
+}
+
+// markSendErrors counts the messages the queue did not accept, which are not expected to be handled
+func (r *results) markSendErrors(count int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sendErrors += count
+}
+
+// markHandled records NTH reporting the message was handled. Messages of earlier runs are counted as unknown.
+func (r *results) markHandled(id string, at time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	sentAt, ok := r.sent[id]
+	if !ok {
+		r.unknown++
+		return
+	}
+	if _, ok := r.handled[id]; ok {
+		r.duplicates++
+		return
+	}
+	r.handled[id] = at.Sub(sentAt)
+	r.lastHandle = at
+}
+
+// pending returns the number of sent messages NTH did not report handling yet
+func (r *results) pending() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.sent) - len(r.handled)
+}
+
+// report is the outcome of a load test
+type report struct {
+	Sent       int     `json:"sent"`
+	SendErrors int     `json:"sendErrors"`
+	Handled    int     `json:"handled"`
+	Dropped    int     `json:"dropped"`
+	Duplicates int     `json:"duplicates"`
+	Unknown    int     `json:"unknown"`
+	Throughput float64 `json:"throughputPerSecond"`
+	// the latencies from sending a message to NTH reporting it was handled, in seconds
+	LatencyMin float64 `json:"latencyMinSeconds"`
+	LatencyP50 float64 `json:"latencyP50Seconds"`
+	LatencyP90 float64 `json:"latencyP90Seconds"`
+	LatencyP99 float64 `json:"latencyP99Seconds"`
+	LatencyMax float64 `json:"latencyMaxSeconds"`
+}
+
+func (r *results) report() report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	latencies := make([]time.Duration, 0, len(r.handled))
+	for _, latency := range r.handled {
+		latencies = append(latencies, latency)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rep := report{
+		Sent:       len(r.sent),
+		SendErrors: r.sendErrors,
+		Handled:    len(r.handled),
+		Dropped:    len(r.sent) - len(r.handled),
+		Duplicates: r.duplicates,
+		Unknown:    r.unknown,
+	}
+	if len(latencies) == 0 {
+		return rep
+	}
+	if elapsed := r.lastHandle.Sub(r.firstSent); elapsed > 0 {
+		rep.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	rep.LatencyMin = latencies[0].Seconds()
+	rep.LatencyP50 = percentile(latencies, 50).Seconds()
+	rep.LatencyP90 = percentile(latencies, 90).Seconds()
+	rep.LatencyP99 = percentile(latencies, 99).Seconds()
+	rep.LatencyMax = latencies[len(latencies)-1].Seconds()
+	return rep
+}
+
+// percentile returns the nearest-rank percentile of the sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (r report) print(out io.Writer) {
+	fmt.Fprintf(out, "Sent: %d (%d failed to send)\n", r.Sent, r.SendErrors)
+	fmt.Fprintf(out, "Handled: %d, dropped: %d, duplicates: %d, unknown: %d\n", r.Handled, r.Dropped, r.Duplicates, r.Unknown)
+	fmt.Fprintf(out, "Throughput: %.2f messages/s\n", r.Throughput)
+	fmt.Fprintf(out, "Latency: min %.3fs, p50 %.3fs, p90 %.3fs, p99 %.3fs, max %.3fs\n", r.LatencyMin, r.LatencyP50, r.LatencyP90, r.LatencyP99, r.LatencyMax)
+}
+
+func (r report) writeJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// violations returns why the report fails the thresholds, if it does
+func (r report) violations(maxDropped int, maxP99 time.Duration) []string {
+	var violations []string
+	if r.Dropped > maxDropped {
+		violations = append(violations, fmt.Sprintf("%d messages were dropped, at most %d are allowed", r.Dropped, maxDropped))
+	}
+	if maxP99 > 0 && r.LatencyP99 > maxP99.Seconds() {
+		violations = append(violations, fmt.Sprintf("the p99 latency %.3fs exceeds %s", r.LatencyP99, maxP99))
+	}
+	return violations
+}
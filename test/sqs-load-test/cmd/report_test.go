@@ -0,0 +1,58 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestReport(t *testing.T) {
+	results := newResults()
+	start := time.Now()
+	var ids []string
+	for i := 0; i < 100; i++ {
+		ids = append(ids, fmt.Sprintf("abcd-%d", i))
+	}
+	results.markSent(ids, start)
+	results.markSendErrors(2)
+	for i, id := range ids[:99] {
+		results.markHandled(id, start.Add(time.Duration(i+1)*time.Second))
+	}
+	results.markHandled(ids[0], start.Add(time.Hour))
+	results.markHandled("earlier-run", start)
+
+	report := results.report()
+	h.Equals(t, 100, report.Sent)
+	h.Equals(t, 2, report.SendErrors)
+	h.Equals(t, 99, report.Handled)
+	h.Equals(t, 1, report.Dropped)
+	h.Equals(t, 1, report.Duplicates)
+	h.Equals(t, 1, report.Unknown)
+	h.Equals(t, 1.0, report.LatencyMin)
+	h.Equals(t, 50.0, report.LatencyP50)
+	h.Equals(t, 90.0, report.LatencyP90)
+	h.Equals(t, 99.0, report.LatencyP99)
+	h.Equals(t, 99.0, report.LatencyMax)
+	h.Equals(t, 1.0, report.Throughput)
+}
+
+func TestReportViolations(t *testing.T) {
+	rep := report{Dropped: 1, LatencyP99: 3}
+	h.Equals(t, 2, len(rep.violations(0, 2*time.Second)))
+	h.Equals(t, 0, len(rep.violations(1, 0)))
+}
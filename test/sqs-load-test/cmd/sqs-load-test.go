@@ -0,0 +1,272 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// the account, ASG and lifecycle hook of the synthetic events
+	account           = "123456789012"
+	asgName           = "nth-load-test"
+	lifecycleHookName = "nth-load-test"
+
+	// maxBatchSize is the most messages SQS accepts in a batch
+	maxBatchSize = 10
+	// progressInterval is how often the progress is logged while waiting for NTH
+	progressInterval = 10 * time.Second
+)
+
+// Get env var or default
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func getIntEnv(key string, fallback int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(fallback)))
+	if err != nil {
+		log.Fatalf("Unable to parse %s as an int: %v", key, err)
+	}
+	return value
+}
+
+// getDurationEnv returns the env var in seconds as a duration
+func getDurationEnv(key string, fallback int) time.Duration {
+	return time.Duration(getIntEnv(key, fallback)) * time.Second
+}
+
+// getListEnv returns the comma separated env var as a list
+func getListEnv(key string, fallback string) []string {
+	var list []string
+	for _, value := range strings.Split(getEnv(key, fallback), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// Get the port to listen on
+func getListenAddress() string {
+	port := getEnv("PORT", "1339")
+	return ":" + port
+}
+
+var (
+	queueURL      = getEnv("QUEUE_URL", "")
+	region        = getEnv("AWS_REGION", "us-east-1")
+	endpoint      = getEnv("AWS_ENDPOINT", "")
+	instanceIDs   = getListEnv("INSTANCE_IDS", "")
+	kinds         = getListEnv("EVENT_KINDS", strings.Join(eventKinds, ","))
+	messageCount  = getIntEnv("MESSAGE_COUNT", 1000)
+	sendRate      = getIntEnv("SEND_RATE", 100)
+	senders       = getIntEnv("SENDERS", 4)
+	resultTimeout = getDurationEnv("RESULT_TIMEOUT", 300)
+	maxDropped    = getIntEnv("MAX_DROPPED", 0)
+	maxP99Latency = getDurationEnv("MAX_P99_LATENCY", 0)
+	reportFile    = getEnv("REPORT_FILE", "")
+)
+
+// message is a synthetic message to send to the queue
+type message struct {
+	id   string
+	body string
+}
+
+// generateMessages returns the messages of the load test, cycling through the kinds and the instances. The IDs are
+// prefixed with the ID of the run, so the webhooks of messages left in the queue by earlier runs are told apart.
+func generateMessages(runID string, count int, kinds []string, instanceIDs []string, now time.Time) ([]message, error) {
+	messages := make([]message, 0, count)
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("%s-%d", runID, i)
+		body, err := syntheticEvent(kinds[i%len(kinds)], id, instanceIDs[i%len(instanceIDs)], region, now)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message{id: id, body: body})
+	}
+	return messages, nil
+}
+
+// batches groups the messages in batches for SendMessageBatch, at the rate of messages per second if it is positive
+func batches(messages []message, rate int) <-chan []message {
+	out := make(chan []message)
+	go func() {
+		defer close(out)
+		var limiter flowcontrol.RateLimiter
+		if rate > 0 {
+			limiter = flowcontrol.NewTokenBucketRateLimiter(float32(rate), maxBatchSize)
+		}
+		batch := make([]message, 0, maxBatchSize)
+		for _, msg := range messages {
+			if limiter != nil {
+				limiter.Accept()
+			}
+			batch = append(batch, msg)
+			if len(batch) == maxBatchSize {
+				out <- batch
+				batch = make([]message, 0, maxBatchSize)
+			}
+		}
+		if len(batch) > 0 {
+			out <- batch
+		}
+	}()
+	return out
+}
+
+// send sends the batches to the queue, recording when each message was accepted
+func send(client *sqs.Client, batches <-chan []message, results *results) {
+	for batch := range batches {
+		entries := make([]types.SendMessageBatchRequestEntry, 0, len(batch))
+		for i, msg := range batch {
+			entries = append(entries, types.SendMessageBatchRequestEntry{Id: aws.String(strconv.Itoa(i)), MessageBody: aws.String(msg.body)})
+		}
+		sentAt := time.Now()
+		output, err := client.SendMessageBatch(context.TODO(), &sqs.SendMessageBatchInput{QueueUrl: aws.String(queueURL), Entries: entries})
+		if err != nil {
+			log.Printf("Unable to send a batch of %d messages: %v", len(batch), err)
+			results.markSendErrors(len(batch))
+			continue
+		}
+		ids := make([]string, 0, len(output.Successful))
+		for _, entry := range output.Successful {
+			index, err := strconv.Atoi(aws.ToString(entry.Id))
+			if err != nil || index >= len(batch) {
+				continue
+			}
+			ids = append(ids, batch[index].id)
+		}
+		results.markSent(ids, sentAt)
+		for _, entry := range output.Failed {
+			log.Printf("Unable to send a message: %s", aws.ToString(entry.Message))
+		}
+		results.markSendErrors(len(output.Failed))
+	}
+}
+
+// waitForResults waits until NTH reported handling every sent message or the timeout
+func waitForResults(results *results, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	lastProgress := time.Now()
+	for pending := results.pending(); pending > 0; pending = results.pending() {
+		if time.Now().After(deadline) {
+			log.Printf("Timed out with %d messages not handled", pending)
+			return
+		}
+		if time.Since(lastProgress) >= progressInterval {
+			log.Printf("Waiting for %d messages to be handled", pending)
+			lastProgress = time.Now()
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func newRunID() string {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		log.Fatal(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+func newSQSClient() *sqs.Client {
+	options := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(region)}
+	if endpoint != "" {
+		resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
+			return aws.Endpoint{URL: endpoint, SigningRegion: region}, nil
+		})
+		options = append(options, awsconfig.WithEndpointResolverWithOptions(resolver))
+	}
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.TODO(), options...)
+	if err != nil {
+		log.Fatalf("Unable to load the AWS config: %v", err)
+	}
+	return sqs.NewFromConfig(awsConfig)
+}
+
+func main() {
+	if queueURL == "" || len(instanceIDs) == 0 {
+		log.Fatal("QUEUE_URL and INSTANCE_IDS are required")
+	}
+	if messageCount <= 0 || senders <= 0 {
+		log.Fatal("MESSAGE_COUNT and SENDERS must be positive")
+	}
+	runID := newRunID()
+	messages, err := generateMessages(runID, messageCount, kinds, instanceIDs, time.Now())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results := newResults()
+	http.HandleFunc("/", handleWebhook(results))
+	go func() {
+		log.Fatal(http.ListenAndServe(getListenAddress(), nil))
+	}()
+	log.Printf("Receiving NTH's webhooks on port %s", getListenAddress())
+
+	client := newSQSClient()
+	log.Printf("Sending %d messages of run %s to %s", len(messages), runID, queueURL)
+	toSend := batches(messages, sendRate)
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send(client, toSend, results)
+		}()
+	}
+	wg.Wait()
+	log.Printf("Sent the messages, waiting up to %s for NTH to handle them", resultTimeout)
+	waitForResults(results, resultTimeout)
+
+	report := results.report()
+	report.print(os.Stdout)
+	if reportFile != "" {
+		file, err := os.Create(reportFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := report.writeJSON(file); err != nil {
+			log.Fatal(err)
+		}
+		file.Close()
+	}
+	if violations := report.violations(maxDropped, maxP99Latency); len(violations) > 0 {
+		for _, violation := range violations {
+			log.Println(violation)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// maxWebhookBytes bounds the webhook bodies read, NTH's webhooks are a few hundred bytes
+const maxWebhookBytes = 1 << 20
+
+// eventIDPattern matches the event ID in the default webhook template, "EventID: <id>", and in JSON templates,
+// "EventID": "<id>"
+var eventIDPattern = regexp.MustCompile(`EventID"?\s*:\s*"?([A-Za-z0-9-]+)`)
+
+// handleWebhook records the events NTH reports through its webhook as handled
+func handleWebhook(results *results) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		receivedAt := time.Now()
+		if req.Method != http.MethodPost {
+			res.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookBytes))
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		matches := eventIDPattern.FindAllSubmatch(body, -1)
+		if len(matches) == 0 {
+			log.Printf("No event ID in the webhook: %s", body)
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, match := range matches {
+			// events not sent by the harness are counted as unknown
+			id, ok := sentID(string(match[1]))
+			if !ok {
+				id = string(match[1])
+			}
+			results.markHandled(id, receivedAt)
+		}
+		res.WriteHeader(http.StatusOK)
+	}
+}
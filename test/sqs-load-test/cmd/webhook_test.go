@@ -0,0 +1,49 @@
+// Copyright 2016-2017 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//     http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	h "github.com/aws/aws-node-termination-handler/pkg/test"
+)
+
+func TestHandleWebhook(t *testing.T) {
+	results := newResults()
+	results.markSent([]string{"abcd-0", "abcd-1"}, time.Now())
+	handler := handleWebhook(results)
+
+	// the default webhook template
+	res := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"text":"[NTH][Instance Interruption] EventID: spot-itn-event-%x - Kind: SPOT_ITN"}`, "abcd-0")
+	handler(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	h.Equals(t, http.StatusOK, res.Code)
+
+	// a JSON template
+	res = httptest.NewRecorder()
+	body = fmt.Sprintf(`{"EventID": "asg-lifecycle-term-%x"}`, "abcd-1")
+	handler(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	h.Equals(t, http.StatusOK, res.Code)
+
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"text":"no event"}`)))
+	h.Equals(t, http.StatusBadRequest, res.Code)
+
+	h.Equals(t, 0, results.pending())
+}